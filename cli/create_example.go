@@ -0,0 +1,275 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/monadicstack/frodo/parser"
+	"github.com/spf13/cobra"
+)
+
+// CreateExampleRequest contains the inputs from our "frodo example" CLI command.
+type CreateExampleRequest struct {
+	// Services is the value of the --services argument; how many services make up the system.
+	Services int
+	// Directory is the value of the --dir argument which defines where we'll write the system's code.
+	Directory string
+	// Force is the status of the --force flag to overwrite files if they already exist.
+	Force bool
+	// Port is the first HTTP port that the services listen on. Each service gets the next one.
+	Port int
+}
+
+// CreateExample is the scaffolding command that generates a complete, runnable system of services that call
+// each other. It's living documentation for how all of the pieces fit together as well as a quick way to
+// smoke test the toolchain.
+type CreateExample struct{}
+
+// Command creates the Cobra struct describing this CLI command and its options.
+func (c CreateExample) Command() *cobra.Command {
+	request := &CreateExampleRequest{}
+	cmd := &cobra.Command{
+		Use:   "example [flags]",
+		Short: "Creates a runnable multi-service system showing how all of the frodo pieces fit together.",
+		Long:  "This creates a package for each service in the system where each service calls the next one using its frodo RPC client. You also get a main() for each service, one that composes every service into a single process, a Dockerfile/docker-compose.yml to run them separately, and tests that verify the whole system works end-to-end.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			crapPants(c.Exec(request))
+		},
+	}
+	cmd.Flags().IntVar(&request.Services, "services", 3, fmt.Sprintf("How many services make up the system (1-%d)", len(exampleServiceNames)))
+	cmd.Flags().StringVar(&request.Directory, "dir", "example", "Path to the directory where we'll write the system's code")
+	cmd.Flags().BoolVar(&request.Force, "force", false, "Overwrite source code files if they exist.")
+	cmd.Flags().IntVar(&request.Port, "port", 9001, "The HTTP port for the first service. Each service after that uses the next port.")
+	return cmd
+}
+
+// exampleServiceNames are the (singular) names of the services we can include in the example system
+// along w/ the packages they live in.
+var exampleServiceNames = []struct {
+	ShortName string
+	Package   string
+}{
+	{ShortName: "Account", Package: "accounts"},
+	{ShortName: "Order", Package: "orders"},
+	{ShortName: "Payment", Package: "payments"},
+	{ShortName: "Shipment", Package: "shipments"},
+	{ShortName: "Inventory", Package: "inventory"},
+	{ShortName: "Review", Package: "reviews"},
+	{ShortName: "Price", Package: "prices"},
+	{ShortName: "Search", Package: "search"},
+	{ShortName: "Notification", Package: "notifications"},
+}
+
+// Exec writes all of the code for the example system and runs each service through 'frodo' to generate
+// their clients and gateways.
+func (c CreateExample) Exec(request *CreateExampleRequest) error {
+	if request.Services < 1 || request.Services > len(exampleServiceNames) {
+		return fmt.Errorf("example: --services must be between 1 and %d", len(exampleServiceNames))
+	}
+	if request.Directory == "" {
+		request.Directory = "example"
+	}
+	if request.Port == 0 {
+		request.Port = 9001
+	}
+
+	moduleDir, err := findModuleDir(request.Directory)
+	if err != nil {
+		return err
+	}
+	relativeDir, err := filepath.Rel(moduleDir, mustAbs(request.Directory))
+	if err != nil {
+		return fmt.Errorf("example: %w", err)
+	}
+
+	ctx := exampleContext{
+		Request:      request,
+		Directory:    request.Directory,
+		ComposedPort: request.Port - 1,
+		Package:      toPackageName(filepath.Base(mustAbs(request.Directory))),
+		ModulePath:   filepath.ToSlash(relativeDir),
+		DockerPath:   filepath.ToSlash(mustRel(request.Directory, moduleDir)),
+	}
+	for i := 0; i < request.Services; i++ {
+		shortName := exampleServiceNames[i].ShortName
+		service := &exampleService{
+			ShortName:   shortName,
+			Name:        shortName + "Service",
+			HandlerName: shortName + "ServiceHandler",
+			Package:     exampleServiceNames[i].Package,
+			FieldName:   strings.Title(exampleServiceNames[i].Package),
+			Port:        request.Port + i,
+		}
+		service.EnvAddress = strings.ToUpper(shortName) + "_SERVICE_ADDRESS"
+		service.Paths.Directory = filepath.Join(ctx.Directory, service.Package)
+		service.Paths.Service = filepath.Join(service.Paths.Directory, strings.ToLower(shortName)+"_service.go")
+		service.Paths.Handler = filepath.Join(service.Paths.Directory, strings.ToLower(shortName)+"_handler.go")
+		service.Paths.Main = filepath.Join(service.Paths.Directory, "cmd", "main.go")
+		ctx.Services = append(ctx.Services, service)
+	}
+
+	// Each service calls the next one in the chain, so the last service is the only one w/o a dependency.
+	for i := 0; i < len(ctx.Services)-1; i++ {
+		ctx.Services[i].Dependency = ctx.Services[i+1]
+	}
+
+	// Write the declarations first so that we can have the parser tell us where they live in the module.
+	for _, service := range ctx.Services {
+		if err := os.MkdirAll(filepath.Join(service.Paths.Directory, "cmd"), 0777); err != nil {
+			return err
+		}
+		if err := scaffoldTemplate(service, request.Force, "templates/example/service.go.tmpl", service.Paths.Service); err != nil {
+			return err
+		}
+	}
+	info, err := parser.ParseFile(ctx.Services[0].Paths.Service)
+	if err != nil {
+		return err
+	}
+	ctx.Import = path.Dir(info.InputPackage.Import)
+	for _, service := range ctx.Services {
+		service.Import = ctx.Import + "/" + service.Package
+	}
+
+	for _, service := range ctx.Services {
+		serviceCtx := exampleServiceContext{exampleContext: ctx, Service: service}
+		if err := scaffoldTemplate(serviceCtx, request.Force, "templates/example/service_handler.go.tmpl", service.Paths.Handler); err != nil {
+			return err
+		}
+		if err := scaffoldTemplate(serviceCtx, request.Force, "templates/example/main.go.tmpl", service.Paths.Main); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join(ctx.Directory, "cmd"), 0777); err != nil {
+		return err
+	}
+	files := map[string]string{
+		"templates/example/system.go.tmpl":          filepath.Join(ctx.Directory, "system.go"),
+		"templates/example/system_test.go.tmpl":     filepath.Join(ctx.Directory, "system_test.go"),
+		"templates/example/compose_main.go.tmpl":    filepath.Join(ctx.Directory, "cmd", "main.go"),
+		"templates/example/Dockerfile.tmpl":         filepath.Join(ctx.Directory, "Dockerfile"),
+		"templates/example/docker-compose.yml.tmpl": filepath.Join(ctx.Directory, "docker-compose.yml"),
+		"templates/example/makefile.tmpl":           filepath.Join(ctx.Directory, "makefile"),
+		"templates/example/README.md.tmpl":          filepath.Join(ctx.Directory, "README.md"),
+	}
+	for templatePath, outputPath := range files {
+		if err := scaffoldTemplate(ctx, request.Force, templatePath, outputPath); err != nil {
+			return err
+		}
+	}
+
+	// Run the declarations through 'frodo' to create the gateway and client generated artifacts.
+	for _, service := range ctx.Services {
+		generateClientRequest := &GenerateClientRequest{InputFileName: service.Paths.Service}
+		if err := (GenerateClient{}).Exec(generateClientRequest); err != nil {
+			return err
+		}
+		generateGatewayRequest := &GenerateGatewayRequest{InputFileName: service.Paths.Service}
+		if err := (GenerateGateway{}).Exec(generateGatewayRequest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findModuleDir walks up from the given directory until it finds the one w/ the "go.mod" file.
+func findModuleDir(dir string) (string, error) {
+	dir = mustAbs(dir)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("example: %w", parser.ErrMissingGoMod)
+		}
+		dir = parent
+	}
+}
+
+func mustAbs(dir string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	return absDir
+}
+
+func mustRel(from string, to string) string {
+	relDir, err := filepath.Rel(mustAbs(from), to)
+	if err != nil {
+		return to
+	}
+	return relDir
+}
+
+var nonPackageChars = regexp.MustCompile("[^a-z0-9]")
+
+// toPackageName turns a directory name like "My-Example" into a valid package name like "myexample".
+func toPackageName(name string) string {
+	name = nonPackageChars.ReplaceAllString(strings.ToLower(name), "")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return "system" + name
+	}
+	return name
+}
+
+type exampleContext struct {
+	// Request are the raw incoming params to the scaffolding operation we're processing.
+	Request *CreateExampleRequest
+	// Directory is where we're writing all of the code for the system.
+	Directory string
+	// Package is the name of the package for the system-wide code in the root directory (e.g. "example").
+	Package string
+	// ComposedPort is the HTTP port used when running every service in a single process.
+	ComposedPort int
+	// Import is the full import path for the root directory within the module.
+	Import string
+	// ModulePath is the path to the root directory relative to the module's "go.mod" file.
+	ModulePath string
+	// DockerPath is the path to the module's root directory relative to the system's root directory.
+	DockerPath string
+	// Services are all of the services in the system. The first one calls the second, the second one
+	// calls the third, and so on.
+	Services []*exampleService
+}
+
+type exampleService struct {
+	// ShortName is the name of the service w/o the "Service" suffix (e.g. "Order").
+	ShortName string
+	// Name is the name of the service interface (e.g. "OrderService").
+	Name string
+	// HandlerName is the name of the struct for the real implementation (e.g. "OrderServiceHandler").
+	HandlerName string
+	// Package is the name of the package that the service belongs to (e.g. "orders").
+	Package string
+	// Import is the full import path for the service's package within the module.
+	Import string
+	// FieldName is the name of the field on other handlers that reference this service (e.g. "Orders").
+	FieldName string
+	// Port is the HTTP port that main() listens on when running the service in its own process.
+	Port int
+	// EnvAddress is the environment variable that tells other services where to find this one.
+	EnvAddress string
+	// Dependency is the (optional) service that this one calls.
+	Dependency *exampleService
+	// Paths contains the directory/filename paths to the various assets we're creating.
+	Paths struct {
+		Directory string
+		Service   string
+		Handler   string
+		Main      string
+	}
+}
+
+type exampleServiceContext struct {
+	exampleContext
+	// Service is the specific service whose files we're currently generating.
+	Service *exampleService
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"go/format"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -23,6 +24,10 @@ type CreateServiceRequest struct {
 	Force bool
 	// Port defines which HTTP port you want the RPC/HTTP gateway to run on by default.
 	Port int
+	// WithDB is the status of the --with-db flag to include a data store interface w/ in-memory and SQL implementations.
+	WithDB bool
+	// WithAuth is the status of the --with-auth flag to require credentials and include token-checking middleware.
+	WithAuth bool
 }
 
 // CreateService is the scaffolding command that creates a new service directory and a minimal
@@ -35,7 +40,7 @@ func (c CreateService) Command() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "create [flags] SERVICE_NAME",
 		Short: "Creates a new service in your project with all of the code required to run.",
-		Long:  "This creates a new package in your project for the service. It creates 4 different Go files: your service declaration (interface/structs), your service handler/implementation, the frodo RPC client, and the frodo RPC/API gateway. This also creates a makefile with convenience targets that regenerate your frodo RPC artifacts, build, test, and run your new service.",
+		Long:  "This creates a new package in your project for the service. It creates your service declaration (interface/structs), your service handler/implementation, example middleware, a test suite, the frodo RPC client, the frodo RPC/API gateway, and a main() that runs it all w/ graceful shutdown. This also creates a Dockerfile and a makefile with convenience targets that regenerate your frodo RPC artifacts, build, test, run, and containerize your new service.",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			request.ServiceName = args[0]
@@ -45,6 +50,8 @@ func (c CreateService) Command() *cobra.Command {
 	cmd.Flags().StringVar(&request.Directory, "dir", "", "Path to the directory where we'll write the Go file (defaults to new directory named after the service)")
 	cmd.Flags().BoolVar(&request.Force, "force", false, "Overwrite declaration/handler source code files if they exist.")
 	cmd.Flags().IntVar(&request.Port, "port", 0, "When generating main(), what port will the RPC/API gateway run on? (default = random port between 9000-9999)")
+	cmd.Flags().BoolVar(&request.WithDB, "with-db", false, "Include a data store interface (w/ in-memory and database/sql implementations) that the handler uses.")
+	cmd.Flags().BoolVar(&request.WithAuth, "with-auth", false, "Require an 'Authorization' header on every call and include middleware that validates it.")
 	return cmd
 }
 
@@ -85,6 +92,10 @@ func (c CreateService) Exec(request *CreateServiceRequest) error {
 	ctx.Paths.Handler = filepath.Join(ctx.Directory, ctx.ShortNameLower+"_handler.go")
 	ctx.Paths.Makefile = filepath.Join(ctx.Directory, "makefile")
 	ctx.Paths.Main = filepath.Join(ctx.Directory, "cmd", "main.go")
+	ctx.Paths.Middleware = filepath.Join(ctx.Directory, "middleware.go")
+	ctx.Paths.Store = filepath.Join(ctx.Directory, ctx.ShortNameLower+"_store.go")
+	ctx.Paths.Test = filepath.Join(ctx.Directory, ctx.ShortNameLower+"_service_test.go")
+	ctx.Paths.Dockerfile = filepath.Join(ctx.Directory, "Dockerfile")
 
 	// Create the service declaration and handler stubs.
 	if err := os.MkdirAll(ctx.Directory, 0777); err != nil {
@@ -94,22 +105,39 @@ func (c CreateService) Exec(request *CreateServiceRequest) error {
 		return err
 	}
 
-	if err := scaffoldTemplate(ctx, "templates/create/service.go.tmpl", ctx.Paths.Service); err != nil {
+	if err := scaffoldTemplate(ctx, request.Force, "templates/create/service.go.tmpl", ctx.Paths.Service); err != nil {
 		return err
 	}
-	if err := scaffoldTemplate(ctx, "templates/create/service_handler.go.tmpl", ctx.Paths.Handler); err != nil {
+	if err := scaffoldTemplate(ctx, request.Force, "templates/create/service_handler.go.tmpl", ctx.Paths.Handler); err != nil {
 		return err
 	}
-	if err := scaffoldTemplate(ctx, "templates/create/makefile.tmpl", ctx.Paths.Makefile); err != nil {
+	if err := scaffoldTemplate(ctx, request.Force, "templates/create/middleware.go.tmpl", ctx.Paths.Middleware); err != nil {
 		return err
 	}
+	if request.WithDB {
+		if err := scaffoldTemplate(ctx, request.Force, "templates/create/service_store.go.tmpl", ctx.Paths.Store); err != nil {
+			return err
+		}
+	}
 
 	info, err := parser.ParseFile(ctx.Paths.Service)
 	if err != nil {
 		return err
 	}
 	ctx.PackageImport = info.InputPackage.Import
-	if err := scaffoldTemplate(ctx, "templates/create/main.go.tmpl", ctx.Paths.Main); err != nil {
+	ctx.ModulePath = filepath.ToSlash(mustRel(info.Module.Directory, mustAbs(ctx.Directory)))
+	ctx.DockerPath = filepath.ToSlash(mustRel(ctx.Directory, info.Module.Directory))
+
+	if err := scaffoldTemplate(ctx, request.Force, "templates/create/main.go.tmpl", ctx.Paths.Main); err != nil {
+		return err
+	}
+	if err := scaffoldTemplate(ctx, request.Force, "templates/create/service_test.go.tmpl", ctx.Paths.Test); err != nil {
+		return err
+	}
+	if err := scaffoldTemplate(ctx, request.Force, "templates/create/makefile.tmpl", ctx.Paths.Makefile); err != nil {
+		return err
+	}
+	if err := scaffoldTemplate(ctx, request.Force, "templates/create/Dockerfile.tmpl", ctx.Paths.Dockerfile); err != nil {
 		return err
 	}
 
@@ -125,12 +153,14 @@ func (c CreateService) Exec(request *CreateServiceRequest) error {
 	return nil
 }
 
-func scaffoldTemplate(ctx createServiceContext, templatePath string, path string) error {
+// scaffoldTemplate evaluates one of our standard templates using the given data, writing the result to 'path'. Set
+// 'force' to overwrite the file if it already exists.
+func scaffoldTemplate(ctx interface{}, force bool, templatePath string, path string) error {
 	t := generate.NewStandardTemplate(templatePath, templatePath)
 
 	// Only allow you to overwrite the file if you included the --force argument.
 	_, err := os.Stat(path)
-	if !os.IsNotExist(err) && !ctx.Request.Force {
+	if !os.IsNotExist(err) && !force {
 		return fmt.Errorf("unable to open %s: already exists (use --force to overwrite it)", path)
 	}
 
@@ -145,6 +175,11 @@ func scaffoldTemplate(ctx createServiceContext, templatePath string, path string
 	if err != nil {
 		return fmt.Errorf("unable to eval code template: %s: %w", path, err)
 	}
+	if strings.HasSuffix(path, ".go") {
+		if code, err = format.Source(code); err != nil {
+			return fmt.Errorf("unable to format code artifact: %s: %w", path, err)
+		}
+	}
 
 	_, err = outputFile.Write(code)
 	if err != nil {
@@ -170,13 +205,21 @@ type createServiceContext struct {
 	Package string
 	// PackageImport is the full import path for this service within the module.
 	PackageImport string
+	// ModulePath is the path to the service's directory relative to the module's "go.mod" file.
+	ModulePath string
+	// DockerPath is the path to the module's root directory relative to the service's directory.
+	DockerPath string
 	// Port is the HTTP port we will have main() listen on to expose the RPC gateway.
 	Port int
 	// Paths contains the directory/filename paths to the various assets we're creating.
 	Paths struct {
-		Service  string
-		Handler  string
-		Makefile string
-		Main     string
+		Service    string
+		Handler    string
+		Store      string
+		Middleware string
+		Test       string
+		Makefile   string
+		Dockerfile string
+		Main       string
 	}
 }
@@ -0,0 +1,369 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/monadicstack/frodo/generate"
+	"github.com/monadicstack/frodo/parser"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// GenerateAllRequest contains all of the CLI options used in the "frodo generate" command.
+type GenerateAllRequest struct {
+	// ConfigFileName is the path to the config file describing everything to generate (the "--config" option).
+	ConfigFileName string
+	// Parallelism is the max number of services to parse or artifacts to generate at once (the "--parallel" option).
+	Parallelism int
+}
+
+// GenerateAll handles the registration and execution of the 'frodo generate' CLI subcommand.
+type GenerateAll struct{}
+
+// Command creates the Cobra struct describing this CLI command and its options.
+func (c GenerateAll) Command() *cobra.Command {
+	request := &GenerateAllRequest{}
+	cmd := &cobra.Command{
+		Use:   "generate [flags]",
+		Short: "Generates every gateway/client/mock/etc described in your 'frodo.yaml' file in one shot.",
+		Long:  "This reads your 'frodo.yaml' config file to determine which service definitions to process, which artifacts (gateway, client, mock, docs, markdown, postman, proto, owners) to generate for each, which client languages you need, where to write the output, and which custom templates to use. Services are parsed and artifacts are generated in parallel, but the output is the same no matter what order they finish in. It is safe to run repeatedly; artifacts whose code hasn't changed are left alone.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			crapPants(c.Exec(request))
+		},
+	}
+	cmd.Flags().StringVar(&request.ConfigFileName, "config", "frodo.yaml", "Path to the config file describing the services/artifacts to generate.")
+	cmd.Flags().IntVar(&request.Parallelism, "parallel", runtime.NumCPU(), "The max number of services/artifacts to process at the same time.")
+	return cmd
+}
+
+// Exec loads the config file and generates every artifact it describes for every service it describes.
+func (c GenerateAll) Exec(request *GenerateAllRequest) error {
+	return forEachArtifact(request.ConfigFileName, request.Parallelism, func(ctx *parser.Context, artifact generate.FileTemplate) error {
+		log.Printf("Generating artifact '%s' for %s", artifact.Name, ctx.Path)
+		return generate.File(ctx, artifact)
+	})
+}
+
+// forEachArtifact loads the config file, parses every service it describes (once), and invokes 'fn' for each
+// artifact the config says we should generate for that service. Parsing and 'fn' run on up to 'parallelism'
+// goroutines at a time, so 'fn' must be safe to call concurrently.
+func forEachArtifact(configFileName string, parallelism int, fn func(ctx *parser.Context, artifact generate.FileTemplate) error) error {
+	config, err := readGenerateConfig(configFileName)
+	if err != nil {
+		return err
+	}
+
+	// Figure out everything we need to parse up front, so that config mistakes fail fast before we do any real work.
+	type serviceFile struct {
+		inputFileName string
+		settings      GenerateSettings
+		artifacts     []generate.FileTemplate
+	}
+	var serviceFiles []serviceFile
+	configDir := filepath.Dir(configFileName)
+	for _, service := range config.Services {
+		settings := config.GenerateSettings.merge(service.GenerateSettings)
+		artifacts, err := settings.fileTemplates(configDir)
+		if err != nil {
+			return err
+		}
+
+		inputFileNames, err := filepath.Glob(resolvePath(configDir, service.File))
+		if err != nil {
+			return fmt.Errorf("%s: invalid service file: %s: %w", configFileName, service.File, err)
+		}
+		if len(inputFileNames) == 0 {
+			return fmt.Errorf("%s: no service definitions match: %s", configFileName, service.File)
+		}
+		for _, inputFileName := range inputFileNames {
+			serviceFiles = append(serviceFiles, serviceFile{inputFileName: inputFileName, settings: settings, artifacts: artifacts})
+		}
+	}
+
+	contexts := make([]*parser.Context, len(serviceFiles))
+	err = runParallel(parallelism, len(serviceFiles), func(i int) error {
+		log.Printf("Parsing service definitions: %s", serviceFiles[i].inputFileName)
+		ctx, err := parser.ParseFile(serviceFiles[i].inputFileName, parser.WithRouteNaming(serviceFiles[i].settings.Routes))
+		if err != nil {
+			return err
+		}
+		if output := serviceFiles[i].settings.Output; output != "" {
+			ctx.OutputPackage.Directory = filepath.Join(ctx.InputPackage.Directory, output)
+			ctx.OutputPackage.Import = path.Join(ctx.InputPackage.Import, filepath.ToSlash(output))
+		}
+		contexts[i] = ctx
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	type artifactJob struct {
+		ctx      *parser.Context
+		artifact generate.FileTemplate
+	}
+	var jobs []artifactJob
+	for i, file := range serviceFiles {
+		for _, artifact := range file.artifacts {
+			jobs = append(jobs, artifactJob{ctx: contexts[i], artifact: artifact})
+		}
+	}
+	return runParallel(parallelism, len(jobs), func(i int) error {
+		return fn(jobs[i].ctx, jobs[i].artifact)
+	})
+}
+
+// runParallel invokes 'fn' for every index from 0 to count-1 using a pool of (at most) 'parallelism' goroutines. Once
+// anything fails, we stop starting new work. Since goroutines finish in whatever order they like, we return the error
+// for the lowest index that failed rather than the first one to fail, so you get the same error on every run.
+func runParallel(parallelism int, count int, fn func(i int) error) error {
+	if parallelism < 1 {
+		parallelism = runtime.NumCPU()
+	}
+	if parallelism > count {
+		parallelism = count
+	}
+
+	indices := make(chan int)
+	errs := make([]error, count)
+	failed := false
+	mutex := sync.Mutex{}
+
+	wg := sync.WaitGroup{}
+	for worker := 0; worker < parallelism; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				err := fn(i)
+
+				mutex.Lock()
+				errs[i] = err
+				failed = failed || err != nil
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		mutex.Lock()
+		stop := failed
+		mutex.Unlock()
+		if stop {
+			break
+		}
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenerateConfig describes the structure of the "frodo.yaml" file that drives the "frodo generate" command. The
+// top-level settings are the defaults for every service, and each service can override any of them:
+//
+//	artifacts: [gateway, client, mock]
+//	languages: [go, js]
+//	routes: kebab-case
+//	services:
+//	  - file: users/user_service.go
+//	  - file: orders/order_service.go
+//	    languages: [go, js, dart]
+//	    hooks: react-query
+//	    output: rpc
+//	  - file: "internal/*/*_service.go"
+//	    templates:
+//	      client.js: templates/client.js.tmpl
+type GenerateConfig struct {
+	GenerateSettings `yaml:",inline"`
+	// Services are the service definition files that we should generate artifacts for.
+	Services []GenerateServiceConfig `yaml:"services"`
+}
+
+// GenerateServiceConfig describes a single entry in the "services" section of the config file.
+type GenerateServiceConfig struct {
+	GenerateSettings `yaml:",inline"`
+	// File is the path to the service definition file, relative to the config file. It can be a glob pattern
+	// (e.g. "services/*/*_service.go") if you want to apply the same settings to many services.
+	File string `yaml:"file"`
+}
+
+// GenerateSettings are the knobs you can turn for an individual service (or all services).
+type GenerateSettings struct {
+	// Artifacts are the things to generate: "gateway", "client", "mock", "docs", "markdown", "postman",
+	// "proto", and/or "owners". The default is to generate the gateway and client.
+	Artifacts []string `yaml:"artifacts,omitempty"`
+	// Languages are the languages you want clients for (e.g. "go", "js", "dart"). The default is "go".
+	Languages []string `yaml:"languages,omitempty"`
+	// Hooks is the data-fetching library (e.g. "react-query") to generate hooks for along w/ the JS client.
+	Hooks string `yaml:"hooks,omitempty"`
+	// Reactor generates a wrapper for the Java client whose functions return Reactor Monos.
+	Reactor bool `yaml:"reactor,omitempty"`
+	// OkHTTP generates an HTTP engine for the Java client that uses OkHttp instead of java.net.http.
+	OkHTTP bool `yaml:"okhttp,omitempty"`
+	// Transport is how callers reach the service: "http" (the default) or a message queue like "nats".
+	Transport string `yaml:"transport,omitempty"`
+	// Server is the language of the gateway: "go" (the default) or "node" for services implemented in Node.
+	Server string `yaml:"server,omitempty"`
+	// Output is the directory where the generated artifacts go, relative to the service definition's
+	// directory. The default is "gen".
+	Output string `yaml:"output,omitempty"`
+	// Routes is the naming convention for the routes of functions w/o a custom route (e.g. "kebab-case"). A
+	// service's own "ROUTES" doc option wins over this.
+	Routes string `yaml:"routes,omitempty"`
+	// Templates lets you use your own template for any artifact, keyed by the artifact name (e.g. "client.js"
+	// or "gateway.go"). The paths are relative to the config file.
+	Templates map[string]string `yaml:"templates,omitempty"`
+}
+
+// merge returns a copy of these settings w/ any non-empty values in 'overrides' replacing ours.
+func (settings GenerateSettings) merge(overrides GenerateSettings) GenerateSettings {
+	merged := settings
+	if len(overrides.Artifacts) > 0 {
+		merged.Artifacts = overrides.Artifacts
+	}
+	if len(overrides.Languages) > 0 {
+		merged.Languages = overrides.Languages
+	}
+	if overrides.Hooks != "" {
+		merged.Hooks = overrides.Hooks
+	}
+	merged.Reactor = settings.Reactor || overrides.Reactor
+	merged.OkHTTP = settings.OkHTTP || overrides.OkHTTP
+	if overrides.Transport != "" {
+		merged.Transport = overrides.Transport
+	}
+	if overrides.Server != "" {
+		merged.Server = overrides.Server
+	}
+	if overrides.Output != "" {
+		merged.Output = overrides.Output
+	}
+	if overrides.Routes != "" {
+		merged.Routes = overrides.Routes
+	}
+	merged.Templates = map[string]string{}
+	for name, templatePath := range settings.Templates {
+		merged.Templates[name] = templatePath
+	}
+	for name, templatePath := range overrides.Templates {
+		merged.Templates[name] = templatePath
+	}
+	return merged
+}
+
+// fileTemplates resolves the artifact names/languages to the actual templates that we'll feed to the generator.
+func (settings GenerateSettings) fileTemplates(configDir string) ([]generate.FileTemplate, error) {
+	artifacts := settings.Artifacts
+	if len(artifacts) == 0 {
+		artifacts = []string{"gateway", "client"}
+	}
+	languages := settings.Languages
+	if len(languages) == 0 {
+		languages = []string{"go"}
+	}
+
+	var names []string
+	for _, artifact := range artifacts {
+		switch strings.ToLower(artifact) {
+		case "gateway":
+			name, err := gatewayArtifactName(settings.Transport, settings.Server)
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, name)
+		case "client":
+			for _, language := range languages {
+				name, err := clientArtifactName(language)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %s", err, language)
+				}
+				names = append(names, name)
+				switch name {
+				case "client.js":
+					hooksName, err := hooksArtifactName(settings.Hooks, name)
+					if err != nil {
+						return nil, err
+					}
+					if hooksName != "" {
+						names = append(names, hooksName)
+					}
+				case "client.java":
+					javaNames, err := javaArtifactNames(settings.Reactor, settings.OkHTTP, name)
+					if err != nil {
+						return nil, err
+					}
+					names = append(names, javaNames...)
+				}
+			}
+		case "mock":
+			names = append(names, "mock.go")
+		case "docs", "openapi":
+			names = append(names, "openapi.yml")
+		case "postman", "insomnia":
+			names = append(names, "postman.json")
+		case "markdown":
+			names = append(names, "docs.md")
+		case "proto", "protobuf":
+			names = append(names, "proto")
+		case "owners":
+			names = append(names, "owners")
+		default:
+			return nil, fmt.Errorf("unsupported artifact: %s", artifact)
+		}
+	}
+
+	fileTemplates := make([]generate.FileTemplate, len(names))
+	for i, name := range names {
+		option := templateOption{}
+		if templatePath, ok := settings.Templates[name]; ok {
+			option.Template = resolvePath(configDir, templatePath)
+		}
+		fileTemplates[i] = option.ToFileTemplate(name)
+	}
+	return fileTemplates, nil
+}
+
+// readGenerateConfig loads/parses the YAML config file at the given path.
+func readGenerateConfig(fileName string) (*GenerateConfig, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config: %w", err)
+	}
+
+	config := &GenerateConfig{}
+	if err = yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("unable to parse config: %s: %w", fileName, err)
+	}
+	if len(config.Services) == 0 {
+		return nil, fmt.Errorf("unable to parse config: %s: no services defined", fileName)
+	}
+	for i, service := range config.Services {
+		if service.File == "" {
+			return nil, fmt.Errorf("unable to parse config: %s: services[%d] is missing a 'file'", fileName, i)
+		}
+	}
+	return config, nil
+}
+
+// resolvePath treats relative paths as relative to 'dir'. Absolute paths are left as-is.
+func resolvePath(dir string, fileName string) string {
+	if filepath.IsAbs(fileName) {
+		return fileName
+	}
+	return filepath.Join(dir, fileName)
+}
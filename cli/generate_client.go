@@ -3,20 +3,35 @@ package cli
 import (
 	"fmt"
 	"log"
+	"path"
 	"strings"
 
 	"github.com/monadicstack/frodo/generate"
-	"github.com/monadicstack/frodo/parser"
 	"github.com/spf13/cobra"
 )
 
 // GenerateClientRequest contains all of the CLI options used in the "frodo client" command.
 type GenerateClientRequest struct {
 	templateOption
+	routeOption
 	// InputFileName is the service definition to parse/process (the "--service" option)
 	InputFileName string
 	// Language is the programming language for the client to generate (the "--language" option)
 	Language string
+	// Hooks is the data-fetching library to generate hooks for along w/ a JS client (the "--hooks" option). The
+	// only one we support right now is "react-query".
+	Hooks string
+	// Reactor generates a wrapper for the Java client whose functions return Reactor Monos (the "--reactor" option).
+	Reactor bool
+	// OkHTTP generates an HTTP engine for the Java client that uses OkHttp instead of java.net.http (the
+	// "--okhttp" option).
+	OkHTTP bool
+	// Module is the path of the standalone Go module to generate for the client (the "--module" option). When
+	// this is blank, we just generate the client in your service's "gen" package like normal.
+	Module string
+	// Directory is where we write the standalone client module (the "--dir" option). It defaults to a
+	// directory named after the last segment of the module path (e.g. "foo-client").
+	Directory string
 }
 
 // GenerateClient handles the registration and execution of the 'frodo client' CLI subcommand.
@@ -35,35 +50,128 @@ func (c GenerateClient) Command() *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVar(&request.Language, "language", "go", "The file extension of the target language (e.g. 'go' or 'js')")
+	cmd.Flags().StringVar(&request.Hooks, "hooks", "", "Also generate hooks that wrap the JS client for this library (e.g. 'react-query').")
+	cmd.Flags().BoolVar(&request.Reactor, "reactor", false, "Also generate a wrapper for the Java client whose functions return Reactor Monos.")
+	cmd.Flags().BoolVar(&request.OkHTTP, "okhttp", false, "Also generate an HTTP engine for the Java client that uses OkHttp.")
 	cmd.Flags().StringVar(&request.Template, "template", "", "Path to a custom Go template file used to generate this artifact.")
+	cmd.Flags().StringVar(&request.Routes, "routes", "", "The naming convention for routes of functions w/o a custom one: 'default', 'kebab-case', 'snake_case', 'lowerCamel', or a template like '/rpc/{service:kebab}/{function}'.")
+	cmd.Flags().StringVar(&request.Module, "module", "", "Generate a standalone Go module w/ this path (e.g. 'github.com/org/foo-client') containing only the client and its models.")
+	cmd.Flags().StringVar(&request.Directory, "dir", "", "When using --module, the directory where we write the module (defaults to the last segment of the module path).")
 	return cmd
 }
 
 // Exec takes all of the parsed CLI flags and generates the target client artifact.
 func (c GenerateClient) Exec(request *GenerateClientRequest) error {
-	switch strings.ToLower(request.Language) {
+	name, err := clientArtifactName(request.Language)
+	if err != nil {
+		return err
+	}
+	hooksName, err := hooksArtifactName(request.Hooks, name)
+	if err != nil {
+		return err
+	}
+	javaNames, err := javaArtifactNames(request.Reactor, request.OkHTTP, name)
+	if err != nil {
+		return err
+	}
+	if request.Module != "" {
+		return c.generateModule(request, request.ToFileTemplate(name))
+	}
+
+	artifacts := []generate.FileTemplate{request.ToFileTemplate(name)}
+	for _, extraName := range append(javaNames, hooksName) {
+		if extraName != "" {
+			artifacts = append(artifacts, templateOption{}.ToFileTemplate(extraName))
+		}
+	}
+	return c.generate(request, artifacts...)
+}
+
+// clientArtifactName determines which artifact/template we use to generate the client for the given language.
+func clientArtifactName(language string) (string, error) {
+	switch strings.ToLower(language) {
 	case "go", "":
-		return c.generate(request, request.ToFileTemplate("client.go"))
+		return "client.go", nil
 	case "js", "javascript", "node", "nodejs":
-		return c.generate(request, request.ToFileTemplate("client.js"))
+		return "client.js", nil
 	case "java":
-		return c.generate(request, request.ToFileTemplate("client.java"))
+		return "client.java", nil
 	case "dart", "flutter":
-		return c.generate(request, request.ToFileTemplate("client.dart"))
+		return "client.dart", nil
 	default:
-		return fmt.Errorf("unsupported client language")
+		return "", fmt.Errorf("unsupported client language")
 	}
 }
 
+// hooksArtifactName determines which artifact/template we use to generate hooks for the given library. The
+// hooks wrap the JS client, so 'clientName' must be "client.js". No hooks library results in a blank name.
+func hooksArtifactName(hooks string, clientName string) (string, error) {
+	var name string
+	switch strings.ToLower(hooks) {
+	case "":
+		return "", nil
+	case "react-query", "tanstack", "tanstack-query":
+		name = "react-query.js"
+	default:
+		return "", fmt.Errorf("unsupported hooks library: %s", hooks)
+	}
+	if clientName != "client.js" {
+		return "", fmt.Errorf("the --hooks option only supports JS clients")
+	}
+	return name, nil
+}
+
+// javaArtifactNames determines which of the optional Java artifacts to generate along w/ the Java client: the
+// Reactor wrapper and/or the OkHttp engine. They both use the Java client, so 'clientName' must be "client.java".
+func javaArtifactNames(reactor bool, okHTTP bool, clientName string) ([]string, error) {
+	var names []string
+	if reactor {
+		names = append(names, "reactor.java")
+	}
+	if okHTTP {
+		names = append(names, "ok-http-engine.java")
+	}
+	if len(names) > 0 && clientName != "client.java" {
+		return nil, fmt.Errorf("the --reactor and --okhttp options only support Java clients")
+	}
+	return names, nil
+}
+
 // generate parses the input service definition file and creates an output client/gateway
-// code, writing it to the output gen/ directory.
-func (c GenerateClient) generate(request *GenerateClientRequest, artifact generate.FileTemplate) error {
+// code (and any other artifacts that go w/ it), writing it to the output gen/ directory.
+func (c GenerateClient) generate(request *GenerateClientRequest, artifacts ...generate.FileTemplate) error {
 	log.Printf("Parsing service definition: %s", request.InputFileName)
-	ctx, err := parser.ParseFile(request.InputFileName)
+	ctx, err := request.ParseFile(request.InputFileName)
 	if err != nil {
 		return err
 	}
 
-	log.Printf("Generating '%s'", artifact.Name)
-	return generate.File(ctx, artifact)
+	for _, artifact := range artifacts {
+		log.Printf("Generating '%s'", artifact.Name)
+		if err = generate.File(ctx, artifact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generateModule parses the input service definition file and creates a standalone Go module that contains
+// only the client and the models it needs, so external consumers don't need to import the service's module.
+func (c GenerateClient) generateModule(request *GenerateClientRequest, artifact generate.FileTemplate) error {
+	if artifact.Name != "client.go" {
+		return fmt.Errorf("the --module option only supports Go clients")
+	}
+
+	log.Printf("Parsing service definition: %s", request.InputFileName)
+	ctx, err := request.ParseFile(request.InputFileName)
+	if err != nil {
+		return err
+	}
+
+	dir := request.Directory
+	if dir == "" {
+		dir = path.Base(request.Module)
+	}
+	log.Printf("Generating client module '%s' in %s", request.Module, dir)
+	return generate.ClientModule(ctx, artifact, request.Module, dir)
 }
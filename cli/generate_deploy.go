@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/monadicstack/frodo/generate"
+	"github.com/monadicstack/frodo/parser"
+	"github.com/spf13/cobra"
+)
+
+// GenerateDeployRequest contains all of the CLI options used in the "frodo deploy" command.
+type GenerateDeployRequest struct {
+	templateOption
+	// InputFileName is the service definition to parse/process (the "--service" option)
+	InputFileName string
+	// Format is the type of manifest to generate: "k8s" (default) for a Kubernetes Deployment, Service, and
+	// Ingress or "knative" for a Knative Service (the "--format" option).
+	Format string
+}
+
+// GenerateDeploy handles the registration and execution of the 'frodo deploy' CLI subcommand.
+type GenerateDeploy struct{}
+
+// Command creates the Cobra struct describing this CLI command and its options.
+func (c GenerateDeploy) Command() *cobra.Command {
+	request := &GenerateDeployRequest{}
+	cmd := &cobra.Command{
+		Use:   "deploy [flags] FILENAME",
+		Short: "Generates the manifests that run your service's gateway on Kubernetes or Knative.",
+		Long:  "This generates deployment manifests for your service's gateway. The ingress routes come from your functions' paths, the liveness/readiness probes call the function w/ the HEALTH doc option, and the CPU/memory/replica hints come from the service's RESOURCES doc option.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			request.InputFileName = args[0]
+			crapPants(c.Exec(request))
+		},
+	}
+	cmd.Flags().StringVar(&request.Template, "template", "", "Path to a custom Go template file used to generate this artifact.")
+	cmd.Flags().StringVar(&request.Format, "format", "k8s", "The type of manifest to generate: 'k8s' or 'knative'.")
+	return cmd
+}
+
+// Exec takes all of the parsed CLI flags and generates the service's deployment manifest.
+func (c GenerateDeploy) Exec(request *GenerateDeployRequest) error {
+	log.Printf("Parsing service definitions: %s", request.InputFileName)
+	ctx, err := parser.ParseFile(request.InputFileName)
+	if err != nil {
+		return err
+	}
+
+	name, err := deployArtifactName(request.Format)
+	if err != nil {
+		return err
+	}
+
+	artifact := request.ToFileTemplate(name)
+	log.Printf("Generating artifact '%s'", artifact.Name)
+	return generate.File(ctx, artifact)
+}
+
+// deployArtifactName returns the name of the standard template that generates the given manifest format.
+func deployArtifactName(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", "k8s", "kubernetes":
+		return "k8s.yaml", nil
+	case "knative":
+		return "knative.yaml", nil
+	default:
+		return "", fmt.Errorf("unsupported deploy format: %s", format)
+	}
+}
@@ -1,18 +1,24 @@
 package cli
 
 import (
+	"fmt"
 	"log"
+	"strings"
 
 	"github.com/monadicstack/frodo/generate"
-	"github.com/monadicstack/frodo/parser"
 	"github.com/spf13/cobra"
 )
 
 // GenerateDocsRequest contains all of the CLI options used in the "frodo docs" command.
 type GenerateDocsRequest struct {
 	templateOption
+	routeOption
 	// InputFileName is the service definition to parse/process (the "--service" option)
 	InputFileName string
+	// Format is the type of documentation to generate: "openapi" (default), "markdown" for an API reference
+	// that you can commit to your docs site, or "postman" for a collection that you can import into
+	// Postman/Insomnia, or "proto" for the Protocol Buffers messages of the request/response bodies (the "--format" option).
+	Format string
 }
 
 // GenerateDocs handles the registration and execution of the 'frodo docs' CLI subcommand.
@@ -31,18 +37,41 @@ func (c GenerateDocs) Command() *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVar(&request.Template, "template", "", "Path to a custom Go template file used to generate this artifact.")
+	cmd.Flags().StringVar(&request.Format, "format", "openapi", "The type of documentation to generate: 'openapi', 'markdown', 'postman' (which Insomnia can import, too), or 'proto'.")
+	cmd.Flags().StringVar(&request.Routes, "routes", "", "The naming convention for routes of functions w/o a custom one: 'default', 'kebab-case', 'snake_case', 'lowerCamel', or a template like '/rpc/{service:kebab}/{function}'.")
 	return cmd
 }
 
 // Exec takes all of the parsed CLI flags and generates the service's documentation artifact(s).
 func (c GenerateDocs) Exec(request *GenerateDocsRequest) error {
 	log.Printf("Parsing service definitions: %s", request.InputFileName)
-	ctx, err := parser.ParseFile(request.InputFileName)
+	ctx, err := request.ParseFile(request.InputFileName)
 	if err != nil {
 		return err
 	}
 
-	artifact := request.ToFileTemplate("openapi.yml")
+	name, err := docsArtifactName(request.Format)
+	if err != nil {
+		return err
+	}
+
+	artifact := request.ToFileTemplate(name)
 	log.Printf("Generating artifact '%s'", artifact.Name)
 	return generate.File(ctx, artifact)
 }
+
+// docsArtifactName returns the name of the standard template that generates the given documentation format.
+func docsArtifactName(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", "openapi", "swagger":
+		return "openapi.yml", nil
+	case "postman", "insomnia":
+		return "postman.json", nil
+	case "markdown", "md":
+		return "docs.md", nil
+	case "proto", "protobuf":
+		return "proto", nil
+	default:
+		return "", fmt.Errorf("unsupported docs format: %s", format)
+	}
+}
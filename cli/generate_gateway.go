@@ -1,18 +1,24 @@
 package cli
 
 import (
+	"fmt"
 	"log"
+	"strings"
 
 	"github.com/monadicstack/frodo/generate"
-	"github.com/monadicstack/frodo/parser"
 	"github.com/spf13/cobra"
 )
 
 // GenerateGatewayRequest contains all of the CLI options used in the "frodo client" command.
 type GenerateGatewayRequest struct {
 	templateOption
+	routeOption
 	// InputFileName is the service definition to parse/process (the "--service" option)
 	InputFileName string
+	// Transport is how callers reach the service: "http" (the default) or a message queue like "nats".
+	Transport string
+	// Language is the programming language of the gateway to generate (the "--language" option).
+	Language string
 }
 
 // GenerateGateway handles the registration and execution of the 'frodo gateway' CLI subcommand.
@@ -32,19 +38,51 @@ func (c GenerateGateway) Command() *cobra.Command {
 		SilenceUsage:  true,
 		SilenceErrors: true,
 	}
+	cmd.Flags().StringVar(&request.Transport, "transport", "http", "How callers invoke the service: 'http' or 'nats' (any request/reply message queue)")
+	cmd.Flags().StringVar(&request.Language, "language", "go", "The language of the gateway: 'go' or 'node' (an Express router)")
 	cmd.Flags().StringVar(&request.Template, "template", "", "Path to a custom Go template file used to generate this artifact.")
+	cmd.Flags().StringVar(&request.Routes, "routes", "", "The naming convention for routes of functions w/o a custom one: 'default', 'kebab-case', 'snake_case', 'lowerCamel', or a template like '/rpc/{service:kebab}/{function}'.")
 	return cmd
 }
 
 // Exec actually executes the parsing/generating logic creating the gateway for the given declaration.
 func (c GenerateGateway) Exec(request *GenerateGatewayRequest) error {
+	name, err := gatewayArtifactName(request.Transport, request.Language)
+	if err != nil {
+		return err
+	}
+	artifact := request.ToFileTemplate(name)
+
 	log.Printf("Parsing service definitions: %s", request.InputFileName)
-	ctx, err := parser.ParseFile(request.InputFileName)
+	ctx, err := request.ParseFile(request.InputFileName)
 	if err != nil {
 		return err
 	}
 
-	artifact := request.ToFileTemplate("gateway.go")
 	log.Printf("Generating artifact '%s'", artifact.Name)
 	return generate.File(ctx, artifact)
 }
+
+// gatewayArtifactName determines which artifact/template we use to generate the gateway for the given
+// transport and language.
+func gatewayArtifactName(transport string, language string) (string, error) {
+	switch strings.ToLower(language) {
+	case "go", "":
+	case "node", "nodejs", "js", "javascript", "express":
+		if transport := strings.ToLower(transport); transport != "http" && transport != "" {
+			return "", fmt.Errorf("node gateways only support the http transport")
+		}
+		return "gateway.js", nil
+	default:
+		return "", fmt.Errorf("unsupported gateway language")
+	}
+
+	switch strings.ToLower(transport) {
+	case "http", "":
+		return "gateway.go", nil
+	case "nats", "queue":
+		return "queue.go", nil
+	default:
+		return "", fmt.Errorf("unsupported gateway transport")
+	}
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"log"
+
+	"github.com/monadicstack/frodo/generate"
+	"github.com/monadicstack/frodo/parser"
+	"github.com/spf13/cobra"
+)
+
+// GenerateGraphQLRequest contains all of the CLI options used in the "frodo graphql" command.
+type GenerateGraphQLRequest struct {
+	templateOption
+	// InputFileName is the service definition to parse/process (the "--service" option)
+	InputFileName string
+}
+
+// GenerateGraphQL handles the registration and execution of the 'frodo graphql' CLI subcommand.
+type GenerateGraphQL struct{}
+
+// Command creates the Cobra struct describing this CLI command and its options.
+func (c GenerateGraphQL) Command() *cobra.Command {
+	request := &GenerateGraphQLRequest{}
+	cmd := &cobra.Command{
+		Use:   "graphql [flags] FILENAME",
+		Short: "(Experimental) Generates a GraphQL schema and resolvers that expose your service via GraphQL.",
+		Long:  "This generates the GraphQL types, queries, and mutations for your service along w/ Go resolvers that delegate each query/mutation to your service (usually its generated client). The schema extends your Query/Mutation types, so you can combine the schemas of several services into a single GraphQL API. The '--template' option replaces the schema template; the resolvers always use the standard one.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			request.InputFileName = args[0]
+			crapPants(c.Exec(request))
+		},
+	}
+	cmd.Flags().StringVar(&request.Template, "template", "", "Path to a custom Go template file used to generate the schema.")
+	return cmd
+}
+
+// Exec takes all of the parsed CLI flags and generates the service's GraphQL schema and resolvers.
+func (c GenerateGraphQL) Exec(request *GenerateGraphQLRequest) error {
+	log.Printf("Parsing service definitions: %s", request.InputFileName)
+	ctx, err := parser.ParseFile(request.InputFileName)
+	if err != nil {
+		return err
+	}
+
+	artifacts := []generate.FileTemplate{
+		request.ToFileTemplate("graphql"),
+		templateOption{}.ToFileTemplate("graphql.go"),
+	}
+	for _, artifact := range artifacts {
+		log.Printf("Generating artifact '%s'", artifact.Name)
+		if err := generate.File(ctx, artifact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
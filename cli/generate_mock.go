@@ -1,10 +1,15 @@
 package cli
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/monadicstack/frodo/generate"
 	"github.com/monadicstack/frodo/parser"
+	"github.com/monadicstack/frodo/rpc"
 	"github.com/spf13/cobra"
 )
 
@@ -13,6 +18,12 @@ type GenerateMockRequest struct {
 	templateOption
 	// InputFileName is the service definition to parse/process (the "--service" option)
 	InputFileName string
+	// Serve is the address (e.g. ":8080") of a mock server to run instead of generating the mock artifact. It
+	// responds to every call w/ example data (the "--serve" option).
+	Serve string
+	// FixtureDir is a directory of canned responses for the mock server (e.g. "GetUser.json") to use instead
+	// of the example data (the "--fixtures" option).
+	FixtureDir string
 }
 
 // GenerateMock handles the registration and execution of the 'frodo mock' CLI subcommand.
@@ -24,6 +35,7 @@ func (c GenerateMock) Command() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "mock [flags] FILENAME",
 		Short: "Creates a mock instance of your service for unit testing.",
+		Long:  "This generates a mock implementation of your service that you can use in unit tests. With the '--serve' option, it runs an HTTP server instead that exposes every function in your service, responding w/ example data (or your fixtures) so that you can build against the API before the real implementation exists.",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			request.InputFileName = args[0]
@@ -31,6 +43,8 @@ func (c GenerateMock) Command() *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVar(&request.Template, "template", "", "Path to a custom Go template file used to generate this artifact.")
+	cmd.Flags().StringVar(&request.Serve, "serve", "", "Run a mock server on this address (e.g. ':8080') rather than generating the mock artifact.")
+	cmd.Flags().StringVar(&request.FixtureDir, "fixtures", "", "Directory of canned responses for the mock server, named after each function (e.g. 'GetUser.json').")
 	return cmd
 }
 
@@ -42,7 +56,21 @@ func (c GenerateMock) Exec(request *GenerateMockRequest) error {
 		return err
 	}
 
+	if request.Serve != "" {
+		return c.serve(ctx, request)
+	}
+
 	artifact := request.ToFileTemplate("mock.go")
 	log.Printf("Generating artifact '%s'", artifact.Name)
 	return generate.File(ctx, artifact)
 }
+
+// serve runs the mock server until you kill the process (e.g. Ctrl+C).
+func (c GenerateMock) serve(ctx *parser.Context, request *GenerateMockRequest) error {
+	runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Serving mock %s on %s", ctx.Service.Name, request.Serve)
+	gateway := newMockGateway(ctx, request.FixtureDir)
+	return rpc.NewServer(request.Serve, gateway).Run(runCtx)
+}
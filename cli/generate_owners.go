@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"log"
+
+	"github.com/monadicstack/frodo/generate"
+	"github.com/monadicstack/frodo/parser"
+	"github.com/spf13/cobra"
+)
+
+// GenerateOwnersRequest contains all of the CLI options used in the "frodo owners" command.
+type GenerateOwnersRequest struct {
+	templateOption
+	// InputFileName is the service definition to parse/process (the "--service" option)
+	InputFileName string
+}
+
+// GenerateOwners handles the registration and execution of the 'frodo owners' CLI subcommand.
+type GenerateOwners struct{}
+
+// Command creates the Cobra struct describing this CLI command and its options.
+func (c GenerateOwners) Command() *cobra.Command {
+	request := &GenerateOwnersRequest{}
+	cmd := &cobra.Command{
+		Use:   "owners [flags] FILENAME",
+		Short: "Generates a CODEOWNERS-style manifest describing who owns each of your service's operations.",
+		Long:  "This generates a manifest based on the OWNER doc options on your service and its functions. Each line contains the operation name, HTTP method, HTTP path, and the owners of that operation, so incident tooling and documentation portals can route questions/pages to the right team.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			request.InputFileName = args[0]
+			crapPants(c.Exec(request))
+		},
+	}
+	cmd.Flags().StringVar(&request.Template, "template", "", "Path to a custom Go template file used to generate this artifact.")
+	return cmd
+}
+
+// Exec takes all of the parsed CLI flags and generates the service's ownership manifest.
+func (c GenerateOwners) Exec(request *GenerateOwnersRequest) error {
+	log.Printf("Parsing service definitions: %s", request.InputFileName)
+	ctx, err := parser.ParseFile(request.InputFileName)
+	if err != nil {
+		return err
+	}
+
+	artifact := request.ToFileTemplate("owners")
+	log.Printf("Generating artifact '%s'", artifact.Name)
+	return generate.File(ctx, artifact)
+}
@@ -0,0 +1,308 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	goparser "go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/monadicstack/frodo/parser"
+	"github.com/spf13/cobra"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// rpcImport is the package for rpc.Empty, which some functions might use as their request/response.
+const rpcImport = "github.com/monadicstack/frodo/rpc"
+
+// rpcErrorsImport is the package whose errors.Unexpected() the generated stubs return.
+const rpcErrorsImport = "github.com/monadicstack/frodo/rpc/errors"
+
+// ImplementServiceRequest contains all of the CLI options used in the "frodo implement" command.
+type ImplementServiceRequest struct {
+	templateOption
+	// InputFileName is the service definition to parse/process.
+	InputFileName string
+	// HandlerName is the name of the struct that implements the service (the "--handler" option).
+	HandlerName string
+}
+
+// ImplementService handles the registration and execution of the 'frodo implement' CLI subcommand.
+type ImplementService struct{}
+
+// Command creates the Cobra struct describing this CLI command and its options.
+func (c ImplementService) Command() *cobra.Command {
+	request := &ImplementServiceRequest{}
+	cmd := &cobra.Command{
+		Use:   "implement [flags] FILENAME",
+		Short: "Adds stub functions to your service handler for any operations it doesn't implement yet.",
+		Long:  "This looks for the struct that implements your service in the same package as the service declaration (e.g. 'UserServiceHandler' for 'UserService'). Every service operation that the handler is missing gets a stub function that returns a 'not implemented' error. If the handler doesn't exist yet, we'll create it in a new file.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			request.InputFileName = args[0]
+			crapPants(c.Exec(request))
+		},
+	}
+	cmd.Flags().StringVar(&request.HandlerName, "handler", "", "Name of the struct that implements your service (default = service name + 'Handler')")
+	cmd.Flags().StringVar(&request.Template, "template", "", "Path to a custom Go template file used to generate the stub functions.")
+	return cmd
+}
+
+// Exec takes all of the parsed CLI flags and adds stubs for the handler's missing functions.
+func (c ImplementService) Exec(request *ImplementServiceRequest) error {
+	log.Printf("Parsing service definitions: %s", request.InputFileName)
+	ctx, err := parser.ParseFile(request.InputFileName)
+	if err != nil {
+		return err
+	}
+
+	implCtx := implementContext{
+		Package:       ctx.InputPackage.Name,
+		ServiceName:   ctx.Service.Name,
+		HandlerName:   request.HandlerName,
+		ErrorsPackage: "errors",
+	}
+	if implCtx.HandlerName == "" {
+		implCtx.HandlerName = ctx.Service.Name + "Handler"
+	}
+
+	handler, err := findHandler(filepath.Dir(ctx.Path), implCtx.HandlerName)
+	if err != nil {
+		return err
+	}
+	for _, function := range ctx.Service.Functions {
+		if !handler.Methods[function.Name] {
+			implCtx.Functions = append(implCtx.Functions, function)
+		}
+	}
+	if len(implCtx.Functions) == 0 && handler.Path != "" {
+		log.Printf("Handler '%s' already implements all of %s", implCtx.HandlerName, implCtx.ServiceName)
+		return nil
+	}
+
+	// When there's no handler yet, we'll put it in a new file next to the service (e.g. "user_handler.go").
+	outputPath := handler.Path
+	source := []byte{}
+	if outputPath == "" {
+		shortName := strings.TrimSuffix(implCtx.ServiceName, "Service")
+		outputPath = filepath.Join(filepath.Dir(ctx.Path), strings.ToLower(shortName)+"_handler.go")
+		implCtx.NewFile = true
+		implCtx.DeclareHandler = true
+	} else if source, err = os.ReadFile(outputPath); err != nil {
+		return fmt.Errorf("unable to read handler: %s: %w", outputPath, err)
+	}
+
+	fileSet := token.NewFileSet()
+	if !implCtx.NewFile {
+		file, err := goparser.ParseFile(fileSet, outputPath, source, goparser.ImportsOnly)
+		if err != nil {
+			return fmt.Errorf("unable to parse handler: %s: %w", outputPath, err)
+		}
+		implCtx.ErrorsPackage = errorsPackageName(file)
+	}
+	implCtx.Receiver = handler.Receiver(implCtx.HandlerName)
+
+	artifact := request.ToFileTemplate("implement.go")
+	stubs, err := artifact.Eval(implCtx)
+	if err != nil {
+		return fmt.Errorf("template eval error: %s: %v", artifact.Name, err)
+	}
+
+	// Tack the stubs onto the end of the handler source and make sure that it imports
+	// everything the stubs need before we write it back out.
+	source = append(source, '\n')
+	source = append(source, stubs...)
+	fileSet = token.NewFileSet()
+	file, err := goparser.ParseFile(fileSet, outputPath, source, goparser.ParseComments)
+	if err != nil {
+		return fmt.Errorf("unable to parse generated stubs: %s: %w", outputPath, err)
+	}
+	astutil.AddImport(fileSet, file, "context")
+	if implCtx.ErrorsPackage == "errors" {
+		astutil.AddImport(fileSet, file, rpcErrorsImport)
+	} else {
+		astutil.AddNamedImport(fileSet, file, implCtx.ErrorsPackage, rpcErrorsImport)
+	}
+	if implCtx.Functions.HasEmpty() {
+		astutil.AddImport(fileSet, file, rpcImport)
+	}
+
+	buf := &bytes.Buffer{}
+	if err = format.Node(buf, fileSet, file); err != nil {
+		return fmt.Errorf("error running 'go fmt': %s: %w", outputPath, err)
+	}
+
+	log.Printf("Adding %d stub function(s) to '%s' in %s", len(implCtx.Functions), implCtx.HandlerName, outputPath)
+	if err = os.WriteFile(outputPath, buf.Bytes(), 0666); err != nil {
+		return fmt.Errorf("unable to write handler: %s: %w", outputPath, err)
+	}
+	return nil
+}
+
+// implementContext is the root data fed to the "implement.go" template to generate the stub functions.
+type implementContext struct {
+	// Package is the name of the package that the service and handler belong to.
+	Package string
+	// ServiceName is the name of the service interface (e.g. "UserService").
+	ServiceName string
+	// HandlerName is the name of the struct that implements the service (e.g. "UserServiceHandler").
+	HandlerName string
+	// Receiver is the receiver declaration used for each stub function (e.g. "svc *UserServiceHandler").
+	Receiver string
+	// ErrorsPackage is the name that the handler file uses to reference frodo's "rpc/errors" package.
+	ErrorsPackage string
+	// NewFile is true when the handler doesn't have a file yet, so we need the package clause.
+	NewFile bool
+	// DeclareHandler is true when the handler struct doesn't exist yet, so we need to declare it.
+	DeclareHandler bool
+	// Functions are the service functions that the handler doesn't implement yet.
+	Functions parser.ServiceFunctionDeclarations
+}
+
+// handlerInfo describes what we found out about an existing handler struct by scanning the package.
+type handlerInfo struct {
+	// Path is the file where the handler struct is declared. This is "" if the struct doesn't exist.
+	Path string
+	// Methods is the set of function names that already have a receiver of the handler's type.
+	Methods map[string]bool
+	// ReceiverName is the receiver variable name used by the existing methods (e.g. "svc").
+	ReceiverName string
+	// ReceiverValue is true if the existing methods use a value receiver rather than a pointer receiver.
+	ReceiverValue bool
+}
+
+// Receiver builds the receiver declaration for new stubs, following the lead of any existing methods.
+func (info handlerInfo) Receiver(handlerName string) string {
+	name := info.ReceiverName
+	if name == "" {
+		name = "svc"
+	}
+	if info.ReceiverValue {
+		return name + " " + handlerName
+	}
+	return name + " *" + handlerName
+}
+
+// findHandler scans all of the Go files in the service's package directory to find where the handler struct
+// is declared and which methods it already has. We look at the raw syntax rather than the type info so that
+// this works even when the handler is spread across multiple files.
+func findHandler(dir string, handlerName string) (handlerInfo, error) {
+	info := handlerInfo{Methods: map[string]bool{}}
+
+	fileSet := token.NewFileSet()
+	notTests := func(fileInfo os.FileInfo) bool {
+		return !strings.HasSuffix(fileInfo.Name(), "_test.go")
+	}
+	pkgs, err := goparser.ParseDir(fileSet, dir, notTests, 0)
+	if err != nil {
+		return info, fmt.Errorf("unable to parse package: %s: %w", dir, err)
+	}
+
+	// Map iteration order is random, so sort the files to make sure that we're consistent about which
+	// method's receiver we mimic when there are several files.
+	files := map[string]*ast.File{}
+	var paths []string
+	for _, pkg := range pkgs {
+		for path, file := range pkg.Files {
+			files[path] = file
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		for _, decl := range files[path].Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				if info.Path == "" && declaresType(decl, handlerName) {
+					info.Path = path
+				}
+			case *ast.FuncDecl:
+				recvName, recvValue, ok := receiverOf(decl, handlerName)
+				if !ok {
+					continue
+				}
+				info.Methods[decl.Name.Name] = true
+				if info.ReceiverName == "" {
+					info.ReceiverName = recvName
+					info.ReceiverValue = recvValue
+				}
+			}
+		}
+	}
+	return info, nil
+}
+
+// declaresType returns true if the declaration is a "type" block that declares the given type name.
+func declaresType(decl *ast.GenDecl, typeName string) bool {
+	if decl.Tok != token.TYPE {
+		return false
+	}
+	for _, spec := range decl.Specs {
+		if typeSpec, ok := spec.(*ast.TypeSpec); ok && typeSpec.Name.Name == typeName {
+			return true
+		}
+	}
+	return false
+}
+
+// receiverOf determines if the function is a method on the given type. If it is, we'll also tell you
+// the name of the receiver variable and whether it's a value receiver (true) or pointer receiver (false).
+func receiverOf(decl *ast.FuncDecl, typeName string) (string, bool, bool) {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return "", false, false
+	}
+
+	recv := decl.Recv.List[0]
+	recvName := ""
+	if len(recv.Names) > 0 && recv.Names[0].Name != "_" {
+		recvName = recv.Names[0].Name
+	}
+
+	recvType := recv.Type
+	recvValue := true
+	if star, ok := recvType.(*ast.StarExpr); ok {
+		recvType = star.X
+		recvValue = false
+	}
+	ident, ok := recvType.(*ast.Ident)
+	if !ok || ident.Name != typeName {
+		return "", false, false
+	}
+	return recvName, recvValue, true
+}
+
+// errorsPackageName determines what name the stubs should use to reference frodo's "rpc/errors" package. If
+// the file already imports it, we'll use that. If the name "errors" is taken by some other package (e.g. the
+// standard library's), we'll import it as "rpcerrors" instead.
+func errorsPackageName(file *ast.File) string {
+	errorsTaken := false
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := ""
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+
+		if path == rpcErrorsImport {
+			if name == "" {
+				return "errors"
+			}
+			return name
+		}
+		if name == "errors" || (name == "" && filepath.Base(path) == "errors") {
+			errorsTaken = true
+		}
+	}
+	if errorsTaken {
+		return "rpcerrors"
+	}
+	return "errors"
+}
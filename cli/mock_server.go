@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path/filepath"
+
+	"github.com/monadicstack/frodo/generate"
+	"github.com/monadicstack/frodo/parser"
+	"github.com/monadicstack/frodo/rpc"
+)
+
+// newMockGateway builds a gateway w/ an endpoint for every function in the service, but rather than calling a real
+// implementation, each one responds w/ the function's fixture (if you supplied a directory that has one) or the
+// example response that we generate for the docs. The routes, status codes, AUTH requirements, ASYNC jobs, and
+// SSE streams all behave just like the real gateway's would.
+func newMockGateway(ctx *parser.Context, fixtureDir string) rpc.Gateway {
+	gw := rpc.NewGateway(rpc.WithMiddleware(allowCORS), rpc.WithEndpointListing())
+	gw.Name = ctx.Service.Name
+	gw.PathPrefix = ctx.Service.Gateway.PathPrefix
+
+	for _, fn := range ctx.Service.Functions {
+		gw.Register(rpc.Endpoint{
+			Method:          fn.Gateway.Method,
+			Path:            fn.Gateway.Path,
+			ServiceName:     ctx.Service.Name,
+			Name:            fn.Name,
+			Status:          mockStatus(fn),
+			Auth:            rpc.AuthRequirement(fn.Gateway.Auth),
+			MaxRequestBytes: fn.Gateway.MaxRequestBytes,
+			PathConstraints: fn.Gateway.PathConstraints,
+			Handler:         mockHandler(gw, fn, fixtureDir),
+		})
+	}
+	if ctx.Service.HasAsync() {
+		gw.Register(gw.JobEndpoint())
+	}
+	gw.Register(gw.EndpointListingEndpoint())
+	return gw
+}
+
+// mockHandler responds to every call w/ the same canned response. We look for the fixture on every request, so
+// you can add/change fixtures w/o restarting the server.
+func mockHandler(gw rpc.Gateway, fn *parser.ServiceFunctionDeclaration, fixtureDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		contentType, body, err := mockResponse(fn, fixtureDir)
+		if err != nil {
+			rpc.Fail(w, req, err)
+			return
+		}
+
+		handler := func(ctx context.Context) (interface{}, error) {
+			return json.RawMessage(body), nil
+		}
+		switch {
+		case fn.Gateway.SSE:
+			gw.StreamEvents(w, req, handler)
+		case fn.Gateway.Async:
+			gw.RunAsync(w, req, handler)
+		case contentType == "application/json":
+			rpc.Reply(w, req, fn.Gateway.Status, json.RawMessage(body))
+		default:
+			w.Header().Set("Content-Type", contentType)
+			w.WriteHeader(fn.Gateway.Status)
+			_, _ = w.Write(body)
+		}
+	}
+}
+
+// mockStatus is the success status that the function's endpoint responds w/, which is always 202 for "ASYNC" ones.
+func mockStatus(fn *parser.ServiceFunctionDeclaration) int {
+	if fn.Gateway.Async {
+		return http.StatusAccepted
+	}
+	return fn.Gateway.Status
+}
+
+// mockResponse loads the content type/body of the function's fixture: the first file in the directory named
+// after the function (e.g. "GetUser.json" or "Download.pdf"). Functions w/o one respond w/ example data.
+func mockResponse(fn *parser.ServiceFunctionDeclaration, fixtureDir string) (string, []byte, error) {
+	if fixtureDir != "" {
+		fixtures, err := filepath.Glob(filepath.Join(fixtureDir, fn.Name+".*"))
+		if err != nil {
+			return "", nil, err
+		}
+		if len(fixtures) > 0 {
+			body, err := ioutil.ReadFile(fixtures[0])
+			if err != nil {
+				return "", nil, fmt.Errorf("unable to read fixture: %w", err)
+			}
+			return fixtureContentType(fixtures[0]), body, nil
+		}
+	}
+
+	if fn.Response.Implements.ContentReader || fn.Response.Implements.ContentWriter {
+		return "text/plain", []byte(fmt.Sprintf("Mock content from %s.%s", fn.Service.Name, fn.Name)), nil
+	}
+	return "application/json", generate.ExampleJSON(fn.Response), nil
+}
+
+func fixtureContentType(fileName string) string {
+	ext := filepath.Ext(fileName)
+	if ext == ".json" {
+		return "application/json"
+	}
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// allowCORS lets frontends running on some other host/port (e.g. a dev server on :3000) call the mock
+// server from the browser.
+func allowCORS(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		next(w, req)
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Allow-Credentials", "true")
+	w.Header().Add("Vary", "Origin")
+	if req.Method != http.MethodOptions {
+		next(w, req)
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Methods", req.Header.Get("Access-Control-Request-Method"))
+	w.Header().Set("Access-Control-Allow-Headers", req.Header.Get("Access-Control-Request-Headers"))
+	w.WriteHeader(http.StatusNoContent)
+}
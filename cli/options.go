@@ -31,6 +31,19 @@ func (opt templateOption) ToFileTemplate(name string) generate.FileTemplate {
 	return generate.NewCustomTemplate(name, opt.Template)
 }
 
+// routeOption can be embedded on a command request struct to give it the "--routes" option, which sets the
+// naming convention for the routes of functions that don't have a custom route.
+type routeOption struct {
+	// Routes is the naming convention (e.g. "kebab-case") or custom template (e.g. "/rpc/{service}/{function}")
+	// for functions w/o a custom route. A service's "ROUTES" doc option wins over this.
+	Routes string
+}
+
+// ParseFile parses the service definition file using this command's route naming convention.
+func (opt routeOption) ParseFile(inputFileName string) (*parser.Context, error) {
+	return parser.ParseFile(inputFileName, parser.WithRouteNaming(opt.Routes))
+}
+
 // crapPants is a catch-all handler for dealing with errors parsing code files and generating artifacts. It
 // tries to give helpful, descriptive error messages that instruct the user how to address the issue in addition
 // to notifying them about the failure.
@@ -53,6 +66,11 @@ func crapPants(err error) {
 		log.Println("  * It's usually more idiomatic to have one service per package (e.g. 'UserService'")
 		log.Println("    goes in the 'users' package and 'OrderService' in the 'orders' package)")
 		log.Println("")
+	case errors.Is(err, parser.ErrInvalidRouteNaming):
+		log.Println("")
+		log.Println("  * Use one of the standard conventions: 'default', 'kebab-case', 'snake_case', or 'lowerCamel'")
+		log.Println("  * Or use a custom template like '/rpc/{service:kebab}/{function:camel}'")
+		log.Println("")
 	case errors.Is(err, parser.ErrMissingGoMod):
 		log.Println("")
 		log.Println("  * Frodo only works with projects that use go modules")
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/monadicstack/frodo/generate"
+	"github.com/monadicstack/frodo/parser"
+	"github.com/spf13/cobra"
+)
+
+// The files that "frodo publish" pushes to the schema registry for each version of a service.
+const (
+	// registryDescriptorFile is the standalone Go file w/ the service interface and its models.
+	registryDescriptorFile = "descriptor.go"
+	// registryOpenAPIFile is the service's OpenAPI documentation.
+	registryOpenAPIFile = "openapi.yml"
+	// registryLatestVersion is the "version" that always contains the most recently published descriptor.
+	registryLatestVersion = "latest"
+)
+
+// PublishRequest contains all of the CLI options used in the "frodo publish" command.
+type PublishRequest struct {
+	// InputFileName is the service definition to parse/process.
+	InputFileName string
+	// Registry is the base URL of the schema registry (the "--registry" option).
+	Registry string
+	// Token (optional) is the bearer token we use to authenticate w/ the registry (the "--token" option). It
+	// defaults to the FRODO_REGISTRY_TOKEN environment variable.
+	Token string
+	// Latest indicates that we should also publish this version as the "latest" one (the "--latest" option).
+	Latest bool
+}
+
+// Publish handles the registration and execution of the 'frodo publish' CLI subcommand.
+type Publish struct{}
+
+// Command creates the Cobra struct describing this CLI command and its options.
+func (c Publish) Command() *cobra.Command {
+	request := &PublishRequest{}
+	cmd := &cobra.Command{
+		Use:   "publish [flags] FILENAME",
+		Short: "Pushes your service's contract (and OpenAPI docs) to a schema registry so others can generate clients w/o your source code.",
+		Long:  "This pushes a standalone Go file containing your service interface and its models (the 'descriptor') along w/ your OpenAPI docs to a schema registry. They're stored by the service's VERSION doc option: PUT {registry}/{service}/{version}/descriptor.go and PUT {registry}/{service}/{version}/openapi.yml. Any HTTP server that stores what you PUT and serves it back on GET will do (e.g. an artifact repository or a WebDAV/S3-style bucket). Other teams can then use 'frodo pull' to generate a client from the descriptor.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			request.InputFileName = args[0]
+			crapPants(c.Exec(request))
+		},
+	}
+	cmd.Flags().StringVar(&request.Registry, "registry", "", "The base URL of the schema registry (e.g. 'https://schemas.acme.com/frodo').")
+	cmd.Flags().StringVar(&request.Token, "token", os.Getenv("FRODO_REGISTRY_TOKEN"), "The bearer token used to authenticate w/ the registry (defaults to $FRODO_REGISTRY_TOKEN).")
+	cmd.Flags().BoolVar(&request.Latest, "latest", true, "Also publish this version as the 'latest' one.")
+	return cmd
+}
+
+// Exec takes all of the parsed CLI flags and pushes the service's descriptor/docs to the registry.
+func (c Publish) Exec(request *PublishRequest) error {
+	if request.Registry == "" {
+		return fmt.Errorf("the --registry option is required")
+	}
+
+	log.Printf("Parsing service definitions: %s", request.InputFileName)
+	ctx, err := parser.ParseFile(request.InputFileName)
+	if err != nil {
+		return err
+	}
+	if ctx.Service.Version == "" {
+		return fmt.Errorf("%s needs a VERSION doc option before you can publish it", ctx.Service.Name)
+	}
+
+	log.Printf("Generating descriptor for %s %s", ctx.Service.Name, ctx.Service.Version)
+	descriptor, err := generate.Descriptor(ctx)
+	if err != nil {
+		return err
+	}
+	_, openAPI, err := generate.Render(ctx, templateOption{}.ToFileTemplate("openapi.yml"))
+	if err != nil {
+		return err
+	}
+
+	versions := []string{ctx.Service.Version}
+	if request.Latest {
+		versions = append(versions, registryLatestVersion)
+	}
+	registry := registryClient{baseURL: request.Registry, token: request.Token}
+	for _, version := range versions {
+		if err = registry.put(ctx.Service.Name, version, registryDescriptorFile, descriptor); err != nil {
+			return err
+		}
+		if err = registry.put(ctx.Service.Name, version, registryOpenAPIFile, openAPI); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registryClient pushes/fetches the files for each version of a service to/from the schema registry.
+type registryClient struct {
+	baseURL string
+	token   string
+}
+
+// url returns the address of the registry file for that version of the service.
+func (registry registryClient) url(service string, version string, file string) string {
+	return strings.TrimSuffix(registry.baseURL, "/") + "/" + url.PathEscape(service) + "/" + url.PathEscape(version) + "/" + file
+}
+
+// put uploads the file for that version of the service.
+func (registry registryClient) put(service string, version string, file string, data []byte) error {
+	address := registry.url(service, version, file)
+	log.Printf("Publishing %s", address)
+	response, err := registry.do(http.MethodPut, address, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	_ = response.Body.Close()
+	return nil
+}
+
+// get downloads the file for that version of the service.
+func (registry registryClient) get(service string, version string, file string) ([]byte, error) {
+	address := registry.url(service, version, file)
+	log.Printf("Fetching %s", address)
+	response, err := registry.do(http.MethodGet, address, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	return ioutil.ReadAll(response.Body)
+}
+
+// do sends the request to the registry, failing on any non-2xx status.
+func (registry registryClient) do(method string, address string, body io.Reader) (*http.Response, error) {
+	request, err := http.NewRequest(method, address, body)
+	if err != nil {
+		return nil, err
+	}
+	if registry.token != "" {
+		request.Header.Set("Authorization", "Bearer "+registry.token)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("schema registry error: %w", err)
+	}
+	if response.StatusCode >= 300 {
+		defer response.Body.Close()
+		message, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+		return nil, fmt.Errorf("schema registry error: %s %s: %s: %s", method, address, response.Status, strings.TrimSpace(string(message)))
+	}
+	return response, nil
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/monadicstack/frodo/internal/naming"
+	"github.com/spf13/cobra"
+)
+
+// PullRequest contains all of the CLI options used in the "frodo pull" command.
+type PullRequest struct {
+	// Service is the name of the service to fetch, optionally w/ the version (e.g. "UserService@1.2.0").
+	Service string
+	// Registry is the base URL of the schema registry (the "--registry" option).
+	Registry string
+	// Token (optional) is the bearer token we use to authenticate w/ the registry (the "--token" option). It
+	// defaults to the FRODO_REGISTRY_TOKEN environment variable.
+	Token string
+	// Directory is where we write the descriptor (the "--dir" option). It defaults to a directory named after
+	// the descriptor's package.
+	Directory string
+	// Language is the programming language for the client to generate (the "--language" option).
+	Language string
+}
+
+// Pull handles the registration and execution of the 'frodo pull' CLI subcommand.
+type Pull struct{}
+
+// Command creates the Cobra struct describing this CLI command and its options.
+func (c Pull) Command() *cobra.Command {
+	request := &PullRequest{}
+	cmd := &cobra.Command{
+		Use:   "pull [flags] SERVICE[@VERSION]",
+		Short: "Fetches a service's descriptor from a schema registry and generates a client for it.",
+		Long:  "This fetches the descriptor that 'frodo publish' pushed to the schema registry (the 'latest' one unless you ask for a specific version, e.g. 'UserService@1.2.0'), writes it to a package in your module, and generates a client from it just like 'frodo client' would. You never need the service's actual source code.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			request.Service = args[0]
+			crapPants(c.Exec(request))
+		},
+	}
+	cmd.Flags().StringVar(&request.Registry, "registry", "", "The base URL of the schema registry (e.g. 'https://schemas.acme.com/frodo').")
+	cmd.Flags().StringVar(&request.Token, "token", os.Getenv("FRODO_REGISTRY_TOKEN"), "The bearer token used to authenticate w/ the registry (defaults to $FRODO_REGISTRY_TOKEN).")
+	cmd.Flags().StringVar(&request.Directory, "dir", "", "The directory where we write the descriptor (defaults to the name of its package).")
+	cmd.Flags().StringVar(&request.Language, "language", "go", "The file extension of the target language (e.g. 'go' or 'js')")
+	return cmd
+}
+
+// Exec takes all of the parsed CLI flags, fetches the descriptor, and generates the client for it.
+func (c Pull) Exec(request *PullRequest) error {
+	if request.Registry == "" {
+		return fmt.Errorf("the --registry option is required")
+	}
+
+	service, version := request.Service, registryLatestVersion
+	if at := strings.LastIndex(service, "@"); at >= 0 {
+		service, version = service[:at], service[at+1:]
+	}
+
+	registry := registryClient{baseURL: request.Registry, token: request.Token}
+	descriptor, err := registry.get(service, version, registryDescriptorFile)
+	if err != nil {
+		return err
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), registryDescriptorFile, descriptor, parser.PackageClauseOnly)
+	if err != nil {
+		return fmt.Errorf("invalid descriptor for %s: %w", request.Service, err)
+	}
+	dir := request.Directory
+	if dir == "" {
+		dir = file.Name.Name
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// Don't clobber the actual service definition if you happen to pull into its package.
+	inputFileName := filepath.Join(dir, naming.ToSnakeCase(service)+".go")
+	if existing, err := ioutil.ReadFile(inputFileName); err == nil && !bytes.HasPrefix(existing, []byte("// Code generated by Frodo")) {
+		return fmt.Errorf("unable to write descriptor: %s already exists and wasn't generated by frodo", inputFileName)
+	}
+	log.Printf("Writing descriptor: %s", inputFileName)
+	if err = ioutil.WriteFile(inputFileName, descriptor, 0644); err != nil {
+		return err
+	}
+	return GenerateClient{}.Exec(&GenerateClientRequest{
+		InputFileName: inputFileName,
+		Language:      request.Language,
+	})
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/monadicstack/frodo/generate"
+	"github.com/monadicstack/frodo/parser"
+	"github.com/spf13/cobra"
+)
+
+// TestTemplatesRequest contains all of the CLI options used in the "frodo test-templates" command.
+type TestTemplatesRequest struct {
+	templateOption
+	// InputFileNames are the service definitions that we render the template for (the fixtures).
+	InputFileNames []string
+	// Name is the name of the artifact the template generates (e.g. "client.ts"). This determines the name of the
+	// golden files and whether we run the output through "go fmt". The default is the template's file name w/o
+	// the ".tmpl" extension.
+	Name string
+	// GoldenDir is the directory where the expected output for each fixture lives (the "--golden" option).
+	GoldenDir string
+	// Update indicates that we should (re)write the golden files w/ the current output rather than compare them.
+	Update bool
+}
+
+// TestTemplates handles the registration and execution of the 'frodo test-templates' CLI subcommand.
+type TestTemplates struct{}
+
+// Command creates the Cobra struct describing this CLI command and its options.
+func (c TestTemplates) Command() *cobra.Command {
+	request := &TestTemplatesRequest{}
+	cmd := &cobra.Command{
+		Use:   "test-templates [flags] FILENAME...",
+		Short: "Snapshot tests your custom template against golden files for some fixture services.",
+		Long:  "This renders your custom template for each of the service definitions you supply (your fixtures) and compares the output to the golden files in the '--golden' directory, exiting w/ a non-zero status if any of them are different. Use it in CI to find out when a new version of frodo changes the data your template sees. Once you're happy w/ the output, run it again w/ '--update' to record the new golden files. The timestamp/version in the generated header don't count as differences.",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			request.InputFileNames = args
+			crapPants(c.Exec(request))
+		},
+	}
+	cmd.Flags().StringVar(&request.Template, "template", "", "Path to the custom Go template file you want to test.")
+	cmd.Flags().StringVar(&request.Name, "name", "", "The artifact the template generates (e.g. 'client.ts'). Defaults to the template's file name w/o '.tmpl'.")
+	cmd.Flags().StringVar(&request.GoldenDir, "golden", "testdata/golden", "The directory containing the expected output for each service.")
+	cmd.Flags().BoolVar(&request.Update, "update", false, "Write the current output to the golden files rather than comparing them.")
+	_ = cmd.MarkFlagRequired("template")
+	return cmd
+}
+
+// Exec renders the template for every fixture and compares the output to the golden files (or updates them).
+func (c TestTemplates) Exec(request *TestTemplatesRequest) error {
+	name := request.Name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(request.Template), ".tmpl")
+	}
+	artifact := request.ToFileTemplate(name)
+
+	failures := 0
+	for _, inputFileName := range request.InputFileNames {
+		log.Printf("Parsing service definitions: %s", inputFileName)
+		ctx, err := parser.ParseFile(inputFileName)
+		if err != nil {
+			return err
+		}
+
+		result, err := generate.Snapshot(ctx, artifact, request.GoldenDir, request.Update)
+		switch {
+		case err != nil:
+			return err
+		case request.Update:
+			log.Printf("Updated golden file: %s", result.GoldenPath)
+		case result.Match:
+			log.Printf("PASS: %s", result.GoldenPath)
+		default:
+			log.Printf("FAIL: %s: %s", result.GoldenPath, result.Diff)
+			failures++
+		}
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d snapshot(s) don't match; run w/ --update if the new output is correct", failures)
+	}
+	return nil
+}
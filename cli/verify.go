@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/monadicstack/frodo/generate"
+	"github.com/monadicstack/frodo/parser"
+	"github.com/spf13/cobra"
+)
+
+// VerifyRequest contains all of the CLI options used in the "frodo verify" command.
+type VerifyRequest struct {
+	routeOption
+	// ConfigFileName is the path to the config file describing everything to verify (the "--config" option).
+	ConfigFileName string
+	// InputFileNames are the service definitions to verify. When this is empty, we verify everything in the config.
+	InputFileNames []string
+}
+
+// Verify handles the registration and execution of the 'frodo verify' CLI subcommand.
+type Verify struct{}
+
+// Command creates the Cobra struct describing this CLI command and its options.
+func (c Verify) Command() *cobra.Command {
+	request := &VerifyRequest{}
+	cmd := &cobra.Command{
+		Use:   "verify [flags] [FILENAME...]",
+		Short: "Fails if any of your generated artifacts are out of date w/ their service definitions.",
+		Long:  "This re-generates your artifacts in memory and compares them to the ones on disk, exiting w/ a non-zero status if any are missing or stale. It doesn't write anything, so you can use it in CI to make sure that nobody forgot to re-run frodo. When you supply service definition files, it verifies the artifacts that already exist in their 'gen/' directories using the standard templates. Otherwise, it verifies everything described in your 'frodo.yaml' file.",
+		Run: func(cmd *cobra.Command, args []string) {
+			request.InputFileNames = args
+			crapPants(c.Exec(request))
+		},
+	}
+	cmd.Flags().StringVar(&request.ConfigFileName, "config", "frodo.yaml", "Path to the config file describing the services/artifacts to verify.")
+	cmd.Flags().StringVar(&request.Routes, "routes", "", "When verifying specific files, the route naming convention you generated them w/ (see 'frodo gateway --help'). The config file has its own 'routes' setting.")
+	return cmd
+}
+
+// Exec re-generates each artifact in memory and fails if any of them don't match what's on disk.
+func (c Verify) Exec(request *VerifyRequest) error {
+	// Artifacts from the config file are verified in parallel, so guard the list of stale ones.
+	var stale []string
+	staleMutex := sync.Mutex{}
+	verify := func(ctx *parser.Context, artifact generate.FileTemplate) error {
+		upToDate, err := generate.UpToDate(ctx, artifact)
+		if err != nil {
+			return err
+		}
+		if !upToDate {
+			outputPath := generate.OutputPath(ctx, artifact)
+			log.Printf("Stale artifact: %s", outputPath)
+
+			staleMutex.Lock()
+			stale = append(stale, outputPath)
+			staleMutex.Unlock()
+		}
+		return nil
+	}
+
+	var err error
+	if len(request.InputFileNames) == 0 {
+		err = forEachArtifact(request.ConfigFileName, runtime.NumCPU(), verify)
+	} else {
+		err = c.verifyExisting(request.routeOption, request.InputFileNames, verify)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(stale) > 0 {
+		return fmt.Errorf("%d generated artifact(s) are out of date; re-run frodo to update them", len(stale))
+	}
+	log.Printf("All generated artifacts are up to date")
+	return nil
+}
+
+// verifyExisting parses each service definition and verifies the artifacts already in its output directory. We
+// figure out which template to use from the artifact's file name (e.g. "user_service.gen.client.js" uses the
+// standard "client.js" template).
+func (c Verify) verifyExisting(routes routeOption, inputFileNames []string, verify func(*parser.Context, generate.FileTemplate) error) error {
+	for _, inputFileName := range inputFileNames {
+		log.Printf("Parsing service definitions: %s", inputFileName)
+		ctx, err := routes.ParseFile(inputFileName)
+		if err != nil {
+			return err
+		}
+
+		prefix := strings.TrimSuffix(filepath.Base(inputFileName), ".go") + ".gen."
+		outputPaths, err := filepath.Glob(filepath.Join(ctx.OutputPackage.Directory, prefix+"*"))
+		if err != nil {
+			return err
+		}
+
+		var artifacts []generate.FileTemplate
+		for _, outputPath := range outputPaths {
+			name := strings.TrimPrefix(filepath.Base(outputPath), prefix)
+			artifact := templateOption{}.ToFileTemplate(name)
+			if _, err := fs.Stat(artifact.FileSystem, artifact.Path); err != nil {
+				return fmt.Errorf("unable to verify %s: no standard template for '%s' (use frodo.yaml for custom templates)", outputPath, name)
+			}
+			artifacts = append(artifacts, artifact)
+		}
+
+		// Java artifacts are named after their class (e.g. "UserServiceClient.java"), so look for those separately.
+		for _, name := range []string{"client.java", "reactor.java", "ok-http-engine.java"} {
+			artifact := templateOption{}.ToFileTemplate(name)
+			if _, err := os.Stat(generate.OutputPath(ctx, artifact)); err == nil {
+				artifacts = append(artifacts, artifact)
+			}
+		}
+
+		if len(artifacts) == 0 {
+			return fmt.Errorf("no generated artifacts found for %s in %s", inputFileName, ctx.OutputPackage.Directory)
+		}
+		for _, artifact := range artifacts {
+			if err := verify(ctx, artifact); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
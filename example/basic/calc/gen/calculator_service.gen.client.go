@@ -1,7 +1,9 @@
 // Code generated by Frodo - DO NOT EDIT.
 //
-//   Timestamp: Tue, 10 May 2022 16:23:56 EDT
+//   Timestamp: Fri, 16 Oct 2026 14:47:44 UTC
 //   Source:    calc/calculator_service.go
+//   Checksum:  sha256:9523134345525fb9bafa4c880bdd3bb3e73ddcd535757959365c7ecdf6c3a4f0
+//   Version:   devel
 //   Generator: https://github.com/monadicstack/frodo
 //
 package calc
@@ -32,6 +34,39 @@ type CalculatorServiceClient struct {
 	rpc.Client
 }
 
+// CalculatorServiceClientInterface is everything that you can do w/ a CalculatorServiceClient: the CalculatorService functions
+// plus the extras that only make sense when you're calling a remote instance. Depend on this (or just CalculatorService
+// if you only call its functions) rather than *CalculatorServiceClient, so that you can inject a mock/fake in your tests.
+type CalculatorServiceClientInterface interface {
+	calc.CalculatorService
+	Batch() *CalculatorServiceBatch
+}
+
+// CalculatorServiceClientAddress is the base address of the remote CalculatorService gateway (e.g. "http://localhost:8080").
+// Dependency injection frameworks like wire, fx, and dig look up dependencies by type, so this keeps the address
+// from getting mixed up w/ every other string in your app.
+type CalculatorServiceClientAddress string
+
+// ProvideCalculatorServiceClient creates the client for dependency injection frameworks like wire, fx, and dig using the
+// address that you provide elsewhere:
+//
+//	fx.Provide(
+//	    func() calc.CalculatorServiceClientAddress { return "http://localhost:8080" },
+//	    calc.ProvideCalculatorServiceClient,
+//	    calc.ProvideCalculatorService,
+//	)
+//
+// If you need any client options, write your own provider that calls NewCalculatorServiceClient() instead.
+func ProvideCalculatorServiceClient(address CalculatorServiceClientAddress) CalculatorServiceClientInterface {
+	return NewCalculatorServiceClient(string(address))
+}
+
+// ProvideCalculatorService exposes the client as a plain CalculatorService for dependency injection frameworks, so
+// the code that uses it doesn't know (or care) whether it's calling a remote instance or a local one.
+func ProvideCalculatorService(client CalculatorServiceClientInterface) calc.CalculatorService {
+	return client
+}
+
 // Add accepts two integers and returns a result w/ their sum.
 func (client *CalculatorServiceClient) Add(ctx context.Context, request *calc.AddRequest) (*calc.AddResponse, error) {
 	if ctx == nil {
@@ -60,6 +95,28 @@ func (client *CalculatorServiceClient) Sub(ctx context.Context, request *calc.Su
 	return response, err
 }
 
+// Batch starts a set of CalculatorService calls that you can send to the gateway in a single round trip. The
+// gateway must enable batching using rpc.WithBatch().
+func (client *CalculatorServiceClient) Batch() *CalculatorServiceBatch {
+	return &CalculatorServiceBatch{Batch: client.Client.Batch()}
+}
+
+// CalculatorServiceBatch collects CalculatorService calls so that you can make them all at once using Send(). Each
+// response is populated (or the call's Err is set) once Send() finishes.
+type CalculatorServiceBatch struct {
+	*rpc.Batch
+}
+
+// Add adds a call to CalculatorService.Add to the batch.
+func (batch *CalculatorServiceBatch) Add(request *calc.AddRequest, response *calc.AddResponse) *rpc.BatchCall {
+	return batch.Batch.Add("CalculatorService", "Add", request, response)
+}
+
+// Sub adds a call to CalculatorService.Sub to the batch.
+func (batch *CalculatorServiceBatch) Sub(request *calc.SubRequest, response *calc.SubResponse) *rpc.BatchCall {
+	return batch.Batch.Add("CalculatorService", "Sub", request, response)
+}
+
 // CalculatorServiceProxy fully implements the CalculatorService interface, but delegates all operations to a "real"
 // instance of the service. You can embed this type in a struct of your choice so you can "override" or
 // decorate operations as you see fit. Any operations on CalculatorService that you don't explicitly define will
@@ -1,7 +1,9 @@
 // Code generated by Frodo - DO NOT EDIT.
 //
-//   Timestamp: Tue, 10 May 2022 16:23:56 EDT
+//   Timestamp: Fri, 16 Oct 2026 18:55:05 UTC
 //   Source:    calc/calculator_service.go
+//   Checksum:  sha256:9523134345525fb9bafa4c880bdd3bb3e73ddcd535757959365c7ecdf6c3a4f0
+//   Version:   devel
 //   Generator: https://github.com/monadicstack/frodo
 //
 package calc
@@ -12,7 +14,7 @@ import (
 
 	"github.com/monadicstack/frodo/example/basic/calc"
 	"github.com/monadicstack/frodo/rpc"
-	"github.com/monadicstack/respond"
+	"github.com/monadicstack/frodo/rpc/events"
 )
 
 // NewCalculatorServiceGateway accepts your "real" CalculatorService instance (the thing that really does the work), and
@@ -36,17 +38,38 @@ func NewCalculatorServiceGateway(service calc.CalculatorService, options ...rpc.
 		Path:        "/CalculatorService.Add",
 		ServiceName: "CalculatorService",
 		Name:        "Add",
+		Status:      200,
 		Handler: func(w http.ResponseWriter, req *http.Request) {
-			response := respond.To(w, req)
-
 			serviceRequest := calc.AddRequest{}
 			if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
-				response.Fail(err)
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := rpc.Normalize(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := gw.OnRequestBound(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
 				return
 			}
 
 			serviceResponse, err := service.Add(req.Context(), &serviceRequest)
-			response.Reply(200, serviceResponse, err)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := events.Flush(req.Context()); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Redact(req.Context(), serviceResponse)
+			response, err := gw.OnResponseMarshal(req.Context(), serviceResponse)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Reply(w, req, 200, response)
 		},
 	})
 
@@ -55,20 +78,51 @@ func NewCalculatorServiceGateway(service calc.CalculatorService, options ...rpc.
 		Path:        "/CalculatorService.Sub",
 		ServiceName: "CalculatorService",
 		Name:        "Sub",
+		Status:      200,
 		Handler: func(w http.ResponseWriter, req *http.Request) {
-			response := respond.To(w, req)
-
 			serviceRequest := calc.SubRequest{}
 			if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
-				response.Fail(err)
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := rpc.Normalize(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := gw.OnRequestBound(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
 				return
 			}
 
 			serviceResponse, err := service.Sub(req.Context(), &serviceRequest)
-			response.Reply(200, serviceResponse, err)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := events.Flush(req.Context()); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Redact(req.Context(), serviceResponse)
+			response, err := gw.OnResponseMarshal(req.Context(), serviceResponse)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Reply(w, req, 200, response)
 		},
 	})
 
+	if gw.BatchConcurrency > 0 {
+		gw.Register(gw.BatchEndpoint())
+	}
+	if gw.JSONRPCConcurrency > 0 {
+		gw.Register(gw.JSONRPCEndpoint())
+	}
+	if gw.EndpointListing {
+		gw.Register(gw.EndpointListingEndpoint())
+	}
+
 	return CalculatorServiceGateway{Gateway: gw, service: service}
 }
 
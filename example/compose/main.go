@@ -60,11 +60,12 @@ func startGateways() {
 	}
 
 	// Combine the gateways for all of our services into a single gateway that routes requests to all of them.
-	gateway := rpc.Compose(
+	gateway, err := rpc.Compose(
 		calcrpc.NewCalculatorServiceGateway(&calcService).Gateway,
 		scoresrpc.NewScoreServiceGateway(&scoreService).Gateway,
 		gamesrpc.NewGameServiceGateway(&gameService).Gateway,
 	)
+	exitOnError(err)
 	http.ListenAndServe(":9004", gateway)
 }
 
@@ -5,12 +5,19 @@ import (
 
 	"github.com/monadicstack/frodo/example/multiservice/games"
 	gamesrpc "github.com/monadicstack/frodo/example/multiservice/games/gen"
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/ids"
+	"github.com/monadicstack/frodo/rpc/operation"
 )
 
 func main() {
 	serviceHandler := games.GameServiceHandler{
 		Repo: games.NewRepo(),
 	}
-	gateway := gamesrpc.NewGameServiceGateway(&serviceHandler)
+	// Tag every request w/ an operation id. Since the score service calls the game service, both
+	// services will log/report the same id for a single high score request.
+	gateway := gamesrpc.NewGameServiceGateway(&serviceHandler,
+		rpc.WithMiddleware(operation.Middleware(ids.New)),
+	)
 	http.ListenAndServe(":9001", gateway)
 }
@@ -1,7 +1,9 @@
 // Code generated by Frodo - DO NOT EDIT.
 //
-//   Timestamp: Tue, 10 May 2022 16:25:34 EDT
+//   Timestamp: Fri, 16 Oct 2026 14:47:44 UTC
 //   Source:    games/game_service.go
+//   Checksum:  sha256:7a9f6bea3246e2bac8d0b4d7bee4b7c6338913e703fc13dfab714fe2b1c1f32d
+//   Version:   devel
 //   Generator: https://github.com/monadicstack/frodo
 //
 package games
@@ -32,6 +34,39 @@ type GameServiceClient struct {
 	rpc.Client
 }
 
+// GameServiceClientInterface is everything that you can do w/ a GameServiceClient: the GameService functions
+// plus the extras that only make sense when you're calling a remote instance. Depend on this (or just GameService
+// if you only call its functions) rather than *GameServiceClient, so that you can inject a mock/fake in your tests.
+type GameServiceClientInterface interface {
+	games.GameService
+	Batch() *GameServiceBatch
+}
+
+// GameServiceClientAddress is the base address of the remote GameService gateway (e.g. "http://localhost:8080").
+// Dependency injection frameworks like wire, fx, and dig look up dependencies by type, so this keeps the address
+// from getting mixed up w/ every other string in your app.
+type GameServiceClientAddress string
+
+// ProvideGameServiceClient creates the client for dependency injection frameworks like wire, fx, and dig using the
+// address that you provide elsewhere:
+//
+//	fx.Provide(
+//	    func() games.GameServiceClientAddress { return "http://localhost:8080" },
+//	    games.ProvideGameServiceClient,
+//	    games.ProvideGameService,
+//	)
+//
+// If you need any client options, write your own provider that calls NewGameServiceClient() instead.
+func ProvideGameServiceClient(address GameServiceClientAddress) GameServiceClientInterface {
+	return NewGameServiceClient(string(address))
+}
+
+// ProvideGameService exposes the client as a plain GameService for dependency injection frameworks, so
+// the code that uses it doesn't know (or care) whether it's calling a remote instance or a local one.
+func ProvideGameService(client GameServiceClientInterface) games.GameService {
+	return client
+}
+
 // GetByID looks up a game record given its unique id.
 func (client *GameServiceClient) GetByID(ctx context.Context, request *games.GetByIDRequest) (*games.GetByIDResponse, error) {
 	if ctx == nil {
@@ -60,6 +95,28 @@ func (client *GameServiceClient) Register(ctx context.Context, request *games.Re
 	return response, err
 }
 
+// Batch starts a set of GameService calls that you can send to the gateway in a single round trip. The
+// gateway must enable batching using rpc.WithBatch().
+func (client *GameServiceClient) Batch() *GameServiceBatch {
+	return &GameServiceBatch{Batch: client.Client.Batch()}
+}
+
+// GameServiceBatch collects GameService calls so that you can make them all at once using Send(). Each
+// response is populated (or the call's Err is set) once Send() finishes.
+type GameServiceBatch struct {
+	*rpc.Batch
+}
+
+// GetByID adds a call to GameService.GetByID to the batch.
+func (batch *GameServiceBatch) GetByID(request *games.GetByIDRequest, response *games.GetByIDResponse) *rpc.BatchCall {
+	return batch.Batch.Add("GameService", "GetByID", request, response)
+}
+
+// Register adds a call to GameService.Register to the batch.
+func (batch *GameServiceBatch) Register(request *games.RegisterRequest, response *games.RegisterResponse) *rpc.BatchCall {
+	return batch.Batch.Add("GameService", "Register", request, response)
+}
+
 // GameServiceProxy fully implements the GameService interface, but delegates all operations to a "real"
 // instance of the service. You can embed this type in a struct of your choice so you can "override" or
 // decorate operations as you see fit. Any operations on GameService that you don't explicitly define will
@@ -1,7 +1,9 @@
 // Code generated by Frodo - DO NOT EDIT.
 //
-//   Timestamp: Tue, 10 May 2022 16:25:33 EDT
+//   Timestamp: Fri, 16 Oct 2026 18:55:06 UTC
 //   Source:    games/game_service.go
+//   Checksum:  sha256:7a9f6bea3246e2bac8d0b4d7bee4b7c6338913e703fc13dfab714fe2b1c1f32d
+//   Version:   devel
 //   Generator: https://github.com/monadicstack/frodo
 //
 package games
@@ -12,7 +14,7 @@ import (
 
 	"github.com/monadicstack/frodo/example/multiservice/games"
 	"github.com/monadicstack/frodo/rpc"
-	"github.com/monadicstack/respond"
+	"github.com/monadicstack/frodo/rpc/events"
 )
 
 // NewGameServiceGateway accepts your "real" GameService instance (the thing that really does the work), and
@@ -36,17 +38,38 @@ func NewGameServiceGateway(service games.GameService, options ...rpc.GatewayOpti
 		Path:        "/game/:ID",
 		ServiceName: "GameService",
 		Name:        "GetByID",
+		Status:      200,
 		Handler: func(w http.ResponseWriter, req *http.Request) {
-			response := respond.To(w, req)
-
 			serviceRequest := games.GetByIDRequest{}
 			if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
-				response.Fail(err)
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := rpc.Normalize(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := gw.OnRequestBound(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
 				return
 			}
 
 			serviceResponse, err := service.GetByID(req.Context(), &serviceRequest)
-			response.Reply(200, serviceResponse, err)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := events.Flush(req.Context()); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Redact(req.Context(), serviceResponse)
+			response, err := gw.OnResponseMarshal(req.Context(), serviceResponse)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Reply(w, req, 200, response)
 		},
 	})
 
@@ -55,20 +78,51 @@ func NewGameServiceGateway(service games.GameService, options ...rpc.GatewayOpti
 		Path:        "/game",
 		ServiceName: "GameService",
 		Name:        "Register",
+		Status:      201,
 		Handler: func(w http.ResponseWriter, req *http.Request) {
-			response := respond.To(w, req)
-
 			serviceRequest := games.RegisterRequest{}
 			if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
-				response.Fail(err)
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := rpc.Normalize(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := gw.OnRequestBound(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
 				return
 			}
 
 			serviceResponse, err := service.Register(req.Context(), &serviceRequest)
-			response.Reply(201, serviceResponse, err)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := events.Flush(req.Context()); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Redact(req.Context(), serviceResponse)
+			response, err := gw.OnResponseMarshal(req.Context(), serviceResponse)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Reply(w, req, 201, response)
 		},
 	})
 
+	if gw.BatchConcurrency > 0 {
+		gw.Register(gw.BatchEndpoint())
+	}
+	if gw.JSONRPCConcurrency > 0 {
+		gw.Register(gw.JSONRPCEndpoint())
+	}
+	if gw.EndpointListing {
+		gw.Register(gw.EndpointListingEndpoint())
+	}
+
 	return GameServiceGateway{Gateway: gw, service: service}
 }
 
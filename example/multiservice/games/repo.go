@@ -2,10 +2,9 @@ package games
 
 import (
 	"context"
-	"fmt"
-	"time"
 
 	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/ids"
 )
 
 // Repo manages access to the data store where we keep game catalog data.
@@ -67,10 +66,8 @@ func (m *mockRepo) Create(_ context.Context, game Game) (Game, error) {
 		return game, errors.BadRequest("create: name is required")
 	}
 
-	// I realize this is *terrible* client-side ID generation, but that's not really the purpose of this
-	// example. It's more about showing you how to wire up your services and less about the finder details of
-	// interacting with your data store.
-	game.ID = fmt.Sprintf("%v", time.Now().UnixNano())
+	// Time-ordered ids (UUIDv7) play nicely w/ database indexes, but any unique id would do here.
+	game.ID = ids.New()
 	m.Games = append(m.Games, game)
 	return game, nil
 }
@@ -6,6 +6,9 @@ import (
 	games "github.com/monadicstack/frodo/example/multiservice/games/gen"
 	"github.com/monadicstack/frodo/example/multiservice/scores"
 	scoresrpc "github.com/monadicstack/frodo/example/multiservice/scores/gen"
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/ids"
+	"github.com/monadicstack/frodo/rpc/operation"
 )
 
 func main() {
@@ -13,6 +16,10 @@ func main() {
 		Games: games.NewGameServiceClient("http://localhost:9001"),
 		Repo:  scores.NewRepo(),
 	}
-	gateway := scoresrpc.NewScoreServiceGateway(&serviceHandler)
+	// Tag every request w/ an operation id. Since the score service calls the game service, both
+	// services will log/report the same id for a single high score request.
+	gateway := scoresrpc.NewScoreServiceGateway(&serviceHandler,
+		rpc.WithMiddleware(operation.Middleware(ids.New)),
+	)
 	http.ListenAndServe(":9002", gateway)
 }
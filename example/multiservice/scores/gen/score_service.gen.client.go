@@ -1,7 +1,9 @@
 // Code generated by Frodo - DO NOT EDIT.
 //
-//   Timestamp: Tue, 10 May 2022 16:25:35 EDT
+//   Timestamp: Fri, 16 Oct 2026 14:47:45 UTC
 //   Source:    scores/score_service.go
+//   Checksum:  sha256:993db175e833dc42d3ae846b29022ed7a183ca9f19c724d073889d65ec2ce1ca
+//   Version:   devel
 //   Generator: https://github.com/monadicstack/frodo
 //
 package scores
@@ -33,6 +35,39 @@ type ScoreServiceClient struct {
 	rpc.Client
 }
 
+// ScoreServiceClientInterface is everything that you can do w/ a ScoreServiceClient: the ScoreService functions
+// plus the extras that only make sense when you're calling a remote instance. Depend on this (or just ScoreService
+// if you only call its functions) rather than *ScoreServiceClient, so that you can inject a mock/fake in your tests.
+type ScoreServiceClientInterface interface {
+	scores.ScoreService
+	Batch() *ScoreServiceBatch
+}
+
+// ScoreServiceClientAddress is the base address of the remote ScoreService gateway (e.g. "http://localhost:8080").
+// Dependency injection frameworks like wire, fx, and dig look up dependencies by type, so this keeps the address
+// from getting mixed up w/ every other string in your app.
+type ScoreServiceClientAddress string
+
+// ProvideScoreServiceClient creates the client for dependency injection frameworks like wire, fx, and dig using the
+// address that you provide elsewhere:
+//
+//	fx.Provide(
+//	    func() scores.ScoreServiceClientAddress { return "http://localhost:8080" },
+//	    scores.ProvideScoreServiceClient,
+//	    scores.ProvideScoreService,
+//	)
+//
+// If you need any client options, write your own provider that calls NewScoreServiceClient() instead.
+func ProvideScoreServiceClient(address ScoreServiceClientAddress) ScoreServiceClientInterface {
+	return NewScoreServiceClient(string(address))
+}
+
+// ProvideScoreService exposes the client as a plain ScoreService for dependency injection frameworks, so
+// the code that uses it doesn't know (or care) whether it's calling a remote instance or a local one.
+func ProvideScoreService(client ScoreServiceClientInterface) scores.ScoreService {
+	return client
+}
+
 // HighScoresForGame fetches the top "N" high scores achieved by any player
 // for the specified game. If you don't specify the HowMany value, this will default
 // to returning the top 5 scores.
@@ -68,6 +103,28 @@ func (client *ScoreServiceClient) NewHighScore(ctx context.Context, request *sco
 	return response, err
 }
 
+// Batch starts a set of ScoreService calls that you can send to the gateway in a single round trip. The
+// gateway must enable batching using rpc.WithBatch().
+func (client *ScoreServiceClient) Batch() *ScoreServiceBatch {
+	return &ScoreServiceBatch{Batch: client.Client.Batch()}
+}
+
+// ScoreServiceBatch collects ScoreService calls so that you can make them all at once using Send(). Each
+// response is populated (or the call's Err is set) once Send() finishes.
+type ScoreServiceBatch struct {
+	*rpc.Batch
+}
+
+// HighScoresForGame adds a call to ScoreService.HighScoresForGame to the batch.
+func (batch *ScoreServiceBatch) HighScoresForGame(request *scores.HighScoresForGameRequest, response *scores.HighScoresForGameResponse) *rpc.BatchCall {
+	return batch.Batch.Add("ScoreService", "HighScoresForGame", request, response)
+}
+
+// NewHighScore adds a call to ScoreService.NewHighScore to the batch.
+func (batch *ScoreServiceBatch) NewHighScore(request *scores.NewHighScoreRequest, response *scores.NewHighScoreResponse) *rpc.BatchCall {
+	return batch.Batch.Add("ScoreService", "NewHighScore", request, response)
+}
+
 // ScoreServiceProxy fully implements the ScoreService interface, but delegates all operations to a "real"
 // instance of the service. You can embed this type in a struct of your choice so you can "override" or
 // decorate operations as you see fit. Any operations on ScoreService that you don't explicitly define will
@@ -1,7 +1,9 @@
 // Code generated by Frodo - DO NOT EDIT.
 //
-//   Timestamp: Tue, 10 May 2022 16:25:34 EDT
+//   Timestamp: Fri, 16 Oct 2026 18:55:07 UTC
 //   Source:    scores/score_service.go
+//   Checksum:  sha256:993db175e833dc42d3ae846b29022ed7a183ca9f19c724d073889d65ec2ce1ca
+//   Version:   devel
 //   Generator: https://github.com/monadicstack/frodo
 //
 package scores
@@ -12,7 +14,7 @@ import (
 
 	"github.com/monadicstack/frodo/example/multiservice/scores"
 	"github.com/monadicstack/frodo/rpc"
-	"github.com/monadicstack/respond"
+	"github.com/monadicstack/frodo/rpc/events"
 )
 
 // NewScoreServiceGateway accepts your "real" ScoreService instance (the thing that really does the work), and
@@ -36,17 +38,38 @@ func NewScoreServiceGateway(service scores.ScoreService, options ...rpc.GatewayO
 		Path:        "/game/:GameID/highscore",
 		ServiceName: "ScoreService",
 		Name:        "HighScoresForGame",
+		Status:      200,
 		Handler: func(w http.ResponseWriter, req *http.Request) {
-			response := respond.To(w, req)
-
 			serviceRequest := scores.HighScoresForGameRequest{}
 			if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
-				response.Fail(err)
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := rpc.Normalize(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := gw.OnRequestBound(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
 				return
 			}
 
 			serviceResponse, err := service.HighScoresForGame(req.Context(), &serviceRequest)
-			response.Reply(200, serviceResponse, err)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := events.Flush(req.Context()); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Redact(req.Context(), serviceResponse)
+			response, err := gw.OnResponseMarshal(req.Context(), serviceResponse)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Reply(w, req, 200, response)
 		},
 	})
 
@@ -55,20 +78,51 @@ func NewScoreServiceGateway(service scores.ScoreService, options ...rpc.GatewayO
 		Path:        "/game/:GameID/highscore",
 		ServiceName: "ScoreService",
 		Name:        "NewHighScore",
+		Status:      201,
 		Handler: func(w http.ResponseWriter, req *http.Request) {
-			response := respond.To(w, req)
-
 			serviceRequest := scores.NewHighScoreRequest{}
 			if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
-				response.Fail(err)
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := rpc.Normalize(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := gw.OnRequestBound(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
 				return
 			}
 
 			serviceResponse, err := service.NewHighScore(req.Context(), &serviceRequest)
-			response.Reply(201, serviceResponse, err)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := events.Flush(req.Context()); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Redact(req.Context(), serviceResponse)
+			response, err := gw.OnResponseMarshal(req.Context(), serviceResponse)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Reply(w, req, 201, response)
 		},
 	})
 
+	if gw.BatchConcurrency > 0 {
+		gw.Register(gw.BatchEndpoint())
+	}
+	if gw.JSONRPCConcurrency > 0 {
+		gw.Register(gw.JSONRPCEndpoint())
+	}
+	if gw.EndpointListing {
+		gw.Register(gw.EndpointListingEndpoint())
+	}
+
 	return ScoreServiceGateway{Gateway: gw, service: service}
 }
 
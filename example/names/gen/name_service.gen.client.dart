@@ -1,58 +1,77 @@
 // Code generated by Frodo - DO NOT EDIT.
 //
-//   Timestamp: Tue, 10 May 2022 16:18:42 EDT
+//   Timestamp: Fri, 16 Oct 2026 18:58:33 UTC
 //   Source:    example/names/name_service.go
+//   Checksum:  sha256:74ad51b7b1cab6474f7901538793d72d79d1ac3928cfc6e8b1af09d9f57a7457
+//   Version:   devel
 //   Generator: https://github.com/monadicstack/frodo
 //
 import 'dart:async';
 import 'dart:convert';
-import 'dart:io';
+import 'package:http/http.dart' as http;
+import 'package:http_parser/http_parser.dart' show parseHttpDate;
 
 class NameServiceClient {
   static const String pathPrefix = '';
 
   final String baseURL;
   String authorization;
-  HttpClient httpClient = HttpClient();
+  http.Client httpClient;
 
+  /// Creates a client that sends requests to the service at 'baseURL'. By default, we use the standard
+  /// package:http client, which works in Flutter apps on mobile, desktop, and the web. Supply your own
+  /// 'httpClient' to customize the transport (e.g. a Dio adapter, retries, or a mock for your tests).
   NameServiceClient(this.baseURL, {
       this.authorization = '',
-  });
+      http.Client? httpClient,
+  }) : httpClient = httpClient ?? http.Client();
 
   
   /// Download returns a raw CSV file containing the parsed name.
-  Future<DownloadResponse> Download(DownloadRequest serviceRequest, {String authorization = ''}) async {
+  ///
+  /// Use 'contentOffset' to skip that many bytes of the content (e.g. to resume a download that was
+  /// interrupted). When you supply the 'contentValidator' (the ContentETag of the original download), the
+  /// gateway only skips them if the content hasn't changed. Check the response's ContentRangeStart to see
+  /// whether it did.
+  Future<DownloadResponse> Download(DownloadRequest serviceRequest, {String authorization = '', int contentOffset = 0, String contentValidator = ''}) async {
     var requestJson = serviceRequest.toJson();
     var method = 'POST';
     var route = '/NameService.Download';
     var url = _joinUrl([baseURL, pathPrefix, _buildRequestPath(method, route, requestJson)]);
 
-    var httpRequest = await httpClient.openUrl(method, Uri.parse(url));
-    httpRequest.headers.set('Accept', 'application/json');
-    httpRequest.headers.set('Authorization', _authorize(authorization));
-    httpRequest.headers.set('Content-Type', 'application/json');
-    httpRequest.write(jsonEncode(requestJson));
+    var httpRequest = http.Request(method, Uri.parse(url));
+    httpRequest.headers['Accept'] = 'application/json';
+    httpRequest.headers['Authorization'] = _authorize(authorization);
+    httpRequest.headers['Content-Type'] = 'application/json';
+    _applyContentOffset(httpRequest, contentOffset, contentValidator);
+    httpRequest.body = jsonEncode(requestJson);
 
-    var httpResponse = await httpRequest.close();
+    var httpResponse = await httpClient.send(httpRequest);
     return _handleResponseRaw(httpResponse, (json) => DownloadResponse.fromJson(json));
   }
   
   /// DownloadExt returns a raw CSV file containing the parsed name. This differs from Download
   /// by giving you the "Ext" knob which will let you exercise the content type and disposition
   /// interfaces that Frodo supports for raw responses.
-  Future<DownloadExtResponse> DownloadExt(DownloadExtRequest serviceRequest, {String authorization = ''}) async {
+  ///
+  /// Use 'contentOffset' to skip that many bytes of the content (e.g. to resume a download that was
+  /// interrupted). When you supply the 'contentValidator' (the ContentETag of the original download), the
+  /// gateway only skips them if the content hasn't changed. Check the response's ContentRangeStart to see
+  /// whether it did.
+  Future<DownloadExtResponse> DownloadExt(DownloadExtRequest serviceRequest, {String authorization = '', int contentOffset = 0, String contentValidator = ''}) async {
     var requestJson = serviceRequest.toJson();
     var method = 'POST';
     var route = '/NameService.DownloadExt';
     var url = _joinUrl([baseURL, pathPrefix, _buildRequestPath(method, route, requestJson)]);
 
-    var httpRequest = await httpClient.openUrl(method, Uri.parse(url));
-    httpRequest.headers.set('Accept', 'application/json');
-    httpRequest.headers.set('Authorization', _authorize(authorization));
-    httpRequest.headers.set('Content-Type', 'application/json');
-    httpRequest.write(jsonEncode(requestJson));
+    var httpRequest = http.Request(method, Uri.parse(url));
+    httpRequest.headers['Accept'] = 'application/json';
+    httpRequest.headers['Authorization'] = _authorize(authorization);
+    httpRequest.headers['Content-Type'] = 'application/json';
+    _applyContentOffset(httpRequest, contentOffset, contentValidator);
+    httpRequest.body = jsonEncode(requestJson);
 
-    var httpResponse = await httpRequest.close();
+    var httpResponse = await httpClient.send(httpRequest);
     return _handleResponseRaw(httpResponse, (json) => DownloadExtResponse.fromJson(json));
   }
   
@@ -63,13 +82,13 @@ class NameServiceClient {
     var route = '/NameService.FirstName';
     var url = _joinUrl([baseURL, pathPrefix, _buildRequestPath(method, route, requestJson)]);
 
-    var httpRequest = await httpClient.openUrl(method, Uri.parse(url));
-    httpRequest.headers.set('Accept', 'application/json');
-    httpRequest.headers.set('Authorization', _authorize(authorization));
-    httpRequest.headers.set('Content-Type', 'application/json');
-    httpRequest.write(jsonEncode(requestJson));
+    var httpRequest = http.Request(method, Uri.parse(url));
+    httpRequest.headers['Accept'] = 'application/json';
+    httpRequest.headers['Authorization'] = _authorize(authorization);
+    httpRequest.headers['Content-Type'] = 'application/json';
+    httpRequest.body = jsonEncode(requestJson);
 
-    var httpResponse = await httpRequest.close();
+    var httpResponse = await httpClient.send(httpRequest);
     return _handleResponse(httpResponse, (json) => FirstNameResponse.fromJson(json));
     
   }
@@ -81,13 +100,13 @@ class NameServiceClient {
     var route = '/NameService.LastName';
     var url = _joinUrl([baseURL, pathPrefix, _buildRequestPath(method, route, requestJson)]);
 
-    var httpRequest = await httpClient.openUrl(method, Uri.parse(url));
-    httpRequest.headers.set('Accept', 'application/json');
-    httpRequest.headers.set('Authorization', _authorize(authorization));
-    httpRequest.headers.set('Content-Type', 'application/json');
-    httpRequest.write(jsonEncode(requestJson));
+    var httpRequest = http.Request(method, Uri.parse(url));
+    httpRequest.headers['Accept'] = 'application/json';
+    httpRequest.headers['Authorization'] = _authorize(authorization);
+    httpRequest.headers['Content-Type'] = 'application/json';
+    httpRequest.body = jsonEncode(requestJson);
 
-    var httpResponse = await httpRequest.close();
+    var httpResponse = await httpClient.send(httpRequest);
     return _handleResponse(httpResponse, (json) => LastNameResponse.fromJson(json));
     
   }
@@ -99,13 +118,13 @@ class NameServiceClient {
     var route = '/NameService.SortName';
     var url = _joinUrl([baseURL, pathPrefix, _buildRequestPath(method, route, requestJson)]);
 
-    var httpRequest = await httpClient.openUrl(method, Uri.parse(url));
-    httpRequest.headers.set('Accept', 'application/json');
-    httpRequest.headers.set('Authorization', _authorize(authorization));
-    httpRequest.headers.set('Content-Type', 'application/json');
-    httpRequest.write(jsonEncode(requestJson));
+    var httpRequest = http.Request(method, Uri.parse(url));
+    httpRequest.headers['Accept'] = 'application/json';
+    httpRequest.headers['Authorization'] = _authorize(authorization);
+    httpRequest.headers['Content-Type'] = 'application/json';
+    httpRequest.body = jsonEncode(requestJson);
 
-    var httpResponse = await httpRequest.close();
+    var httpResponse = await httpClient.send(httpRequest);
     return _handleResponse(httpResponse, (json) => SortNameResponse.fromJson(json));
     
   }
@@ -117,18 +136,31 @@ class NameServiceClient {
     var route = '/NameService.Split';
     var url = _joinUrl([baseURL, pathPrefix, _buildRequestPath(method, route, requestJson)]);
 
-    var httpRequest = await httpClient.openUrl(method, Uri.parse(url));
-    httpRequest.headers.set('Accept', 'application/json');
-    httpRequest.headers.set('Authorization', _authorize(authorization));
-    httpRequest.headers.set('Content-Type', 'application/json');
-    httpRequest.write(jsonEncode(requestJson));
+    var httpRequest = http.Request(method, Uri.parse(url));
+    httpRequest.headers['Accept'] = 'application/json';
+    httpRequest.headers['Authorization'] = _authorize(authorization);
+    httpRequest.headers['Content-Type'] = 'application/json';
+    httpRequest.body = jsonEncode(requestJson);
 
-    var httpResponse = await httpRequest.close();
+    var httpResponse = await httpClient.send(httpRequest);
     return _handleResponse(httpResponse, (json) => SplitResponse.fromJson(json));
     
   }
   
 
+  /// Removes the attributes for header/cookie fields (e.g. `frodo:"header=X-Tenant-ID"`) from the request
+  /// JSON since they're not sent in the body/query string. Returns their values keyed by header/cookie name.
+  Map<String, String> _removeValues(Map<String, dynamic> requestJson, Map<String, String> attributes) {
+    var values = Map<String, String>();
+    attributes.forEach((name, attribute) {
+      var value = requestJson.remove(attribute);
+      if (_notEmpty(value)) {
+        values[name] = value.toString();
+      }
+    });
+    return values;
+  }
+
   String _buildRequestPath(String method, String route, Map<String, dynamic> requestJson) {
     String stringify(Map<String, dynamic> json, String key) {
       return Uri.encodeComponent(json[key]?.toString() ?? '');
@@ -136,6 +168,11 @@ class NameServiceClient {
     String stringifyAndRemove(Map<String, dynamic> json, String key) {
       return Uri.encodeComponent(json.remove(key)?.toString() ?? '');
     }
+    // Catch-all params (e.g. "*path") fill in the rest of the path, so keep the slashes in the value.
+    String catchAllAndRemove(Map<String, dynamic> json, String key) {
+      var value = json.remove(key)?.toString() ?? '';
+      return value.split('/').where((s) => s.isNotEmpty).map(Uri.encodeComponent).join('/');
+    }
 
     // Since we're embedding values in a path or query string, we need to flatten "{a: {b: {c: 4}}}"
     // down to "a.b.c=4" for it to fit nicely into our URL-based binding.
@@ -143,7 +180,9 @@ class NameServiceClient {
 
     var resolvedPath = route
       .split('/')
-      .map((s) => s.startsWith(':') ? stringifyAndRemove(requestJson, s.substring(1)) : s)
+      .map((s) => s.startsWith(':')
+          ? stringifyAndRemove(requestJson, s.substring(1))
+          : s.startsWith('*') ? catchAllAndRemove(requestJson, s.substring(1)) : s)
       .join('/');
 
     // These encode the data in the body, so no need to shove it in the query string.
@@ -159,27 +198,79 @@ class NameServiceClient {
     return resolvedPath + '?' + queryValues;
   }
 
-  Future<T> _handleResponse<T>(HttpClientResponse httpResponse, T Function(Map<String, dynamic>) factory) async {
+  Future<T> _handleResponse<T>(http.StreamedResponse httpResponse, T Function(Map<String, dynamic>) factory) async {
     if (httpResponse.statusCode >= 400) {
       throw await NameServiceException.fromResponse(httpResponse);
     }
 
-    var bodyJson = await _streamToString(httpResponse);
-    return factory(jsonDecode(bodyJson));
+    // "204 No Content" and "205 Reset Content" responses don't have a body to parse.
+    if (httpResponse.statusCode == 204 || httpResponse.statusCode == 205) {
+      await httpResponse.stream.drain();
+      return factory({});
+    }
+
+    var bodyJson = await _streamToString(httpResponse.stream);
+    var responseJson = jsonDecode(bodyJson);
+
+    // The gateway wrapped the response in an envelope, so the actual response is in the 'data'.
+    if (httpResponse.headers['x-rpc-envelope'] != null) {
+      responseJson = responseJson['data'];
+    }
+    return factory(responseJson);
   }
 
-  Future<T> _handleResponseRaw<T>(HttpClientResponse httpResponse, T Function(Map<String, dynamic>) factory) async {
+  Future<T> _handleResponseRaw<T>(http.StreamedResponse httpResponse, T Function(Map<String, dynamic>) factory) async {
     if (httpResponse.statusCode >= 400) {
       throw await NameServiceException.fromResponse(httpResponse);
     }
 
+    var lastModified = httpResponse.headers['last-modified'];
+    var contentRange = _parseContentRange(httpResponse);
     return factory({
-      'Content': httpResponse,
-      'ContentType': httpResponse.headers.value('Content-Type') ?? 'application/octet-stream',
-      'ContentFileName': _dispositionFileName(httpResponse.headers.value('Content-Disposition')),
+      'Content': httpResponse.stream,
+      'ContentType': httpResponse.headers['content-type'] ?? 'application/octet-stream',
+      'ContentFileName': _dispositionFileName(httpResponse.headers['content-disposition']),
+      'ContentETag': httpResponse.headers['etag'] ?? '',
+      'ContentModTime': lastModified == null ? null : parseHttpDate(lastModified),
+      'ContentRangeStart': contentRange[0],
+      'ContentRangeEnd': contentRange[1],
+      'ContentRangeSize': contentRange[2],
     });
   }
 
+  void _applyContentOffset(http.Request httpRequest, int offset, String validator) {
+    if (offset <= 0) {
+      return;
+    }
+    httpRequest.headers['Range'] = 'bytes=$offset-';
+    if (validator.isNotEmpty) {
+      httpRequest.headers['If-Range'] = validator;
+    }
+  }
+
+  /// Writes the values of cookie fields to the request's "Cookie" header. Browsers don't let you set
+  /// that header yourself, so on the web you'll need to make sure the browser already has the cookies.
+  void _applyCookies(http.Request httpRequest, Map<String, String> cookieValues) {
+    if (cookieValues.isEmpty) {
+      return;
+    }
+    httpRequest.headers['Cookie'] = cookieValues.entries
+      .map((cookie) => '${cookie.key}=${cookie.value}')
+      .join('; ');
+  }
+
+  /// Returns the [start, end, size] of the bytes in the response. For 206 responses, that comes from
+  /// the Content-Range header (e.g. "bytes 100-199/1000"). Otherwise, the response has all of it.
+  List<int> _parseContentRange(http.StreamedResponse httpResponse) {
+    var size = httpResponse.contentLength ?? -1;
+    var match = RegExp(r'^bytes (\d+)-(\d+)/(\d+|\*)$').firstMatch(httpResponse.headers['content-range'] ?? '');
+    if (httpResponse.statusCode != 206 || match == null) {
+      return [0, size < 0 ? -1 : size - 1, size];
+    }
+    var total = match.group(3) == '*' ? -1 : int.parse(match.group(3)!);
+    return [int.parse(match.group(1)!), int.parse(match.group(2)!), total];
+  }
+
   String _authorize(String callAuthorization) {
     return callAuthorization.trim().isNotEmpty
       ? callAuthorization
@@ -249,94 +340,201 @@ class NameServiceClient {
   }
 }
 
+/// The base class for all errors that the service (or the client) throws. You can catch the subclass for a
+/// specific status (e.g. NameServiceNotFoundException) or catch this to handle all of them.
 class NameServiceException implements Exception {
   int status;
   String message;
+  String code;
+  Map<String, dynamic> details;
+
+  NameServiceException(this.status, this.message, {this.code = '', this.details = const {}});
+
+  /// Creates the exception subclass that matches the status (the same ones as the rpc/errors package).
+  factory NameServiceException.fromStatus(int status, String message, {String code = '', Map<String, dynamic> details = const {}}) {
+    switch (status) {
+      case 400:
+        return NameServiceBadRequestException(message, code: code, details: details);
+      case 401:
+        return NameServiceBadCredentialsException(message, code: code, details: details);
+      case 403:
+        return NameServicePermissionDeniedException(message, code: code, details: details);
+      case 404:
+        return NameServiceNotFoundException(message, code: code, details: details);
+      case 408:
+        return NameServiceTimeoutException(message, code: code, details: details);
+      case 409:
+        return NameServiceAlreadyExistsException(message, code: code, details: details);
+      case 429:
+        return NameServiceThrottledException(message, code: code, details: details);
+      case 503:
+        return NameServiceUnavailableException(message, code: code, details: details);
+      default:
+        return NameServiceException(status, message, code: code, details: details);
+    }
+  }
 
-  NameServiceException(this.status, this.message);
-
-  static Future<NameServiceException> fromResponse(HttpClientResponse response) async {
-    var body = await _streamToString(response);
+  static Future<NameServiceException> fromResponse(http.StreamedResponse response) async {
+    var body = await _streamToString(response.stream);
     var message = '';
+    var code = '';
+    Map<String, dynamic> details = {};
     try {
       Map<String, dynamic> json = jsonDecode(body);
-      message = json['message'] ?? json['error'] ?? body;
+
+      // The gateway wrapped the failure in an envelope, so the actual error is in the 'error'.
+      if (response.headers['x-rpc-envelope'] != null && json['error'] is Map<String, dynamic>) {
+        json = json['error'];
+      }
+      message = json['message'] ?? json['detail'] ?? json['title'] ?? json['error'] ?? body;
+      code = json['code'] ?? '';
+      details = json['details'] ?? {};
     }
     catch (_) {
       message = body;
     }
-    throw new NameServiceException(response.statusCode, message);
+    throw NameServiceException.fromStatus(response.statusCode, message, code: code, details: details);
+  }
+
+  @override
+  String toString() {
+    return 'NameServiceException($status): $message';
   }
 }
 
+/// The request was malformed or failed validation (400).
+class NameServiceBadRequestException extends NameServiceException {
+  NameServiceBadRequestException(String message, {String code = '', Map<String, dynamic> details = const {}})
+    : super(400, message, code: code, details: details);
+}
 
-/// SortNameResponse is the output for the SortName function.
-class SortNameResponse implements NameServiceModelJSON { 
-  String? SortName;
+/// The caller didn't supply credentials or they're invalid (401).
+class NameServiceBadCredentialsException extends NameServiceException {
+  NameServiceBadCredentialsException(String message, {String code = '', Map<String, dynamic> details = const {}})
+    : super(401, message, code: code, details: details);
+}
 
-  SortNameResponse({ 
-    this.SortName,
-  });
+/// The caller's credentials are valid, but they're not allowed to do this (403).
+class NameServicePermissionDeniedException extends NameServiceException {
+  NameServicePermissionDeniedException(String message, {String code = '', Map<String, dynamic> details = const {}})
+    : super(403, message, code: code, details: details);
+}
 
-  SortNameResponse.fromJson(Map<String, dynamic> json) { 
-    SortName = json['SortName'];
-  }
+/// The resource (or function) doesn't exist (404).
+class NameServiceNotFoundException extends NameServiceException {
+  NameServiceNotFoundException(String message, {String code = '', Map<String, dynamic> details = const {}})
+    : super(404, message, code: code, details: details);
+}
 
-  Map<String, dynamic> toJson() {
-    return { 
-      'SortName': SortName,
-    };
-  }
+/// The service took too long to do the work (408).
+class NameServiceTimeoutException extends NameServiceException {
+  NameServiceTimeoutException(String message, {String code = '', Map<String, dynamic> details = const {}})
+    : super(408, message, code: code, details: details);
 }
 
-class SplitRequest implements NameServiceModelJSON { 
-  String? Name;
+/// The resource that you're trying to create already exists (409).
+class NameServiceAlreadyExistsException extends NameServiceException {
+  NameServiceAlreadyExistsException(String message, {String code = '', Map<String, dynamic> details = const {}})
+    : super(409, message, code: code, details: details);
+}
 
-  SplitRequest({ 
-    this.Name,
+/// The caller is making too many requests, so back off for a bit (429).
+class NameServiceThrottledException extends NameServiceException {
+  NameServiceThrottledException(String message, {String code = '', Map<String, dynamic> details = const {}})
+    : super(429, message, code: code, details: details);
+}
+
+/// The service is temporarily unable to handle the request (503).
+class NameServiceUnavailableException extends NameServiceException {
+  NameServiceUnavailableException(String message, {String code = '', Map<String, dynamic> details = const {}})
+    : super(503, message, code: code, details: details);
+}
+
+
+/// DownloadExtRequest is the input for the DownloadExt function.
+class DownloadExtRequest implements NameServiceModelJSON { 
+  String Name;
+  String Ext;
+
+  DownloadExtRequest({ 
+    this.Name = '',
+    this.Ext = '',
   });
 
-  SplitRequest.fromJson(Map<String, dynamic> json) { 
-    Name = json['Name'];
-  }
+  factory DownloadExtRequest.fromJson(Map<String, dynamic> json) => DownloadExtRequest( 
+    Name: json['Name'] as String? ?? '',
+    Ext: json['Ext'] as String? ?? '',
+  );
 
   Map<String, dynamic> toJson() {
     return { 
       'Name': Name,
+      'Ext': Ext,
     };
   }
 }
 
-/// NameRequest generalizes the data we pass to any of the name service functions.
-class NameRequest implements NameServiceModelJSON { 
-  String? Name;
+/// DownloadExtResponse is the output for the DownloadExt function.
+class DownloadExtResponse implements NameServiceModelJSON { 
+  Stream<List<int>>? Content;
+  String? ContentType;
+  String? ContentFileName;
+  String? ContentETag;
+  DateTime? ContentModTime;
+  int? ContentRangeStart;
+  int? ContentRangeEnd;
+  int? ContentRangeSize;
 
-  NameRequest({ 
-    this.Name,
+  DownloadExtResponse({ 
+    this.Content,
+    this.ContentType,
+    this.ContentFileName,
+    this.ContentETag,
+    this.ContentModTime,
+    this.ContentRangeStart,
+    this.ContentRangeEnd,
+    this.ContentRangeSize,
+    
   });
 
-  NameRequest.fromJson(Map<String, dynamic> json) { 
-    Name = json['Name'];
-  }
+  factory DownloadExtResponse.fromJson(Map<String, dynamic> json) => DownloadExtResponse( 
+    Content: json['Content'] as Stream<List<int>>?,
+    ContentType: json['ContentType'] as String? ?? 'application/octet-stream',
+    ContentFileName: json['ContentFileName'] as String? ?? '',
+    ContentETag: json['ContentETag'] as String? ?? '',
+    ContentModTime: json['ContentModTime'] as DateTime?,
+    ContentRangeStart: json['ContentRangeStart'] as int?,
+    ContentRangeEnd: json['ContentRangeEnd'] as int?,
+    ContentRangeSize: json['ContentRangeSize'] as int?,
+    
+  );
 
   Map<String, dynamic> toJson() {
     return { 
-      'Name': Name,
+      'Content': _streamToString(Content),
+      'ContentType': ContentType ?? 'application/octet-stream',
+      'ContentFileName': ContentFileName ?? '',
+      'ContentETag': ContentETag ?? '',
+      'ContentModTime': ContentModTime?.toIso8601String(),
+      'ContentRangeStart': ContentRangeStart,
+      'ContentRangeEnd': ContentRangeEnd,
+      'ContentRangeSize': ContentRangeSize,
+      
     };
   }
 }
 
-/// SortNameRequest is the input for the SortName function.
-class SortNameRequest implements NameServiceModelJSON { 
-  String? Name;
+/// DownloadRequest is the input for the Download function.
+class DownloadRequest implements NameServiceModelJSON { 
+  String Name;
 
-  SortNameRequest({ 
-    this.Name,
+  DownloadRequest({ 
+    this.Name = '',
   });
 
-  SortNameRequest.fromJson(Map<String, dynamic> json) { 
-    Name = json['Name'];
-  }
+  factory DownloadRequest.fromJson(Map<String, dynamic> json) => DownloadRequest( 
+    Name: json['Name'] as String? ?? '',
+  );
 
   Map<String, dynamic> toJson() {
     return { 
@@ -350,42 +548,62 @@ class DownloadResponse implements NameServiceModelJSON {
   Stream<List<int>>? Content;
   String? ContentType;
   String? ContentFileName;
+  String? ContentETag;
+  DateTime? ContentModTime;
+  int? ContentRangeStart;
+  int? ContentRangeEnd;
+  int? ContentRangeSize;
 
   DownloadResponse({ 
     this.Content,
     this.ContentType,
     this.ContentFileName,
+    this.ContentETag,
+    this.ContentModTime,
+    this.ContentRangeStart,
+    this.ContentRangeEnd,
+    this.ContentRangeSize,
     
   });
 
-  DownloadResponse.fromJson(Map<String, dynamic> json) { 
-    Content = json['Content'] as Stream<List<int>>?;
-    ContentType = json['ContentType'] ?? 'application/octet-stream';
-    ContentFileName = json['ContentFileName'] ?? '';
+  factory DownloadResponse.fromJson(Map<String, dynamic> json) => DownloadResponse( 
+    Content: json['Content'] as Stream<List<int>>?,
+    ContentType: json['ContentType'] as String? ?? 'application/octet-stream',
+    ContentFileName: json['ContentFileName'] as String? ?? '',
+    ContentETag: json['ContentETag'] as String? ?? '',
+    ContentModTime: json['ContentModTime'] as DateTime?,
+    ContentRangeStart: json['ContentRangeStart'] as int?,
+    ContentRangeEnd: json['ContentRangeEnd'] as int?,
+    ContentRangeSize: json['ContentRangeSize'] as int?,
     
-  }
+  );
 
   Map<String, dynamic> toJson() {
     return { 
       'Content': _streamToString(Content),
       'ContentType': ContentType ?? 'application/octet-stream',
       'ContentFileName': ContentFileName ?? '',
+      'ContentETag': ContentETag ?? '',
+      'ContentModTime': ContentModTime?.toIso8601String(),
+      'ContentRangeStart': ContentRangeStart,
+      'ContentRangeEnd': ContentRangeEnd,
+      'ContentRangeSize': ContentRangeSize,
       
     };
   }
 }
 
-/// LastNameRequest is the output for the LastName function.
-class LastNameRequest implements NameServiceModelJSON { 
-  String? Name;
+/// FirstNameRequest is the input for the FirstName function.
+class FirstNameRequest implements NameServiceModelJSON { 
+  String Name;
 
-  LastNameRequest({ 
-    this.Name,
+  FirstNameRequest({ 
+    this.Name = '',
   });
 
-  LastNameRequest.fromJson(Map<String, dynamic> json) { 
-    Name = json['Name'];
-  }
+  factory FirstNameRequest.fromJson(Map<String, dynamic> json) => FirstNameRequest( 
+    Name: json['Name'] as String? ?? '',
+  );
 
   Map<String, dynamic> toJson() {
     return { 
@@ -394,108 +612,93 @@ class LastNameRequest implements NameServiceModelJSON {
   }
 }
 
-/// LastNameResponse is the output for the LastName function.
-class LastNameResponse implements NameServiceModelJSON { 
-  String? LastName;
+/// FirstNameResponse is the output for the FirstName function.
+class FirstNameResponse implements NameServiceModelJSON { 
+  String FirstName;
 
-  LastNameResponse({ 
-    this.LastName,
+  FirstNameResponse({ 
+    this.FirstName = '',
   });
 
-  LastNameResponse.fromJson(Map<String, dynamic> json) { 
-    LastName = json['LastName'];
-  }
+  factory FirstNameResponse.fromJson(Map<String, dynamic> json) => FirstNameResponse( 
+    FirstName: json['FirstName'] as String? ?? '',
+  );
 
   Map<String, dynamic> toJson() {
     return { 
-      'LastName': LastName,
+      'FirstName': FirstName,
     };
   }
 }
 
-/// DownloadExtRequest is the input for the DownloadExt function.
-class DownloadExtRequest implements NameServiceModelJSON { 
-  String? Name;
-  String? Ext;
+/// LastNameRequest is the output for the LastName function.
+class LastNameRequest implements NameServiceModelJSON { 
+  String Name;
 
-  DownloadExtRequest({ 
-    this.Name,
-    this.Ext,
+  LastNameRequest({ 
+    this.Name = '',
   });
 
-  DownloadExtRequest.fromJson(Map<String, dynamic> json) { 
-    Name = json['Name'];
-    Ext = json['Ext'];
-  }
+  factory LastNameRequest.fromJson(Map<String, dynamic> json) => LastNameRequest( 
+    Name: json['Name'] as String? ?? '',
+  );
 
   Map<String, dynamic> toJson() {
     return { 
       'Name': Name,
-      'Ext': Ext,
     };
   }
 }
 
-/// FirstNameResponse is the output for the FirstName function.
-class FirstNameResponse implements NameServiceModelJSON { 
-  String? FirstName;
+/// LastNameResponse is the output for the LastName function.
+class LastNameResponse implements NameServiceModelJSON { 
+  String LastName;
 
-  FirstNameResponse({ 
-    this.FirstName,
+  LastNameResponse({ 
+    this.LastName = '',
   });
 
-  FirstNameResponse.fromJson(Map<String, dynamic> json) { 
-    FirstName = json['FirstName'];
-  }
+  factory LastNameResponse.fromJson(Map<String, dynamic> json) => LastNameResponse( 
+    LastName: json['LastName'] as String? ?? '',
+  );
 
   Map<String, dynamic> toJson() {
     return { 
-      'FirstName': FirstName,
+      'LastName': LastName,
     };
   }
 }
 
-/// DownloadExtResponse is the output for the DownloadExt function.
-class DownloadExtResponse implements NameServiceModelJSON { 
-  Stream<List<int>>? Content;
-  String? ContentType;
-  String? ContentFileName;
+/// NameRequest generalizes the data we pass to any of the name service functions.
+class NameRequest implements NameServiceModelJSON { 
+  String Name;
 
-  DownloadExtResponse({ 
-    this.Content,
-    this.ContentType,
-    this.ContentFileName,
-    
+  NameRequest({ 
+    this.Name = '',
   });
 
-  DownloadExtResponse.fromJson(Map<String, dynamic> json) { 
-    Content = json['Content'] as Stream<List<int>>?;
-    ContentType = json['ContentType'] ?? 'application/octet-stream';
-    ContentFileName = json['ContentFileName'] ?? '';
-    
-  }
+  factory NameRequest.fromJson(Map<String, dynamic> json) => NameRequest( 
+    Name: json['Name'] as String? ?? '',
+  );
 
   Map<String, dynamic> toJson() {
     return { 
-      'Content': _streamToString(Content),
-      'ContentType': ContentType ?? 'application/octet-stream',
-      'ContentFileName': ContentFileName ?? '',
-      
+      'Name': Name,
     };
   }
 }
 
-/// DownloadRequest is the input for the Download function.
-class DownloadRequest implements NameServiceModelJSON { 
-  String? Name;
+/// SortNameRequest is the input for the SortName function.
+class SortNameRequest implements NameServiceModelJSON { 
+  String Name;
 
-  DownloadRequest({ 
-    this.Name,
+  SortNameRequest({ 
+    this.Name = '',
   });
 
-  DownloadRequest.fromJson(Map<String, dynamic> json) { 
-    Name = json['Name'];
-  }
+  factory SortNameRequest.fromJson(Map<String, dynamic> json) => SortNameRequest( 
+    Name: json['Name'] as String? ?? '',
+  );
 
   Map<String, dynamic> toJson() {
     return { 
@@ -504,17 +707,35 @@ class DownloadRequest implements NameServiceModelJSON {
   }
 }
 
-/// FirstNameRequest is the input for the FirstName function.
-class FirstNameRequest implements NameServiceModelJSON { 
-  String? Name;
+/// SortNameResponse is the output for the SortName function.
+class SortNameResponse implements NameServiceModelJSON { 
+  String SortName;
 
-  FirstNameRequest({ 
-    this.Name,
+  SortNameResponse({ 
+    this.SortName = '',
   });
 
-  FirstNameRequest.fromJson(Map<String, dynamic> json) { 
-    Name = json['Name'];
+  factory SortNameResponse.fromJson(Map<String, dynamic> json) => SortNameResponse( 
+    SortName: json['SortName'] as String? ?? '',
+  );
+
+  Map<String, dynamic> toJson() {
+    return { 
+      'SortName': SortName,
+    };
   }
+}
+
+class SplitRequest implements NameServiceModelJSON { 
+  String Name;
+
+  SplitRequest({ 
+    this.Name = '',
+  });
+
+  factory SplitRequest.fromJson(Map<String, dynamic> json) => SplitRequest( 
+    Name: json['Name'] as String? ?? '',
+  );
 
   Map<String, dynamic> toJson() {
     return { 
@@ -525,18 +746,18 @@ class FirstNameRequest implements NameServiceModelJSON {
 
 /// SplitResponse is the output for the Split function.
 class SplitResponse implements NameServiceModelJSON { 
-  String? FirstName;
-  String? LastName;
+  String FirstName;
+  String LastName;
 
   SplitResponse({ 
-    this.FirstName,
-    this.LastName,
+    this.FirstName = '',
+    this.LastName = '',
   });
 
-  SplitResponse.fromJson(Map<String, dynamic> json) { 
-    FirstName = json['FirstName'];
-    LastName = json['LastName'];
-  }
+  factory SplitResponse.fromJson(Map<String, dynamic> json) => SplitResponse( 
+    FirstName: json['FirstName'] as String? ?? '',
+    LastName: json['LastName'] as String? ?? '',
+  );
 
   Map<String, dynamic> toJson() {
     return { 
@@ -553,8 +774,11 @@ class NameServiceModelJSON {
   }
 }
 
-List<T>? _map<T>(List<dynamic>? jsonList, T Function(dynamic) mapping) {
-  return jsonList == null ? null : jsonList.map(mapping).toList();
+bool _notEmpty(dynamic value) {
+  if (value == null || value == '' || value == 0 || value == false) {
+    return false;
+  }
+  return !(value is List && value.isEmpty) && !(value is Map && value.isEmpty);
 }
 
 Future<String> _streamToString(Stream<List<int>>? stream) async {
@@ -1,7 +1,9 @@
 // Code generated by Frodo - DO NOT EDIT.
 //
-//   Timestamp: Tue, 10 May 2022 16:18:41 EDT
+//   Timestamp: Fri, 16 Oct 2026 14:47:46 UTC
 //   Source:    example/names/name_service.go
+//   Checksum:  sha256:74ad51b7b1cab6474f7901538793d72d79d1ac3928cfc6e8b1af09d9f57a7457
+//   Version:   devel
 //   Generator: https://github.com/monadicstack/frodo
 //
 package names
@@ -33,6 +35,39 @@ type NameServiceClient struct {
 	rpc.Client
 }
 
+// NameServiceClientInterface is everything that you can do w/ a NameServiceClient: the NameService functions
+// plus the extras that only make sense when you're calling a remote instance. Depend on this (or just NameService
+// if you only call its functions) rather than *NameServiceClient, so that you can inject a mock/fake in your tests.
+type NameServiceClientInterface interface {
+	names.NameService
+	Batch() *NameServiceBatch
+}
+
+// NameServiceClientAddress is the base address of the remote NameService gateway (e.g. "http://localhost:8080").
+// Dependency injection frameworks like wire, fx, and dig look up dependencies by type, so this keeps the address
+// from getting mixed up w/ every other string in your app.
+type NameServiceClientAddress string
+
+// ProvideNameServiceClient creates the client for dependency injection frameworks like wire, fx, and dig using the
+// address that you provide elsewhere:
+//
+//	fx.Provide(
+//	    func() names.NameServiceClientAddress { return "http://localhost:8080" },
+//	    names.ProvideNameServiceClient,
+//	    names.ProvideNameService,
+//	)
+//
+// If you need any client options, write your own provider that calls NewNameServiceClient() instead.
+func ProvideNameServiceClient(address NameServiceClientAddress) NameServiceClientInterface {
+	return NewNameServiceClient(string(address))
+}
+
+// ProvideNameService exposes the client as a plain NameService for dependency injection frameworks, so
+// the code that uses it doesn't know (or care) whether it's calling a remote instance or a local one.
+func ProvideNameService(client NameServiceClientInterface) names.NameService {
+	return client
+}
+
 // Download returns a raw CSV file containing the parsed name.
 func (client *NameServiceClient) Download(ctx context.Context, request *names.DownloadRequest) (*names.DownloadResponse, error) {
 	if ctx == nil {
@@ -119,6 +154,38 @@ func (client *NameServiceClient) Split(ctx context.Context, request *names.Split
 	return response, err
 }
 
+// Batch starts a set of NameService calls that you can send to the gateway in a single round trip. The
+// gateway must enable batching using rpc.WithBatch().
+func (client *NameServiceClient) Batch() *NameServiceBatch {
+	return &NameServiceBatch{Batch: client.Client.Batch()}
+}
+
+// NameServiceBatch collects NameService calls so that you can make them all at once using Send(). Each
+// response is populated (or the call's Err is set) once Send() finishes.
+type NameServiceBatch struct {
+	*rpc.Batch
+}
+
+// FirstName adds a call to NameService.FirstName to the batch.
+func (batch *NameServiceBatch) FirstName(request *names.FirstNameRequest, response *names.FirstNameResponse) *rpc.BatchCall {
+	return batch.Batch.Add("NameService", "FirstName", request, response)
+}
+
+// LastName adds a call to NameService.LastName to the batch.
+func (batch *NameServiceBatch) LastName(request *names.LastNameRequest, response *names.LastNameResponse) *rpc.BatchCall {
+	return batch.Batch.Add("NameService", "LastName", request, response)
+}
+
+// SortName adds a call to NameService.SortName to the batch.
+func (batch *NameServiceBatch) SortName(request *names.SortNameRequest, response *names.SortNameResponse) *rpc.BatchCall {
+	return batch.Batch.Add("NameService", "SortName", request, response)
+}
+
+// Split adds a call to NameService.Split to the batch.
+func (batch *NameServiceBatch) Split(request *names.SplitRequest, response *names.SplitResponse) *rpc.BatchCall {
+	return batch.Batch.Add("NameService", "Split", request, response)
+}
+
 // NameServiceProxy fully implements the NameService interface, but delegates all operations to a "real"
 // instance of the service. You can embed this type in a struct of your choice so you can "override" or
 // decorate operations as you see fit. Any operations on NameService that you don't explicitly define will
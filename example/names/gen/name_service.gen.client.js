@@ -1,241 +1,374 @@
 // Code generated by Frodo - DO NOT EDIT.
 //
-//   Timestamp: Tue, 10 May 2022 16:18:41 EDT
+//   Timestamp: Fri, 16 Oct 2026 18:58:32 UTC
 //   Source:    example/names/name_service.go
+//   Checksum:  sha256:74ad51b7b1cab6474f7901538793d72d79d1ac3928cfc6e8b1af09d9f57a7457
+//   Version:   devel
 //   Generator: https://github.com/monadicstack/frodo
 //
-/* global fetch,module,window */
-'use strict';
+/* global AbortController,clearTimeout,document,fetch,setTimeout,window */
 
 /**
  * Exposes all of the standard operations for the remote NameService service. These RPC calls
  * will be sent over http(s) to the backend service instances. 
  * NameService performs parsing/processing on a person's name. This is primarily just
  * used as a reference service for integration testing our generated clients.
+ *
+ * Every method is also exported as a standalone function that accepts the client's config as its first
+ * argument (see nameServiceConfig()). If you only import the functions you call, your bundler can leave the
+ * rest of them out of your build.
  */
-class NameServiceClient {
-    _baseURL;
-    _fetch;
-    _authorization;
+export class NameServiceClient {
+    _config;
 
     /**
      * @param {string} baseURL The protocol/host/port used by all API/service
      *     calls (e.g. "https://some-server:9000")
-     * @param {object} [options]
-     * @param {fetch|*} [options.fetch] Provide a custom implementation for the 'fetch' API. Not
-     *     necessary if running in browser.
-     * @param {string} [options.authorization] Use these credentials in the HTTP Authorization header
-     *      for every request. Only use the client-level authorization when all requests to the
-     *      service should have the same credentials. If you allow multiple users in your system,
-     *      leave this blank and use the authorization option on each request.
+     * @param {ClientOptions} [options]
      */
-    constructor(baseURL, {fetch, authorization} = {}) {
-        this._baseURL = trimSlashes(trimSlashes(baseURL) + '/' + trimSlashes(''));
-        this._fetch = fetch || defaultFetch();
-        this._authorization = authorization || '';
+    constructor(baseURL, options = {}) {
+        this._config = nameServiceConfig(baseURL, options);
     }
-
     
     /**
-     * Download returns a raw CSV file containing the parsed name. 
+     * See Download() for details.
      *
      * @param { DownloadRequest } serviceRequest The input parameters
-     * @param {object} [options]
-     * @param { string } [options.authorization] The HTTP Authorization header value to include
-     *     in the request. This will override any authorization you might have applied when
-     *     constructing this client. Use this in multi-tenant situations where multiple users
-     *     might utilize this service.
+     * @param {ContentCallOptions} [options]
      * @returns {Promise<DownloadResponse>} The JSON-encoded return value of the operation.
      */
-    async Download(serviceRequest, {authorization} = {}) {
-        if (!serviceRequest) {
-            throw new Error('precondition failed: empty request');
-        }
-
-        const method = 'POST';
-        const route = '/NameService.Download';
-        const url = this._baseURL + '/' + buildRequestPath(method, route, serviceRequest);
-        const fetchOptions = {
-            method: 'POST',
-            headers: {
-                'Authorization': authorization || this._authorization,
-                'Accept': 'application/json,*/*',
-                'Content-Type': 'application/json; charset=utf-8',
-            },
-            body: JSON.stringify(serviceRequest),
-        };
-
-        const response = await this._fetch(url, fetchOptions);
-        return handleResponseRaw(response);
+    Download(serviceRequest, options = {}) {
+        return Download(this._config, serviceRequest, options);
     }
     
     /**
-     * DownloadExt returns a raw CSV file containing the parsed name. This differs from Download 
-     * by giving you the "Ext" knob which will let you exercise the content type and disposition 
-     * interfaces that Frodo supports for raw responses. 
+     * See DownloadExt() for details.
      *
      * @param { DownloadExtRequest } serviceRequest The input parameters
-     * @param {object} [options]
-     * @param { string } [options.authorization] The HTTP Authorization header value to include
-     *     in the request. This will override any authorization you might have applied when
-     *     constructing this client. Use this in multi-tenant situations where multiple users
-     *     might utilize this service.
+     * @param {ContentCallOptions} [options]
      * @returns {Promise<DownloadExtResponse>} The JSON-encoded return value of the operation.
      */
-    async DownloadExt(serviceRequest, {authorization} = {}) {
-        if (!serviceRequest) {
-            throw new Error('precondition failed: empty request');
-        }
-
-        const method = 'POST';
-        const route = '/NameService.DownloadExt';
-        const url = this._baseURL + '/' + buildRequestPath(method, route, serviceRequest);
-        const fetchOptions = {
-            method: 'POST',
-            headers: {
-                'Authorization': authorization || this._authorization,
-                'Accept': 'application/json,*/*',
-                'Content-Type': 'application/json; charset=utf-8',
-            },
-            body: JSON.stringify(serviceRequest),
-        };
-
-        const response = await this._fetch(url, fetchOptions);
-        return handleResponseRaw(response);
+    DownloadExt(serviceRequest, options = {}) {
+        return DownloadExt(this._config, serviceRequest, options);
     }
     
     /**
-     * FirstName extracts just the first name from a full name string. 
+     * See FirstName() for details.
      *
      * @param { FirstNameRequest } serviceRequest The input parameters
-     * @param {object} [options]
-     * @param { string } [options.authorization] The HTTP Authorization header value to include
-     *     in the request. This will override any authorization you might have applied when
-     *     constructing this client. Use this in multi-tenant situations where multiple users
-     *     might utilize this service.
+     * @param {CallOptions} [options]
      * @returns {Promise<FirstNameResponse>} The JSON-encoded return value of the operation.
      */
-    async FirstName(serviceRequest, {authorization} = {}) {
-        if (!serviceRequest) {
-            throw new Error('precondition failed: empty request');
-        }
-
-        const method = 'POST';
-        const route = '/NameService.FirstName';
-        const url = this._baseURL + '/' + buildRequestPath(method, route, serviceRequest);
-        const fetchOptions = {
-            method: 'POST',
-            headers: {
-                'Authorization': authorization || this._authorization,
-                'Accept': 'application/json,*/*',
-                'Content-Type': 'application/json; charset=utf-8',
-            },
-            body: JSON.stringify(serviceRequest),
-        };
-
-        const response = await this._fetch(url, fetchOptions);
-        return handleResponseJSON(response);
+    FirstName(serviceRequest, options = {}) {
+        return FirstName(this._config, serviceRequest, options);
     }
     
     /**
-     * LastName extracts just the last name from a full name string. 
+     * See LastName() for details.
      *
      * @param { LastNameRequest } serviceRequest The input parameters
-     * @param {object} [options]
-     * @param { string } [options.authorization] The HTTP Authorization header value to include
-     *     in the request. This will override any authorization you might have applied when
-     *     constructing this client. Use this in multi-tenant situations where multiple users
-     *     might utilize this service.
+     * @param {CallOptions} [options]
      * @returns {Promise<LastNameResponse>} The JSON-encoded return value of the operation.
      */
-    async LastName(serviceRequest, {authorization} = {}) {
-        if (!serviceRequest) {
-            throw new Error('precondition failed: empty request');
-        }
-
-        const method = 'POST';
-        const route = '/NameService.LastName';
-        const url = this._baseURL + '/' + buildRequestPath(method, route, serviceRequest);
-        const fetchOptions = {
-            method: 'POST',
-            headers: {
-                'Authorization': authorization || this._authorization,
-                'Accept': 'application/json,*/*',
-                'Content-Type': 'application/json; charset=utf-8',
-            },
-            body: JSON.stringify(serviceRequest),
-        };
-
-        const response = await this._fetch(url, fetchOptions);
-        return handleResponseJSON(response);
+    LastName(serviceRequest, options = {}) {
+        return LastName(this._config, serviceRequest, options);
     }
     
     /**
-     * SortName establishes the "phone book" name for the given full name. 
+     * See SortName() for details.
      *
      * @param { SortNameRequest } serviceRequest The input parameters
-     * @param {object} [options]
-     * @param { string } [options.authorization] The HTTP Authorization header value to include
-     *     in the request. This will override any authorization you might have applied when
-     *     constructing this client. Use this in multi-tenant situations where multiple users
-     *     might utilize this service.
+     * @param {CallOptions} [options]
      * @returns {Promise<SortNameResponse>} The JSON-encoded return value of the operation.
      */
-    async SortName(serviceRequest, {authorization} = {}) {
-        if (!serviceRequest) {
-            throw new Error('precondition failed: empty request');
-        }
-
-        const method = 'POST';
-        const route = '/NameService.SortName';
-        const url = this._baseURL + '/' + buildRequestPath(method, route, serviceRequest);
-        const fetchOptions = {
-            method: 'POST',
-            headers: {
-                'Authorization': authorization || this._authorization,
-                'Accept': 'application/json,*/*',
-                'Content-Type': 'application/json; charset=utf-8',
-            },
-            body: JSON.stringify(serviceRequest),
-        };
-
-        const response = await this._fetch(url, fetchOptions);
-        return handleResponseJSON(response);
+    SortName(serviceRequest, options = {}) {
+        return SortName(this._config, serviceRequest, options);
     }
     
     /**
-     * Split separates a first and last name. 
+     * See Split() for details.
      *
      * @param { SplitRequest } serviceRequest The input parameters
-     * @param {object} [options]
-     * @param { string } [options.authorization] The HTTP Authorization header value to include
-     *     in the request. This will override any authorization you might have applied when
-     *     constructing this client. Use this in multi-tenant situations where multiple users
-     *     might utilize this service.
+     * @param {CallOptions} [options]
      * @returns {Promise<SplitResponse>} The JSON-encoded return value of the operation.
      */
-    async Split(serviceRequest, {authorization} = {}) {
-        if (!serviceRequest) {
-            throw new Error('precondition failed: empty request');
+    Split(serviceRequest, options = {}) {
+        return Split(this._config, serviceRequest, options);
+    }
+    
+}
+
+/**
+ * Creates the config that the standalone service functions use to call the remote NameService.
+ *
+ * @param {string} baseURL The protocol/host/port used by all API/service
+ *     calls (e.g. "https://some-server:9000")
+ * @param {ClientOptions} [options]
+ * @returns {ClientConfig}
+ */
+export function nameServiceConfig(baseURL, {fetch, authorization, metadata, timeout, csrfCookie, csrfHeader, cookies} = {}) {
+    return {
+        baseURL: trimSlashes(trimSlashes(baseURL) + '/' + trimSlashes('')),
+        fetch: fetch || defaultFetch(),
+        authorization: authorization || '',
+        metadata: metadata || {},
+        timeout: typeof timeout === 'number' ? timeout : 30000,
+        csrfCookie: csrfCookie || 'frodo-csrf',
+        csrfHeader: csrfHeader || 'X-CSRF-Token',
+        cookieJar: cookies ? {} : null,
+    };
+}
+
+/**
+ * Download returns a raw CSV file containing the parsed name. 
+ *
+ * @param {ClientConfig} config The connection info for the service (see nameServiceConfig())
+ * @param { DownloadRequest } serviceRequest The input parameters
+ * @param {ContentCallOptions} [options]
+ * @returns {Promise<DownloadResponse>} The raw content and its metadata.
+ */
+export async function Download(config, serviceRequest, options = {}) {
+    if (!serviceRequest) {
+        throw new Error('precondition failed: empty request');
+    }
+
+    const method = 'POST';
+    const route = '/NameService.Download';
+    const url = config.baseURL + '/' + buildRequestPath(method, route, serviceRequest);
+    const fetchOptions = {
+        method: 'POST',
+        headers: await requestHeaders(config, options, {
+            'Accept': 'application/json,*/*',
+            'Content-Type': 'application/json; charset=utf-8',
+        }),
+        body: JSON.stringify(serviceRequest),
+    };
+    applyCSRFToken(fetchOptions, config.csrfCookie, config.csrfHeader);
+    applyContentOffset(fetchOptions, options.contentOffset, options.contentValidator);
+    return send(config, url, fetchOptions, options, handleResponseRaw);
+}
+
+/**
+ * DownloadExt returns a raw CSV file containing the parsed name. This differs from Download 
+ * by giving you the "Ext" knob which will let you exercise the content type and disposition 
+ * interfaces that Frodo supports for raw responses. 
+ *
+ * @param {ClientConfig} config The connection info for the service (see nameServiceConfig())
+ * @param { DownloadExtRequest } serviceRequest The input parameters
+ * @param {ContentCallOptions} [options]
+ * @returns {Promise<DownloadExtResponse>} The raw content and its metadata.
+ */
+export async function DownloadExt(config, serviceRequest, options = {}) {
+    if (!serviceRequest) {
+        throw new Error('precondition failed: empty request');
+    }
+
+    const method = 'POST';
+    const route = '/NameService.DownloadExt';
+    const url = config.baseURL + '/' + buildRequestPath(method, route, serviceRequest);
+    const fetchOptions = {
+        method: 'POST',
+        headers: await requestHeaders(config, options, {
+            'Accept': 'application/json,*/*',
+            'Content-Type': 'application/json; charset=utf-8',
+        }),
+        body: JSON.stringify(serviceRequest),
+    };
+    applyCSRFToken(fetchOptions, config.csrfCookie, config.csrfHeader);
+    applyContentOffset(fetchOptions, options.contentOffset, options.contentValidator);
+    return send(config, url, fetchOptions, options, handleResponseRaw);
+}
+
+/**
+ * FirstName extracts just the first name from a full name string. 
+ *
+ * @param {ClientConfig} config The connection info for the service (see nameServiceConfig())
+ * @param { FirstNameRequest } serviceRequest The input parameters
+ * @param {CallOptions} [options]
+ * @returns {Promise<FirstNameResponse>} The JSON-encoded return value of the operation.
+ */
+export async function FirstName(config, serviceRequest, options = {}) {
+    if (!serviceRequest) {
+        throw new Error('precondition failed: empty request');
+    }
+
+    const method = 'POST';
+    const route = '/NameService.FirstName';
+    const url = config.baseURL + '/' + buildRequestPath(method, route, serviceRequest);
+    const fetchOptions = {
+        method: 'POST',
+        headers: await requestHeaders(config, options, {
+            'Accept': 'application/json,*/*',
+            'Content-Type': 'application/json; charset=utf-8',
+        }),
+        body: JSON.stringify(serviceRequest),
+    };
+    applyCSRFToken(fetchOptions, config.csrfCookie, config.csrfHeader);
+    return send(config, url, fetchOptions, options, handleResponseJSON);
+}
+
+/**
+ * LastName extracts just the last name from a full name string. 
+ *
+ * @param {ClientConfig} config The connection info for the service (see nameServiceConfig())
+ * @param { LastNameRequest } serviceRequest The input parameters
+ * @param {CallOptions} [options]
+ * @returns {Promise<LastNameResponse>} The JSON-encoded return value of the operation.
+ */
+export async function LastName(config, serviceRequest, options = {}) {
+    if (!serviceRequest) {
+        throw new Error('precondition failed: empty request');
+    }
+
+    const method = 'POST';
+    const route = '/NameService.LastName';
+    const url = config.baseURL + '/' + buildRequestPath(method, route, serviceRequest);
+    const fetchOptions = {
+        method: 'POST',
+        headers: await requestHeaders(config, options, {
+            'Accept': 'application/json,*/*',
+            'Content-Type': 'application/json; charset=utf-8',
+        }),
+        body: JSON.stringify(serviceRequest),
+    };
+    applyCSRFToken(fetchOptions, config.csrfCookie, config.csrfHeader);
+    return send(config, url, fetchOptions, options, handleResponseJSON);
+}
+
+/**
+ * SortName establishes the "phone book" name for the given full name. 
+ *
+ * @param {ClientConfig} config The connection info for the service (see nameServiceConfig())
+ * @param { SortNameRequest } serviceRequest The input parameters
+ * @param {CallOptions} [options]
+ * @returns {Promise<SortNameResponse>} The JSON-encoded return value of the operation.
+ */
+export async function SortName(config, serviceRequest, options = {}) {
+    if (!serviceRequest) {
+        throw new Error('precondition failed: empty request');
+    }
+
+    const method = 'POST';
+    const route = '/NameService.SortName';
+    const url = config.baseURL + '/' + buildRequestPath(method, route, serviceRequest);
+    const fetchOptions = {
+        method: 'POST',
+        headers: await requestHeaders(config, options, {
+            'Accept': 'application/json,*/*',
+            'Content-Type': 'application/json; charset=utf-8',
+        }),
+        body: JSON.stringify(serviceRequest),
+    };
+    applyCSRFToken(fetchOptions, config.csrfCookie, config.csrfHeader);
+    return send(config, url, fetchOptions, options, handleResponseJSON);
+}
+
+/**
+ * Split separates a first and last name. 
+ *
+ * @param {ClientConfig} config The connection info for the service (see nameServiceConfig())
+ * @param { SplitRequest } serviceRequest The input parameters
+ * @param {CallOptions} [options]
+ * @returns {Promise<SplitResponse>} The JSON-encoded return value of the operation.
+ */
+export async function Split(config, serviceRequest, options = {}) {
+    if (!serviceRequest) {
+        throw new Error('precondition failed: empty request');
+    }
+
+    const method = 'POST';
+    const route = '/NameService.Split';
+    const url = config.baseURL + '/' + buildRequestPath(method, route, serviceRequest);
+    const fetchOptions = {
+        method: 'POST',
+        headers: await requestHeaders(config, options, {
+            'Accept': 'application/json,*/*',
+            'Content-Type': 'application/json; charset=utf-8',
+        }),
+        body: JSON.stringify(serviceRequest),
+    };
+    applyCSRFToken(fetchOptions, config.csrfCookie, config.csrfHeader);
+    return send(config, url, fetchOptions, options, handleResponseJSON);
+}
+
+
+/**
+ * Sends the request using the config's 'fetch', aborting it if it takes longer than the timeout or the
+ * caller's signal aborts. The timeout includes reading the response, so 'handler' runs before we clear it.
+ *
+ * @param {ClientConfig} config The connection info for the service
+ * @param {string} url The full URL of the request
+ * @param {object} fetchOptions The options we're passing to 'fetch'
+ * @param {CallOptions} options The options for this call (we only care about 'timeout' and 'signal')
+ * @param {function(Response): Promise<*>} handler Turns the response into the value we resolve w/
+ * @returns {Promise<*>}
+ */
+async function send(config, url, fetchOptions, {timeout, signal}, handler) {
+    timeout = typeof timeout === 'number' ? timeout : config.timeout;
+
+    let timedOut = false;
+    const controller = new AbortController();
+    const abort = () => controller.abort(signal.reason);
+    const timer = timeout > 0 ? setTimeout(() => { timedOut = true; controller.abort(); }, timeout) : null;
+    if (signal) {
+        signal.aborted ? abort() : signal.addEventListener('abort', abort);
+    }
+
+    try {
+        const response = await config.fetch(url, Object.assign({}, applyCookieJar(fetchOptions, config.cookieJar), {signal: controller.signal}));
+        storeCookies(config.cookieJar, response);
+        return await handler(response);
+    }
+    catch (err) {
+        if (timedOut) {
+            throw new GatewayError(408, 'request timed out after ' + timeout + 'ms');
+        }
+        throw err;
+    }
+    finally {
+        clearTimeout(timer);
+        if (signal) {
+            signal.removeEventListener('abort', abort);
         }
+    }
+}
 
-        const method = 'POST';
-        const route = '/NameService.Split';
-        const url = this._baseURL + '/' + buildRequestPath(method, route, serviceRequest);
-        const fetchOptions = {
-            method: 'POST',
-            headers: {
-                'Authorization': authorization || this._authorization,
-                'Accept': 'application/json,*/*',
-                'Content-Type': 'application/json; charset=utf-8',
-            },
-            body: JSON.stringify(serviceRequest),
-        };
-
-        const response = await this._fetch(url, fetchOptions);
-        return handleResponseJSON(response);
+/**
+ * Adds the Authorization and metadata (X-RPC-Values) headers to the other headers for the request. The
+ * call's authorization overrides the client's, and the call's metadata is merged w/ the client's.
+ *
+ * @param {ClientConfig} config The connection info for the service
+ * @param {CallOptions} options The options for this call
+ * @param {Object} headers The other headers for the request
+ * @returns {Promise<Object>} The same headers object w/ the new headers
+ */
+async function requestHeaders(config, {authorization, metadata}, headers) {
+    authorization = authorization || config.authorization;
+    if (typeof authorization === 'function') {
+        authorization = await authorization();
     }
-    
+    if (authorization) {
+        headers['Authorization'] = authorization;
+    }
+
+    const values = Object.assign({}, config.metadata, metadata);
+    if (Object.keys(values).length > 0) {
+        headers['X-RPC-Values'] = encodeMetadata(values);
+    }
+    return headers;
+}
+
+/**
+ * Encodes the metadata values the same way that the Go client does (e.g. {"TraceID":{"value":"abc"}}). Header
+ * values can only contain ASCII characters, so everything else is escaped (JSON decoders understand "\uXXXX").
+ *
+ * @param {Object} values The metadata values keyed by name
+ * @returns {string}
+ */
+function encodeMetadata(values) {
+    const entries = {};
+    Object.keys(values).forEach(key => entries[key] = {value: values[key]});
+    return JSON.stringify(entries).replace(/[\u007f-\uffff]/g, c => '\\u' + ('0000' + c.charCodeAt(0).toString(16)).slice(-4));
 }
 
 /**
@@ -249,9 +382,15 @@ class NameServiceClient {
  */
 function buildRequestPath(method, path, serviceRequest) {
     const pathSegments = path.split("/").map(segment => {
-        return segment.startsWith(":")
-            ? attributeValue(serviceRequest, segment.substring(1))
-            : segment;
+        if (segment.startsWith(":")) {
+            return attributeValue(serviceRequest, segment.substring(1));
+        }
+        if (segment.startsWith("*")) {
+            // Catch-all params (e.g. "*path") fill in the rest of the path, so keep the slashes in the value.
+            const value = attributeValue(serviceRequest, segment.substring(1)) || '';
+            return value.replace(/^(%2F)+/i, '').replace(/%2F/gi, '/');
+        }
+        return segment;
     });
     const resolvedPath = trimSlashes(pathSegments.join("/"));
 
@@ -321,16 +460,23 @@ async function handleResponseJSON(response) {
     if (response.status >= 400) {
         throw await newError(response);
     }
-    return await response.json();
+    // "204 No Content" and "205 Reset Content" responses don't have a body to parse.
+    if (response.status === 204 || response.status === 205) {
+        return {};
+    }
+    const responseValue = await response.json();
+    return isEnvelope(response) ? responseValue.data : responseValue;
 }
 
 /**
  * Accepts the full response data and the request's promise resolve/reject and determines
  * which to invoke. This assumes that you want the raw bytes as a blob from the HTTP response
  * rather than treating it like JSON. This will also capture the Content-Type value as well as
- * the "filename" from the Content-Disposition if it's set to "attachment".
+ * the "filename" from the Content-Disposition if it's set to "attachment". The ETag/Last-Modified
+ * and the range of bytes we received are included so that you can resume the download later.
  *
- * @returns { {content: Blob, contentType: string, contentFileName: string} }
+ * @returns { {Content: Blob, ContentType: string, ContentFileName: string, ContentETag: string,
+ *     ContentModTime: Date|null, ContentRangeStart: number, ContentRangeEnd: number, ContentRangeSize: number} }
  */
 async function handleResponseRaw(response) {
     if (response.status >= 400) {
@@ -339,11 +485,57 @@ async function handleResponseRaw(response) {
     const content = await response.blob();
     const contentType = response.headers.get('content-type') || 'application/octet-stream';
     const contentFileName = dispositionFileName(response.headers.get('content-disposition'));
+    const lastModified = response.headers.get('last-modified');
+    const contentRange = parseContentRange(response, content.size);
     return {
         Content: content,
         ContentType: contentType,
         ContentFileName: contentFileName,
+        ContentETag: response.headers.get('etag') || '',
+        ContentModTime: lastModified ? new Date(lastModified) : null,
+        ContentRangeStart: contentRange.start,
+        ContentRangeEnd: contentRange.end,
+        ContentRangeSize: contentRange.size,
+    }
+}
+
+/**
+ * Asks the gateway to skip the first 'offset' bytes of the raw content. When you supply the validator
+ * (the ETag or Last-Modified of the original download), the gateway only skips them if the content
+ * hasn't changed since then.
+ *
+ * @param {object} fetchOptions The options we're about to pass to 'fetch'
+ * @param {number} [offset] The number of bytes to skip
+ * @param {string} [validator] The ETag or Last-Modified value of the original download
+ */
+function applyContentOffset(fetchOptions, offset, validator) {
+    if (!offset || offset <= 0) {
+        return;
+    }
+    fetchOptions.headers['Range'] = 'bytes=' + offset + '-';
+    if (validator) {
+        fetchOptions.headers['If-Range'] = validator instanceof Date ? validator.toUTCString() : validator;
+    }
+}
+
+/**
+ * Determines which bytes of the content are in the response. For 206 responses, that comes from
+ * the Content-Range header (e.g. "bytes 100-199/1000"). Otherwise, the response has all of it.
+ *
+ * @param {Response} response The raw HTTP response
+ * @param {number} length The number of bytes we actually received
+ * @returns { {start: number, end: number, size: number} }
+ */
+function parseContentRange(response, length) {
+    const match = /^bytes (\d+)-(\d+)\/(\d+|\*)$/.exec(response.headers.get('content-range') || '');
+    if (response.status !== 206 || !match) {
+        return { start: 0, end: length - 1, size: length };
     }
+    return {
+        start: parseInt(match[1], 10),
+        end: parseInt(match[2], 10),
+        size: match[3] === '*' ? -1 : parseInt(match[3], 10),
+    };
 }
 
 /**
@@ -353,11 +545,18 @@ async function handleResponseRaw(response) {
  * @returns {Promise<GatewayError>}
  */
 async function newError(response) {
-    const responseValue = isJSON(response)
+    let responseValue = isJSON(response)
         ? await response.json()
         : await response.text();
 
-    throw new GatewayError(response.status, parseErrorMessage(responseValue));
+    // The gateway wrapped the failure in an envelope, so the actual error is in the 'error'.
+    if (isEnvelope(response) && responseValue && responseValue.error) {
+        responseValue = responseValue.error;
+    }
+
+    const code = responseValue && typeof responseValue === 'object' ? responseValue.code : undefined;
+    const details = responseValue && typeof responseValue === 'object' ? responseValue.details : undefined;
+    throw new GatewayError(response.status, parseErrorMessage(responseValue), code, details);
 }
 
 /**
@@ -379,17 +578,25 @@ function dispositionFileName(contentDisposition = '') {
     return fileName;
 }
 
+/**
+ * Determines whether or not the gateway wrapped the response value in an
+ * envelope (i.e. {"data": ..., "error": ..., "meta": ...}) that we need to unwrap.
+ */
+function isEnvelope(response) {
+    return !!response.headers.get('x-rpc-envelope');
+}
+
 /**
  * Determines whether or not the response has a content type of JSON.
  */
 function isJSON(response) {
-    const contentType = response.headers.get('content-type');
-    return contentType && contentType.toLowerCase().startsWith('application/json');
+    const contentType = (response.headers.get('content-type') || '').toLowerCase();
+    return contentType.startsWith('application/json') || contentType.startsWith('application/problem+json');
 }
 
 /**
 * Looks at the response value and attempts to peel off an error message from it using the standard
-* error JSON structures used by frodo gateways.
+* error JSON structures used by frodo gateways (including RFC 7807 "problem+json" responses).
 *
 * @param {*} err The error whose raw message you're trying to extract.
 * @returns {string}
@@ -401,6 +608,12 @@ function parseErrorMessage(err) {
     if (typeof err.message !== 'undefined') {
         return err.message;
     }
+    if (typeof err.detail !== 'undefined') {
+        return err.detail;
+    }
+    if (typeof err.title !== 'undefined') {
+        return err.title;
+    }
     if (typeof err.error !== 'undefined') {
         return err.error;
     }
@@ -418,6 +631,173 @@ function supportsBody(method) {
     return method === 'POST' || method === 'PUT' || method === 'PATCH';
 }
 
+/**
+ * Removes the attributes for header/cookie fields (e.g. `frodo:"header=X-Tenant-ID"`) from the request
+ * and returns their values keyed by the header/cookie name. Empty values are not included.
+ *
+ * @param {Object} serviceRequest The (copy of the) input struct for the service call
+ * @param {Object} attributes Maps each header/cookie name to the request attribute that holds its value
+ * @returns {Object} The header/cookie values to send (e.g. {"X-Tenant-ID": "acme"})
+ */
+function removeValues(serviceRequest, attributes) {
+    const values = {};
+    Object.keys(attributes).forEach(name => {
+        const normalized = attributes[name].toLowerCase();
+        Object.keys(serviceRequest).filter(key => key.toLowerCase() === normalized).forEach(key => {
+            const value = serviceRequest[key];
+            delete serviceRequest[key];
+            if (value !== null && value !== undefined && value !== '') {
+                values[name] = String(value);
+            }
+        });
+    });
+    return values;
+}
+
+/**
+ * Deletes the given attributes from the request when their values are empty (null, undefined, '', 0, false,
+ * or an empty array). This mirrors the `json:",omitempty"` behavior of the Go client.
+ *
+ * @param {Object} serviceRequest The (copy of the) input struct for the service call
+ * @param {string[]} attributes The names of the attributes tagged w/ 'omitempty'
+ * @returns {Object} The same request w/ the empty attributes removed
+ */
+function removeEmptyValues(serviceRequest, attributes) {
+    attributes.forEach(attribute => {
+        const value = serviceRequest[attribute];
+        if (!value || (Array.isArray(value) && value.length === 0)) {
+            delete serviceRequest[attribute];
+        }
+    });
+    return serviceRequest;
+}
+
+/**
+ * Adds the cookie values to the request's "Cookie" header. Browsers don't let you set this header (they
+ * manage cookies for you), but it works in Node and other non-browser environments.
+ *
+ * @param {Object} fetchOptions The options we're about to supply to fetch()
+ * @param {Object} cookies The cookie values keyed by the cookie name
+ */
+function applyCookies(fetchOptions, cookies) {
+    const pairs = Object.keys(cookies).map(name => name + '=' + cookies[name]);
+    if (pairs.length > 0) {
+        fetchOptions.headers['Cookie'] = pairs.join('; ');
+    }
+}
+
+/**
+ * When the client has a cookie jar, this adds the jar's cookies to the request's "Cookie" header (alongside
+ * the values of any cookie fields) and has browsers include their own cookies even for cross-origin calls.
+ *
+ * @param {Object} fetchOptions The options we're about to supply to fetch()
+ * @param {Object|null} cookieJar The cookie values we've received so far, keyed by the cookie name
+ * @returns {Object} The fetch options to use; a copy when we had to change anything.
+ */
+function applyCookieJar(fetchOptions, cookieJar) {
+    if (!cookieJar) {
+        return fetchOptions;
+    }
+
+    const headers = Object.assign({}, fetchOptions.headers);
+    const pairs = headers['Cookie'] ? [headers['Cookie']] : [];
+    const sent = pairs.join('; ').split(';').map(pair => pair.split('=')[0].trim());
+    Object.keys(cookieJar)
+        .filter(name => !sent.includes(name))
+        .forEach(name => pairs.push(name + '=' + cookieJar[name]));
+    if (pairs.length > 0) {
+        headers['Cookie'] = pairs.join('; ');
+    }
+    return Object.assign({}, fetchOptions, {headers, credentials: 'include'});
+}
+
+/**
+ * Saves the cookies from the response's "Set-Cookie" headers in the client's cookie jar, removing the
+ * ones that the gateway expired. Browsers hide these headers from JavaScript (they manage the cookies
+ * themselves), so this only does anything in Node and other non-browser environments.
+ *
+ * @param {Object|null} cookieJar The cookie values we've received so far, keyed by the cookie name
+ * @param {Response} response The response from the gateway
+ */
+function storeCookies(cookieJar, response) {
+    if (!cookieJar || !response.headers) {
+        return;
+    }
+
+    const setCookies = typeof response.headers.getSetCookie === 'function'
+        ? response.headers.getSetCookie()
+        : (response.headers.get('Set-Cookie') || '').split(/,(?=\s*[^;,=\s]+=)/);
+
+    setCookies.filter(setCookie => setCookie.trim()).forEach(setCookie => {
+        const [pair, ...attributes] = setCookie.split(';').map(part => part.trim());
+        const separator = pair.indexOf('=');
+        if (separator <= 0) {
+            return;
+        }
+        const name = pair.substring(0, separator);
+        const expired = attributes.some(attr => {
+            const [key, value = ''] = attr.split('=');
+            return (key.toLowerCase() === 'max-age' && Number(value) <= 0) ||
+                (key.toLowerCase() === 'expires' && Date.parse(value) <= Date.now());
+        });
+        if (expired) {
+            delete cookieJar[name];
+        }
+        else {
+            cookieJar[name] = pair.substring(separator + 1);
+        }
+    });
+}
+
+/**
+ * Echoes the gateway's CSRF token (if the browser has one) in a header on state-changing requests. This
+ * is a no-op outside of the browser or when the gateway isn't using the CSRF middleware.
+ *
+ * @param {object} fetchOptions The options for the request we're about to send
+ * @param {string} cookieName The name of the cookie that holds the CSRF token
+ * @param {string} headerName The name of the header where we echo the token
+ */
+function applyCSRFToken(fetchOptions, cookieName, headerName) {
+    if (!supportsBody(fetchOptions.method) && fetchOptions.method !== 'DELETE') {
+        return;
+    }
+    const token = cookieValue(cookieName);
+    if (token) {
+        fetchOptions.headers[headerName] = token;
+    }
+}
+
+/**
+ * Fails w/ the same 413 error that the gateway would when the request body is bigger than the function's
+ * "MAXBYTES" limit, so that we don't bother uploading it.
+ *
+ * @param {string} body The JSON-encoded request body
+ * @param {number} maxBytes The largest body (in bytes) that the gateway accepts for the function
+ */
+function checkRequestSize(body, maxBytes) {
+    const size = typeof TextEncoder !== 'undefined' ? new TextEncoder().encode(body).length : body.length;
+    if (size > maxBytes) {
+        throw new GatewayError(413, 'request body too large: limit is ' + maxBytes + ' bytes');
+    }
+}
+
+/**
+ * Looks up the value of the browser cookie w/ the given name.
+ *
+ * @param {string} name The name of the cookie to find
+ * @returns {string} The cookie's value or '' if there isn't one (or we're not in a browser)
+ */
+function cookieValue(name) {
+    if (typeof document === 'undefined' || !document.cookie) {
+        return '';
+    }
+    const prefix = name + '=';
+    const cookie = document.cookie.split(';')
+        .map(c => c.trim())
+        .find(c => c.startsWith(prefix));
+    return cookie ? decodeURIComponent(cookie.substring(prefix.length)) : '';
+}
+
 /**
  * Removes all leading/trailing slashes from the given URL segment.
  *
@@ -445,7 +825,7 @@ function trimSlashes(value) {
 */
 function defaultFetch() {
     if (typeof fetch === 'undefined') {
-        throw new Error('no global fetch found - if using node, install/import node-fetch');
+        throw new Error('no global fetch found - use Node 18+ or supply your own fetch implementation');
     }
 
     const runningInBrowser = typeof window !== 'undefined';
@@ -457,7 +837,7 @@ function defaultFetch() {
 * It captures the server's error message as well as HTTP status so you can properly handle the
 * result in your consumer code.
 */
-class GatewayError {
+export class GatewayError {
     /**
     * The HTTP 4XX/5XX status code of the failure.
     *
@@ -472,9 +852,26 @@ class GatewayError {
     */
     message;
 
-    constructor(status, message) {
+    /**
+    * The application-specific error code (e.g. "ORDER_EXPIRED") if the server supplied one.
+    *
+    * @type {string}
+    */
+    code;
+
+    /**
+    * Structured data the server supplied to describe the failure in more detail (e.g. which
+    * fields failed validation).
+    *
+    * @type {Object}
+    */
+    details;
+
+    constructor(status, message, code = '', details = {}) {
         this.status = status;
         this.message = message;
+        this.code = code || '';
+        this.details = details || {};
     }
 
     toString() {
@@ -482,60 +879,101 @@ class GatewayError {
     }
 }
 
+/**
+ * @typedef { object } ClientOptions
+ * @property { fetch|* } [fetch] Provide a custom implementation for the 'fetch' API. Not necessary in
+ *     browsers, Node 18+, or edge runtimes since they all have a global 'fetch'.
+ * @property { string|function(): (string|Promise<string>) } [authorization] Use these credentials in the
+ *     HTTP Authorization header for every request. Supply a function to look up the current credentials
+ *     (e.g. a token that gets refreshed) before each request. If you allow multiple users in your system,
+ *     leave this blank and use the authorization option on each request.
+ * @property { Object } [metadata] Metadata values (e.g. {TraceID: 'abc'}) to send w/ every request. Your
+ *     Go service can look them up using metadata.Value().
+ * @property { number } [timeout] How many milliseconds each request can take before we abort it (default
+ *     30000). Use 0 to wait forever.
+ * @property { string } [csrfCookie] The name of the cookie w/ the gateway's CSRF token. The client echoes
+ *     it in a header on every POST/PUT/PATCH/DELETE. Defaults to "frodo-csrf".
+ * @property { string } [csrfHeader] The name of the header where the client echoes the CSRF token.
+ *     Defaults to "X-CSRF-Token".
+ * @property { boolean } [cookies] Remember the cookies that the gateway sets (e.g. a session) and send
+ *     them back on later calls. Each config keeps its own cookies. In browsers, this has the browser
+ *     include its cookies on cross-origin calls, too.
+ */
 
 /**
- * @typedef { object } SortNameResponse
- * @property { string } [SortName]
-*/
+ * @typedef { object } ClientConfig The resolved ClientOptions (see nameServiceConfig()).
+ */
+
 /**
- * @typedef { object } FirstNameRequest
+ * @typedef { object } CallOptions
+ * @property { string|function(): (string|Promise<string>) } [authorization] The HTTP Authorization header
+ *     value to include in the request. This will override any authorization you might have applied when
+ *     constructing the client. Use this in multi-tenant situations where multiple users might utilize
+ *     this service.
+ * @property { Object } [metadata] Metadata values to send w/ this request (merged w/ the client's).
+ * @property { number } [timeout] How many milliseconds this request can take (overrides the client's).
+ * @property { AbortSignal } [signal] Aborts the request when the signal does (e.g. the user navigated away).
+ */
+
+/**
+ * @typedef { CallOptions } ContentCallOptions
+ * @property { number } [contentOffset] Skip this many bytes of the content (e.g. to resume a download that
+ *     was interrupted). Check the response's ContentRangeStart to see if the gateway actually skipped them.
+ * @property { string } [contentValidator] The ContentETag (or ContentModTime) you received w/ the original
+ *     download. The gateway only honors the offset when the content hasn't changed.
+ */
+
+/**
+ * @typedef { object } DownloadExtRequest
  * @property { string } [Name]
+ * @property { string } [Ext]
 */
 /**
- * @typedef { object } LastNameResponse
- * @property { string } [LastName]
+ * @typedef { object } DownloadExtResponse
 */
 /**
- * @typedef { object } SortNameRequest
+ * @typedef { object } DownloadRequest
  * @property { string } [Name]
 */
 /**
- * @typedef { object } DownloadExtResponse
+ * @typedef { object } DownloadResponse
 */
 /**
- * @typedef { object } SplitResponse
- * @property { string } [FirstName]
- * @property { string } [LastName]
+ * @typedef { object } FirstNameRequest
+ * @property { string } [Name]
 */
 /**
- * @typedef { object } DownloadResponse
+ * @typedef { object } FirstNameResponse
+ * @property { string } [FirstName]
 */
 /**
  * @typedef { object } LastNameRequest
  * @property { string } [Name]
 */
+/**
+ * @typedef { object } LastNameResponse
+ * @property { string } [LastName]
+*/
 /**
  * @typedef { object } NameRequest
  * @property { string } [Name]
 */
 /**
- * @typedef { object } DownloadExtRequest
+ * @typedef { object } SortNameRequest
  * @property { string } [Name]
- * @property { string } [Ext]
 */
 /**
- * @typedef { object } FirstNameResponse
- * @property { string } [FirstName]
+ * @typedef { object } SortNameResponse
+ * @property { string } [SortName]
 */
 /**
  * @typedef { object } SplitRequest
  * @property { string } [Name]
 */
 /**
- * @typedef { object } DownloadRequest
- * @property { string } [Name]
+ * @typedef { object } SplitResponse
+ * @property { string } [FirstName]
+ * @property { string } [LastName]
 */
 
-module.exports = {
-    NameServiceClient,
-};
+
@@ -1,7 +1,9 @@
 // Code generated by Frodo - DO NOT EDIT.
 //
-//   Timestamp: Tue, 10 May 2022 16:18:40 EDT
+//   Timestamp: Fri, 16 Oct 2026 18:55:07 UTC
 //   Source:    example/names/name_service.go
+//   Checksum:  sha256:74ad51b7b1cab6474f7901538793d72d79d1ac3928cfc6e8b1af09d9f57a7457
+//   Version:   devel
 //   Generator: https://github.com/monadicstack/frodo
 //
 package names
@@ -12,7 +14,7 @@ import (
 
 	"github.com/monadicstack/frodo/example/names"
 	"github.com/monadicstack/frodo/rpc"
-	"github.com/monadicstack/respond"
+	"github.com/monadicstack/frodo/rpc/events"
 )
 
 // NewNameServiceGateway accepts your "real" NameService instance (the thing that really does the work), and
@@ -36,17 +38,38 @@ func NewNameServiceGateway(service names.NameService, options ...rpc.GatewayOpti
 		Path:        "/NameService.Download",
 		ServiceName: "NameService",
 		Name:        "Download",
+		Status:      200,
 		Handler: func(w http.ResponseWriter, req *http.Request) {
-			response := respond.To(w, req)
-
 			serviceRequest := names.DownloadRequest{}
 			if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
-				response.Fail(err)
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := rpc.Normalize(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := gw.OnRequestBound(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
 				return
 			}
 
 			serviceResponse, err := service.Download(req.Context(), &serviceRequest)
-			response.Reply(200, serviceResponse, err)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := events.Flush(req.Context()); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Redact(req.Context(), serviceResponse)
+			response, err := gw.OnResponseMarshal(req.Context(), serviceResponse)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Reply(w, req, 200, response)
 		},
 	})
 
@@ -55,17 +78,38 @@ func NewNameServiceGateway(service names.NameService, options ...rpc.GatewayOpti
 		Path:        "/NameService.DownloadExt",
 		ServiceName: "NameService",
 		Name:        "DownloadExt",
+		Status:      200,
 		Handler: func(w http.ResponseWriter, req *http.Request) {
-			response := respond.To(w, req)
-
 			serviceRequest := names.DownloadExtRequest{}
 			if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
-				response.Fail(err)
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := rpc.Normalize(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := gw.OnRequestBound(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
 				return
 			}
 
 			serviceResponse, err := service.DownloadExt(req.Context(), &serviceRequest)
-			response.Reply(200, serviceResponse, err)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := events.Flush(req.Context()); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Redact(req.Context(), serviceResponse)
+			response, err := gw.OnResponseMarshal(req.Context(), serviceResponse)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Reply(w, req, 200, response)
 		},
 	})
 
@@ -74,17 +118,38 @@ func NewNameServiceGateway(service names.NameService, options ...rpc.GatewayOpti
 		Path:        "/NameService.FirstName",
 		ServiceName: "NameService",
 		Name:        "FirstName",
+		Status:      200,
 		Handler: func(w http.ResponseWriter, req *http.Request) {
-			response := respond.To(w, req)
-
 			serviceRequest := names.FirstNameRequest{}
 			if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
-				response.Fail(err)
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := rpc.Normalize(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := gw.OnRequestBound(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
 				return
 			}
 
 			serviceResponse, err := service.FirstName(req.Context(), &serviceRequest)
-			response.Reply(200, serviceResponse, err)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := events.Flush(req.Context()); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Redact(req.Context(), serviceResponse)
+			response, err := gw.OnResponseMarshal(req.Context(), serviceResponse)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Reply(w, req, 200, response)
 		},
 	})
 
@@ -93,17 +158,38 @@ func NewNameServiceGateway(service names.NameService, options ...rpc.GatewayOpti
 		Path:        "/NameService.LastName",
 		ServiceName: "NameService",
 		Name:        "LastName",
+		Status:      200,
 		Handler: func(w http.ResponseWriter, req *http.Request) {
-			response := respond.To(w, req)
-
 			serviceRequest := names.LastNameRequest{}
 			if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
-				response.Fail(err)
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := rpc.Normalize(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := gw.OnRequestBound(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
 				return
 			}
 
 			serviceResponse, err := service.LastName(req.Context(), &serviceRequest)
-			response.Reply(200, serviceResponse, err)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := events.Flush(req.Context()); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Redact(req.Context(), serviceResponse)
+			response, err := gw.OnResponseMarshal(req.Context(), serviceResponse)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Reply(w, req, 200, response)
 		},
 	})
 
@@ -112,17 +198,38 @@ func NewNameServiceGateway(service names.NameService, options ...rpc.GatewayOpti
 		Path:        "/NameService.SortName",
 		ServiceName: "NameService",
 		Name:        "SortName",
+		Status:      200,
 		Handler: func(w http.ResponseWriter, req *http.Request) {
-			response := respond.To(w, req)
-
 			serviceRequest := names.SortNameRequest{}
 			if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
-				response.Fail(err)
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := rpc.Normalize(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := gw.OnRequestBound(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
 				return
 			}
 
 			serviceResponse, err := service.SortName(req.Context(), &serviceRequest)
-			response.Reply(200, serviceResponse, err)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := events.Flush(req.Context()); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Redact(req.Context(), serviceResponse)
+			response, err := gw.OnResponseMarshal(req.Context(), serviceResponse)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Reply(w, req, 200, response)
 		},
 	})
 
@@ -131,20 +238,51 @@ func NewNameServiceGateway(service names.NameService, options ...rpc.GatewayOpti
 		Path:        "/NameService.Split",
 		ServiceName: "NameService",
 		Name:        "Split",
+		Status:      200,
 		Handler: func(w http.ResponseWriter, req *http.Request) {
-			response := respond.To(w, req)
-
 			serviceRequest := names.SplitRequest{}
 			if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
-				response.Fail(err)
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := rpc.Normalize(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := gw.OnRequestBound(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
 				return
 			}
 
 			serviceResponse, err := service.Split(req.Context(), &serviceRequest)
-			response.Reply(200, serviceResponse, err)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := events.Flush(req.Context()); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Redact(req.Context(), serviceResponse)
+			response, err := gw.OnResponseMarshal(req.Context(), serviceResponse)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Reply(w, req, 200, response)
 		},
 	})
 
+	if gw.BatchConcurrency > 0 {
+		gw.Register(gw.BatchEndpoint())
+	}
+	if gw.JSONRPCConcurrency > 0 {
+		gw.Register(gw.JSONRPCEndpoint())
+	}
+	if gw.EndpointListing {
+		gw.Register(gw.EndpointListingEndpoint())
+	}
+
 	return NameServiceGateway{Gateway: gw, service: service}
 }
 
@@ -3,15 +3,22 @@ package generate
 import (
 	"bytes"
 	"embed"
+	"encoding/json"
 	"fmt"
 	"go/format"
+	"go/types"
 	"io/fs"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 
+	"github.com/monadicstack/frodo/internal/constraints"
 	"github.com/monadicstack/frodo/internal/naming"
 	"github.com/monadicstack/frodo/parser"
 )
@@ -24,20 +31,32 @@ var StandardTemplates embed.FS
 // code/project file. The 'ctx' will be fed in as the root data to the Go template represented by
 // the fileTemplate parameter.
 func File(ctx *parser.Context, fileTemplate FileTemplate) error {
-	inputFileName := filepath.Base(ctx.Path)
-	inputDir := filepath.Dir(ctx.Path)
+	// Step 1: Generate the source code (in memory) for the artifact.
+	outputPath, sourceCode, err := Render(ctx, fileTemplate)
+	if err != nil {
+		return err
+	}
 
-	outputFileName := strings.TrimSuffix(inputFileName, ".go") + ".gen." + fileTemplate.Name
-	outputDir := filepath.Join(inputDir, "gen")
-	outputPath := filepath.Join(outputDir, outputFileName)
+	// Step 2: Write the code to the output ".gen.xxx" file.
+	if err = writeArtifact(outputPath, sourceCode); err != nil {
+		return fmt.Errorf("error writing generated code: %s: %w", fileTemplate.Name, err)
+	}
+	return nil
+}
 
-	// Step 1: Create the "gen/" directory in the same directory as the file we're parsing.
-	err := os.MkdirAll(outputDir, os.ModePerm)
-	if err != nil {
+// writeArtifact writes the generated source code to the file at 'outputPath', creating its directory if need be.
+func writeArtifact(outputPath string, sourceCode []byte) error {
+	// If the only thing that changed since the last time we generated this file is the timestamp, leave
+	// the old file alone. This way re-running frodo on services you didn't touch doesn't muck up your diffs.
+	if unchanged(outputPath, sourceCode) {
+		return nil
+	}
+
+	outputDir := filepath.Dir(outputPath)
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
 		return fmt.Errorf("unable to create directory: %s: %w", outputDir, err)
 	}
 
-	// Step 2: Recreate the output ".gen.xxx" file from scratch.
 	_ = os.Remove(outputPath)
 	outputFile, err := os.Create(outputPath)
 	if err != nil {
@@ -45,26 +64,167 @@ func File(ctx *parser.Context, fileTemplate FileTemplate) error {
 	}
 	defer outputFile.Close()
 
-	// Step 3: Generate a []byte containing all of the source code bytes that we generated from the template.
+	if _, err = outputFile.Write(sourceCode); err != nil {
+		return fmt.Errorf("unable to write file: %s: %w", outputPath, err)
+	}
+	return nil
+}
+
+// UpToDate generates the artifact in memory and compares it to the one that's already on disk. It
+// returns false if the file doesn't exist or its code is different from what we'd generate now (e.g. you
+// changed the service but forgot to re-run frodo). The timestamp/version in the header don't count.
+func UpToDate(ctx *parser.Context, fileTemplate FileTemplate) (bool, error) {
+	outputPath, sourceCode, err := Render(ctx, fileTemplate)
+	if err != nil {
+		return false, err
+	}
+	return unchanged(outputPath, sourceCode), nil
+}
+
+// SnapshotResult describes how an artifact compares to its golden file (see Snapshot).
+type SnapshotResult struct {
+	// GoldenPath is the golden file that we compared the artifact to.
+	GoldenPath string
+	// Match is true when the artifact's code is the same as the golden file's. Just like UpToDate(), the
+	// timestamp/version in the header don't count.
+	Match bool
+	// Diff describes the first line that's different (e.g. `line 12: expected "foo", got "bar"`) when it doesn't match.
+	Diff string
+}
+
+// Snapshot renders the artifact and compares it to its golden file in 'goldenDir' (same name as the artifact, so the
+// "client.ts" artifact for "foo_service.go" is "foo_service.gen.client.ts"). This lets custom template authors check
+// their output against fixtures whenever frodo's Context changes. When 'update' is true, we (re)write the golden
+// file w/ the current output instead, so the result is always a match.
+func Snapshot(ctx *parser.Context, fileTemplate FileTemplate, goldenDir string, update bool) (SnapshotResult, error) {
+	outputPath, sourceCode, err := Render(ctx, fileTemplate)
+	if err != nil {
+		return SnapshotResult{}, err
+	}
+
+	result := SnapshotResult{GoldenPath: filepath.Join(goldenDir, filepath.Base(outputPath))}
+	if update {
+		if err = writeArtifact(result.GoldenPath, sourceCode); err != nil {
+			return result, fmt.Errorf("error writing golden file: %s: %w", fileTemplate.Name, err)
+		}
+		result.Match = true
+		return result, nil
+	}
+
+	golden, err := os.ReadFile(result.GoldenPath)
+	if err != nil {
+		result.Diff = "missing golden file"
+		return result, nil
+	}
+	result.Match = unchanged(result.GoldenPath, sourceCode)
+	if !result.Match {
+		result.Diff = firstDifference(golden, sourceCode)
+	}
+	return result, nil
+}
+
+// firstDifference describes the first line where the actual source code doesn't match the expected code. Just
+// like unchanged(), lines w/ the timestamp/version in the header don't count.
+func firstDifference(expected []byte, actual []byte) string {
+	expectedLines := strings.Split(string(expected), "\n")
+	actualLines := strings.Split(string(actual), "\n")
+	for i := 0; i < len(expectedLines) || i < len(actualLines); i++ {
+		switch {
+		case i >= len(expectedLines):
+			return fmt.Sprintf("line %d: expected end of file, got %q", i+1, actualLines[i])
+		case i >= len(actualLines):
+			return fmt.Sprintf("line %d: expected %q, got end of file", i+1, expectedLines[i])
+		case expectedLines[i] == actualLines[i]:
+			continue
+		case volatileHeader(expectedLines[i]) && volatileHeader(actualLines[i]):
+			continue
+		default:
+			return fmt.Sprintf("line %d: expected %q, got %q", i+1, expectedLines[i], actualLines[i])
+		}
+	}
+	return ""
+}
+
+// Render evaluates the file template for the parsed service, returning the path where the artifact
+// belongs and its source code. Unlike File(), this does not write anything to disk.
+func Render(ctx *parser.Context, fileTemplate FileTemplate) (string, []byte, error) {
+	outputPath := OutputPath(ctx, fileTemplate)
+
 	sourceCode, err := fileTemplate.Eval(ctx)
 	if err != nil {
-		return fmt.Errorf("template eval error: %s: %v", fileTemplate.Name, err)
+		return outputPath, nil, fmt.Errorf("template eval error: %s: %v", fileTemplate.Name, err)
 	}
 
-	// Step 4: Run the generated source code through "go fmt" (if generating a Go artifact)
+	// Run the generated source code through "go fmt" (if generating a Go artifact)
 	original := sourceCode
 	sourceCode, err = prettify(fileTemplate, sourceCode)
 	if err != nil {
 		fmt.Println(string(original))
-		return fmt.Errorf("error running 'go fmt': %s: %v", fileTemplate.Name, err)
+		return outputPath, nil, fmt.Errorf("error running 'go fmt': %s: %v", fileTemplate.Name, err)
 	}
+	return outputPath, sourceCode, nil
+}
+
+// OutputPath determines where we write the artifact for the given service. For instance, when generating
+// the "client.js" artifact for "foo/foo_service.go", this is "foo/gen/foo_service.gen.client.js".
+//
+// Java is the exception since the file name must match the public class inside of it. The "client.java"
+// artifact for the FooService is "foo/gen/FooServiceClient.java" and "ok-http-engine.java" is
+// "foo/gen/FooServiceOkHttpEngine.java".
+func OutputPath(ctx *parser.Context, fileTemplate FileTemplate) string {
+	if strings.HasSuffix(fileTemplate.Name, ".java") && ctx.Service != nil {
+		className := ctx.Service.Name
+		for _, word := range strings.Split(strings.TrimSuffix(fileTemplate.Name, ".java"), "-") {
+			className += naming.ToUpperCamel(word)
+		}
+		return filepath.Join(ctx.OutputPackage.Directory, className+".java")
+	}
+
+	inputFileName := filepath.Base(ctx.Path)
+	outputFileName := strings.TrimSuffix(inputFileName, ".go") + ".gen." + fileTemplate.Name
+	return filepath.Join(ctx.OutputPackage.Directory, outputFileName)
+}
 
-	// Step 5: Write your cleaned up code to the actual output file.
-	_, err = outputFile.Write(sourceCode)
+// artifactFileName returns the base name of another artifact generated for the same service (e.g. when
+// the "react-query.js" hooks need to import "foo_service.gen.client.js").
+func artifactFileName(ctx *parser.Context, name string) string {
+	return filepath.Base(OutputPath(ctx, FileTemplate{Name: name}))
+}
+
+// unchanged returns true when the file at 'path' already contains 'sourceCode', ignoring the
+// "Timestamp:" and "Version:" lines in the header comment of generated files.
+func unchanged(path string, sourceCode []byte) bool {
+	existing, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("error writing generated code: %s: %w", fileTemplate.Name, err)
+		return false
 	}
-	return nil
+	return bytes.Equal(withoutVolatileHeaders(existing), withoutVolatileHeaders(sourceCode))
+}
+
+// withoutVolatileHeaders strips the lines from the header comment of generated source code that change
+// even when the code doesn't (i.e. when we generated it and which version of frodo did it).
+func withoutVolatileHeaders(sourceCode []byte) []byte {
+	var results [][]byte
+	lines := bytes.Split(sourceCode, []byte("\n"))
+	for i, line := range lines {
+		if !bytes.HasPrefix(line, []byte("//")) && !bytes.HasPrefix(line, []byte("#")) {
+			results = append(results, lines[i:]...)
+			break
+		}
+		if volatileHeader(string(line)) {
+			continue
+		}
+		results = append(results, line)
+	}
+	return bytes.Join(results, []byte("\n"))
+}
+
+// volatileHeader returns true for the header comment lines w/ the timestamp/version (see withoutVolatileHeaders).
+func volatileHeader(line string) bool {
+	if !strings.HasPrefix(line, "//") && !strings.HasPrefix(line, "#") {
+		return false
+	}
+	return strings.Contains(line, "Timestamp:") || strings.Contains(line, "Version:")
 }
 
 // NewStandardTemplate creates the metadata that points to one of our standard, built-in
@@ -95,7 +255,7 @@ func NewCustomTemplate(name string, path string) FileTemplate {
 	absolutePath, _ := filepath.Abs(path)
 	return FileTemplate{
 		Name:       name,
-		FileSystem: os.DirFS(""),
+		FileSystem: os.DirFS("/"),
 		Path:       absolutePath[1:],
 	}
 }
@@ -137,13 +297,22 @@ func (t FileTemplate) Eval(data interface{}) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-// prettify runs your generated Go code through 'go fmt'. If the template is for some
-// language other than Go, we'll return the source code as-is.
+// prettify runs your generated Go code through 'go fmt' and re-indents generated JSON (which also makes
+// sure that it's valid). If the template is for some other language, we'll return the source code as-is.
 func prettify(t FileTemplate, sourceCode []byte) ([]byte, error) {
-	if !strings.HasSuffix(t.Name, ".go") {
+	switch {
+	case strings.HasSuffix(t.Name, ".go"):
+		return format.Source(sourceCode)
+	case strings.HasSuffix(t.Name, ".json"):
+		buf := bytes.Buffer{}
+		if err := json.Indent(&buf, bytes.TrimSpace(sourceCode), "", "    "); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\n")
+		return buf.Bytes(), nil
+	default:
 		return sourceCode, nil
 	}
-	return format.Source(sourceCode)
 }
 
 // templateFuncs are all of pipe functions we want available when evaluating the Go template
@@ -161,17 +330,96 @@ var templateFuncs = template.FuncMap{
 	"EmptyString":        naming.EmptyString,
 	"NotEmptyString":     naming.NotEmptyString,
 	"PathTokens":         naming.PathTokens,
+	"ToKebabCase":        naming.ToKebabCase,
+	"ToSnakeCase":        naming.ToSnakeCase,
+	"Pluralize":          naming.Pluralize,
 	"ToLower":            strings.ToLower,
 	"ToUpper":            strings.ToUpper,
+	"FrodoVersion":       version,
+	"ArtifactFileName":   artifactFileName,
+	"StatusText":         http.StatusText,
 
 	// Language/format-specific value conversions
-	"JSONType":       jsonFunctions{}.convertType,
-	"JSPropertyType": jsFunctions{}.convertPropertyType,
-	"JSTypedefType":  jsFunctions{}.convertTypedefType,
-	"JavaPackage":    javaFunctions{}.convertPackage,
-	"JavaType":       javaFunctions{}.convertType,
-	"DartType":       dartFunctions{}.convertType,
-	"OpenAPIPath":    openapiFunctions{}.convertPath,
+	"GoType":           goFunctions{}.convertType,
+	"GoOneOf":          goFunctions{}.convertOneOf,
+	"GoDeprecation":    goFunctions{}.convertDeprecation,
+	"JSONType":         jsonFunctions{}.convertType,
+	"JSONString":       jsonFunctions{}.quote,
+	"JSONIndent":       jsonFunctions{}.indent,
+	"JSPropertyType":   jsFunctions{}.convertPropertyType,
+	"JSTypedefType":    jsFunctions{}.convertTypedefType,
+	"JavaPackage":      javaFunctions{}.convertPackage,
+	"JavaType":         javaFunctions{}.convertType,
+	"JavaFieldType":    javaFunctions{}.convertFieldType,
+	"DartType":         dartFunctions{}.convertType,
+	"DartFieldType":    dartFunctions{}.convertFieldType,
+	"DartNullable":     dartFunctions{}.nullable,
+	"DartZero":         dartFunctions{}.zeroValue,
+	"DartFromJSON":     dartFunctions{}.fromJSON,
+	"DartToJSON":       dartFunctions{}.toJSON,
+	"NodeSchema":       nodeFunctions{}.convertSchema,
+	"OpenAPIPath":      openapiFunctions{}.convertPath,
+	"OpenAPIPattern":   constraints.Pattern,
+	"OpenAPIParamType": openapiFunctions{}.convertParamType,
+	"OpenAPIFormat":    openapiFunctions{}.convertParamFormat,
+	"OpenAPIDefault":   openapiFunctions{}.convertDefault,
+	"PostmanBody":      postmanFunctions{}.convertBody,
+	"PostmanValue":     postmanFunctions{}.convertValue,
+	"PostmanPath":      postmanFunctions{}.convertPathVariables,
+	"PostmanPathValue": postmanFunctions{}.convertPathValue,
+	"PostmanURLPath":   postmanFunctions{}.convertURLPath,
+	"ExampleJSON":      exampleFunctions{}.convertJSON,
+	"ExampleBody":      exampleFunctions{}.convertBody,
+	"ExampleParam":     exampleFunctions{}.convertParameter,
+	"ExamplePathParam": exampleFunctions{}.convertPathParameter,
+	"ExampleCurl":      exampleFunctions{}.convertCurl,
+	"ExampleValue":     exampleFunctions{}.convertValue,
+	"ZeroValue":        zeroFunctions{}.convertValue,
+	"MarkdownType":     markdownFunctions{}.convertType,
+	"MarkdownIn":       markdownFunctions{}.convertSource,
+	"MarkdownText":     markdownFunctions{}.convertText,
+	"MarkdownAnchor":   markdownFunctions{}.anchor,
+	"IngressPaths":     deployFunctions{}.convertIngressPaths,
+	"IngressBodySize":  deployFunctions{}.convertIngressBodySize,
+	"GraphQLType":      graphqlFunctions{}.convertType,
+	"GraphQLObject":    graphqlFunctions{}.object,
+	"GraphQLFields":    graphqlFunctions{}.fields,
+	"GraphQLText":      graphqlFunctions{}.convertText,
+	"ProtoMessage":     protoFunctions{}.message,
+	"ProtoFields":      protoFunctions{}.fields,
+}
+
+type goFunctions struct{}
+
+// convertType returns the name that generated Go code uses for a request/response type (e.g. "calc.AddRequest"). The
+// shared rpc.Empty type is the exception because it doesn't live in the service's package.
+func (funcs goFunctions) convertType(packageName string, t *parser.TypeDeclaration) string {
+	if t.Empty {
+		return naming.NoPointer(t.Name)
+	}
+	return packageName + "." + naming.NoPointer(t.Name)
+}
+
+// convertOneOf wraps the Go expression for a request/response value so that "ONEOF" unions are encoded/decoded w/
+// their discriminator attribute (e.g. `rpc.OneOf(response, "type")`). Other types use the expression as-is.
+func (funcs goFunctions) convertOneOf(t *parser.TypeDeclaration, expr string) string {
+	if t.Discriminator == "" {
+		return expr
+	}
+	return fmt.Sprintf("rpc.OneOf(%s, %q)", expr, t.Discriminator)
+}
+
+// convertDeprecation returns the Go expression for the rpc.Deprecation of a "DEPRECATED" function, leaving
+// out the notice/sunset when the function's doc options don't have them (e.g. `rpc.Deprecation{Notice: "use Foo"}`).
+func (funcs goFunctions) convertDeprecation(deprecation *parser.DeprecationOptions) string {
+	var fields []string
+	if deprecation.Notice != "" {
+		fields = append(fields, fmt.Sprintf("Notice: %q", deprecation.Notice))
+	}
+	if sunset := deprecation.SunsetHeader(); sunset != "" {
+		fields = append(fields, fmt.Sprintf("Sunset: %q", sunset))
+	}
+	return "rpc.Deprecation{" + strings.Join(fields, ", ") + "}"
 }
 
 type jsFunctions struct{}
@@ -184,6 +432,9 @@ func (funcs jsFunctions) convertPropertyType(t *parser.TypeDeclaration) string {
 }
 
 func (funcs jsFunctions) convertTypedefType(t *parser.TypeDeclaration) string {
+	if t.Discriminator != "" {
+		return funcs.convertUnionType(t)
+	}
 	switch t.Kind {
 	case reflect.String:
 		return "string"
@@ -211,6 +462,19 @@ func (funcs jsFunctions) convertTypedefType(t *parser.TypeDeclaration) string {
 	}
 }
 
+// convertUnionType describes a "ONEOF" union as a union of its alternatives, each w/ its own value for the
+// discriminator attribute (e.g. "({type:'User'} & User) | ({type:'Group'} & Group)").
+func (funcs jsFunctions) convertUnionType(t *parser.TypeDeclaration) string {
+	alternatives := make([]string, len(t.Alternatives))
+	for i, alternative := range t.Alternatives {
+		alternatives[i] = fmt.Sprintf("({%s:'%s'} & %s)",
+			t.Discriminator,
+			naming.NoPackage(alternative.Name),
+			funcs.convertPropertyType(alternative))
+	}
+	return strings.Join(alternatives, " | ")
+}
+
 type jsonFunctions struct{}
 
 func (funcs jsonFunctions) convertType(t *parser.TypeDeclaration) string {
@@ -232,6 +496,21 @@ func (funcs jsonFunctions) convertType(t *parser.TypeDeclaration) string {
 	}
 }
 
+// quote formats the value as a JSON string literal (quotes included), escaping it as necessary.
+func (funcs jsonFunctions) quote(value string) string {
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
+
+// indent pretty-prints a single line of JSON (e.g. from ExampleJSON) so that it's easier for people to read.
+func (funcs jsonFunctions) indent(value string) string {
+	buf := bytes.Buffer{}
+	if err := json.Indent(&buf, []byte(value), "", "    "); err != nil {
+		return value
+	}
+	return buf.String()
+}
+
 type javaFunctions struct{}
 
 func (funcs javaFunctions) convertPackage(packageName string) string {
@@ -246,44 +525,152 @@ func (funcs javaFunctions) convertPackage(packageName string) string {
 }
 
 func (funcs javaFunctions) convertType(t *parser.TypeDeclaration) string {
+	return funcs.javaType(t, false)
+}
+
+// convertFieldType returns the Java type of the model's field. Pointers can be null, so they use the boxed
+// type (e.g. "Integer" instead of "int").
+func (funcs javaFunctions) convertFieldType(field *parser.FieldDeclaration) string {
+	return funcs.javaType(field.Type, field.Pointer)
+}
+
+// javaType converts the Go type to its Java equivalent. When 'boxed' is true, primitives use their wrapper
+// types since that's all that generics (and null values) support.
+func (funcs javaFunctions) javaType(t *parser.TypeDeclaration, boxed bool) string {
+	if naming.NoPointer(t.Name) == "time.Time" {
+		return "java.time.OffsetDateTime"
+	}
+	// Named primitives/slices/maps (e.g. "type Status string") are just their underlying type in Java. We
+	// only generate classes for structs.
+	if !t.Basic && t.Kind == reflect.Struct {
+		return naming.CleanTypeNameUpper(t.Name)
+	}
+
+	primitive := func(unboxed string, wrapper string) string {
+		if boxed {
+			return wrapper
+		}
+		return unboxed
+	}
 	switch t.Kind {
 	case reflect.String:
 		return "String"
 	case reflect.Bool:
-		return "boolean"
+		return primitive("boolean", "Boolean")
 	case reflect.Int8, reflect.Uint8:
-		return "byte"
+		return primitive("byte", "Byte")
 	case reflect.Int16, reflect.Uint16:
-		return "short"
+		return primitive("short", "Short")
 	case reflect.Int, reflect.Int32, reflect.Uint, reflect.Uint32:
-		return "int"
+		return primitive("int", "Integer")
 	case reflect.Int64, reflect.Uint64:
-		return "long"
+		return primitive("long", "Long")
 	case reflect.Float32:
-		return "float"
-	case reflect.Float64:
-		return "double"
-	case reflect.Complex64, reflect.Complex128:
-		return "double"
+		return primitive("float", "Float")
+	case reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return primitive("double", "Double")
 	case reflect.Array, reflect.Slice:
-		elemType := funcs.convertType(t.Elem)
-		return "java.util.List<" + elemType + ">"
+		return "java.util.List<" + funcs.javaType(t.Elem, true) + ">"
 	case reflect.Map:
-		elemType := funcs.convertType(t.Elem)
-		keyType := funcs.convertType(t.Key)
-		return "java.util.Map<" + keyType + "," + elemType + ">"
-	case reflect.Struct, reflect.Interface:
-		return t.Name
+		return "java.util.Map<" + funcs.javaType(t.Key, true) + ", " + funcs.javaType(t.Elem, true) + ">"
 	default:
 		return "Object"
 	}
 }
 
+type zeroFunctions struct{}
+
+// convertValue returns the literal for the type's zero value in the given language: "go", "js" (or "ts"), "dart",
+// or "java". Values that can be nil in Go (pointers, slices, maps) are null/nil in every language, too.
+func (funcs zeroFunctions) convertValue(language string, t *parser.TypeDeclaration) (string, error) {
+	nillable := strings.HasPrefix(t.Name, "*") || t.Kind == reflect.Ptr || t.Kind == reflect.Slice ||
+		t.Kind == reflect.Map || t.Kind == reflect.Interface
+
+	switch strings.ToLower(language) {
+	case "go":
+		return funcs.goValue(t, nillable), nil
+	case "js", "javascript", "ts", "typescript":
+		return funcs.jsValue(t, nillable), nil
+	case "dart":
+		if zero := (dartFunctions{}).zeroValue(t); zero != "" && !nillable {
+			return zero, nil
+		}
+		return "null", nil
+	case "java":
+		return funcs.javaValue(t, nillable), nil
+	default:
+		return "", fmt.Errorf("ZeroValue: unsupported language: %s", language)
+	}
+}
+
+func (funcs zeroFunctions) goValue(t *parser.TypeDeclaration, nillable bool) string {
+	switch {
+	case nillable:
+		return "nil"
+	case t.Kind == reflect.String:
+		return `""`
+	case t.Kind == reflect.Bool:
+		return "false"
+	case t.Kind == reflect.Struct || t.Kind == reflect.Array:
+		return t.Name + "{}"
+	case t.Kind >= reflect.Int && t.Kind <= reflect.Complex128:
+		return "0"
+	default:
+		return "nil"
+	}
+}
+
+func (funcs zeroFunctions) jsValue(t *parser.TypeDeclaration, nillable bool) string {
+	switch {
+	case nillable:
+		return "null"
+	case t.Kind == reflect.String:
+		return "''"
+	case t.Kind == reflect.Bool:
+		return "false"
+	case t.Kind == reflect.Struct:
+		return "{}"
+	case t.Kind == reflect.Array:
+		return "[]"
+	case t.Kind >= reflect.Int && t.Kind <= reflect.Complex128:
+		return "0"
+	default:
+		return "null"
+	}
+}
+
+func (funcs zeroFunctions) javaValue(t *parser.TypeDeclaration, nillable bool) string {
+	if nillable {
+		return "null"
+	}
+	switch (javaFunctions{}).javaType(t, false) {
+	case "String":
+		return `""`
+	case "boolean":
+		return "false"
+	case "byte", "short", "int":
+		return "0"
+	case "long":
+		return "0L"
+	case "float":
+		return "0.0f"
+	case "double":
+		return "0.0"
+	default:
+		return "null"
+	}
+}
+
 type dartFunctions struct{}
 
 func (funcs dartFunctions) convertType(t *parser.TypeDeclaration) string {
-	if !t.Basic {
-		return naming.JoinPackageName(naming.NoPointer(t.Name))
+	if funcs.isTime(t) {
+		return "DateTime"
+	}
+	// Named primitives/slices/maps (e.g. "type Status string") are just their underlying type in Dart. We
+	// only generate classes for structs.
+	if !t.Basic && t.Kind == reflect.Struct {
+		return naming.CleanTypeNameUpper(t.Name)
 	}
 	switch t.Kind {
 	case reflect.String:
@@ -304,22 +691,192 @@ func (funcs dartFunctions) convertType(t *parser.TypeDeclaration) string {
 	case reflect.Map:
 		keyType := funcs.convertType(t.Key)
 		elemType := funcs.convertType(t.Elem)
-		return "Map<" + keyType + "," + elemType + ">"
-	case reflect.Struct, reflect.Interface:
-		return "dynamic"
+		return "Map<" + keyType + ", " + elemType + ">"
 	default:
 		return "dynamic"
 	}
 }
 
+// convertFieldType returns the Dart type of the model's field, which is nullable unless it's always present.
+func (funcs dartFunctions) convertFieldType(field *parser.FieldDeclaration) string {
+	dartType := funcs.convertType(field.Type)
+	if dartType == "dynamic" || !funcs.nullable(field) {
+		return dartType
+	}
+	return dartType + "?"
+}
+
+func (funcs dartFunctions) isTime(t *parser.TypeDeclaration) bool {
+	return naming.NoPointer(t.Name) == "time.Time"
+}
+
+// nullable returns true when the Dart model should use a nullable type for the field. Pointers can be nil and
+// slices/maps/structs can be null/absent in the JSON, so only non-pointer primitives are always present.
+func (funcs dartFunctions) nullable(field *parser.FieldDeclaration) bool {
+	return field.Pointer || funcs.zeroValue(field.Type) == ""
+}
+
+// zeroValue returns the Dart literal for the Go zero value of a primitive type. It's empty for everything else.
+func (funcs dartFunctions) zeroValue(t *parser.TypeDeclaration) string {
+	switch funcs.convertType(t) {
+	case "String":
+		return "''"
+	case "bool":
+		return "false"
+	case "int":
+		return "0"
+	case "double":
+		return "0.0"
+	default:
+		return ""
+	}
+}
+
+// fromJSON returns the Dart expression that converts the field's attribute in the 'json' map to the field's
+// type. Numbers go through 'num' since JSON doesn't distinguish 5 from 5.0 (the same as json_serializable).
+func (funcs dartFunctions) fromJSON(field *parser.FieldDeclaration) string {
+	expr := funcs.convertFromJSON(field.Type, "json['"+field.Binding.Name+"']", true)
+	if funcs.nullable(field) {
+		return expr
+	}
+	return expr + " ?? " + funcs.zeroValue(field.Type)
+}
+
+func (funcs dartFunctions) convertFromJSON(t *parser.TypeDeclaration, expr string, nullable bool) string {
+	optional := ""
+	if nullable {
+		optional = "?"
+	}
+
+	dartType := funcs.convertType(t)
+	switch {
+	case dartType == "DateTime":
+		if nullable {
+			return expr + " == null ? null : DateTime.parse(" + expr + " as String)"
+		}
+		return "DateTime.parse(" + expr + " as String)"
+	case dartType == "String" || dartType == "bool":
+		return expr + " as " + dartType + optional
+	case dartType == "int":
+		return "(" + expr + " as num" + optional + ")" + optional + ".toInt()"
+	case dartType == "double":
+		return "(" + expr + " as num" + optional + ")" + optional + ".toDouble()"
+	case t.Kind == reflect.Struct && !t.Basic:
+		if nullable {
+			return expr + " == null ? null : " + dartType + ".fromJson(" + expr + " as Map<String, dynamic>)"
+		}
+		return dartType + ".fromJson(" + expr + " as Map<String, dynamic>)"
+	case t.SliceLike():
+		elem := funcs.convertFromJSON(t.Elem, "x", false)
+		return "(" + expr + " as List<dynamic>" + optional + ")" + optional + ".map((x) => " + elem + ").toList()"
+	case t.MapLike():
+		key := "k"
+		if keyType := funcs.convertType(t.Key); keyType == "int" || keyType == "double" {
+			key = keyType + ".parse(k)"
+		}
+		elem := funcs.convertFromJSON(t.Elem, "v", false)
+		return "(" + expr + " as Map<String, dynamic>" + optional + ")" + optional + ".map((k, v) => MapEntry(" + key + ", " + elem + "))"
+	default:
+		return expr
+	}
+}
+
+// toJSON returns the Dart expression that converts the field to the value we include in the JSON map.
+func (funcs dartFunctions) toJSON(field *parser.FieldDeclaration) string {
+	return funcs.convertToJSON(field.Type, field.Binding.Name, funcs.nullable(field))
+}
+
+func (funcs dartFunctions) convertToJSON(t *parser.TypeDeclaration, expr string, nullable bool) string {
+	optional := ""
+	if nullable {
+		optional = "?"
+	}
+
+	switch {
+	case funcs.isTime(t):
+		return expr + optional + ".toUtc().toIso8601String()"
+	case t.Kind == reflect.Struct && !t.Basic:
+		return expr + optional + ".toJson()"
+	case t.SliceLike() && funcs.convertToJSON(t.Elem, "x", false) != "x":
+		return expr + optional + ".map((x) => " + funcs.convertToJSON(t.Elem, "x", false) + ").toList()"
+	case t.MapLike() && (funcs.convertType(t.Key) != "String" || funcs.convertToJSON(t.Elem, "v", false) != "v"):
+		return expr + optional + ".map((k, v) => MapEntry(k.toString(), " + funcs.convertToJSON(t.Elem, "v", false) + "))"
+	default:
+		return expr
+	}
+}
+
+type nodeFunctions struct{}
+
+// convertSchema describes the type to the Node gateway's binder as a JS object literal. Struct fields map to
+// their nested schemas and everything else maps to the kind of value that the field accepts (e.g. 'int' or
+// 'string'). The binder uses it to parse path/query values and to validate JSON bodies the same way that
+// the Go gateway would, so {Limit: 'int', Criteria: {Text: 'string'}} lets it bind "Criteria.Text=foo".
+func (funcs nodeFunctions) convertSchema(t *parser.TypeDeclaration) string {
+	return funcs.schema(t, map[*parser.TypeDeclaration]bool{})
+}
+
+func (funcs nodeFunctions) schema(t *parser.TypeDeclaration, visiting map[*parser.TypeDeclaration]bool) string {
+	// Just like the Go binder, we don't try to bind values to types w/ custom unmarshaling logic ourselves; we
+	// only make sure that they're a string/number/bool. Structs like time.Time just take whatever they're given.
+	if funcs.unmarshaler(t) {
+		if t.ObjectLike() {
+			return "'any'"
+		}
+		return "'json'"
+	}
+
+	switch t.Kind {
+	case reflect.String:
+		return "'string'"
+	case reflect.Bool:
+		return "'bool'"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "'int'"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "'uint'"
+	case reflect.Float32, reflect.Float64:
+		return "'float'"
+	case reflect.Array, reflect.Slice:
+		return "'array'"
+	case reflect.Map:
+		return "'map'"
+	case reflect.Struct:
+		// Recursive types (e.g. a tree node w/ a *Node field) would otherwise result in an infinite schema.
+		if visiting[t] {
+			return "'any'"
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		var fields []string
+		for _, field := range t.NonOmittedFields() {
+			name := strings.ReplaceAll(field.Binding.Name, "'", "\\'")
+			fields = append(fields, "'"+name+"': "+funcs.schema(field.Type, visiting))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	default:
+		return "'any'"
+	}
+}
+
+// unmarshaler determines if the type (or a pointer to it) has custom JSON/text unmarshaling behavior.
+func (funcs nodeFunctions) unmarshaler(t *parser.TypeDeclaration) bool {
+	if t.Type == nil {
+		return false
+	}
+	methods := types.NewMethodSet(types.NewPointer(t.Type))
+	return methods.Lookup(nil, "UnmarshalJSON") != nil || methods.Lookup(nil, "UnmarshalText") != nil
+}
+
 type openapiFunctions struct{}
 
 // convertPath converts a router-compatible path pattern like "/foo/:bar/baz/:goo" to the equivalent
-// path that OpenAPI/Swagger prefers: "/foo/{bar}/baz/{goo}"
+// path that OpenAPI/Swagger prefers: "/foo/{bar}/baz/{goo}". Catch-all params like "*path" become "{path}".
 func (funcs openapiFunctions) convertPath(path string) string {
 	segments := strings.Split(path, "/")
 	for i, segment := range segments {
-		if strings.HasPrefix(segment, ":") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
 			segments[i] = "{" + segment[1:] + "}"
 		}
 	}
@@ -329,3 +886,764 @@ func (funcs openapiFunctions) convertPath(path string) string {
 	}
 	return "/" + path
 }
+
+// convertParamType returns the schema type of a path/query/header/cookie parameter. This matches the JSON type except
+// for times and durations, which the gateway binds from strings like "2021-04-06" or "5m".
+func (funcs openapiFunctions) convertParamType(t *parser.TypeDeclaration) string {
+	if funcs.convertParamFormat(t) != "" {
+		return "string"
+	}
+	return jsonFunctions{}.convertType(t)
+}
+
+// convertParamFormat returns the schema format of a path/query/header/cookie parameter (e.g. "date-time" for
+// time.Time values). It's empty for types that the gateway binds w/o any special parsing.
+func (funcs openapiFunctions) convertParamFormat(t *parser.TypeDeclaration) string {
+	switch naming.NoPointer(t.Name) {
+	case "time.Time":
+		return "date-time"
+	case "time.Duration":
+		return "duration"
+	default:
+		return ""
+	}
+}
+
+// convertDefault formats the field's default value for a schema. Numbers and booleans are written as-is when the
+// default looks like one. Everything else (including values like "5s" for a duration) is a quoted string.
+func (funcs openapiFunctions) convertDefault(field *parser.FieldDeclaration) string {
+	switch (jsonFunctions{}).convertType(field.Type) {
+	case "number":
+		if _, err := strconv.ParseFloat(field.Default, 64); err == nil {
+			return field.Default
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(field.Default); err == nil {
+			return strconv.FormatBool(b)
+		}
+	}
+	return jsonFunctions{}.quote(field.Default)
+}
+
+type postmanFunctions struct{}
+
+// convertBody builds the example JSON body for a function's request in a Postman collection.
+func (funcs postmanFunctions) convertBody(fn *parser.ServiceFunctionDeclaration) string {
+	exampleJSON, _ := json.MarshalIndent(exampleFunctions{}.body(fn), "", "    ")
+	return string(exampleJSON)
+}
+
+// convertPathVariables returns a parameter for every ":xxx" segment in the function's path. Unlike the gateway's
+// PathParameters(), this includes segments that don't bind to a request field (their Field is nil) because
+// Postman still needs a value for them in order to build the URL.
+func (funcs postmanFunctions) convertPathVariables(fn *parser.ServiceFunctionDeclaration) parser.GatewayParameters {
+	pathParams := fn.Gateway.PathParameters()
+
+	var results parser.GatewayParameters
+	for _, segment := range naming.PathTokens(fn.Gateway.Path) {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		if param := pathParams.ByName(segment[1:]); param != nil {
+			results = append(results, param)
+			continue
+		}
+		results = append(results, &parser.GatewayParameter{
+			Name:       segment[1:],
+			Constraint: fn.Gateway.PathConstraints[segment[1:]],
+			CatchAll:   strings.HasPrefix(segment, "*"),
+		})
+	}
+	return results
+}
+
+// convertURLPath converts catch-all params like "*path" to ":path" since Postman only recognizes
+// the ":xxx" style of path variable.
+func (funcs postmanFunctions) convertURLPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "*") {
+			segments[i] = ":" + segment[1:]
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// convertPathValue returns the example value for a path variable, taking its constraint into account.
+func (funcs postmanFunctions) convertPathValue(param *parser.GatewayParameter) string {
+	return exampleFunctions{}.pathText(param)
+}
+
+// convertValue returns the example value for a path/query/header/cookie parameter (e.g. "1" for an int).
+func (funcs postmanFunctions) convertValue(field *parser.FieldDeclaration) string {
+	if field == nil {
+		return ""
+	}
+	return exampleFunctions{}.text(field)
+}
+
+// ExampleJSON builds the same example value of the type that the generated docs use for it. For instance, the
+// mock server ("frodo mock --serve") responds w/ the example value of each function's response type.
+func ExampleJSON(t *parser.TypeDeclaration) []byte {
+	return []byte(exampleFunctions{}.convertJSON(t))
+}
+
+// exampleBaseURL is where the docs' examples (and the Postman collection) expect the service to be running.
+const exampleBaseURL = "http://localhost:9000"
+
+type exampleFunctions struct{}
+
+// convertJSON builds an example value of the type (e.g. a function's response) as a single line of JSON.
+func (funcs exampleFunctions) convertJSON(t *parser.TypeDeclaration) string {
+	exampleJSON, _ := json.Marshal(funcs.example("", t, map[*parser.TypeDeclaration]bool{}))
+	return string(exampleJSON)
+}
+
+// convertValue builds an example value of a field or type as a single line of JSON. Fields get better examples
+// than their types would on their own since we use their names as hints (e.g. "Email" looks like an email).
+func (funcs exampleFunctions) convertValue(fieldOrType interface{}) (string, error) {
+	var value interface{}
+	switch fieldOrType := fieldOrType.(type) {
+	case *parser.FieldDeclaration:
+		value = funcs.value(fieldOrType)
+	case *parser.TypeDeclaration:
+		value = funcs.example("", fieldOrType, map[*parser.TypeDeclaration]bool{})
+	default:
+		return "", fmt.Errorf("ExampleValue: expected a field or type, not %T", fieldOrType)
+	}
+	exampleJSON, err := json.Marshal(value)
+	return string(exampleJSON), err
+}
+
+// convertBody builds the example request body for the function as a single line of JSON.
+func (funcs exampleFunctions) convertBody(fn *parser.ServiceFunctionDeclaration) string {
+	exampleJSON, _ := json.Marshal(funcs.body(fn))
+	return string(exampleJSON)
+}
+
+// convertParameter builds the example value for a single path/query/header/cookie parameter as JSON.
+func (funcs exampleFunctions) convertParameter(field *parser.FieldDeclaration) string {
+	exampleJSON, _ := json.Marshal(funcs.paramValue(field))
+	return string(exampleJSON)
+}
+
+// convertPathParameter builds the example value for a path parameter as JSON. Unlike convertParameter, the
+// value satisfies the parameter's constraint (e.g. a UUID for ":id(uuid)").
+func (funcs exampleFunctions) convertPathParameter(param *parser.GatewayParameter) string {
+	exampleJSON, _ := json.Marshal(funcs.pathValue(param))
+	return string(exampleJSON)
+}
+
+// convertCurl builds a "curl" command that calls the function w/ example values for all of its parameters.
+func (funcs exampleFunctions) convertCurl(fn *parser.ServiceFunctionDeclaration) string {
+	segments := strings.Split(fn.Gateway.FullPath(), "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		param := fn.Gateway.PathParameters().ByName(segment[1:])
+		if param == nil {
+			param = &parser.GatewayParameter{Name: segment[1:], Constraint: fn.Gateway.PathConstraints[segment[1:]]}
+		}
+		segments[i] = url.PathEscape(funcs.pathText(param))
+		if strings.HasPrefix(segment, "*") {
+			segments[i] = strings.ReplaceAll(segments[i], "%2F", "/")
+		}
+	}
+	path := strings.Join(segments, "/")
+	var query []string
+	for _, param := range fn.Gateway.QueryParameters() {
+		query = append(query, url.QueryEscape(param.Name)+"="+url.QueryEscape(funcs.text(param.Field)))
+	}
+	if len(query) > 0 {
+		path += "?" + strings.Join(query, "&")
+	}
+
+	command := []string{"curl"}
+	switch {
+	case fn.Gateway.Method == http.MethodHead:
+		command[0] += " --head"
+	case fn.Gateway.Method != http.MethodGet:
+		command[0] += " -X " + fn.Gateway.Method
+	}
+	if fn.Gateway.SSE {
+		command[0] += " -N"
+	}
+	command[0] += " " + funcs.shellQuote(exampleBaseURL+path)
+
+	if fn.Gateway.Auth == "required" || fn.Gateway.Auth == "optional" {
+		command = append(command, `-H "Authorization: Bearer $TOKEN"`)
+	}
+	if fn.Gateway.SSE {
+		command = append(command, "-H 'Accept: text/event-stream'")
+	}
+	for _, param := range fn.Gateway.HeaderParameters() {
+		command = append(command, "-H "+funcs.shellQuote(param.Name+": "+funcs.text(param.Field)))
+	}
+	var cookies []string
+	for _, param := range fn.Gateway.CookieParameters() {
+		cookies = append(cookies, param.Name+"="+funcs.text(param.Field))
+	}
+	if len(cookies) > 0 {
+		command = append(command, "-b "+funcs.shellQuote(strings.Join(cookies, "; ")))
+	}
+	if fn.Gateway.SupportsBody() {
+		command = append(command, "-H 'Content-Type: application/json'")
+		command = append(command, "-d "+funcs.shellQuote(funcs.convertBody(fn)))
+	}
+	return strings.Join(command, " \\\n    ")
+}
+
+// shellQuote wraps the value in single quotes so that the shell doesn't expand anything in it.
+func (funcs exampleFunctions) shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// body builds the example values for every attribute that the gateway binds from the function's request body.
+func (funcs exampleFunctions) body(fn *parser.ServiceFunctionDeclaration) exampleObject {
+	pathParams := fn.Gateway.PathParameters()
+
+	body := exampleObject{}
+	for _, field := range fn.Request.NonOmittedFields() {
+		if field.Binding.Source != "" || pathParams.ByName(field.Binding.Name) != nil {
+			continue
+		}
+		body = append(body, exampleAttribute{Name: field.Binding.Name, Value: funcs.value(field)})
+	}
+	return body
+}
+
+// text formats the example value of a path/query/header/cookie parameter the way it appears in the request.
+func (funcs exampleFunctions) text(field *parser.FieldDeclaration) string {
+	return funcs.format(funcs.paramValue(field))
+}
+
+// pathText formats the example value of a path parameter the way it appears in the URL.
+func (funcs exampleFunctions) pathText(param *parser.GatewayParameter) string {
+	return funcs.format(funcs.pathValue(param))
+}
+
+func (funcs exampleFunctions) format(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case exampleObject, []interface{}, nil:
+		return ""
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+func (funcs exampleFunctions) value(field *parser.FieldDeclaration) interface{} {
+	return funcs.example(field.Name, field.Type, map[*parser.TypeDeclaration]bool{})
+}
+
+// paramValue builds the example value for a path/query/header/cookie parameter. This is the same as the value we'd
+// use in a body except for durations; the gateway binds parameters like "5m" which are easier to read than nanoseconds.
+func (funcs exampleFunctions) paramValue(field *parser.FieldDeclaration) interface{} {
+	if naming.NoPointer(field.Type.Name) == "time.Duration" {
+		return "5m"
+	}
+	return funcs.value(field)
+}
+
+// pathValue builds the example value for a path parameter. String params w/ a constraint (e.g. ":id(uuid)") use
+// a value that satisfies it, catch-all params (e.g. "*path") use a multi-segment path, and params that don't
+// bind to a field get a string based on their name.
+func (funcs exampleFunctions) pathValue(param *parser.GatewayParameter) interface{} {
+	stringly := param.Field == nil || param.Field.Type.Kind == reflect.String
+
+	switch {
+	case !stringly:
+		return funcs.paramValue(param.Field)
+	case param.Constraint != "":
+		return constraints.Example(param.Constraint)
+	case param.CatchAll:
+		return "docs/readme.txt"
+	case param.Field != nil:
+		return funcs.value(param.Field)
+	default:
+		return funcs.exampleString(param.Name)
+	}
+}
+
+// example builds a realistic-looking value for the type. The name of the field helps us pick better
+// strings than "string" for common things like IDs, emails, and URLs.
+func (funcs exampleFunctions) example(name string, t *parser.TypeDeclaration, visiting map[*parser.TypeDeclaration]bool) interface{} {
+	if t.Name == "time.Time" || t.Name == "*time.Time" {
+		return "2024-01-02T15:04:05Z"
+	}
+	if (nodeFunctions{}).unmarshaler(t) {
+		if t.ObjectLike() {
+			return exampleObject{}
+		}
+		return funcs.exampleString(name)
+	}
+
+	switch t.Kind {
+	case reflect.String:
+		return funcs.exampleString(name)
+	case reflect.Bool:
+		return true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return 1
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return 1
+	case reflect.Float32, reflect.Float64:
+		return 1.5
+	case reflect.Array, reflect.Slice:
+		// The standard library marshals []byte as a base64 string, not an array of numbers.
+		if t.Elem == nil || t.Elem.Kind == reflect.Uint8 {
+			return "aGVsbG8="
+		}
+		return []interface{}{funcs.example(name, t.Elem, visiting)}
+	case reflect.Map:
+		if t.Elem == nil {
+			return exampleObject{}
+		}
+		return exampleObject{{Name: "key", Value: funcs.example(name, t.Elem, visiting)}}
+	case reflect.Struct:
+		// Recursive types (e.g. a tree node w/ a *Node field) would otherwise result in an infinite example.
+		if visiting[t] {
+			return nil
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		object := exampleObject{}
+		for _, field := range t.NonOmittedFields() {
+			object = append(object, exampleAttribute{Name: field.Binding.Name, Value: funcs.example(field.Name, field.Type, visiting)})
+		}
+		return object
+	default:
+		return nil
+	}
+}
+
+func (funcs exampleFunctions) exampleString(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.Contains(name, "email"):
+		return "jane.doe@example.com"
+	case strings.Contains(name, "url") || strings.Contains(name, "uri") || strings.Contains(name, "link"):
+		return "https://example.com"
+	case name == "id" || strings.HasSuffix(name, "id"):
+		return "a1b2c3d4"
+	default:
+		return "string"
+	}
+}
+
+// exampleObject is a JSON object whose attributes are marshaled in the same order as the model's fields
+// rather than alphabetically like a map's would be.
+type exampleObject []exampleAttribute
+
+type exampleAttribute struct {
+	Name  string
+	Value interface{}
+}
+
+// MarshalJSON writes the attributes as a JSON object in order.
+func (object exampleObject) MarshalJSON() ([]byte, error) {
+	buf := bytes.Buffer{}
+	buf.WriteString("{")
+	for i, attribute := range object {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		name, _ := json.Marshal(attribute.Name)
+		value, err := json.Marshal(attribute.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+type markdownFunctions struct{}
+
+// convertType describes the type in a field table of the Markdown docs (e.g. "integer" or "array of string").
+// Models that have their own section in the docs link to it.
+func (funcs markdownFunctions) convertType(t *parser.TypeDeclaration) string {
+	if t.Name == "time.Time" {
+		return "string (RFC 3339)"
+	}
+
+	switch t.Kind {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return "number"
+	case reflect.Array, reflect.Slice:
+		if t.Elem == nil {
+			return "array"
+		}
+		return "array of " + funcs.convertType(t.Elem)
+	case reflect.Map:
+		if t.Elem == nil {
+			return "map"
+		}
+		return "map of " + funcs.convertType(t.Elem)
+	case reflect.Struct:
+		name := naming.NoPointer(t.Name)
+		if t.Basic {
+			return name
+		}
+		return "[" + name + "](#" + funcs.anchor(name) + ")"
+	default:
+		return "any"
+	}
+}
+
+// convertSource describes where the gateway binds the request field from: "path", "query", "body", or the name
+// of the header/cookie (e.g. "header `X-Tenant-ID`").
+func (funcs markdownFunctions) convertSource(fn *parser.ServiceFunctionDeclaration, field *parser.FieldDeclaration) string {
+	pathParam := fn.Gateway.PathParameters().ByName(field.Binding.Name)
+
+	switch {
+	case field.Binding.FromHeader():
+		return "header `" + field.Binding.SourceName + "`"
+	case field.Binding.FromCookie():
+		return "cookie `" + field.Binding.SourceName + "`"
+	case pathParam != nil && pathParam.Constraint != "":
+		return "path (" + pathParam.Constraint + ")"
+	case pathParam != nil && pathParam.CatchAll:
+		return "path (catch-all)"
+	case pathParam != nil:
+		return "path"
+	case fn.Gateway.SupportsBody():
+		return "body"
+	default:
+		return "query"
+	}
+}
+
+// convertText flattens GoDoc comments into a single line that's safe to use in a table cell.
+func (funcs markdownFunctions) convertText(docs parser.DocumentationLines) string {
+	var words []string
+	for _, line := range docs {
+		if line = strings.TrimSpace(line); line != "" {
+			words = append(words, line)
+		}
+	}
+	return strings.ReplaceAll(strings.Join(words, " "), "|", "\\|")
+}
+
+// anchor converts a heading into the fragment that GitHub (and most other renderers) use to link to it.
+func (funcs markdownFunctions) anchor(heading string) string {
+	var anchor strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r == ' ':
+			anchor.WriteRune('-')
+		case r == '-' || r == '_' || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			anchor.WriteRune(r)
+		}
+	}
+	return anchor.String()
+}
+
+type deployFunctions struct{}
+
+// convertIngressPaths returns the path prefixes that the Ingress should route to the service. Each function
+// contributes the static part of its path (e.g. "/v2/user" for "/v2/user/:id"), and we drop any prefix that's
+// already covered by a shorter one, so a service w/ a PREFIX usually needs just that one path.
+func (funcs deployFunctions) convertIngressPaths(service *parser.ServiceDeclaration) []string {
+	var paths []string
+	for _, function := range service.Functions {
+		paths = append(paths, funcs.staticPrefix(function.Gateway.FullPath()))
+	}
+	if service.HasAsync() {
+		paths = append(paths, strings.TrimSuffix(service.Gateway.PathPrefix, "/")+"/jobs")
+	}
+	sort.Strings(paths)
+
+	var results []string
+	for _, path := range paths {
+		covered := false
+		for _, result := range results {
+			if result == "/" || path == result || strings.HasPrefix(path, result+"/") {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			results = append(results, path)
+		}
+	}
+	return results
+}
+
+// staticPrefix returns the part of the route before its first path parameter (e.g. "/user" for "/user/:id/group").
+func (funcs deployFunctions) staticPrefix(path string) string {
+	var segments []string
+	for _, segment := range naming.PathTokens(path) {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			break
+		}
+		segments = append(segments, segment)
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// convertIngressBodySize returns the largest request body (e.g. "20m") that the service's "MAXBYTES" options allow
+// in the format that ingress-nginx's "proxy-body-size" annotation expects. It's "0" (no limit) when any function is
+// "MAXBYTES unlimited" and "" when none of them have the option, so the ingress' default applies.
+func (funcs deployFunctions) convertIngressBodySize(service *parser.ServiceDeclaration) string {
+	largest := int64(0)
+	for _, function := range service.Functions {
+		if function.Gateway.MaxRequestBytes < 0 {
+			return "0"
+		}
+		if function.Gateway.MaxRequestBytes > largest {
+			largest = function.Gateway.MaxRequestBytes
+		}
+	}
+	if largest == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dm", (largest+(1<<20)-1)>>20)
+}
+
+type graphqlFunctions struct{}
+
+// convertType returns the GraphQL type for a field or response (e.g. "[String]" or "User"). Inputs get their own
+// "Input" types since GraphQL doesn't let you use object types as arguments. Maps, "ONEOF" unions, and anything
+// else that GraphQL can't describe fall back to the "JSON" scalar.
+func (funcs graphqlFunctions) convertType(t *parser.TypeDeclaration, input bool) string {
+	if t == nil || t.Discriminator != "" {
+		return "JSON"
+	}
+	if t.Name == "time.Time" {
+		return "String"
+	}
+
+	switch t.Kind {
+	case reflect.String:
+		return "String"
+	case reflect.Bool:
+		return "Boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "Int"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "Int"
+	case reflect.Float32, reflect.Float64:
+		return "Float"
+	case reflect.Array, reflect.Slice:
+		if t.Elem == nil {
+			return "JSON"
+		}
+		// Byte slices are base64 encoded strings in JSON.
+		if t.Elem.Name == "byte" || t.Elem.Name == "uint8" {
+			return "String"
+		}
+		return "[" + funcs.convertType(t.Elem, input) + "]"
+	case reflect.Struct:
+		if !funcs.object(t) {
+			return "JSON"
+		}
+		if input {
+			return naming.CleanTypeNameUpper(t.Name) + "Input"
+		}
+		return naming.CleanTypeNameUpper(t.Name)
+	default:
+		return "JSON"
+	}
+}
+
+// object returns true for the struct types that get their own GraphQL object/input types. Structs w/o any fields
+// (e.g. rpc.Empty) can't since GraphQL types need at least one field.
+func (funcs graphqlFunctions) object(t *parser.TypeDeclaration) bool {
+	if t == nil || t.Basic || t.Kind != reflect.Struct || t.Discriminator != "" {
+		return false
+	}
+	return len(t.NonOmittedFields()) > 0
+}
+
+// fields returns the service functions that become fields of the "Query" type (GET functions) or the
+// "Mutation" type (everything else). A blank type name returns the fields of both. Functions that stream
+// "SSE" events or raw content don't fit GraphQL's request/response model, so they aren't part of either.
+func (funcs graphqlFunctions) fields(service *parser.ServiceDeclaration, typeName string) parser.ServiceFunctionDeclarations {
+	var results parser.ServiceFunctionDeclarations
+	for _, function := range service.Functions {
+		query := function.Gateway.Method == http.MethodGet
+		switch {
+		case function.Gateway.SSE:
+			continue
+		case function.Request.Implements.ContentReader, function.Response.Implements.ContentReader:
+			continue
+		case typeName == "Query" && !query, typeName == "Mutation" && query:
+			continue
+		}
+		results = append(results, function)
+	}
+	return results
+}
+
+// convertText turns documentation comments into a GraphQL description (a block string on a single line), or
+// a blank string when there aren't any.
+func (funcs graphqlFunctions) convertText(docs parser.DocumentationLines) string {
+	var words []string
+	for _, line := range docs {
+		if line = strings.TrimSpace(line); line != "" {
+			words = append(words, line)
+		}
+	}
+	if len(words) == 0 {
+		return ""
+	}
+	return `"""` + strings.ReplaceAll(strings.Join(words, " "), `"""`, `\"""`) + `"""`
+}
+
+type protoFunctions struct{}
+
+// protoField is a single field of a message in the ".proto" file.
+type protoField struct {
+	// Number is the field's number in the Protocol Buffers wire format.
+	Number int
+	// Name is the field's name in the message.
+	Name string
+	// Type is the Protocol Buffers type (e.g. "optional int64", "repeated string", or "map<string, User>").
+	Type string
+	// Documentation are all of the comments documenting the field.
+	Documentation parser.DocumentationLines
+}
+
+// message returns true for the struct types that get their own message in the ".proto" file.
+func (funcs protoFunctions) message(t *parser.TypeDeclaration) bool {
+	return t != nil && !t.Basic && t.Kind == reflect.Struct && t.Name != "time.Time"
+}
+
+// fields numbers the fields of the struct the same way that the "rpc/protobuf" package does at runtime: in the
+// order that they're declared (including the fields of embedded structs), skipping the ones tagged `json:"-"`.
+// The fields of a "ONEOF" union are its alternatives instead, which go in the message's 'oneof'.
+func (funcs protoFunctions) fields(t *parser.TypeDeclaration) []protoField {
+	var results []protoField
+	if t.Discriminator != "" {
+		for i, alternative := range t.Alternatives {
+			results = append(results, protoField{
+				Number:        i + 1,
+				Name:          naming.CleanTypeNameUpper(alternative.Name),
+				Type:          funcs.convertType(alternative),
+				Documentation: alternative.Documentation,
+			})
+		}
+		return results
+	}
+
+	for i, field := range t.NonOmittedFields() {
+		fieldType := funcs.convertType(field.Type)
+		if field.Pointer && funcs.scalar(field.Type) {
+			fieldType = "optional " + fieldType
+		}
+		results = append(results, protoField{
+			Number:        i + 1,
+			Name:          field.Name,
+			Type:          fieldType,
+			Documentation: field.Documentation,
+		})
+	}
+	return results
+}
+
+// convertType returns the Protocol Buffers type for the field. Anything that proto3 can't describe (e.g.
+// interface{} values, slices of slices, or structs w/o any fields) is a string containing the value's JSON.
+func (funcs protoFunctions) convertType(t *parser.TypeDeclaration) string {
+	if funcs.json(t) || t.Name == "time.Time" {
+		return "string"
+	}
+
+	switch t.Kind {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int64:
+		return "int64"
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return "int32"
+	case reflect.Uint, reflect.Uint64:
+		return "uint64"
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return "uint32"
+	case reflect.Float32:
+		return "float"
+	case reflect.Float64:
+		return "double"
+	case reflect.Slice:
+		if funcs.bytes(t) {
+			return "bytes"
+		}
+		return "repeated " + funcs.convertType(t.Elem)
+	case reflect.Map:
+		return "map<" + funcs.convertType(t.Key) + ", " + funcs.convertType(t.Elem) + ">"
+	case reflect.Struct:
+		return naming.CleanTypeNameUpper(t.Name)
+	default:
+		return "string"
+	}
+}
+
+// json returns true for the types that proto3 can't describe, so they're strings containing the value's JSON.
+func (funcs protoFunctions) json(t *parser.TypeDeclaration) bool {
+	switch {
+	case t == nil:
+		return true
+	case funcs.scalar(t):
+		return false
+	case t.Kind == reflect.Struct:
+		return len(t.NonOmittedFields()) == 0
+	case t.Kind == reflect.Slice:
+		return !funcs.bytes(t) && !funcs.repeatable(t.Elem)
+	case t.Kind == reflect.Map:
+		return !funcs.mapKey(t.Key) || !funcs.repeatable(t.Elem)
+	default:
+		return true
+	}
+}
+
+// bytes returns true for byte slices, which are "bytes" rather than repeated numbers.
+func (funcs protoFunctions) bytes(t *parser.TypeDeclaration) bool {
+	return t.Kind == reflect.Slice && t.Elem != nil && (t.Elem.Name == "byte" || t.Elem.Name == "uint8")
+}
+
+// scalar returns true for the numbers, strings, bools, and timestamps that can be "optional" fields.
+func (funcs protoFunctions) scalar(t *parser.TypeDeclaration) bool {
+	if t.Name == "time.Time" {
+		return true
+	}
+	switch t.Kind {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// mapKey returns true for the types that proto3 allows as map keys.
+func (funcs protoFunctions) mapKey(t *parser.TypeDeclaration) bool {
+	if t == nil || t.Name == "time.Time" {
+		return false
+	}
+	return funcs.scalar(t) && t.Kind != reflect.Float32 && t.Kind != reflect.Float64
+}
+
+// repeatable returns true when the type can be the element of a repeated field or the value of a map field.
+func (funcs protoFunctions) repeatable(t *parser.TypeDeclaration) bool {
+	if t == nil {
+		return false
+	}
+	return funcs.bytes(t) || (t.Kind != reflect.Slice && t.Kind != reflect.Map && !funcs.json(t))
+}
@@ -3,10 +3,15 @@
 package generate_test
 
 import (
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/monadicstack/frodo/generate"
+	"github.com/monadicstack/frodo/parser"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -81,6 +86,693 @@ func (suite *FileTemplateSuite) TestEval_success() {
 	suite.Require().Equal(expected, string(output))
 }
 
+// Ensures that File() only rewrites an artifact when its code changes, not just the timestamp/version, and that
+// UpToDate() agrees w/ it about whether or not the artifact is stale.
+func (suite *FileTemplateSuite) TestFile_unchanged() {
+	r := suite.Require()
+	defer func(version string) { generate.Version = version }(generate.Version)
+
+	t := generate.FileTemplate{
+		Name:       "header.txt",
+		FileSystem: os.DirFS("testdata"),
+		Path:       "header.tmpl",
+	}
+	ctx := &parser.Context{
+		Path:          "foo_service.go",
+		Timestamp:     time.Date(2022, 5, 10, 12, 0, 0, 0, time.UTC),
+		Checksum:      "sha256:abc",
+		OutputPackage: &parser.PackageDeclaration{Directory: suite.T().TempDir()},
+	}
+	outputPath := filepath.Join(ctx.OutputPackage.Directory, "foo_service.gen.header.txt")
+	r.Equal(outputPath, generate.OutputPath(ctx, t))
+
+	upToDate, err := generate.UpToDate(ctx, t)
+	r.NoError(err)
+	r.False(upToDate, "Should be stale if the artifact doesn't exist yet")
+
+	generate.Version = "v1.0.0"
+	r.NoError(generate.File(ctx, t))
+	original, err := os.ReadFile(outputPath)
+	r.NoError(err)
+	r.Contains(string(original), "Checksum:  sha256:abc\n")
+	r.Contains(string(original), "Version:   v1.0.0\n")
+
+	// New timestamp and version, but the code is the same, so leave the original alone.
+	ctx.Timestamp = ctx.Timestamp.Add(time.Hour)
+	generate.Version = "v1.1.0"
+	upToDate, err = generate.UpToDate(ctx, t)
+	r.NoError(err)
+	r.True(upToDate)
+	r.NoError(generate.File(ctx, t))
+	current, err := os.ReadFile(outputPath)
+	r.NoError(err)
+	r.Equal(string(original), string(current))
+
+	// The service definition changed, so it's stale now.
+	ctx.Checksum = "sha256:def"
+	upToDate, err = generate.UpToDate(ctx, t)
+	r.NoError(err)
+	r.False(upToDate)
+	r.NoError(generate.File(ctx, t))
+	current, err = os.ReadFile(outputPath)
+	r.NoError(err)
+	r.Contains(string(current), "Checksum:  sha256:def\n")
+	r.Contains(string(current), "Version:   v1.1.0\n")
+}
+
+// Ensures that Snapshot() compares the artifact to its golden file, ignoring the timestamp/version just like
+// UpToDate() does, and that updating writes the golden file so that it matches from then on.
+func (suite *FileTemplateSuite) TestSnapshot() {
+	r := suite.Require()
+	defer func(version string) { generate.Version = version }(generate.Version)
+
+	t := generate.NewCustomTemplate("header.txt", "testdata/header.tmpl")
+	ctx := &parser.Context{
+		Path:          "foo_service.go",
+		Timestamp:     time.Date(2022, 5, 10, 12, 0, 0, 0, time.UTC),
+		Checksum:      "sha256:abc",
+		OutputPackage: &parser.PackageDeclaration{Directory: suite.T().TempDir()},
+	}
+	goldenDir := suite.T().TempDir()
+	goldenPath := filepath.Join(goldenDir, "foo_service.gen.header.txt")
+
+	result, err := generate.Snapshot(ctx, t, goldenDir, false)
+	r.NoError(err)
+	r.Equal(generate.SnapshotResult{GoldenPath: goldenPath, Match: false, Diff: "missing golden file"}, result)
+
+	generate.Version = "v1.0.0"
+	result, err = generate.Snapshot(ctx, t, goldenDir, true)
+	r.NoError(err)
+	r.True(result.Match)
+	golden, err := os.ReadFile(goldenPath)
+	r.NoError(err)
+	r.Contains(string(golden), "Checksum:  sha256:abc\n")
+	_, err = os.Stat(generate.OutputPath(ctx, t))
+	r.True(os.IsNotExist(err), "Should only write the golden file, not the artifact")
+
+	// New timestamp and version, but the code is the same, so it still matches.
+	ctx.Timestamp = ctx.Timestamp.Add(time.Hour)
+	generate.Version = "v1.1.0"
+	result, err = generate.Snapshot(ctx, t, goldenDir, false)
+	r.NoError(err)
+	r.Equal(generate.SnapshotResult{GoldenPath: goldenPath, Match: true}, result)
+
+	ctx.Path = "bar/foo_service.go"
+	result, err = generate.Snapshot(ctx, t, goldenDir, false)
+	r.NoError(err)
+	r.False(result.Match)
+	r.Equal(`line 4: expected "//   Source:    foo_service.go", got "//   Source:    bar/foo_service.go"`, result.Diff)
+}
+
+// Ensures that the general purpose helpers are available to (custom) templates.
+func (suite *FileTemplateSuite) TestRender_helpers() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/defaults/service.go")
+	r.NoError(err)
+
+	_, sourceCode, err := generate.Render(ctx, generate.NewCustomTemplate("helpers.txt", "testdata/helpers.tmpl"))
+	r.NoError(err)
+	r.Equal(`Save: Saves search-request search_request
+Search: Searches search-request search_request
+Text: go="" js='' dart='' java="" example="string"
+Limit: go=0 js=0 dart=0 java=0 example=1
+SortBy: go="" js='' dart='' java="" example="string"
+Timeout: go=0 js=0 dart=0 java=0L example=1
+Fuzzy: go=false js=false dart=false java=false example=true
+Tenant: go="" js='' dart='' java="" example="string"
+SearchResponse: go=SearchResponse{} example={"Results":["string"]}
+Results: go=nil js=null java=null
+`, strings.TrimSuffix(string(sourceCode), "\n"))
+}
+
+// Ensures that the React Query hooks import the service's JS client and only use queries for GET/HEAD functions.
+func (suite *FileTemplateSuite) TestRender_reactQuery() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/docoptions/service.go")
+	r.NoError(err)
+
+	outputPath, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("react-query.js", "templates/react-query.js.tmpl"))
+	r.NoError(err)
+	r.Equal("service.gen.react-query.js", filepath.Base(outputPath))
+
+	hooks := string(sourceCode)
+	r.Contains(hooks, "import {LebowskiServiceClient} from './service.gen.client.js';")
+	r.Contains(hooks, "import {useMutation, useQuery, useQueryClient} from '@tanstack/react-query';")
+	r.Contains(hooks, "Dude: (serviceRequest) => ['LebowskiService', 'Dude', serviceRequest || {}],")
+	r.Contains(hooks, "export function useDude(serviceRequest, options = {}) {")
+	r.Contains(hooks, "export function useRug(serviceRequest, options = {}) {")
+	r.Contains(hooks, "export function useWalter({invalidate = true, onSuccess, ...options} = {}) {")
+	r.Contains(hooks, "export function useRemoveToe({invalidate = true, onSuccess, ...options} = {}) {")
+	r.NotContains(hooks, "useDonny", "SSE functions don't have hooks")
+	r.NotContains(hooks, "nextPage", "Should only include paging helpers for paginated queries")
+}
+
+func (suite *FileTemplateSuite) TestRender_java() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/docoptions/service.go")
+	r.NoError(err)
+
+	outputPath, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("client.java", "templates/client.java.tmpl"))
+	r.NoError(err)
+	r.Equal("LebowskiServiceClient.java", filepath.Base(outputPath), "Java files should be named after their class")
+
+	client := string(sourceCode)
+	r.Contains(client, "public class LebowskiServiceClient {")
+	r.Contains(client, `public static final String PATH_PREFIX = "/big";`)
+	r.Contains(client, "public Response dude(Request request) {")
+	r.Contains(client, "public Job jackieAsync(Request request) {")
+	r.Contains(client, "public CompletableFuture<Response> dude(Request request) {")
+	r.Contains(client, "public static class LebowskiServiceException extends RuntimeException {")
+	r.Contains(client, "public static class NotFoundException extends LebowskiServiceException {")
+	r.NotContains(client, "donny(", "SSE functions aren't supported")
+
+	outputPath, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("reactor.java", "templates/reactor.java.tmpl"))
+	r.NoError(err)
+	r.Equal("LebowskiServiceReactor.java", filepath.Base(outputPath))
+	r.Contains(string(sourceCode), "public Mono<Response> dude(Request request) {")
+
+	outputPath, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("ok-http-engine.java", "templates/ok-http-engine.java.tmpl"))
+	r.NoError(err)
+	r.Equal("LebowskiServiceOkHttpEngine.java", filepath.Base(outputPath))
+	r.Contains(string(sourceCode), "public class LebowskiServiceOkHttpEngine implements HttpEngine {")
+}
+
+func (suite *FileTemplateSuite) TestRender_nodeGateway() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/bindingopts/service.go")
+	r.NoError(err)
+
+	outputPath, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("gateway.js", "templates/gateway.js.tmpl"))
+	r.NoError(err)
+	r.Equal("service.gen.gateway.js", filepath.Base(outputPath))
+
+	gateway := string(sourceCode)
+	r.Contains(gateway, "export function createBindingServiceGateway(service, options = {}) {")
+	r.Contains(gateway, "router.post('/BindingService.BindIt', handler(gateway, {")
+	r.Contains(gateway, "schema: {'record_id': 'string', 'Name': 'string', 'include': 'string', 'TenantID': 'string', 'session': 'string'},")
+	r.Contains(gateway, "headers: {'TenantID': 'X-Tenant-ID'},")
+	r.Contains(gateway, "cookies: {'session': 'session'},")
+	r.NotContains(gateway, "randomUUID", "Should only include job support for services w/ ASYNC functions")
+}
+
+// Ensures that the Postman collection is valid JSON w/ a request per function and example bodies for POST/PUT/PATCH.
+func (suite *FileTemplateSuite) TestRender_postman() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/bindingopts/service.go")
+	r.NoError(err)
+
+	outputPath, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("postman.json", "templates/postman.json.tmpl"))
+	r.NoError(err)
+	r.Equal("service.gen.postman.json", filepath.Base(outputPath))
+
+	type keyValue struct{ Key, Value string }
+	collection := struct {
+		Item []struct {
+			Name string
+			Item []struct {
+				Name    string
+				Request struct {
+					Method string
+					Header []keyValue
+					URL    struct{ Raw string }
+					Body   struct{ Raw string }
+				}
+			}
+		}
+		Variable []keyValue
+	}{}
+	r.NoError(json.Unmarshal(sourceCode, &collection), "Should generate valid JSON")
+	r.Len(collection.Item, 1)
+	r.Equal("BindingService", collection.Item[0].Name)
+	r.Len(collection.Item[0].Item, 1)
+
+	bindIt := collection.Item[0].Item[0]
+	r.Equal("BindIt", bindIt.Name)
+	r.Equal("POST", bindIt.Request.Method)
+	r.Equal("{{baseUrl}}/BindingService.BindIt", bindIt.Request.URL.Raw)
+	r.Contains(bindIt.Request.Header, keyValue{Key: "X-Tenant-ID", Value: "a1b2c3d4"})
+	r.Contains(bindIt.Request.Header, keyValue{Key: "Cookie", Value: "session=string"})
+	r.JSONEq(`{"record_id": "a1b2c3d4", "Name": "string", "include": "string"}`, bindIt.Request.Body.Raw, "Should exclude header/cookie fields")
+	r.Equal([]keyValue{{Key: "baseUrl", Value: "http://localhost:9000"}, {Key: "authToken", Value: ""}}, collection.Variable)
+}
+
+// Ensures that the Markdown reference documents each function's route, status, fields, and errors.
+func (suite *FileTemplateSuite) TestRender_markdown() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/bindingopts/service.go")
+	r.NoError(err)
+
+	outputPath, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("docs.md", "templates/docs.md.tmpl"))
+	r.NoError(err)
+	r.Equal("service.gen.docs.md", filepath.Base(outputPath))
+
+	docs := string(sourceCode)
+	r.Contains(docs, "| [BindIt](#bindit) | `POST` | `/BindingService.BindIt` |")
+	r.Contains(docs, "* **Success:** `200 OK`")
+	r.Contains(docs, "| `record_id` | string | body |  |")
+	r.Contains(docs, "| `TenantID` | string | header `X-Tenant-ID` |  |")
+	r.Contains(docs, "| `session` | string | cookie `session` |  |")
+	r.Contains(docs, "| `400 Bad Request` |")
+	r.NotContains(docs, "Timestamp", "Docs shouldn't change unless the service does")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("openapi.yml", "templates/openapi.yml.tmpl"))
+	r.NoError(err)
+	r.Equal(2, strings.Count(string(sourceCode), "x-redact: true"), "Should flag the fields tagged w/ frodo:\"redact\"")
+
+	ctx, err = parser.ParseFile("../parser/testdata/docoptions/service.go")
+	r.NoError(err)
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("docs.md", "templates/docs.md.tmpl"))
+	r.NoError(err)
+
+	docs = string(sourceCode)
+	r.Contains(docs, "GET /big/dude/:id\n")
+	r.Contains(docs, "* **Success:** `202 Accepted` - the function runs in the background.")
+	r.Contains(docs, "| `401 Unauthorized` |")
+	r.Contains(docs, "| [Job Status](#job-status) | `GET` | `/big/jobs/:id` |")
+}
+
+// Ensures that the OpenAPI docs include example payloads that follow the models' JSON names/types and a
+// curl snippet for each function.
+func (suite *FileTemplateSuite) TestRender_openAPIExamples() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/fieldtypes/service.go")
+	r.NoError(err)
+
+	_, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("openapi.yml", "templates/openapi.yml.tmpl"))
+	r.NoError(err)
+
+	docs := string(sourceCode)
+	r.Contains(docs, `"EmbeddedC":{"Name":"string"}`, "Should include nested structs")
+	r.Contains(docs, `"Time":"2024-01-02T15:04:05Z"`, "Should format times as RFC 3339")
+	r.Contains(docs, `"BasicSlice":["string"],"BasicMap":{"key":"string"}`, "Should include slices/maps")
+	r.Contains(docs, `"SharedType":{"ID":"a1b2c3d4"}`, "Should use the field names to make values more realistic")
+	r.Contains(docs, `source: "curl -X POST 'http://localhost:9000/HappyLittleService.PaintTree' \\\n    -H 'Content-Type: application/json'`)
+
+	ctx, err = parser.ParseFile("../parser/testdata/bindingopts/service.go")
+	r.NoError(err)
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("docs.md", "templates/docs.md.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "curl -X POST 'http://localhost:9000/BindingService.BindIt' \\\n"+
+		"    -H 'X-Tenant-ID: a1b2c3d4' \\\n"+
+		"    -b 'session=string' \\\n"+
+		"    -H 'Content-Type: application/json' \\\n"+
+		`    -d '{"record_id":"a1b2c3d4","Name":"string","include":"string"}'`)
+}
+
+// Ensures that the gateways apply the request fields' defaults and that the docs describe them.
+func (suite *FileTemplateSuite) TestRender_defaults() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/defaults/service.go")
+	r.NoError(err)
+
+	_, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("gateway.go", "templates/gateway.go.tmpl"))
+	r.NoError(err)
+	r.Equal(2, strings.Count(string(sourceCode),
+		`Defaults:    map[string]string{"Limit": "25", "sort": "name", "Timeout": "5s", "Fuzzy": "true", "Tenant": "acme"},`))
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("gateway.js", "templates/gateway.js.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), `defaults: {'Limit': "25", 'sort': "name", 'Timeout': "5s", 'Fuzzy': "true", 'Tenant': "acme"},`)
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("openapi.yml", "templates/openapi.yml.tmpl"))
+	r.NoError(err)
+	docs := string(sourceCode)
+	r.Contains(docs, "                      type: number\n                      default: 25\n")
+	r.Contains(docs, "                      type: string\n                      default: \"name\"\n")
+	r.Contains(docs, "                      type: string\n                      format: duration\n                      default: \"5s\"\n")
+	r.Contains(docs, "                      type: boolean\n                      default: true\n")
+	r.Contains(docs, "                      type: string\n                      default: \"acme\"\n", "Should include header defaults")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("docs.md", "templates/docs.md.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "| `Limit` | integer | query | Limit is the max number of results to return. Default: `25` |")
+	r.Contains(string(sourceCode), "| `Timeout` | integer | query |  Default: `5s` |")
+	r.Contains(string(sourceCode), "| `Text` | string | query | Text is what you're searching for. |")
+}
+
+// Ensures that the docs describe the formats that the gateway's binder accepts for times/durations in parameters.
+func (suite *FileTemplateSuite) TestRender_scalarParameters() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/fieldtypes/service.go")
+	r.NoError(err)
+	ctx.Service.Functions[0].Gateway.Method = "GET"
+
+	_, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("openapi.yml", "templates/openapi.yml.tmpl"))
+	r.NoError(err)
+
+	docs := string(sourceCode)
+	r.Contains(docs, "                  schema:\n"+
+		"                      type: string\n"+
+		"                      format: date-time\n"+
+		"                  example: \"2024-01-02T15:04:05Z\"\n")
+	r.Contains(docs, "                  schema:\n"+
+		"                      type: string\n"+
+		"                      format: duration\n"+
+		"                  example: \"5m\"\n")
+	r.Contains(docs, "Duration=5m\\u0026", "Should use readable durations in the curl example")
+	r.Equal(4, strings.Count(docs, "                      format: "), "Should only include formats for times/durations")
+}
+
+// Ensures that the docs describe the format of constrained path params and that their examples satisfy them.
+func (suite *FileTemplateSuite) TestRender_pathConstraints() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/snakecase/service.go")
+	r.NoError(err)
+
+	_, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("openapi.yml", "templates/openapi.yml.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "type: string\n                      format: uuid\n                  example: \"3fa85f64-5717-4562-b3fc-2c963f66afa6\"")
+	r.Contains(string(sourceCode), "curl 'http://localhost:9000/user/3fa85f64-5717-4562-b3fc-2c963f66afa6?")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("docs.md", "templates/docs.md.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "| `user_id` | string | path (uuid) |")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("gateway.go", "templates/gateway.go.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), `PathConstraints: map[string]string{"user_id": "uuid"},`)
+}
+
+// Ensures that the docs/examples fill in catch-all path params w/ an entire path.
+func (suite *FileTemplateSuite) TestRender_catchAll() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/snakecase/service.go")
+	r.NoError(err)
+
+	_, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("openapi.yml", "templates/openapi.yml.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), `"/files/{bucket}/{file_path}":`)
+	r.Contains(string(sourceCode), `example: "docs/readme.txt"`)
+	r.Contains(string(sourceCode), "curl 'http://localhost:9000/files/string/docs/readme.txt'")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("docs.md", "templates/docs.md.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "| `file_path` | string | path (catch-all) |")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("postman.json", "templates/postman.json.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), `"raw": "{{baseUrl}}/files/:bucket/:file_path"`)
+}
+
+// Ensures that the docs describe redirect responses rather than a JSON body.
+func (suite *FileTemplateSuite) TestRender_redirect() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/snakecase/service.go")
+	r.NoError(err)
+
+	_, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("openapi.yml", "templates/openapi.yml.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "\"3XX\":\n                    description: Redirects the caller to the URL in the Location header.")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("docs.md", "templates/docs.md.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "* **Success:** `3XX` - a redirect to the URL in the `Location` header.")
+	r.Contains(string(sourceCode), "Redirects the caller to another URL (the `Location` header) rather than returning JSON.")
+}
+
+// Ensures that gateways forward requests for PROXY functions rather than invoking the service.
+func (suite *FileTemplateSuite) TestRender_proxy() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/docoptions/service.go")
+	r.NoError(err)
+
+	_, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("gateway.go", "templates/gateway.go.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), `Handler:     rpc.ProxyHandler("http://legacy:8080/nihilists"),`)
+	r.NotContains(string(sourceCode), "service.RemoveToe(req.Context()", "Should not invoke the service for proxied functions")
+	r.Contains(string(sourceCode), "service.Rug(req.Context()", "Should ignore invalid proxy targets")
+	r.Contains(string(sourceCode), `"github.com/monadicstack/frodo/rpc/events"`)
+}
+
+// Ensures that Go clients include an interface (w/ the client-only extras) and providers for DI frameworks.
+func (suite *FileTemplateSuite) TestRender_clientInterface() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/docoptions/service.go")
+	r.NoError(err)
+
+	_, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("client.go", "templates/client.go.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "type LebowskiServiceClientInterface interface {")
+	r.Contains(string(sourceCode), "\tdocoptions.LebowskiService\n")
+	r.Contains(string(sourceCode), "\tAwait(ctx context.Context, jobID string, response interface{}) error\n")
+	r.Contains(string(sourceCode), "\tBatch() *LebowskiServiceBatch\n")
+	r.Contains(string(sourceCode), "type LebowskiServiceClientAddress string")
+	r.Contains(string(sourceCode), "func ProvideLebowskiServiceClient(address LebowskiServiceClientAddress) LebowskiServiceClientInterface {")
+	r.Contains(string(sourceCode), "func ProvideLebowskiService(client LebowskiServiceClientInterface) docoptions.LebowskiService {")
+}
+
+// Ensures that Go artifacts reference rpc.Empty w/o the service's package and import the rpc package when they need it.
+func (suite *FileTemplateSuite) TestRender_empty() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/empty/service.go")
+	r.NoError(err)
+
+	_, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("client.go", "templates/client.go.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "Ping(ctx context.Context, request *rpc.Empty) (*rpc.Empty, error) {")
+	r.Contains(string(sourceCode), "Status(ctx context.Context, request *rpc.Empty) (*empty.StatusResponse, error) {")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("gateway.go", "templates/gateway.go.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "serviceRequest := rpc.Empty{}")
+	r.Contains(string(sourceCode), "serviceRequest := empty.RestartRequest{}")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("mock.go", "templates/mock.go.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "\t\"github.com/monadicstack/frodo/rpc\"\n")
+	r.Contains(string(sourceCode), "PingFunc    func(context.Context, *rpc.Empty) (*rpc.Empty, error)")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("client.dart", "templates/client.dart.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "Future<RpcEmpty> Ping(RpcEmpty serviceRequest")
+	r.Contains(string(sourceCode), "class RpcEmpty implements")
+}
+
+// Ensures that gateways/clients encode "ONEOF" unions w/ their discriminator and that the docs describe the alternatives.
+func (suite *FileTemplateSuite) TestRender_oneOf() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/oneof/service.go")
+	r.NoError(err)
+
+	_, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("gateway.go", "templates/gateway.go.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), `rpc.Reply(w, req, 200, rpc.OneOf(response, "kind"))`)
+	r.Contains(string(sourceCode), `rpc.Reply(w, req, 200, rpc.OneOf(response, "type"))`)
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("client.go", "templates/client.go.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), `err := client.Invoke(ctx, "POST", "/SearchService.Search", request, rpc.OneOf(response, "kind"))`)
+	r.Contains(string(sourceCode), `return batch.Batch.Add("SearchService", "Search", request, rpc.OneOf(response, "kind"))`)
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("openapi.yml", "templates/openapi.yml.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "            oneOf:\n                - $ref: \"#/components/schemas/UserMatch\"\n                - $ref: \"#/components/schemas/GroupMatch\"\n")
+	r.Contains(string(sourceCode), "                propertyName: kind\n")
+	r.Contains(string(sourceCode), "                    GroupMatch: \"#/components/schemas/GroupMatch\"")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("client.js", "templates/client.js.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "@typedef { ({kind:'UserMatch'} & UserMatch) | ({kind:'GroupMatch'} & GroupMatch) } SearchResult\n*/")
+}
+
+// Ensures that the JS client fills in the variables in the service's path prefix, but only when it has some.
+func (suite *FileTemplateSuite) TestRender_prefixVariables() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/prefix/service.go")
+	r.NoError(err)
+
+	_, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("client.js", "templates/client.js.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "csrfHeader, cookies, prefixVariables} = {}) {")
+	r.Contains(string(sourceCode), "baseURL: trimSlashes(trimSlashes(baseURL) + '/' + expandPrefix('/${API_PREFIX}/:tenant/v2', prefixVariables || {})),")
+	r.Contains(string(sourceCode), "function expandPrefix(prefix, variables) {")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("client.go", "templates/client.go.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), `rpcClient.PathPrefix = "/${API_PREFIX}/:tenant/v2"`)
+
+	ctx, err = parser.ParseFile("../parser/testdata/docoptions/service.go")
+	r.NoError(err)
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("client.js", "templates/client.js.tmpl"))
+	r.NoError(err)
+	r.NotContains(string(sourceCode), "prefixVariables")
+	r.NotContains(string(sourceCode), "expandPrefix")
+}
+
+// Ensures that "DEPRECATED" functions announce it in the gateway, the clients, and the OpenAPI docs.
+func (suite *FileTemplateSuite) TestRender_deprecated() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/docoptions/service.go")
+	r.NoError(err)
+
+	_, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("gateway.go", "templates/gateway.go.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), `&rpc.Deprecation{Notice: "use Walter instead", Sunset: "Fri, 01 Jan 2027 00:00:00 GMT"},`)
+	r.Contains(string(sourceCode), `&rpc.Deprecation{Sunset: "Fri, 01 Jan 2027 12:30:00 GMT"},`)
+	r.Contains(string(sourceCode), `&rpc.Deprecation{},`)
+	r.Equal(3, strings.Count(string(sourceCode), "&rpc.Deprecation{"))
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("client.go", "templates/client.go.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "// Deprecated: use Walter instead\n")
+	r.Contains(string(sourceCode), "// Deprecated: Dude is going away.\n")
+	r.Contains(string(sourceCode), `client.WarnDeprecated(ctx, "Jackie", rpc.Deprecation{Notice: "use Walter instead", Sunset: "Fri, 01 Jan 2027 00:00:00 GMT"})`)
+	r.Equal(3, strings.Count(string(sourceCode), "client.WarnDeprecated("), "Async functions should only warn once")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("openapi.yml", "templates/openapi.yml.tmpl"))
+	r.NoError(err)
+	r.Equal(3, strings.Count(string(sourceCode), "deprecated: true"))
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("client.js", "templates/client.js.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), " * @deprecated use Walter instead\n")
+	r.Contains(string(sourceCode), `config.onDeprecated('LebowskiService.Jackie', {notice: "use Walter instead", sunset: 'Fri, 01 Jan 2027 00:00:00 GMT'});`)
+	r.Contains(string(sourceCode), "onDeprecated: onDeprecated || warnDeprecated,")
+}
+
+// Ensures that clients check the size of request bodies for functions w/ a "MAXBYTES"/"MAXBODY" limit and that
+// the OpenAPI docs include the limit.
+func (suite *FileTemplateSuite) TestRender_maxBody() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/docoptions/service.go")
+	r.NoError(err)
+
+	_, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("client.go", "templates/client.go.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "ctx = rpc.LimitRequestBody(ctx, 2097152)")
+	r.Equal(1, strings.Count(string(sourceCode), "rpc.LimitRequestBody("), "Should skip GET and unlimited functions")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("openapi.yml", "templates/openapi.yml.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "x-max-body-bytes: 2097152\n")
+	r.Contains(string(sourceCode), "                413:\n                    description: The request body is larger than 2097152 bytes.\n")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("client.js", "templates/client.js.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "checkRequestSize(fetchOptions.body, 2097152);")
+	r.Equal(2, strings.Count(string(sourceCode), "checkRequestSize("), "Should only include the helper and the one call")
+}
+
+// Ensures that the client has a predicate for each of the "ERRORS" that a function declares and that the docs
+// list them.
+func (suite *FileTemplateSuite) TestRender_errors() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/docoptions/service.go")
+	r.NoError(err)
+
+	_, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("client.go", "templates/client.go.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "\t\"github.com/monadicstack/frodo/rpc/errors\"\n")
+	r.Contains(string(sourceCode), "func IsMaudeNotFound(err error) bool {\n\treturn err != nil && errors.Status(err) == 404\n}")
+	r.Contains(string(sourceCode), "func IsMaudeImATeapot(err error) bool {")
+	r.Contains(string(sourceCode), "func IsMaudeStatus499(err error) bool {")
+	r.Equal(4, strings.Count(string(sourceCode), "errors.Status(err)"))
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("docs.md", "templates/docs.md.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "| `409 Conflict` | A failure that this operation declares it may return. |\n")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("openapi.yml", "templates/openapi.yml.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "                404:\n                    description: Not Found\n                409:\n")
+
+	ctx, err = parser.ParseFile("../parser/testdata/basic/service.go")
+	r.NoError(err)
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("client.go", "templates/client.go.tmpl"))
+	r.NoError(err)
+	r.NotContains(string(sourceCode), "rpc/errors", "Should only import errors when there are predicates")
+}
+
+// Ensures that the deployment manifests route the functions' paths, probe the "HEALTH" function, and include
+// the service's "RESOURCES" hints.
+func (suite *FileTemplateSuite) TestRender_deploy() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/docoptions/service.go")
+	r.NoError(err)
+
+	outputPath, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("k8s.yaml", "templates/k8s.yaml.tmpl"))
+	r.NoError(err)
+	r.Equal(filepath.Join("../parser/testdata/docoptions/gen", "service.gen.k8s.yaml"), outputPath)
+	r.Contains(string(sourceCode), "  name: lebowski-service\n")
+	r.Contains(string(sourceCode), "          image: lebowski-service:999.12\n")
+	r.Contains(string(sourceCode), "            httpGet:\n              path: /big/LebowskiService.Donny\n              port: http\n")
+	r.Contains(string(sourceCode), "            requests:\n              cpu: \"250m\"\n              memory: \"256Mi\"\n")
+	r.NotContains(string(sourceCode), "replicas:")
+	r.Contains(string(sourceCode), `nginx.ingress.kubernetes.io/proxy-body-size: "0"`, "Unlimited functions should lift the ingress limit")
+	r.Contains(string(sourceCode), `nginx.ingress.kubernetes.io/proxy-buffering: "off"`, "SSE functions should disable buffering")
+	for _, path := range []string{"/big/LebowskiService.Donny", "/big/LebowskiService.Walter", "/big/dude", "/big/jobs", "/big/nihilist", "/big/ties/room/together"} {
+		r.Contains(string(sourceCode), "          - path: "+path+"\n            pathType: Prefix\n")
+	}
+	r.Equal(6, strings.Count(string(sourceCode), "pathType: Prefix"), "Should not repeat covered paths")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("knative.yaml", "templates/knative.yaml.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "apiVersion: serving.knative.dev/v1\nkind: Service\n")
+	r.Contains(string(sourceCode), "            httpGet:\n              path: /big/LebowskiService.Donny\n")
+
+	ctx, err = parser.ParseFile("../parser/testdata/basic/service.go")
+	r.NoError(err)
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("k8s.yaml", "templates/k8s.yaml.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "          image: dude-service:0.0.1\n")
+	r.Contains(string(sourceCode), "            tcpSocket:\n              port: http\n", "Should fall back to TCP probes w/o a HEALTH function")
+	r.NotContains(string(sourceCode), "resources:")
+	r.NotContains(string(sourceCode), "annotations:")
+}
+
+// Ensures that the GraphQL schema maps requests/responses to input/object types, splits the functions into queries
+// and mutations, and that the resolvers delegate each field to the service.
+func (suite *FileTemplateSuite) TestRender_graphql() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/snakecase/service.go")
+	r.NoError(err)
+
+	outputPath, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("graphql", "templates/graphql.tmpl"))
+	r.NoError(err)
+	r.Equal(filepath.Join("../parser/testdata/snakecase/gen", "service.gen.graphql"), outputPath)
+	r.Contains(string(sourceCode), "type GetUserResponse {\n  first_name: String\n  address: Address\n}\n")
+	r.Contains(string(sourceCode), "input GetUserResponseInput {\n  first_name: String\n  address: AddressInput\n}\n")
+	r.Contains(string(sourceCode), "extend type Query {\n  getUser(input: GetUserRequestInput): GetUserResponse\n")
+	r.NotContains(string(sourceCode), "extend type Mutation", "Should not extend Mutation w/o any non-GET functions")
+
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("graphql.go", "templates/graphql.go.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "&rpc.JSON{Marshaler: rpc.StandardMarshaler, SnakeCase: true}")
+	r.Contains(string(sourceCode), "\tcase \"getUser\":\n\t\trequest := &snakecase.GetUserRequest{}\n")
+	r.Contains(string(sourceCode), "response, err := resolver.service.GetUser(ctx, request)")
+
+	ctx, err = parser.ParseFile("../parser/testdata/docoptions/service.go")
+	r.NoError(err)
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("graphql", "templates/graphql.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "extend type Query {\n  \"\"\"Dude abides.\"\"\"\n  dude: JSON @deprecated\n}\n")
+	r.Contains(string(sourceCode), `  jackie: JSON @deprecated(reason: "use Walter instead")`)
+	r.NotContains(string(sourceCode), "donny", "SSE functions don't fit GraphQL")
+}
+
+// Ensures that the .proto messages number the fields the same way the runtime does and that unions use a 'oneof'.
+func (suite *FileTemplateSuite) TestRender_proto() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../parser/testdata/fieldtypes/service.go")
+	r.NoError(err)
+
+	outputPath, sourceCode, err := generate.Render(ctx, generate.NewStandardTemplate("proto", "templates/proto.tmpl"))
+	r.NoError(err)
+	r.Equal(filepath.Join("../parser/testdata/fieldtypes/gen", "service.gen.proto"), outputPath)
+	r.Contains(string(sourceCode), "syntax = \"proto3\";\n\npackage fieldtypes;\n")
+	r.Contains(string(sourceCode), "message Request {\n  string EmbeddedA = 1;\n  bool EmbeddedB = 2;\n  ExportedStruct EmbeddedC = 3;\n")
+	r.Contains(string(sourceCode), "  optional string BasicPointer = 6;\n")
+	r.Contains(string(sourceCode), "  string Interface = 15;\n", "Should use JSON strings for interfaces")
+	r.Contains(string(sourceCode), "  repeated string BasicSlice = 17;\n  map<string, string> BasicMap = 18;\n")
+	r.Contains(string(sourceCode), "  TestdataSharedType SharedType = 26;\n}\n")
+
+	ctx, err = parser.ParseFile("../parser/testdata/oneof/service.go")
+	r.NoError(err)
+	_, sourceCode, err = generate.Render(ctx, generate.NewStandardTemplate("proto", "templates/proto.tmpl"))
+	r.NoError(err)
+	r.Contains(string(sourceCode), "message SearchResult {\n  oneof kind {\n    UserMatch UserMatch = 1;\n    GroupMatch GroupMatch = 2;\n  }\n}\n")
+}
+
 func TestFileTemplateSuite(t *testing.T) {
 	suite.Run(t, new(FileTemplateSuite))
 }
@@ -0,0 +1,379 @@
+package generate
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/monadicstack/frodo/parser"
+	"golang.org/x/tools/go/packages"
+)
+
+// ClientModule generates a complete, standalone Go module in 'dir' that contains only what external consumers
+// need to call the service: a "go.mod" for 'modulePath', a copy of the service interface and all of the
+// request/response/event types (and their methods/helpers) in the root package, and the RPC client in the
+// "gen" package. This way they can depend on your client w/o importing your service's entire module.
+//
+// The module mirrors the layout of your service's package, so consumers use it the same way they would if
+// they imported your service directly; only the import paths change.
+func ClientModule(ctx *parser.Context, clientTemplate FileTemplate, modulePath string, dir string) error {
+	declarations, err := clientModuleDeclarations(ctx)
+	if err != nil {
+		return err
+	}
+
+	moduleCtx := *ctx
+	moduleCtx.InputPackage = &parser.PackageDeclaration{
+		Name:      ctx.InputPackage.Name,
+		Import:    modulePath,
+		Directory: dir,
+	}
+	moduleCtx.OutputPackage = &parser.PackageDeclaration{
+		Name:      ctx.OutputPackage.Name,
+		Import:    modulePath + "/gen",
+		Directory: filepath.Join(dir, "gen"),
+	}
+	data := clientModuleContext{
+		Context:          &moduleCtx,
+		ModulePath:       modulePath,
+		FrodoVersion:     moduleVersion(version()),
+		clientModuleDecl: declarations,
+	}
+
+	modelsPath := filepath.Join(dir, filepath.Base(ctx.Path))
+	if err := renderModuleFile(data, "module/go.mod", filepath.Join(dir, "go.mod")); err != nil {
+		return err
+	}
+	if err := renderModuleFile(data, "module/models.go", modelsPath); err != nil {
+		return err
+	}
+	return File(&moduleCtx, clientTemplate)
+}
+
+// Descriptor returns the source code of a standalone Go file that describes the service's contract: the service
+// interface and all of the request/response/event types (along w/ their methods/helpers) that ClientModule()
+// copies into the client module. This is what "frodo publish" pushes to a schema registry, so other teams can
+// generate clients from it w/o access to your service's source code.
+func Descriptor(ctx *parser.Context) ([]byte, error) {
+	declarations, err := clientModuleDeclarations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := clientModuleContext{Context: ctx, clientModuleDecl: declarations}
+	fileTemplate := NewStandardTemplate(filepath.Base(ctx.Path), "templates/module/models.go.tmpl")
+	sourceCode, err := fileTemplate.Eval(data)
+	if err != nil {
+		return nil, fmt.Errorf("template eval error: descriptor: %v", err)
+	}
+	if sourceCode, err = prettify(fileTemplate, sourceCode); err != nil {
+		return nil, fmt.Errorf("error running 'go fmt': descriptor: %v", err)
+	}
+	return sourceCode, nil
+}
+
+// clientModuleContext is the root data we feed to the templates for the "go.mod" and models files.
+type clientModuleContext struct {
+	*parser.Context
+	clientModuleDecl
+	// ModulePath is the name of the module we're generating (e.g. "github.com/org/foo-client").
+	ModulePath string
+	// FrodoVersion is the version of the Frodo runtime module that the module should depend on. We release
+	// the runtime w/ the same version as the CLI. It's blank when we don't know (e.g. you built frodo
+	// from source), so the user should run "go mod tidy".
+	FrodoVersion string
+}
+
+// clientModuleDecl contains the source code we're copying from the service's package into the client module.
+type clientModuleDecl struct {
+	// Imports are the import specs (e.g. `"time"` or `foo "github.com/x/y"`) that the copied code needs.
+	Imports []string
+	// Declarations are the verbatim source code for each type/func/const/var we're copying.
+	Declarations []string
+}
+
+// renderModuleFile evaluates one of the standard client module templates, writing the result to 'path'.
+func renderModuleFile(data clientModuleContext, name string, path string) error {
+	fileTemplate := NewStandardTemplate(filepath.Base(path), "templates/"+name+".tmpl")
+	sourceCode, err := fileTemplate.Eval(data)
+	if err != nil {
+		return fmt.Errorf("template eval error: %s: %v", name, err)
+	}
+	if sourceCode, err = prettify(fileTemplate, sourceCode); err != nil {
+		return fmt.Errorf("error running 'go fmt': %s: %v", name, err)
+	}
+	return writeArtifact(path, sourceCode)
+}
+
+// clientModuleDeclarations finds the declarations for the service interface, any events it emits, and everything
+// that those declarations reference within the service's package (transitively). Types bring along all of their
+// methods, too, since things like pagination and custom JSON marshaling depend on them.
+func clientModuleDeclarations(ctx *parser.Context) (clientModuleDecl, error) {
+	config := &packages.Config{
+		Tests: false,
+		Mode:  packages.NeedName | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+	}
+	loadedPackages, err := packages.Load(config, ctx.Path)
+	if err != nil {
+		return clientModuleDecl{}, err
+	}
+	if len(loadedPackages) != 1 {
+		return clientModuleDecl{}, parser.ErrMultiplePackages
+	}
+
+	pkg := loadedPackages[0]
+	index := newDeclarationIndex(pkg)
+	collector := &declarationCollector{
+		pkg:      pkg,
+		index:    index,
+		included: map[ast.Node]bool{},
+		imports:  map[string]string{},
+	}
+
+	// We loaded the package again (w/ more type info), so look things up by name rather than using the
+	// parser's types.Object values.
+	scope := pkg.Types.Scope()
+	collector.add(scope.Lookup(ctx.Service.Name))
+	for _, event := range ctx.Service.Events() {
+		collector.add(scope.Lookup(event.Name))
+	}
+	collector.run()
+
+	// Other packages in your own module would drag your module right back in, so at least let the user know.
+	for importPath := range collector.imports {
+		if strings.HasPrefix(importPath, ctx.Module.Name+"/") || importPath == ctx.Module.Name {
+			log.Printf("Warning: the client module still imports %s from your service's module", importPath)
+		}
+	}
+	return collector.result(), nil
+}
+
+// declarationIndex lets us find the AST declaration for any package-level object in the service's package.
+type declarationIndex struct {
+	// objects maps each package-level type/func/const/var to the node we copy when we need it.
+	objects map[types.Object]ast.Node
+	// methods maps each type to the declarations of all of its methods.
+	methods map[types.Object][]*ast.FuncDecl
+	// order tracks where each declaration appears in the source so the output follows the original order.
+	order map[ast.Node]int
+}
+
+func newDeclarationIndex(pkg *packages.Package) declarationIndex {
+	index := declarationIndex{
+		objects: map[types.Object]ast.Node{},
+		methods: map[types.Object][]*ast.FuncDecl{},
+		order:   map[ast.Node]int{},
+	}
+
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			index.order[decl] = len(index.order)
+
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil {
+					index.objects[pkg.TypesInfo.Defs[decl.Name]] = decl
+					continue
+				}
+				if receiver := receiverTypeName(decl); receiver != nil {
+					receiverType := pkg.TypesInfo.Uses[receiver]
+					index.methods[receiverType] = append(index.methods[receiverType], decl)
+				}
+
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						// Copy types individually, even when they're declared in a "type (...)" group.
+						if !decl.Lparen.IsValid() {
+							index.objects[pkg.TypesInfo.Defs[spec.Name]] = decl
+							continue
+						}
+						index.objects[pkg.TypesInfo.Defs[spec.Name]] = spec
+						index.order[spec] = len(index.order)
+					case *ast.ValueSpec:
+						// Copy the entire "const (...)" group so that things like iota still work.
+						for _, name := range spec.Names {
+							index.objects[pkg.TypesInfo.Defs[name]] = decl
+						}
+					}
+				}
+			}
+		}
+	}
+	return index
+}
+
+// receiverTypeName digs the type name out of a method receiver like "(r *FooRequest)".
+func receiverTypeName(decl *ast.FuncDecl) *ast.Ident {
+	if len(decl.Recv.List) == 0 {
+		return nil
+	}
+	expr := decl.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	ident, _ := expr.(*ast.Ident)
+	return ident
+}
+
+// declarationCollector walks the declarations we need, following every reference to another package-level
+// object in the same package until we've got everything required for the copied code to compile.
+type declarationCollector struct {
+	pkg      *packages.Package
+	index    declarationIndex
+	queue    []ast.Node
+	included map[ast.Node]bool
+	// imports maps the import path of every package the copied code references to the name it uses.
+	imports map[string]string
+}
+
+// add queues up the declaration for the given object (and its methods if it's a type).
+func (c *declarationCollector) add(obj types.Object) {
+	if obj == nil {
+		return
+	}
+	if node, ok := c.index.objects[obj]; ok {
+		c.addNode(node)
+	}
+	for _, method := range c.index.methods[obj] {
+		c.addNode(method)
+	}
+}
+
+func (c *declarationCollector) addNode(node ast.Node) {
+	if c.included[node] {
+		return
+	}
+	c.included[node] = true
+	c.queue = append(c.queue, node)
+}
+
+func (c *declarationCollector) run() {
+	scope := c.pkg.Types.Scope()
+	for len(c.queue) > 0 {
+		node := c.queue[0]
+		c.queue = c.queue[1:]
+
+		ast.Inspect(node, func(n ast.Node) bool {
+			ident, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			switch obj := c.pkg.TypesInfo.Uses[ident].(type) {
+			case *types.PkgName:
+				c.imports[obj.Imported().Path()] = obj.Name()
+			case nil:
+			default:
+				if obj.Pkg() == c.pkg.Types && obj.Parent() == scope {
+					c.add(obj)
+				}
+			}
+			return true
+		})
+	}
+}
+
+// result returns the source code for all of the collected declarations in the order they appear in the
+// original package, along w/ the imports they need.
+func (c *declarationCollector) result() clientModuleDecl {
+	var nodes []ast.Node
+	for node := range c.included {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return c.index.order[nodes[i]] < c.index.order[nodes[j]]
+	})
+
+	result := clientModuleDecl{}
+	for _, node := range nodes {
+		result.Declarations = append(result.Declarations, c.source(node))
+	}
+	var importPaths []string
+	for importPath := range c.imports {
+		importPaths = append(importPaths, importPath)
+	}
+	// Standard library packages go first (they don't have a "." in the first segment), then everything else.
+	sort.Slice(importPaths, func(i, j int) bool {
+		iStandard, jStandard := standardPackage(importPaths[i]), standardPackage(importPaths[j])
+		if iStandard != jStandard {
+			return iStandard
+		}
+		return importPaths[i] < importPaths[j]
+	})
+	for i, importPath := range importPaths {
+		spec := strconv.Quote(importPath)
+		if name := c.imports[importPath]; name != defaultPackageName(importPath) {
+			spec = name + " " + spec
+		}
+		if i > 0 && standardPackage(importPaths[i-1]) && !standardPackage(importPath) {
+			result.Imports = append(result.Imports, "")
+		}
+		result.Imports = append(result.Imports, spec)
+	}
+	return result
+}
+
+// source grabs the original source code (including doc comments) for the declaration.
+func (c *declarationCollector) source(node ast.Node) string {
+	var doc *ast.CommentGroup
+	switch node := node.(type) {
+	case *ast.FuncDecl:
+		doc = node.Doc
+	case *ast.GenDecl:
+		doc = node.Doc
+	case *ast.TypeSpec:
+		doc = node.Doc
+	}
+
+	file := c.pkg.Fset.File(node.Pos())
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		return ""
+	}
+	text := func(start token.Pos, end token.Pos) string {
+		return string(data[file.Offset(start):file.Offset(end)])
+	}
+
+	source := text(node.Pos(), node.End())
+	if _, ok := node.(*ast.TypeSpec); ok {
+		// Types from a "type (...)" group need their own "type" keyword now.
+		source = "type " + source
+	}
+	if doc != nil {
+		source = text(doc.Pos(), doc.End()) + "\n" + source
+	}
+	return source
+}
+
+// defaultPackageName guesses the name of a package based on its import path (e.g. "github.com/foo/bar/v2" is "bar").
+func defaultPackageName(importPath string) string {
+	segments := strings.Split(importPath, "/")
+	name := segments[len(segments)-1]
+	if len(segments) > 1 && strings.HasPrefix(name, "v") {
+		if _, err := strconv.Atoi(name[1:]); err == nil {
+			name = segments[len(segments)-2]
+		}
+	}
+	return name
+}
+
+// standardPackage returns true if the import path looks like it belongs to the standard library.
+func standardPackage(importPath string) bool {
+	return !strings.Contains(strings.Split(importPath, "/")[0], ".")
+}
+
+// moduleVersion only returns the version if it's a real release that "go.mod" can require.
+func moduleVersion(version string) string {
+	if !strings.HasPrefix(version, "v") || strings.Contains(version, "+") {
+		return ""
+	}
+	return version
+}
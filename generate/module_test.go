@@ -0,0 +1,69 @@
+// +build unit
+
+package generate_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/monadicstack/frodo/generate"
+	"github.com/monadicstack/frodo/parser"
+	"github.com/stretchr/testify/suite"
+)
+
+type ClientModuleSuite struct {
+	suite.Suite
+}
+
+// Ensures that ClientModule() writes the go.mod, the copied service interface/models, and the client, but leaves
+// behind anything in the service's package that the client doesn't need (like the implementation).
+func (suite *ClientModuleSuite) TestClientModule() {
+	r := suite.Require()
+	defer func(version string) { generate.Version = version }(generate.Version)
+	generate.Version = "v1.2.3"
+
+	ctx, err := parser.ParseFile("../example/names/name_service.go")
+	r.NoError(err)
+
+	dir := suite.T().TempDir()
+	clientTemplate := generate.NewStandardTemplate("client.go", "templates/client.go.tmpl")
+	r.NoError(generate.ClientModule(ctx, clientTemplate, "github.com/acme/names-client", dir))
+
+	goMod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	r.NoError(err)
+	r.Contains(string(goMod), "module github.com/acme/names-client\n")
+	r.Contains(string(goMod), "require github.com/monadicstack/frodo/rpc v1.2.3")
+
+	models, err := os.ReadFile(filepath.Join(dir, "name_service.go"))
+	r.NoError(err)
+	r.Contains(string(models), "package names\n")
+	r.Contains(string(models), "type NameService interface {")
+	r.Contains(string(models), "type SplitRequest NameRequest")
+	r.Contains(string(models), "func (r *DownloadResponse) SetContent(reader io.ReadCloser) {")
+	r.NotContains(string(models), "NameServiceHandler")
+
+	client, err := os.ReadFile(filepath.Join(dir, "gen", "name_service.gen.client.go"))
+	r.NoError(err)
+	r.Contains(string(client), `"github.com/acme/names-client"`)
+	r.NotContains(string(client), `"github.com/monadicstack/frodo/example/names"`)
+}
+
+// Ensures that the descriptor is a standalone Go file w/ the service interface and models, but nothing else.
+func (suite *ClientModuleSuite) TestDescriptor() {
+	r := suite.Require()
+	ctx, err := parser.ParseFile("../example/names/name_service.go")
+	r.NoError(err)
+
+	descriptor, err := generate.Descriptor(ctx)
+	r.NoError(err)
+	r.Contains(string(descriptor), "// Code generated by Frodo - DO NOT EDIT.")
+	r.Contains(string(descriptor), "package names\n")
+	r.Contains(string(descriptor), "type NameService interface {")
+	r.Contains(string(descriptor), "type SplitRequest NameRequest")
+	r.NotContains(string(descriptor), "NameServiceHandler")
+}
+
+func TestClientModuleSuite(t *testing.T) {
+	suite.Run(t, new(ClientModuleSuite))
+}
@@ -1,6 +1,5 @@
-/* global require,process */
-const fetch = require('./node_modules/node-fetch/lib/index.js');
-const {NameServiceClient} = require('../../../example/names/gen/name_service.gen.client.js');
+/* global process */
+import {NameServiceClient} from '../../../example/names/gen/name_service.gen.client.js';
 
 async function main() {
     const suite = new TestSuite();
@@ -10,7 +9,7 @@ async function main() {
 
 class TestSuite {
     async testNotConnected() {
-        const client = new NameServiceClient('http://localhost:9999', {fetch});
+        const client = new NameServiceClient('http://localhost:9999');
         await output(client.Split({ Name: 'Jeff Lebowski' }));
         await output(client.Download({ Name: 'Jeff Lebowski' }));
     }
@@ -21,7 +20,7 @@ class TestSuite {
     }
 
     async testSuccess() {
-        const client = new NameServiceClient('http://localhost:9100', {fetch});
+        const client = new NameServiceClient('http://localhost:9100');
         await output(client.Split({ Name: 'Jeff Lebowski' }));
         await output(client.FirstName({ Name: 'Jeff Lebowski' }));
         await output(client.LastName({ Name: 'Jeff Lebowski' }));
@@ -30,19 +29,19 @@ class TestSuite {
     }
 
     async testSuccessRaw() {
-        const client = new NameServiceClient('http://localhost:9100', {fetch});
+        const client = new NameServiceClient('http://localhost:9100');
         await outputRaw(client.Download({ Name: 'Jeff Lebowski' }));
     }
 
     async testSuccessRawHeaders() {
-        const client = new NameServiceClient('http://localhost:9100', {fetch});
+        const client = new NameServiceClient('http://localhost:9100');
         await outputRaw(client.DownloadExt({ Name: 'Jeff Lebowski', Ext: 'csv' }));
         await outputRaw(client.DownloadExt({ Name: 'Jeff Lebowski', Ext: 'txt' }));
         await outputRaw(client.DownloadExt({ Name: 'Jeff Lebowski', Ext: 't"x"t' }));
     }
 
     async testValidationFailure() {
-        const client = new NameServiceClient('http://localhost:9100', {fetch});
+        const client = new NameServiceClient('http://localhost:9100');
         await output(client.Split({ Name: '' }));
         await output(client.Split({ }));
         await output(client.FirstName({ Name: '' }));
@@ -58,7 +57,7 @@ class TestSuite {
     }
 
     async testAuthFailureClient() {
-        const client = new NameServiceClient('http://localhost:9100', {fetch, authorization: 'Donny'});
+        const client = new NameServiceClient('http://localhost:9100', {authorization: 'Donny'});
         await output(client.Split({ Name: 'Dude' }));
         await output(client.FirstName({ Name: 'Dude' }));
         await output(client.LastName({ Name: 'Dude' }));
@@ -66,7 +65,7 @@ class TestSuite {
     }
 
     async testAuthFailureCall() {
-        const client = new NameServiceClient('http://localhost:9100', {fetch});
+        const client = new NameServiceClient('http://localhost:9100');
         await output(client.Split({Name: 'Dude'}, {authorization: 'Donny'}));
         await output(client.FirstName({Name: 'Dude'}, {authorization: 'Donny'}));
         await output(client.LastName({Name: 'Dude'}, {authorization: 'Donny'}));
@@ -74,7 +73,7 @@ class TestSuite {
     }
 
     async testAuthFailureCallOverride() {
-        const client = new NameServiceClient('http://localhost:9100', {fetch, authorization: 'Donny'});
+        const client = new NameServiceClient('http://localhost:9100', {authorization: 'Donny'});
         await output(client.Split({Name: 'Dude'}, {authorization: 'ok'}));
         await output(client.FirstName({Name: 'Dude'}, {authorization: 'ok'}));
         await output(client.LastName({Name: 'Dude'}, {authorization: 'ok'}));
@@ -98,12 +97,17 @@ async function output(value, raw = false) {
         const failure = await e;
         const failureJSON = typeof failure === 'string'
             ? JSON.stringify({message: failure})
-            : JSON.stringify(failure);
+            : JSON.stringify(failure instanceof Error ? {message: errorMessage(failure)} : failure);
 
         console.info('FAIL ' + failureJSON);
     }
 }
 
+// Node's fetch hides the reason for network failures (e.g. ECONNREFUSED) in the error's cause.
+function errorMessage(err) {
+    return err.cause ? err.message + ': ' + (err.cause.code || err.cause.message) : err.message;
+}
+
 main()
     .then()
     .catch((e) => console.info('FAILURE:' + e));
@@ -0,0 +1,32 @@
+package generate
+
+import (
+	"runtime/debug"
+)
+
+// Version is the version of Frodo that we include in the header of every artifact we generate. You can set it
+// when building the 'frodo' binary w/ '-ldflags "-X github.com/monadicstack/frodo/generate.Version=v1.2.3"'. When
+// you don't, we use the module version that "go install" recorded in the binary.
+var Version = ""
+
+// version resolves the Frodo version that we should include in generated artifacts.
+func version() string {
+	if Version != "" {
+		return Version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+
+	const frodoModule = "github.com/monadicstack/frodo"
+	if info.Main.Path == frodoModule && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	for _, dependency := range info.Deps {
+		if dependency.Path == frodoModule && dependency.Version != "" {
+			return dependency.Version
+		}
+	}
+	return "devel"
+}
@@ -4,10 +4,16 @@ go 1.16
 
 require (
 	github.com/dimfeld/httptreemux/v5 v5.2.2
+	github.com/monadicstack/frodo/rpc v0.0.0-00010101000000-000000000000
 	github.com/monadicstack/respond v0.4.2
 	github.com/spf13/cobra v1.1.1
 	github.com/stretchr/testify v1.6.1
 	github.com/urfave/negroni v1.0.0
 	golang.org/x/mod v0.4.0
 	golang.org/x/tools v0.0.0-20191119224855-298f0cb1881e
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
+
+// The RPC runtime is its own module so that generated code doesn't drag in the CLI, parser, etc. We always
+// build the CLI against the runtime in this repo.
+replace github.com/monadicstack/frodo/rpc => ./rpc
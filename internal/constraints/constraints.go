@@ -0,0 +1,75 @@
+// Package constraints defines the formats you can require of a path parameter by adding the constraint's name
+// in parentheses after the parameter (e.g. "GET /user/:id(uuid)/order/:num(int)"). The parser strips them out
+// of the route, the gateway rejects requests whose parameters don't match, and the docs describe the format.
+// The runtime validates parameters using its own copy of these patterns (rpc/internal/constraints) so that
+// it doesn't depend on this module; keep the two in sync.
+package constraints
+
+import (
+	"regexp"
+	"strings"
+)
+
+// constraint is the pattern that a parameter w/ a given constraint must match as well as the value
+// we use for the parameter when building examples for the docs.
+type constraint struct {
+	pattern *regexp.Regexp
+	example string
+}
+
+var constraints = map[string]constraint{
+	"int":      {pattern: regexp.MustCompile(`^-?[0-9]+$`), example: "1"},
+	"uint":     {pattern: regexp.MustCompile(`^[0-9]+$`), example: "1"},
+	"float":    {pattern: regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`), example: "1.5"},
+	"uuid":     {pattern: regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`), example: "3fa85f64-5717-4562-b3fc-2c963f66afa6"},
+	"alpha":    {pattern: regexp.MustCompile(`^[A-Za-z]+$`), example: "abc"},
+	"alphanum": {pattern: regexp.MustCompile(`^[A-Za-z0-9]+$`), example: "abc123"},
+	"slug":     {pattern: regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`), example: "hello-world"},
+}
+
+// Valid returns true when the name is one of the supported constraints (e.g. "uuid").
+func Valid(name string) bool {
+	_, ok := constraints[name]
+	return ok
+}
+
+// Pattern returns the regular expression that values w/ the named constraint must match. It returns an
+// empty string for unknown constraints.
+func Pattern(name string) string {
+	if c, ok := constraints[name]; ok {
+		return c.pattern.String()
+	}
+	return ""
+}
+
+// Example returns a value that satisfies the named constraint (e.g. "1" for "int"). It returns an empty
+// string for unknown constraints.
+func Example(name string) string {
+	return constraints[name].example
+}
+
+// Split strips the constraints from a route like "/user/:id(uuid)/order/:num(int)", giving you the path the
+// router understands ("/user/:id/order/:num") and the constraint for each parameter that has one. Constraints
+// w/ unknown names are still stripped from the path, but the parameter is left unconstrained.
+func Split(path string) (string, map[string]string) {
+	var results map[string]string
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		open := strings.Index(segment, "(")
+		if !strings.HasPrefix(segment, ":") || open < 0 || !strings.HasSuffix(segment, ")") {
+			continue
+		}
+
+		paramName := segment[1:open]
+		constraintName := strings.ToLower(strings.TrimSpace(segment[open+1 : len(segment)-1]))
+		segments[i] = segment[:open]
+		if !Valid(constraintName) {
+			continue
+		}
+		if results == nil {
+			results = map[string]string{}
+		}
+		results[paramName] = constraintName
+	}
+	return strings.Join(segments, "/"), results
+}
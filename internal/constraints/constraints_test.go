@@ -0,0 +1,55 @@
+// +build unit
+
+package constraints_test
+
+import (
+	"testing"
+
+	"github.com/monadicstack/frodo/internal/constraints"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConstraintsSuite struct {
+	suite.Suite
+}
+
+func (suite *ConstraintsSuite) TestSplit() {
+	r := suite.Require()
+
+	path, params := constraints.Split("/user/:id(uuid)/order/:num(int)")
+	r.Equal("/user/:id/order/:num", path)
+	r.Equal(map[string]string{"id": "uuid", "num": "int"}, params)
+
+	path, params = constraints.Split("/user/:id/order/:num(UInt)")
+	r.Equal("/user/:id/order/:num", path)
+	r.Equal(map[string]string{"num": "uint"}, params)
+
+	path, params = constraints.Split("/user/:id(nope)/order")
+	r.Equal("/user/:id/order", path, "Should strip unknown constraints")
+	r.Nil(params, "Should ignore unknown constraints")
+
+	path, params = constraints.Split("/user/(uuid)/order/:num(int")
+	r.Equal("/user/(uuid)/order/:num(int", path, "Should only strip constraints from parameters")
+	r.Nil(params)
+
+	path, params = constraints.Split("/")
+	r.Equal("/", path)
+	r.Nil(params)
+}
+
+func (suite *ConstraintsSuite) TestExample() {
+	r := suite.Require()
+
+	for _, name := range []string{"int", "uint", "float", "uuid", "alpha", "alphanum", "slug"} {
+		r.True(constraints.Valid(name), name)
+		r.NotEmpty(constraints.Pattern(name), name)
+		r.Regexp(constraints.Pattern(name), constraints.Example(name), "%s: Example should satisfy the constraint", name)
+	}
+	r.False(constraints.Valid("nope"))
+	r.Equal("", constraints.Example("nope"))
+	r.Equal("", constraints.Pattern("nope"))
+}
+
+func TestConstraintsSuite(t *testing.T) {
+	suite.Run(t, new(ConstraintsSuite))
+}
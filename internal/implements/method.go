@@ -54,6 +54,9 @@ func Signature(method *types.Func, name string, paramTypes []string, resultTypes
 	if method.Name() != name {
 		return false
 	}
+	if signature.Params().Len() < len(paramTypes) || signature.Results().Len() < len(resultTypes) {
+		return false
+	}
 
 	for i, paramType := range paramTypes {
 		param := signature.Params().At(i)
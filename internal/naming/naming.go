@@ -1,6 +1,9 @@
 package naming
 
-import "strings"
+import (
+	"strings"
+	"unicode"
+)
 
 // NoPackage strips of any package prefixes from an identifier (e.g. "context.Context" -> "Context")
 func NoPackage(ident string) string {
@@ -75,6 +78,79 @@ func ToUpperCamel(value string) string {
 	return strings.ToUpper(firstChar) + value[1:]
 }
 
+// ToSnakeCase converts a Go-style identifier to snake_case (e.g. "FirstName" -> "first_name"). Acronyms
+// stay together, so "UserID" becomes "user_id" and "HTTPServer" becomes "http_server".
+func ToSnakeCase(value string) string {
+	runes := []rune(value)
+	result := strings.Builder{}
+	for i, r := range runes {
+		if !unicode.IsUpper(r) {
+			result.WriteRune(r)
+			continue
+		}
+		// Start a new word when we go from lower to upper ("firstName") or when we hit the last
+		// letter of an acronym that is followed by another word ("HTTPServer").
+		if i > 0 && runes[i-1] != '_' {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				result.WriteRune('_')
+			}
+		}
+		result.WriteRune(unicode.ToLower(r))
+	}
+	return result.String()
+}
+
+// ToKebabCase converts a Go-style identifier to kebab-case (e.g. "UserService" -> "user-service"), which
+// is what Kubernetes and friends expect resource names to look like.
+func ToKebabCase(value string) string {
+	return strings.ReplaceAll(ToSnakeCase(value), "_", "-")
+}
+
+// Pluralize returns the plural form of an English noun/identifier (e.g. "User" -> "Users", "Category" ->
+// "Categories", "Address" -> "Addresses"). Only the last word of a camel case identifier changes, so
+// "SalesPerson" becomes "SalesPeople". This covers the common rules, not every quirk of English.
+func Pluralize(value string) string {
+	lower := strings.ToLower(value)
+	for singular, plural := range irregularPlurals {
+		if !strings.HasSuffix(lower, singular) {
+			continue
+		}
+		// Keep the capitalization of the word we're replacing, so "Person" becomes "People", not "people".
+		start := len(value) - len(singular)
+		if start > 0 && !unicode.IsUpper(rune(value[start])) {
+			continue
+		}
+		if unicode.IsUpper(rune(value[start])) {
+			plural = strings.ToUpper(plural[:1]) + plural[1:]
+		}
+		return value[:start] + plural
+	}
+
+	switch {
+	case value == "":
+		return ""
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return value + "es"
+	case len(lower) > 1 && strings.HasSuffix(lower, "y") && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return value[:len(value)-1] + "ies"
+	default:
+		return value + "s"
+	}
+}
+
+// irregularPlurals are the nouns most likely to show up in a service's names that don't follow the normal rules.
+var irregularPlurals = map[string]string{
+	"person": "people",
+	"child":  "children",
+	"man":    "men",
+	"woman":  "women",
+	"datum":  "data",
+	"index":  "indices",
+}
+
 // EmptyString is a predicate that returns true when the input value is "".
 func EmptyString(value string) bool {
 	return value == ""
@@ -106,25 +182,3 @@ func CleanTypeNameUpper(typeName string) string {
 	typeName = ToUpperCamel(typeName)
 	return typeName
 }
-
-// DispositionFileName extracts the "filename" from an HTTP Content-Disposition header value.
-func DispositionFileName(contentDisposition string) string {
-	// The start or the file name in the header is the index of "filename=" plus the 9
-	// characters in that substring.
-	fileNameAttrIndex := strings.Index(contentDisposition, "filename=")
-	if fileNameAttrIndex < 0 {
-		return ""
-	}
-
-	// Support the fact that all of these are valid for the disposition header:
-	//
-	//   attachment; filename=foo.pdf
-	//   attachment; filename="foo.pdf"
-	//   attachment; filename='foo.pdf'
-	//
-	// This just makes sure that you don't have any quotes in your final value.
-	fileName := contentDisposition[fileNameAttrIndex+9:]
-	fileName = strings.Trim(fileName, `"'`)
-	fileName = strings.ReplaceAll(fileName, `\"`, `"`)
-	return fileName
-}
@@ -95,6 +95,48 @@ func (suite *NamingSuite) TestLeadingSlash() {
 	r.Equal("//foo/bar//", naming.LeadingSlash("//foo/bar//"))
 }
 
+func (suite *NamingSuite) TestToSnakeCase() {
+	r := suite.Require()
+	r.Equal("", naming.ToSnakeCase(""))
+	r.Equal("foo", naming.ToSnakeCase("foo"))
+	r.Equal("foo", naming.ToSnakeCase("Foo"))
+	r.Equal("first_name", naming.ToSnakeCase("FirstName"))
+	r.Equal("first_name", naming.ToSnakeCase("firstName"))
+	r.Equal("first_name", naming.ToSnakeCase("first_name"))
+	r.Equal("id", naming.ToSnakeCase("ID"))
+	r.Equal("user_id", naming.ToSnakeCase("UserID"))
+	r.Equal("http_server", naming.ToSnakeCase("HTTPServer"))
+	r.Equal("address2", naming.ToSnakeCase("Address2"))
+	r.Equal("v2_name", naming.ToSnakeCase("V2Name"))
+}
+
+func (suite *NamingSuite) TestToKebabCase() {
+	r := suite.Require()
+	r.Equal("", naming.ToKebabCase(""))
+	r.Equal("foo", naming.ToKebabCase("Foo"))
+	r.Equal("user-service", naming.ToKebabCase("UserService"))
+	r.Equal("http-server", naming.ToKebabCase("HTTPServer"))
+	r.Equal("first-name", naming.ToKebabCase("first_name"))
+}
+
+func (suite *NamingSuite) TestPluralize() {
+	r := suite.Require()
+	r.Equal("", naming.Pluralize(""))
+	r.Equal("Users", naming.Pluralize("User"))
+	r.Equal("users", naming.Pluralize("user"))
+	r.Equal("Categories", naming.Pluralize("Category"))
+	r.Equal("Keys", naming.Pluralize("Key"))
+	r.Equal("Addresses", naming.Pluralize("Address"))
+	r.Equal("Boxes", naming.Pluralize("Box"))
+	r.Equal("Matches", naming.Pluralize("Match"))
+	r.Equal("Statuses", naming.Pluralize("Status"))
+	r.Equal("People", naming.Pluralize("Person"))
+	r.Equal("SalesPeople", naming.Pluralize("SalesPerson"))
+	r.Equal("children", naming.Pluralize("child"))
+	r.Equal("Humans", naming.Pluralize("Human"), "Should only replace whole words")
+	r.Equal("SalesWomen", naming.Pluralize("SalesWoman"))
+}
+
 func (suite *NamingSuite) TestEmptyString() {
 	r := suite.Require()
 	r.Equal(true, naming.EmptyString(""))
@@ -17,8 +17,18 @@ func main() {
 	rootCmd.AddCommand(cli.GenerateGateway{}.Command())
 	rootCmd.AddCommand(cli.GenerateClient{}.Command())
 	rootCmd.AddCommand(cli.GenerateMock{}.Command())
+	rootCmd.AddCommand(cli.ImplementService{}.Command())
 	rootCmd.AddCommand(cli.GenerateDocs{}.Command())
+	rootCmd.AddCommand(cli.GenerateOwners{}.Command())
+	rootCmd.AddCommand(cli.GenerateDeploy{}.Command())
+	rootCmd.AddCommand(cli.GenerateGraphQL{}.Command())
+	rootCmd.AddCommand(cli.GenerateAll{}.Command())
+	rootCmd.AddCommand(cli.Verify{}.Command())
+	rootCmd.AddCommand(cli.TestTemplates{}.Command())
+	rootCmd.AddCommand(cli.Publish{}.Command())
+	rootCmd.AddCommand(cli.Pull{}.Command())
 	rootCmd.AddCommand(cli.CreateService{}.Command())
+	rootCmd.AddCommand(cli.CreateExample{}.Command())
 
 	log.SetFlags(0)
 	if err := rootCmd.Execute(); err != nil {
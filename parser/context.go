@@ -8,10 +8,12 @@ import (
 	"net/http"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/monadicstack/frodo/internal/naming"
+	"github.com/monadicstack/frodo/internal/reflection"
 	"golang.org/x/tools/go/packages"
 )
 
@@ -32,6 +34,9 @@ type Context struct {
 	AbsolutePath string
 	// Timestamp is when we performed the parsing.
 	Timestamp time.Time
+	// Checksum is the SHA-256 hash of the input file's contents (e.g. "sha256:5e884898da..."). We include it
+	// in the header of generated artifacts so that you can tell which version of the service they came from.
+	Checksum string
 
 	// --- Fields related to orienting ourselves to the user's module/package.
 
@@ -59,6 +64,9 @@ type Context struct {
 	Tags Tags
 	// RawTypes contains the tree of all raw, parsed type information as we get it from the AST.
 	RawTypes *packages.Package
+
+	// routeNaming is the route naming convention from WithRouteNaming for services w/o a "ROUTES" doc option.
+	routeNaming string
 }
 
 // Scope returns the root of the parsed type tree for the source file we parsed.
@@ -79,6 +87,10 @@ type ServiceDeclaration struct {
 	// Version is the (hopefully) semantic version of your API (e.g. 1.2.0). This is NOT the prefix
 	// to all routes in the API for the service. It's just an identifier available to code gen tools.
 	Version string
+	// Owners are the teams/people responsible for this service as defined by "OWNER" doc options.
+	Owners []string
+	// Resources are the sizing hints from the "RESOURCES" doc option that "frodo deploy" puts in the manifests.
+	Resources ResourceOptions
 	// Gateway contains the configuration HTTP-related options for this service.
 	Gateway *GatewayServiceOptions
 	// Functions are all of the functions explicitly defined on this service.
@@ -98,9 +110,155 @@ func (service ServiceDeclaration) FunctionByName(name string) *ServiceFunctionDe
 	return nil
 }
 
+// HasAsync returns true when at least one of the service's functions uses the "ASYNC" doc option.
+func (service ServiceDeclaration) HasAsync() bool {
+	for _, function := range service.Functions {
+		if function.Gateway != nil && function.Gateway.Async {
+			return true
+		}
+	}
+	return false
+}
+
+// HasErrors returns true when at least one of the service's functions declares failures using the "ERRORS" doc option.
+func (service ServiceDeclaration) HasErrors() bool {
+	for _, function := range service.Functions {
+		if len(function.Errors) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// HasSSE returns true when at least one of the service's functions uses the "SSE" doc option.
+func (service ServiceDeclaration) HasSSE() bool {
+	for _, function := range service.Functions {
+		if function.Gateway != nil && function.Gateway.SSE {
+			return true
+		}
+	}
+	return false
+}
+
+// HasDeprecated returns true when at least one of the service's functions uses the "DEPRECATED" doc option.
+func (service ServiceDeclaration) HasDeprecated() bool {
+	for _, function := range service.Functions {
+		if function.Deprecation != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// HealthFunction returns the function w/ the "HEALTH" doc option that deployment manifests should use for their
+// liveness/readiness probes. Probes can't fill in path parameters, so only "GET" functions w/o any count. This
+// returns nil when the service doesn't have one.
+func (service ServiceDeclaration) HealthFunction() *ServiceFunctionDeclaration {
+	for _, function := range service.Functions {
+		gateway := function.Gateway
+		if gateway != nil && gateway.Health && gateway.Method == http.MethodGet && len(gateway.PathParameters()) == 0 {
+			return function
+		}
+	}
+	return nil
+}
+
+// HasEmpty returns true when at least one of the service's functions uses rpc.Empty as its request or response.
+func (service ServiceDeclaration) HasEmpty() bool {
+	return service.Functions.HasEmpty()
+}
+
+// AllProxied returns true when the gateway doesn't invoke any of the service's functions itself because they all
+// use the "PROXY" doc option to forward their requests to another backend.
+func (service ServiceDeclaration) AllProxied() bool {
+	for _, function := range service.Functions {
+		if function.Gateway == nil || function.Gateway.Proxy == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// SyncFunctions returns all of the service's functions that do NOT use the "ASYNC" doc option.
+func (service ServiceDeclaration) SyncFunctions() ServiceFunctionDeclarations {
+	var results ServiceFunctionDeclarations
+	for _, function := range service.Functions {
+		if function.Gateway == nil || !function.Gateway.Async {
+			results = append(results, function)
+		}
+	}
+	return results
+}
+
+// Events returns all of the unique event structs that the service's functions publish, in the order
+// that they first appear in "EMITS" doc options.
+func (service ServiceDeclaration) Events() []*TypeDeclaration {
+	var results []*TypeDeclaration
+	seen := map[*TypeDeclaration]bool{}
+	for _, function := range service.Functions {
+		for _, event := range function.Events {
+			if !seen[event] {
+				seen[event] = true
+				results = append(results, event)
+			}
+		}
+	}
+	return results
+}
+
 // ServiceFunctionDeclarations defines a collection of related service functions/operations.
 type ServiceFunctionDeclarations []*ServiceFunctionDeclaration
 
+// HasEmpty returns true when at least one of the functions uses rpc.Empty as its request or response.
+func (functions ServiceFunctionDeclarations) HasEmpty() bool {
+	for _, function := range functions {
+		if function.Request.Empty || function.Response.Empty {
+			return true
+		}
+	}
+	return false
+}
+
+// DeprecationOptions describes a function that callers should stop using.
+type DeprecationOptions struct {
+	// Notice is the text after "DEPRECATED" that tells callers what to do instead (e.g. "use LookupUser").
+	Notice string
+	// Sunset is when the function is going away according to the "SUNSET" doc option. It's the
+	// zero time when the function doesn't have one.
+	Sunset time.Time
+}
+
+// SunsetHeader returns the sunset date formatted for the HTTP "Sunset" header (e.g. "Fri, 01 Jan 2027 00:00:00 GMT").
+// It's blank when there's no sunset date.
+func (opts DeprecationOptions) SunsetHeader() string {
+	if opts.Sunset.IsZero() {
+		return ""
+	}
+	return opts.Sunset.UTC().Format(http.TimeFormat)
+}
+
+// ErrorDeclaration describes one of the failures that a function declares it may return (e.g. "ERRORS 404").
+type ErrorDeclaration struct {
+	// Status is the HTTP status code of the failure (e.g. 404).
+	Status int
+	// Name is the status text w/o spaces/punctuation (e.g. "NotFound") so that generated code can use it in
+	// identifiers. It's "Status499" looking for statuses that don't have any standard text.
+	Name string
+}
+
+// ErrorDeclarations are all of the failures that a function declares it may return.
+type ErrorDeclarations []*ErrorDeclaration
+
+// Contains returns true when one of the declared failures has the given HTTP status code.
+func (errs ErrorDeclarations) Contains(status int) bool {
+	for _, err := range errs {
+		if err.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
 // ServiceFunctionDeclaration defines a single operation/function within a service (one of the interface functions).
 type ServiceFunctionDeclaration struct {
 	// Name is the name of the function defined in the service interface (the function name to call this operation).
@@ -113,10 +271,25 @@ type ServiceFunctionDeclaration struct {
 	Gateway *GatewayFunctionOptions
 	// Documentation are all of the comments documenting this operation.
 	Documentation DocumentationLines
+	// Owners are the teams/people responsible for this operation as defined by "OWNER" doc options. When
+	// the function doesn't have any of its own, it inherits the owners of the service.
+	Owners []string
+	// Events are the event structs that this function publishes as defined by "EMITS" doc options.
+	Events []*TypeDeclaration
+	// Deprecation describes the "DEPRECATED" and "SUNSET" doc options. It's nil when the function isn't deprecated.
+	Deprecation *DeprecationOptions
+	// Errors are the failure statuses that this function declares it may return via "ERRORS" doc options.
+	Errors ErrorDeclarations
 	// Service represents the interface/service that this function belongs to.
 	Service *ServiceDeclaration
 }
 
+// Paginated returns true when the function's request/response follow frodo's pagination conventions. The request
+// must embed rpc.PagingRequest and the response must embed rpc.PagingResponse along with a single slice of results.
+func (f ServiceFunctionDeclaration) Paginated() bool {
+	return f.Request.Implements.PagingRequest && f.Response.PageItems() != nil
+}
+
 // String returns the function signature for this operation for debugging purposes.
 func (f ServiceFunctionDeclaration) String() string {
 	return fmt.Sprintf("%s(context.Context, *%v) (*%v, error)",
@@ -150,6 +323,17 @@ func (fields FieldDeclarations) ByName(name string) *FieldDeclaration {
 	return nil
 }
 
+// Defaults returns the subset of fields that have a default value (see FieldDeclaration.Default).
+func (fields FieldDeclarations) Defaults() FieldDeclarations {
+	var result FieldDeclarations
+	for _, f := range fields {
+		if f.Default != "" {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
 // TransportFields returns the subset of child fields for this field that
 // are NOT omitted (i.e. should be included in JSON/transport).
 func (fields FieldDeclarations) TransportFields() FieldDeclarations {
@@ -187,6 +371,9 @@ type FieldDeclaration struct {
 	Documentation DocumentationLines
 	// Binding describes the custom binding instructions used when unmarshaling request data onto this field.
 	Binding *FieldBindingOptions
+	// Default is the raw value (e.g. "25") that the gateway applies to this field when it's still zero after
+	// binding. This comes from the "DEFAULT" doc option or a `default:"25"` tag, and it's empty when neither is set.
+	Default string
 }
 
 // FieldBindingOptions provides hints to the generation tools about how the runtime binder will
@@ -196,6 +383,19 @@ type FieldBindingOptions struct {
 	Omit bool
 	// Name is the remapped JSON attribute for the associated field (e.g. `json:"user_id"` -> user_id).
 	Name string
+	// Tagged is true when the `json` tag explicitly remapped the field's name. Services that use the
+	// "JSON snake_case" doc option leave these names alone.
+	Tagged bool
+	// OmitEmpty will be true if the `json` tag included the 'omitempty' option (e.g. `json:"name,omitempty"`).
+	OmitEmpty bool
+	// Source is "header" or "cookie" when the field is bound from one of those rather than the
+	// body/path/query (e.g. `frodo:"header=X-Tenant-ID"`). It's empty for normal fields.
+	Source string
+	// SourceName is the name of the header/cookie that the field is bound from (e.g. "X-Tenant-ID").
+	SourceName string
+	// Redact is true when the field is tagged w/ `frodo:"redact"` because it holds sensitive data (passwords,
+	// PII, etc.). Gateways that log payloads (see rpc.WithPayloadLogging) replace its value w/ "[REDACTED]".
+	Redact bool
 }
 
 // NotOmit is a convenience for templates that returns true when we should expose this field to
@@ -204,6 +404,16 @@ func (opts FieldBindingOptions) NotOmit() bool {
 	return !opts.Omit
 }
 
+// FromHeader returns true when the gateway binds this field from an HTTP header.
+func (opts FieldBindingOptions) FromHeader() bool {
+	return opts.Source == reflection.BindingSourceHeader
+}
+
+// FromCookie returns true when the gateway binds this field from an HTTP cookie.
+func (opts FieldBindingOptions) FromCookie() bool {
+	return opts.Source == reflection.BindingSourceCookie
+}
+
 // ModuleDeclaration contains information about the Go module that the service belongs
 // to. This is information scraped from project's "go.mod" file.
 type ModuleDeclaration struct {
@@ -366,6 +576,42 @@ type GatewayServiceOptions struct {
 	Service *ServiceDeclaration
 	// PathPrefix is the optional version/domain prefix for all endpoints in the API (e.g. "v2/").
 	PathPrefix string
+	// Auth is the default authorization requirement ("required", "optional", or "none") for functions
+	// that don't specify their own. This is blank when the service doesn't have an "AUTH" doc option.
+	Auth string
+	// SnakeCase indicates that fields w/o a `json` tag use snake_case attribute names (e.g. "FirstName" becomes
+	// "first_name") rather than their exact Go names. This is enabled via the "JSON snake_case" doc option.
+	SnakeCase bool
+	// RouteNaming is the naming convention (e.g. "kebab-case") or custom template (e.g. "/rpc/{service}/{function}")
+	// for the routes of functions w/o a custom route. It comes from the "ROUTES" doc option or WithRouteNaming, and
+	// it's blank when the service uses the standard "/ServiceName.FunctionName" routes.
+	RouteNaming string
+}
+
+// HasPrefixVariables returns true when the PathPrefix has variables that the gateway/clients fill in at runtime
+// (e.g. "${API_PREFIX}/v2" or "/:tenant/v2").
+func (opts GatewayServiceOptions) HasPrefixVariables() bool {
+	if strings.Contains(opts.PathPrefix, "$") {
+		return true
+	}
+	for _, segment := range strings.Split(opts.PathPrefix, "/") {
+		if strings.HasPrefix(segment, ":") {
+			return true
+		}
+	}
+	return false
+}
+
+// ResourceOptions are the hints about how much CPU/memory the service needs and how many copies of it to run.
+// They come from the "RESOURCES" doc option (e.g. "RESOURCES cpu=250m memory=256Mi replicas=3"). Any value
+// that the option doesn't include is blank/0, so deployment manifests can leave it out.
+type ResourceOptions struct {
+	// CPU is the amount of CPU that each instance requests in Kubernetes units (e.g. "250m" or "2").
+	CPU string
+	// Memory is the amount of memory that each instance requests in Kubernetes units (e.g. "256Mi").
+	Memory string
+	// Replicas is the number of instances of the service to run.
+	Replicas int
 }
 
 // GatewayFunctionOptions contains all of the configurable HTTP-related options for a single
@@ -377,10 +623,51 @@ type GatewayFunctionOptions struct {
 	Method string
 	// Path defines the URL pattern to provide to the gateway's router/mux to access this operation.
 	Path string
+	// PathConstraints are the formats that path parameters must match, keyed by the parameter name (e.g.
+	// "id" -> "uuid" for the route "GET /user/:id(uuid)"). The Path itself doesn't include the constraints.
+	PathConstraints map[string]string
 	// Status indicates what success status code the gateway should use when responding via HTTP (e.g. 200, 202, etc)
 	Status int
+	// Auth indicates whether the caller must supply the Authorization header ("required"), may supply
+	// it ("optional"), or whether the endpoint ignores it entirely ("none"). This is enabled via the "AUTH"
+	// doc option and is blank when neither the function nor its service specifies one.
+	Auth string
+	// Async indicates that the gateway should reply w/ a 202 and a job id immediately, then run the function in the
+	// background. Callers poll "GET /jobs/:id" for the result. This is enabled via the "ASYNC" doc option.
+	Async bool
+	// SSE indicates that the gateway should keep the connection open and stream the events that the function
+	// sends via rpc.StreamEvent() to the caller as Server-Sent Events. This is enabled via the "SSE" doc option.
+	SSE bool
+	// MaxRequestBytes overrides the gateway's limit on the size of the request body for this operation. This is
+	// enabled via the "MAXBYTES" doc option (e.g. "MAXBYTES 10MB") or its "MAXBODY" alias. It's 0 when the
+	// function doesn't have the option and -1 when the option is "MAXBYTES unlimited". Generated clients also
+	// reject bodies over the limit before sending them.
+	MaxRequestBytes int64
+	// MaxConcurrency overrides the gateway's limit on how many requests for this operation can run at the same
+	// time. This is enabled via the "CONCURRENCY" doc option (e.g. "CONCURRENCY 16"). It's 0 when the function
+	// doesn't have the option and -1 when the option is "CONCURRENCY unlimited".
+	MaxConcurrency int
+	// Strict indicates that the gateway should reject request bodies w/ attributes that don't match a field
+	// in the request struct rather than silently ignoring them. This is enabled via the "STRICT" doc option.
+	Strict bool
+	// SkipMiddleware are the names of the gateway's middleware groups that should NOT run for this function
+	// (e.g. a public health check skipping "auth"). This is enabled via the "SKIP-MIDDLEWARE" doc option.
+	SkipMiddleware []string
+	// Proxy is the base URL of the backend (e.g. "http://legacy:8080") that the gateway forwards this function's
+	// requests to rather than invoking the service. This is enabled via the "PROXY" doc option.
+	Proxy string
+	// Dedupe is how long the gateway remembers the invocation ids of calls to this function so that it drops
+	// retries of calls it already handled. This is enabled via the "DEDUPE" doc option (e.g. "DEDUPE 30m"), which
+	// remembers them for DefaultDedupeWindow when it doesn't say. It's 0 when the function doesn't have the option.
+	Dedupe time.Duration
+	// Health indicates that deployment manifests should probe this function to see if the service is alive/ready.
+	// Only "GET" functions w/o path parameters can be health checks. This is enabled via the "HEALTH" doc option.
+	Health bool
 }
 
+// DefaultDedupeWindow is how long the gateway remembers invocation ids for functions w/ a plain "DEDUPE" doc option.
+const DefaultDedupeWindow = 10 * time.Minute
+
 // SupportsBody returns true when the method is either POST, PUT, or PATCH; the HTTP methods
 // where we expect you to feed request data via the request body rather than query string.
 func (opts GatewayFunctionOptions) SupportsBody() bool {
@@ -388,14 +675,24 @@ func (opts GatewayFunctionOptions) SupportsBody() bool {
 	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
 }
 
+// FullPath returns the Path for this operation including the service's PathPrefix (if it has one). For
+// instance, if the service has the prefix "/v2" and this function's path is "/user/:id", this will
+// return "/v2/user/:id".
+func (opts GatewayFunctionOptions) FullPath() string {
+	if opts.Function == nil || opts.Function.Service == nil || opts.Function.Service.Gateway == nil {
+		return opts.Path
+	}
+	return strings.TrimSuffix(opts.Function.Service.Gateway.PathPrefix, "/") + opts.Path
+}
+
 // PathParameters looks at all of the ":xxx" path parameters in HTTPPath and returns the fields on
 // the request struct that will be bound by those values at runtime. For instance, if the path
 // was "/user/:userID/address/:addressID", this will return a 2-element slice containing the request's
-// UserID and AddressID fields.
+// UserID and AddressID fields. This includes catch-all parameters like the "*path" in "/files/*path".
 func (opts GatewayFunctionOptions) PathParameters() GatewayParameters {
 	var results GatewayParameters
 	for _, segment := range strings.Split(opts.Path, "/") {
-		if !strings.HasPrefix(segment, ":") {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
 			continue
 		}
 
@@ -406,8 +703,10 @@ func (opts GatewayFunctionOptions) PathParameters() GatewayParameters {
 		}
 
 		results = append(results, &GatewayParameter{
-			Name:  paramName,
-			Field: field,
+			Name:       paramName,
+			Field:      field,
+			Constraint: opts.PathConstraints[paramName],
+			CatchAll:   strings.HasPrefix(segment, "*"),
 		})
 	}
 	return results
@@ -429,10 +728,13 @@ func (opts GatewayFunctionOptions) QueryParameters() GatewayParameters {
 	pathParams := opts.PathParameters()
 
 	for _, field := range opts.Function.Request.Fields {
-		// Exclude any fields that will be bound using path parameters.
+		// Exclude any fields that will be bound using path parameters or headers/cookies.
 		if pathParams.ByName(field.Binding.Name) != nil {
 			continue
 		}
+		if field.Binding.Source != "" {
+			continue
+		}
 
 		results = append(results, &GatewayParameter{
 			Name:  field.Binding.Name,
@@ -442,6 +744,31 @@ func (opts GatewayFunctionOptions) QueryParameters() GatewayParameters {
 	return results
 }
 
+// HeaderParameters describes all of the request struct attributes that are bound from HTTP headers
+// (i.e. fields tagged w/ `frodo:"header=X-Tenant-ID"`). The parameter's name is the name of the header.
+func (opts GatewayFunctionOptions) HeaderParameters() GatewayParameters {
+	return opts.sourceParameters(reflection.BindingSourceHeader)
+}
+
+// CookieParameters describes all of the request struct attributes that are bound from HTTP cookies
+// (i.e. fields tagged w/ `frodo:"cookie=session"`). The parameter's name is the name of the cookie.
+func (opts GatewayFunctionOptions) CookieParameters() GatewayParameters {
+	return opts.sourceParameters(reflection.BindingSourceCookie)
+}
+
+func (opts GatewayFunctionOptions) sourceParameters(source string) GatewayParameters {
+	var results GatewayParameters
+	for _, field := range opts.Function.Request.Fields {
+		if field.Binding.Source == source {
+			results = append(results, &GatewayParameter{
+				Name:  field.Binding.SourceName,
+				Field: field,
+			})
+		}
+	}
+	return results
+}
+
 // GatewayParameters is an overlay of a service function's path and request type/field info. It helps you
 // indicate how a given field will be bound when handling incoming requests (e.g. path params vs query params).
 type GatewayParameters []*GatewayParameter
@@ -474,6 +801,12 @@ type GatewayParameter struct {
 	// Field indicates which model attribute will be populated when this parameter goes
 	// through the request binder.
 	Field *FieldDeclaration
+	// Constraint is the format (e.g. "uuid" or "int") that a path param must match; the gateway rejects
+	// requests w/ a 400 when it doesn't. It's blank for unconstrained path params and all other parameters.
+	Constraint string
+	// CatchAll indicates that this is a path param like the "*path" in "/files/*path" that binds the rest of the
+	// path, slashes and all (e.g. "a/b/c.txt" for "/files/a/b/c.txt"). Clients shouldn't escape its slashes.
+	CatchAll bool
 }
 
 // TypeDeclaration is a snapshot of the type information for any type referenced, directly or indirectly, in
@@ -497,6 +830,13 @@ type TypeDeclaration struct {
 	Fields FieldDeclarations
 	// Documentation are all of the comments documenting this operation.
 	Documentation DocumentationLines
+	// Discriminator is the JSON attribute that says which alternative a "ONEOF" union holds. It's
+	// empty for all other types.
+	Discriminator string
+	// Alternatives are the shapes that a "ONEOF" union can take; the struct pointers that it embeds.
+	Alternatives []*TypeDeclaration
+	// Empty is true when this is rpc.Empty, the shared request/response for functions w/o any meaningful input/output.
+	Empty bool
 	// Implements contains some quick checks for whether or not this type implements the various
 	// single function interfaces used to handle raw data responses.
 	Implements struct {
@@ -512,6 +852,12 @@ type TypeDeclaration struct {
 		ContentFileNameReader bool
 		// ContentFileNameWriter is true when it implements that interface.
 		ContentFileNameWriter bool
+		// Redirector is true when it implements that interface, so the gateway responds w/ a redirect instead of JSON.
+		Redirector bool
+		// PagingRequest is true when the type embeds rpc.PagingRequest.
+		PagingRequest bool
+		// PagingResponse is true when the type embeds rpc.PagingResponse.
+		PagingResponse bool
 	}
 }
 
@@ -520,6 +866,26 @@ func (t TypeDeclaration) String() string {
 	return t.Name
 }
 
+// PageItems returns the field that contains the results of a paginated response. This only returns a value when
+// the type embeds rpc.PagingResponse and it has exactly one slice field; otherwise it returns nil.
+func (t TypeDeclaration) PageItems() *FieldDeclaration {
+	if !t.Implements.PagingResponse {
+		return nil
+	}
+
+	var items *FieldDeclaration
+	for _, field := range t.NonOmittedFields() {
+		if !field.Type.SliceLike() {
+			continue
+		}
+		if items != nil {
+			return nil
+		}
+		items = field
+	}
+	return items
+}
+
 // SliceLike returns true for array or slice types. This will also be true for any alias to an array/slice type.
 func (t TypeDeclaration) SliceLike() bool {
 	return t.Kind == reflect.Slice || t.Kind == reflect.Array
@@ -564,6 +930,18 @@ func (t TypeDeclaration) NonOmittedFields() FieldDeclarations {
 	return results
 }
 
+// OmitEmptyFields returns just the subset of fields tagged w/ 'omitempty' that clients should leave out of
+// the request when they're empty.
+func (t TypeDeclaration) OmitEmptyFields() FieldDeclarations {
+	var results FieldDeclarations
+	for _, f := range t.NonOmittedFields() {
+		if f.Binding.OmitEmpty {
+			results = append(results, f)
+		}
+	}
+	return results
+}
+
 // TypeRegistry is a quick lookup of all types we encountered when processing your declaration file.
 type TypeRegistry map[string]*TypeDeclaration
 
@@ -601,7 +979,8 @@ func (reg TypeRegistry) WithoutInvalid() TypeRegistry {
 }
 
 // NonBasicTypes returns a slice containing only types not declared as "Basic". This way you only
-// iterate complex types that you defined or imported.
+// iterate complex types that you defined or imported. The types are sorted by name so that generated
+// artifacts don't change from run to run.
 func (reg TypeRegistry) NonBasicTypes() []*TypeDeclaration {
 	var results []*TypeDeclaration
 	for _, t := range reg {
@@ -613,6 +992,13 @@ func (reg TypeRegistry) NonBasicTypes() []*TypeDeclaration {
 		}
 		results = append(results, t)
 	}
+	// Types from different packages can have the same name, so fall back to the full key to break ties.
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		return reg.key(results[i].Type, results[i].Name) < reg.key(results[j].Type, results[j].Name)
+	})
 	return results
 }
 
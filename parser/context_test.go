@@ -394,6 +394,23 @@ func (suite *ContextSuite) TestGatewayFunctionOptions_SupportsBody() {
 	check("Patch", true)
 }
 
+func (suite *ContextSuite) TestGatewayFunctionOptions_FullPath() {
+	check := func(prefix string, path string, expected string) {
+		service := &parser.ServiceDeclaration{Gateway: &parser.GatewayServiceOptions{PathPrefix: prefix}}
+		function := &parser.ServiceFunctionDeclaration{Service: service}
+		function.Gateway = &parser.GatewayFunctionOptions{Function: function, Path: path}
+		suite.Require().Equal(expected, function.Gateway.FullPath(), "Gateway.FullPath(%s, %s) should be %s", prefix, path, expected)
+	}
+
+	check("", "/user/:id", "/user/:id")
+	check("/", "/user/:id", "/user/:id")
+	check("/v2", "/user/:id", "/v2/user/:id")
+	check("/v2/", "/user/:id", "/v2/user/:id")
+
+	// No back-pointers to the service, so there's no prefix to apply.
+	suite.Require().Equal("/user/:id", parser.GatewayFunctionOptions{Path: "/user/:id"}.FullPath())
+}
+
 func (suite *ContextSuite) TestGatewayFunctionOptions_PathParameters() {
 	fields := parser.FieldDeclarations{
 		&parser.FieldDeclaration{Name: "ID", Binding: &parser.FieldBindingOptions{Name: "ID"}},
@@ -511,6 +528,39 @@ func (suite *ContextSuite) TestGatewayFunctionOptions_QueryParameters() {
 	suite.Require().Len(params, 0)
 }
 
+func (suite *ContextSuite) TestGatewayFunctionOptions_HeaderAndCookieParameters() {
+	fields := parser.FieldDeclarations{
+		&parser.FieldDeclaration{Name: "ID", Binding: &parser.FieldBindingOptions{Name: "ID"}},
+		&parser.FieldDeclaration{Name: "TenantID", Binding: &parser.FieldBindingOptions{Name: "TenantID", Source: "header", SourceName: "X-Tenant-ID"}},
+		&parser.FieldDeclaration{Name: "Session", Binding: &parser.FieldBindingOptions{Name: "Session", Source: "cookie", SourceName: "session"}},
+		&parser.FieldDeclaration{Name: "Trace", Binding: &parser.FieldBindingOptions{Name: "Trace", Source: "header", SourceName: "X-Trace"}},
+	}
+	function := &parser.ServiceFunctionDeclaration{
+		Request: &parser.TypeDeclaration{Fields: fields},
+	}
+	options := parser.GatewayFunctionOptions{
+		Function: function,
+		Method:   "GET",
+		Path:     "/foo",
+	}
+
+	params := options.HeaderParameters()
+	suite.Require().Len(params, 2)
+	suite.Require().Equal("X-Tenant-ID", params[0].Name)
+	suite.Require().Equal("TenantID", params[0].Field.Name)
+	suite.Require().Equal("X-Trace", params[1].Name)
+	suite.Require().Equal("Trace", params[1].Field.Name)
+
+	params = options.CookieParameters()
+	suite.Require().Len(params, 1)
+	suite.Require().Equal("session", params[0].Name)
+	suite.Require().Equal("Session", params[0].Field.Name)
+
+	params = options.QueryParameters()
+	suite.Require().Len(params, 1, "Header/cookie fields should not be query params")
+	suite.Require().Equal("ID", params[0].Name)
+}
+
 func (suite *ContextSuite) TestGatewayParameters_Empty_NotEmpty() {
 	params := parser.GatewayParameters{}
 	suite.Require().True(params.Empty())
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"go/ast"
 	"go/doc"
@@ -9,14 +10,18 @@ import (
 	"go/types"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
+	"github.com/monadicstack/frodo/internal/constraints"
 	"github.com/monadicstack/frodo/internal/implements"
 	"github.com/monadicstack/frodo/internal/naming"
+	"github.com/monadicstack/frodo/internal/reflection"
 	"golang.org/x/mod/modfile"
 	"golang.org/x/tools/go/packages"
 )
@@ -25,6 +30,15 @@ import (
 // not have the VERSION doc option.
 const DefaultServiceVersion = "0.0.1"
 
+// pagingRequestType is the fully qualified name of the type that paginated requests embed.
+const pagingRequestType = "github.com/monadicstack/frodo/rpc.PagingRequest"
+
+// pagingResponseType is the fully qualified name of the type that paginated responses embed.
+const pagingResponseType = "github.com/monadicstack/frodo/rpc.PagingResponse"
+
+// emptyType is the fully qualified name of the shared request/response for functions w/o any meaningful input/output.
+const emptyType = "github.com/monadicstack/frodo/rpc.Empty"
+
 // ErrNoServices is the error returned when your input file does not contain any "XyzService" interfaces.
 var ErrNoServices = fmt.Errorf("file does not contain any service interfaces")
 
@@ -52,6 +66,23 @@ var ErrTypeNotTwoParams = fmt.Errorf("must have two params")
 // ErrTypeNotTwoReturns is the error for when your function signature doesn't return two values.
 var ErrTypeNotTwoReturns = fmt.Errorf("must have two return values")
 
+// ErrInvalidRouteNaming is the error for when the "ROUTES" doc option (or the route naming you supplied
+// via WithRouteNaming) isn't one of the conventions we support or a valid custom route template.
+var ErrInvalidRouteNaming = fmt.Errorf("invalid route naming")
+
+// ParseOption lets you customize how we parse a service definition file.
+type ParseOption func(ctx *Context)
+
+// WithRouteNaming sets the naming convention for the routes of functions that don't have a custom
+// route (e.g. "GET /user/:ID"). This is how a CLI flag can give every service your organization's
+// URL standards. A service's own "ROUTES" doc option always wins over this. See ParseRouteNaming for
+// the conventions you can use.
+func WithRouteNaming(routeNaming string) ParseOption {
+	return func(ctx *Context) {
+		ctx.routeNaming = routeNaming
+	}
+}
+
 // ParseFile parses a source code file containing a service interface declaration as well as the
 // structs for the request/response inputs and outputs. It will aggregate all of the services/ops/models
 // described in the source code in a much more simple/direct Context.
@@ -59,10 +90,14 @@ var ErrTypeNotTwoReturns = fmt.Errorf("must have two return values")
 // The resulting Context contains all of the information from the source code that we need to generate
 // clients/gateways for the service(s). It will also be used as the input value when evaluating any
 // of our artifact templates.
-func ParseFile(inputPath string) (*Context, error) {
+func ParseFile(inputPath string, options ...ParseOption) (*Context, error) {
 	fileSet := token.NewFileSet()
 
-	file, err := parser.ParseFile(fileSet, inputPath, nil, parser.ParseComments)
+	source, err := ioutil.ReadFile(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse go file: %s: %w", inputPath, err)
+	}
+	file, err := parser.ParseFile(fileSet, inputPath, source, parser.ParseComments)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse go file: %s: %w", inputPath, err)
 	}
@@ -78,6 +113,10 @@ func ParseFile(inputPath string) (*Context, error) {
 		Path:         inputPath,
 		AbsolutePath: absolutePath,
 		Timestamp:    time.Now(),
+		Checksum:     fmt.Sprintf("sha256:%x", sha256.Sum256(source)),
+	}
+	for _, option := range options {
+		option(ctx)
 	}
 
 	if ctx.Module, err = ParseModuleInfo(ctx); err != nil {
@@ -145,7 +184,36 @@ func registerType(ctx *Context, registry TypeRegistry, t types.Type) *TypeDeclar
 	typeDeclaration := registry.Register(&TypeDeclaration{Name: name, Type: t})
 
 	registerTypeEntry(ctx, registry, typeDeclaration, t)
-	return ApplyTypeDocumentation(ctx, typeDeclaration)
+	ApplyTypeDocumentation(ctx, typeDeclaration)
+	if typeDeclaration.Discriminator != "" {
+		parseAlternatives(ctx, registry, typeDeclaration)
+	}
+	return typeDeclaration
+}
+
+// parseAlternatives registers the shapes that a "ONEOF" union can take; the struct pointers that it embeds. The
+// union's fields are the alternatives' fields merged together, so when two alternatives have a field w/ the same
+// name (e.g. "ID"), we only keep the first one.
+func parseAlternatives(ctx *Context, registry TypeRegistry, union *TypeDeclaration) {
+	structType, _ := underlyingStruct(union.Type)
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		if !field.Exported() || !field.Embedded() || !pointerType(field.Type()) {
+			continue
+		}
+		if _, ok := underlyingStruct(field.Type()); !ok {
+			continue
+		}
+		union.Alternatives = append(union.Alternatives, registerType(ctx, registry, field.Type()))
+	}
+
+	var fields FieldDeclarations
+	for _, field := range union.Fields {
+		if fields.ByName(field.Name) == nil {
+			fields = append(fields, field)
+		}
+	}
+	union.Fields = fields
 }
 
 func registerTypeEntry(ctx *Context, registry TypeRegistry, entry *TypeDeclaration, t types.Type) {
@@ -174,6 +242,9 @@ func registerTypeEntry(ctx *Context, registry TypeRegistry, entry *TypeDeclarati
 		entry.Implements.ContentWriter = implements.Method(tt, "SetContent", []string{"io.ReadCloser"}, nil)
 		entry.Implements.ContentTypeWriter = implements.Method(tt, "SetContentType", []string{"string"}, nil)
 		entry.Implements.ContentFileNameWriter = implements.Method(tt, "SetContentFileName", []string{"string"}, nil)
+		entry.Implements.Redirector = implements.Method(tt, "Redirect", nil, []string{"string", "int"})
+		entry.Implements.PagingRequest = implements.Method(tt, "Paging", nil, []string{"*" + pagingRequestType})
+		entry.Implements.PagingResponse = implements.Method(tt, "Paging", nil, []string{"*" + pagingResponseType})
 
 	case *types.Array:
 		entry.Basic = entry.Type == t
@@ -229,6 +300,7 @@ func parseStructField(ctx *Context, registry TypeRegistry, model *TypeDeclaratio
 		Pointer:    pointerType(structField.Type()),
 	}
 	fieldDecl.Binding = ParseBindingOptions(ctx, fieldDecl, structField)
+	fieldDecl.Default = ctx.Tags.ForField(fieldDecl).Get("default")
 	return ApplyFieldDocumentation(ctx, fieldDecl)
 }
 
@@ -478,10 +550,18 @@ func ParseService(ctx *Context) (*ServiceDeclaration, error) {
 	service := ApplyServiceDocumentation(ctx, &ServiceDeclaration{
 		Name:    serviceName,
 		Version: DefaultServiceVersion,
-		Gateway: &GatewayServiceOptions{},
+		Gateway: &GatewayServiceOptions{RouteNaming: ctx.routeNaming},
 	})
 	service.Gateway.Service = service
 
+	if _, err = RouteForFunction(service.Gateway.RouteNaming, service.Name, "Function"); err != nil {
+		return nil, fmt.Errorf("%s: %w", service.Name, err)
+	}
+
+	if service.Gateway.SnakeCase {
+		applySnakeCase(ctx.Types)
+	}
+
 	service.Functions, err = ParseServiceFunctions(ctx, service, serviceInterface)
 	if err != nil {
 		return nil, err
@@ -489,6 +569,20 @@ func ParseService(ctx *Context) (*ServiceDeclaration, error) {
 	return service, nil
 }
 
+// applySnakeCase renames the JSON attributes of every field w/o an explicit `json` name to its snake_case
+// equivalent (e.g. "FirstName" -> "first_name"). This mirrors what the gateway/client do at runtime when
+// the service uses the "JSON snake_case" doc option.
+func applySnakeCase(types TypeRegistry) {
+	for _, typeDecl := range types {
+		for _, field := range typeDecl.Fields {
+			if field.Binding == nil || field.Binding.Tagged || field.Binding.Omit {
+				continue
+			}
+			field.Binding.Name = naming.ToSnakeCase(field.Name)
+		}
+	}
+}
+
 // ParseServiceFunctions creates function declarations for all methods on the service interface.
 func ParseServiceFunctions(ctx *Context, service *ServiceDeclaration, interfaceType *types.Interface) ([]*ServiceFunctionDeclaration, error) {
 	var functions []*ServiceFunctionDeclaration
@@ -506,13 +600,22 @@ func ParseServiceFunctions(ctx *Context, service *ServiceDeclaration, interfaceT
 // ParseServiceFunction captures the information for a single function on a service. This includes all of the
 // doc options that configure the gateway stuff.
 func ParseServiceFunction(ctx *Context, service *ServiceDeclaration, funcType *types.Func) (*ServiceFunctionDeclaration, error) {
+	routeNaming := ""
+	if service.Gateway != nil {
+		routeNaming = service.Gateway.RouteNaming
+	}
+	path, err := RouteForFunction(routeNaming, service.Name, funcType.Name())
+	if err != nil {
+		return nil, fmt.Errorf("%s.%s(): %w", service.Name, funcType.Name(), err)
+	}
+
 	function := &ServiceFunctionDeclaration{
 		Name:    funcType.Name(),
 		Service: service,
 		Gateway: &GatewayFunctionOptions{
 			Status: http.StatusOK,
 			Method: http.MethodPost,
-			Path:   "/" + service.Name + "." + funcType.Name(),
+			Path:   path,
 		},
 	}
 	function.Gateway.Function = function
@@ -553,18 +656,59 @@ func ParseServiceFunction(ctx *Context, service *ServiceDeclaration, funcType *t
 
 	// We're enforcing a convention that you define your request/response structs in the same file as the
 	// services that they correspond to. Even if you want to share common types across services, that's fine,
-	// but you need to define an alias or a new type where the common type is embedded in that file.
-	if function.Request, _ = ctx.Types.Lookup(param2.Type()); function.Request == nil {
+	// but you need to define an alias or a new type where the common type is embedded in that file. The
+	// one exception is rpc.Empty for functions that don't have any meaningful input/output.
+	if function.Request = lookupMessageType(ctx, param2.Type()); function.Request == nil {
 		return nil, fmt.Errorf("%s(): request struct must be defined in %s", function.Name, ctx.Path)
 	}
-	if function.Response, _ = ctx.Types.Lookup(result1.Type()); function.Response == nil {
+	if function.Response = lookupMessageType(ctx, result1.Type()); function.Response == nil {
 		return nil, fmt.Errorf("%s(): response struct must be defined in %s", function.Name, ctx.Path)
 	}
 
 	ApplyFunctionDocumentation(ctx, function)
+	if service.Gateway.SnakeCase {
+		function.Gateway.Path, function.Gateway.PathConstraints = snakeCasePath(function.Gateway, function.Request.Fields)
+	}
 	return function, nil
 }
 
+// lookupMessageType finds the request/response struct for a service function in the registry. Since rpc.Empty
+// isn't defined in the service's package, we register it the first time that a function uses it.
+func lookupMessageType(ctx *Context, t types.Type) *TypeDeclaration {
+	if naming.NoPointer(t.String()) != emptyType {
+		typeDeclaration, _ := ctx.Types.Lookup(t)
+		return typeDeclaration
+	}
+	typeDeclaration := registerType(ctx, ctx.Types, t)
+	typeDeclaration.Empty = true
+	return typeDeclaration
+}
+
+// snakeCasePath renames path params like ":userID" to the snake_case attribute of the field they bind
+// (e.g. ":user_id") so that the gateway, clients, and docs all agree on the parameter's name. Any path
+// constraints are renamed along w/ their params.
+func snakeCasePath(gateway *GatewayFunctionOptions, fields FieldDeclarations) (string, map[string]string) {
+	var pathConstraints map[string]string
+	segments := strings.Split(gateway.Path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		paramName := segment[1:]
+		if field := fields.ByName(paramName); field != nil && !field.Binding.Tagged {
+			segments[i] = segment[:1] + field.Binding.Name
+			paramName = field.Binding.Name
+		}
+		if constraint, ok := gateway.PathConstraints[segment[1:]]; ok {
+			if pathConstraints == nil {
+				pathConstraints = map[string]string{}
+			}
+			pathConstraints[paramName] = constraint
+		}
+	}
+	return strings.Join(segments, "/"), pathConstraints
+}
+
 func flattenedStructFields(structType *types.Struct) []*types.Var {
 	var fields []*types.Var
 	for i := 0; i < structType.NumFields(); i++ {
@@ -602,9 +746,9 @@ func flattenedStructFields(structType *types.Struct) []*types.Var {
 	return fields
 }
 
-// ParseBindingOptions looks at the `json` tags of the given struct field and returns this field's binding
-// configuration. It indicates whether the field should be left out of JSON marshaling and what field name to
-// use when going to/from JSON format. If the field has no `json` tag, you will get a set of binding options
+// ParseBindingOptions looks at the `json`/`frodo` tags of the given struct field and returns this field's binding
+// configuration. It indicates whether the field should be left out of JSON marshaling, what field name to
+// use when going to/from JSON format, and whether it's bound from a header/cookie. If the field has no `json` tag, you will get a set of binding options
 // representing the default values (i.e. include the field and use its exact name).
 func ParseBindingOptions(ctx *Context, field *FieldDeclaration, fieldVar *types.Var) *FieldBindingOptions {
 	options := &FieldBindingOptions{
@@ -614,21 +758,28 @@ func ParseBindingOptions(ctx *Context, field *FieldDeclaration, fieldVar *types.
 
 	// The field doesn't have a 'json' tag assigned or they weirdly defined `json:""`, then
 	// the default binding options reign supreme.
-	tag := ctx.Tags.ForField(field).Get("json")
-	if tag == "" {
-		return options
+	tags := ctx.Tags.ForField(field)
+	if tag := tags.Get("json"); tag != "" {
+		// Non-Go clients care about 'omitempty' so they can leave empty values out of request bodies.
+		tagParts := strings.Split(tag, ",")
+		for _, tagOption := range tagParts[1:] {
+			options.OmitEmpty = options.OmitEmpty || strings.TrimSpace(tagOption) == "omitempty"
+		}
+		switch name := tagParts[0]; name {
+		case "-":
+			options.Omit = true
+		case "":
+			// e.g. `json:",omitempty"` keeps the default name.
+		default:
+			options.Name = name
+			options.Tagged = true
+		}
 	}
 
-	// We don't care about 'omitempty' or anything other than the remapped name. The
-	// runtime binder cares, but not the syntax parser.
-	switch name := strings.Split(tag, ",")[0]; name {
-	case "-":
-		options.Omit = true
-		return options
-	default:
-		options.Name = name
-		return options
-	}
+	// Fields tagged w/ `frodo:"header=X-Foo"` or `frodo:"cookie=foo"` are bound from there instead.
+	options.Source, options.SourceName = reflection.BindingSource(tags, options.Name)
+	options.Redact = reflection.Redacted(tags)
+	return options
 }
 
 // The first param to all service functions should be a standard "context.Context"
@@ -715,6 +866,291 @@ func parseHTTPStatus(statusText string) int {
 	return int(status)
 }
 
+// parseAuth normalizes the right hand side of an "AUTH required" looking comment. If the value isn't one
+// of "required", "optional", or "none", we'll treat it as though there was no AUTH option at all.
+func parseAuth(authText string) string {
+	authText = strings.ToLower(strings.TrimSpace(authText))
+	switch authText {
+	case "required", "optional", "none":
+		return authText
+	default:
+		return ""
+	}
+}
+
+// parseJSONNaming accepts the text after "JSON " in a service doc option and returns true when the
+// service should use snake_case attribute names. Anything else (e.g. "JSON default") keeps the Go names.
+func parseJSONNaming(namingText string) bool {
+	namingText = strings.ToLower(strings.TrimSpace(namingText))
+	return namingText == "snake_case"
+}
+
+// routeNamingTemplates are the custom route templates for each of the built-in naming conventions that
+// you can use in the "ROUTES" doc option (e.g. "ROUTES kebab-case").
+var routeNamingTemplates = map[string]string{
+	"":           "/{service}.{function}",
+	"default":    "/{service}.{function}",
+	"kebab-case": "/{service:kebab}/{function:kebab}",
+	"snake_case": "/{service:snake}/{function:snake}",
+	"lowercamel": "/{service:camel}.{function:camel}",
+}
+
+// RouteForFunction determines the route for a function that doesn't have a custom one (e.g. "GET /user/:ID")
+// based on the service's route naming convention. The conventions for the "CalculatorService.Add" function are:
+//
+//     default     /CalculatorService.Add
+//     kebab-case  /calculator-service/add
+//     snake_case  /calculator_service/add
+//     lowerCamel  /calculatorService.add
+//
+// You can also supply your own template where "{service}" and "{function}" are replaced w/ the service/function
+// names. Add ":kebab", ":snake", or ":camel" to change the case of the name (e.g. "/rpc/{service:kebab}/{function}"
+// is "/rpc/calculator-service/Add"). It returns ErrInvalidRouteNaming for anything else.
+func RouteForFunction(routeNaming string, serviceName string, functionName string) (string, error) {
+	routeTemplate, ok := routeNamingTemplates[strings.ToLower(strings.TrimSpace(routeNaming))]
+	if !ok && strings.Contains(routeNaming, "{") {
+		routeTemplate = routeNaming
+	}
+	if routeTemplate == "" {
+		return "", fmt.Errorf("%w: %s", ErrInvalidRouteNaming, routeNaming)
+	}
+
+	route := strings.Builder{}
+	for {
+		open := strings.Index(routeTemplate, "{")
+		if open < 0 {
+			break
+		}
+		length := strings.Index(routeTemplate[open:], "}")
+		if length < 0 {
+			return "", fmt.Errorf("%w: %s: missing '}'", ErrInvalidRouteNaming, routeNaming)
+		}
+		value, ok := routeToken(routeTemplate[open+1:open+length], serviceName, functionName)
+		if !ok {
+			return "", fmt.Errorf("%w: %s: unknown token %s", ErrInvalidRouteNaming, routeNaming, routeTemplate[open:open+length+1])
+		}
+		route.WriteString(routeTemplate[:open])
+		route.WriteString(value)
+		routeTemplate = routeTemplate[open+length+1:]
+	}
+	route.WriteString(routeTemplate)
+	return normalizePath(route.String()), nil
+}
+
+// routeToken resolves a single "{service:kebab}" looking token (w/o the braces) in a custom route template. It
+// returns false when the token isn't "service" or "function" or the case isn't one we support.
+func routeToken(token string, serviceName string, functionName string) (string, bool) {
+	name, casing := token, ""
+	if colon := strings.IndexRune(token, ':'); colon >= 0 {
+		name, casing = token[:colon], token[colon+1:]
+	}
+
+	var value string
+	switch strings.TrimSpace(name) {
+	case "service":
+		value = serviceName
+	case "function":
+		value = functionName
+	default:
+		return "", false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(casing)) {
+	case "":
+		return value, true
+	case "kebab":
+		return naming.ToKebabCase(value), true
+	case "snake":
+		return naming.ToSnakeCase(value), true
+	case "camel":
+		return naming.ToLowerCamel(value), true
+	default:
+		return "", false
+	}
+}
+
+// byteSizeUnits are the suffixes you can use in a "MAXBYTES 10MB" looking comment. Like most tools
+// that deal w/ memory/upload sizes, a "KB" is 1024 bytes, not 1000.
+var byteSizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{suffix: "GB", size: 1 << 30},
+	{suffix: "MB", size: 1 << 20},
+	{suffix: "KB", size: 1 << 10},
+	{suffix: "G", size: 1 << 30},
+	{suffix: "M", size: 1 << 20},
+	{suffix: "K", size: 1 << 10},
+	{suffix: "B", size: 1},
+}
+
+// parseByteSize parses the right hand side of a "MAXBYTES 10MB" looking comment. You can supply a plain
+// number of bytes or use a KB/MB/GB suffix. The value "unlimited" results in -1 so that the endpoint isn't
+// subject to the gateway's limit. Invalid sizes are treated as though there was no MAXBYTES option at all.
+func parseByteSize(sizeText string) int64 {
+	sizeText = strings.ToUpper(strings.TrimSpace(sizeText))
+	if sizeText == "UNLIMITED" {
+		return -1
+	}
+
+	multiplier := int64(1)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(sizeText, unit.suffix) {
+			sizeText = strings.TrimSpace(strings.TrimSuffix(sizeText, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+	size, err := strconv.ParseInt(sizeText, 10, 64)
+	if err != nil || size <= 0 {
+		return 0
+	}
+	return size * multiplier
+}
+
+// parseConcurrency parses the right hand side of a "CONCURRENCY 16" looking comment. The value "unlimited"
+// results in -1 so that the endpoint isn't subject to the gateway's limit. Invalid limits are treated as
+// though there was no CONCURRENCY option at all.
+func parseConcurrency(limitText string) int {
+	limitText = strings.TrimSpace(limitText)
+	if strings.EqualFold(limitText, "unlimited") {
+		return -1
+	}
+	limit, err := strconv.Atoi(limitText)
+	if err != nil || limit <= 0 {
+		return 0
+	}
+	return limit
+}
+
+// parseProxy validates the right hand side of a "PROXY http://legacy:8080" looking comment. We'll ignore
+// anything that isn't an absolute http(s) URL since the gateway would have nowhere to send the requests.
+func parseProxy(targetText string) string {
+	targetText = strings.TrimSpace(targetText)
+	target, err := url.Parse(targetText)
+	if err != nil || target.Host == "" || (target.Scheme != "http" && target.Scheme != "https") {
+		return ""
+	}
+	return strings.TrimSuffix(targetText, "/")
+}
+
+// parseDedupe validates the right hand side of a "DEDUPE 30m" looking comment. When the window is missing or
+// isn't a positive duration, we use the default window.
+func parseDedupe(windowText string) time.Duration {
+	window, err := time.ParseDuration(strings.TrimSpace(windowText))
+	if err != nil || window <= 0 {
+		return DefaultDedupeWindow
+	}
+	return window
+}
+
+// parseResources parses the right hand side of a "RESOURCES cpu=250m memory=256Mi replicas=3" looking comment. The
+// hints can be in any order and separated by spaces or commas. We ignore unknown hints and invalid replica counts.
+func parseResources(resourcesText string, resources *ResourceOptions) {
+	for _, hint := range parseList(resourcesText) {
+		key, value := hint, ""
+		if i := strings.Index(hint, "="); i >= 0 {
+			key, value = strings.TrimSpace(hint[:i]), strings.TrimSpace(hint[i+1:])
+		}
+		if value == "" {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "cpu":
+			resources.CPU = value
+		case "memory", "mem":
+			resources.Memory = value
+		case "replicas":
+			if replicas, err := strconv.Atoi(value); err == nil && replicas > 0 {
+				resources.Replicas = replicas
+			}
+		}
+	}
+}
+
+// deprecation returns the function's deprecation options, creating them if this is the first "DEPRECATED"
+// or "SUNSET" doc option that we've seen. A sunset date implies that the function is deprecated.
+func deprecation(function *ServiceFunctionDeclaration) *DeprecationOptions {
+	if function.Deprecation == nil {
+		function.Deprecation = &DeprecationOptions{}
+	}
+	return function.Deprecation
+}
+
+// parseSunset parses the right hand side of a "SUNSET 2027-01-01" looking comment. We also accept RFC 3339
+// timestamps and HTTP dates. The boolean is false when the date is in none of those formats.
+func parseSunset(dateText string) (time.Time, bool) {
+	dateText = strings.TrimSpace(dateText)
+	for _, layout := range []string{"2006-01-02", time.RFC3339, http.TimeFormat} {
+		if sunset, err := time.Parse(layout, dateText); err == nil {
+			return sunset, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseDiscriminator parses the right hand side of a "ONEOF kind" looking comment; the name of the JSON attribute
+// that says which alternative the union holds. We'll use "type" if the name is blank.
+func parseDiscriminator(nameText string) string {
+	if fields := strings.Fields(nameText); len(fields) > 0 {
+		return fields[0]
+	}
+	return "type"
+}
+
+// parseList splits the right hand side of an "OWNER team-a, team-b" looking comment into
+// the individual values. Values can be separated by commas, spaces, or both.
+func parseList(listText string) []string {
+	return strings.FieldsFunc(listText, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
+// parseEvents resolves the struct names in an "EMITS UserCreated, UserDeleted" looking comment to the
+// types we parsed from your declaration file. Names can be separated by commas, spaces, or both. We'll
+// ignore any names that aren't structs defined in the same file as your service.
+func parseEvents(ctx *Context, eventsText string) []*TypeDeclaration {
+	var results []*TypeDeclaration
+	for _, name := range parseList(eventsText) {
+		event, ok := ctx.Types.LookupByName(name)
+		if !ok || event.Kind != reflect.Struct {
+			continue
+		}
+		results = append(results, event)
+	}
+	return results
+}
+
+// parseErrors parses the right hand side of an "ERRORS 404, 409" looking comment, adding the statuses to the ones
+// the function has already declared. Statuses can be separated by commas, spaces, or both. We ignore anything that
+// isn't a 4XX/5XX status as well as duplicates.
+func parseErrors(errs ErrorDeclarations, statusText string) ErrorDeclarations {
+	for _, value := range parseList(statusText) {
+		status, err := strconv.Atoi(value)
+		if err != nil || status < 400 || status > 599 || errs.Contains(status) {
+			continue
+		}
+		errs = append(errs, &ErrorDeclaration{Status: status, Name: errorName(status)})
+	}
+	return errs
+}
+
+// errorName strips everything but the letters/digits from the status text, so 404 is "NotFound" and 418
+// is "ImATeapot". Statuses w/o any standard text are just "Status499" looking names.
+func errorName(status int) string {
+	name := strings.Builder{}
+	for _, word := range strings.FieldsFunc(http.StatusText(status), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	}) {
+		word = strings.ReplaceAll(word, "'", "")
+		name.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	if name.Len() == 0 {
+		return "Status" + strconv.Itoa(status)
+	}
+	return name.String()
+}
+
 // ApplyServiceDocumentation takes the documentation comment block above your interface type
 // declaration and applies them to the service snapshot, parsing all Doc Options in the process.
 func ApplyServiceDocumentation(ctx *Context, service *ServiceDeclaration) *ServiceDeclaration {
@@ -730,6 +1166,16 @@ func ApplyServiceDocumentation(ctx *Context, service *ServiceDeclaration) *Servi
 			service.Gateway.PathPrefix = normalizePath(line[7:])
 		case strings.HasPrefix(line, "VERSION "):
 			service.Version = strings.TrimSpace(line[8:])
+		case strings.HasPrefix(line, "AUTH "):
+			service.Gateway.Auth = parseAuth(line[5:])
+		case strings.HasPrefix(line, "OWNER "):
+			service.Owners = append(service.Owners, parseList(line[6:])...)
+		case strings.HasPrefix(line, "JSON "):
+			service.Gateway.SnakeCase = parseJSONNaming(line[5:])
+		case strings.HasPrefix(line, "ROUTES "):
+			service.Gateway.RouteNaming = strings.TrimSpace(line[7:])
+		case strings.HasPrefix(line, "RESOURCES "):
+			parseResources(line[10:], &service.Resources)
 		default:
 			service.Documentation = append(service.Documentation, line)
 		}
@@ -751,6 +1197,7 @@ func ApplyFunctionDocumentation(ctx *Context, function *ServiceFunctionDeclarati
 	// reject the request (i.e. no default CORS). If bring your own CORS middleware to the
 	// party it will respond affirmatively before the rejection. There's more info in the
 	// comments of gateway.New() that describes why we need this limitation for now.
+	defaultMethod, defaultPath := function.Gateway.Method, function.Gateway.Path
 	for _, line := range ctx.Documentation.ForFunction(function) {
 		switch {
 		case strings.HasPrefix(line, "GET "):
@@ -773,11 +1220,59 @@ func ApplyFunctionDocumentation(ctx *Context, function *ServiceFunctionDeclarati
 			function.Gateway.Path = normalizePath(line[5:])
 		case strings.HasPrefix(line, "HTTP "):
 			function.Gateway.Status = parseHTTPStatus(line[5:])
+		case strings.TrimSpace(line) == "ASYNC":
+			function.Gateway.Async = true
+		case strings.TrimSpace(line) == "SSE":
+			function.Gateway.SSE = true
+		case strings.TrimSpace(line) == "STRICT":
+			function.Gateway.Strict = true
+		case strings.HasPrefix(line, "MAXBYTES "):
+			function.Gateway.MaxRequestBytes = parseByteSize(line[9:])
+		case strings.HasPrefix(line, "MAXBODY "):
+			function.Gateway.MaxRequestBytes = parseByteSize(line[8:])
+		case strings.HasPrefix(line, "CONCURRENCY "):
+			function.Gateway.MaxConcurrency = parseConcurrency(line[12:])
+		case strings.HasPrefix(line, "SKIP-MIDDLEWARE "):
+			function.Gateway.SkipMiddleware = append(function.Gateway.SkipMiddleware, parseList(line[16:])...)
+		case strings.HasPrefix(line, "PROXY "):
+			function.Gateway.Proxy = parseProxy(line[6:])
+		case strings.TrimSpace(line) == "DEDUPE" || strings.HasPrefix(line, "DEDUPE "):
+			function.Gateway.Dedupe = parseDedupe(line[6:])
+		case strings.TrimSpace(line) == "HEALTH":
+			function.Gateway.Health = true
+		case strings.HasPrefix(line, "AUTH "):
+			function.Gateway.Auth = parseAuth(line[5:])
+		case strings.HasPrefix(line, "OWNER "):
+			function.Owners = append(function.Owners, parseList(line[6:])...)
+		case strings.HasPrefix(line, "EMITS "):
+			function.Events = append(function.Events, parseEvents(ctx, line[6:])...)
+		case strings.HasPrefix(line, "ERRORS "):
+			function.Errors = parseErrors(function.Errors, line[7:])
+		case strings.TrimSpace(line) == "DEPRECATED" || strings.HasPrefix(line, "DEPRECATED "):
+			deprecation(function).Notice = strings.TrimSpace(strings.TrimPrefix(line, "DEPRECATED"))
+		case strings.HasPrefix(line, "SUNSET "):
+			if sunset, ok := parseSunset(line[7:]); ok {
+				deprecation(function).Sunset = sunset
+			}
 		default:
 			function.Documentation = append(function.Documentation, line)
 		}
 	}
 	function.Documentation = function.Documentation.Trim()
+	function.Gateway.Path, function.Gateway.PathConstraints = constraints.Split(function.Gateway.Path)
+
+	// Browsers can only open event streams using GET, so that's the default for "SSE" functions.
+	if function.Gateway.SSE && function.Gateway.Method == defaultMethod && function.Gateway.Path == defaultPath {
+		function.Gateway.Method = http.MethodGet
+	}
+	// Functions w/o their own OWNER options belong to whoever owns the service.
+	if len(function.Owners) == 0 && function.Service != nil {
+		function.Owners = function.Service.Owners
+	}
+	// Functions w/o their own AUTH option have the same requirements as the service.
+	if function.Gateway.Auth == "" && function.Service != nil && function.Service.Gateway != nil {
+		function.Gateway.Auth = function.Service.Gateway.Auth
+	}
 }
 
 // ApplyTypeDocumentation takes the documentation comment block above your struct/alias type
@@ -786,7 +1281,18 @@ func ApplyTypeDocumentation(ctx *Context, t *TypeDeclaration) *TypeDeclaration {
 	if t == nil {
 		return t
 	}
-	t.Documentation = ctx.Documentation.ForType(t).Trim()
+	t.Documentation = nil
+	for _, line := range ctx.Documentation.ForType(t) {
+		switch {
+		case strings.TrimSpace(line) == "ONEOF" && t.Kind == reflect.Struct:
+			t.Discriminator = "type"
+		case strings.HasPrefix(line, "ONEOF ") && t.Kind == reflect.Struct:
+			t.Discriminator = parseDiscriminator(line[6:])
+		default:
+			t.Documentation = append(t.Documentation, line)
+		}
+	}
+	t.Documentation = t.Documentation.Trim()
 	return t
 }
 
@@ -796,7 +1302,16 @@ func ApplyFieldDocumentation(ctx *Context, field *FieldDeclaration) *FieldDeclar
 	if field == nil {
 		return field
 	}
-	field.Documentation = ctx.Documentation.ForField(field).Trim()
+	field.Documentation = nil
+	for _, line := range ctx.Documentation.ForField(field) {
+		switch {
+		case strings.HasPrefix(line, "DEFAULT "):
+			field.Default = strings.TrimSpace(line[8:])
+		default:
+			field.Documentation = append(field.Documentation, line)
+		}
+	}
+	field.Documentation = field.Documentation.Trim()
 	return field
 }
 
@@ -1,9 +1,13 @@
+//go:build unit
 // +build unit
 
 package parser_test
 
 import (
+	"errors"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/monadicstack/frodo/parser"
 	"github.com/stretchr/testify/suite"
@@ -42,6 +46,84 @@ func (suite *ParserSuite) TestBasic() {
 	suite.assertField(model, "Pins", expectedField{TypeName: "int"})
 }
 
+// Ensure that the "ROUTES" doc option determines the routes for functions w/o a custom route and that it
+// beats the convention you supply when parsing.
+func (suite *ParserSuite) TestRouteNaming() {
+	ctx, err := parser.ParseFile("testdata/routes/service.go", parser.WithRouteNaming("snake_case"))
+	suite.Require().NoError(err)
+	suite.Require().Equal("kebab-case", ctx.Service.Gateway.RouteNaming)
+	suite.Require().Equal([]string{"AccountService uses kebab-case routes for functions w/o a custom one."}, []string(ctx.Service.Documentation))
+
+	suite.assertFunction(ctx.Service, "GetAccount", expectedFunction{
+		RequestType:   "GetAccountRequest",
+		ResponseType:  "GetAccountResponse",
+		Documentation: parser.DocumentationLines{},
+		Gateway:       expectedGateway{Method: "POST", Path: "/account-service/get-account", Status: 200},
+	})
+	suite.Require().Equal("/v2/account-service/get-account", ctx.Service.Functions[0].Gateway.FullPath())
+	suite.Require().Equal("/accounts/:ID", ctx.Service.Functions[1].Gateway.Path, "Custom routes should win")
+
+	watch := ctx.Service.Functions[2].Gateway
+	suite.Require().Equal("GET", watch.Method, "SSE functions should still use GET w/ the new default route")
+	suite.Require().Equal("/account-service/watch-http-events", watch.Path)
+}
+
+// Ensure that WithRouteNaming applies to services that don't have a "ROUTES" doc option.
+func (suite *ParserSuite) TestRouteNaming_option() {
+	ctx, err := parser.ParseFile("testdata/basic/service.go", parser.WithRouteNaming("lowerCamel"))
+	suite.Require().NoError(err)
+	suite.Require().Equal("/dudeService.bowl", ctx.Service.Functions[0].Gateway.Path)
+
+	ctx, err = parser.ParseFile("testdata/basic/service.go", parser.WithRouteNaming("/rpc/{service:kebab}/{function}"))
+	suite.Require().NoError(err)
+	suite.Require().Equal("/rpc/dude-service/Bowl", ctx.Service.Functions[0].Gateway.Path)
+
+	ctx, err = parser.ParseFile("testdata/basic/service.go", parser.WithRouteNaming(""))
+	suite.Require().NoError(err)
+	suite.Require().Equal("/DudeService.Bowl", ctx.Service.Functions[0].Gateway.Path)
+
+	_, err = parser.ParseFile("testdata/basic/service.go", parser.WithRouteNaming("SCREAMING_CASE"))
+	suite.Require().True(errors.Is(err, parser.ErrInvalidRouteNaming), "Should reject unknown conventions")
+}
+
+// Ensure that we keep the variables in the path prefix so that the gateway/clients can fill them in at runtime.
+func (suite *ParserSuite) TestPrefixVariables() {
+	ctx, err := parser.ParseFile("testdata/prefix/service.go")
+	suite.Require().NoError(err)
+	suite.Require().Equal("/${API_PREFIX}/:tenant/v2", ctx.Service.Gateway.PathPrefix)
+	suite.Require().True(ctx.Service.Gateway.HasPrefixVariables())
+	suite.Require().Equal("/${API_PREFIX}/:tenant/v2/TenantService.GetTenant", ctx.Service.Functions[0].Gateway.FullPath())
+
+	ctx, err = parser.ParseFile("testdata/routes/service.go")
+	suite.Require().NoError(err)
+	suite.Require().False(ctx.Service.Gateway.HasPrefixVariables())
+}
+
+func (suite *ParserSuite) TestRouteForFunction() {
+	route := func(routeNaming string) string {
+		path, err := parser.RouteForFunction(routeNaming, "UserService", "GetUserByID")
+		suite.Require().NoError(err, routeNaming)
+		return path
+	}
+	suite.Require().Equal("/UserService.GetUserByID", route(""))
+	suite.Require().Equal("/UserService.GetUserByID", route("default"))
+	suite.Require().Equal("/user-service/get-user-by-id", route("kebab-case"))
+	suite.Require().Equal("/user-service/get-user-by-id", route(" Kebab-Case "))
+	suite.Require().Equal("/user_service/get_user_by_id", route("snake_case"))
+	suite.Require().Equal("/userService.getUserByID", route("lowerCamel"))
+	suite.Require().Equal("/api/v1/users/getUserByID", route("api/v1/users/{function:camel}/"))
+	suite.Require().Equal("/user_service-GetUserByID", route("{service:snake}-{ function }"))
+
+	invalid := func(routeNaming string) {
+		_, err := parser.RouteForFunction(routeNaming, "UserService", "GetUserByID")
+		suite.Require().True(errors.Is(err, parser.ErrInvalidRouteNaming), routeNaming)
+	}
+	invalid("kebab")
+	invalid("/rpc/{service")
+	invalid("/rpc/{resource}/{function}")
+	invalid("/rpc/{service:upper}/{function}")
+}
+
 // Ensure that all of the doc options have the correct effect on the parsed context.
 func (suite *ParserSuite) TestDocOptions() {
 	ctx, err := parser.ParseFile("testdata/docoptions/service.go")
@@ -60,23 +142,23 @@ func (suite *ParserSuite) TestDocOptions() {
 		Documentation: parser.DocumentationLines{
 			"Dude abides.",
 		},
-		Gateway: expectedGateway{Method: "GET", Path: "/dude/:id", Status: 202},
+		Gateway: expectedGateway{Method: "GET", Path: "/dude/:id", Status: 202, Auth: "none", MaxRequestBytes: 10 << 20, MaxConcurrency: 16, PathConstraints: map[string]string{"id": "int"}, Health: true},
 	})
 	suite.assertFunction(service, "Walter", expectedFunction{
 		Documentation: parser.DocumentationLines{},
-		Gateway:       expectedGateway{Method: "POST", Path: "/LebowskiService.Walter", Status: 200},
+		Gateway:       expectedGateway{Method: "POST", Path: "/LebowskiService.Walter", Status: 200, Auth: "required"},
 	})
 	suite.assertFunction(service, "Donny", expectedFunction{
 		Documentation: parser.DocumentationLines{},
-		Gateway:       expectedGateway{Method: "POST", Path: "/LebowskiService.Donny", Status: 204},
+		Gateway:       expectedGateway{Method: "GET", Path: "/LebowskiService.Donny", Status: 204, Auth: "optional", SSE: true, MaxRequestBytes: 512, Health: true},
 	})
 	suite.assertFunction(service, "Maude", expectedFunction{
 		Documentation: parser.DocumentationLines{},
-		Gateway:       expectedGateway{Method: "POST", Path: "/dude/:id/child", Status: 201},
+		Gateway:       expectedGateway{Method: "POST", Path: "/dude/:id/child", Status: 201, Auth: "required", Strict: true, MaxRequestBytes: 2 << 20, SkipMiddleware: []string{"auth", "metrics", "logging"}, Dedupe: parser.DefaultDedupeWindow},
 	})
 	suite.assertFunction(service, "Jackie", expectedFunction{
 		Documentation: parser.DocumentationLines{},
-		Gateway:       expectedGateway{Method: "PUT", Path: "/dude/jail", Status: 200},
+		Gateway:       expectedGateway{Method: "PUT", Path: "/dude/jail", Status: 200, Async: true, Auth: "required", MaxRequestBytes: -1, MaxConcurrency: -1, Strict: true, Dedupe: 30 * time.Minute},
 	})
 	suite.assertFunction(service, "Stranger", expectedFunction{
 		Documentation: parser.DocumentationLines{
@@ -84,20 +166,62 @@ func (suite *ParserSuite) TestDocOptions() {
 			"",
 			"Sometimes the bar eats you.",
 		},
-		Gateway: expectedGateway{Method: "PATCH", Path: "/dude/:id", Status: 200},
+		Gateway: expectedGateway{Method: "PATCH", Path: "/dude/:id", Status: 200, Auth: "required", SSE: true},
 	})
 	suite.assertFunction(service, "RemoveToe", expectedFunction{
 		Documentation: parser.DocumentationLines{
 			"RemoveToe attempts to extort $1 million.",
 		},
-		Gateway: expectedGateway{Method: "DELETE", Path: "/nihilist/:id/toe", Status: 200},
+		Gateway: expectedGateway{Method: "DELETE", Path: "/nihilist/:id/toe", Status: 200, Auth: "required", Proxy: "http://legacy:8080/nihilists"},
 	})
 	suite.assertFunction(service, "Rug", expectedFunction{
 		Documentation: parser.DocumentationLines{
 			"* HTTP 202",
 		},
-		Gateway: expectedGateway{Method: "HEAD", Path: "/ties/room/together", Status: 200},
+		Gateway: expectedGateway{Method: "HEAD", Path: "/ties/room/together", Status: 200, Auth: "required", Dedupe: parser.DefaultDedupeWindow, Health: true},
 	})
+
+	suite.Require().Equal("required", service.Gateway.Auth)
+	suite.Require().Equal(parser.ResourceOptions{CPU: "250m", Memory: "256Mi"}, service.Resources)
+	suite.Require().Equal("Donny", service.HealthFunction().Name, "Only GET functions w/o path params can be health checks")
+	suite.Require().False(service.AllProxied(), "Service should only be proxied when every function is")
+	suite.Require().True(service.HasSSE(), "Service w/ an SSE function should have SSE")
+	suite.Require().True(service.HasAsync(), "Service w/ an ASYNC function should be async")
+	suite.Require().Len(service.SyncFunctions(), 7, "Sync functions should not include ASYNC ones")
+	for _, function := range service.SyncFunctions() {
+		suite.Require().NotEqual("Jackie", function.Name, "Sync functions should not include ASYNC ones")
+	}
+	suite.Require().Equal([]string{"team-lebowski", "team-bowling"}, service.Owners)
+	suite.Require().Equal([]string{"team-art", "knox", "da-fino"}, service.FunctionByName("Maude").Owners)
+	suite.Require().Equal([]string{"team-lebowski", "team-bowling"}, service.FunctionByName("Walter").Owners)
+
+	suite.Require().Len(service.FunctionByName("Maude").Events, 1, "Should ignore unknown event names")
+	suite.Require().Equal("RugSoiled", service.FunctionByName("Maude").Events[0].Name)
+	suite.Require().Len(service.FunctionByName("Stranger").Events, 1)
+	suite.Require().Empty(service.FunctionByName("Walter").Events)
+	suite.Require().Len(service.Events(), 1, "Service events should not contain duplicates")
+	suite.Require().Equal("RugSoiled", service.Events()[0].Name)
+
+	suite.Require().True(service.HasErrors())
+	suite.Require().Equal(parser.ErrorDeclarations{
+		{Status: 404, Name: "NotFound"},
+		{Status: 409, Name: "Conflict"},
+		{Status: 418, Name: "ImATeapot"},
+		{Status: 499, Name: "Status499"},
+	}, service.FunctionByName("Maude").Errors)
+	suite.Require().True(service.FunctionByName("Maude").Errors.Contains(409))
+	suite.Require().False(service.FunctionByName("Maude").Errors.Contains(500))
+	suite.Require().Empty(service.FunctionByName("Walter").Errors)
+	suite.Require().Empty(service.FunctionByName("Rug").Errors, "Should ignore statuses that aren't failures")
+
+	suite.Require().True(service.HasDeprecated())
+	suite.Require().Equal(&parser.DeprecationOptions{}, service.FunctionByName("Dude").Deprecation)
+	suite.Require().Equal("use Walter instead", service.FunctionByName("Jackie").Deprecation.Notice)
+	suite.Require().Equal("Fri, 01 Jan 2027 00:00:00 GMT", service.FunctionByName("Jackie").Deprecation.SunsetHeader())
+	suite.Require().Equal("", service.FunctionByName("RemoveToe").Deprecation.Notice)
+	suite.Require().Equal("Fri, 01 Jan 2027 12:30:00 GMT", service.FunctionByName("RemoveToe").Deprecation.SunsetHeader())
+	suite.Require().Nil(service.FunctionByName("Walter").Deprecation)
+	suite.Require().Nil(service.FunctionByName("Rug").Deprecation, "Should ignore invalid sunset dates")
 }
 
 func (suite *ParserSuite) TestBindingOptions() {
@@ -115,6 +239,8 @@ func (suite *ParserSuite) TestBindingOptions() {
 	suite.Require().Equal("Name", binding.Name)
 	suite.Require().False(binding.Omit)
 	suite.Require().True(binding.NotOmit())
+	suite.Require().Equal("", binding.Source, "Redacted fields should use the usual binding rules")
+	suite.Require().True(binding.Redact)
 
 	binding = request.Fields.ByName("OmitMe").Binding
 	suite.Require().Equal("OmitMe", binding.Name)
@@ -125,6 +251,98 @@ func (suite *ParserSuite) TestBindingOptions() {
 	suite.Require().Equal("include", binding.Name)
 	suite.Require().False(binding.Omit)
 	suite.Require().True(binding.NotOmit())
+	suite.Require().True(binding.Tagged)
+	suite.Require().True(binding.OmitEmpty)
+	suite.Require().Equal("", binding.Source)
+	suite.Require().False(binding.FromHeader())
+	suite.Require().False(binding.FromCookie())
+
+	binding = request.Fields.ByName("TenantID").Binding
+	suite.Require().Equal("TenantID", binding.Name)
+	suite.Require().False(binding.Tagged)
+	suite.Require().False(binding.OmitEmpty)
+	suite.Require().True(binding.FromHeader())
+	suite.Require().Equal("X-Tenant-ID", binding.SourceName)
+	suite.Require().False(binding.Redact)
+
+	binding = request.Fields.ByName("Session").Binding
+	suite.Require().Equal("session", binding.Name)
+	suite.Require().True(binding.FromCookie())
+	suite.Require().Equal("session", binding.SourceName, "Should default to the binding name")
+	suite.Require().True(binding.Redact, "Should support multiple frodo tag options")
+}
+
+func (suite *ParserSuite) TestSnakeCase() {
+	ctx, err := parser.ParseFile("testdata/snakecase/service.go")
+	suite.Require().NoError(err)
+	suite.Require().True(ctx.Service.Gateway.SnakeCase)
+	suite.Require().Equal([]string{"SnakeService uses snake_case attribute names for untagged fields."}, []string(ctx.Service.Documentation))
+
+	request, _ := ctx.Types.LookupByName("GetUserRequest")
+	suite.Require().Equal("user_id", request.Fields.ByName("UserID").Binding.Name)
+	suite.Require().Equal("http_proxy", request.Fields.ByName("HTTPProxy").Binding.Name)
+	suite.Require().Equal("RenamedValue", request.Fields.ByName("Renamed").Binding.Name, "Should leave tagged names alone")
+	suite.Require().Equal("empty", request.Fields.ByName("Empty").Binding.Name)
+	suite.Require().True(request.Fields.ByName("Empty").Binding.OmitEmpty)
+	suite.Require().True(request.Fields.ByName("OmitMe").Binding.Omit)
+	suite.Require().Equal("tenant_id", request.Fields.ByName("TenantID").Binding.Name)
+	suite.Require().Equal("X-Tenant-ID", request.Fields.ByName("TenantID").Binding.SourceName)
+	suite.Require().Len(request.OmitEmptyFields(), 2)
+	suite.Require().Equal("Renamed", request.OmitEmptyFields()[0].Name)
+	suite.Require().Equal("Empty", request.OmitEmptyFields()[1].Name)
+
+	address, _ := ctx.Types.LookupByName("Address")
+	suite.Require().Equal("zip_code", address.Fields.ByName("ZipCode").Binding.Name)
+
+	pathParams := ctx.Service.Functions[0].Gateway.PathParameters()
+	suite.Require().Len(pathParams, 1)
+	suite.Require().Equal("/user/:user_id", ctx.Service.Functions[0].Gateway.Path)
+	suite.Require().Equal("user_id", pathParams[0].Name)
+	suite.Require().Equal("UserID", pathParams[0].Field.Name)
+	suite.Require().Equal("uuid", pathParams[0].Constraint, "Constraints should follow the renamed param")
+	suite.Require().Equal(map[string]string{"user_id": "uuid"}, ctx.Service.Functions[0].Gateway.PathConstraints)
+
+	queryParams := ctx.Service.Functions[0].Gateway.QueryParameters()
+	suite.Require().Nil(queryParams.ByName("user_id"), "Path params shouldn't also be query params")
+	suite.Require().NotNil(queryParams.ByName("http_proxy"))
+
+	pathParams = ctx.Service.Functions[1].Gateway.PathParameters()
+	suite.Require().Equal("/files/:bucket/*file_path", ctx.Service.Functions[1].Gateway.Path, "Should rename catch-all params")
+	suite.Require().Len(pathParams, 2)
+	suite.Require().Equal("bucket", pathParams[0].Name)
+	suite.Require().False(pathParams[0].CatchAll)
+	suite.Require().Equal("file_path", pathParams[1].Name)
+	suite.Require().Equal("FilePath", pathParams[1].Field.Name)
+	suite.Require().True(pathParams[1].CatchAll)
+	suite.Require().Empty(ctx.Service.Functions[1].Gateway.QueryParameters(), "Catch-all params shouldn't also be query params")
+
+	suite.Require().False(ctx.Service.Functions[1].Response.Implements.Redirector)
+	suite.Require().True(ctx.Service.Functions[2].Response.Implements.Redirector)
+}
+
+// Ensures that we capture field defaults from both the "DEFAULT" doc option and `default` tags.
+func (suite *ParserSuite) TestFieldDefaults() {
+	ctx, err := parser.ParseFile("testdata/defaults/service.go")
+	suite.Require().NoError(err)
+
+	request, _ := ctx.Types.LookupByName("SearchRequest")
+	fields := request.Fields
+	suite.Require().Equal("", fields.ByName("Text").Default)
+	suite.Require().Equal("25", fields.ByName("Limit").Default)
+	suite.Require().Equal("name", fields.ByName("SortBy").Default)
+	suite.Require().Equal("5s", fields.ByName("Timeout").Default, "Doc option should win over the tag")
+	suite.Require().Equal("true", fields.ByName("Fuzzy").Default)
+	suite.Require().Equal("acme", fields.ByName("Tenant").Default)
+	suite.Require().Len(fields.Defaults(), 5)
+
+	suite.Require().Equal(parser.DocumentationLines{"Limit is the max number of results to return."}, fields.ByName("Limit").Documentation)
+	suite.Require().Empty(fields.ByName("Timeout").Documentation, "Should strip the doc option")
+}
+
+func (suite *ParserSuite) TestSnakeCase_disabled() {
+	ctx, err := parser.ParseFile("testdata/bindingopts/service.go")
+	suite.Require().NoError(err)
+	suite.Require().False(ctx.Service.Gateway.SnakeCase)
 }
 
 func (suite *ParserSuite) TestFieldTypes() {
@@ -189,6 +407,89 @@ func (suite *ParserSuite) TestFieldTypes() {
 	suite.Require().Nil(fields.ByName("notExported"))
 }
 
+// Ensures that we recognize functions that follow the pagination conventions (embedding rpc.PagingRequest
+// and rpc.PagingResponse w/ a single slice of results).
+func (suite *ParserSuite) TestPaging() {
+	ctx, err := parser.ParseFile("testdata/paging/service.go")
+	suite.Require().NoError(err)
+
+	bowlers := ctx.Service.FunctionByName("ListBowlers")
+	suite.Require().True(bowlers.Request.Implements.PagingRequest)
+	suite.Require().True(bowlers.Response.Implements.PagingResponse)
+	suite.Require().True(bowlers.Paginated())
+	suite.Require().Equal("Bowlers", bowlers.Response.PageItems().Name)
+
+	// The embedded paging fields should be flattened like any other embedded struct.
+	suite.assertFieldType(bowlers.Request.Fields, "Limit", expectedFieldType{Name: "int", JSON: "number"})
+	suite.assertFieldType(bowlers.Request.Fields, "Cursor", expectedFieldType{Name: "string", JSON: "string"})
+	suite.assertFieldType(bowlers.Response.Fields, "NextCursor", expectedFieldType{Name: "string", JSON: "string"})
+
+	games := ctx.Service.FunctionByName("ListGames")
+	suite.Require().False(games.Request.Implements.PagingRequest)
+	suite.Require().True(games.Response.Implements.PagingResponse)
+	suite.Require().False(games.Paginated(), "Request must embed rpc.PagingRequest")
+
+	lanes := ctx.Service.FunctionByName("ListLanes")
+	suite.Require().True(lanes.Request.Implements.PagingRequest)
+	suite.Require().Nil(lanes.Response.PageItems())
+	suite.Require().False(lanes.Paginated(), "Response must have exactly one slice of results")
+}
+
+// Ensures that functions can use rpc.Empty for their request/response even though it's not defined in the service's package.
+func (suite *ParserSuite) TestEmpty() {
+	ctx, err := parser.ParseFile("testdata/empty/service.go")
+	suite.Require().NoError(err)
+	suite.Require().True(ctx.Service.HasEmpty())
+
+	ping := ctx.Service.FunctionByName("Ping")
+	suite.Require().Equal("rpc.Empty", ping.Request.Name)
+	suite.Require().True(ping.Request.Empty)
+	suite.Require().Equal(reflect.Struct, ping.Request.Kind)
+	suite.Require().Empty(ping.Request.Fields)
+	suite.Require().Same(ping.Request, ping.Response, "Should share a single rpc.Empty declaration")
+
+	restart := ctx.Service.FunctionByName("Restart")
+	suite.Require().False(restart.Request.Empty)
+	suite.Require().True(restart.Response.Empty)
+
+	status := ctx.Service.FunctionByName("Status")
+	suite.Require().True(status.Request.Empty)
+	suite.Require().False(status.Response.Empty)
+
+	_, ok := ctx.Types.LookupByName("github.com/monadicstack/frodo/rpc.Empty")
+	suite.Require().True(ok, "Should register rpc.Empty so that clients/docs describe it")
+
+	basic, err := parser.ParseFile("testdata/basic/service.go")
+	suite.Require().NoError(err)
+	suite.Require().False(basic.Service.HasEmpty())
+}
+
+// Ensures that "ONEOF" structs know their discriminator and alternatives.
+func (suite *ParserSuite) TestOneOf() {
+	ctx, err := parser.ParseFile("testdata/oneof/service.go")
+	suite.Require().NoError(err)
+
+	search := ctx.Service.FunctionByName("Search").Response
+	suite.Require().Equal("kind", search.Discriminator)
+	suite.Require().Len(search.Alternatives, 2)
+	suite.Require().Equal("UserMatch", search.Alternatives[0].Name)
+	suite.Require().Equal("GroupMatch", search.Alternatives[1].Name)
+	suite.Require().Equal([]string{"SearchResult is either a user or a group that matches the query."}, []string(search.Documentation))
+
+	// The alternatives' fields are merged, but "ID" should only show up once.
+	suite.Require().Len(search.Fields, 3)
+	suite.Require().NotNil(search.Fields.ByName("ID"))
+	suite.Require().NotNil(search.Fields.ByName("Name"))
+	suite.Require().NotNil(search.Fields.ByName("Members"))
+
+	shape := ctx.Service.FunctionByName("Draw").Response
+	suite.Require().Equal("type", shape.Discriminator, "Should default to 'type'")
+	suite.Require().Len(shape.Alternatives, 2)
+
+	suite.Require().Empty(ctx.Service.FunctionByName("Draw").Request.Discriminator)
+	suite.Require().Empty(search.Alternatives[0].Discriminator)
+}
+
 // Ensures that you can only have one service defined in the same file.
 func (suite *ParserSuite) TestMultiService() {
 	_, err := parser.ParseFile("testdata/multiservice/service.go")
@@ -301,6 +602,17 @@ func (suite *ParserSuite) assertFunction(service *parser.ServiceDeclaration, fun
 	suite.Require().Equal(expected.Gateway.Path, gateway.Path, "%s: Gateway: Incorrect path", name)
 	suite.Require().Equal(expected.Gateway.Method, gateway.Method, "%s: Gateway: Incorrect method", name)
 	suite.Require().Equal(expected.Gateway.Status, gateway.Status, "%s: Gateway: Incorrect status", name)
+	suite.Require().Equal(expected.Gateway.Async, gateway.Async, "%s: Gateway: Incorrect async", name)
+	suite.Require().Equal(expected.Gateway.SSE, gateway.SSE, "%s: Gateway: Incorrect SSE", name)
+	suite.Require().Equal(expected.Gateway.Auth, gateway.Auth, "%s: Gateway: Incorrect auth", name)
+	suite.Require().Equal(expected.Gateway.MaxRequestBytes, gateway.MaxRequestBytes, "%s: Gateway: Incorrect max request bytes", name)
+	suite.Require().Equal(expected.Gateway.PathConstraints, gateway.PathConstraints, "%s: Gateway: Incorrect path constraints", name)
+	suite.Require().Equal(expected.Gateway.MaxConcurrency, gateway.MaxConcurrency, "%s: Gateway: Incorrect max concurrency", name)
+	suite.Require().Equal(expected.Gateway.Proxy, gateway.Proxy, "%s: Gateway: Incorrect proxy", name)
+	suite.Require().Equal(expected.Gateway.Strict, gateway.Strict, "%s: Gateway: Incorrect strict", name)
+	suite.Require().Equal(expected.Gateway.SkipMiddleware, gateway.SkipMiddleware, "%s: Gateway: Incorrect skip middleware", name)
+	suite.Require().Equal(expected.Gateway.Dedupe, gateway.Dedupe, "%s: Gateway: Incorrect dedupe", name)
+	suite.Require().Equal(expected.Gateway.Health, gateway.Health, "%s: Gateway: Incorrect health", name)
 
 	// Only check the model types if specified. Blank means this test doesn't care about the request/response models.
 	if expected.RequestType != "" {
@@ -383,9 +695,20 @@ type expectedFunction struct {
 }
 
 type expectedGateway struct {
-	Path   string
-	Method string
-	Status int
+	Path            string
+	Method          string
+	Status          int
+	Async           bool
+	SSE             bool
+	Auth            string
+	MaxRequestBytes int64
+	MaxConcurrency  int
+	PathConstraints map[string]string
+	Strict          bool
+	SkipMiddleware  []string
+	Proxy           string
+	Dedupe          time.Duration
+	Health          bool
 }
 
 type expectedModel struct {
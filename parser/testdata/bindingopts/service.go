@@ -8,9 +8,11 @@ type BindingService interface {
 
 type Request struct {
 	ID        string `json:"record_id"`
-	Name      string `json:"Name"`
+	Name      string `json:"Name" frodo:"redact"`
 	OmitMe    string `json:"-"`
 	IncludeMe string `json:"include,omitempty"`
+	TenantID  string `frodo:"header=X-Tenant-ID"`
+	Session   string `json:"session" frodo:"cookie,redact"`
 }
 
 type Response struct{}
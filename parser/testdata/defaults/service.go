@@ -0,0 +1,48 @@
+package defaults
+
+import (
+	"context"
+	"time"
+)
+
+/*
+ * This test covers default values for request fields. Here are some of the explicit cases this covers:
+ *
+ * - Defaults can come from the "DEFAULT" doc option or a `default` tag
+ * - The doc option wins when a field has both
+ * - The doc option is not part of the field's documentation
+ * - Fields w/o either have no default
+ */
+
+type SearchService interface {
+	// GET /search
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+
+	// POST /search/saved
+	Save(context.Context, *SearchRequest) (*SearchResponse, error)
+}
+
+type SearchRequest struct {
+	// Text is what you're searching for.
+	Text string
+
+	// Limit is the max number of results to return.
+	//
+	// DEFAULT 25
+	Limit int
+
+	// SortBy is the attribute to sort the results by.
+	SortBy string `json:"sort" default:"name"`
+
+	// DEFAULT 5s
+	Timeout time.Duration `default:"10s"`
+
+	// DEFAULT true
+	Fuzzy bool
+
+	Tenant string `frodo:"header=X-Tenant-ID" default:"acme"`
+}
+
+type SearchResponse struct {
+	Results []string
+}
@@ -18,41 +18,100 @@ import "context"
  * - All supported HTTP methods are accounted for
  * - Option key can have leading spaces, but not other leading characters
  * - Option order doesn't matter (can do route then status or status then route)
+ * - Owners can be separated by commas/spaces and functions inherit the service's owners
+ * - Functions can opt in to running asynchronously w/ the ASYNC option
+ * - Functions inherit the service's AUTH requirement unless they have a valid one of their own
+ * - Functions can declare the events they emit; unknown event names are ignored
+ * - Functions can stream events w/ the SSE option; they default to GET unless they have their own route
+ * - Functions can override the max request size in bytes w/ optional KB/MB/GB units; invalid sizes are ignored
+ * - MAXBODY is an alias for MAXBYTES
+ * - Functions can override the max concurrent requests w/ the CONCURRENCY option; invalid limits are ignored
+ * - Path params can have constraints like ":id(int)"; unknown constraints are stripped from the path and ignored
+ * - Functions can opt in to rejecting unknown request attributes w/ the STRICT option
+ * - Functions can skip middleware groups by name w/ the SKIP-MIDDLEWARE option (repeated options accumulate)
+ * - Functions can forward requests to another backend w/ the PROXY option; targets that aren't http(s) URLs are ignored
+ * - Functions can be DEPRECATED w/ or w/o a notice; a valid SUNSET date implies deprecation and invalid dates are ignored
+ * - Functions can DEDUPE retries w/ an optional window; missing or invalid windows use the default
+ * - Services can have RESOURCES hints; unknown hints and invalid replica counts are ignored
+ * - Functions can be the service's HEALTH check, but only GET functions w/o path params count
+ * - Functions can declare the ERRORS they may return; statuses outside 4XX/5XX and duplicates are ignored
  */
 
 // LebowskiService occupies various administration buildings.
 // VERSION 999.12
 // PREFIX  big
+// OWNER team-lebowski, team-bowling
+// AUTH required
+// RESOURCES cpu=250m, memory=256Mi replicas=zero gpu=1
 type LebowskiService interface {
 	// Dude abides.
 	//
 	//
-	// GET /dude/:id/
+	// GET /dude/:id(Int)/
 	// HTTP 202
+	// AUTH none
+	// MAXBYTES 10MB
+	// CONCURRENCY 16
+	// DEPRECATED
+	// HEALTH
 	Dude(context.Context, *Request) (*Response, error)
 	Walter(context.Context, *Request) (*Response, error)
 	//
 	// HTTP 204
 	//
+	// AUTH Optional
 	//
+	// MAXBYTES 512
+	// SSE
+	// HEALTH
 	Donny(context.Context, *Request) (*Response, error)
 	// HTTP 201
+	// STRICT
+	// SKIP-MIDDLEWARE auth, metrics
 	// POST /dude/:id/child
+	// SKIP-MIDDLEWARE   logging
+	// OWNER team-art
+	// OWNER  knox   da-fino
+	// EMITS RugSoiled, Unknown
+	// ERRORS 404,409  418
+	// ERRORS 409, 499
+	// MAXBODY 2MB
+	// DEDUPE
 	Maude(context.Context, *Request) (*Response, error)
 	// PUT       /dude/jail
+	//   ASYNC
+	// MAXBYTES Unlimited
+	// CONCURRENCY unlimited
+	//    STRICT
+	// DEPRECATED   use Walter instead
+	// SUNSET 2027-01-01
+	// DEDUPE   30m
 	Jackie(context.Context, *Request) (*Response, error)
 	// Sometimes you eat the bar.
 	//
-	// PATCH dude/:id
+	// PATCH dude/:id(nope)
+	// EMITS   RugSoiled
+	//SSE
 	// Sometimes the bar eats you.
 	Stranger(context.Context, *Request) (*Response, error)
 	// RemoveToe attempts to extort $1 million.
 	// DELETE /nihilist/:id/toe
+	// PROXY   http://legacy:8080/nihilists/
+	// SUNSET Fri, 01 Jan 2027 12:30:00 GMT
 	RemoveToe(context.Context, *Request) (*Response, error)
 	//     HEAD /ties/room/together
 	// * HTTP 202
+	// AUTH sometimes
+	// MAXBYTES lots
+	// CONCURRENCY -3
+	// PROXY legacy:8080
+	// SUNSET someday
+	// DEDUPE -5m
+	// ERRORS 200, nope, 600
+	// HEALTH
 	Rug(context.Context, *Request) (*Response, error)
 }
 
 type Request struct{}
 type Response struct{}
+type RugSoiled struct{}
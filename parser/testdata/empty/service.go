@@ -0,0 +1,24 @@
+package empty
+
+import (
+	"context"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+type HealthService interface {
+	// Ping makes sure that the service is up and running.
+	Ping(context.Context, *rpc.Empty) (*rpc.Empty, error)
+	// Restart doesn't have any meaningful output.
+	Restart(context.Context, *RestartRequest) (*rpc.Empty, error)
+	// Status doesn't have any meaningful input.
+	Status(context.Context, *rpc.Empty) (*StatusResponse, error)
+}
+
+type RestartRequest struct {
+	Graceful bool
+}
+
+type StatusResponse struct {
+	Healthy bool
+}
@@ -0,0 +1,52 @@
+package oneof
+
+import (
+	"context"
+)
+
+type SearchService interface {
+	Draw(context.Context, *DrawRequest) (*Shape, error)
+	Search(context.Context, *SearchRequest) (*SearchResult, error)
+}
+
+type DrawRequest struct {
+	Size int
+}
+
+// Shape is either a circle or a square.
+//
+// ONEOF
+type Shape struct {
+	*Circle
+	*Square
+}
+
+type Circle struct {
+	Radius int
+}
+
+type Square struct {
+	Width int
+}
+
+type SearchRequest struct {
+	Query string
+}
+
+// SearchResult is either a user or a group that matches the query.
+//
+// ONEOF kind
+type SearchResult struct {
+	*UserMatch
+	*GroupMatch
+}
+
+type UserMatch struct {
+	ID   string
+	Name string
+}
+
+type GroupMatch struct {
+	ID      string
+	Members int
+}
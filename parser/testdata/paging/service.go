@@ -0,0 +1,50 @@
+package paging
+
+import (
+	"context"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+type BowlingService interface {
+	ListBowlers(context.Context, *ListBowlersRequest) (*ListBowlersResponse, error)
+	ListGames(context.Context, *ListGamesRequest) (*ListGamesResponse, error)
+	ListLanes(context.Context, *ListLanesRequest) (*ListLanesResponse, error)
+}
+
+type ListBowlersRequest struct {
+	rpc.PagingRequest
+	League string
+}
+
+type ListBowlersResponse struct {
+	rpc.PagingResponse
+	Bowlers []Bowler
+}
+
+// ListGamesRequest doesn't embed the paging request, so the function is not paginated.
+type ListGamesRequest struct {
+	BowlerID string
+	Limit    int
+}
+
+type ListGamesResponse struct {
+	rpc.PagingResponse
+	Games []int
+}
+
+type ListLanesRequest struct {
+	rpc.PagingRequest
+}
+
+// ListLanesResponse has more than one slice, so we can't tell which one has the page's results.
+type ListLanesResponse struct {
+	rpc.PagingResponse
+	Lanes  []int
+	Closed []int
+}
+
+type Bowler struct {
+	ID   string
+	Name string
+}
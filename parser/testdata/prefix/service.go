@@ -0,0 +1,19 @@
+package prefix
+
+import "context"
+
+// TenantService is deployed behind ingresses w/ different path layouts.
+//
+// PATH ${API_PREFIX}/:tenant/v2
+type TenantService interface {
+	GetTenant(context.Context, *GetTenantRequest) (*GetTenantResponse, error)
+}
+
+type GetTenantRequest struct {
+	Tenant string
+}
+
+type GetTenantResponse struct {
+	Tenant string
+	Name   string
+}
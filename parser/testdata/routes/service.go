@@ -0,0 +1,24 @@
+package routes
+
+import "context"
+
+// AccountService uses kebab-case routes for functions w/o a custom one.
+//
+// ROUTES kebab-case
+// PATH v2
+type AccountService interface {
+	GetAccount(context.Context, *GetAccountRequest) (*GetAccountResponse, error)
+	// GET /accounts/:ID
+	LookupAccount(context.Context, *GetAccountRequest) (*GetAccountResponse, error)
+	// SSE
+	WatchHTTPEvents(context.Context, *GetAccountRequest) (*GetAccountResponse, error)
+}
+
+type GetAccountRequest struct {
+	ID string
+}
+
+type GetAccountResponse struct {
+	ID   string
+	Name string
+}
@@ -0,0 +1,54 @@
+package snakecase
+
+import "context"
+
+// SnakeService uses snake_case attribute names for untagged fields.
+//
+// JSON snake_case
+type SnakeService interface {
+	// GET /user/:userID(uuid)
+	GetUser(context.Context, *GetUserRequest) (*GetUserResponse, error)
+	// GET /files/:bucket/*FilePath
+	OpenFile(context.Context, *OpenFileRequest) (*OpenFileResponse, error)
+	// GET /links/:LinkID
+	ResolveLink(context.Context, *ResolveLinkRequest) (*ResolveLinkResponse, error)
+}
+
+type GetUserRequest struct {
+	UserID    string
+	HTTPProxy string
+	Renamed   string `json:"RenamedValue,omitempty"`
+	Empty     string `json:",omitempty"`
+	OmitMe    string `json:"-"`
+	TenantID  string `frodo:"header=X-Tenant-ID"`
+}
+
+type GetUserResponse struct {
+	FirstName string
+	Address   Address
+}
+
+type Address struct {
+	ZipCode string
+}
+
+type OpenFileRequest struct {
+	Bucket   string
+	FilePath string
+}
+
+type OpenFileResponse struct {
+	Size int
+}
+
+type ResolveLinkRequest struct {
+	LinkID string
+}
+
+type ResolveLinkResponse struct {
+	URL string
+}
+
+func (res ResolveLinkResponse) Redirect() (string, int) {
+	return res.URL, 302
+}
@@ -0,0 +1,200 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/ids"
+	"github.com/monadicstack/frodo/rpc/jobs"
+	"github.com/monadicstack/frodo/rpc/operation"
+)
+
+// WithJobStore lets you decide where the gateway keeps track of the jobs for "ASYNC" service functions. By
+// default, the gateway keeps them in memory, so you only need this when you run multiple instances of your
+// service and need any instance to be able to answer "GET /jobs/:id" for any job.
+func WithJobStore(store jobs.Store) GatewayOption {
+	return func(gw *Gateway) {
+		gw.JobStore = store
+	}
+}
+
+// RunAsync is used by generated gateways to handle "ASYNC" service functions. It records a new pending job,
+// immediately replies w/ a 202 and the job info, then runs the handler in a background worker. When the handler
+// finishes, the job is updated w/ either the JSON-encoded result or the error.
+//
+// The handler's context has all of the same values as the request (metadata, authorization, etc), but it
+// is not canceled when the request finishes since the whole point is to keep working after we've replied.
+func (gw Gateway) RunAsync(w http.ResponseWriter, req *http.Request, handler func(ctx context.Context) (interface{}, error)) {
+	now := time.Now()
+	job := jobs.Job{
+		ID:        ids.New(),
+		Status:    jobs.StatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	version := ""
+	if endpoint := EndpointFromContext(req.Context()); endpoint != nil {
+		version = endpoint.Version
+		job.ServiceName = endpoint.ServiceName
+		job.Name = endpoint.Name
+	}
+	if err := gw.JobStore.Save(req.Context(), job); err != nil {
+		Fail(w, req, err)
+		return
+	}
+
+	gw.jobs.start()
+	go func() {
+		defer gw.jobs.finish()
+		runJob(detachedContext{parent: req.Context()}, gw, job, handler)
+	}()
+
+	w.Header().Set("Location", toEndpointPath(version, toEndpointPath(gw.requestPathPrefix(req), "/jobs/"+job.ID)))
+	reply(w, req, http.StatusAccepted, job, gw.JSON.framework())
+}
+
+// runJob is the background worker that actually invokes the "ASYNC" service function and records the outcome.
+func runJob(ctx context.Context, gw Gateway, job jobs.Job, handler func(ctx context.Context) (interface{}, error)) {
+	job.Status = jobs.StatusRunning
+	job.UpdatedAt = time.Now()
+	_ = gw.JobStore.Save(ctx, job)
+
+	result, err := gw.invokeJobHandler(ctx, handler)
+	if err == nil {
+		job.Result, err = gw.JSON.marshal(result)
+	}
+
+	job.UpdatedAt = time.Now()
+	if err != nil {
+		job.Status = jobs.StatusFailed
+		job.Error = toRPCError(ctx, err)
+	} else {
+		job.Status = jobs.StatusSucceeded
+	}
+	_ = gw.JobStore.Save(ctx, job)
+}
+
+// invokeJobHandler runs the handler, converting any panics to errors. The gateway's normal panic recovery
+// only protects the request goroutine, so without this, a bad async function would crash the whole process.
+// We still notify the gateway's panic handlers so the failure doesn't go unnoticed.
+func (gw Gateway) invokeJobHandler(ctx context.Context, handler func(ctx context.Context) (interface{}, error)) (result interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = gw.panicked(ctx, recovered, debug.Stack())
+		}
+	}()
+	return handler(ctx)
+}
+
+// JobEndpoint creates the "GET /jobs/:id" endpoint that callers use to check on the status of "ASYNC" service
+// functions. Generated gateways register it automatically when the service has any "ASYNC" functions.
+func (gw Gateway) JobEndpoint() Endpoint {
+	return Endpoint{
+		Method:      http.MethodGet,
+		Path:        "/jobs/:id",
+		ServiceName: gw.Name,
+		Name:        "Jobs",
+		Status:      http.StatusOK,
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			id := PathParams(req)["id"]
+			job, err := gw.JobStore.Load(req.Context(), id)
+			if err != nil {
+				Fail(w, req, err)
+				return
+			}
+			reply(w, req, http.StatusOK, job, gw.JSON.framework())
+		},
+	}
+}
+
+// newJobTracker creates the built-in component that keeps track of the background workers for "ASYNC" functions.
+func newJobTracker() *jobTracker {
+	tracker := &jobTracker{}
+	tracker.idle = sync.NewCond(&tracker.mutex)
+	return tracker
+}
+
+// jobTracker counts the jobs that are currently running so that, when the server shuts down, we give them
+// a chance to finish rather than abandoning them halfway through.
+type jobTracker struct {
+	mutex   sync.Mutex
+	idle    *sync.Cond
+	running int
+}
+
+func (tracker *jobTracker) start() {
+	if tracker == nil {
+		return
+	}
+	tracker.mutex.Lock()
+	tracker.running++
+	tracker.mutex.Unlock()
+}
+
+func (tracker *jobTracker) finish() {
+	if tracker == nil {
+		return
+	}
+	tracker.mutex.Lock()
+	tracker.running--
+	if tracker.running == 0 {
+		tracker.idle.Broadcast()
+	}
+	tracker.mutex.Unlock()
+}
+
+// Run waits for the server to shut down and then waits for all of the running jobs to finish. The server
+// stops waiting for us once its shutdown timeout expires.
+func (tracker *jobTracker) Run(ctx context.Context) error {
+	<-ctx.Done()
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	for tracker.running > 0 {
+		tracker.idle.Wait()
+	}
+	return nil
+}
+
+// detachedContext keeps all of the values from the parent context, but none of its cancellation. Async jobs use
+// this so that the job keeps running after the gateway has replied to the caller (which cancels the request context).
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (ctx detachedContext) Value(key interface{}) interface{} {
+	return ctx.parent.Value(key)
+}
+
+// toRPCError captures all of the info about the error that we need to send it to the caller. If the gateway
+// sanitizes errors, the caller only gets the generic version of 5xx failures (see WithErrorSanitization).
+func toRPCError(ctx context.Context, err error) *errors.RPCError {
+	id := operation.ID(ctx)
+	if gw, _ := ctx.Value(contextKeyGateway{}).(*Gateway); gw.sanitizes(err) {
+		id = sanitizedOperationID(id)
+		err = sanitizeError(ctx, err, id)
+	}
+	return &errors.RPCError{
+		HTTPStatus:  errors.Status(err),
+		Message:     err.Error(),
+		Code:        errors.Code(err),
+		Details:     errors.Details(err),
+		OperationID: id,
+	}
+}
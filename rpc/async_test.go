@@ -0,0 +1,142 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/jobs"
+	"github.com/monadicstack/frodo/rpc/metadata"
+)
+
+type asyncResponse struct {
+	Greeting string
+}
+
+// asyncEndpoint creates the "Hello" endpoint, which greets the name in a background job. It fails when the name
+// is "fail" and panics when it's "panic".
+func asyncEndpoint(gw *rpc.Gateway) rpc.Endpoint {
+	return testEndpoint("POST", "/hello/:name", "Hello", func(w http.ResponseWriter, req *http.Request) {
+		serviceRequest := struct{ Name string }{}
+		if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
+			rpc.Fail(w, req, err)
+			return
+		}
+		gw.RunAsync(w, req, func(ctx context.Context) (interface{}, error) {
+			time.Sleep(50 * time.Millisecond)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			switch serviceRequest.Name {
+			case "fail":
+				return nil, errors.WithCode(errors.PermissionDenied("not allowed"), "NOPE")
+			case "panic":
+				panic("kaboom")
+			}
+
+			// Make sure that the job still has the request's values even though the request is done.
+			greeting := ""
+			metadata.Value(ctx, "greeting", &greeting)
+			return asyncResponse{Greeting: greeting + " " + serviceRequest.Name}, nil
+		})
+	})
+}
+
+// Ensures that the gateway replies right away w/ the pending job (whose Location header points to the status
+// endpoint) and runs the handler in the background.
+func (suite *GatewaySuite) TestRunAsync() {
+	r := suite.Require()
+	gw := suite.newGateway(rpc.WithResponseEnvelope())
+	gw.PathPrefix = "/v2"
+	gw.Register(asyncEndpoint(&gw))
+	gw.Register(gw.JobEndpoint())
+	server := httptest.NewServer(gw)
+	defer server.Close()
+
+	client := rpc.NewClient("TestService", server.URL, rpc.WithJobPollInterval(10*time.Millisecond))
+	client.PathPrefix = "/v2"
+	ctx := metadata.WithValue(context.Background(), "greeting", "Hello")
+
+	job := jobs.Job{}
+	err := client.Invoke(ctx, "POST", "/hello/:name", struct{ Name string }{Name: "Dude"}, &job)
+	r.NoError(err)
+	r.NotEmpty(job.ID)
+	r.Equal("TestService", job.ServiceName)
+	r.Equal("Hello", job.Name)
+	r.Equal(jobs.StatusPending, job.Status)
+	r.False(job.Done())
+
+	response := asyncResponse{}
+	r.NoError(client.Await(ctx, job.ID, &response))
+	r.Equal("Hello Dude", response.Greeting)
+
+	// Once the job is done, it should stay done.
+	response = asyncResponse{}
+	r.NoError(client.Await(context.Background(), job.ID, &response))
+	r.Equal("Hello Dude", response.Greeting)
+
+	res, err := suite.HTTPClient.Post(server.URL+"/v2/hello/Walter", "application/json", nil)
+	r.NoError(err)
+	defer res.Body.Close()
+
+	body := struct{ Data jobs.Job }{}
+	r.NoError(json.NewDecoder(res.Body).Decode(&body))
+	r.Equal(202, res.StatusCode)
+	r.Equal("/v2/jobs/"+body.Data.ID, res.Header.Get("Location"))
+
+	res, err = suite.HTTPClient.Get(server.URL + res.Header.Get("Location"))
+	r.NoError(err)
+	defer res.Body.Close()
+	r.Equal(200, res.StatusCode)
+}
+
+// Ensures that Await() gives you the same error that the function would have returned synchronously, and that it
+// stops waiting when the context is done, but the job keeps running.
+func (suite *GatewaySuite) TestRunAsync_await() {
+	r := suite.Require()
+	panics := make(chan interface{}, 10)
+	gw := suite.newGateway(rpc.WithPanicHandler(func(ctx context.Context, recovered interface{}, stack []byte) {
+		panics <- recovered
+	}))
+	gw.Register(asyncEndpoint(&gw))
+	gw.Register(gw.JobEndpoint())
+	server := httptest.NewServer(gw)
+	defer server.Close()
+
+	client := rpc.NewClient("TestService", server.URL, rpc.WithJobPollInterval(10*time.Millisecond))
+	ctx := context.Background()
+
+	job := jobs.Job{}
+	r.NoError(client.Invoke(ctx, "POST", "/hello/:name", struct{ Name string }{Name: "fail"}, &job))
+	err := client.Await(ctx, job.ID, &asyncResponse{})
+	r.True(errors.IsPermissionDenied(err), "Should restore the job's error status")
+	r.Equal("NOPE", errors.Code(err))
+	r.Contains(err.Error(), "not allowed")
+
+	r.NoError(client.Invoke(ctx, "POST", "/hello/:name", struct{ Name string }{Name: "panic"}, &job))
+	err = client.Await(ctx, job.ID, &asyncResponse{})
+	r.True(errors.IsUnexpected(err), "Panics in the job should fail the job, not crash the server")
+	r.Contains(err.Error(), "kaboom")
+	r.Equal("kaboom", <-panics, "Should notify the panic handlers")
+
+	err = client.Await(ctx, "not-a-real-job", &asyncResponse{})
+	r.True(errors.IsNotFound(err), "Should fail w/ a 404 when the job doesn't exist")
+
+	job = jobs.Job{}
+	r.NoError(client.Invoke(ctx, "POST", "/hello/:name", struct{ Name string }{Name: "Donny"}, &job))
+
+	timeout, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	r.Error(client.Await(timeout, job.ID, &asyncResponse{}))
+
+	response := asyncResponse{}
+	r.NoError(client.Await(ctx, job.ID, &response))
+	r.Equal(" Donny", response.Greeting)
+}
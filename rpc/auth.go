@@ -0,0 +1,119 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/monadicstack/frodo/rpc/authorization"
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+// AuthRequirement describes whether callers of an endpoint need to supply the "Authorization" header. You
+// set this per function (or for the whole service) using the "AUTH" doc option.
+type AuthRequirement string
+
+const (
+	// AuthRequired means that the gateway rejects requests w/o an "Authorization" header w/ a 401 before
+	// any of your middleware or your handler ever runs.
+	AuthRequired = AuthRequirement("required")
+	// AuthOptional means that anonymous callers are allowed, but when they do supply credentials, your
+	// AuthMiddleware still validates them.
+	AuthOptional = AuthRequirement("optional")
+	// AuthNone means that the endpoint doesn't care about credentials at all, so we skip your AuthMiddleware
+	// entirely (e.g. login, health checks, webhooks w/ their own signatures, etc).
+	AuthNone = AuthRequirement("none")
+)
+
+// AuthMiddleware wraps your authentication middleware so that it honors each endpoint's "AUTH" doc option. Rather
+// than sprinkling if-statements that check the endpoint name throughout your middleware, you can do this:
+//
+//     gateway := usersrpc.NewUserServiceGateway(service, rpc.WithMiddleware(
+//         rpc.AuthMiddleware(validateJWT),
+//         ...
+//     ))
+//
+// The wrapped middleware is skipped for "AUTH none" endpoints as well as "AUTH optional" endpoints when the
+// caller didn't supply any credentials. For "AUTH required" endpoints (and ones w/o an AUTH option), it always
+// runs. The only exception is CORS preflight (OPTIONS) requests since browsers never send credentials w/ them.
+func AuthMiddleware(handlers ...MiddlewareFunc) MiddlewareFunc {
+	return func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		if skipAuthMiddleware(req) {
+			next(w, req)
+			return
+		}
+		middlewarePipeline(handlers).Then(next)(w, req)
+	}
+}
+
+// skipAuthMiddleware returns true when the endpoint's auth requirement says that we don't need to
+// validate the request's credentials.
+func skipAuthMiddleware(req *http.Request) bool {
+	// CORS preflight requests never include credentials, so there's nothing to validate.
+	if req.Method == http.MethodOptions {
+		return true
+	}
+
+	endpoint := EndpointFromContext(req.Context())
+	if endpoint == nil {
+		return false
+	}
+
+	switch endpoint.Auth {
+	case AuthNone:
+		return true
+	case AuthOptional:
+		return authorization.FromContext(req.Context()).Empty()
+	default:
+		return false
+	}
+}
+
+// enforceAuthorization rejects requests to "AUTH required" endpoints that don't have an "Authorization" header. It
+// runs after restoreAuthorization, so the header is already on the context.
+func enforceAuthorization(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	// CORS preflight requests never include credentials, so don't reject them. Your CORS middleware
+	// will handle them and the real request that follows will still need to be authorized.
+	if req.Method == http.MethodOptions {
+		next(w, req)
+		return
+	}
+
+	endpoint := EndpointFromContext(req.Context())
+	if endpoint != nil && endpoint.Auth == AuthRequired && authorization.FromContext(req.Context()).Empty() {
+		Fail(w, req, errors.BadCredentials("authorization required"))
+		return
+	}
+	next(w, req)
+}
+
+// AuthorizationProvider supplies the "Authorization" header value (e.g. "Bearer eyJhbGciOi...") that the client
+// should use for a call. Return an empty string to make the call w/o credentials.
+type AuthorizationProvider func(ctx context.Context) string
+
+// WithAuthorizationProvider makes the client ask the provider for credentials on every call that doesn't
+// already have them on its context. This is how you give a client its own identity (e.g. a service account)
+// w/ a token that changes over time; the provider can cache the token and fetch a fresh one when it's about
+// to expire:
+//
+//     client := usersrpc.NewUserServiceClient("http://localhost:9000",
+//         rpc.WithAuthorizationProvider(func(ctx context.Context) string {
+//             return authorization.NewBearer(tokens.Current(ctx)).String()
+//         }),
+//     )
+//
+// Credentials on the context (e.g. the ones your gateway received from your caller or ones you added
+// using authorization.WithToken) always win over the provider's.
+func WithAuthorizationProvider(provider AuthorizationProvider) ClientOption {
+	return func(rpcClient *Client) {
+		rpcClient.AuthorizationProvider = provider
+	}
+}
+
+// resolve returns the credentials on the context, falling back to the provider's when there aren't any.
+func (provider AuthorizationProvider) resolve(ctx context.Context) authorization.Header {
+	auth := authorization.FromContext(ctx)
+	if auth.Empty() && provider != nil {
+		auth = authorization.New(provider(ctx))
+	}
+	return auth
+}
@@ -0,0 +1,99 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/authorization"
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+// newAuthServer creates a server w/ auth middleware that only accepts "Token good" and an endpoint for each
+// of the AUTH requirements.
+func (suite *GatewaySuite) newAuthServer() *httptest.Server {
+	validateToken := func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		if authorization.FromContext(req.Context()).String() != "Token good" {
+			rpc.Fail(w, req, errors.PermissionDenied("bad token"))
+			return
+		}
+		next(w, req)
+	}
+
+	gw := suite.newGateway(rpc.WithMiddleware(rpc.AuthMiddleware(validateToken)))
+	register := func(path string, auth rpc.AuthRequirement) {
+		endpoint := testEndpoint("GET", path, path, func(w http.ResponseWriter, req *http.Request) {
+			rpc.Reply(w, req, 200, "ok")
+		})
+		endpoint.Auth = auth
+		gw.Register(endpoint)
+	}
+	register("/required", rpc.AuthRequired)
+	register("/optional", rpc.AuthOptional)
+	register("/none", rpc.AuthNone)
+	register("/unspecified", "")
+	return httptest.NewServer(gw)
+}
+
+// Ensures that "AUTH required" endpoints reject anonymous callers before the auth middleware and still validate
+// the credentials when they're supplied, "AUTH optional" endpoints allow anonymous callers (but still validate
+// supplied credentials), and "AUTH none" endpoints skip the auth middleware entirely. Endpoints w/o an AUTH
+// option always run the auth middleware like they did before AUTH existed.
+func (suite *GatewaySuite) TestAuthRequirement() {
+	server := suite.newAuthServer()
+	defer server.Close()
+
+	tests := []struct {
+		path string
+		// statuses are what we expect w/o credentials, w/ bad ones, and w/ good ones.
+		statuses [3]int
+	}{
+		{path: "/required", statuses: [3]int{401, 403, 200}},
+		{path: "/optional", statuses: [3]int{200, 403, 200}},
+		{path: "/none", statuses: [3]int{200, 200, 200}},
+		{path: "/unspecified", statuses: [3]int{403, 403, 200}},
+	}
+	for _, test := range tests {
+		suite.assertExchanges(server, []exchange{
+			{name: test.path + " anonymous", method: "GET", path: test.path, status: test.statuses[0]},
+			{name: test.path + " bad", method: "GET", path: test.path, header: http.Header{"Authorization": {"Token bad"}}, status: test.statuses[1]},
+			{name: test.path + " good", method: "GET", path: test.path, header: http.Header{"Authorization": {"Token good"}}, status: test.statuses[2]},
+		})
+	}
+
+	// We should leave CORS preflight requests alone; there's no CORS middleware, so it's a 405.
+	suite.assertExchanges(server, []exchange{
+		{method: "OPTIONS", path: "/required", status: 405},
+	})
+}
+
+// Ensures that clients use the provider's credentials unless the context already has some.
+func (suite *GatewaySuite) TestWithAuthorizationProvider() {
+	r := suite.Require()
+	server := suite.newAuthServer()
+	defer server.Close()
+
+	calls := 0
+	client := rpc.NewClient("TestService", server.URL, rpc.WithAuthorizationProvider(func(ctx context.Context) string {
+		calls++
+		return "Token good"
+	}))
+
+	var response string
+	r.NoError(client.Invoke(context.Background(), "GET", "/required", nil, &response))
+	r.Equal(1, calls)
+
+	ctx := authorization.WithHeader(context.Background(), authorization.New("Token bad"))
+	err := client.Invoke(ctx, "GET", "/required", nil, &response)
+	r.True(errors.IsPermissionDenied(err), "Should use the context's credentials rather than the provider's")
+	r.Equal(1, calls, "Should not bother calling the provider when the context has credentials")
+
+	client = rpc.NewClient("TestService", server.URL, rpc.WithAuthorizationProvider(func(ctx context.Context) string {
+		return ""
+	}))
+	err = client.Invoke(context.Background(), "GET", "/required", nil, &response)
+	r.True(errors.IsBadCredentials(err), "Should not send credentials when the provider doesn't have any")
+}
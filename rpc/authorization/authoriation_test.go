@@ -96,6 +96,62 @@ func (suite *HeaderSuite) TestWithHeader() {
 	suite.Equal(headerB, authorization.FromContext(ctx))
 }
 
+func (suite *HeaderSuite) TestHeader_Scheme() {
+	header := authorization.New("")
+	suite.Require().Equal("", header.Scheme())
+	suite.Require().Equal("", header.Credentials())
+
+	header = authorization.New("xxx")
+	suite.Require().Equal("", header.Scheme())
+	suite.Require().Equal("xxx", header.Credentials())
+
+	header = authorization.New("Bearer   xxx ")
+	suite.Require().Equal("Bearer", header.Scheme())
+	suite.Require().Equal("xxx", header.Credentials())
+	suite.Require().True(header.Is("bearer"), "Scheme comparisons should be case-insensitive")
+	suite.Require().False(header.Is("Basic"))
+
+	header = authorization.New("Token a b")
+	suite.Require().Equal("Token", header.Scheme())
+	suite.Require().Equal("a b", header.Credentials())
+}
+
+func (suite *HeaderSuite) TestHeader_Bearer() {
+	suite.Require().Equal("xxx", authorization.New("Bearer xxx").Bearer())
+	suite.Require().Equal("xxx", authorization.New("bearer xxx").Bearer())
+	suite.Require().Equal("", authorization.New("Token xxx").Bearer())
+	suite.Require().Equal("", authorization.New("xxx").Bearer())
+	suite.Require().Equal("", authorization.None.Bearer())
+
+	suite.Require().Equal("Bearer xxx", authorization.NewBearer(" xxx ").String())
+	suite.Require().Equal(authorization.None, authorization.NewBearer(""))
+}
+
+func (suite *HeaderSuite) TestHeader_Basic() {
+	header := authorization.NewBasic("dude", "pass:word")
+	suite.Require().Equal("Basic ZHVkZTpwYXNzOndvcmQ=", header.String())
+
+	username, password, ok := header.Basic()
+	suite.Require().True(ok)
+	suite.Require().Equal("dude", username)
+	suite.Require().Equal("pass:word", password)
+
+	_, _, ok = authorization.New("Bearer ZHVkZTpwYXNzOndvcmQ=").Basic()
+	suite.Require().False(ok, "Should only decode Basic headers")
+
+	_, _, ok = authorization.New("Basic !!!").Basic()
+	suite.Require().False(ok, "Should fail when the credentials aren't base64")
+
+	_, _, ok = authorization.New("Basic ZHVkZQ==").Basic()
+	suite.Require().False(ok, "Should fail when the credentials don't have a colon")
+}
+
+func (suite *HeaderSuite) TestWithToken() {
+	ctx := authorization.WithToken(context.Background(), "xxx")
+	suite.Require().Equal("Bearer xxx", authorization.FromContext(ctx).String())
+	suite.Require().Equal("xxx", authorization.FromContext(ctx).Bearer())
+}
+
 func TestHeaderSuite(t *testing.T) {
 	suite.Run(t, new(HeaderSuite))
 }
@@ -2,6 +2,7 @@ package authorization
 
 import (
 	"context"
+	"encoding/base64"
 	"strings"
 )
 
@@ -29,11 +30,39 @@ func FromContext(ctx context.Context) Header {
 	return *header
 }
 
+// WithToken returns a new child context whose calls use the bearer token as their credentials. It's just
+// shorthand for WithHeader(ctx, NewBearer(token)).
+func WithToken(ctx context.Context, token string) context.Context {
+	return WithHeader(ctx, NewBearer(token))
+}
+
 // New creates a Header based on the HTTP Authorization header value that can be used for RPC authorization.
 func New(value string) Header {
 	return Header{value: strings.TrimSpace(value)}
 }
 
+// NewBearer creates a Header for the token using the "Bearer" scheme (e.g. "Bearer eyJhbGciOi...").
+func NewBearer(token string) Header {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return None
+	}
+	return New(SchemeBearer + " " + token)
+}
+
+// NewBasic creates a Header for the username/password using the "Basic" scheme.
+func NewBasic(username string, password string) Header {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return New(SchemeBasic + " " + credentials)
+}
+
+const (
+	// SchemeBearer is the authorization scheme for OAuth2/JWT style tokens (RFC 6750).
+	SchemeBearer = "Bearer"
+	// SchemeBasic is the authorization scheme for base64-encoded username/password pairs (RFC 7617).
+	SchemeBasic = "Basic"
+)
+
 // Header wraps the raw string from the HTTP Authorization header.
 type Header struct {
 	value string
@@ -52,3 +81,57 @@ func (h Header) NotEmpty() bool {
 func (h Header) String() string {
 	return h.value
 }
+
+// Scheme returns the authentication scheme at the start of the header (e.g. "Bearer" or "Basic"). It's
+// blank when the header is empty or just a single value w/o a scheme.
+func (h Header) Scheme() string {
+	scheme, _ := h.split()
+	return scheme
+}
+
+// Credentials returns everything in the header after the scheme (e.g. the token in "Bearer xxx"). When
+// the header doesn't have a scheme, the credentials are the entire value.
+func (h Header) Credentials() string {
+	_, credentials := h.split()
+	return credentials
+}
+
+// Is returns true when the header uses the given authentication scheme. Just like HTTP, the comparison
+// is case-insensitive, so "bearer xxx" is still a "Bearer" header.
+func (h Header) Is(scheme string) bool {
+	return strings.EqualFold(h.Scheme(), scheme)
+}
+
+// Bearer returns the token from a "Bearer" header. It's blank if the header uses any other scheme.
+func (h Header) Bearer() string {
+	if !h.Is(SchemeBearer) {
+		return ""
+	}
+	return h.Credentials()
+}
+
+// Basic returns the username and password from a "Basic" header. The boolean is false if the header uses any
+// other scheme or the credentials aren't properly encoded.
+func (h Header) Basic() (username string, password string, ok bool) {
+	if !h.Is(SchemeBasic) {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(h.Credentials())
+	if err != nil {
+		return "", "", false
+	}
+	colon := strings.IndexByte(string(decoded), ':')
+	if colon < 0 {
+		return "", "", false
+	}
+	return string(decoded[:colon]), string(decoded[colon+1:]), true
+}
+
+// split breaks the header into its scheme and credentials (e.g. "Bearer xxx" -> "Bearer", "xxx").
+func (h Header) split() (string, string) {
+	space := strings.IndexAny(h.value, " \t")
+	if space < 0 {
+		return "", h.value
+	}
+	return h.value[:space], strings.TrimSpace(h.value[space+1:])
+}
@@ -0,0 +1,343 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/respond"
+	"github.com/urfave/negroni"
+)
+
+// BatchPath is the path of the endpoint that lets callers invoke multiple service functions in a single
+// round trip (see WithBatch). Like any other endpoint, it sits under the gateway's path prefix (if any).
+const BatchPath = "/rpc/batch"
+
+// WithBatch enables the "POST /rpc/batch" endpoint, which lets callers make multiple calls in a single round
+// trip. This is great for chatty mobile clients that would otherwise pay the network latency for each call. The
+// body is an array of calls, and the response is an array w/ the outcome of each call in the same order:
+//
+//     POST /rpc/batch
+//     [
+//         {"service": "UserService", "method": "GetUser", "body": {"ID": "123"}},
+//         {"service": "GroupService", "method": "ListGroups", "body": {"UserID": "123"}}
+//     ]
+//
+//     200 OK
+//     [
+//         {"status": 200, "headers": {"Content-Type": ["application/json"]}, "body": {"ID": "123", ...}},
+//         {"status": 403, "headers": {"Content-Type": ["application/json"]}, "body": {"message": "..."}}
+//     ]
+//
+// The gateway runs up to 'concurrency' of the calls at the same time. Each call runs through the same middleware
+// that it would if you called it directly (w/ the batch request's headers), so one failed call doesn't fail the
+// others. You get that call's error status/body in its slot instead. Functions that respond w/ raw content or an
+// event stream ("SSE") can't be batched. The default of 0 disables the endpoint entirely.
+func WithBatch(concurrency int) GatewayOption {
+	return func(gw *Gateway) {
+		gw.BatchConcurrency = concurrency
+	}
+}
+
+// BatchEndpoint creates the "POST /rpc/batch" endpoint that callers use to invoke multiple service functions in a
+// single round trip. Generated gateways register it automatically when you enable it using WithBatch().
+func (gw Gateway) BatchEndpoint() Endpoint {
+	return Endpoint{
+		Method:      http.MethodPost,
+		Path:        BatchPath,
+		ServiceName: gw.Name,
+		Name:        "Batch",
+		Status:      http.StatusOK,
+		batch:       true,
+		Handler: batchHandler(gw.BatchConcurrency, func(service string, name string) (*Gateway, Endpoint, bool) {
+			endpoint, ok := gw.endpoints.lookupFunction(service, name)
+			return &gw, endpoint, ok
+		}),
+	}
+}
+
+// batchLookup finds the endpoint (and the gateway that owns it) for the service function that a batch wants to call.
+type batchLookup func(service string, name string) (*Gateway, Endpoint, bool)
+
+// batchCall is a single entry in the body of a "POST /rpc/batch" request.
+type batchCall struct {
+	Service string          `json:"service"`
+	Method  string          `json:"method"`
+	Body    json.RawMessage `json:"body,omitempty"`
+}
+
+// batchResult is the outcome of a single call in a "POST /rpc/batch" response.
+type batchResult struct {
+	Status  int             `json:"status"`
+	Headers http.Header     `json:"headers,omitempty"`
+	Body    json.RawMessage `json:"body,omitempty"`
+}
+
+// batchHandler runs each of the calls in the batch through its endpoint's middleware/handler, 'concurrency' at
+// a time, and replies w/ all of the results once they're done.
+func batchHandler(concurrency int, lookup batchLookup) http.HandlerFunc {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		var calls []batchCall
+		if err := json.NewDecoder(req.Body).Decode(&calls); err != nil {
+			if errors.Status(err) == http.StatusRequestEntityTooLarge {
+				Fail(w, req, err)
+				return
+			}
+			Fail(w, req, errors.BadRequest("invalid batch: %v", err))
+			return
+		}
+
+		// The batch request already counts against the gateway's in-flight limit, so don't shed its calls.
+		ctx := context.WithValue(req.Context(), contextKeyBatch{}, true)
+		req = req.WithContext(ctx)
+
+		results := make([]batchResult, len(calls))
+		slots := make(chan struct{}, concurrency)
+		wg := sync.WaitGroup{}
+		for i, call := range calls {
+			gw, endpoint, ok := lookup(call.Service, call.Method)
+			if !ok {
+				results[i] = batchFailure(req, errors.NotFound("batch: unknown function %s.%s", call.Service, call.Method))
+				continue
+			}
+
+			wg.Add(1)
+			slots <- struct{}{}
+			go func(i int, call batchCall) {
+				defer func() { <-slots }()
+				defer wg.Done()
+				results[i] = runBatchCall(req, gw, endpoint, call.Body)
+			}(i, call)
+		}
+		wg.Wait()
+
+		// The results are frodo's own type, so they're never wrapped in an envelope or snake_cased.
+		respond.To(w, req).Reply(http.StatusOK, results)
+	}
+}
+
+// runBatchCall invokes the endpoint as though the caller had sent the body directly to it using the same headers
+// as the batch request. There's no path or query string, so the binder reads every value from the body, even
+// for GET/DELETE functions.
+func runBatchCall(req *http.Request, gw *Gateway, endpoint Endpoint, body json.RawMessage) batchResult {
+	if len(body) == 0 {
+		body = json.RawMessage("{}")
+	}
+	if batchEndpoint := EndpointFromContext(req.Context()); batchEndpoint != nil {
+		endpoint.Version = batchEndpoint.Version
+	}
+
+	ctx := context.WithValue(req.Context(), contextKeyGateway{}, gw)
+	ctx = context.WithValue(ctx, contextKeyEndpoint{}, endpoint)
+	callRequest := req.Clone(ctx)
+	callRequest.Method = strings.ToUpper(endpoint.Method)
+	callRequest.URL.Path = toEndpointPath(endpoint.Version, toEndpointPath(gw.requestPathPrefix(req), endpoint.Path))
+	callRequest.URL.RawPath = ""
+	callRequest.URL.RawQuery = ""
+	callRequest.Body = ioutil.NopCloser(bytes.NewReader(body))
+	callRequest.ContentLength = int64(len(body))
+	callRequest.Header.Set("Content-Type", "application/json")
+	callRequest.Header.Del("Accept")
+	callRequest.Header.Del("Content-Length")
+	callRequest.Header.Del("Content-Encoding")
+	callRequest.Header.Del("Accept-Encoding")
+	callRequest.Header.Del("If-None-Match")
+	callRequest.Header.Del("If-Modified-Since")
+
+	w := newBatchResponseWriter()
+	gw.pipeline(endpoint).Then(endpoint.Handler)(negroni.NewResponseWriter(w), callRequest)
+	if !w.isJSON() {
+		return batchFailure(req, errors.BadRequest("batch: %s does not respond w/ JSON, so it can't be batched", endpoint))
+	}
+	return w.result()
+}
+
+// batchFailure formats the error just like the gateway would if you had called the function directly.
+func batchFailure(req *http.Request, err error) batchResult {
+	w := newBatchResponseWriter()
+	Fail(w, req, err)
+	return w.result()
+}
+
+// contextKeyBatch marks the context of a request that's running as part of a batch.
+type contextKeyBatch struct{}
+
+// isBatchCall returns true when the request is one of the calls in a "POST /rpc/batch" request.
+func isBatchCall(ctx context.Context) bool {
+	batch, _ := ctx.Value(contextKeyBatch{}).(bool)
+	return batch
+}
+
+// newBatchResponseWriter creates a response writer that buffers a call's response so we can include it in
+// the batch's results.
+func newBatchResponseWriter() *batchResponseWriter {
+	return &batchResponseWriter{header: http.Header{}}
+}
+
+// batchResponseWriter buffers the status, headers, and body of a single call in a batch.
+type batchResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *batchResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *batchResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(data)
+}
+
+// isJSON returns true when the call responded w/ a JSON body (or no body at all).
+func (w *batchResponseWriter) isJSON() bool {
+	if w.body.Len() == 0 {
+		return true
+	}
+	contentType, _, _ := mime.ParseMediaType(w.header.Get("Content-Type"))
+	isJSONType := contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+	return isJSONType && json.Valid(w.body.Bytes())
+}
+
+func (w *batchResponseWriter) result() batchResult {
+	result := batchResult{Status: w.status, Headers: w.header}
+	if result.Status == 0 {
+		result.Status = http.StatusOK
+	}
+	if w.body.Len() > 0 {
+		result.Body = w.body.Bytes()
+	}
+	return result
+}
+
+// Batch collects multiple service calls so that you can send them to the gateway in a single round trip (see
+// WithBatch). Generated clients have a strongly-typed version of this that you should use instead:
+//
+//     batch := userClient.Batch()
+//     user := batch.GetUser(&users.GetUserRequest{ID: "123"}, &users.GetUserResponse{})
+//     groups := batch.ListGroups(&users.ListGroupsRequest{UserID: "123"}, &users.ListGroupsResponse{})
+//     if err := batch.Send(ctx); err != nil {
+//         // The entire batch failed (e.g. the gateway is down)
+//     }
+//     if user.Err != nil {
+//         // Just this call failed
+//     }
+type Batch struct {
+	client Client
+	calls  []*BatchCall
+}
+
+// BatchCall is a single service call in a Batch. Once you send the batch, the call's Response is
+// populated or Err describes why this particular call failed.
+type BatchCall struct {
+	// Service is the name of the service that this call invokes (e.g. "UserService").
+	Service string
+	// Name is the name of the service function that this call invokes (e.g. "GetUser").
+	Name string
+	// Request is the service request struct for the call.
+	Request interface{}
+	// Response is the service response struct that we populate w/ the result of the call.
+	Response interface{}
+	// Err is the error that the service function returned (or why we couldn't make the call).
+	Err error
+}
+
+// Batch starts a new set of calls that you can send to the gateway in a single round trip.
+func (c Client) Batch() *Batch {
+	return &Batch{client: c}
+}
+
+// Add includes another call in the batch. The service response is populated once you Send() the batch.
+func (b *Batch) Add(service string, name string, serviceRequest interface{}, serviceResponse interface{}) *BatchCall {
+	call := &BatchCall{Service: service, Name: name, Request: serviceRequest, Response: serviceResponse}
+	b.calls = append(b.calls, call)
+	return call
+}
+
+// Calls returns all of the calls in the batch in the order that you added them.
+func (b *Batch) Calls() []*BatchCall {
+	return b.calls
+}
+
+// Send makes all of the calls in a single round trip to the gateway's "POST /rpc/batch" endpoint. The error is
+// only non-nil when the batch as a whole failed. Check each BatchCall's Err to see if that call failed.
+func (b *Batch) Send(ctx context.Context) error {
+	if len(b.calls) == 0 {
+		return nil
+	}
+	c := b.client
+
+	calls := make([]batchCall, len(b.calls))
+	for i, call := range b.calls {
+		body, err := c.JSON.marshal(call.Request)
+		if err != nil {
+			return fmt.Errorf("rpc: unable to create request body: %w", err)
+		}
+		calls[i] = batchCall{Service: call.Service, Method: call.Name, Body: body}
+	}
+	body, err := json.Marshal(calls)
+	if err != nil {
+		return fmt.Errorf("rpc: unable to create request body: %w", err)
+	}
+
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	address := c.BaseURL + toEndpointPath(c.Version, toEndpointPath(c.pathPrefix(), BatchPath))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, address, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("rpc: unable to create request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := c.send(request)
+	if err != nil {
+		return fmt.Errorf("rpc: round trip error: %w", err)
+	}
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("rpc: unable to decode response: %w", c.decodeStatusError(response))
+	}
+	defer response.Body.Close()
+
+	var results []batchResult
+	if err = json.NewDecoder(response.Body).Decode(&results); err != nil {
+		return fmt.Errorf("rpc: unable to decode response: %w", err)
+	}
+	if len(results) != len(b.calls) {
+		return fmt.Errorf("rpc: unable to decode response: expected %d batch results, got %d", len(b.calls), len(results))
+	}
+	for i, result := range results {
+		b.calls[i].Err = c.decodeBatchResult(result, b.calls[i].Response)
+	}
+	return nil
+}
+
+// decodeBatchResult populates the service response (or returns the error) exactly like we would
+// had we received the result as the response to a normal call.
+func (c Client) decodeBatchResult(result batchResult, serviceResponse interface{}) error {
+	body := []byte(result.Body)
+	if len(body) == 0 {
+		body = []byte("{}")
+	}
+	return c.decodeResponse(&http.Response{
+		StatusCode: result.Status,
+		Header:     result.Headers,
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+	}, serviceResponse)
+}
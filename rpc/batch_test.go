@@ -0,0 +1,142 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+// Ensures that each call gets its own result (success or failure) in the same order as the calls, and that a
+// composite gateway has a single batch endpoint that can call functions on any of its services. The batch
+// endpoint doesn't exist unless you ask for it.
+func (suite *GatewaySuite) TestWithBatch() {
+	r := suite.Require()
+	composite, err := rpc.Compose(
+		suite.newEchoGateway("FooService", rpc.WithBatch(2)),
+		suite.newEchoGateway("BarService", rpc.WithBatch(2)),
+	)
+	r.NoError(err)
+
+	tests := []struct {
+		name    string
+		gateway http.Handler
+		body    string
+		status  int
+		json    string
+	}{
+		{
+			name:    "disabled",
+			gateway: suite.newEchoGateway("BatchService"),
+			body:    `[]`,
+			status:  404,
+		},
+		{
+			name:    "calls",
+			gateway: suite.newEchoGateway("BatchService", rpc.WithBatch(2)),
+			body: `[
+				{"service": "BatchService", "method": "Echo", "body": {"Text": "a"}},
+				{"service": "BatchService", "method": "Fail"},
+				{"service": "BatchService", "method": "Nope"},
+				{"service": "BatchService", "method": "Download"},
+				{"service": "BatchService", "method": "Batch"},
+				{"service": "BatchService", "method": "Echo", "body": {"Text": "b"}}
+			]`,
+			status: 200,
+			json: `[
+				{"status": 200, "headers": {"Content-Type": ["application/json"]}, "body": {"Text": "BatchService.Echo:a"}},
+				{"status": 403, "headers": {"Content-Type": ["application/json"]}, "body": {"status": 403, "message": "nope"}},
+				{"status": 404, "headers": {"Content-Type": ["application/json"]}, "body": {"status": 404, "message": "batch: unknown function BatchService.Nope"}},
+				{"status": 400, "headers": {"Content-Type": ["application/json"]}, "body": {"status": 400, "message": "batch: BatchService.Download does not respond w/ JSON, so it can't be batched"}},
+				{"status": 404, "headers": {"Content-Type": ["application/json"]}, "body": {"status": 404, "message": "batch: unknown function BatchService.Batch"}},
+				{"status": 200, "headers": {"Content-Type": ["application/json"]}, "body": {"Text": "BatchService.Echo:b"}}
+			]`,
+		},
+		{
+			name:    "not an array",
+			gateway: suite.newEchoGateway("BatchService", rpc.WithBatch(2)),
+			body:    `{"service": "BatchService"}`,
+			status:  400,
+		},
+		{
+			name:    "composite",
+			gateway: composite,
+			body: `[
+				{"service": "FooService", "method": "Echo", "body": {"Text": "a"}},
+				{"service": "BarService", "method": "Echo", "body": {"Text": "b"}}
+			]`,
+			status: 200,
+			json: `[
+				{"status": 200, "headers": {"Content-Type": ["application/json"]}, "body": {"Text": "FooService.Echo:a"}},
+				{"status": 200, "headers": {"Content-Type": ["application/json"]}, "body": {"Text": "BarService.Echo:b"}}
+			]`,
+		},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		test.gateway.ServeHTTP(w, httptest.NewRequest("POST", rpc.BatchPath, strings.NewReader(test.body)))
+		r.Equal(test.status, w.Code, test.name)
+		if test.json != "" {
+			r.JSONEq(test.json, w.Body.String(), test.name)
+		}
+	}
+}
+
+// Ensures that we never run more than the configured number of calls at the same time.
+func (suite *GatewaySuite) TestWithBatch_concurrency() {
+	r := suite.Require()
+
+	running := int32(0)
+	maxRunning := int32(0)
+	gw := suite.newGateway(rpc.WithBatch(2))
+	gw.Register(testEndpoint("POST", "/slow", "Slow", func(w http.ResponseWriter, req *http.Request) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			highest := atomic.LoadInt32(&maxRunning)
+			if current <= highest || atomic.CompareAndSwapInt32(&maxRunning, highest, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		rpc.Reply(w, req, 200, echoRequest{Text: "done"})
+	}))
+	gw.Register(gw.BatchEndpoint())
+
+	calls := strings.TrimSuffix(strings.Repeat(`{"service": "TestService", "method": "Slow"},`, 6), ",")
+	w := httptest.NewRecorder()
+	gw.ServeHTTP(w, httptest.NewRequest("POST", rpc.BatchPath, strings.NewReader("["+calls+"]")))
+	r.Equal(200, w.Code)
+	r.Equal(int32(2), atomic.LoadInt32(&maxRunning))
+}
+
+// Ensures that the client sends all of the calls at once and populates each call's response/error.
+func (suite *GatewaySuite) TestWithBatch_client() {
+	r := suite.Require()
+	server := httptest.NewServer(suite.newEchoGateway("BatchService", rpc.WithBatch(2)))
+	defer server.Close()
+
+	batch := rpc.NewClient("BatchService", server.URL).Batch()
+	echoCall := batch.Add("BatchService", "Echo", &echoRequest{Text: "a"}, &echoRequest{})
+	fail := batch.Add("BatchService", "Fail", &echoRequest{}, &echoRequest{})
+	r.NoError(batch.Send(context.Background()))
+	r.Len(batch.Calls(), 2)
+
+	r.NoError(echoCall.Err)
+	r.Equal("BatchService.Echo:a", echoCall.Response.(*echoRequest).Text)
+	r.True(errors.IsPermissionDenied(fail.Err))
+	r.Contains(fail.Err.Error(), "nope")
+
+	batch = rpc.NewClient("BatchService", server.URL, rpc.WithVersion("v2")).Batch()
+	batch.Add("BatchService", "Echo", &echoRequest{Text: "a"}, &echoRequest{})
+	err := batch.Send(context.Background())
+	r.Error(err, "The entire batch should fail when the gateway doesn't have the endpoint")
+	r.True(errors.IsNotFound(err))
+}
@@ -1,17 +1,21 @@
 package rpc
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
 	"reflect"
 	"strings"
 
-	"github.com/dimfeld/httptreemux/v5"
-	"github.com/monadicstack/frodo/internal/reflection"
 	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/internal/reflection"
 )
 
 // Binder performs the work of taking all meaningful values from an incoming request (body,
@@ -31,6 +35,16 @@ func WithBinder(binder Binder) GatewayOption {
 	}
 }
 
+// WithStrictBinding rejects JSON request bodies that contain attributes w/o a matching field in your request
+// struct. Normally, those values are silently dropped, so a client that sends "Frist" instead of "First" never
+// finds out that their data went nowhere. In strict mode, the caller gets a 400 that lists every unknown
+// attribute instead. You can also enable this for individual operations using the "STRICT" doc option.
+func WithStrictBinding() GatewayOption {
+	return func(gw *Gateway) {
+		gw.StrictBinding = true
+	}
+}
+
 // jsonBinder is the default gateway binder that uses encoding/json to apply body/path/query data
 // to service request models. This unifies the processing so that all 3 sources of values are unmarshaled
 // using the same semantics. The goal is that whether a value comes in from the body or the path or the
@@ -52,126 +66,522 @@ func WithBinder(binder Binder) GatewayOption {
 //
 // After generating each value, the jsonBinder will feed the massaged JSON to a 'json.Decoder' and standard
 // JSON marshaling rules will overlay each one onto your 'out' value.
+//
+// That's a lot of work for "age=39", though, so in practice the binder only does this for fields whose types
+// have custom UnmarshalJSON() logic. For plain strings, numbers, and booleans, it uses a binding plan that
+// it computes once per type to find the right field and set it directly. The end result is the same either way.
+// When binding a request to a gateway endpoint, the binder also compiles the endpoint's defaults on the first
+// request, so that later requests only do the work that actually depends on the request.
 type jsonBinder struct{}
 
 // jsonBindingContext carries our buffer/decoder context through all the binding operations so
 // that all values can share resources (e.g. binding the path params can piggy-back off of the
 // work of binding the query string).
 type jsonBindingContext struct {
-	buf     *bytes.Buffer
+	// plan is the cached binding plan for the 'out' value's type, so most values can be set directly.
+	plan *bindingPlan
+	// defaults are the default values to apply to any fields that are still zero (see BindDefaults).
+	defaults []bindingDefault
+	// outValue is the reflective value of the struct we're binding to.
+	outValue reflect.Value
+	// buf is where we write the synthetic JSON for values that need the JSON binding behavior. Since
+	// the fast path handles most values, this is lazily created the first time we actually need it.
+	buf *bytes.Buffer
+	// decoder reads the synthetic JSON from 'buf'.
 	decoder *json.Decoder
+	// json are the gateway's JSON settings (see WithJSON).
+	json *JSON
+	// strict indicates that unknown attributes in the body should be rejected (see WithStrictBinding).
+	strict bool
+	// provided is where we record the attributes that the caller actually sent (see Provided). This
+	// is nil when the request didn't come through a gateway.
+	provided *providedFields
+	// failedSource/failedKey describe the input that we couldn't bind when it's not obvious from the step that
+	// failed (e.g. the header that a BindSources() failure came from), so that we can report the failure.
+	failedSource string
+	failedKey    string
 }
 
 func (b jsonBinder) Bind(req *http.Request, out interface{}) error {
 	if req == nil {
 		return fmt.Errorf("unable to bind nil request")
 	}
-	// To keep the logic more simple (but fast enough for most use cases), we will generate a separate
-	// JSON representation of each value and run it through the JSON decoder. To make things a bit more
-	// efficient, we'll re-use the buffer/reader and the JSON decoder for each value we unmarshal
-	// on the output. This way we only suffer one buffer allocation no matter how many values we handle.
-	buf := &bytes.Buffer{}
-	ctx := jsonBindingContext{
-		buf:     buf,
-		decoder: json.NewDecoder(buf),
+	if outValue := reflect.ValueOf(out); outValue.Kind() != reflect.Ptr || outValue.IsNil() {
+		return fmt.Errorf("unable to bind to non-pointer value %T", out)
+	}
+	// Most path/query values are primitives (strings, ints, bools, etc) that we can set directly using
+	// the type's cached binding plan. We only fall back to generating JSON for each value when the field
+	// has custom unmarshaling logic. To make that a bit more efficient, we'll re-use the buffer/reader and
+	// the JSON decoder for each of those values, so we only suffer one buffer allocation no matter how
+	// many values we handle.
+	endpoint, _ := req.Context().Value(contextKeyEndpoint{}).(Endpoint)
+	binding := endpoint.binding.compile(reflect.TypeOf(out), endpoint.Defaults)
+	ctx := &jsonBindingContext{
+		plan:     binding.plan,
+		defaults: binding.defaults,
+		outValue: reflect.Indirect(reflect.ValueOf(out)),
+		json:     gatewayJSON(req),
+		strict:   strictBinding(req, endpoint),
+		provided: providedFieldsFromContext(req.Context()),
 	}
 
 	if err := b.BindQueryString(ctx, req, out); err != nil {
-		return fmt.Errorf("error binding query string: %w", err)
+		return b.bindingFailed(req, ctx, "query", "query string", err)
 	}
 	if err := b.BindBody(ctx, req, out); err != nil {
-		return fmt.Errorf("error binding body: %w", err)
+		return b.bindingFailed(req, ctx, "body", "body", err)
 	}
 	if err := b.BindPathParams(ctx, req, out); err != nil {
-		return fmt.Errorf("error binding path params: %w", err)
+		return b.bindingFailed(req, ctx, "path", "path params", err)
+	}
+	if err := b.BindSources(ctx, req); err != nil {
+		return b.bindingFailed(req, ctx, "header", "headers/cookies", err)
+	}
+	if err := b.BindDefaults(ctx, req); err != nil {
+		return b.bindingFailed(req, ctx, "default", "defaults", err)
 	}
 	return nil
 }
 
-// BindBody decodes the JSON body of the request onto the 'out' value.
-func (b jsonBinder) BindBody(_ jsonBindingContext, req *http.Request, out interface{}) error {
+// BindBody decodes the body of the request onto the 'out' value. This is typically JSON, but HTML form
+// bodies (URL-encoded or multipart) are supported, too. See BindForm() for details.
+func (b jsonBinder) BindBody(ctx *jsonBindingContext, req *http.Request, out interface{}) error {
 	if req.Body == nil {
 		return nil
 	}
 	if req.Body == http.NoBody {
 		return nil
 	}
-	if req.Method != "POST" && req.Method != "PUT" && req.Method != "PATCH" {
+	if req.Method != "POST" && req.Method != "PUT" && req.Method != "PATCH" && !isBatchCall(req.Context()) {
 		return nil // Only bind methods universally intended to have body data that affects the request.
 	}
-	return json.NewDecoder(req.Body).Decode(out)
+
+	switch contentType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); contentType {
+	case "application/x-www-form-urlencoded":
+		// Tools like curl label every body as a form unless you say otherwise, so `curl -d '{"A":5}'` is
+		// really sending JSON. Only treat the body as a form when it doesn't look like a JSON object.
+		body := bufio.NewReader(req.Body)
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{body, req.Body}
+		if !b.looksLikeJSONObject(body) {
+			return b.BindForm(ctx, req, out)
+		}
+	case "multipart/form-data":
+		return b.BindMultipartForm(ctx, req, out)
+	case ProtobufContentType:
+		if gatewayProtobuf(req) {
+			return bindBodyProtobuf(req.Body, out)
+		}
+	}
+
+	if !ctx.json.standard() {
+		return b.bindBodyJSON(ctx, req.Body, out)
+	}
+
+	body := &countingReader{reader: req.Body}
+	decoder := json.NewDecoder(body)
+	if !ctx.strict && ctx.provided == nil {
+		if err := decoder.Decode(out); err != nil {
+			return b.bodyError(decoder, body, err)
+		}
+		return nil
+	}
+
+	// The decoder only tells us about the first unknown field, so hang onto the raw JSON in case
+	// we need to go back and find the rest of them. We also need it to know which attributes you sent.
+	raw := &bytes.Buffer{}
+	body.reader = io.TeeReader(req.Body, raw)
+	if ctx.strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(out); err != nil {
+		if ctx.strict && strings.HasPrefix(err.Error(), "json: unknown field ") {
+			return b.unknownFieldsError(raw.Bytes(), out, ctx.json)
+		}
+		return b.bodyError(decoder, body, err)
+	}
+	ctx.provided.addBody(raw.Bytes())
+	return nil
+}
+
+// bindBodyJSON decodes the JSON body using the gateway's custom JSON settings. Other libraries don't give us
+// the same detailed errors that "encoding/json" does, so the caller just gets the library's error message.
+func (b jsonBinder) bindBodyJSON(ctx *jsonBindingContext, body io.Reader, out interface{}) error {
+	data, err := ioutil.ReadAll(body)
+	if err == nil && len(bytes.TrimSpace(data)) == 0 {
+		return errors.WithDetails(
+			errors.BadRequest("invalid JSON at offset 0: empty body"),
+			map[string]interface{}{"offset": int64(0)},
+		)
+	}
+	if err == nil {
+		err = ctx.json.unmarshal(data, out)
+	}
+	if err == nil {
+		ctx.provided.addBody(data)
+	}
+	if err == nil && ctx.strict {
+		err = b.unknownFieldsError(data, out, ctx.json)
+	}
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Status(err) != http.StatusInternalServerError:
+		// The body was too big or a custom UnmarshalJSON() already told us exactly what status it wants.
+		return err
+	default:
+		return errors.BadRequest("invalid JSON: %v", err)
+	}
+}
+
+// unknownFieldsError returns the 400 error for strict binding that lists the paths of all of the attributes
+// in the body that don't match a field in the 'out' value (e.g. "Criteria.Limt"). It returns nil when every
+// attribute is accounted for.
+func (b jsonBinder) unknownFieldsError(data []byte, out interface{}, config *JSON) error {
+	var fields []string
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	value := json.RawMessage{}
+	if err := decoder.Decode(&value); err != nil {
+		return errors.BadRequest("invalid JSON: %v", err)
+	}
+	unknownJSONFields(value, reflect.TypeOf(out), "", config != nil && config.SnakeCase, &fields)
+	if len(fields) == 0 {
+		return nil
+	}
+	return errors.WithDetails(
+		errors.BadRequest("unknown fields in request body: %s", strings.Join(fields, ", ")),
+		map[string]interface{}{"fields": fields},
+	)
+}
+
+// strictBinding returns true when the gateway or the endpoint handling the request wants to reject
+// unknown attributes in the body.
+func strictBinding(req *http.Request, endpoint Endpoint) bool {
+	if endpoint.Strict {
+		return true
+	}
+	gw, ok := req.Context().Value(contextKeyGateway{}).(*Gateway)
+	return ok && gw.StrictBinding
+}
+
+// looksLikeJSONObject peeks at the start of the body to see if it's a JSON object (i.e. the first non-whitespace
+// character is a '{'). This doesn't consume any of the body, so you can still read the whole thing afterwards.
+func (b jsonBinder) looksLikeJSONObject(body *bufio.Reader) bool {
+	for i := 1; ; i++ {
+		data, err := body.Peek(i)
+		if len(data) < i || err != nil {
+			return false
+		}
+		switch data[i-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		default:
+			return data[i-1] == '{'
+		}
+	}
+}
+
+// maxFormMemory is how much of a multipart form body we'll hold in memory. Just like the standard library,
+// file parts beyond this limit are stored in temporary files.
+const maxFormMemory = 32 << 20
+
+// BindForm decodes a URL-encoded HTML form body (i.e. "application/x-www-form-urlencoded") onto the
+// 'out' value. Form fields follow the same rules as query string parameters, so you use dotted keys like
+// "Criteria.Limit" to bind nested values:
+//
+//     <form method="POST" action="/UserService.SearchUsers">
+//         <input name="Text" />
+//         <input name="Criteria.Limit" value="10" />
+//     </form>
+func (b jsonBinder) BindForm(ctx *jsonBindingContext, req *http.Request, out interface{}) error {
+	if err := req.ParseForm(); err != nil {
+		return b.formError("invalid form", err)
+	}
+	return b.bindFormValues(ctx, req.PostForm, out)
+}
+
+// BindMultipartForm decodes a "multipart/form-data" body onto the 'out' value. The fields follow the
+// same rules as BindForm(). File parts of the form are not bound to your request.
+func (b jsonBinder) BindMultipartForm(ctx *jsonBindingContext, req *http.Request, out interface{}) error {
+	if err := req.ParseMultipartForm(maxFormMemory); err != nil {
+		return b.formError("invalid multipart form", err)
+	}
+	return b.bindFormValues(ctx, req.MultipartForm.Value, out)
+}
+
+func (b jsonBinder) bindFormValues(ctx *jsonBindingContext, values url.Values, out interface{}) error {
+	for key, value := range values {
+		// Bad values in the body are the caller's fault, just like bad JSON.
+		if err := b.bindValue(ctx, key, value[0], out); err != nil {
+			ctx.failedKey = key
+			return errors.BadRequest("%v", err)
+		}
+	}
+	return nil
+}
+
+// formError converts a failure from parsing the form body into a 400-style error. Failures that already
+// have a status (e.g. the 413 when the body is too large) are passed through as-is.
+func (b jsonBinder) formError(message string, err error) error {
+	if errors.Status(err) != http.StatusInternalServerError {
+		return err
+	}
+	return errors.BadRequest("%s: %v", message, err)
+}
+
+// countingReader keeps track of how many bytes we've read from the underlying reader. When the body
+// is truncated, the decoder can't tell us where the failure happened, but this can.
+type countingReader struct {
+	reader    io.Reader
+	bytesRead int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.bytesRead += int64(n)
+	return n, err
+}
+
+// bodyError converts a failure from decoding the JSON body into a 400-style error that tells the caller
+// where in the body the failure occurred. The details will include the byte "offset" of the failure and,
+// when we know it, the "path" to the field (e.g. "Criteria.Limit") as well as the JSON type we "expected".
+func (b jsonBinder) bodyError(decoder *json.Decoder, body *countingReader, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case stderrors.As(err, &syntaxErr):
+		return errors.WithDetails(
+			errors.BadRequest("invalid JSON at offset %d: %v", syntaxErr.Offset, err),
+			map[string]interface{}{"offset": syntaxErr.Offset},
+		)
+	case stderrors.As(err, &typeErr) && typeErr.Field == "":
+		return errors.WithDetails(
+			errors.BadRequest("invalid JSON at offset %d: expected %v, got %s", typeErr.Offset, typeErr.Type, typeErr.Value),
+			map[string]interface{}{"offset": typeErr.Offset, "expected": typeErr.Type.String()},
+		)
+	case stderrors.As(err, &typeErr):
+		return errors.WithDetails(
+			errors.BadRequest("invalid value for '%s' at offset %d: expected %v, got %s", typeErr.Field, typeErr.Offset, typeErr.Type, typeErr.Value),
+			map[string]interface{}{"offset": typeErr.Offset, "path": typeErr.Field, "expected": typeErr.Type.String()},
+		)
+	case err == io.EOF:
+		return errors.WithDetails(
+			errors.BadRequest("invalid JSON at offset 0: empty body"),
+			map[string]interface{}{"offset": int64(0)},
+		)
+	case err == io.ErrUnexpectedEOF:
+		return errors.WithDetails(
+			errors.BadRequest("invalid JSON at offset %d: unexpected end of body", body.bytesRead),
+			map[string]interface{}{"offset": body.bytesRead},
+		)
+	case errors.Status(err) != http.StatusInternalServerError:
+		// A custom UnmarshalJSON() already told us exactly what status it wants, so respect that.
+		return err
+	default:
+		return errors.WithDetails(
+			errors.BadRequest("invalid JSON near offset %d: %v", decoder.InputOffset(), err),
+			map[string]interface{}{"offset": decoder.InputOffset()},
+		)
+	}
 }
 
 // BindQueryString decodes the query string parameters onto the 'out' value. Each parameter will
-// be converted to an equivalent JSON object and unmarshaled separately.
-func (b jsonBinder) BindQueryString(ctx jsonBindingContext, req *http.Request, out interface{}) error {
+// be set directly or converted to an equivalent JSON object and unmarshaled separately.
+func (b jsonBinder) BindQueryString(ctx *jsonBindingContext, req *http.Request, out interface{}) error {
 	if req.URL == nil {
 		return errors.BadRequest("request missing url")
 	}
-	return b.bindValues(ctx, req.URL.Query(), out)
+	for key, value := range req.URL.Query() {
+		if err := b.bindValue(ctx, key, value[0], out); err != nil {
+			ctx.failedKey = key
+			return err
+		}
+	}
+	return nil
 }
 
 // BindPathParams decodes the URL path parameters onto the 'out' value. Each parameter will
-// be converted to an equivalent JSON object and unmarshaled separately.
-func (b jsonBinder) BindPathParams(ctx jsonBindingContext, req *http.Request, out interface{}) error {
-	params := httptreemux.ContextParams(req.Context())
-	values := url.Values{}
-	for key, value := range params {
-		values.Set(key, value)
+// be set directly or converted to an equivalent JSON object and unmarshaled separately.
+func (b jsonBinder) BindPathParams(ctx *jsonBindingContext, req *http.Request, out interface{}) error {
+	for key, value := range PathParams(req) {
+		if err := b.bindValue(ctx, key, value, out); err != nil {
+			ctx.failedKey = key
+			return err
+		}
 	}
-	return b.bindValues(ctx, values, out)
+	return nil
 }
 
-func (b jsonBinder) bindValues(ctx jsonBindingContext, requestValues url.Values, out interface{}) error {
-	outValue := reflect.Indirect(reflect.ValueOf(out))
+// BindSources applies header and cookie values to the fields tagged w/ `frodo:"header=..."` or `frodo:"cookie=..."`.
+// These fields are ONLY bound from their header/cookie, so we clear out anything the body tried to sneak in.
+func (b jsonBinder) BindSources(ctx *jsonBindingContext, req *http.Request) error {
+	for _, field := range ctx.plan.sources {
+		field.clear(ctx.outValue)
 
-	for key, value := range requestValues {
-		keySegments := strings.Split(key, ".")
+		value, ok := b.sourceValue(req, field)
+		if !ok {
+			continue
+		}
+		ctx.provided.addKey(field.provided)
+		if err := b.bindSourceValue(ctx, field, value); err != nil {
+			ctx.failedSource, ctx.failedKey = field.source, field.name
+			return errors.BadRequest("unable to bind %s '%s'='%s': %v", field.source, field.name, value, err)
+		}
+	}
+	return nil
+}
 
-		// Follow the segments of the key and determine the JSON type of the last segment. So if you
-		// are binding the key "foo.bar.baz", we'll look at the Go data type of the "baz" field once
-		// we've followed the path "out.foo.bar". This will spit back our enum for the JSON data type
-		// that will most naturally unmarshal to the Go type. So if the Go data type for the "baz" field
-		// is uint16 then we'd expect this to return 'jsonTypeNumber'. If "baz" were a string then
-		// we'd expect this to return 'jsonTypeString', and so on.
-		valueType := b.keyToJSONType(outValue, keySegments, value[0])
+// sourceValue looks up the raw header/cookie value for the field. The boolean is false when the
+// request doesn't have that header/cookie at all.
+func (b jsonBinder) sourceValue(req *http.Request, field bindingSourceField) (string, bool) {
+	switch field.source {
+	case reflection.BindingSourceHeader:
+		values := req.Header.Values(field.name)
+		if len(values) == 0 {
+			return "", false
+		}
+		return values[0], true
+	case reflection.BindingSourceCookie:
+		cookie, err := req.Cookie(field.name)
+		if err != nil {
+			return "", false
+		}
+		return cookie.Value, true
+	default:
+		return "", false
+	}
+}
 
-		// We didn't find a field path with that name (e.g. the key was "name" but there was no field called "name")
-		if valueType == jsonTypeNil {
+// BindDefaults applies the default values from `default:"25"` tags and the endpoint's "DEFAULT" doc options
+// to any fields that are still zero after everything else has been bound.
+func (b jsonBinder) BindDefaults(ctx *jsonBindingContext, req *http.Request) error {
+	for _, field := range ctx.defaults {
+		if current := field.get(ctx.outValue); current.IsValid() && !current.IsZero() {
 			continue
 		}
+		if err := b.bindFieldValue(ctx, field.bindingField, field.value); err != nil {
+			ctx.failedKey = field.key
+			return fmt.Errorf("invalid default '%s'='%s': %w", field.key, field.value, err)
+		}
+	}
+	return nil
+}
+
+// bindSourceValue sets the header/cookie value on the field.
+func (b jsonBinder) bindSourceValue(ctx *jsonBindingContext, field bindingSourceField, value string) error {
+	return b.bindFieldValue(ctx, field.bindingField, value)
+}
+
+// bindFieldValue sets the value on a field that we've already looked up in the binding plan. Since we know
+// exactly which field we're binding, types w/ custom unmarshaling logic just unmarshal the value directly.
+func (b jsonBinder) bindFieldValue(ctx *jsonBindingContext, field bindingField, value string) error {
+	switch field.kind {
+	case bindingKindSkip:
+		return nil
+	case bindingKindJSON:
+		fieldValue := field.settable(ctx.outValue)
+		valueJSON := []byte(value)
+		if b.valueToJSONType(field.typ, value) == jsonTypeString {
+			valueJSON, _ = json.Marshal(value)
+		}
+		return json.Unmarshal(valueJSON, fieldValue.Addr().Interface())
+	default:
+		return field.set(ctx.outValue, value)
+	}
+}
+
+// bindValue applies a single path/query parameter to the 'out' value. Primitive fields are parsed and set
+// directly using the binding plan. Fields w/ custom unmarshaling logic go through the JSON binding process.
+func (b jsonBinder) bindValue(ctx *jsonBindingContext, key string, value string, out interface{}) error {
+	// We didn't find a field path with that name (e.g. the key was "name" but there was no field called "name").
+	// For recursive types, though, the key might go deeper than the plan does, so let the JSON binding sort it out.
+	field, ok := ctx.plan.lookup(key)
+	if !ok && ctx.json.SnakeCase && strings.Contains(key, "_") {
+		// Lookups ignore case, so dropping the underscores lets "first_name" find the field "FirstName".
+		if field, ok = ctx.plan.lookup(strings.ReplaceAll(key, "_", "")); ok {
+			key = strings.ReplaceAll(key, "_", "")
+		}
+	}
+	if !ok {
+		ctx.provided.add(key)
+	}
+	if !ok && ctx.plan.recursive {
+		valueType := b.keyToJSONType(ctx.outValue, strings.Split(key, "."), value)
+		return b.bindValueJSON(ctx, key, value, valueType, out)
+	}
+	if !ok {
+		return nil
+	}
+
+	ctx.provided.addKey(field.provided)
+
+	switch field.kind {
+	case bindingKindSkip:
 		// Maybe you provided "foo.bar.baz=4" and there is a field at "out.foo.bar.baz", but it's
 		// a struct of some kind, so "4" is not enough to properly bind it. Arrays/slices we'll handle
 		// in a future version... maybe.
-		if valueType == jsonTypeObject || valueType == jsonTypeArray {
-			continue
+		return nil
+	case bindingKindJSON:
+		return b.bindValueJSON(ctx, key, value, b.valueToJSONType(field.typ, value), out)
+	default:
+		if err := field.set(ctx.outValue, value); err != nil {
+			return fmt.Errorf("unable to bind value '%s'='%s': %w", key, value, err)
 		}
+		return nil
+	}
+}
 
-		// Convert the parameter "foo.bar.baz=4" into {"foo":{"bar":{"baz":4}}} so that the standard
-		// JSON decoder can work its magic to apply that to 'out' properly.
-		ctx.buf.Reset()
-		b.writeParamJSON(ctx.buf, keySegments, value[0], valueType)
+// bindValueJSON converts the parameter to JSON and lets the standard JSON decoder apply it to the 'out'
+// value. This is slower than setting the field directly, but it respects any custom UnmarshalJSON() logic.
+//
+// The value type is the JSON data type that will most naturally unmarshal to the Go type of the field at
+// "foo.bar.baz". So if the Go data type for the "baz" field is uint16 then we'd expect 'jsonTypeNumber'. If
+// "baz" were a string then we'd expect 'jsonTypeString', and so on. The binding plan usually already knows
+// the field's type. Otherwise, keyToJSONType() can go find it.
+func (b jsonBinder) bindValueJSON(ctx *jsonBindingContext, key string, value string, valueType jsonType, out interface{}) error {
+	if valueType == jsonTypeNil || valueType == jsonTypeObject || valueType == jsonTypeArray {
+		return nil
+	}
 
-		// Now that we have a close-enough JSON representation of your parameter, let the standard
-		// JSON decoder do its magic.
-		if err := ctx.decoder.Decode(out); err != nil {
-			return fmt.Errorf("unable to bind value '%s'='%s': %w", key, value[0], err)
-		}
+	if ctx.buf == nil {
+		ctx.buf = &bytes.Buffer{}
+		ctx.decoder = json.NewDecoder(ctx.buf)
+	}
+
+	// Convert the parameter "foo.bar.baz=4" into {"foo":{"bar":{"baz":4}}} so that the standard
+	// JSON decoder can work its magic to apply that to 'out' properly.
+	ctx.buf.Reset()
+	b.writeParamJSON(ctx.buf, key, value, valueType)
+
+	// Now that we have a close-enough JSON representation of your parameter, let the standard
+	// JSON decoder do its magic.
+	if err := ctx.decoder.Decode(out); err != nil {
+		return fmt.Errorf("unable to bind value '%s'='%s': %w", key, value, err)
 	}
 	return nil
 }
 
-// writeParamJSON accepts the decomposed parameter key (e.g. "foo.bar.baz") and the raw string value (e.g. "moo")
+// writeParamJSON accepts the parameter key (e.g. "foo.bar.baz") and the raw string value (e.g. "moo")
 // and writes JSON to the buffer which can be used in standard JSON decoding/unmarshaling to apply the value
 // to the out object (e.g. `{"foo":{"bar":{"baz":"moo"}}}`).
-func (b jsonBinder) writeParamJSON(buf *bytes.Buffer, keySegments []string, value string, valueType jsonType) {
-	for _, keySegment := range keySegments {
+func (b jsonBinder) writeParamJSON(buf *bytes.Buffer, key string, value string, valueType jsonType) {
+	// Same as ranging over strings.Split(key, "."), just w/o allocating the slice of segments.
+	depth := strings.Count(key, ".") + 1
+	for remaining, i := key, 0; i < depth; i++ {
+		keySegment := remaining
+		if dot := strings.IndexByte(remaining, '.'); dot >= 0 {
+			keySegment, remaining = remaining[:dot], remaining[dot+1:]
+		}
 		buf.WriteString(`{"`)
 		buf.WriteString(keySegment)
 		buf.WriteString(`":`)
 	}
 	b.writeBindingValueJSON(buf, value, valueType)
-	for i := 0; i < len(keySegments); i++ {
+	for i := 0; i < depth; i++ {
 		buf.WriteString("}")
 	}
 }
@@ -251,6 +661,12 @@ func (b jsonBinder) keyToJSONType(outValue reflect.Value, key []string, value st
 	// JSON number (since the duration is an int64), but the value doesn't "look" like a number; it looks
 	// like a freeform string. As a result, we need to build the binding JSON {"foo":"PT3M49S"} since we will
 	// treat the right-hand side as a string rather than {"foo":PT3M48S} which is not valid.
+	return b.valueToJSONType(actualType, value)
+}
+
+// valueToJSONType returns the JSON type we should use when binding the raw value to a field of the given
+// type. Numeric/boolean types only stay numbers/booleans if the value looks like one (see keyToJSONType).
+func (b jsonBinder) valueToJSONType(actualType reflect.Type, value string) jsonType {
 	t := b.typeToJSONType(actualType)
 	if t == jsonTypeBool && !b.looksLikeBoolJSON(value) {
 		return jsonTypeString
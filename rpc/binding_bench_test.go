@@ -5,6 +5,7 @@ package rpc_test
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
@@ -45,6 +46,52 @@ func BenchmarkJsonBinder_Bind(b *testing.B) {
 	}
 }
 
+// BenchmarkJsonBinder_BindEndpoint binds a request w/ lots of fields (including ones w/ custom unmarshaling and
+// defaults) the way that a generated gateway does, so the endpoint's binding info is available.
+func BenchmarkJsonBinder_BindEndpoint(b *testing.B) {
+	type benchmarkLevel int
+	type benchmarkRequest struct {
+		ID       string
+		Text     string
+		Limit    int `default:"25"`
+		Offset   int
+		Sort     string `json:"order"`
+		Fuzzy    bool
+		Tenant   string `frodo:"header=X-Tenant-ID"`
+		Level    textLevel
+		Other    textLevel
+		Duration aliasDuration
+		Criteria searchCriteria
+		Extra1   string
+		Extra2   int
+		Extra3   float64
+		Extra4   benchmarkLevel
+	}
+
+	gw := rpc.NewGateway()
+	gw.Register(rpc.Endpoint{
+		Method:      "GET",
+		Path:        "/search/:ID",
+		ServiceName: "SearchService",
+		Name:        "Search",
+		Defaults:    map[string]string{"order": "name", "Fuzzy": "true"},
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			output := benchmarkRequest{}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = gw.Binder.Bind(req, &output)
+			}
+			b.StopTimer()
+		},
+	})
+
+	query := "?Text=dude&Offset=5&Level=high&Other=low&Duration=5m&Criteria.Limit=3&Criteria.audit.CreatedBy=me&Extra2=4"
+	req := httptest.NewRequest("GET", "/search/abcdef"+query, nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+	gw.ServeHTTP(httptest.NewRecorder(), req)
+}
+
 type mockRouteData struct {
 	route  string
 	params map[string]string
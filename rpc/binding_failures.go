@@ -0,0 +1,92 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+// BindingFailure describes a call that the gateway rejected because it couldn't make sense of the caller's
+// input, so the call never made it to your service. These are usually bugs in one of your callers, so
+// the failure tells you which operation they called and which bit of input was bad.
+type BindingFailure struct {
+	// Endpoint is the operation that the caller was trying to invoke.
+	Endpoint Endpoint
+	// Source is where the bad input came from: "query", "body", "path", "header", "cookie", "default" (a
+	// "DEFAULT" doc option or `default` tag that doesn't fit the field), or "metadata" (the "X-RPC-Values" header).
+	Source string
+	// Key is the name of the bad param/field (e.g. "Criteria.Limit") when we know it. Failures from the
+	// "X-RPC-Values" header use the header's name since the whole header is unreadable.
+	Key string
+	// Err is the error that the caller received.
+	Err error
+}
+
+// BindingFailureHandler is notified whenever the gateway rejects a call because it couldn't bind the request or
+// the caller sent a malformed "X-RPC-Values" header.
+type BindingFailureHandler func(ctx context.Context, failure BindingFailure)
+
+// WithBindingFailureHandler registers callbacks that the gateway invokes whenever it rejects a call before it
+// reaches your service because of the caller's input: the request couldn't be bound, a path param didn't match its constraint, or the
+// "X-RPC-Values" header was malformed. Without this, those failures are invisible since the caller is the only
+// one who sees the error, so this is where you bump a counter to find out which client integration is broken:
+//
+//     gateway := users.NewUserServiceGateway(service, rpc.WithBindingFailureHandler(
+//         func(ctx context.Context, failure rpc.BindingFailure) {
+//             metrics.Increment("binding_failures",
+//                 "operation", failure.Endpoint.String(),
+//                 "source", failure.Source,
+//                 "key", failure.Key,
+//             )
+//         },
+//     ))
+//
+// The default binder reports its own failures. If you supply a custom Binder, it's up to you to report yours
+// using ReportBindingFailure().
+func WithBindingFailureHandler(handlers ...BindingFailureHandler) GatewayOption {
+	return func(gw *Gateway) {
+		gw.bindingFailureHandlers = append(gw.bindingFailureHandlers, handlers...)
+	}
+}
+
+// ReportBindingFailure notifies the gateway's BindingFailureHandlers that the request failed to bind. Custom
+// binders can use this to report their failures the same way that the default binder does. When the request
+// isn't being handled by a gateway, this does nothing.
+func ReportBindingFailure(req *http.Request, source string, key string, err error) {
+	gw, ok := req.Context().Value(contextKeyGateway{}).(*Gateway)
+	if !ok || len(gw.bindingFailureHandlers) == 0 {
+		return
+	}
+
+	failure := BindingFailure{Source: source, Key: key, Err: err}
+	if endpoint := EndpointFromContext(req.Context()); endpoint != nil {
+		failure.Endpoint = *endpoint
+	}
+	for _, handler := range gw.bindingFailureHandlers {
+		handler(req.Context(), failure)
+	}
+}
+
+// bindingFailed reports the failure from one step of the binding process and returns the error that the caller
+// should receive. The source/key are the ones that the step recorded in the binding context or, for body
+// failures, the path to the bad attribute(s) from the error's details.
+func (b jsonBinder) bindingFailed(req *http.Request, ctx *jsonBindingContext, source string, step string, err error) error {
+	if ctx.failedSource != "" {
+		source = ctx.failedSource
+	}
+	key := ctx.failedKey
+	if details := errors.Details(err); key == "" && details != nil {
+		if path, ok := details["path"].(string); ok {
+			key = path
+		}
+		if fields, ok := details["fields"].([]string); ok {
+			key = strings.Join(fields, ",")
+		}
+	}
+	err = fmt.Errorf("error binding %s: %w", step, err)
+	ReportBindingFailure(req, source, key, err)
+	return err
+}
@@ -0,0 +1,88 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/metadata"
+)
+
+type bindingFailureRequest struct {
+	ID       string
+	Limit    int
+	Criteria struct {
+		Sort  string
+		Limit int
+	}
+	Version int `frodo:"header=X-Version"`
+}
+
+// Ensures that we report each kind of bad input along w/ the operation and the key that was bad, and that
+// successful calls don't report anything.
+func (suite *GatewaySuite) TestWithBindingFailureHandler() {
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		header http.Header
+		source string
+		key    string
+	}{
+		{name: "success", path: "/search/1?Limit=10", body: `{"Criteria":{"Limit":5}}`},
+		{name: "query", path: "/search/1?Limit=ten", body: `{}`, source: "query", key: "Limit"},
+		{name: "body", path: "/search/1", body: `{"Criteria":{"Limit":"ten"}}`, source: "body", key: "Criteria.Limit"},
+		{name: "form", path: "/search/1", body: `Limit=ten`, header: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}, source: "body", key: "Limit"},
+		{name: "syntax", path: "/search/1", body: `{"Criteria":`, source: "body"},
+		{name: "path", path: "/search/abc", body: `{}`, source: "path", key: "ID"},
+		{name: "header", path: "/search/1", body: `{}`, header: http.Header{"X-Version": {"two"}}, source: "header", key: "X-Version"},
+		{name: "metadata", path: "/search/1", body: `{}`, header: http.Header{metadata.RequestHeader: {`{"Oops`}}, source: "metadata", key: metadata.RequestHeader},
+	}
+
+	var failures []rpc.BindingFailure
+	gw := suite.newGateway(rpc.WithBindingFailureHandler(func(ctx context.Context, failure rpc.BindingFailure) {
+		failures = append(failures, failure)
+	}))
+	search := testEndpoint("POST", "/search/:ID", "Search", echo(&gw, func() interface{} { return &bindingFailureRequest{} }))
+	search.PathConstraints = map[string]string{"ID": "int"}
+	gw.Register(search)
+
+	for _, test := range tests {
+		r := suite.Require()
+		failures = nil
+
+		req := httptest.NewRequest("POST", test.path, strings.NewReader(test.body))
+		for key, values := range test.header {
+			req.Header.Set(key, values[0])
+		}
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+
+		if test.source == "" {
+			r.Equal(200, w.Code, test.name)
+			r.Empty(failures, test.name)
+			continue
+		}
+		r.GreaterOrEqual(w.Code, 400, test.name)
+		r.Len(failures, 1, test.name)
+		r.Equal("TestService.Search", failures[0].Endpoint.String(), test.name)
+		r.Equal(test.source, failures[0].Source, test.name)
+		r.Equal(test.key, failures[0].Key, test.name)
+		r.Error(failures[0].Err, test.name)
+		if test.source != "metadata" {
+			// The whole header is unreadable, so there's no one key for the error to point at.
+			r.Contains(failures[0].Err.Error(), test.key, test.name)
+		}
+	}
+}
+
+// Ensures that ReportBindingFailure() is harmless when the request isn't being handled by a gateway.
+func (suite *GatewaySuite) TestReportBindingFailure_noGateway() {
+	suite.NotPanics(func() {
+		rpc.ReportBindingFailure(httptest.NewRequest("GET", "/", nil), "query", "Limit", nil)
+	})
+}
@@ -0,0 +1,559 @@
+package rpc
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/monadicstack/frodo/rpc/internal/reflection"
+)
+
+// bindingPlans caches the binding plan for every request type we've bound so far. Plans are
+// immutable once built, so every request for that type can share them.
+var bindingPlans sync.Map
+
+// bindingPlan describes how to apply path/query parameters to some struct type w/o having to
+// generate/decode JSON for every value. It maps every parameter key that we know how to bind
+// (e.g. "criteria.audit.createdby") to the field it should set. Keys are all lower case since
+// we look up fields case-insensitively to match the semantics of encoding/json.
+type bindingPlan struct {
+	fields map[string]bindingField
+	// recursive indicates that the type refers back to itself somewhere (e.g. a tree node w/ a *Node field). We
+	// stop building the plan once we loop back around, so keys we don't have a plan for could still be valid.
+	recursive bool
+	// sources are the fields bound from a header or cookie (e.g. `frodo:"header=X-Tenant-ID"`) rather than
+	// the body/path/query. These fields are NOT in the 'fields' lookup so that params can't bind them.
+	sources []bindingSourceField
+	// optional are the top-level pointer fields. Clients leave them out of the body when they're nil rather
+	// than sending null, so that the gateway knows the caller didn't provide them (see Provided).
+	optional []bindingOptionalField
+	// defaults are the values from `default:"25"` tags on top-level fields, keyed by the field's binding
+	// name. The binder applies them to any of those fields that are still zero after binding.
+	defaults map[string]string
+	// defaultFields are the 'defaults' w/ their fields already looked up, so the binder doesn't have to.
+	defaultFields []bindingDefault
+	// ambiguous are the keys (and the embedding depth) that more than one field at that depth wants. Just like
+	// encoding/json, we don't bind those at all unless a shallower field comes along later.
+	ambiguous map[string]int
+}
+
+// bindingDefault is a default value that we've already resolved to the field it applies to.
+type bindingDefault struct {
+	bindingField
+	// key is the name of the field that the default came from (e.g. "Limit" or "first_name").
+	key string
+	// value is the raw default value (e.g. "25").
+	value string
+}
+
+// bindingOptionalField describes a top-level pointer field that clients only send when it's not nil.
+type bindingOptionalField struct {
+	bindingField
+	// key is the field's binding name (e.g. "Email"), which is also its attribute name in JSON.
+	key string
+}
+
+// bindingSourceField describes a field that's bound from a header or cookie rather than the body/path/query.
+type bindingSourceField struct {
+	bindingField
+	// key is the field's binding name (e.g. "TenantID"), which is also its attribute name in JSON.
+	key string
+	// source is where the value comes from: reflection.BindingSourceHeader or reflection.BindingSourceCookie.
+	source string
+	// name is the name of the header/cookie (e.g. "X-Tenant-ID").
+	name string
+}
+
+// bindingField describes how to set the value for a single parameter key.
+type bindingField struct {
+	// index is the path of field indices from the root struct to the field we're setting. This works
+	// just like reflect.Value.FieldByIndex() except that we allocate nil pointers along the way.
+	index []int
+	// kind indicates how we convert the raw parameter value into the field's Go value.
+	kind bindingKind
+	// bitSize is the size of the int/uint/float so that strconv enforces overflow rules for us.
+	bitSize int
+	// parse is the registered parser for bindingKindScalar fields (e.g. time.Duration).
+	parse ScalarParser
+	// typ is the field's type w/ any pointers flattened, so binding JSON values doesn't need to go find it.
+	typ reflect.Type
+	// provided is the normalized name (see providedKey) that we record when the caller supplies this field.
+	provided string
+	// depth is how many embedded (anonymous) structs deep the field is within its parent, so that fields of
+	// the parent shadow the same fields of the structs it embeds (see bindingPlan.register).
+	depth int
+	// name is the field's exact binding name (e.g. "ID" or "firstName") before we lower-cased it for the key.
+	name string
+	// tagged indicates that the field's binding name comes from its `json` tag rather than the Go field name.
+	tagged bool
+}
+
+// bindingKind indicates how we should parse/apply a parameter value to a field.
+type bindingKind int
+
+const (
+	// bindingKindSkip fields are things like structs, slices, and maps that a single parameter value can't bind.
+	bindingKindSkip = bindingKind(0)
+	// bindingKindJSON fields have a custom UnmarshalJSON() (or are nested in a type that does), so we
+	// need to let the slower JSON binding logic handle them to respect that behavior.
+	bindingKindJSON   = bindingKind(1)
+	bindingKindString = bindingKind(2)
+	bindingKindInt    = bindingKind(3)
+	bindingKindUint   = bindingKind(4)
+	bindingKindFloat  = bindingKind(5)
+	bindingKindBool   = bindingKind(6)
+	// bindingKindScalar fields have a ScalarParser registered for their type (see RegisterScalar).
+	bindingKindScalar = bindingKind(7)
+	// bindingKindText fields are structs/arrays/slices like uuid.UUID, big.Int, or net.IP that implement
+	// encoding.TextUnmarshaler, so a single parameter value is enough to bind them.
+	bindingKindText = bindingKind(8)
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// bindingPlanFor returns the (cached) binding plan for the given type. You can pass either the
+// struct type or a pointer to it. The first time we see a type, we walk all of its fields so that
+// every request after that only needs to do a map lookup to figure out where each value goes.
+func bindingPlanFor(outType reflect.Type) *bindingPlan {
+	if plan, ok := bindingPlans.Load(outType); ok {
+		return plan.(*bindingPlan)
+	}
+
+	plan := &bindingPlan{fields: map[string]bindingField{}}
+	structType := reflection.FlattenPointerType(outType)
+	if structType.Kind() == reflect.Struct {
+		visiting := map[reflect.Type]bool{}
+		plan.addFields(structType, "", nil, 0, isUnmarshaler(structType), visiting)
+		plan.optional = plan.resolveOptional()
+		plan.defaultFields = plan.resolveDefaults(nil)
+	}
+
+	actual, _ := bindingPlans.LoadOrStore(outType, plan)
+	return actual.(*bindingPlan)
+}
+
+// addFields recursively adds an entry for every field on the struct type (and the fields of nested
+// structs) so that the key "foo.bar.baz" maps to the field we'd find by following Foo, then Bar, then Baz.
+// The 'viaJSON' flag indicates that some parent type had custom unmarshaling logic, so every field
+// underneath it needs to be bound using JSON, too. The 'depth' is how many embedded structs deep we are within
+// the struct whose fields the 'prefix' refers to.
+func (plan *bindingPlan) addFields(structType reflect.Type, prefix string, index []int, depth int, viaJSON bool, visiting map[reflect.Type]bool) {
+	// Recursive types (e.g. a tree node w/ a *Node field) would otherwise result in an infinite plan.
+	if visiting[structType] {
+		plan.recursive = true
+		return
+	}
+	visiting[structType] = true
+	defer delete(visiting, structType)
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		fieldIndex := append(append([]int{}, index...), i)
+		fieldType := reflection.FlattenPointerType(field.Type)
+
+		// Just like the standard library, the fields of embedded structs are treated as though they're
+		// fields on the parent, so you bind "ID" rather than "EmbeddedID.ID".
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			plan.addFields(fieldType, prefix, fieldIndex, depth+1, viaJSON, visiting)
+			continue
+		}
+		// Embedded pointers to structs have never been supported by the binder, so we still ignore them.
+		if field.Anonymous && field.Type.Kind() == reflect.Ptr {
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		key := prefix + strings.ToLower(reflection.BindingName(field))
+		fieldViaJSON := viaJSON || isUnmarshaler(fieldType)
+
+		if value, ok := field.Tag.Lookup("default"); ok && prefix == "" {
+			if plan.defaults == nil {
+				plan.defaults = map[string]string{}
+			}
+			plan.defaults[reflection.BindingName(field)] = value
+		}
+
+		// Header/cookie fields are only supported on the top-level request (or structs embedded in it).
+		if source, name := reflection.BindingSource(field.Tag, reflection.BindingName(field)); source != "" && prefix == "" {
+			plan.sources = append(plan.sources, bindingSourceField{
+				bindingField: newBindingField(fieldType, fieldIndex, fieldViaJSON, reflection.BindingName(field)),
+				key:          reflection.BindingName(field),
+				source:       source,
+				name:         name,
+			})
+			continue
+		}
+		if field.Tag.Get("json") == "-" {
+			continue
+		}
+		if field.Type.Kind() == reflect.Ptr && prefix == "" {
+			plan.optional = append(plan.optional, bindingOptionalField{
+				bindingField: bindingField{index: fieldIndex},
+				key:          reflection.BindingName(field),
+			})
+		}
+
+		bindingField := newBindingField(fieldType, fieldIndex, fieldViaJSON, key)
+		bindingField.depth = depth
+		bindingField.name = reflection.BindingName(field)
+		bindingField.tagged = !strings.HasPrefix(field.Tag.Get("json"), ",") && field.Tag.Get("json") != ""
+		if !plan.register(key, bindingField) {
+			continue
+		}
+		if fieldType.Kind() == reflect.Struct && !isScalar(fieldType) {
+			plan.addFields(fieldType, key+".", fieldIndex, 0, fieldViaJSON, visiting)
+		}
+	}
+}
+
+// register adds the field to the plan unless another field already owns the key. We resolve conflicts the same
+// way that encoding/json does, so params and the body always bind the same field: the shallowest field wins,
+// even if we found a deeper one in an embedded struct first. When two fields are equally shallow, the one
+// w/ a `json` tag wins; if that doesn't settle it, neither one is bound. Fields whose names only differ by
+// case (e.g. "ID" and "Id") aren't a conflict, so the one we found first is the one that case-insensitive
+// lookups find. It returns false when the field didn't win, so there's no need to add its nested fields.
+func (plan *bindingPlan) register(key string, field bindingField) bool {
+	if depth, ok := plan.ambiguous[key]; ok && depth <= field.depth {
+		return false
+	}
+
+	existing, ok := plan.fields[key]
+	switch {
+	case !ok:
+	case field.depth > existing.depth:
+		return false
+	case field.depth < existing.depth:
+		plan.remove(key)
+	case field.name != existing.name:
+		return false
+	case field.tagged && !existing.tagged:
+		plan.remove(key)
+	case existing.tagged && !field.tagged:
+		return false
+	default:
+		plan.remove(key)
+		if plan.ambiguous == nil {
+			plan.ambiguous = map[string]int{}
+		}
+		plan.ambiguous[key] = field.depth
+		return false
+	}
+	delete(plan.ambiguous, key)
+	plan.fields[key] = field
+	return true
+}
+
+// remove takes the key out of the plan along w/ all of the nested keys underneath it (e.g. "criteria.limit" when
+// removing "criteria"), since they belong to a field that lost to another one.
+func (plan *bindingPlan) remove(key string) {
+	delete(plan.fields, key)
+	for nested := range plan.fields {
+		if strings.HasPrefix(nested, key+".") {
+			delete(plan.fields, nested)
+		}
+	}
+	for nested := range plan.ambiguous {
+		if strings.HasPrefix(nested, key+".") {
+			delete(plan.ambiguous, nested)
+		}
+	}
+}
+
+// resolveOptional drops the optional fields that lost their key to another field (see register), so the client
+// doesn't leave the winning field out of the body just because a field that it shadows is nil.
+func (plan *bindingPlan) resolveOptional() []bindingOptionalField {
+	var optional []bindingOptionalField
+	for _, field := range plan.optional {
+		if winner, ok := plan.fields[strings.ToLower(field.key)]; ok && reflect.DeepEqual(winner.index, field.index) {
+			optional = append(optional, field)
+		}
+	}
+	return optional
+}
+
+// newBindingField creates the plan entry for a field of the given type at the given index path.
+func newBindingField(fieldType reflect.Type, index []int, viaJSON bool, key string) bindingField {
+	field := bindingField{
+		index:    index,
+		kind:     toBindingKind(fieldType, viaJSON),
+		typ:      fieldType,
+		provided: providedKey(key),
+	}
+	switch field.kind {
+	case bindingKindInt, bindingKindUint, bindingKindFloat:
+		field.bitSize = fieldType.Bits()
+	case bindingKindScalar:
+		field.parse, _ = scalarParser(fieldType)
+	}
+	return field
+}
+
+// toBindingKind determines how we should apply a raw parameter value to a field of this type.
+func toBindingKind(fieldType reflect.Type, viaJSON bool) bindingKind {
+	if _, ok := scalarParser(fieldType); ok {
+		return bindingKindScalar
+	}
+	switch fieldType.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array:
+		// Binding "foo=4" to a struct/slice isn't something we support unless the type knows how to parse text.
+		if reflect.PtrTo(fieldType).Implements(textUnmarshalerType) {
+			return bindingKindText
+		}
+		return bindingKindSkip
+	case reflect.Map:
+		return bindingKindSkip
+	}
+	if viaJSON {
+		return bindingKindJSON
+	}
+
+	switch fieldType.Kind() {
+	case reflect.String:
+		return bindingKindString
+	case reflect.Bool:
+		return bindingKindBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return bindingKindInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return bindingKindUint
+	case reflect.Float32, reflect.Float64:
+		return bindingKindFloat
+	default:
+		return bindingKindSkip
+	}
+}
+
+// isUnmarshaler determines if the type (or a pointer to it) has custom JSON unmarshaling behavior.
+func isUnmarshaler(t reflect.Type) bool {
+	ptrType := reflect.PtrTo(t)
+	return t.Implements(jsonUnmarshalerType) || ptrType.Implements(jsonUnmarshalerType) ||
+		t.Implements(textUnmarshalerType) || ptrType.Implements(textUnmarshalerType)
+}
+
+// isScalar determines if a single parameter value binds the entire type (e.g. time.Time or big.Int), so
+// the plan shouldn't bother looking at its fields.
+func isScalar(t reflect.Type) bool {
+	kind := toBindingKind(t, false)
+	return kind == bindingKindScalar || kind == bindingKindText
+}
+
+// lookup finds the binding info for the given parameter key (e.g. "Criteria.Limit"). This runs for every
+// param on every request, so we lower-case ASCII keys into a stack buffer rather than allocating a new
+// string; the compiler doesn't allocate for the string(bytes) conversion when it's only used as a map key.
+func (plan *bindingPlan) lookup(key string) (bindingField, bool) {
+	var buf [64]byte
+	if len(key) > len(buf) {
+		field, ok := plan.fields[strings.ToLower(key)]
+		return field, ok
+	}
+
+	lower := buf[:len(key)]
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= utf8RuneSelf:
+			field, ok := plan.fields[strings.ToLower(key)]
+			return field, ok
+		case 'A' <= c && c <= 'Z':
+			c += 'a' - 'A'
+		}
+		lower[i] = c
+	}
+	field, ok := plan.fields[string(lower)]
+	return field, ok
+}
+
+// utf8RuneSelf is the smallest byte value that isn't plain ASCII (same as utf8.RuneSelf).
+const utf8RuneSelf = 0x80
+
+// lookupAny finds the binding info for the given field, including fields bound from headers/cookies which
+// lookup() ignores. Since this is for our own config rather than caller-supplied params, "first_name"
+// can find the field "FirstName" regardless of the gateway's JSON settings.
+func (plan *bindingPlan) lookupAny(key string) (bindingField, bool) {
+	if field, ok := plan.lookup(key); ok {
+		return field, true
+	}
+	for _, field := range plan.sources {
+		if strings.EqualFold(field.key, key) {
+			return field.bindingField, true
+		}
+	}
+	if strings.Contains(key, "_") {
+		return plan.lookupAny(strings.ReplaceAll(key, "_", ""))
+	}
+	return bindingField{}, false
+}
+
+// resolveDefaults looks up the fields for the `default` tag values as well as any overrides (e.g. the
+// endpoint's "DEFAULT" doc options), which take precedence. Defaults for fields that don't exist are ignored.
+func (plan *bindingPlan) resolveDefaults(overrides map[string]string) []bindingDefault {
+	values := map[string]string{}
+	for key, value := range plan.defaults {
+		values[key] = value
+	}
+	for key, value := range overrides {
+		values[key] = value
+	}
+
+	var defaults []bindingDefault
+	for key, value := range values {
+		if field, ok := plan.lookupAny(key); ok {
+			defaults = append(defaults, bindingDefault{bindingField: field, key: key, value: value})
+		}
+	}
+	// Applying them in a consistent order makes failures (e.g. a bad default value) consistent, too.
+	sort.Slice(defaults, func(i, j int) bool { return defaults[i].key < defaults[j].key })
+	return defaults
+}
+
+// endpointBinding is the binding info for a single endpoint, so that every request to it can share the work of
+// compiling it. The gateway gives each endpoint its own when you register it. We don't know the request type
+// until something binds it, so we compile it on the first request and reuse it for every one after that.
+type endpointBinding struct {
+	compiled atomic.Value
+}
+
+// compiledBinding is everything the binder needs to bind the endpoint's request type.
+type compiledBinding struct {
+	// outType is the type of value we compiled this for (e.g. *SearchRequest).
+	outType reflect.Type
+	// plan is the cached binding plan for 'outType'.
+	plan *bindingPlan
+	// defaults are the `default` tag values and the endpoint's Defaults w/ their fields already looked up.
+	defaults []bindingDefault
+}
+
+// compile returns the endpoint's binding info for the given request type, using the one we compiled on the first
+// request when possible. Handlers that bind some other type (or endpoints that were never registered) still work;
+// they just have to compile it every time.
+func (binding *endpointBinding) compile(outType reflect.Type, defaults map[string]string) *compiledBinding {
+	if binding != nil {
+		if compiled, ok := binding.compiled.Load().(*compiledBinding); ok && compiled.outType == outType {
+			return compiled
+		}
+	}
+
+	plan := bindingPlanFor(outType)
+	compiled := &compiledBinding{outType: outType, plan: plan, defaults: plan.defaultFields}
+	if len(defaults) > 0 {
+		compiled.defaults = plan.resolveDefaults(defaults)
+	}
+	if binding != nil && binding.compiled.Load() == nil {
+		binding.compiled.Store(compiled)
+	}
+	return compiled
+}
+
+// set parses the raw parameter value and assigns it to the field on the 'out' struct value. Any nil
+// pointers along the way are allocated so that "CriteriaPtr.Limit=5" works even if CriteriaPtr is nil.
+func (field bindingField) set(outValue reflect.Value, value string) error {
+	fieldValue := field.settable(outValue)
+
+	switch field.kind {
+	case bindingKindString:
+		fieldValue.SetString(value)
+	case bindingKindBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean: %w", err)
+		}
+		fieldValue.SetBool(b)
+	case bindingKindInt:
+		n, err := strconv.ParseInt(value, 10, field.bitSize)
+		if err != nil {
+			return fmt.Errorf("invalid integer: %w", err)
+		}
+		fieldValue.SetInt(n)
+	case bindingKindUint:
+		n, err := strconv.ParseUint(value, 10, field.bitSize)
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer: %w", err)
+		}
+		fieldValue.SetUint(n)
+	case bindingKindFloat:
+		n, err := strconv.ParseFloat(value, field.bitSize)
+		if err != nil {
+			return fmt.Errorf("invalid number: %w", err)
+		}
+		fieldValue.SetFloat(n)
+	case bindingKindScalar:
+		return field.setScalar(fieldValue, value)
+	case bindingKindText:
+		return fieldValue.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	return nil
+}
+
+// setScalar runs the field's registered ScalarParser and assigns the result to the field value.
+func (field bindingField) setScalar(fieldValue reflect.Value, value string) error {
+	parsed, err := field.parse(value)
+	if err != nil {
+		return err
+	}
+
+	parsedValue := reflect.Indirect(reflect.ValueOf(parsed))
+	switch {
+	case !parsedValue.IsValid():
+		fieldValue.Set(reflect.Zero(fieldValue.Type()))
+	case parsedValue.Type().AssignableTo(fieldValue.Type()):
+		fieldValue.Set(parsedValue)
+	case parsedValue.Type().ConvertibleTo(fieldValue.Type()):
+		fieldValue.Set(parsedValue.Convert(fieldValue.Type()))
+	default:
+		return fmt.Errorf("scalar parser returned %v, not %v", parsedValue.Type(), fieldValue.Type())
+	}
+	return nil
+}
+
+// settable follows the field's index path on the 'out' struct value, allocating any nil pointers along the
+// way, and returns the (non-pointer) value we should set.
+func (field bindingField) settable(outValue reflect.Value) reflect.Value {
+	fieldValue := outValue
+	for _, i := range field.index {
+		fieldValue = field.deref(fieldValue).Field(i)
+	}
+	return field.deref(fieldValue)
+}
+
+// get returns the field's current value on the 'out' struct value. If there's a nil pointer along
+// the way, you'll get back an invalid value, so check IsValid() before doing anything with it.
+func (field bindingField) get(outValue reflect.Value) reflect.Value {
+	fieldValue := outValue
+	for _, i := range field.index {
+		fieldValue = reflect.Indirect(fieldValue)
+		if !fieldValue.IsValid() {
+			return fieldValue
+		}
+		fieldValue = fieldValue.Field(i)
+	}
+	return fieldValue
+}
+
+// clear resets the field on the 'out' struct value back to its zero value.
+func (field bindingField) clear(outValue reflect.Value) {
+	if fieldValue := field.get(outValue); fieldValue.IsValid() {
+		fieldValue.Set(reflect.Zero(fieldValue.Type()))
+	}
+}
+
+// deref follows pointers until we get to an actual value, allocating any pointers that are nil.
+func (field bindingField) deref(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+	return value
+}
@@ -1,18 +1,28 @@
+//go:build unit
 // +build unit
 
 package rpc_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
+	"math/big"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/dimfeld/httptreemux/v5"
 	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/errors"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -74,8 +84,8 @@ func (suite *BindingSuite) TestBind_pathParameters() {
 		"Criteria.Limit":             "15",
 		"Criteria.Text":              "test",
 		"Criteria.audit.CreatedBy":   "Bob",
-		"Criteria.audit.created":     "2020-02-20T01:02:03Z05:00",
-		"Criteria.audit.CreatedDate": "1984-01-01T01:02:03Z05:00", // should ignore this one for remapped "created"
+		"Criteria.audit.created":     "2020-02-20T01:02:03+05:00",
+		"Criteria.audit.CreatedDate": "1984-01-01T01:02:03+05:00", // should ignore this one for remapped "created"
 
 		"CriteriaPtr.Limit":                "54",
 		"CriteriaPtr.audit.CreatedBy":      "Dude",
@@ -104,7 +114,7 @@ func (suite *BindingSuite) TestBind_pathParameters() {
 	suite.Require().Equal(15, result.Criteria.Limit)
 	suite.Require().Equal("test", result.Criteria.Text)
 	suite.Require().Equal("Bob", result.Criteria.AuditTrail.CreatedBy)
-	suite.Require().Equal(parseTime("2020-02-20T01:02:03Z05:00"), result.Criteria.AuditTrail.CreatedDate)
+	suite.Require().Equal(parseTime("2020-02-20T01:02:03+05:00"), result.Criteria.AuditTrail.CreatedDate)
 
 	suite.Require().Equal(aliasBasic("moo"), result.AliasBasic)
 	suite.Require().Equal(88, result.AliasComplex.Offset)
@@ -148,8 +158,8 @@ func (suite *BindingSuite) TestBind_queryString() {
 		"Criteria.Limit":             "15",
 		"Criteria.Text":              "test",
 		"Criteria.audit.CreatedBy":   "Bob",
-		"Criteria.audit.created":     "2020-02-20T01:02:03Z05:00",
-		"Criteria.audit.CreatedDate": "1984-01-01T01:02:03Z05:00", // should ignore this one for remapped "created"
+		"Criteria.audit.created":     "2020-02-20T01:02:03+05:00",
+		"Criteria.audit.CreatedDate": "1984-01-01T01:02:03+05:00", // should ignore this one for remapped "created"
 
 		"CriteriaPtr.Limit":                "54",
 		"CriteriaPtr.audit.CreatedBy":      "Dude",
@@ -178,7 +188,7 @@ func (suite *BindingSuite) TestBind_queryString() {
 	suite.Require().Equal(15, result.Criteria.Limit)
 	suite.Require().Equal("test", result.Criteria.Text)
 	suite.Require().Equal("Bob", result.Criteria.AuditTrail.CreatedBy)
-	suite.Require().Equal(parseTime("2020-02-20T01:02:03Z05:00"), result.Criteria.AuditTrail.CreatedDate)
+	suite.Require().Equal(parseTime("2020-02-20T01:02:03+05:00"), result.Criteria.AuditTrail.CreatedDate)
 
 	suite.Require().Equal(aliasBasic("moo"), result.AliasBasic)
 	suite.Require().Equal(88, result.AliasComplex.Offset)
@@ -227,6 +237,126 @@ func (suite *BindingSuite) TestBind_body() {
 	suite.Equal(12345, result.Int, "PATCH request: body should bind JSON properly")
 }
 
+// Ensures that fields tagged w/ `frodo:"header=..."` or `frodo:"cookie=..."` are ONLY bound from
+// the header/cookie, not the body, path, or query string.
+func (suite *BindingSuite) TestBind_headersAndCookies() {
+	bind := func(req *http.Request) (sourceBindingRequest, error) {
+		result := sourceBindingRequest{}
+		err := rpc.NewGateway().Binder.Bind(req, &result)
+		return result, err
+	}
+
+	req := suite.newRequest("POST", `{"Name": "body", "TenantID": "body", "Session": "body"}`,
+		bindingValues{"TenantID": "query", "Version": "1"},
+		bindingValues{"Session": "path"})
+	req.Header = http.Header{}
+	req.Header.Set("X-Tenant-ID", "header")
+	req.Header.Set("X-Version", "42")
+	req.Header.Set("X-Level", "high")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "cookie"})
+
+	result, err := bind(req)
+	suite.Require().NoError(err)
+	suite.Equal("body", result.Name)
+	suite.Equal("header", result.TenantID)
+	suite.Equal("cookie", result.Session)
+	suite.Require().NotNil(result.Version)
+	suite.Equal(42, *result.Version)
+	suite.Equal(textLevel(3), result.Level, "Should support custom unmarshaling")
+	suite.Equal("", result.Secret, "Should default to the field name when the tag doesn't have one")
+
+	req = suite.newRequest("POST", `{"TenantID": "body", "Session": "body"}`, noQuery, noPathParams)
+	result, err = bind(req)
+	suite.Require().NoError(err)
+	suite.Equal("", result.TenantID, "Should not bind from the body when the header is missing")
+	suite.Equal("", result.Session, "Should not bind from the body when the cookie is missing")
+
+	req = suite.newRequest("GET", noBody, noQuery, noPathParams)
+	req.Header = http.Header{"Secret": []string{"shh"}}
+	result, err = bind(req)
+	suite.Require().NoError(err)
+	suite.Equal("shh", result.Secret)
+
+	req = suite.newRequest("GET", noBody, noQuery, noPathParams)
+	req.Header = http.Header{"X-Version": []string{"abc"}}
+	_, err = bind(req)
+	suite.Require().Error(err)
+	suite.Equal(400, errors.Status(err), "Bad header values should be a 400")
+}
+
+// Ensures that URL-encoded form bodies bind using the same rules as query string parameters.
+func (suite *BindingSuite) TestBind_form() {
+	form := url.Values{}
+	form.Set("String", "foo")
+	form.Set("Int", "-5")
+	form.Set("Criteria.Limit", "10")
+	form.Set("criteria.audit.createdby", "Bob")
+	form.Set("AliasDuration", "1m")
+
+	req := suite.newRequest("POST", form.Encode(), bindingValues{"Uint": "4", "Int": "4"}, bindingValues{"String": "bar"})
+	req.Header = http.Header{"Content-Type": []string{"application/x-www-form-urlencoded"}}
+	result, err := suite.bind(req)
+	suite.Require().NoError(err)
+	suite.Equal("bar", result.String, "Path params should override the form")
+	suite.Equal(-5, result.Int, "Form should override the query string")
+	suite.Equal(uint(4), result.Uint, "Query should be bound when not present in the form")
+	suite.Equal(10, result.Criteria.Limit)
+	suite.Equal("Bob", result.Criteria.AuditTrail.CreatedBy)
+	suite.Equal(aliasDuration(time.Minute), result.AliasDuration)
+
+	req = suite.newRequest("GET", form.Encode(), noQuery, noPathParams)
+	req.Header = http.Header{"Content-Type": []string{"application/x-www-form-urlencoded"}}
+	result, err = suite.bind(req)
+	suite.Require().NoError(err)
+	suite.Equal(serviceRequest{}, result, "GET request: should not bind form")
+
+	req = suite.newRequest("POST", "Int=abc", noQuery, noPathParams)
+	req.Header = http.Header{"Content-Type": []string{"application/x-www-form-urlencoded; charset=utf-8"}}
+	_, err = suite.bind(req)
+	suite.Require().Error(err)
+	suite.Equal(400, errors.Status(err), "Bad form values should be a 400")
+
+	req = suite.newRequest("POST", "Int=%zz", noQuery, noPathParams)
+	req.Header = http.Header{"Content-Type": []string{"application/x-www-form-urlencoded"}}
+	_, err = suite.bind(req)
+	suite.Require().Error(err)
+	suite.Equal(400, errors.Status(err), "Malformed forms should be a 400")
+
+	// Make sure that 'curl -d' still works w/ JSON even though it claims to be sending a form.
+	req = suite.newRequest("POST", `  {"String": "json", "Int": 5}`, noQuery, noPathParams)
+	req.Header = http.Header{"Content-Type": []string{"application/x-www-form-urlencoded"}}
+	result, err = suite.bind(req)
+	suite.Require().NoError(err)
+	suite.Equal("json", result.String, "Should bind JSON bodies mislabeled as forms")
+	suite.Equal(5, result.Int, "Should bind JSON bodies mislabeled as forms")
+}
+
+// Ensures that multipart form bodies bind their field values, ignoring any files.
+func (suite *BindingSuite) TestBind_multipartForm() {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	_ = writer.WriteField("String", "foo")
+	_ = writer.WriteField("CriteriaPtr.Offset", "20")
+	file, _ := writer.CreateFormFile("StringPtr", "hello.txt")
+	_, _ = file.Write([]byte("file contents"))
+	_ = writer.Close()
+
+	req := suite.newRequest("PUT", body.String(), noQuery, noPathParams)
+	req.Header = http.Header{"Content-Type": []string{writer.FormDataContentType()}}
+	result, err := suite.bind(req)
+	suite.Require().NoError(err)
+	suite.Equal("foo", result.String)
+	suite.Require().NotNil(result.CriteriaPtr)
+	suite.Equal(20, result.CriteriaPtr.Offset)
+	suite.Nil(result.StringPtr, "Should not bind file parts")
+
+	req = suite.newRequest("PUT", "not multipart", noQuery, noPathParams)
+	req.Header = http.Header{"Content-Type": []string{"multipart/form-data; boundary=xyz"}}
+	_, err = suite.bind(req)
+	suite.Require().Error(err)
+	suite.Equal(400, errors.Status(err))
+}
+
 // Make sure that if the same value appears in the body, query string, and path that path always wins, body is next,
 // and query string is least "sticky" of the values.
 func (suite *BindingSuite) TestBind_bindingOrder() {
@@ -282,6 +412,359 @@ func (suite *BindingSuite) TestBind_errors() {
 	suite.Error(err, "Should return an error when URL is nil")
 }
 
+// Make sure that body decoding failures are 400s that tell the caller where the body went wrong. The exact
+// offset of a bad value varies a bit between Go versions, so we only pin it down for syntax errors.
+func (suite *BindingSuite) TestBind_bodyErrors() {
+	bindBody := func(body string) (string, map[string]interface{}) {
+		req := suite.newRequest("POST", body, noQuery, noPathParams)
+		_, err := suite.bind(req)
+		suite.Require().Error(err, "Body should fail: %s", body)
+		suite.Require().Equal(400, errors.Status(err), "Body should fail w/ a 400: %s", body)
+		suite.Require().Contains(errors.Details(err), "offset", "Details should include the offset: %s", body)
+		return err.Error(), errors.Details(err)
+	}
+
+	message, details := bindBody(`{"String": ?}`)
+	suite.Equal("error binding body: invalid JSON at offset 12: invalid character '?' looking for beginning of value", message)
+	suite.Equal(map[string]interface{}{"offset": int64(12)}, details)
+
+	message, details = bindBody(`{"String": "foo", "Criteria": {"Limit": "ten"}}`)
+	suite.Contains(message, "invalid value for 'Criteria.Limit' at offset")
+	suite.Contains(message, "expected int, got string")
+	suite.Equal("Criteria.Limit", details["path"])
+	suite.Equal("int", details["expected"])
+
+	message, details = bindBody(`["foo"]`)
+	suite.Contains(message, "expected rpc_test.serviceRequest, got array")
+	suite.NotContains(details, "path")
+
+	message, details = bindBody(`{"String": "foo", "Int": 12`)
+	suite.Equal("error binding body: invalid JSON at offset 27: unexpected end of body", message)
+	suite.Equal(map[string]interface{}{"offset": int64(27)}, details)
+
+	message, _ = bindBody(`{"AliasDuration": "forever"}`)
+	suite.Contains(message, `time: invalid duration "forever"`)
+}
+
+// Ensures that strict binding rejects bodies w/ unknown attributes and reports all of them.
+func (suite *BindingSuite) TestBind_strict() {
+	bindBody := func(gw rpc.Gateway, body string) (int, string, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/Some.Function", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+
+		response := struct {
+			Message string
+			Details map[string]interface{}
+		}{}
+		_ = json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response.Message, response.Details
+	}
+	newGateway := func(strictEndpoint bool, options ...rpc.GatewayOption) rpc.Gateway {
+		gw := rpc.NewGateway(options...)
+		gw.Register(rpc.Endpoint{
+			Method:      "POST",
+			Path:        "/Some.Function",
+			ServiceName: "Some",
+			Name:        "Function",
+			Strict:      strictEndpoint,
+			Handler: func(w http.ResponseWriter, req *http.Request) {
+				value := serviceRequest{}
+				if err := gw.Binder.Bind(req, &value); err != nil {
+					rpc.Fail(w, req, err)
+					return
+				}
+				rpc.Reply(w, req, 200, map[string]string{"String": value.String})
+			},
+		})
+		return gw
+	}
+
+	// Unknown attributes are silently ignored by default.
+	gw := newGateway(false)
+	status, _, _ := bindBody(gw, `{"String":"foo","Strnig":"bar"}`)
+	suite.Equal(200, status)
+
+	gw = newGateway(false, rpc.WithStrictBinding())
+	status, _, _ = bindBody(gw, `{"string":"foo","remapped_int":5,"ID":"1","MoreID":"2","Criteria":{"audit":{"created":"2020-01-01T00:00:00Z"}},"StringMap":{"a":"b"}}`)
+	suite.Equal(200, status, "Known, embedded, and remapped fields should be fine")
+
+	status, message, details := bindBody(gw, `{"Strnig":"bar","String":"foo","Criteria":{"Limt":5,"audit":{"CreatedBy":"me","Who":"you"}},"RemappedString":"x"}`)
+	suite.Equal(400, status)
+	suite.Equal("error binding body: unknown fields in request body: Strnig, Criteria.Limt, Criteria.audit.Who, RemappedString", message)
+	suite.Equal([]interface{}{"Strnig", "Criteria.Limt", "Criteria.audit.Who", "RemappedString"}, details["fields"])
+
+	status, message, _ = bindBody(gw, `{"String": ?}`)
+	suite.Equal(400, status)
+	suite.Contains(message, "invalid JSON at offset 12", "Syntax errors should still include the offset")
+
+	// The STRICT doc option turns it on for just that endpoint.
+	gw = newGateway(true)
+	status, message, _ = bindBody(gw, `{"String":"foo","Strnig":"bar"}`)
+	suite.Equal(400, status)
+	suite.Contains(message, "unknown fields in request body: Strnig")
+
+	// It should understand the attributes used by your JSON settings, too.
+	gw = newGateway(false, rpc.WithStrictBinding(), rpc.WithJSON(rpc.JSONSnakeCase()))
+	status, _, _ = bindBody(gw, `{"string":"foo","alias_basic":"x","criteria":{"limit":5},"remapped_int":5}`)
+	suite.Equal(200, status)
+	status, message, _ = bindBody(gw, `{"string":"foo","criteria":{"limt":5}}`)
+	suite.Equal(400, status)
+	suite.Contains(message, "unknown fields in request body: criteria.limt")
+}
+
+// Ensures that the binder applies `default` tags and the endpoint's defaults to fields that are still zero.
+func (suite *BindingSuite) TestBind_defaults() {
+	bind := func(defaults map[string]string, path string, body string) (int, defaultsBindingRequest) {
+		gw := rpc.NewGateway()
+		result := defaultsBindingRequest{}
+		gw.Register(rpc.Endpoint{
+			Method:      "POST",
+			Path:        "/Some.Function",
+			ServiceName: "Some",
+			Name:        "Function",
+			Defaults:    defaults,
+			Handler: func(w http.ResponseWriter, req *http.Request) {
+				if err := gw.Binder.Bind(req, &result); err != nil {
+					rpc.Fail(w, req, err)
+					return
+				}
+				rpc.Reply(w, req, 200, result)
+			},
+		})
+
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+		return w.Code, result
+	}
+
+	status, result := bind(nil, "/Some.Function", `{}`)
+	suite.Require().Equal(200, status)
+	suite.Equal(25, result.Limit)
+	suite.Equal("name", result.SortBy)
+	suite.Equal(5*time.Second, result.Timeout)
+	suite.Require().NotNil(result.Page)
+	suite.Equal(1, *result.Page)
+	suite.Equal("acme", result.TenantID)
+	suite.Equal("", result.Text, "Should leave fields w/o defaults alone")
+
+	status, result = bind(nil, "/Some.Function?Limit=10", `{"sort":"date", "Page":0}`)
+	suite.Require().Equal(200, status)
+	suite.Equal(10, result.Limit, "Should not replace values that the caller provided")
+	suite.Equal("date", result.SortBy)
+	suite.Require().NotNil(result.Page)
+	suite.Equal(0, *result.Page, "Should allow explicit zeros for pointer fields")
+
+	status, result = bind(map[string]string{"Limit": "50", "Text": "hello", "sort": "size"}, "/Some.Function", `{}`)
+	suite.Require().Equal(200, status)
+	suite.Equal(50, result.Limit, "Endpoint defaults should take precedence over tags")
+	suite.Equal("hello", result.Text)
+	suite.Equal("size", result.SortBy)
+	suite.Equal(5*time.Second, result.Timeout)
+
+	status, _ = bind(map[string]string{"Limit": "lots"}, "/Some.Function", `{}`)
+	suite.Equal(500, status, "Invalid defaults are the service's fault, not the caller's")
+}
+
+// Ensures that the binding info we compile for an endpoint on its first request doesn't leak values between
+// requests, still works if the handler binds some other type, and is recompiled when you re-register it.
+func (suite *BindingSuite) TestBind_endpointBinding() {
+	type otherRequest struct {
+		Limit int `default:"3"`
+		Text  string
+	}
+	intPtr := func(value int) *int { return &value }
+
+	gw := rpc.NewGateway()
+	register := func(defaults map[string]string) {
+		gw.Register(rpc.Endpoint{
+			Method:      "POST",
+			Path:        "/Some.Function",
+			ServiceName: "Some",
+			Name:        "Function",
+			Defaults:    defaults,
+			Handler: func(w http.ResponseWriter, req *http.Request) {
+				result := defaultsBindingRequest{}
+				if req.URL.Query().Get("other") != "" {
+					other := otherRequest{}
+					_ = gw.Binder.Bind(req, &other)
+					result.Limit, result.Text = other.Limit, other.Text
+				} else if err := gw.Binder.Bind(req, &result); err != nil {
+					rpc.Fail(w, req, err)
+					return
+				}
+				rpc.Reply(w, req, 200, result)
+			},
+		})
+	}
+	bind := func(path string, body string) defaultsBindingRequest {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+		suite.Require().Equal(200, w.Code, w.Body.String())
+
+		result := defaultsBindingRequest{}
+		suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &result))
+		return result
+	}
+
+	register(map[string]string{"Text": "hello"})
+	suite.Equal(defaultsBindingRequest{Limit: 5, SortBy: "name", Timeout: 5 * time.Second, Page: intPtr(1), TenantID: "acme", Text: "hello"},
+		bind("/Some.Function?Limit=5", `{}`))
+	suite.Equal(defaultsBindingRequest{Limit: 25, SortBy: "date", Timeout: 5 * time.Second, Page: intPtr(2), TenantID: "acme", Text: "hello"},
+		bind("/Some.Function", `{"sort":"date", "Page":2}`))
+	suite.Equal(defaultsBindingRequest{Limit: 3, Text: "hello"},
+		bind("/Some.Function?other=true", `{}`), "Should handle types other than the one we compiled")
+
+	register(map[string]string{"Text": "goodbye", "Limit": "10"})
+	suite.Equal(defaultsBindingRequest{Limit: 10, SortBy: "name", Timeout: 5 * time.Second, Page: intPtr(1), TenantID: "acme", Text: "goodbye"},
+		bind("/Some.Function", `{}`), "Should use the new defaults after re-registering")
+}
+
+// Ensures that primitive values we set directly (w/o generating JSON) follow the same rules as the JSON
+// binding: case-insensitive keys, nil pointers are allocated, and bad values fail.
+func (suite *BindingSuite) TestBind_primitives() {
+	req := suite.newRequest("GET", noBody, bindingValues{
+		"string":            `say "hi" \o/`,
+		"STRINGPTR":         "foo",
+		"int":               "-42",
+		"Float64":           "-1.5",
+		"criteriaptr.LIMIT": "5",
+		"bool":              "false",
+		"NotAField":         "ignore",
+		"Int.Nope":          "ignore",
+	}, noPathParams)
+	result, err := suite.bind(req)
+	suite.Require().NoError(err)
+	suite.Equal(`say "hi" \o/`, result.String, "Should set strings exactly as-is")
+	suite.Require().NotNil(result.StringPtr, "Should allocate nil pointers")
+	suite.Equal("foo", *result.StringPtr)
+	suite.Equal(-42, result.Int, "Should support negative numbers")
+	suite.Equal(-1.5, result.Float64, "Should support negative numbers")
+	suite.Require().NotNil(result.CriteriaPtr, "Should allocate nil pointers")
+	suite.Equal(5, result.CriteriaPtr.Limit)
+	suite.Equal(false, result.Bool)
+
+	assertBindError := func(key string, value string) {
+		req = suite.newRequest("GET", noBody, noQuery, bindingValues{key: value})
+		_, err = suite.bind(req)
+		suite.Error(err, "Should fail to bind %s=%s", key, value)
+	}
+	assertBindError("Int", "12.5")
+	assertBindError("Int", "")
+	assertBindError("Uint", "-1")
+	assertBindError("Float32", "abc")
+	assertBindError("Bool", "yup")
+	assertBindError("Criteria.Limit", "99999999999999999999999")
+}
+
+// Ensures that fields w/ custom unmarshaling logic (or that are nested in types that have it) still
+// go through the JSON binding process.
+func (suite *BindingSuite) TestBind_customUnmarshaling() {
+	req := suite.newRequest("GET", noBody, bindingValues{
+		"Duration":   "1m30s",
+		"Level":      "high",
+		"Custom.Foo": "abc",
+	}, noPathParams)
+
+	result := customBindingRequest{}
+	err := rpc.NewGateway().Binder.Bind(req, &result)
+	suite.Require().NoError(err)
+	suite.Equal(aliasDuration(90*time.Second), result.Duration)
+	suite.Equal(textLevel(3), result.Level)
+	suite.Equal("ABC", result.Custom.Foo, "Should use the parent type's UnmarshalJSON()")
+
+	req = suite.newRequest("GET", noBody, bindingValues{"Level": "meh"}, noPathParams)
+	err = rpc.NewGateway().Binder.Bind(req, &result)
+	suite.Error(err, "Should return errors from UnmarshalText()")
+}
+
+// Ensures that time.Duration, time.Time, types that implement encoding.TextUnmarshaler, and types w/ a registered
+// ScalarParser can all be bound from a single path/query value.
+func (suite *BindingSuite) TestBind_scalars() {
+	bind := func(values bindingValues) (scalarBindingRequest, error) {
+		result := scalarBindingRequest{}
+		req := suite.newRequest("GET", noBody, values, noPathParams)
+		return result, rpc.NewGateway().Binder.Bind(req, &result)
+	}
+
+	result, err := bind(bindingValues{
+		"Timeout":         "5m30s",
+		"TimeoutPtr":      "1500000000",
+		"Since":           "2021-04-06T13:14:15Z",
+		"Until":           "2021-05-01",
+		"Big":             "123456789012345678901234567890",
+		"IP":              "10.0.0.1",
+		"Price":           "$1.50",
+		"Audit.Created":   "2020-01-02",
+		"Audit.CreatedBy": "dude",
+	})
+	suite.Require().NoError(err)
+	suite.Equal(5*time.Minute+30*time.Second, result.Timeout)
+	suite.Require().NotNil(result.TimeoutPtr)
+	suite.Equal(1500*time.Millisecond, *result.TimeoutPtr, "Should accept a number of nanoseconds")
+	suite.Equal(parseTime("2021-04-06T13:14:15Z"), result.Since)
+	suite.Require().NotNil(result.Until)
+	suite.Equal(parseTime("2021-05-01T00:00:00Z"), *result.Until, "Should accept plain dates")
+	suite.Require().NotNil(result.Big)
+	suite.Equal("123456789012345678901234567890", result.Big.String())
+	suite.Equal("10.0.0.1", result.IP.String())
+	suite.Equal(cents(150), result.Price)
+	suite.Equal(parseTime("2020-01-02T00:00:00Z"), result.Audit.CreatedDate)
+	suite.Equal("dude", result.Audit.CreatedBy)
+
+	_, err = bind(bindingValues{"Timeout": "forever"})
+	suite.Error(err, "Should fail on invalid durations")
+	_, err = bind(bindingValues{"Since": "April 6"})
+	suite.Error(err, "Should fail on invalid times")
+	_, err = bind(bindingValues{"Big": "lots"})
+	suite.Error(err, "Should fail when UnmarshalText() fails")
+	_, err = bind(bindingValues{"Price": "1.50"})
+	suite.Error(err, "Should fail when the registered parser fails")
+
+	// You should be able to swap out the layouts we use for times.
+	rpc.RegisterScalar(time.Time{}, rpc.TimeParser("01/02/2006"))
+	defer rpc.RegisterScalar(time.Time{}, rpc.TimeParser(time.RFC3339Nano, "2006-01-02"))
+
+	result, err = bind(bindingValues{"Since": "04/06/2021"})
+	suite.Require().NoError(err)
+	suite.Equal(parseTime("2021-04-06T00:00:00Z"), result.Since)
+	_, err = bind(bindingValues{"Since": "2021-04-06T13:14:15Z"})
+	suite.Error(err, "Should only accept the registered layouts")
+}
+
+// Ensures that recursive types don't cause the binder to loop forever when building its binding plan, but
+// that you can still bind values as deep as you like.
+func (suite *BindingSuite) TestBind_recursiveType() {
+	req := suite.newRequest("GET", noBody, bindingValues{
+		"Name":             "root",
+		"Child.Name":       "child",
+		"Child.Child.Name": "grandchild",
+	}, noPathParams)
+
+	result := recursiveBindingRequest{}
+	err := rpc.NewGateway().Binder.Bind(req, &result)
+	suite.Require().NoError(err)
+	suite.Equal("root", result.Name)
+	suite.Require().NotNil(result.Child)
+	suite.Equal("child", result.Child.Name)
+	suite.Require().NotNil(result.Child.Child)
+	suite.Equal("grandchild", result.Child.Child.Name)
+}
+
+// Ensures that we don't panic when you try to bind to something other than a pointer.
+func (suite *BindingSuite) TestBind_nonPointer() {
+	req := suite.newRequest("GET", noBody, bindingValues{"String": "foo"}, noPathParams)
+	suite.Error(rpc.NewGateway().Binder.Bind(req, serviceRequest{}))
+	suite.Error(rpc.NewGateway().Binder.Bind(req, nil))
+
+	var nilRequest *serviceRequest
+	suite.Error(rpc.NewGateway().Binder.Bind(req, nilRequest))
+}
+
 // This ensures that our binder accepts the short-form JSON for embedded structs/attributes. In this case, if you
 // are trying to set "serviceRequest.EmbeddedID.ID" you need to have the param "ID" and not "EmbeddedID.ID" so that
 // we match the semantics of the standard library.
@@ -314,6 +797,46 @@ func (suite *BindingSuite) TestBind_embeddedStruct() {
 	suite.Equal(99, result.EmbeddedID.MoreEmbedded.MoreTotal, "Path params should be able to set embedded struct attributes.")
 }
 
+// Ensures that fields on the request shadow the fields w/ the same name on the structs it embeds, no matter whether
+// the value comes from the path, query, or body. Equally deep fields w/ the same name aren't bound at all, unless one
+// of them has a `json` tag. These are the same rules that encoding/json follows.
+func (suite *BindingSuite) TestBind_embeddedStructShadowed() {
+	bind := func(req *http.Request) shadowingRequest {
+		result := shadowingRequest{}
+		suite.Require().NoError(rpc.NewGateway().Binder.Bind(req, &result))
+		return result
+	}
+	params := bindingValues{"ID": "outer", "Name": "outer", "Ambiguous": "outer", "Tagged": "outer", "Criteria.Limit": "5"}
+
+	result := bind(suite.newRequest("GET", noBody, noQuery, params))
+	suite.Equal("outer", result.ID, "Path params should bind the outer field")
+	suite.Equal("", result.EmbeddedID.ID, "Path params should not bind the shadowed field")
+	suite.Equal("outer", result.Name)
+	suite.Equal("", result.shadowingNames.Name)
+	suite.Equal(5, result.Criteria.Limit)
+	suite.Equal(0, result.shadowingNames.Criteria.Limit)
+	suite.Equal("", result.shadowingNames.Ambiguous, "Ambiguous fields should not be bound")
+	suite.Equal("", result.moreShadowingNames.Ambiguous, "Ambiguous fields should not be bound")
+	suite.Equal("", result.shadowingNames.Tagged)
+	suite.Equal("outer", result.moreShadowingNames.Tagged, "Tagged fields should beat equally deep untagged fields")
+
+	result = bind(suite.newRequest("GET", noBody, params, noPathParams))
+	suite.Equal("outer", result.ID, "Query params should bind the outer field")
+	suite.Equal("", result.EmbeddedID.ID, "Query params should not bind the shadowed field")
+	suite.Equal("outer", result.Name)
+	suite.Equal("", result.shadowingNames.Name)
+	suite.Equal(5, result.Criteria.Limit)
+	suite.Equal("", result.shadowingNames.Ambiguous)
+	suite.Equal("", result.moreShadowingNames.Ambiguous)
+	suite.Equal("outer", result.moreShadowingNames.Tagged)
+
+	expected := shadowingRequest{}
+	body := `{"ID":"outer", "Name":"outer", "Ambiguous":"outer", "Tagged":"outer", "Criteria":{"Limit":5}}`
+	suite.Require().NoError(json.Unmarshal([]byte(body), &expected))
+	suite.Equal(expected, bind(suite.newRequest("POST", body, noQuery, noPathParams)), "The body should bind the same fields as params")
+	suite.Equal(expected, bind(suite.newRequest("GET", noBody, params, noPathParams)), "Params should bind the same fields as the body")
+}
+
 // This ensures that the binder adheres to standard JSON decoding rules. If you have an embedded struct, you
 // need to use the sugar-coated form of the attribute rather than the full one. In this case, if you're trying to
 // set "serviceRequest.EmbeddedID.ID", your params should include "ID=123" and not "EmbeddedID.ID=123". I think
@@ -475,6 +998,110 @@ func (a *aliasYesNo) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+type cents int64
+
+func init() {
+	rpc.RegisterScalar(cents(0), func(value string) (interface{}, error) {
+		if !strings.HasPrefix(value, "$") {
+			return nil, fmt.Errorf("missing '$'")
+		}
+		dollars, err := strconv.ParseFloat(value[1:], 64)
+		return int64(dollars * 100), err
+	})
+}
+
+type scalarBindingRequest struct {
+	Timeout    time.Duration
+	TimeoutPtr *time.Duration
+	Since      time.Time
+	Until      *time.Time
+	Big        *big.Int
+	IP         net.IP
+	Price      cents
+	Audit      auditTrail
+}
+
+type defaultsBindingRequest struct {
+	Limit    int           `default:"25"`
+	SortBy   string        `json:"sort" default:"name"`
+	Timeout  time.Duration `default:"5s"`
+	Page     *int          `default:"1"`
+	TenantID string        `frodo:"header=X-Tenant-ID" default:"acme"`
+	Text     string
+}
+
+type customBindingRequest struct {
+	Duration aliasDuration
+	Level    textLevel
+	Custom   upperCaser
+}
+
+type sourceBindingRequest struct {
+	Name     string
+	TenantID string    `frodo:"header=X-Tenant-ID"`
+	Session  string    `frodo:"cookie=session"`
+	Version  *int      `frodo:"header=X-Version"`
+	Level    textLevel `frodo:"header=X-Level"`
+	Secret   string    `json:"-" frodo:"header"`
+}
+
+type shadowingRequest struct {
+	EmbeddedID
+	shadowingNames
+	moreShadowingNames
+	ID       string
+	Name     string
+	Criteria searchCriteria
+}
+
+type shadowingNames struct {
+	Name      string
+	Ambiguous string
+	Tagged    string
+	Criteria  searchCriteria
+}
+
+type moreShadowingNames struct {
+	Ambiguous string
+	Tagged    string `json:"Tagged"`
+}
+
+type recursiveBindingRequest struct {
+	Name  string
+	Child *recursiveBindingRequest
+}
+
+// textLevel unmarshals "low", "medium", or "high" as 1, 2, or 3.
+type textLevel int
+
+func (level *textLevel) UnmarshalText(data []byte) error {
+	switch string(data) {
+	case "low":
+		*level = 1
+	case "medium":
+		*level = 2
+	case "high":
+		*level = 3
+	default:
+		return fmt.Errorf("invalid level: %s", data)
+	}
+	return nil
+}
+
+// upperCaser upper-cases the value of Foo when unmarshaling.
+type upperCaser struct {
+	Foo string
+}
+
+func (u *upperCaser) UnmarshalJSON(data []byte) error {
+	raw := struct{ Foo string }{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	u.Foo = strings.ToUpper(raw.Foo)
+	return nil
+}
+
 type bindingValues map[string]string
 
 var noQuery = bindingValues{}
@@ -0,0 +1,119 @@
+// Package cache lets Frodo's Go clients remember the responses to GET calls so that read-heavy lookups don't
+// need a round trip to the gateway every time. The client honors the "Cache-Control" header that the gateway
+// responds with (e.g. "max-age=30, stale-while-revalidate=60") to decide how long each response is fresh, and
+// it uses the "ETag" to cheaply revalidate responses once they're not.
+//
+// By default, clients keep the responses in memory. You can provide a Store backed by something else (Redis,
+// memcached, etc) when you want several processes to share the same cache:
+//
+//     client := scoresrpc.NewScoreServiceClient(address, rpc.WithResponseCache(myRedisCacheStore))
+package cache
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Entry is a single response that the client remembers.
+type Entry struct {
+	// Header contains the headers of the original response (Content-Type, ETag, etc).
+	Header http.Header
+	// Vary contains the values of the request headers that the response's "Vary" header lists. The client only
+	// uses the response for calls that have the same values.
+	Vary http.Header
+	// Body is the raw response body.
+	Body []byte
+	// StoredAt is when the client received (or last revalidated) the response.
+	StoredAt time.Time
+	// MaxAge is how long after StoredAt that the response is fresh, so the client can use it w/o asking the gateway.
+	MaxAge time.Duration
+	// StaleWhileRevalidate is how long after the response stops being fresh that the client can still use it
+	// while it fetches a new one in the background.
+	StaleWhileRevalidate time.Duration
+}
+
+// Fresh returns true when the client can use the response w/o asking the gateway.
+func (entry Entry) Fresh(now time.Time) bool {
+	return now.Before(entry.StoredAt.Add(entry.MaxAge))
+}
+
+// Revalidating returns true when the response is no longer fresh, but the client can still use it
+// while it fetches a new one in the background.
+func (entry Entry) Revalidating(now time.Time) bool {
+	return !entry.Fresh(now) && now.Before(entry.StoredAt.Add(entry.MaxAge+entry.StaleWhileRevalidate))
+}
+
+// ETag returns the entity tag that the client sends in the "If-None-Match" header to revalidate the response.
+func (entry Entry) ETag() string {
+	return entry.Header.Get("ETag")
+}
+
+// Store is where the client keeps the responses that it can reuse. Implementations must be safe for concurrent use.
+type Store interface {
+	// Load fetches the response that we stored for the key. It returns nil (w/o an error) when there isn't one.
+	Load(ctx context.Context, key string) (*Entry, error)
+	// Save remembers the response for the key, replacing any previous one.
+	Save(ctx context.Context, key string, entry *Entry) error
+}
+
+// NewMemoryStore creates a Store that keeps up to 'maxEntries' responses in memory, discarding the least
+// recently used ones once it's full. A 'maxEntries' <= 0 keeps the 1000 most recent responses.
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		recent:     list.New(),
+	}
+}
+
+// MemoryStore is the default Store that clients use. The responses don't survive restarts and
+// aren't shared between processes.
+type MemoryStore struct {
+	mutex      sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	recent     *list.List
+}
+
+type memoryStoreEntry struct {
+	key   string
+	entry *Entry
+}
+
+// Load fetches the response that we stored for the key, marking it as recently used.
+func (store *MemoryStore) Load(_ context.Context, key string) (*Entry, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	element, ok := store.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	store.recent.MoveToFront(element)
+	return element.Value.(*memoryStoreEntry).entry, nil
+}
+
+// Save remembers the response for the key, discarding the least recently used response if we're full.
+func (store *MemoryStore) Save(_ context.Context, key string, entry *Entry) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if element, ok := store.entries[key]; ok {
+		element.Value = &memoryStoreEntry{key: key, entry: entry}
+		store.recent.MoveToFront(element)
+		return nil
+	}
+	store.entries[key] = store.recent.PushFront(&memoryStoreEntry{key: key, entry: entry})
+	for store.recent.Len() > store.maxEntries {
+		oldest := store.recent.Back()
+		store.recent.Remove(oldest)
+		delete(store.entries, oldest.Value.(*memoryStoreEntry).key)
+	}
+	return nil
+}
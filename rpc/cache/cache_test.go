@@ -0,0 +1,65 @@
+// +build unit
+
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc/cache"
+	"github.com/stretchr/testify/suite"
+)
+
+type CacheSuite struct {
+	suite.Suite
+}
+
+// Ensures that entries are fresh for their max age, then usable while revalidating for a while longer.
+func (suite *CacheSuite) TestEntry_lifetime() {
+	r := suite.Require()
+	storedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	entry := cache.Entry{StoredAt: storedAt, MaxAge: time.Minute, StaleWhileRevalidate: time.Hour}
+
+	r.True(entry.Fresh(storedAt.Add(59 * time.Second)))
+	r.False(entry.Revalidating(storedAt.Add(59 * time.Second)))
+	r.False(entry.Fresh(storedAt.Add(time.Minute)))
+	r.True(entry.Revalidating(storedAt.Add(time.Minute)))
+	r.True(entry.Revalidating(storedAt.Add(time.Hour)))
+	r.False(entry.Revalidating(storedAt.Add(61 * time.Minute)))
+
+	entry = cache.Entry{StoredAt: storedAt}
+	r.False(entry.Fresh(storedAt), "Should always revalidate entries w/o a lifetime")
+	r.False(entry.Revalidating(storedAt))
+}
+
+// Ensures that the memory store discards the least recently used entries once it's full.
+func (suite *CacheSuite) TestMemoryStore() {
+	r := suite.Require()
+	ctx := context.Background()
+	store := cache.NewMemoryStore(2)
+
+	entry, err := store.Load(ctx, "a")
+	r.NoError(err)
+	r.Nil(entry)
+
+	r.NoError(store.Save(ctx, "a", &cache.Entry{Body: []byte("a")}))
+	r.NoError(store.Save(ctx, "b", &cache.Entry{Body: []byte("b")}))
+	_, _ = store.Load(ctx, "a")
+	r.NoError(store.Save(ctx, "c", &cache.Entry{Body: []byte("c")}))
+
+	entry, _ = store.Load(ctx, "b")
+	r.Nil(entry, "Should discard the least recently used entry")
+	entry, _ = store.Load(ctx, "a")
+	r.Equal([]byte("a"), entry.Body)
+	entry, _ = store.Load(ctx, "c")
+	r.Equal([]byte("c"), entry.Body)
+
+	r.NoError(store.Save(ctx, "c", &cache.Entry{Body: []byte("c2")}))
+	entry, _ = store.Load(ctx, "c")
+	r.Equal([]byte("c2"), entry.Body, "Should replace existing entries")
+}
+
+func TestCacheSuite(t *testing.T) {
+	suite.Run(t, new(CacheSuite))
+}
@@ -0,0 +1,243 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc/cache"
+)
+
+// WithResponseCache makes the client remember the responses to GET calls in the given store (in memory when
+// it's nil) and honor the "Cache-Control" header that the gateway responds with:
+//
+//   * While a response is fresh ("max-age"), the client uses it w/o asking the gateway at all.
+//   * Once it's stale, but still within its "stale-while-revalidate" window, the client uses it
+//     right away and fetches a new one in the background for next time.
+//   * After that, the client revalidates it using its "ETag", so an unchanged response only costs a "304".
+//
+// Responses marked "no-store" are never cached and "no-cache" ones are always revalidated. Responses are
+// cached separately for each set of credentials and honor the "Vary" header, but they're otherwise keyed
+// on the URL, so only use this for functions whose responses don't depend on anything else (e.g. metadata).
+// Your service decides how long its responses can be cached using rpc.SetHeader() or a HeaderReader:
+//
+//     rpc.SetHeader(ctx, "Cache-Control", "max-age=30, stale-while-revalidate=300")
+func WithResponseCache(store cache.Store) ClientOption {
+	return func(rpcClient *Client) {
+		if store == nil {
+			store = cache.NewMemoryStore(0)
+		}
+		rpcClient.responseCache = &responseCache{store: store}
+	}
+}
+
+// responseCache is the client middleware that serves GET calls from the cache when it can.
+type responseCache struct {
+	store cache.Store
+	// timeout is how long background revalidation can take (the client's Timeout).
+	timeout time.Duration
+	// revalidating tracks the keys that we're already fetching in the background, so a burst of calls
+	// for the same stale response only results in one extra call to the gateway.
+	revalidating sync.Map
+}
+
+// serve is client middleware that responds w/ the cached response when it's fresh (or stale, but revalidating)
+// and otherwise fetches/revalidates it from the gateway.
+func (rc *responseCache) serve(request *http.Request, next RoundTripperFunc) (*http.Response, error) {
+	if request.Method != http.MethodGet || request.Header.Get("Range") != "" || isStreamCall(request.Context()) {
+		return next(request)
+	}
+
+	key := responseCacheKey(request)
+	entry, err := rc.store.Load(request.Context(), key)
+	if err != nil || (entry != nil && !sameVary(entry, request)) {
+		entry = nil
+	}
+
+	now := time.Now()
+	switch {
+	case entry == nil:
+		return rc.fetch(request, key, nil, next)
+	case entry.Fresh(now):
+		return cachedResponse(request, entry), nil
+	case entry.Revalidating(now):
+		rc.revalidate(request, key, entry, next)
+		return cachedResponse(request, entry), nil
+	default:
+		return rc.fetch(request, key, entry, next)
+	}
+}
+
+// fetch sends the request to the gateway (revalidating the entry if we have one) and caches the response.
+func (rc *responseCache) fetch(request *http.Request, key string, entry *cache.Entry, next RoundTripperFunc) (*http.Response, error) {
+	if entry != nil && entry.ETag() != "" && request.Header.Get("If-None-Match") == "" {
+		request.Header.Set("If-None-Match", entry.ETag())
+	}
+
+	response, err := next(request)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case response.StatusCode == http.StatusNotModified && entry != nil && entry.ETag() != "" && request.Header.Get("If-None-Match") == entry.ETag():
+		_ = response.Body.Close()
+		refreshed := *entry
+		refreshed.StoredAt = time.Now()
+		if response.Header.Get("Cache-Control") != "" {
+			refreshed.MaxAge, refreshed.StaleWhileRevalidate, _ = cacheLifetime(response.Header)
+		}
+		_ = rc.store.Save(request.Context(), key, &refreshed)
+		return cachedResponse(request, &refreshed), nil
+
+	case response.StatusCode == http.StatusOK && (isJSONResponse(response) || isProtobuf(response.Header)):
+		maxAge, staleWhileRevalidate, ok := cacheLifetime(response.Header)
+		vary, varyOK := varyHeaders(request, response)
+		if !ok || !varyOK {
+			return response, nil
+		}
+		body, err := ioutil.ReadAll(response.Body)
+		_ = response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		_ = rc.store.Save(request.Context(), key, &cache.Entry{
+			Header:               response.Header.Clone(),
+			Vary:                 vary,
+			Body:                 body,
+			StoredAt:             time.Now(),
+			MaxAge:               maxAge,
+			StaleWhileRevalidate: staleWhileRevalidate,
+		})
+		response.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return response, nil
+
+	default:
+		return response, nil
+	}
+}
+
+// revalidate fetches a new copy of the stale response in the background, unless we're already doing that.
+func (rc *responseCache) revalidate(request *http.Request, key string, entry *cache.Entry, next RoundTripperFunc) {
+	if _, busy := rc.revalidating.LoadOrStore(key, true); busy {
+		return
+	}
+
+	// The caller is going to be done w/ its request (and cancel its context) long before we're done w/ ours.
+	var ctx context.Context = detachedContext{parent: request.Context()}
+	cancel := context.CancelFunc(func() {})
+	if rc.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, rc.timeout)
+	}
+	backgroundRequest := request.Clone(ctx)
+	go func() {
+		defer rc.revalidating.Delete(key)
+		defer cancel()
+
+		response, err := rc.fetch(backgroundRequest, key, entry, next)
+		if err != nil {
+			return
+		}
+		_, _ = io.Copy(ioutil.Discard, response.Body)
+		_ = response.Body.Close()
+	}()
+}
+
+// responseCacheKey identifies the cached response for the request. Different credentials get different
+// responses, so we include a hash of them rather than the raw token.
+func responseCacheKey(request *http.Request) string {
+	key := request.Method + " " + request.URL.String()
+	if auth := request.Header.Get("Authorization"); auth != "" {
+		hash := sha256.Sum256([]byte(auth))
+		key += " " + hex.EncodeToString(hash[:16])
+	}
+	return key
+}
+
+// cacheLifetime parses the response's "Cache-Control" header to figure out how long the response is fresh and
+// how long after that we can use it while revalidating. It returns false when we shouldn't cache the response.
+// Responses w/o any lifetime are only worth caching when they have an "ETag" that we can use to revalidate them.
+func cacheLifetime(header http.Header) (time.Duration, time.Duration, bool) {
+	maxAge, staleWhileRevalidate, noCache := time.Duration(0), time.Duration(0), false
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value := strings.ToLower(strings.TrimSpace(directive)), ""
+		if i := strings.Index(name, "="); i >= 0 {
+			name, value = strings.TrimSpace(name[:i]), strings.Trim(strings.TrimSpace(name[i+1:]), `"`)
+		}
+		switch name {
+		case "no-store":
+			return 0, 0, false
+		case "no-cache":
+			noCache = true
+		case "max-age":
+			maxAge = parseCacheSeconds(value)
+		case "stale-while-revalidate":
+			staleWhileRevalidate = parseCacheSeconds(value)
+		}
+	}
+	if noCache {
+		maxAge, staleWhileRevalidate = 0, 0
+	}
+	return maxAge, staleWhileRevalidate, maxAge > 0 || staleWhileRevalidate > 0 || header.Get("ETag") != ""
+}
+
+// parseCacheSeconds converts a "Cache-Control" number of seconds to a duration. Invalid values are 0.
+func parseCacheSeconds(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// varyHeaders captures the request headers that the response's "Vary" header lists. It returns false
+// when the response varies on "*" (i.e. it's not cacheable).
+func varyHeaders(request *http.Request, response *http.Response) (http.Header, bool) {
+	vary := http.Header{}
+	for _, value := range response.Header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			switch name {
+			case "":
+				continue
+			case "*":
+				return nil, false
+			}
+			vary[name] = request.Header.Values(name)
+		}
+	}
+	return vary, true
+}
+
+// sameVary returns true when the request has the same values as the cached one for all of the "Vary" headers.
+func sameVary(entry *cache.Entry, request *http.Request) bool {
+	for name, values := range entry.Vary {
+		if strings.Join(values, ",") != strings.Join(request.Header.Values(name), ",") {
+			return false
+		}
+	}
+	return true
+}
+
+// cachedResponse builds the "200 OK" response for the cached entry.
+func cachedResponse(request *http.Request, entry *cache.Entry) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       request,
+	}
+}
@@ -0,0 +1,171 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/authorization"
+	"github.com/monadicstack/frodo/rpc/cache"
+)
+
+type cachingResponse struct {
+	Value int
+}
+
+// agingStore makes the cached responses look older than they are, so we don't need to wait for them to go stale.
+type agingStore struct {
+	*cache.MemoryStore
+	mutex sync.Mutex
+	age   time.Duration
+}
+
+func (store *agingStore) Load(ctx context.Context, key string) (*cache.Entry, error) {
+	entry, err := store.MemoryStore.Load(ctx, key)
+	if entry == nil {
+		return nil, err
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	aged := *entry
+	aged.StoredAt = aged.StoredAt.Add(-store.age)
+	return &aged, err
+}
+
+func (store *agingStore) setAge(age time.Duration) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.age = age
+}
+
+// cachingBackend is the "GET /value" endpoint, which responds w/ its value and Cache-Control header, keeping
+// track of how many times it was actually called.
+type cachingBackend struct {
+	mutex        sync.Mutex
+	cacheControl string
+	value        int
+	hits         int
+	ifNoneMatch  []string
+}
+
+func (backend *cachingBackend) serve(w http.ResponseWriter, req *http.Request) {
+	backend.mutex.Lock()
+	backend.hits++
+	backend.ifNoneMatch = append(backend.ifNoneMatch, req.Header.Get("If-None-Match"))
+	response := cachingResponse{Value: backend.value}
+	if backend.cacheControl != "" {
+		rpc.SetHeader(req.Context(), "Cache-Control", backend.cacheControl)
+	}
+	backend.mutex.Unlock()
+	rpc.Reply(w, req, 200, response)
+}
+
+func (backend *cachingBackend) update(cacheControl string, value int) {
+	backend.mutex.Lock()
+	defer backend.mutex.Unlock()
+	backend.cacheControl, backend.value = cacheControl, value
+}
+
+func (backend *cachingBackend) gatewayHits() int {
+	backend.mutex.Lock()
+	defer backend.mutex.Unlock()
+	return backend.hits
+}
+
+// Ensures that the client doesn't bother the gateway while the cached response is fresh, uses stale responses
+// right away while it fetches the new one in the background, revalidates "no-cache" responses using their ETag,
+// and never caches "no-store" responses. Callers w/ different credentials don't see each other's responses.
+func (suite *GatewaySuite) TestWithResponseCache() {
+	r := suite.Require()
+	var backend *cachingBackend
+	var store *agingStore
+	var client rpc.Client
+	setup := func() func() {
+		backend = &cachingBackend{}
+		gw := suite.newGateway(rpc.WithETags())
+		gw.Register(testEndpoint("GET", "/value", "Value", backend.serve))
+		server := httptest.NewServer(gw)
+		store = &agingStore{MemoryStore: cache.NewMemoryStore(0)}
+		client = rpc.NewClient("TestService", server.URL, rpc.WithResponseCache(store))
+		return server.Close
+	}
+	get := func(ctx context.Context) int {
+		response := cachingResponse{}
+		r.NoError(client.Invoke(ctx, "GET", "/value", struct{}{}, &response))
+		return response.Value
+	}
+	ctx := context.Background()
+
+	// Fresh
+	teardown := setup()
+	backend.update("max-age=60", 1)
+	r.Equal(1, get(ctx))
+	backend.update("max-age=60", 2)
+	r.Equal(1, get(ctx), "Should use the fresh response")
+	r.Equal(1, backend.gatewayHits())
+
+	store.setAge(2 * time.Minute)
+	r.Equal(2, get(ctx), "Should fetch a new response once it's stale")
+	r.Equal(2, backend.gatewayHits())
+	teardown()
+
+	// Stale while revalidate
+	teardown = setup()
+	backend.update("max-age=10, stale-while-revalidate=60", 1)
+	r.Equal(1, get(ctx))
+
+	backend.update("max-age=10, stale-while-revalidate=60", 2)
+	store.setAge(30 * time.Second)
+	r.Equal(1, get(ctx), "Should use the stale response while revalidating")
+	r.Eventually(func() bool { return backend.gatewayHits() == 2 }, time.Second, 5*time.Millisecond)
+
+	store.setAge(0)
+	r.Eventually(func() bool { return get(ctx) == 2 }, time.Second, 5*time.Millisecond, "Should use the revalidated response")
+	r.Equal(2, backend.gatewayHits())
+
+	store.setAge(time.Hour)
+	backend.update("max-age=10, stale-while-revalidate=60", 3)
+	r.Equal(3, get(ctx), "Should wait for the gateway once it's too stale")
+	teardown()
+
+	// No cache
+	teardown = setup()
+	backend.update("no-cache", 1)
+	r.Equal(1, get(ctx))
+	r.Equal(1, get(ctx))
+	r.Equal(2, backend.gatewayHits())
+	r.Empty(backend.ifNoneMatch[0])
+	r.NotEmpty(backend.ifNoneMatch[1], "Should revalidate w/ the ETag")
+
+	backend.update("no-cache", 2)
+	r.Equal(2, get(ctx), "Should use the new response once it changes")
+	teardown()
+
+	// No store
+	teardown = setup()
+	backend.update("max-age=60, no-store", 1)
+	r.Equal(1, get(ctx))
+	r.Equal(1, get(ctx))
+	r.Equal(2, backend.gatewayHits())
+	r.Equal([]string{"", ""}, backend.ifNoneMatch)
+	teardown()
+
+	// Credentials
+	teardown = setup()
+	defer teardown()
+	dude := authorization.WithHeader(ctx, authorization.New("Token dude"))
+	walter := authorization.WithHeader(ctx, authorization.New("Token walter"))
+
+	backend.update("max-age=60", 1)
+	r.Equal(1, get(dude))
+	backend.update("max-age=60", 2)
+	r.Equal(2, get(walter))
+	r.Equal(1, get(dude))
+	r.Equal(2, get(walter))
+	r.Equal(2, backend.gatewayHits())
+}
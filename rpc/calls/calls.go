@@ -0,0 +1,113 @@
+// Package calls provides options that customize a single call that a client makes w/o changing how the client
+// behaves for every other call. You can pass them straight to the client's Invoke():
+//
+//     err := client.Invoke(ctx, "POST", "/user", request, response,
+//         calls.WithTimeout(2*time.Second),
+//         calls.WithIdempotencyKey(request.RequestID),
+//     )
+//
+// Generated clients implement your service's interface, so their functions can't accept extra arguments. Put
+// the options on the context instead; the client applies them to every call made w/ that context:
+//
+//     ctx = calls.With(ctx, calls.WithHeader("X-Debug", "true"))
+//     user, err := client.GetUser(ctx, &users.GetUserRequest{ID: "123"})
+package calls
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Option is a setting for a single call that you supply using With() or pass to the client's Invoke().
+type Option func(*Options)
+
+// Options are the settings for a single call once all of the call's Option values have been applied.
+type Options struct {
+	// Header contains additional HTTP headers to send along w/ the call.
+	Header http.Header
+	// Timeout overrides the client's timeout for this call. It's 0 when the call uses the client's timeout.
+	Timeout time.Duration
+	// IdempotencyKey is the invocation id that the client sends for this call so that gateways that deduplicate
+	// calls (see the "DEDUPE" doc option) can tell retries apart from new calls. It's blank when the client
+	// should generate a new id for each call.
+	IdempotencyKey string
+	// Response (optional) is where the client records the status/headers of the call's HTTP response.
+	Response *Response
+}
+
+// Response describes the HTTP response that the client received for a call (see CaptureResponse).
+type Response struct {
+	// Status is the HTTP status code of the response (e.g. 200 or 404).
+	Status int
+	// Header contains all of the response's HTTP headers.
+	Header http.Header
+}
+
+// WithHeader sends an additional HTTP header along w/ the call. The client's own headers (e.g. "Authorization"
+// or "X-RPC-Values") take precedence, so this can't be used to change those.
+func WithHeader(key string, value string) Option {
+	return func(options *Options) {
+		options.Header.Set(key, value)
+	}
+}
+
+// WithTimeout overrides the client's timeout for the call. Unlike the client's timeout, this also applies to "SSE"
+// calls. It never extends the deadline of the context that you make the call with.
+func WithTimeout(timeout time.Duration) Option {
+	return func(options *Options) {
+		options.Timeout = timeout
+	}
+}
+
+// WithIdempotencyKey sends the given key as the call's invocation id rather than a new, random one. Use the same
+// key when you retry the call (even from a different process) so that the gateway can tell that it's a retry.
+// Only calls that change state (e.g. POST, PUT, PATCH, DELETE) send an invocation id.
+func WithIdempotencyKey(key string) Option {
+	return func(options *Options) {
+		options.IdempotencyKey = key
+	}
+}
+
+// CaptureResponse records the status and headers of the call's HTTP response in 'response' once the call is
+// done, so you can read rate limit headers, ETags, pagination links, etc. that aren't part of the service's
+// response struct:
+//
+//     info := calls.Response{}
+//     users, err := client.ListUsers(calls.With(ctx, calls.CaptureResponse(&info)), request)
+//     remaining := info.Header.Get("X-RateLimit-Remaining")
+//
+// It's filled in for failed calls, too, as long as the gateway responded at all. Calls made over a message queue
+// (see rpc.WithQueueConn) don't have an HTTP response, so it's left alone for those.
+func CaptureResponse(response *Response) Option {
+	return func(options *Options) {
+		options.Response = response
+	}
+}
+
+type contextKeyOptions struct{}
+
+// With returns a context that carries the options for every call that a client makes w/ it. Options that you
+// supply here are applied after the ones already on the context, so they win when they conflict.
+func With(ctx context.Context, options ...Option) context.Context {
+	if len(options) == 0 {
+		return ctx
+	}
+	existing, _ := ctx.Value(contextKeyOptions{}).([]Option)
+	combined := make([]Option, 0, len(existing)+len(options))
+	combined = append(append(combined, existing...), options...)
+	return context.WithValue(ctx, contextKeyOptions{}, combined)
+}
+
+// FromContext applies all of the options on the context (see With) to determine the settings for a call.
+func FromContext(ctx context.Context) Options {
+	resolved := Options{Header: http.Header{}}
+	if ctx == nil {
+		return resolved
+	}
+	options, _ := ctx.Value(contextKeyOptions{}).([]Option)
+	for _, option := range options {
+		option(&resolved)
+	}
+	return resolved
+}
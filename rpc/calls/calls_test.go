@@ -0,0 +1,64 @@
+// +build unit
+
+package calls_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc/calls"
+	"github.com/stretchr/testify/suite"
+)
+
+type CallsSuite struct {
+	suite.Suite
+}
+
+// Ensures that a context w/o any options resolves to empty settings that are still safe to use.
+func (suite *CallsSuite) TestFromContext_empty() {
+	r := suite.Require()
+
+	options := calls.FromContext(context.Background())
+	r.Equal(http.Header{}, options.Header)
+	r.Equal(time.Duration(0), options.Timeout)
+	r.Equal("", options.IdempotencyKey)
+
+	r.NotPanics(func() {
+		calls.FromContext(nil)
+	})
+}
+
+// Ensures that options are applied in the order that you add them, so later ones win.
+func (suite *CallsSuite) TestWith() {
+	r := suite.Require()
+
+	ctx := calls.With(context.Background(),
+		calls.WithHeader("X-Debug", "true"),
+		calls.WithTimeout(time.Second),
+		calls.WithIdempotencyKey("abc"),
+	)
+	nested := calls.With(ctx,
+		calls.WithHeader("X-Debug", "false"),
+		calls.WithHeader("X-Tenant", "acme"),
+		calls.WithTimeout(2*time.Second),
+	)
+
+	options := calls.FromContext(nested)
+	r.Equal("false", options.Header.Get("X-Debug"))
+	r.Equal("acme", options.Header.Get("X-Tenant"))
+	r.Equal(2*time.Second, options.Timeout)
+	r.Equal("abc", options.IdempotencyKey)
+
+	options = calls.FromContext(ctx)
+	r.Equal("true", options.Header.Get("X-Debug"), "Nested options should not affect the parent context")
+	r.Equal("", options.Header.Get("X-Tenant"))
+	r.Equal(time.Second, options.Timeout)
+
+	r.Equal(ctx, calls.With(ctx), "No options should leave the context alone")
+}
+
+func TestCallsSuite(t *testing.T) {
+	suite.Run(t, new(CallsSuite))
+}
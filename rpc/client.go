@@ -3,6 +3,7 @@ package rpc
 import (
 	"bytes"
 	"context"
+	"encoding"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,57 +11,107 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strings"
 	"time"
 
-	"github.com/monadicstack/frodo/internal/naming"
-	"github.com/monadicstack/frodo/internal/reflection"
-	"github.com/monadicstack/frodo/rpc/authorization"
+	"github.com/monadicstack/frodo/rpc/calls"
 	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/internal/naming"
+	"github.com/monadicstack/frodo/rpc/internal/reflection"
+	"github.com/monadicstack/frodo/rpc/jobs"
 	"github.com/monadicstack/frodo/rpc/metadata"
 )
 
 // NewClient constructs the RPC client that does the "heavy lifting" when communicating
 // with remote frodo-powered RPC services. It contains all data/logic required to marshal/unmarshal
 // requests/responses as well as communicate w/ the remote service.
+//
+// The address is usually something like "http://localhost:8080", but you can also use an address like
+// "unix:///var/run/users.sock" to talk to a gateway listening on a Unix domain socket (e.g. a sidecar).
+// If you supply your own HTTP client via WithHTTPClient, its transport needs to connect to the socket itself.
 func NewClient(name string, addr string, options ...ClientOption) Client {
 	defaultTimeout := 30 * time.Second
 	client := Client{
 		HTTP: &http.Client{
-			Timeout: defaultTimeout,
-			Transport: &http.Transport{
-				DialContext:         (&net.Dialer{Timeout: defaultTimeout}).DialContext,
-				TLSHandshakeTimeout: defaultTimeout,
-			},
+			Transport: newTransport(&net.Dialer{Timeout: defaultTimeout, KeepAlive: defaultTimeout}),
 		},
-		Name:       name,
-		BaseURL:    strings.TrimSuffix(addr, "/"),
-		middleware: clientMiddlewarePipeline{},
+		Timeout:         defaultTimeout,
+		Name:            name,
+		BaseURL:         strings.TrimSuffix(addr, "/"),
+		JobPollInterval: time.Second,
+		Baggage:         true,
+		middleware:      clientMiddlewarePipeline{},
+	}
+	// Unix domain sockets don't have a host, but HTTP requests need one, so we use a placeholder. The
+	// transport always connects to the socket anyway.
+	if socketPath, ok := unixSocketPath(addr); ok {
+		client.BaseURL = "http://localhost"
+		WithDialContext(dialUnix(socketPath, &net.Dialer{Timeout: defaultTimeout}))(&client)
 	}
 	for _, option := range options {
 		option(&client)
 	}
 
-	mw := clientMiddlewarePipeline{
-		writeMetadataHeader,
-		writeAuthorizationHeader,
+	mw := clientMiddlewarePipeline{writeMetadataHeader}
+	if client.Baggage {
+		mw = append(mw, writeBaggageHeader)
+	}
+	mw = append(mw, writeTimeoutHeader, writeAuthorizationHeader(client.AuthorizationProvider))
+	if client.tokenRefresher != nil {
+		mw = append(mw, client.tokenRefresher.authorize)
+	}
+	if client.CookieJar != nil {
+		mw = append(mw, applyCookieJar(client.CookieJar))
 	}
 	client.middleware = append(mw, client.middleware...)
-	client.roundTrip = client.middleware.Then(client.HTTP.Do)
+
+	if client.etagCache != nil {
+		client.middleware = append(client.middleware, client.etagCache.revalidate)
+	}
+	if client.responseCache != nil {
+		client.responseCache.timeout = client.Timeout
+		client.middleware = append(client.middleware, client.responseCache.serve)
+	}
+
+	// Compression goes last, so your middleware sees the uncompressed request/response bodies.
+	client.middleware = append(client.middleware, acceptCompressedResponse(client.Compression))
+	if client.Compression != nil {
+		client.middleware = append(client.middleware, compressRequest(client.Compression))
+	}
+	client.roundTrip = client.middleware.Then(client.do)
 
 	return client
 }
 
 // WithHTTPClient allows you to provide an HTTP client configured to your liking. You do not *need*
-// to supply this. The default client already implements a 30 second timeout, but if you want a
-// different timeout or custom dialer/transport/etc, then you can feed in you custom client here and
-// we'll use that one for all HTTP communication with other services.
+// to supply this. The default client already has a tuned connection pool and every call has a 30 second
+// timeout (see WithTimeout), but if you want a custom dialer/transport/etc, then you can feed in you custom
+// client here and we'll use that one for all HTTP communication with other services.
 func WithHTTPClient(httpClient *http.Client) ClientOption {
 	return func(rpcClient *Client) {
 		rpcClient.HTTP = httpClient
 	}
 }
 
+// WithJobPollInterval changes how long the client waits between checks on the status of "ASYNC" service
+// functions. The default is 1 second.
+func WithJobPollInterval(interval time.Duration) ClientOption {
+	return func(rpcClient *Client) {
+		rpcClient.JobPollInterval = interval
+	}
+}
+
+// WithBaggage enables/disables sending your metadata values in a W3C "Baggage" header in addition to the
+// "X-RPC-Values" header (it's enabled by default). Baggage lets services that don't use frodo, but do understand
+// standard propagation (e.g. OpenTelemetry), read and forward your values. Disable it if the services you call
+// don't need it or your metadata is too large to send twice.
+func WithBaggage(enabled bool) ClientOption {
+	return func(rpcClient *Client) {
+		rpcClient.Baggage = enabled
+	}
+}
+
 // ClientOption is a single configurable setting that modifies some attribute of the RPC client
 // when building one via NewClient().
 type ClientOption func(*Client)
@@ -78,8 +129,37 @@ type Client struct {
 	// you can segment/version your services. Typically this will be the same as what you apply as
 	// the gateway's path prefix.
 	PathPrefix string
+	// PrefixVariables (optional) are the values of the variables in the PathPrefix (see WithClientPrefixVariables).
+	PrefixVariables map[string]string
+	// Version (optional) is the version of the service to call when the gateway serves more than one (see
+	// WithVersion). It's a prefix that goes in front of the PathPrefix (e.g. "/v2/api/users/:id").
+	Version string
 	// Name is just the display name of the service; used only for debugging/tracing purposes.
 	Name string
+	// AuthorizationProvider (optional) supplies credentials for calls whose context doesn't have any.
+	AuthorizationProvider AuthorizationProvider
+	// ErrorRegistry (optional) maps the error codes in failed responses back to the errors that caused them.
+	ErrorRegistry *errors.Registry
+	// Timeout is how long each call can take when its context doesn't already have a deadline.
+	Timeout time.Duration
+	// JobPollInterval is how long Await() waits between checks on the status of an "ASYNC" service function.
+	JobPollInterval time.Duration
+	// HedgeDelay (optional) is how long GET/HEAD calls wait before sending a second request (see WithHedging).
+	HedgeDelay time.Duration
+	// Queue (optional) sends calls over a message queue instead of HTTP (see WithQueueConn).
+	Queue QueueConn
+	// QueueSubjects maps each function's "METHOD /path" route to its message queue subject.
+	QueueSubjects map[string]string
+	// Compression (optional) compresses request bodies (see WithClientCompression).
+	Compression *Compression
+	// JSON (optional) customizes how we encode/decode request and response bodies (see WithClientJSON).
+	JSON *JSON
+	// CookieJar (optional) stores the cookies that the gateway sets and sends them back on later calls (see WithCookieJar).
+	CookieJar http.CookieJar
+	// DeprecationHandler (optional) is notified when you call a deprecated operation (see WithDeprecationHandler).
+	DeprecationHandler DeprecationHandler
+	// Baggage indicates that we also send your metadata values in a W3C "Baggage" header (see WithBaggage).
+	Baggage bool
 	// Middleware defines all of the units of work we will apply to the request/response when
 	// round-tripping our RPC call to he remote service.
 	middleware clientMiddlewarePipeline
@@ -87,32 +167,80 @@ type Client struct {
 	// function. This is what we'll call once we've created the HTTP/RPC request when invoking
 	// one of your client's service functions.
 	roundTrip RoundTripperFunc
+	// components are the background workers started by the client's options.
+	components []Component
+	// etagCache (optional) remembers tagged responses so we can revalidate them (see WithETagCache).
+	etagCache *etagCache
+	// responseCache (optional) serves GET calls from the cache when it can (see WithResponseCache).
+	responseCache *responseCache
+	// tokenRefresher (optional) manages the client's own bearer token (see WithTokenRefresh).
+	tokenRefresher *tokenRefresher
+	// protobuf (optional) tracks whether the gateway speaks protobuf (see WithClientProtobuf).
+	protobuf *protobufNegotiation
 }
 
 // Invoke handles the standard request/response logic used to call a service method on the remote service.
 // You should NOT call this yourself. Instead, you should stick to the strongly typed, code-generated
-// service functions on your client.
-func (c Client) Invoke(ctx context.Context, method string, path string, serviceRequest interface{}, serviceResponse interface{}) error {
+// service functions on your client. The options customize just this call (see the calls package), and they're
+// applied after any options on the context (see calls.With).
+func (c Client) Invoke(ctx context.Context, method string, path string, serviceRequest interface{}, serviceResponse interface{}, options ...calls.Option) error {
+	ctx = calls.With(ctx, options...)
+	call := calls.FromContext(ctx)
+	if call.IdempotencyKey != "" {
+		ctx = WithInvocationID(ctx, call.IdempotencyKey)
+	}
+	if c.Queue != nil {
+		return c.invokeQueue(ctx, method, path, serviceRequest, serviceResponse)
+	}
+
 	// Step 1: Fill in the URL path and query string w/ fields from the request. (e.g. /user/:id -> /user/abc)
 	address := c.buildURL(method, path, serviceRequest)
 
-	// Step 2: Create a JSON reader for the request body (POST/PUT/PATCH only).
-	body, err := c.createRequestBody(method, serviceRequest)
+	// Step 2: Create a JSON (or protobuf) reader for the request body (POST/PUT/PATCH only).
+	useProtobuf := c.protobuf.negotiated()
+	body, err := c.createRequestBody(method, serviceRequest, useProtobuf)
 	if err != nil {
 		return fmt.Errorf("rpc: unable to create request body: %w", err)
 	}
+	if err = checkRequestBodyLimit(ctx, body); err != nil {
+		return err
+	}
 
 	// Step 3: Form the HTTP request
+	ctx, cancel := c.callContext(ctx)
+	if _, ok := serviceResponse.(RedirectWriter); ok {
+		ctx = withoutRedirects(ctx)
+	}
 	request, err := http.NewRequestWithContext(ctx, method, address, body)
 	if err != nil {
+		cancel()
 		return fmt.Errorf("rpc: unable to create request: %w", err)
 	}
+	for key, values := range call.Header {
+		request.Header[key] = values
+	}
+	c.writeRequestSources(request, serviceRequest)
+	writeInvocationID(ctx, request)
+	if _, ok := serviceResponse.(ContentWriter); ok {
+		writeContentOffset(ctx, request)
+	}
+	if isStreamCall(ctx) {
+		request.Header.Set("Accept", EventStreamContentType)
+	} else if c.protobuf != nil {
+		writeProtobufHeaders(request, serviceResponse, useProtobuf)
+	}
 
 	// Step 4: Run the request through all middleware and fire it off.
-	response, err := c.roundTrip(request)
+	response, err := c.send(request)
 	if err != nil {
+		cancel()
 		return fmt.Errorf("rpc: round trip error: %w", err)
 	}
+	response.Body = cancelOnClose{ReadCloser: response.Body, cancel: cancel}
+	if call.Response != nil {
+		call.Response.Status = response.StatusCode
+		call.Response.Header = response.Header.Clone()
+	}
 
 	// Step 5: Based on the status code, either fill in the "out" struct (service response) with the
 	// unmarshaled JSON or respond a properly formed error.
@@ -123,19 +251,120 @@ func (c Client) Invoke(ctx context.Context, method string, path string, serviceR
 	return nil
 }
 
+// send dispatches the request through the client's middleware, hedging it when that's enabled and safe.
+func (c Client) send(request *http.Request) (*http.Response, error) {
+	if c.shouldHedge(request) {
+		return c.hedge(request)
+	}
+	return c.roundTrip(request)
+}
+
+// Await polls the gateway's "GET /jobs/:id" endpoint until the "ASYNC" service function for that job finishes. When
+// the job succeeds, the result is unmarshaled into the service response. When it fails, you get back the same error
+// that you would have received had you called the function synchronously. If the context is canceled or times out
+// before the job finishes, we stop waiting (the job still runs on the server) and return the context's error.
+//
+// Generated clients use this to implement the normal version of "ASYNC" functions, but you can call it yourself
+// if you fired off the job using the "XxxAsync" version of the function and want to check back later.
+func (c Client) Await(ctx context.Context, jobID string, serviceResponse interface{}) error {
+	pollInterval := c.JobPollInterval
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+
+	jobRequest := struct{ ID string }{ID: jobID}
+	for {
+		job := jobs.Job{}
+		if err := c.Invoke(ctx, http.MethodGet, "/jobs/:id", &jobRequest, &job); err != nil {
+			return err
+		}
+
+		switch job.Status {
+		case jobs.StatusSucceeded:
+			if err := c.JSON.unmarshal(job.Result, serviceResponse); err != nil {
+				return fmt.Errorf("rpc: unable to decode job result: %w", err)
+			}
+			return nil
+		case jobs.StatusFailed:
+			if job.Error == nil {
+				return errors.Unexpected("rpc error: job failed: %s", jobID)
+			}
+			return c.toError(*job.Error)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// toError rebuilds the error that the service function returned from the failure info that we
+// received from the gateway (e.g. a failed job or message queue reply).
+func (c Client) toError(failure errors.RPCError) error {
+	rpcErr := errors.New(failure.HTTPStatus, "rpc error: %s", failure.Message)
+	rpcErr.Code = failure.Code
+	rpcErr.Details = failure.Details
+	rpcErr.OperationID = failure.OperationID
+	return c.restoreCause(rpcErr)
+}
+
 func (c Client) decodeResponse(response *http.Response, serviceResponse interface{}) error {
 	if response.StatusCode >= 400 {
 		return c.decodeStatusError(response)
 	}
+	if strings.HasPrefix(response.Header.Get("Content-Type"), EventStreamContentType) {
+		return c.decodeResponseStream(response, serviceResponse)
+	}
+	// Apply the headers last so that decoding the body doesn't clobber any fields that SetHeaders() populates.
+	if headerWriter, ok := serviceResponse.(HeaderWriter); ok {
+		defer headerWriter.SetHeaders(response.Header)
+	}
+	if hasNoContent(response.StatusCode) {
+		return response.Body.Close()
+	}
+	if redirectWriter, ok := serviceResponse.(RedirectWriter); ok && isRedirect(response) {
+		return c.decodeRedirect(response, redirectWriter)
+	}
 	if contentWriter, ok := serviceResponse.(ContentWriter); ok {
 		return c.decodeResponseRaw(response, contentWriter)
 	}
+	if isProtobuf(response.Header) {
+		c.protobuf.negotiate()
+		return c.decodeResponseProtobuf(response, serviceResponse)
+	}
 	return c.decodeResponseJSON(response, serviceResponse)
 }
 
 func (c Client) decodeResponseJSON(response *http.Response, serviceResponse interface{}) error {
 	defer response.Body.Close()
 
+	// The gateway wrapped the response in an envelope, so the actual response is in the "data".
+	if response.Header.Get(EnvelopeHeader) != "" {
+		envelope := struct {
+			Data json.RawMessage `json:"data"`
+		}{}
+		if err := json.NewDecoder(response.Body).Decode(&envelope); err != nil {
+			return fmt.Errorf("rpc: unable to decode response envelope: %w", err)
+		}
+		if err := c.JSON.unmarshal(envelope.Data, serviceResponse); err != nil {
+			return fmt.Errorf("rpc: unable to decode response: %w", err)
+		}
+		return nil
+	}
+
+	if !c.JSON.standard() {
+		responseJSON, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return fmt.Errorf("rpc: unable to read response: %w", err)
+		}
+		if err = c.JSON.unmarshal(responseJSON, serviceResponse); err != nil {
+			return fmt.Errorf("rpc: unable to decode response: %w", err)
+		}
+		return nil
+	}
+
 	err := json.NewDecoder(response.Body).Decode(serviceResponse)
 	if err != nil {
 		return fmt.Errorf("rpc: unable to decode response: %w", err)
@@ -157,6 +386,16 @@ func (c Client) decodeResponseRaw(response *http.Response, serviceResponse Conte
 		fileName := naming.DispositionFileName(response.Header.Get("Content-Disposition"))
 		fileNameWriter.SetContentFileName(fileName)
 	}
+	if etagWriter, ok := serviceResponse.(ContentETagWriter); ok {
+		etagWriter.SetContentETag(response.Header.Get("ETag"))
+	}
+	if modTimeWriter, ok := serviceResponse.(ContentModTimeWriter); ok {
+		modTime, _ := http.ParseTime(response.Header.Get("Last-Modified"))
+		modTimeWriter.SetContentModTime(modTime)
+	}
+	if rangeWriter, ok := serviceResponse.(ContentRangeWriter); ok {
+		rangeWriter.SetContentRange(readContentRange(response))
+	}
 	return nil
 }
 
@@ -168,6 +407,35 @@ func (c Client) decodeStatusError(r *http.Response) error {
 	errData, _ := ioutil.ReadAll(r.Body)
 	contentType := r.Header.Get("Content-Type")
 
+	// The gateway wrapped the failure in an envelope, so the actual error is in the "error". It's in the
+	// gateway's error format, so the RFC 7807 ones are the only ones w/ a "type".
+	if r.Header.Get(EnvelopeHeader) != "" {
+		envelope := struct {
+			Error json.RawMessage `json:"error"`
+		}{}
+		if err := json.Unmarshal(errData, &envelope); err == nil && len(envelope.Error) > 0 {
+			errData = envelope.Error
+			problem := problemDetails{}
+			if err = json.Unmarshal(errData, &problem); err == nil && problem.Type != "" {
+				contentType = ProblemJSONContentType
+			}
+		}
+	}
+
+	// The gateway was configured to use the RFC 7807 problem format, so the message is in the 'detail'.
+	if strings.HasPrefix(contentType, ProblemJSONContentType) {
+		problem := problemDetails{}
+		_ = json.Unmarshal(errData, &problem)
+		if problem.Detail == "" {
+			problem.Detail = problem.Title
+		}
+		rpcErr := errors.New(r.StatusCode, "rpc error: %s", problem.Detail)
+		rpcErr.Code = problem.Code
+		rpcErr.Details = problem.Details
+		rpcErr.OperationID = problem.OperationID
+		return c.restoreCause(rpcErr)
+	}
+
 	// If the server didn't return JSON, assume that it's just plain text w/ the message to propagate
 	// as you'd get if you invoked `http.Error()`
 	if !strings.HasPrefix(contentType, "application/json") {
@@ -189,41 +457,159 @@ func (c Client) decodeStatusError(r *http.Response) error {
 	if strings.HasPrefix(string(errData), `{`) {
 		err := errors.RPCError{}
 		_ = json.Unmarshal(errData, &err)
-		return errors.New(r.StatusCode, "rpc error: %s", err.Error())
+		rpcErr := errors.New(r.StatusCode, "rpc error: %s", err.Error())
+		rpcErr.Code = err.Code
+		rpcErr.Details = err.Details
+		rpcErr.OperationID = err.OperationID
+		return c.restoreCause(rpcErr)
 	}
 
 	// It's JSON, but it's a format we don't recognize, so no message for you. Keep the status, though.
 	return errors.New(r.StatusCode, "rpc error")
 }
 
-func (c Client) createRequestBody(method string, serviceRequest interface{}) (io.Reader, error) {
+// restoreCause looks up the error code in the client's error registry. If the gateway failed w/ one of the
+// registered errors, we'll make that the cause of the client-side error so errors.Is()/errors.As() work.
+func (c Client) restoreCause(err errors.RPCError) errors.RPCError {
+	if cause, ok := c.ErrorRegistry.Lookup(err.Code); ok {
+		err.Cause = cause
+	}
+	return err
+}
+
+func (c Client) createRequestBody(method string, serviceRequest interface{}, useProtobuf bool) (io.Reader, error) {
 	if shouldEncodeUsingQueryString(method) {
 		return nil, nil
 	}
-	body := &bytes.Buffer{}
-	err := json.NewEncoder(body).Encode(serviceRequest)
+	if useProtobuf {
+		return c.createRequestBodyProtobuf(serviceRequest)
+	}
+	requestJSON, err := c.JSON.marshal(serviceRequest)
+	if err != nil {
+		return nil, err
+	}
+	body := bytes.NewBuffer(requestJSON)
+
+	// Fields bound from headers/cookies shouldn't also be sent in the body. Neither should nil pointers; the
+	// gateway would treat a null as the caller explicitly providing a value for that field (see Provided).
+	omit := omittedAttributes(serviceRequest)
+	if len(omit) == 0 {
+		return body, nil
+	}
+	attributes := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body.Bytes(), &attributes); err != nil {
+		return body, nil // custom MarshalJSON() that isn't an object, so leave it alone
+	}
+	for _, key := range omit {
+		delete(attributes, key)
+		if c.JSON != nil && c.JSON.SnakeCase {
+			delete(attributes, naming.ToSnakeCase(key))
+		}
+	}
+	body.Reset()
+	err = json.NewEncoder(body).Encode(attributes)
 	return body, err
 }
 
+// writeRequestSources writes the values of any header/cookie fields on the service request (e.g. fields
+// tagged w/ `frodo:"header=X-Tenant-ID"`) to the outgoing request. Zero values are not sent.
+func (c Client) writeRequestSources(request *http.Request, serviceRequest interface{}) {
+	requestValue := reflect.ValueOf(serviceRequest)
+	for _, field := range requestSources(serviceRequest) {
+		value, ok := sourceString(field.get(requestValue))
+		if !ok {
+			continue
+		}
+		switch field.source {
+		case reflection.BindingSourceHeader:
+			request.Header.Set(field.name, value)
+		case reflection.BindingSourceCookie:
+			request.AddCookie(&http.Cookie{Name: field.name, Value: value})
+		}
+	}
+}
+
+// requestSources returns all of the fields on the service request that are bound from headers/cookies.
+func requestSources(serviceRequest interface{}) []bindingSourceField {
+	if serviceRequest == nil {
+		return nil
+	}
+	return bindingPlanFor(reflect.TypeOf(serviceRequest)).sources
+}
+
+// omittedAttributes returns the names of the service request's attributes that shouldn't be sent in the body: the
+// header/cookie fields and any pointer fields that are nil.
+func omittedAttributes(serviceRequest interface{}) []string {
+	if serviceRequest == nil {
+		return nil
+	}
+
+	var omit []string
+	plan := bindingPlanFor(reflect.TypeOf(serviceRequest))
+	for _, field := range plan.sources {
+		omit = append(omit, field.key)
+	}
+
+	requestValue := reflect.ValueOf(serviceRequest)
+	for _, field := range plan.optional {
+		if value := field.get(requestValue); !value.IsValid() || value.IsNil() {
+			omit = append(omit, field.key)
+		}
+	}
+	return omit
+}
+
+// sourceString formats the field's value for a header/cookie. The boolean is false when the value is
+// nil or zero, so we shouldn't send it at all.
+func sourceString(value reflect.Value) (string, bool) {
+	value = reflect.Indirect(value)
+	if !value.IsValid() || value.IsZero() {
+		return "", false
+	}
+	if marshaler, ok := value.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		return string(text), err == nil
+	}
+	return fmt.Sprintf("%v", value.Interface()), true
+}
+
+// escapeCatchAll escapes each segment of a catch-all path param's value while leaving the slashes between them alone.
+func escapeCatchAll(value string) string {
+	segments := strings.Split(strings.TrimPrefix(value, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
 func (c Client) buildURL(method string, path string, serviceRequest interface{}) string {
 	attributes := reflection.ToAttributes(serviceRequest)
 
+	// The prefix can have its own params (e.g. "/:tenant/v2"), so fill them in just like the endpoint's.
+	path = toEndpointPath(c.pathPrefix(), path)
 	path = strings.TrimPrefix(path, "/")
 	path = strings.TrimSuffix(path, "/")
 	pathSegments := strings.Split(path, "/")
 
 	for i, pathSegment := range pathSegments {
 		// Leave fixed segments alone (e.g. "user" in "/user/:id/messages")
-		if !strings.HasPrefix(pathSegment, ":") {
+		if !strings.HasPrefix(pathSegment, ":") && !strings.HasPrefix(pathSegment, "*") {
 			continue
 		}
 
 		// Replace path param variables w/ the equivalent value from the service request.
 		paramName := pathSegment[1:]
+		if c.JSON != nil && c.JSON.SnakeCase && attributes.Find(paramName) == nil {
+			paramName = strings.ReplaceAll(paramName, "_", "")
+		}
 		attr := attributes.Find(paramName)
-		if attr == nil {
+		switch {
+		case attr == nil:
 			pathSegments[i] = ""
-		} else {
+		case strings.HasPrefix(pathSegment, "*"):
+			// Catch-all params (e.g. "*path" in "/files/*path") fill in the rest of the path, slashes and all.
+			pathSegments[i] = escapeCatchAll(fmt.Sprintf("%v", attr.Value))
+		default:
 			pathSegments[i] = fmt.Sprintf("%v", attr.Value)
 		}
 
@@ -231,8 +617,13 @@ func (c Client) buildURL(method string, path string, serviceRequest interface{})
 		attributes = attributes.Remove(paramName)
 	}
 
+	// Header/cookie values (especially things like session ids) have no business being in the URL.
+	for _, field := range requestSources(serviceRequest) {
+		attributes = attributes.Remove(field.key)
+	}
+
 	// If we're doing a POST/PUT/PATCH, don't bother adding query string arguments.
-	address := c.BaseURL + toEndpointPath(c.PathPrefix, strings.Join(pathSegments, "/"))
+	address := c.BaseURL + toEndpointPath(c.Version, strings.Join(pathSegments, "/"))
 	if shouldEncodeUsingBody(method) {
 		return address
 	}
@@ -240,11 +631,24 @@ func (c Client) buildURL(method string, path string, serviceRequest interface{})
 	// We're doing a GET/DELETE/etc, so all request values must come via query string args
 	queryString := url.Values{}
 	for _, attr := range attributes {
-		queryString.Set(attr.Name, fmt.Sprintf("%v", attr.Value))
+		queryString.Set(c.queryParamName(attr.Name), fmt.Sprintf("%v", attr.Value))
 	}
 	return address + "?" + queryString.Encode()
 }
 
+// queryParamName converts the attribute name (e.g. "Address.ZipCode") to the query string parameter we
+// should send, which is "address.zip_code" when the client uses snake_case JSON.
+func (c Client) queryParamName(name string) string {
+	if c.JSON == nil || !c.JSON.SnakeCase {
+		return name
+	}
+	segments := strings.Split(name, ".")
+	for i, segment := range segments {
+		segments[i] = naming.ToSnakeCase(segment)
+	}
+	return strings.Join(segments, ".")
+}
+
 func shouldEncodeUsingBody(method string) bool {
 	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
 }
@@ -265,13 +669,28 @@ func writeMetadataHeader(request *http.Request, next RoundTripperFunc) (*http.Re
 	return next(request)
 }
 
+// writeBaggageHeader encodes the context's metadata values as a W3C "Baggage" header so that services that
+// don't speak "X-RPC-Values" can still use/forward them. If something else already set the request's baggage
+// (e.g. a header from calls.WithHeader), we leave it alone.
+func writeBaggageHeader(request *http.Request, next RoundTripperFunc) (*http.Response, error) {
+	if request.Header.Get(metadata.BaggageHeader) != "" {
+		return next(request)
+	}
+	if baggage := metadata.ToBaggage(request.Context()); baggage != "" {
+		request.Header.Set(metadata.BaggageHeader, baggage)
+	}
+	return next(request)
+}
+
 // writeAuthorizationHeader takes the authorization information on the context (if present) and applies it
 // to the "Authorization" header on the request. This ensures that the credentials used to authenticate/authorize
-// the request to this service are automatically applied this upstream service call, too.
-func writeAuthorizationHeader(request *http.Request, next RoundTripperFunc) (*http.Response, error) {
-	auth := authorization.FromContext(request.Context())
-	if auth.NotEmpty() {
-		request.Header.Set("Authorization", auth.String())
+// the request to this service are automatically applied this upstream service call, too. When the context
+// doesn't have any credentials, we fall back to the client's provider (see WithAuthorizationProvider).
+func writeAuthorizationHeader(provider AuthorizationProvider) ClientMiddlewareFunc {
+	return func(request *http.Request, next RoundTripperFunc) (*http.Response, error) {
+		if auth := provider.resolve(request.Context()); auth.NotEmpty() {
+			request.Header.Set("Authorization", auth.String())
+		}
+		return next(request)
 	}
-	return next(request)
 }
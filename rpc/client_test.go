@@ -5,6 +5,7 @@ package rpc_test
 import (
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,6 +16,8 @@ import (
 
 	"github.com/monadicstack/frodo/rpc"
 	"github.com/monadicstack/frodo/rpc/authorization"
+	"github.com/monadicstack/frodo/rpc/calls"
+	"github.com/monadicstack/frodo/rpc/errors"
 	"github.com/monadicstack/frodo/rpc/metadata"
 	"github.com/stretchr/testify/suite"
 )
@@ -30,7 +33,7 @@ func (suite *ClientSuite) TestNewClient_default() {
 	suite.Require().Equal(":9000", client.BaseURL)
 	suite.Require().Equal("", client.PathPrefix)
 	suite.Require().NotNil(client.HTTP, "Default HTTP client should be non-nil")
-	suite.Require().Equal(30*time.Second, client.HTTP.Timeout, "Default HTTP client timeout should be 30 seconds")
+	suite.Require().Equal(30*time.Second, client.Timeout, "Default call timeout should be 30 seconds")
 
 	// Yes, you can leave these blank. You'll have a bad time... but you can do it.
 	client = rpc.NewClient("", "")
@@ -138,6 +141,35 @@ func (suite *ClientSuite) TestInvoke_pathParams() {
 	suite.Require().Equal("Loblaw", out.Name)
 }
 
+// Ensures that catch-all params (e.g. "*ID") fill in the rest of the path w/o escaping the slashes in the value.
+func (suite *ClientSuite) TestInvoke_catchAllParams() {
+	client := suite.newClient(func(r *http.Request) (*http.Response, error) {
+		suite.Require().Equal("/files/docs/my%20notes/a%3Fb.txt", r.URL.EscapedPath())
+		suite.Require().Empty(r.URL.Query().Get("ID"), "Should not be in the query string when field is in the path")
+		return suite.respond(200, &clientResponse{ID: "Bob"})
+	})
+
+	out := &clientResponse{}
+	err := client.Invoke(context.Background(), "GET", "/files/*id", &clientRequest{ID: "/docs/my notes/a?b.txt"}, out)
+	suite.Require().NoError(err)
+	suite.Require().Equal("Bob", out.ID)
+}
+
+// Ensures that WithVersion() puts the version in front of the client's path prefix.
+func (suite *ClientSuite) TestInvoke_version() {
+	client := suite.newClient(func(r *http.Request) (*http.Response, error) {
+		suite.assertURL(r, "http://localhost:9000/v2/api/foo/123")
+		return suite.respond(200, &clientResponse{ID: "Bob"})
+	})
+	rpc.WithVersion("v2")(&client)
+	client.PathPrefix = "api"
+
+	out := &clientResponse{}
+	err := client.Invoke(context.Background(), "GET", "/foo/:id", &clientRequest{ID: "123"}, out)
+	suite.Require().NoError(err)
+	suite.Require().Equal("Bob", out.ID)
+}
+
 // Ensures that an RPC client will translate 4XX/5XX errors into the
 // equivalent status-coded error.
 func (suite *ClientSuite) TestInvoke_httpStatusError() {
@@ -158,6 +190,13 @@ func (suite *ClientSuite) TestInvoke_httpStatusError() {
 		case "/504":
 			body := `{"foo": "broke as hell"}`
 			return &http.Response{StatusCode: 504, Header: typeJSON, Body: io.NopCloser(strings.NewReader(body))}, nil
+		case "/422":
+			body := `{"status":422, "message":"order expired", "code":"ORDER_EXPIRED", "details":{"retryAfter":5}, "operationId":"op-123"}`
+			return &http.Response{StatusCode: 422, Header: typeJSON, Body: io.NopCloser(strings.NewReader(body))}, nil
+		case "/410":
+			typeProblem := http.Header{"Content-Type": []string{"application/problem+json"}}
+			body := `{"type":"about:blank", "title":"Gone", "status":410, "detail":"long gone"}`
+			return &http.Response{StatusCode: 410, Header: typeProblem, Body: io.NopCloser(strings.NewReader(body))}, nil
 		}
 		panic("how did you get here?")
 	})
@@ -183,6 +222,47 @@ func (suite *ClientSuite) TestInvoke_httpStatusError() {
 	err = client.Invoke(context.Background(), "POST", "/504", &clientRequest{}, out)
 	suite.Require().Error(err, "Client.Invoke() - 504 status code should return an error")
 	suite.Require().NotContains(err.Error(), "broke as hell", "Client.Invoke() - not include unknown error message formats")
+
+	// An RFC 7807 problem+json response should use the 'detail' as the message.
+	out = &clientResponse{}
+	err = client.Invoke(context.Background(), "POST", "/410", &clientRequest{}, out)
+	suite.Require().Error(err, "Client.Invoke() - 410 status code should return an error")
+	suite.Require().Equal(410, errors.Status(err), "Client.Invoke() - should preserve problem+json status")
+	suite.Require().Contains(err.Error(), "long gone", "Client.Invoke() - should include problem+json detail")
+
+	// Error codes and details should survive the round trip.
+	out = &clientResponse{}
+	err = client.Invoke(context.Background(), "POST", "/422", &clientRequest{}, out)
+	suite.Require().Error(err, "Client.Invoke() - 422 status code should return an error")
+	suite.Require().Equal(422, errors.Status(err), "Client.Invoke() - should preserve status")
+	suite.Require().Equal("ORDER_EXPIRED", errors.Code(err), "Client.Invoke() - should preserve error code")
+	suite.Require().Equal(map[string]interface{}{"retryAfter": 5.0}, errors.Details(err), "Client.Invoke() - should preserve error details")
+	rpcErr := errors.RPCError{}
+	suite.Require().True(stderrors.As(err, &rpcErr))
+	suite.Require().Equal("op-123", rpcErr.OperationID, "Client.Invoke() - should preserve operation id")
+}
+
+// Ensures that registered error codes are turned back into the original errors.
+func (suite *ClientSuite) TestInvoke_errorRegistry() {
+	errExpired := errors.BadRequest("order expired")
+	registry := errors.NewRegistry().Register("ORDER_EXPIRED", errExpired)
+
+	client := rpc.NewClient("Test", "http://localhost:9000", rpc.WithClientErrorRegistry(registry))
+	client.HTTP.Transport = rpc.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		header := http.Header{"Content-Type": []string{"application/json"}}
+		body := `{"status":400, "message":"order expired", "code":"` + strings.TrimPrefix(r.URL.Path, "/") + `"}`
+		return &http.Response{StatusCode: 400, Header: header, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})
+
+	err := client.Invoke(context.Background(), "POST", "/ORDER_EXPIRED", &clientRequest{}, &clientResponse{})
+	suite.Require().Error(err)
+	suite.Require().True(stderrors.Is(err, errExpired), "Client.Invoke() - should wrap the registered error")
+	suite.Require().Equal(400, errors.Status(err))
+
+	err = client.Invoke(context.Background(), "POST", "/SOMETHING_ELSE", &clientRequest{}, &clientResponse{})
+	suite.Require().Error(err)
+	suite.Require().False(stderrors.Is(err, errExpired), "Client.Invoke() - should not wrap unregistered codes")
+	suite.Require().Equal("SOMETHING_ELSE", errors.Code(err))
 }
 
 // Check all of the different ways that Invoke() can fail.
@@ -239,6 +319,46 @@ func (suite *ClientSuite) TestWithMiddleware() {
 	suite.Require().Equal("C", values[2])
 }
 
+// Ensures that the client transparently unwraps responses when the gateway uses response envelopes.
+func (suite *ClientSuite) TestInvoke_envelope() {
+	client := suite.newClient(func(r *http.Request) (*http.Response, error) {
+		body := `{"data":{"ID":"123","Name":"Bob"},"meta":{"requestId":"op-123","durationMs":4}}`
+		response := &http.Response{StatusCode: 200, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}
+		response.Header.Set(rpc.EnvelopeHeader, "true")
+		return response, nil
+	})
+
+	out := &clientResponse{}
+	err := client.Invoke(context.Background(), "POST", "/foo", &clientRequest{}, out)
+	suite.Require().NoError(err)
+	suite.Require().Equal("123", out.ID)
+	suite.Require().Equal("Bob", out.Name)
+}
+
+// Ensures that the client restores the error from the envelope when the gateway uses response envelopes.
+func (suite *ClientSuite) TestInvoke_envelopeError() {
+	body := ""
+	client := suite.newClient(func(r *http.Request) (*http.Response, error) {
+		response := &http.Response{StatusCode: 404, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}
+		response.Header.Set("Content-Type", "application/json")
+		response.Header.Set(rpc.EnvelopeHeader, "true")
+		return response, nil
+	})
+
+	body = `{"data":null,"error":{"status":404,"message":"no foo for you","code":"NO_FOO"},"meta":{"durationMs":4}}`
+	err := client.Invoke(context.Background(), "POST", "/foo", &clientRequest{}, &clientResponse{})
+	suite.Require().Error(err)
+	suite.Require().Equal(404, errors.Status(err))
+	suite.Require().Contains(err.Error(), "rpc error: no foo for you")
+	suite.Require().Equal("NO_FOO", errors.Code(err))
+
+	body = `{"data":null,"error":{"type":"about:blank","title":"Not Found","status":404,"detail":"no foo for you"},"meta":{"durationMs":4}}`
+	err = client.Invoke(context.Background(), "POST", "/foo", &clientRequest{}, &clientResponse{})
+	suite.Require().Error(err)
+	suite.Require().Equal(404, errors.Status(err))
+	suite.Require().Contains(err.Error(), "rpc error: no foo for you")
+}
+
 func (suite *ClientSuite) TestInvoke_includeHeaders() {
 	client := suite.newClient(func(r *http.Request) (*http.Response, error) {
 		suite.Require().Equal("Hello", r.Header.Get("Authorization"))
@@ -253,6 +373,127 @@ func (suite *ClientSuite) TestInvoke_includeHeaders() {
 	suite.Require().NoError(err)
 }
 
+// Ensures that fields tagged w/ `frodo:"header=..."` or `frodo:"cookie=..."` are sent as headers/cookies
+// rather than in the query string or body.
+func (suite *ClientSuite) TestInvoke_headersAndCookies() {
+	type sourceRequest struct {
+		ID       string
+		TenantID string `frodo:"header=X-Tenant-ID"`
+		Session  string `frodo:"cookie=session"`
+		Version  *int   `frodo:"header=X-Version"`
+	}
+
+	client := suite.newClient(func(r *http.Request) (*http.Response, error) {
+		suite.Require().Equal("acme", r.Header.Get("X-Tenant-ID"))
+		suite.Require().Empty(r.Header.Values("X-Version"), "Should not send nil values")
+		cookie, err := r.Cookie("session")
+		suite.Require().NoError(err)
+		suite.Require().Equal("s3cr3t", cookie.Value)
+
+		query := r.URL.Query()
+		suite.Require().Equal("123", query.Get("ID"))
+		suite.Require().Empty(query.Get("TenantID"), "Header values should not be in the query string")
+		suite.Require().Empty(query.Get("Session"), "Cookie values should not be in the query string")
+		return suite.respond(200, &clientResponse{ID: "123"})
+	})
+	in := &sourceRequest{ID: "123", TenantID: "acme", Session: "s3cr3t"}
+	err := client.Invoke(context.Background(), "GET", "/foo", in, &clientResponse{})
+	suite.Require().NoError(err)
+
+	client = suite.newClient(func(r *http.Request) (*http.Response, error) {
+		suite.Require().Equal("acme", r.Header.Get("X-Tenant-ID"))
+		suite.Require().Equal("2", r.Header.Get("X-Version"))
+
+		body := map[string]interface{}{}
+		suite.Require().NoError(json.NewDecoder(r.Body).Decode(&body))
+		suite.Require().Equal(map[string]interface{}{"ID": "123"}, body, "Header/cookie values should not be in the body")
+		return suite.respond(200, &clientResponse{ID: "123"})
+	})
+	version := 2
+	in = &sourceRequest{ID: "123", TenantID: "acme", Version: &version}
+	err = client.Invoke(context.Background(), "POST", "/foo", in, &clientResponse{})
+	suite.Require().NoError(err)
+}
+
+// Ensures that per-call options from the context and from Invoke() itself only affect that one call.
+func (suite *ClientSuite) TestInvoke_callOptions() {
+	var request *http.Request
+	var deadline time.Time
+	client := suite.newClient(func(r *http.Request) (*http.Response, error) {
+		request = r
+		deadline, _ = r.Context().Deadline()
+		return suite.respond(200, &clientResponse{ID: "123"})
+	})
+	client.Timeout = time.Hour
+	ctx := metadata.WithValue(context.Background(), "Tenant", "acme")
+	ctx = calls.With(ctx, calls.WithHeader("X-Debug", "true"), calls.WithHeader(metadata.RequestHeader, "nope"))
+
+	err := client.Invoke(ctx, "POST", "/foo", &clientRequest{ID: "123"}, &clientResponse{},
+		calls.WithTimeout(time.Minute),
+		calls.WithIdempotencyKey("abc"),
+	)
+	suite.Require().NoError(err)
+	suite.Require().Equal("true", request.Header.Get("X-Debug"))
+	suite.Require().Equal("abc", request.Header.Get(rpc.InvocationHeader))
+	suite.Require().Contains(request.Header.Get(metadata.RequestHeader), "acme", "The client's own headers should win")
+	suite.Require().WithinDuration(time.Now().Add(time.Minute), deadline, 5*time.Second)
+
+	err = client.Invoke(context.Background(), "POST", "/foo", &clientRequest{ID: "123"}, &clientResponse{})
+	suite.Require().NoError(err)
+	suite.Require().Empty(request.Header.Get("X-Debug"))
+	suite.Require().NotEqual("abc", request.Header.Get(rpc.InvocationHeader))
+	suite.Require().WithinDuration(time.Now().Add(time.Hour), deadline, 5*time.Second)
+}
+
+// Ensures that CaptureResponse() records the status and headers of both successful and failed calls.
+func (suite *ClientSuite) TestInvoke_captureResponse() {
+	status := 200
+	client := suite.newClient(func(r *http.Request) (*http.Response, error) {
+		response, err := suite.respond(status, &clientResponse{ID: "123"})
+		response.Header = http.Header{"Etag": {`"v1"`}, "X-Ratelimit-Remaining": {"9"}}
+		return response, err
+	})
+
+	info := calls.Response{}
+	err := client.Invoke(calls.With(context.Background(), calls.CaptureResponse(&info)), "GET", "/foo", &clientRequest{}, &clientResponse{})
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, info.Status)
+	suite.Require().Equal(`"v1"`, info.Header.Get("ETag"))
+	suite.Require().Equal("9", info.Header.Get("X-RateLimit-Remaining"))
+
+	status = 429
+	info = calls.Response{}
+	err = client.Invoke(context.Background(), "GET", "/foo", &clientRequest{}, &clientResponse{}, calls.CaptureResponse(&info))
+	suite.Require().Error(err)
+	suite.Require().Equal(429, info.Status)
+	suite.Require().Equal("9", info.Header.Get("X-RateLimit-Remaining"))
+}
+
+// Ensures that the client sends metadata as W3C baggage unless you disable it or already set the header yourself.
+func (suite *ClientSuite) TestInvoke_baggage() {
+	var request *http.Request
+	roundTripper := func(r *http.Request) (*http.Response, error) {
+		request = r
+		return suite.respond(200, &clientResponse{})
+	}
+	ctx := metadata.WithValue(context.Background(), "userId", "alice")
+
+	client := suite.newClient(roundTripper)
+	suite.Require().NoError(client.Invoke(ctx, "GET", "/foo", &clientRequest{}, &clientResponse{}))
+	suite.Require().Equal("userId=alice", request.Header.Get(metadata.BaggageHeader))
+	suite.Require().Contains(request.Header.Get(metadata.RequestHeader), "alice")
+
+	err := client.Invoke(ctx, "GET", "/foo", &clientRequest{}, &clientResponse{}, calls.WithHeader(metadata.BaggageHeader, "mine=1"))
+	suite.Require().NoError(err)
+	suite.Require().Equal("mine=1", request.Header.Get(metadata.BaggageHeader))
+
+	client = rpc.NewClient("Test", "http://localhost:9000", rpc.WithBaggage(false))
+	client.HTTP.Transport = rpc.RoundTripperFunc(roundTripper)
+	suite.Require().NoError(client.Invoke(ctx, "GET", "/foo", &clientRequest{}, &clientResponse{}))
+	suite.Require().Empty(request.Header.Get(metadata.BaggageHeader))
+	suite.Require().Contains(request.Header.Get(metadata.RequestHeader), "alice")
+}
+
 func (suite *ClientSuite) newClient(roundTripper rpc.RoundTripperFunc) rpc.Client {
 	client := rpc.NewClient("Test", "http://localhost:9000")
 	client.HTTP.Transport = roundTripper
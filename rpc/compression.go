@@ -0,0 +1,502 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/urfave/negroni"
+)
+
+// Encoding is a content coding (e.g. "gzip") that gateways and clients can use to compress HTTP bodies. Frodo
+// supports "gzip" and "deflate" out of the box. Since we don't want to force a dependency on everyone, you can
+// add support for Brotli (or anything else) by adapting your library of choice:
+//
+//     brotliEncoding := rpc.Encoding{
+//         Name: "br",
+//         NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+//             if level == 0 {
+//                 level = brotli.DefaultCompression
+//             }
+//             return brotli.NewWriterLevel(w, level), nil
+//         },
+//         NewReader: func(r io.Reader) (io.ReadCloser, error) {
+//             return ioutil.NopCloser(brotli.NewReader(r)), nil
+//         },
+//     }
+//     gateway := calcrpc.NewCalculatorServiceGateway(service, rpc.WithCompression(
+//         rpc.CompressionEncodings(brotliEncoding),
+//     ))
+type Encoding struct {
+	// Name is the token used in the "Accept-Encoding" and "Content-Encoding" headers (e.g. "br").
+	Name string
+	// NewWriter compresses everything written to it, writing the result to 'w'. A level of 0
+	// means that you should use the encoding's default level.
+	NewWriter func(w io.Writer, level int) (io.WriteCloser, error)
+	// NewReader decompresses the data from 'r'.
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+// GzipEncoding compresses bodies using the "gzip" content coding.
+var GzipEncoding = Encoding{
+	Name: "gzip",
+	NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	},
+	NewReader: func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+}
+
+// DeflateEncoding compresses bodies using the "deflate" content coding.
+var DeflateEncoding = Encoding{
+	Name: "deflate",
+	NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+		if level == 0 {
+			level = flate.DefaultCompression
+		}
+		return flate.NewWriter(w, level)
+	},
+	NewReader: func(r io.Reader) (io.ReadCloser, error) {
+		return flate.NewReader(r), nil
+	},
+}
+
+// Compression describes how the gateway/client compresses HTTP bodies.
+type Compression struct {
+	// Level is how hard the encoding should work to compress the data. The range of valid values depends on
+	// the encoding (e.g. 1-9 for gzip). The default of 0 uses each encoding's default level.
+	Level int
+	// MinSize is the smallest body (in bytes) that we'll bother compressing. Compressing a handful of bytes
+	// usually makes them bigger, not smaller. The default is 1024.
+	MinSize int
+	// Encodings are the content codings we support in order of preference.
+	Encodings []Encoding
+}
+
+// CompressionOption is a setting that lets you tune how the gateway/client compresses HTTP bodies.
+type CompressionOption func(*Compression)
+
+// CompressionLevel sets how hard the encoding should work to compress the data. The range of valid values
+// depends on the encoding (e.g. 1-9 for gzip).
+func CompressionLevel(level int) CompressionOption {
+	return func(compression *Compression) {
+		compression.Level = level
+	}
+}
+
+// CompressionMinSize sets the smallest body (in bytes) that we'll bother compressing.
+func CompressionMinSize(size int) CompressionOption {
+	return func(compression *Compression) {
+		compression.MinSize = size
+	}
+}
+
+// CompressionEncodings adds support for additional content codings such as Brotli. They're preferred over
+// the built-in "gzip" and "deflate" encodings when the other side supports them.
+func CompressionEncodings(encodings ...Encoding) CompressionOption {
+	return func(compression *Compression) {
+		compression.Encodings = append(append([]Encoding{}, encodings...), compression.Encodings...)
+	}
+}
+
+// newCompression builds the compression settings, starting w/ the defaults.
+func newCompression(options ...CompressionOption) *Compression {
+	compression := &Compression{
+		MinSize:   1024,
+		Encodings: []Encoding{GzipEncoding, DeflateEncoding},
+	}
+	for _, option := range options {
+		option(compression)
+	}
+	return compression
+}
+
+// defaultCompression supplies the encodings we understand when the gateway/client wasn't given any settings.
+var defaultCompression = newCompression()
+
+// lookup finds the encoding w/ the given name (case-insensitive).
+func (compression *Compression) lookup(name string) (Encoding, bool) {
+	for _, encoding := range compression.Encodings {
+		if strings.EqualFold(encoding.Name, name) {
+			return encoding, true
+		}
+	}
+	return Encoding{}, false
+}
+
+// acceptEncoding is the value of the "Accept-Encoding" header that tells the other side which encodings we support.
+func (compression *Compression) acceptEncoding() string {
+	names := make([]string, len(compression.Encodings))
+	for i, encoding := range compression.Encodings {
+		names[i] = encoding.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// negotiate picks the encoding that we should use to compress the response based on the "Accept-Encoding"
+// header. When the caller likes more than one of our encodings equally, we go w/ the one we prefer.
+func (compression *Compression) negotiate(acceptEncoding string) (Encoding, bool) {
+	var best Encoding
+	bestQuality := 0.0
+	for _, encoding := range compression.Encodings {
+		if quality := encodingQuality(acceptEncoding, encoding.Name); quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best, bestQuality > 0
+}
+
+// encodingQuality returns the "q" value that the "Accept-Encoding" header gives to the encoding (0 when
+// the caller doesn't accept it at all).
+func encodingQuality(acceptEncoding string, name string) float64 {
+	wildcard := 0.0
+	for _, token := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(token, ";")
+		tokenName := strings.TrimSpace(params[0])
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				quality, _ = strconv.ParseFloat(param[2:], 64)
+			}
+		}
+
+		switch {
+		case strings.EqualFold(tokenName, name):
+			return quality
+		case tokenName == "*":
+			wildcard = quality
+		}
+	}
+	return wildcard
+}
+
+// WithCompression compresses responses using gzip/deflate (or any other Encoding that you add) when the caller
+// includes an "Accept-Encoding" header that supports it. Small responses and responses that are already
+// compressed (e.g. images or zip files returned as raw file data) are sent as-is. Gateways always decompress
+// request bodies that use one of their encodings, regardless of whether you use this option.
+func WithCompression(options ...CompressionOption) GatewayOption {
+	return func(gw *Gateway) {
+		gw.Compression = newCompression(options...)
+	}
+}
+
+// WithClientCompression compresses the bodies of requests that the client sends to the gateway. The client
+// always asks the gateway for compressed responses and decompresses them, regardless of whether you use this
+// option. Requests use the first of the client's encodings, so the gateway must support it, too.
+func WithClientCompression(options ...CompressionOption) ClientOption {
+	return func(rpcClient *Client) {
+		rpcClient.Compression = newCompression(options...)
+	}
+}
+
+// decompressRequest is gateway middleware that transparently decompresses request bodies sent
+// w/ a "Content-Encoding" header, so the binder only ever sees the raw bytes.
+func decompressRequest(compression *Compression) MiddlewareFunc {
+	if compression == nil {
+		compression = defaultCompression
+	}
+	return func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		contentEncoding := strings.TrimSpace(req.Header.Get("Content-Encoding"))
+		if contentEncoding == "" || strings.EqualFold(contentEncoding, "identity") || req.Body == nil {
+			next(w, req)
+			return
+		}
+
+		encoding, ok := compression.lookup(contentEncoding)
+		if !ok {
+			Fail(w, req, errors.New(http.StatusUnsupportedMediaType, "unsupported content encoding: %s", contentEncoding))
+			return
+		}
+		body, err := encoding.NewReader(req.Body)
+		if err != nil {
+			Fail(w, req, errors.BadRequest("unable to decompress request body: %v", err))
+			return
+		}
+		defer body.Close()
+
+		req.Body = body
+		req.ContentLength = -1
+		req.Header.Del("Content-Encoding")
+		req.Header.Del("Content-Length")
+		next(w, req)
+	}
+}
+
+// compressResponse is gateway middleware that compresses the response when the caller supports one of
+// our encodings and the response is worth compressing.
+func compressResponse(compression *Compression) MiddlewareFunc {
+	return func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding, ok := compression.negotiate(req.Header.Get("Accept-Encoding"))
+		if !ok || req.Method == http.MethodHead {
+			next(w, req)
+			return
+		}
+
+		writer := &compressWriter{ResponseWriter: w, compression: compression, encoding: encoding}
+		defer writer.Close()
+
+		// Negroni's middleware (e.g. their logger) expects the writer to be one of theirs, so re-wrap it.
+		next(negroni.NewResponseWriter(writer), req)
+	}
+}
+
+// compressWriter buffers the response until we know whether it's big enough to compress. Once we've
+// seen MinSize bytes, we either start compressing or just pass everything through to the real writer.
+type compressWriter struct {
+	http.ResponseWriter
+	compression *Compression
+	encoding    Encoding
+	status      int
+	buffer      bytes.Buffer
+	decided     bool
+	encoder     io.WriteCloser
+}
+
+// WriteHeader holds onto the status until we know whether we're compressing the response since
+// that determines which headers we send.
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buffer.Write(data)
+	if w.buffer.Len() >= w.compression.MinSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// Flush sends everything that we have so far to the caller (e.g. when streaming a response).
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(w.buffer.Len() >= w.compression.MinSize)
+	}
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets websocket-style handlers take over the connection.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.Unexpected("response writer does not support hijacking")
+}
+
+// Close sends anything still in the buffer and finishes the compressed stream.
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		if w.status == 0 {
+			return nil
+		}
+		if err := w.decide(false); err != nil {
+			return err
+		}
+	}
+	if w.encoder != nil {
+		return w.encoder.Close()
+	}
+	return nil
+}
+
+// decide writes the headers and the buffered bytes, compressing them if 'worthIt' and the response isn't
+// already compressed.
+func (w *compressWriter) decide(worthIt bool) error {
+	w.decided = true
+
+	header := w.Header()
+	if worthIt && w.compressible(header) {
+		encoder, err := w.encoding.NewWriter(w.ResponseWriter, w.compression.Level)
+		if err == nil {
+			w.encoder = encoder
+			header.Set("Content-Encoding", w.encoding.Name)
+			header.Del("Content-Length")
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	data := w.buffer.Bytes()
+	w.buffer = bytes.Buffer{}
+	if len(data) == 0 {
+		return nil
+	}
+	if w.encoder != nil {
+		_, err := w.encoder.Write(data)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(data)
+	return err
+}
+
+// compressible determines whether compressing the response would accomplish anything.
+func (w *compressWriter) compressible(header http.Header) bool {
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	if w.status < 200 || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	// Byte ranges refer to the uncompressed content, so compressing some (or all) of it would
+	// leave callers resuming a download w/ offsets that don't line up.
+	if w.status == http.StatusPartialContent || header.Get("Accept-Ranges") != "" {
+		return false
+	}
+	// Compressors buffer data until they have enough to compress, which would hold up each event.
+	if strings.HasPrefix(header.Get("Content-Type"), EventStreamContentType) {
+		return false
+	}
+	return !alreadyCompressed(header.Get("Content-Type"))
+}
+
+// alreadyCompressed returns true for content types whose data is already compressed, so compressing
+// them again just burns CPU (and might even make them bigger).
+func alreadyCompressed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "image/") && mediaType != "image/svg+xml":
+		return true
+	case strings.HasPrefix(mediaType, "video/"), strings.HasPrefix(mediaType, "audio/"):
+		return true
+	}
+	switch mediaType {
+	case "application/zip",
+		"application/gzip",
+		"application/x-gzip",
+		"application/x-bzip2",
+		"application/x-7z-compressed",
+		"application/x-rar-compressed",
+		"application/x-xz",
+		"application/zstd",
+		"application/pdf",
+		"font/woff",
+		"font/woff2":
+		return true
+	}
+	return false
+}
+
+// acceptCompressedResponse is client middleware that asks the gateway to compress the response and
+// transparently decompresses it, so the rest of the client only ever sees the raw bytes.
+func acceptCompressedResponse(compression *Compression) ClientMiddlewareFunc {
+	if compression == nil {
+		compression = defaultCompression
+	}
+	return func(request *http.Request, next RoundTripperFunc) (*http.Response, error) {
+		if request.Header.Get("Accept-Encoding") == "" {
+			request.Header.Set("Accept-Encoding", compression.acceptEncoding())
+		}
+
+		response, err := next(request)
+		if err != nil {
+			return nil, err
+		}
+
+		contentEncoding := strings.TrimSpace(response.Header.Get("Content-Encoding"))
+		if contentEncoding == "" || strings.EqualFold(contentEncoding, "identity") {
+			return response, nil
+		}
+		encoding, ok := compression.lookup(contentEncoding)
+		if !ok {
+			return response, nil
+		}
+		body, err := encoding.NewReader(response.Body)
+		if err != nil {
+			response.Body.Close()
+			return nil, errors.Unexpected("unable to decompress response body: %v", err)
+		}
+
+		response.Body = decompressedBody{ReadCloser: body, compressed: response.Body}
+		response.ContentLength = -1
+		response.Uncompressed = true
+		response.Header.Del("Content-Encoding")
+		response.Header.Del("Content-Length")
+		return response, nil
+	}
+}
+
+// decompressedBody makes sure that we close both the decompressing reader and the original response body.
+type decompressedBody struct {
+	io.ReadCloser
+	compressed io.ReadCloser
+}
+
+func (body decompressedBody) Close() error {
+	_ = body.ReadCloser.Close()
+	return body.compressed.Close()
+}
+
+// compressRequest is client middleware that compresses request bodies that are at least MinSize bytes.
+func compressRequest(compression *Compression) ClientMiddlewareFunc {
+	return func(request *http.Request, next RoundTripperFunc) (*http.Response, error) {
+		if request.Body == nil || request.Body == http.NoBody || len(compression.Encodings) == 0 {
+			return next(request)
+		}
+
+		data, err := ioutil.ReadAll(request.Body)
+		_ = request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if len(data) < compression.MinSize {
+			request.Body = ioutil.NopCloser(bytes.NewReader(data))
+			request.ContentLength = int64(len(data))
+			return next(request)
+		}
+
+		encoding := compression.Encodings[0]
+		compressed := &bytes.Buffer{}
+		encoder, err := encoding.NewWriter(compressed, compression.Level)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = encoder.Write(data); err != nil {
+			return nil, err
+		}
+		if err = encoder.Close(); err != nil {
+			return nil, err
+		}
+
+		request.Body = ioutil.NopCloser(compressed)
+		request.ContentLength = int64(compressed.Len())
+		request.Header.Set("Content-Encoding", encoding.Name)
+		return next(request)
+	}
+}
@@ -0,0 +1,203 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+type compressionFile struct {
+	content     string
+	contentType string
+}
+
+func (f compressionFile) Content() io.ReadCloser {
+	return ioutil.NopCloser(strings.NewReader(f.content))
+}
+
+func (f compressionFile) ContentType() string {
+	return f.contentType
+}
+
+// Ensures that gateways only compress large responses when you ask them to, using the encoding that the caller
+// prefers (including custom ones like Brotli), and that raw responses are only compressed when their data isn't
+// already compressed. Gateways always decompress request bodies, even w/o WithCompression().
+func (suite *GatewaySuite) TestWithCompression() {
+	text := strings.Repeat("a", 2048)
+	jsonText := func(text string) string { return `{"Text":"` + text + `"}` }
+	gzipped := &bytes.Buffer{}
+	writer := gzip.NewWriter(gzipped)
+	_, _ = writer.Write([]byte(`{"Text":"zipped"}`))
+	_ = writer.Close()
+
+	compression := []rpc.GatewayOption{rpc.WithCompression()}
+	tests := []struct {
+		name    string
+		options []rpc.GatewayOption
+		method  string
+		path    string
+		body    string
+		header  http.Header
+		status  int
+		// encoding is the Content-Encoding that we expect the gateway to use.
+		encoding string
+		// response is the decompressed body that we expect back (not checked when empty).
+		response string
+	}{
+		{name: "disabled", method: "POST", path: "/echo", body: jsonText(text), header: http.Header{"Accept-Encoding": {"gzip"}}, status: 200, response: jsonText(text)},
+		{name: "gzip", options: compression, method: "POST", path: "/echo", body: jsonText(text), header: http.Header{"Accept-Encoding": {"gzip"}}, status: 200, encoding: "gzip", response: jsonText(text)},
+		{name: "preferred", options: compression, method: "POST", path: "/echo", body: jsonText(text), header: http.Header{"Accept-Encoding": {"deflate;q=0.5, gzip;q=0.1"}}, status: 200, encoding: "deflate"},
+		{name: "wildcard should pick our preferred encoding", options: compression, method: "POST", path: "/echo", body: jsonText(text), header: http.Header{"Accept-Encoding": {"br, *;q=0.2"}}, status: 200, encoding: "gzip", response: jsonText(text)},
+		{name: "should not use encodings the caller rejects", options: compression, method: "POST", path: "/echo", body: jsonText(text), header: http.Header{"Accept-Encoding": {"gzip;q=0, deflate;q=0"}}, status: 200, response: jsonText(text)},
+		{name: "should not compress w/o Accept-Encoding", options: compression, method: "POST", path: "/echo", body: jsonText(text), status: 200, response: jsonText(text)},
+		{name: "too small", options: []rpc.GatewayOption{rpc.WithCompression(rpc.CompressionMinSize(100))}, method: "POST", path: "/echo", body: jsonText("small"), header: http.Header{"Accept-Encoding": {"gzip"}}, status: 200, response: jsonText("small")},
+		{name: "min size", options: []rpc.GatewayOption{rpc.WithCompression(rpc.CompressionMinSize(100))}, method: "POST", path: "/echo", body: jsonText(strings.Repeat("b", 100)), header: http.Header{"Accept-Encoding": {"gzip"}}, status: 200, encoding: "gzip", response: jsonText(strings.Repeat("b", 100))},
+		{name: "custom", options: []rpc.GatewayOption{rpc.WithCompression(rpc.CompressionEncodings(reverseEncoding))}, method: "POST", path: "/echo", body: jsonText(text + "d"), header: http.Header{"Accept-Encoding": {"gzip, reverse"}}, status: 200, encoding: "reverse", response: jsonText(text + "d")},
+		{name: "custom not accepted", options: []rpc.GatewayOption{rpc.WithCompression(rpc.CompressionEncodings(reverseEncoding))}, method: "POST", path: "/echo", body: jsonText(text), header: http.Header{"Accept-Encoding": {"gzip"}}, status: 200, encoding: "gzip", response: jsonText(text)},
+		{name: "png", options: compression, method: "GET", path: "/file?type=image/png", header: http.Header{"Accept-Encoding": {"gzip"}}, status: 200, response: strings.Repeat("x", 2048)},
+		{name: "zip", options: compression, method: "GET", path: "/file?type=application/zip", header: http.Header{"Accept-Encoding": {"gzip"}}, status: 200, response: strings.Repeat("x", 2048)},
+		{name: "mp4", options: compression, method: "GET", path: "/file?type=video/mp4", header: http.Header{"Accept-Encoding": {"gzip"}}, status: 200, response: strings.Repeat("x", 2048)},
+		{name: "gzip file", options: compression, method: "GET", path: "/file?type=application/gzip", header: http.Header{"Accept-Encoding": {"gzip"}}, status: 200, response: strings.Repeat("x", 2048)},
+		{name: "csv", options: compression, method: "GET", path: "/file?type=text/csv", header: http.Header{"Accept-Encoding": {"gzip"}}, status: 200, encoding: "gzip", response: strings.Repeat("x", 2048)},
+		{name: "gzip request", method: "POST", path: "/echo", body: gzipped.String(), header: http.Header{"Content-Encoding": {"gzip"}}, status: 200, response: `{"Text":"zipped"}`},
+		{name: "bad gzip request", method: "POST", path: "/echo", body: `{"Text":"not zipped"}`, header: http.Header{"Content-Encoding": {"gzip"}}, status: 400},
+		{name: "unsupported request encoding", method: "POST", path: "/echo", body: `{"Text":"hi"}`, header: http.Header{"Content-Encoding": {"compress"}}, status: 415},
+	}
+	for _, test := range tests {
+		r := suite.Require()
+
+		// The "Echo" endpoint replies w/ the text it received and the "File" endpoint replies w/ raw data
+		// of the requested content type.
+		gw := suite.newGateway(test.options...)
+		gw.Register(testEndpoint("POST", "/echo", "Echo", echo(&gw, func() interface{} { return &echoRequest{} })))
+		gw.Register(testEndpoint("GET", "/file", "File", func(w http.ResponseWriter, req *http.Request) {
+			rpc.Reply(w, req, 200, compressionFile{
+				content:     strings.Repeat("x", 2048),
+				contentType: req.URL.Query().Get("type"),
+			})
+		}))
+
+		req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		for key, values := range test.header {
+			req.Header[http.CanonicalHeaderKey(key)] = values
+		}
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+
+		r.Equal(test.status, w.Code, test.name)
+		r.Equal(test.encoding, w.Header().Get("Content-Encoding"), test.name)
+		if test.encoding != "" {
+			r.Contains(w.Header().Values("Vary"), "Accept-Encoding", test.name)
+		}
+		if test.response == "" {
+			continue
+		}
+
+		response := w.Body.String()
+		switch test.encoding {
+		case "gzip":
+			r.Less(w.Body.Len(), len(test.response), test.name)
+			response = suite.gunzip(w.Body.Bytes())
+		case "reverse":
+			response = reverseString(response)
+		}
+		if strings.HasPrefix(test.response, "{") {
+			r.JSONEq(test.response, response, test.name)
+		} else {
+			r.Equal(test.response, response, test.name)
+		}
+	}
+}
+
+// Ensures that the client asks for compressed responses and transparently decompresses them, and that it
+// compresses large request bodies when configured to do so.
+func (suite *GatewaySuite) TestWithClientCompression() {
+	r := suite.Require()
+	var contentEncodings []string
+	gw := suite.newGateway(rpc.WithCompression(rpc.CompressionMinSize(10)))
+	gw.Register(testEndpoint("POST", "/echo", "Echo", echo(&gw, func() interface{} { return &echoRequest{} })))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		contentEncodings = append(contentEncodings, req.Header.Get("Content-Encoding"))
+		gw.ServeHTTP(w, req)
+	}))
+	defer server.Close()
+
+	var contentEncoding string
+	client := rpc.NewClient("TestService", server.URL, rpc.WithClientMiddleware(
+		func(request *http.Request, next rpc.RoundTripperFunc) (*http.Response, error) {
+			response, err := next(request)
+			if err == nil {
+				contentEncoding = response.Header.Get("Content-Encoding")
+			}
+			return response, err
+		},
+	))
+
+	text := strings.Repeat("e", 100)
+	response := echoRequest{}
+	r.NoError(client.Invoke(context.Background(), "POST", "/echo", &echoRequest{Text: text}, &response))
+	r.Equal(text, response.Text)
+	r.Equal("", contentEncoding, "Client middleware should see the decompressed response")
+
+	contentEncodings = nil
+	client = rpc.NewClient("TestService", server.URL, rpc.WithClientCompression(rpc.CompressionMinSize(50)))
+	r.NoError(client.Invoke(context.Background(), "POST", "/echo", &echoRequest{Text: "small"}, &response))
+	r.Equal("small", response.Text)
+
+	text = strings.Repeat("f", 100)
+	r.NoError(client.Invoke(context.Background(), "POST", "/echo", &echoRequest{Text: text}, &response))
+	r.Equal(text, response.Text)
+	r.Equal([]string{"", "gzip"}, contentEncodings)
+}
+
+func (suite *GatewaySuite) gunzip(data []byte) string {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	suite.Require().NoError(err)
+	result, err := ioutil.ReadAll(reader)
+	suite.Require().NoError(err)
+	return string(result)
+}
+
+// reverseEncoding is a silly "compression" algorithm that just reverses the bytes. It
+// lets us test custom encodings w/o pulling in a Brotli library.
+var reverseEncoding = rpc.Encoding{
+	Name: "reverse",
+	NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+		return &reverseWriter{writer: w}, nil
+	},
+	NewReader: func(r io.Reader) (io.ReadCloser, error) {
+		data, err := ioutil.ReadAll(r)
+		return ioutil.NopCloser(strings.NewReader(reverseString(string(data)))), err
+	},
+}
+
+type reverseWriter struct {
+	writer io.Writer
+	buffer bytes.Buffer
+}
+
+func (w *reverseWriter) Write(data []byte) (int, error) {
+	return w.buffer.Write(data)
+}
+
+func (w *reverseWriter) Close() error {
+	_, err := w.writer.Write([]byte(reverseString(w.buffer.String())))
+	return err
+}
+
+func reverseString(value string) string {
+	runes := []rune(value)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
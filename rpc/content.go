@@ -0,0 +1,232 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentModTimeReader allows raw responses to specify when the data they represent was last modified. The
+// gateway sends it as the "Last-Modified" header and uses it to answer "If-Modified-Since" requests w/ a 304.
+type ContentModTimeReader interface {
+	// ContentModTime returns the time that the content was last modified. The zero time means "unknown".
+	ContentModTime() time.Time
+}
+
+// ContentModTimeWriter allows raw responses to capture the "Last-Modified" time of the data received from
+// the gateway. This is utilized by clients to automatically populate the value.
+type ContentModTimeWriter interface {
+	// SetContentModTime applies the last modified time of the content to the response.
+	SetContentModTime(modTime time.Time)
+}
+
+// ContentETagReader allows raw responses to specify an entity tag that identifies this specific version of
+// the data. The gateway sends it as the "ETag" header and uses it to answer "If-None-Match" requests w/ a 304. You
+// can include the quotes (e.g. `"v1"` or `W/"v1"`) or not; we'll add them if you don't.
+type ContentETagReader interface {
+	// ContentETag returns the entity tag for this version of the content.
+	ContentETag() string
+}
+
+// ContentETagWriter allows raw responses to capture the "ETag" of the data received from the gateway. This
+// is utilized by clients to automatically populate the value.
+type ContentETagWriter interface {
+	// SetContentETag applies the entity tag of the content to the response.
+	SetContentETag(etag string)
+}
+
+// ContentRangeWriter allows raw responses to capture which bytes of the content the gateway actually sent. This
+// is utilized by clients to automatically populate the value. When the gateway sends the entire content, the
+// start is 0. The end is inclusive (just like the "Content-Range" header) and both the end and size are -1
+// when the gateway didn't tell us how big the content is.
+type ContentRangeWriter interface {
+	// SetContentRange applies the byte range of the content that the response contains.
+	SetContentRange(start int64, end int64, size int64)
+}
+
+// contextKeyContentOffset is where WithContentOffset stores the offset/validator for the call.
+type contextKeyContentOffset struct{}
+
+// contentOffset describes the part of some raw content that the client wants the gateway to send.
+type contentOffset struct {
+	offset    int64
+	validator string
+}
+
+// WithContentOffset asks the gateway to skip the first 'offset' bytes of the raw content returned by the
+// service call that you make w/ this context. Use this to resume a download that was interrupted:
+//
+//     ctx = rpc.WithContentOffset(ctx, alreadyDownloaded, export.ETag)
+//     export, err := client.DownloadExport(ctx, &exports.DownloadExportRequest{ID: id})
+//
+// The validator is the ETag (or "Last-Modified" time) you received w/ the original download. The gateway
+// only honors the offset when the content hasn't changed since then; otherwise it sends all of it again.
+// Leave it blank to resume no matter what. If your response implements ContentRangeWriter, check the
+// start of the range to see which one you got. This only applies to calls whose responses are raw content
+// and the gateway can only skip bytes when the service's content is an io.ReadSeeker (e.g. an *os.File).
+func WithContentOffset(ctx context.Context, offset int64, validator string) context.Context {
+	return context.WithValue(ctx, contextKeyContentOffset{}, contentOffset{offset: offset, validator: validator})
+}
+
+// writeContentOffset adds the "Range" (and "If-Range") headers to the request when the context
+// says that the caller only wants part of the content.
+func writeContentOffset(ctx context.Context, request *http.Request) {
+	offset, ok := ctx.Value(contextKeyContentOffset{}).(contentOffset)
+	if !ok || offset.offset <= 0 {
+		return
+	}
+	request.Header.Set("Range", "bytes="+strconv.FormatInt(offset.offset, 10)+"-")
+	if offset.validator != "" {
+		request.Header.Set("If-Range", offset.validator)
+	}
+}
+
+// readContentRange determines which bytes of the content are in the response. For 206 responses that
+// comes from the "Content-Range" header. Otherwise, the response contains all of the content.
+func readContentRange(response *http.Response) (start int64, end int64, size int64) {
+	if response.StatusCode != http.StatusPartialContent {
+		if response.ContentLength < 0 {
+			return 0, -1, -1
+		}
+		return 0, response.ContentLength - 1, response.ContentLength
+	}
+
+	// Should look like "bytes 100-199/1000" or "bytes 100-199/*" when the size is unknown.
+	contentRange := strings.TrimPrefix(response.Header.Get("Content-Range"), "bytes ")
+	slash := strings.Index(contentRange, "/")
+	dash := strings.Index(contentRange, "-")
+	if slash < 0 || dash < 0 || dash > slash {
+		return 0, -1, -1
+	}
+	start, _ = strconv.ParseInt(contentRange[:dash], 10, 64)
+	end, err := strconv.ParseInt(contentRange[dash+1:slash], 10, 64)
+	if err != nil {
+		end = -1
+	}
+	size, err = strconv.ParseInt(contentRange[slash+1:], 10, 64)
+	if err != nil {
+		size = -1
+	}
+	return start, end, size
+}
+
+// writeContent writes the raw content response. Unlike the standard 'respond' behavior, this supports "Range"
+// requests when the content is an io.ReadSeeker and conditional requests when the response implements
+// ContentETagReader and/or ContentModTimeReader.
+func writeContent(w http.ResponseWriter, req *http.Request, status int, value ContentReader) {
+	content := value.Content()
+	if content == nil {
+		w.WriteHeader(status)
+		return
+	}
+	defer func() { _ = content.Close() }()
+
+	header := w.Header()
+	header.Set("Content-Type", contentType(value))
+	header.Set("Content-Disposition", contentDisposition(value))
+	if etag := contentETag(value); etag != "" {
+		header.Set("ETag", etag)
+	}
+	modTime := contentModTime(value)
+
+	// Ranges and 304s only make sense when the success status is a plain-old 200. If you told us to
+	// reply w/ a 201 or something else, that's what you'll get.
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+		_, _ = io.Copy(w, content)
+		return
+	}
+
+	// The standard library already does all of the Range/If-Range/If-None-Match heavy lifting for us
+	// as long as we can seek around the content.
+	if seeker, ok := content.(io.ReadSeeker); ok {
+		http.ServeContent(w, req, "", modTime, seeker)
+		return
+	}
+
+	if !modTime.IsZero() {
+		header.Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+	if contentNotModified(req, header.Get("ETag"), modTime) {
+		header.Del("Content-Type")
+		header.Del("Content-Disposition")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(status)
+	if req.Method != http.MethodHead {
+		_, _ = io.Copy(w, content)
+	}
+}
+
+// contentNotModified determines if the caller already has this version of the content based on the
+// "If-None-Match" and "If-Modified-Since" headers. Just like the standard library, "If-None-Match"
+// wins when the caller supplies both.
+func contentNotModified(req *http.Request, etag string, modTime time.Time) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etag != "" && etagMatches(ifNoneMatch, etag)
+	}
+	if ifModifiedSince := req.Header.Get("If-Modified-Since"); ifModifiedSince != "" && !modTime.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		return err == nil && !modTime.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// etagMatches uses the "weak" comparison to see if any of the tags in the "If-None-Match" header match
+// the content's entity tag (e.g. `W/"v1", "v2"`).
+func etagMatches(ifNoneMatch string, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// contentType assumes "application/octet-stream" unless the response implements ContentTypeReader.
+func contentType(value ContentReader) string {
+	if typeReader, ok := value.(ContentTypeReader); ok && typeReader.ContentType() != "" {
+		return typeReader.ContentType()
+	}
+	return "application/octet-stream"
+}
+
+// contentDisposition is "inline" unless the response implements ContentFileNameReader, in
+// which case it's an "attachment" w/ that file name.
+func contentDisposition(value ContentReader) string {
+	fileNameReader, ok := value.(ContentFileNameReader)
+	if !ok || fileNameReader.ContentFileName() == "" {
+		return "inline"
+	}
+	return `attachment; filename="` + strings.ReplaceAll(fileNameReader.ContentFileName(), `"`, `\"`) + `"`
+}
+
+// contentETag returns the properly quoted entity tag of the response if it implements ContentETagReader.
+func contentETag(value ContentReader) string {
+	etagReader, ok := value.(ContentETagReader)
+	if !ok {
+		return ""
+	}
+	etag := strings.TrimSpace(etagReader.ContentETag())
+	if etag == "" || strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// contentModTime returns the last modified time of the response if it implements ContentModTimeReader.
+func contentModTime(value ContentReader) time.Time {
+	if modTimeReader, ok := value.(ContentModTimeReader); ok {
+		return modTimeReader.ContentModTime()
+	}
+	return time.Time{}
+}
@@ -0,0 +1,207 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+var contentModTime = time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC)
+
+const contentText = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// contentRequest decides what kind of raw response the gateway returns.
+type contentRequest struct {
+	Seekable bool
+}
+
+// contentResponse is a raw response w/ all of the optional reader interfaces implemented.
+type contentResponse struct {
+	seekable bool
+}
+
+func (res contentResponse) Content() io.ReadCloser {
+	if res.seekable {
+		return contentReadSeeker{strings.NewReader(contentText)}
+	}
+	return ioutil.NopCloser(strings.NewReader(contentText))
+}
+
+func (res contentResponse) ContentType() string {
+	return "text/plain"
+}
+
+func (res contentResponse) ContentETag() string {
+	return "v1"
+}
+
+func (res contentResponse) ContentModTime() time.Time {
+	return contentModTime
+}
+
+type contentReadSeeker struct {
+	*strings.Reader
+}
+
+func (contentReadSeeker) Close() error {
+	return nil
+}
+
+// contentDownload is what the client fills in w/ the raw response.
+type contentDownload struct {
+	content     io.ReadCloser
+	etag        string
+	modTime     time.Time
+	start, end  int64
+	size        int64
+	rangeCalled bool
+}
+
+func (d *contentDownload) SetContent(content io.ReadCloser) {
+	d.content = content
+}
+
+func (d *contentDownload) SetContentETag(etag string) {
+	d.etag = etag
+}
+
+func (d *contentDownload) SetContentModTime(modTime time.Time) {
+	d.modTime = modTime
+}
+
+func (d *contentDownload) SetContentRange(start int64, end int64, size int64) {
+	d.start, d.end, d.size = start, end, size
+	d.rangeCalled = true
+}
+
+func (d *contentDownload) text() string {
+	defer d.content.Close()
+	data, _ := ioutil.ReadAll(d.content)
+	return string(data)
+}
+
+// contentEndpoint creates the "GET /download" endpoint, which responds w/ seekable content or not depending on
+// what the request asks for.
+func contentEndpoint(gw *rpc.Gateway) rpc.Endpoint {
+	return testEndpoint("GET", "/download", "Download", func(w http.ResponseWriter, req *http.Request) {
+		serviceRequest := contentRequest{}
+		if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
+			rpc.Fail(w, req, err)
+			return
+		}
+		rpc.Reply(w, req, 200, contentResponse{seekable: serviceRequest.Seekable})
+	})
+}
+
+// Ensures that seekable content supports Range requests (and non-seekable content just sends everything), that we
+// respond w/ a 304 when the caller already has the current version of the content, and that we never compress
+// ranged content since the offsets need to line up w/ the raw bytes.
+func (suite *GatewaySuite) TestReply_content() {
+	modified := contentModTime.Format(http.TimeFormat)
+	conditional := func(path string) []exchange {
+		return []exchange{
+			{method: "GET", path: path, header: http.Header{"If-None-Match": {`"v1"`}}, status: 304, noBody: true},
+			{name: "should use weak comparison for If-None-Match", method: "GET", path: path, header: http.Header{"If-None-Match": {`"v0", W/"v1"`}}, status: 304},
+			{method: "GET", path: path, header: http.Header{"If-None-Match": {`"v0"`}}, status: 200, response: contentText},
+			{method: "GET", path: path, header: http.Header{"If-Modified-Since": {modified}}, status: 304},
+			{method: "GET", path: path, header: http.Header{"If-Modified-Since": {contentModTime.Add(-time.Hour).Format(http.TimeFormat)}}, status: 200, response: contentText},
+			{name: "If-None-Match should win over If-Modified-Since", method: "GET", path: path, header: http.Header{"If-None-Match": {`"v0"`}, "If-Modified-Since": {modified}}, status: 200},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		options   []rpc.GatewayOption
+		exchanges []exchange
+	}{
+		{
+			name: "range",
+			exchanges: []exchange{
+				{method: "GET", path: "/download?Seekable=true", status: 200, response: contentText, responseHeader: http.Header{
+					"Accept-Ranges": {"bytes"},
+					"Content-Type":  {"text/plain"},
+					"ETag":          {`"v1"`},
+					"Last-Modified": {modified},
+				}},
+				{method: "GET", path: "/download?Seekable=true", header: http.Header{"Range": {"bytes=10-"}}, status: 206, response: contentText[10:], responseHeader: http.Header{"Content-Range": {"bytes 10-35/36"}}},
+				{method: "GET", path: "/download?Seekable=true", header: http.Header{"Range": {"bytes=0-3"}}, status: 206, response: "0123"},
+				{name: "matching If-Range should honor the range", method: "GET", path: "/download?Seekable=true", header: http.Header{"Range": {"bytes=10-"}, "If-Range": {`"v1"`}}, status: 206, response: contentText[10:]},
+				{name: "stale If-Range should send the whole content", method: "GET", path: "/download?Seekable=true", header: http.Header{"Range": {"bytes=10-"}, "If-Range": {`"v0"`}}, status: 200, response: contentText},
+				{method: "GET", path: "/download?Seekable=true", header: http.Header{"Range": {"bytes=100-"}}, status: 416},
+			},
+		},
+		{
+			name: "not seekable",
+			exchanges: []exchange{
+				{method: "GET", path: "/download", header: http.Header{"Range": {"bytes=10-"}}, status: 200, response: contentText, responseHeader: http.Header{
+					"Accept-Ranges": {},
+					"ETag":          {`"v1"`},
+					"Last-Modified": {modified},
+				}},
+			},
+		},
+		{
+			name:      "conditional",
+			exchanges: append(conditional("/download?Seekable=true"), conditional("/download")...),
+		},
+		{
+			name:    "compression",
+			options: []rpc.GatewayOption{rpc.WithCompression(rpc.CompressionMinSize(1))},
+			exchanges: []exchange{
+				{method: "GET", path: "/download?Seekable=true", header: http.Header{"Accept-Encoding": {"gzip"}}, status: 200, response: contentText, responseHeader: http.Header{"Content-Encoding": {}}},
+				{method: "GET", path: "/download?Seekable=true", header: http.Header{"Accept-Encoding": {"gzip"}, "Range": {"bytes=10-"}}, status: 206, response: contentText[10:], responseHeader: http.Header{"Content-Encoding": {}}},
+			},
+		},
+	}
+	for _, test := range tests {
+		gw := suite.newGateway(test.options...)
+		gw.Register(contentEndpoint(&gw))
+		suite.assertResponses(gw, test.exchanges)
+	}
+}
+
+// Ensures that the client can resume a download and that it captures the range/validators from the response.
+func (suite *GatewaySuite) TestReply_contentResume() {
+	r := suite.Require()
+	gw := suite.newGateway()
+	gw.Register(contentEndpoint(&gw))
+	server := httptest.NewServer(gw)
+	defer server.Close()
+
+	client := rpc.NewClient("TestService", server.URL)
+	ctx := context.Background()
+
+	download := contentDownload{}
+	r.NoError(client.Invoke(ctx, "GET", "/download", &contentRequest{Seekable: true}, &download))
+	r.Equal(contentText, download.text())
+	r.Equal(`"v1"`, download.etag)
+	r.True(contentModTime.Equal(download.modTime))
+	r.True(download.rangeCalled)
+	r.Equal([]int64{0, 35, 36}, []int64{download.start, download.end, download.size})
+
+	download = contentDownload{}
+	ctx = rpc.WithContentOffset(context.Background(), 10, `"v1"`)
+	r.NoError(client.Invoke(ctx, "GET", "/download", &contentRequest{Seekable: true}, &download))
+	r.Equal(contentText[10:], download.text())
+	r.Equal([]int64{10, 35, 36}, []int64{download.start, download.end, download.size})
+
+	download = contentDownload{}
+	ctx = rpc.WithContentOffset(context.Background(), 10, `"v0"`)
+	r.NoError(client.Invoke(ctx, "GET", "/download", &contentRequest{Seekable: true}, &download))
+	r.Equal(contentText, download.text(), "Changed content should be sent in full")
+	r.Equal(int64(0), download.start)
+
+	download = contentDownload{}
+	ctx = rpc.WithContentOffset(context.Background(), 10, "")
+	r.NoError(client.Invoke(ctx, "GET", "/download", &contentRequest{Seekable: false}, &download))
+	r.Equal(contentText, download.text(), "Non-seekable content should be sent in full")
+	r.Equal(int64(0), download.start)
+}
@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/cookiejar"
+)
+
+// SetCookie adds a "Set-Cookie" header to the gateway's response for the current call. This lets your service
+// functions manage things like browser sessions w/o any raw HTTP plumbing:
+//
+//     func (svc *AuthServiceHandler) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
+//         session := svc.Sessions.Create(req.Username)
+//         rpc.SetCookie(ctx, &http.Cookie{Name: "session", Value: session.ID, HttpOnly: true, Secure: true})
+//         return &LoginResponse{}, nil
+//     }
+//
+// Invalid cookies are dropped just like they are in http.SetCookie. Just like SetHeader, this does nothing when
+// the function is not being invoked through a gateway, and it won't affect "ASYNC" or "SSE" functions.
+func SetCookie(ctx context.Context, cookie *http.Cookie) {
+	header, ok := ctx.Value(contextKeyResponseHeaders{}).(http.Header)
+	if !ok || cookie == nil {
+		return
+	}
+	if value := cookie.String(); value != "" {
+		header.Add("Set-Cookie", value)
+	}
+}
+
+// WithCookieJar has the client remember the cookies that the gateway sets and send them back on future calls, just
+// like a browser does. This lets you call services that rely on cookie-based sessions. Each client instance keeps
+// its own cookies, so create a separate client per user. When the jar is nil, the client uses a new, empty
+// jar from the standard library's "net/http/cookiejar" package.
+//
+// The jar belongs to the RPC client, not the underlying HTTP client, so this works even if you supply your own
+// HTTP client via WithHTTPClient().
+func WithCookieJar(jar http.CookieJar) ClientOption {
+	return func(rpcClient *Client) {
+		if jar == nil {
+			jar, _ = cookiejar.New(nil)
+		}
+		rpcClient.CookieJar = jar
+	}
+}
+
+// applyCookieJar is client middleware that sends the jar's cookies w/ each request and saves the ones that come back.
+func applyCookieJar(jar http.CookieJar) ClientMiddlewareFunc {
+	return func(request *http.Request, next RoundTripperFunc) (*http.Response, error) {
+		for _, cookie := range jar.Cookies(request.URL) {
+			// Cookies from header/cookie request fields are more specific, so they win.
+			if _, err := request.Cookie(cookie.Name); err == http.ErrNoCookie {
+				request.AddCookie(cookie)
+			}
+		}
+
+		response, err := next(request)
+		if err != nil {
+			return nil, err
+		}
+		if cookies := response.Cookies(); len(cookies) > 0 {
+			jar.SetCookies(request.URL, cookies)
+		}
+		return response, nil
+	}
+}
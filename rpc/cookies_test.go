@@ -0,0 +1,83 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+type cookieRequest struct {
+	Name string
+}
+
+type cookieResponse struct {
+	Session string
+	Theme   string
+}
+
+// Ensures that the gateway writes a Set-Cookie header for each valid cookie, and that clients only send
+// back cookies when they have a jar and that each client has its own cookies.
+func (suite *GatewaySuite) TestSetCookie() {
+	r := suite.Require()
+	gw := suite.newGateway()
+	gw.Register(testEndpoint("POST", "/login", "Login", func(w http.ResponseWriter, req *http.Request) {
+		serviceRequest := cookieRequest{}
+		if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
+			rpc.Fail(w, req, err)
+			return
+		}
+		rpc.SetCookie(req.Context(), &http.Cookie{Name: "session", Value: serviceRequest.Name, Path: "/", HttpOnly: true})
+		rpc.SetCookie(req.Context(), &http.Cookie{Name: "theme", Value: "dark"})
+		rpc.SetCookie(req.Context(), &http.Cookie{Name: "bad name"})
+		rpc.SetCookie(req.Context(), nil)
+		rpc.Reply(w, req, 200, cookieResponse{})
+	}))
+	gw.Register(testEndpoint("GET", "/whoami", "Whoami", func(w http.ResponseWriter, req *http.Request) {
+		response := cookieResponse{}
+		if cookie, err := req.Cookie("session"); err == nil {
+			response.Session = cookie.Value
+		}
+		if cookie, err := req.Cookie("theme"); err == nil {
+			response.Theme = cookie.Value
+		}
+		rpc.Reply(w, req, 200, response)
+	}))
+
+	server := httptest.NewServer(gw)
+	defer server.Close()
+
+	suite.assertExchanges(server, []exchange{
+		{
+			name:           "login",
+			method:         "POST",
+			path:           "/login",
+			status:         200,
+			responseHeader: http.Header{"Set-Cookie": {"session=; Path=/; HttpOnly", "theme=dark"}},
+		},
+	})
+
+	call := func(client rpc.Client) cookieResponse {
+		response := cookieResponse{}
+		r.NoError(client.Invoke(context.Background(), "GET", "/whoami", nil, &response))
+		return response
+	}
+
+	noJar := rpc.NewClient("TestService", server.URL)
+	r.NoError(noJar.Invoke(context.Background(), "POST", "/login", &cookieRequest{Name: "dude"}, &cookieResponse{}))
+	r.Equal(cookieResponse{}, call(noJar), "Should not remember cookies w/o a jar")
+
+	dude := rpc.NewClient("TestService", server.URL, rpc.WithCookieJar(nil))
+	walter := rpc.NewClient("TestService", server.URL, rpc.WithCookieJar(nil))
+	r.NoError(dude.Invoke(context.Background(), "POST", "/login", &cookieRequest{Name: "dude"}, &cookieResponse{}))
+	r.NoError(walter.Invoke(context.Background(), "POST", "/login", &cookieRequest{Name: "walter"}, &cookieResponse{}))
+	r.Equal(cookieResponse{Session: "dude", Theme: "dark"}, call(dude))
+	r.Equal(cookieResponse{Session: "walter", Theme: "dark"}, call(walter))
+
+	suite.NotPanics(func() {
+		rpc.SetCookie(context.Background(), &http.Cookie{Name: "session", Value: "abc"})
+	}, "Should do nothing outside of a gateway")
+}
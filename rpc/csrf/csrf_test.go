@@ -0,0 +1,129 @@
+// +build unit
+
+package csrf_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/monadicstack/frodo/rpc/csrf"
+	"github.com/stretchr/testify/suite"
+)
+
+type CSRFSuite struct {
+	suite.Suite
+}
+
+// serve runs the request through the middleware and returns the response along w/ whether the
+// middleware let the request through to the handler.
+func (suite *CSRFSuite) serve(mw func(http.ResponseWriter, *http.Request, http.HandlerFunc), req *http.Request) (*httptest.ResponseRecorder, bool) {
+	w := httptest.NewRecorder()
+	called := false
+	mw(w, req, func(w http.ResponseWriter, req *http.Request) {
+		called = true
+	})
+	return w, called
+}
+
+// tokenCookie returns the CSRF cookie set by the response (if any).
+func (suite *CSRFSuite) tokenCookie(w *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// Ensures that callers w/o a token get one, and callers w/ one keep theirs.
+func (suite *CSRFSuite) TestMiddleware_issueToken() {
+	r := suite.Require()
+	mw := csrf.Middleware()
+
+	w, called := suite.serve(mw, httptest.NewRequest("GET", "/user/123", nil))
+	r.True(called, "Safe requests don't need a token")
+	cookie := suite.tokenCookie(w, csrf.CookieName)
+	r.NotNil(cookie, "Should issue a token when the caller doesn't have one")
+	r.NotEmpty(cookie.Value)
+	r.Equal("/", cookie.Path)
+	r.False(cookie.HttpOnly, "The JS client needs to be able to read the token")
+	r.False(cookie.Secure)
+
+	w, _ = suite.serve(mw, httptest.NewRequest("GET", "/user/456", nil))
+	r.NotEqual(cookie.Value, suite.tokenCookie(w, csrf.CookieName).Value, "Tokens should be random")
+
+	req := httptest.NewRequest("GET", "/user/123", nil)
+	req.AddCookie(&http.Cookie{Name: csrf.CookieName, Value: "abc"})
+	w, _ = suite.serve(mw, req)
+	r.Nil(suite.tokenCookie(w, csrf.CookieName), "Should not replace an existing token")
+
+	w, _ = suite.serve(csrf.Middleware(csrf.WithSecureCookie()), httptest.NewRequest("GET", "/user/123", nil))
+	r.True(suite.tokenCookie(w, csrf.CookieName).Secure)
+}
+
+// Ensures that state-changing requests must echo the cookie's token in the header.
+func (suite *CSRFSuite) TestMiddleware_validate() {
+	r := suite.Require()
+	mw := csrf.Middleware()
+
+	newRequest := func(method string, cookieToken string, headerToken string) *http.Request {
+		req := httptest.NewRequest(method, "/user/123", nil)
+		if cookieToken != "" {
+			req.AddCookie(&http.Cookie{Name: csrf.CookieName, Value: cookieToken})
+		}
+		if headerToken != "" {
+			req.Header.Set(csrf.HeaderName, headerToken)
+		}
+		return req
+	}
+
+	for _, method := range []string{"POST", "PUT", "PATCH", "DELETE"} {
+		_, called := suite.serve(mw, newRequest(method, "abc", "abc"))
+		r.True(called, "%s: Should allow matching tokens", method)
+
+		w, called := suite.serve(mw, newRequest(method, "abc", "xyz"))
+		r.False(called, "%s: Should reject mismatched tokens", method)
+		r.Equal(403, w.Code)
+		r.Contains(w.Body.String(), csrf.ErrorCode)
+
+		_, called = suite.serve(mw, newRequest(method, "abc", ""))
+		r.False(called, "%s: Should reject missing header", method)
+
+		_, called = suite.serve(mw, newRequest(method, "", "abc"))
+		r.False(called, "%s: Should reject missing cookie", method)
+	}
+
+	for _, method := range []string{"GET", "HEAD", "OPTIONS"} {
+		_, called := suite.serve(mw, newRequest(method, "abc", "xyz"))
+		r.True(called, "%s: Safe requests don't need a token", method)
+	}
+
+	req := newRequest("POST", "", "")
+	req.Header.Set("Authorization", "Token 12345")
+	_, called := suite.serve(mw, req)
+	r.True(called, "Requests that aren't cookie-authenticated don't need a token")
+}
+
+// Ensures that you can change the names of the cookie and header.
+func (suite *CSRFSuite) TestMiddleware_customNames() {
+	r := suite.Require()
+	mw := csrf.Middleware(csrf.WithCookieName("xsrf"), csrf.WithHeaderName("X-XSRF"))
+
+	req := httptest.NewRequest("POST", "/user/123", nil)
+	req.AddCookie(&http.Cookie{Name: "xsrf", Value: "abc"})
+	req.Header.Set("X-XSRF", "abc")
+	_, called := suite.serve(mw, req)
+	r.True(called)
+
+	req = httptest.NewRequest("POST", "/user/123", nil)
+	req.AddCookie(&http.Cookie{Name: csrf.CookieName, Value: "abc"})
+	req.Header.Set(csrf.HeaderName, "abc")
+	w, called := suite.serve(mw, req)
+	r.False(called, "Should ignore the default names")
+	r.NotNil(suite.tokenCookie(w, "xsrf"))
+}
+
+func TestCSRFSuite(t *testing.T) {
+	suite.Run(t, new(CSRFSuite))
+}
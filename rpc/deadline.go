@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// TimeoutHeader is the HTTP header that clients use to tell the gateway how many milliseconds the caller is
+// willing to wait for a response. The gateway applies it as a deadline on the request's context, so your
+// handler (and any calls that it makes to other services) give up once the caller has stopped waiting. We
+// send the remaining time rather than the deadline itself so that clock skew between machines doesn't matter.
+const TimeoutHeader = "X-RPC-Timeout"
+
+// writeTimeoutHeader sends the time left before the call's deadline (if it has one) in the "X-RPC-Timeout" header.
+func writeTimeoutHeader(request *http.Request, next RoundTripperFunc) (*http.Response, error) {
+	if deadline, ok := request.Context().Deadline(); ok {
+		// Round up so that a call w/ less than a millisecond left doesn't look like it has no time at all.
+		remaining := (time.Until(deadline) + time.Millisecond - 1) / time.Millisecond
+		if remaining > 0 {
+			request.Header.Set(TimeoutHeader, strconv.FormatInt(int64(remaining), 10))
+		}
+	}
+	return next(request)
+}
+
+// restoreTimeout applies the caller's "X-RPC-Timeout" header as a deadline on the request context. It can only
+// shorten a deadline that the context already has. Bad/missing values are ignored since the caller can still
+// give up on its own.
+func restoreTimeout(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	millis, err := strconv.ParseInt(req.Header.Get(TimeoutHeader), 10, 64)
+	if err != nil || millis <= 0 {
+		next(w, req)
+		return
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), time.Duration(millis)*time.Millisecond)
+	defer cancel()
+	next(w, req.WithContext(ctx))
+}
@@ -0,0 +1,47 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+// Ensures that the client sends the call's remaining time and the gateway applies it to the handler's context,
+// and that the gateway ignores timeouts that it can't use.
+func (suite *GatewaySuite) TestTimeoutHeader() {
+	r := suite.Require()
+	var deadline time.Time
+	gw := suite.newGateway()
+	gw.Register(testEndpoint("GET", "/deadline", "Deadline", func(w http.ResponseWriter, req *http.Request) {
+		deadline, _ = req.Context().Deadline()
+		rpc.Reply(w, req, 200, struct{}{})
+	}))
+	server := httptest.NewServer(gw)
+	defer server.Close()
+
+	client := rpc.NewClient("TestService", server.URL, rpc.WithTimeout(time.Minute))
+	r.NoError(client.Invoke(context.Background(), "GET", "/deadline", &struct{}{}, &struct{}{}))
+	r.WithinDuration(time.Now().Add(time.Minute), deadline, 5*time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	r.NoError(client.Invoke(ctx, "GET", "/deadline", &struct{}{}, &struct{}{}))
+	r.WithinDuration(time.Now().Add(10*time.Second), deadline, 5*time.Second)
+
+	client = rpc.NewClient("TestService", server.URL, rpc.WithTimeout(0))
+	r.NoError(client.Invoke(context.Background(), "GET", "/deadline", &struct{}{}, &struct{}{}))
+	r.True(deadline.IsZero(), "Calls w/o a deadline shouldn't give the handler one")
+
+	for _, value := range []string{"abc", "-5", "0"} {
+		deadline = time.Time{}
+		suite.assertExchanges(server, []exchange{
+			{name: value, method: "GET", path: "/deadline", header: http.Header{rpc.TimeoutHeader: {value}}, status: 200},
+		})
+		r.True(deadline.IsZero(), "Timeout '%s' shouldn't give the handler a deadline", value)
+	}
+}
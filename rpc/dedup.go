@@ -0,0 +1,116 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/monadicstack/frodo/rpc/dedup"
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/ids"
+	"github.com/urfave/negroni"
+)
+
+// InvocationHeader is the request header that contains the unique id of a single call. Retries of the
+// same call send the same id, so "DEDUPE" functions can tell that they've already handled it.
+const InvocationHeader = "X-Invocation-ID"
+
+// DuplicateInvocationCode is the error code of the 409 failure that the gateway responds with when a "DEDUPE"
+// function receives an invocation id that it has already seen.
+const DuplicateInvocationCode = "DUPLICATE_INVOCATION"
+
+// WithDedupStore lets you decide where the gateway keeps track of the invocation ids for "DEDUPE" service
+// functions. By default, the gateway keeps them in memory, so you only need this when you run multiple instances
+// of your service and need every instance to catch a duplicate, no matter which one handled the original call.
+func WithDedupStore(store dedup.Store) GatewayOption {
+	return func(gw *Gateway) {
+		gw.DedupStore = store
+	}
+}
+
+// WithInvocationID makes the calls that use this context send the given invocation id rather than a new one for
+// each call. Clients already reuse the id when their middleware retries a request, but if you retry the call
+// yourself, use this so that "DEDUPE" functions can tell that it's the same call:
+//
+//     ctx = rpc.WithInvocationID(ctx, ids.New())
+//     for attempt := 0; attempt < 3; attempt++ {
+//         if _, err = payments.Charge(ctx, &ChargeRequest{...}); err == nil || !isTransient(err) {
+//             break
+//         }
+//     }
+//
+// Only use this context for retries of a single call; every call that uses it looks like the same call.
+func WithInvocationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKeyInvocationID{}, id)
+}
+
+type contextKeyInvocationID struct{}
+
+// writeInvocationID tags requests that change state w/ the invocation id from the context or a new one. Requests
+// that don't change state (e.g. GET) can safely run more than once, so they don't need one.
+func writeInvocationID(ctx context.Context, request *http.Request) {
+	if !changesState(request.Method) {
+		return
+	}
+	id, _ := ctx.Value(contextKeyInvocationID{}).(string)
+	if id == "" {
+		id = ids.New()
+	}
+	request.Header.Set(InvocationHeader, id)
+}
+
+// deduplicate is gateway middleware that rejects calls to "DEDUPE" endpoints w/ a 409 when we've already seen their
+// invocation id. It runs after all of your middleware so that calls rejected by it (e.g. an expired token that the
+// client refreshes and retries) don't use up their invocation id. Calls w/o an invocation id are always allowed,
+// and calls that fail give their id back so that the caller can retry them.
+func deduplicate(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	endpoint := EndpointFromContext(req.Context())
+	if endpoint == nil || endpoint.Dedupe <= 0 || req.Method != endpoint.Method || !changesState(req.Method) {
+		next(w, req)
+		return
+	}
+	id := req.Header.Get(InvocationHeader)
+	gw, ok := req.Context().Value(contextKeyGateway{}).(*Gateway)
+	if id == "" || !ok || gw.DedupStore == nil {
+		next(w, req)
+		return
+	}
+
+	// Invocation ids are only unique per call, so make sure that we don't confuse calls to different functions.
+	key := endpoint.String() + ":" + id
+	added, err := gw.DedupStore.Add(req.Context(), key, endpoint.Dedupe)
+	if err != nil {
+		Fail(w, req, errors.Unavailable("unable to check invocation id: %v", err))
+		return
+	}
+	if !added {
+		Fail(w, req, errors.WithCode(errors.AlreadyExists("duplicate invocation: %s", id), DuplicateInvocationCode))
+		return
+	}
+	defer releaseFailedInvocation(w, gw.DedupStore, key)
+	next(w, req)
+}
+
+// releaseFailedInvocation removes the invocation id from the store when the call didn't succeed (including when
+// it panicked), so that the caller's retry actually runs rather than getting a 409 for a call that never worked.
+func releaseFailedInvocation(w http.ResponseWriter, store dedup.Store, key string) {
+	writer, ok := w.(negroni.ResponseWriter)
+	if !ok {
+		return
+	}
+	if status := writer.Status(); status >= 200 && status < 300 {
+		return
+	}
+	// The caller may have already given up on the request (e.g. a timeout), but we still need the id back.
+	// If the store fails, the id just stays put until its window expires like it would have before.
+	_ = store.Remove(context.Background(), key)
+}
+
+// changesState returns true for the HTTP methods that aren't safe to repeat (i.e. not GET, HEAD, or OPTIONS).
+func changesState(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,79 @@
+// Package dedup remembers which service calls a gateway has already seen so that it can drop duplicates. Frodo's
+// Go clients tag every call that changes state (POST, PUT, PATCH, DELETE) w/ a unique invocation id. When you mark a
+// service function w/ the "DEDUPE" doc option, the gateway records each invocation id in a Store and rejects any
+// call whose id it has already seen w/ a 409, so a retried call never runs twice.
+//
+// By default, gateways keep the invocation ids in memory which is fine for a single instance of your service. If
+// you run multiple instances behind a load balancer, you'll want to provide a Store backed by something that every
+// instance can see (Redis, your database, etc) so a retry that lands on another instance is still caught:
+//
+//     gateway := paymentsrpc.NewPaymentServiceGateway(service, rpc.WithDedupStore(myRedisDedupStore))
+package dedup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store remembers the invocation ids that the gateway has seen. Implementations must be safe for concurrent
+// use, and Add must be atomic so that two copies of the same call arriving at the same time can't both run.
+type Store interface {
+	// Add records the id for the given window of time. It returns false when the id was already recorded
+	// and its window hasn't expired yet (i.e. the call is a duplicate).
+	Add(ctx context.Context, id string, window time.Duration) (bool, error)
+	// Remove forgets the id so that the next call w/ it is allowed through. The gateway does this when the call
+	// fails so that the caller can retry it.
+	Remove(ctx context.Context, id string) error
+}
+
+// NewMemoryStore creates a Store that keeps the invocation ids in memory for the life of the process. Ids are
+// discarded once their window expires, so memory doesn't grow forever.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{ids: map[string]time.Time{}}
+}
+
+// MemoryStore is the default Store that gateways use. It works great when you run a single instance
+// of your service, but the ids don't survive restarts and aren't shared between processes.
+type MemoryStore struct {
+	evictedAt time.Time
+	mutex     sync.Mutex
+	ids       map[string]time.Time
+}
+
+// Add records the id for the given window of time, returning false if we've already seen it.
+func (store *MemoryStore) Add(_ context.Context, id string, window time.Duration) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	store.evict(now)
+	if expiresAt, ok := store.ids[id]; ok && now.Before(expiresAt) {
+		return false, nil
+	}
+	store.ids[id] = now.Add(window)
+	return true, nil
+}
+
+// Remove forgets the id so that the next call w/ it is allowed through.
+func (store *MemoryStore) Remove(_ context.Context, id string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.ids, id)
+	return nil
+}
+
+// evict discards any ids whose windows have expired. It only scans the ids about once a minute so that
+// busy services don't pay for it on every call. You must already have the lock when calling this.
+func (store *MemoryStore) evict(now time.Time) {
+	if now.Sub(store.evictedAt) < time.Minute {
+		return
+	}
+	store.evictedAt = now
+	for id, expiresAt := range store.ids {
+		if !now.Before(expiresAt) {
+			delete(store.ids, id)
+		}
+	}
+}
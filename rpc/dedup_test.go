@@ -0,0 +1,181 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/dedup"
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+// Ensures that the gateway rejects repeat calls to "DEDUPE" endpoints w/ the same invocation id, but not calls
+// that failed, calls to endpoints w/o a window, GET calls, or calls w/o an invocation id. When it can't reach
+// the store, it fails w/ a 503 rather than risk running the call twice. Each case is a series of calls w/
+// the number of calls that we expect to reach the handlers afterwards. The Go client should send the ids.
+func (suite *GatewaySuite) TestDedupe() {
+	// Each server has a few "DEDUPE" endpoints and one that doesn't dedupe, and counts every call that reaches
+	// a handler. The "Flaky" endpoint fails w/ a 503 the first time it's called.
+	serve := func(store dedup.Store) (*httptest.Server, *int32) {
+		var options []rpc.GatewayOption
+		if store != nil {
+			options = append(options, rpc.WithDedupStore(store))
+		}
+		handled := int32(0)
+		gw := suite.newGateway(options...)
+		for _, r := range []struct {
+			method string
+			name   string
+			window time.Duration
+		}{
+			{method: "POST", name: "Charge", window: time.Minute},
+			{method: "POST", name: "Refund", window: time.Minute},
+			{method: "POST", name: "Flaky", window: time.Minute},
+			{method: "POST", name: "Log"},
+			{method: "GET", name: "Lookup", window: time.Minute},
+		} {
+			name := r.name
+			endpoint := testEndpoint(r.method, "/"+name, name, func(w http.ResponseWriter, req *http.Request) {
+				if atomic.AddInt32(&handled, 1) == 1 && name == "Flaky" {
+					rpc.Fail(w, req, errors.Unavailable("try again"))
+					return
+				}
+				rpc.Reply(w, req, 200, map[string]string{})
+			})
+			endpoint.Dedupe = r.window
+			gw.Register(endpoint)
+		}
+		return httptest.NewServer(gw), &handled
+	}
+	call := func(method string, path string, id string, status int) exchange {
+		return exchange{
+			name:   method + " " + path + " " + id,
+			method: method,
+			path:   path,
+			body:   "{}",
+			header: http.Header{rpc.InvocationHeader: {id}},
+			status: status,
+		}
+	}
+	tests := []struct {
+		name    string
+		store   dedup.Store
+		calls   []exchange
+		handled int32
+	}{
+		{
+			name: "duplicate",
+			calls: []exchange{
+				call("POST", "/Charge", "abc", 200),
+				call("POST", "/Charge", "abc", 409),
+				call("POST", "/Charge", "def", 200),
+				call("POST", "/Refund", "abc", 200),
+			},
+			handled: 3,
+		},
+		{
+			name: "failure then retry",
+			calls: []exchange{
+				call("POST", "/Flaky", "abc", 503),
+				call("POST", "/Flaky", "abc", 200),
+				call("POST", "/Flaky", "abc", 409),
+			},
+			handled: 2,
+		},
+		{
+			name: "ignored",
+			calls: []exchange{
+				call("POST", "/Log", "abc", 200),
+				call("POST", "/Log", "abc", 200),
+				call("GET", "/Lookup", "abc", 200),
+				call("GET", "/Lookup", "abc", 200),
+				call("POST", "/Charge", "", 200),
+				call("POST", "/Charge", "", 200),
+			},
+			handled: 6,
+		},
+		{
+			name:    "store failure",
+			store:   failingDedupStore{},
+			calls:   []exchange{call("POST", "/Charge", "abc", 503)},
+			handled: 0,
+		},
+	}
+	for _, test := range tests {
+		server, handled := serve(test.store)
+		suite.assertExchanges(server, test.calls)
+		suite.Require().Equal(test.handled, atomic.LoadInt32(handled), test.name)
+		server.Close()
+	}
+
+	// The Go client sends an invocation id for calls that change state, and the id comes back as a 409 w/ the
+	// duplicate code when we reuse it.
+	r := suite.Require()
+	server, handled := serve(nil)
+	defer server.Close()
+
+	var sent []string
+	client := rpc.NewClient("TestService", server.URL)
+	transport := http.DefaultTransport
+	client.HTTP.Transport = rpc.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.Header.Get(rpc.InvocationHeader))
+		return transport.RoundTrip(req)
+	})
+
+	response := map[string]string{}
+	r.NoError(client.Invoke(context.Background(), "POST", "/Charge", nil, &response))
+	r.NoError(client.Invoke(context.Background(), "POST", "/Charge", nil, &response))
+	r.NoError(client.Invoke(context.Background(), "GET", "/Lookup", nil, &response))
+	r.Len(sent, 3)
+	r.NotEmpty(sent[0])
+	r.NotEqual(sent[0], sent[1], "Should send a new invocation id for each call")
+	r.Empty(sent[2], "Should not send invocation ids for GET calls")
+
+	ctx := rpc.WithInvocationID(context.Background(), "retry-me")
+	r.NoError(client.Invoke(ctx, "POST", "/Charge", nil, &response))
+	err := client.Invoke(ctx, "POST", "/Charge", nil, &response)
+	r.Error(err)
+	r.Equal(409, errors.Status(err))
+	r.Equal(rpc.DuplicateInvocationCode, errors.Code(err))
+	r.Equal("retry-me", sent[4])
+	r.Equal(int32(4), atomic.LoadInt32(handled))
+}
+
+// Ensures that the memory store forgets ids once their window expires.
+func (suite *GatewaySuite) TestDedupe_memoryStore() {
+	r := suite.Require()
+	store := dedup.NewMemoryStore()
+
+	added, err := store.Add(context.Background(), "abc", 20*time.Millisecond)
+	r.NoError(err)
+	r.True(added)
+
+	added, _ = store.Add(context.Background(), "abc", 20*time.Millisecond)
+	r.False(added, "Should reject ids that are still in their window")
+
+	added, _ = store.Add(context.Background(), "def", 20*time.Millisecond)
+	r.True(added)
+
+	r.NoError(store.Remove(context.Background(), "def"))
+	added, _ = store.Add(context.Background(), "def", 20*time.Millisecond)
+	r.True(added, "Should accept ids that were removed")
+
+	time.Sleep(30 * time.Millisecond)
+	added, _ = store.Add(context.Background(), "abc", 20*time.Millisecond)
+	r.True(added, "Should accept ids once their window expires")
+}
+
+type failingDedupStore struct{}
+
+func (failingDedupStore) Add(context.Context, string, time.Duration) (bool, error) {
+	return false, errors.Unavailable("redis is down")
+}
+
+func (failingDedupStore) Remove(context.Context, string) error {
+	return errors.Unavailable("redis is down")
+}
@@ -0,0 +1,92 @@
+package rpc
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// Deprecation describes an operation that uses the "DEPRECATED" doc option (and, optionally, "SUNSET"):
+//
+//     // GetUser fetches a user's profile.
+//     //
+//     // DEPRECATED use LookupUser
+//     // SUNSET 2027-01-01
+//     GetUser(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error)
+//
+// Gateways respond to deprecated operations w/ a "Deprecation: true" header (and a "Sunset" header when
+// there's a sunset date) so that callers using any HTTP client can tell. Generated Go clients report each
+// call to a deprecated operation to the client's DeprecationHandler before they make it.
+type Deprecation struct {
+	// Notice tells callers what to do instead (e.g. "use LookupUser"). It's blank when the
+	// doc option doesn't say.
+	Notice string
+	// Sunset (optional) is when the operation is going away, formatted as an HTTP date
+	// (e.g. "Fri, 01 Jan 2027 00:00:00 GMT").
+	Sunset string
+}
+
+// DeprecationHandler is a callback that generated clients invoke every time you call a deprecated operation.
+// The name is the "Service.Function" name of the operation.
+type DeprecationHandler func(ctx context.Context, name string, deprecation Deprecation)
+
+// WithDeprecationHandler replaces what the client does when you call a deprecated operation. By default, the
+// client logs a warning (via the standard "log" package) the first time that you call each one. The handler
+// runs before the client makes the call, so it's a good place to bump a metric or fail your tests:
+//
+//     client := users.NewUserServiceClient(address, rpc.WithDeprecationHandler(
+//         func(ctx context.Context, name string, deprecation rpc.Deprecation) {
+//             metrics.Increment("deprecated_calls", "operation", name)
+//         },
+//     ))
+func WithDeprecationHandler(handler DeprecationHandler) ClientOption {
+	return func(rpcClient *Client) {
+		rpcClient.DeprecationHandler = handler
+	}
+}
+
+// WarnDeprecated hands the deprecated operation (the function name, not including the service) to the client's
+// DeprecationHandler. You should NOT call this yourself; generated clients call it for you.
+func (c Client) WarnDeprecated(ctx context.Context, name string, deprecation Deprecation) {
+	handler := c.DeprecationHandler
+	if handler == nil {
+		handler = logDeprecation
+	}
+	handler(ctx, c.Name+"."+name, deprecation)
+}
+
+// loggedDeprecations are the "Service.Function" names of the deprecated operations that logDeprecation
+// has already warned about.
+var loggedDeprecations sync.Map
+
+// logDeprecation is the default DeprecationHandler. It logs a warning the first time that the
+// process calls each deprecated operation.
+func logDeprecation(_ context.Context, name string, deprecation Deprecation) {
+	if _, logged := loggedDeprecations.LoadOrStore(name, true); logged {
+		return
+	}
+	message := "rpc: " + name + " is deprecated"
+	if deprecation.Notice != "" {
+		message += ": " + deprecation.Notice
+	}
+	if deprecation.Sunset != "" {
+		message += " (sunset: " + deprecation.Sunset + ")"
+	}
+	log.Print(message)
+}
+
+// announceDeprecation is gateway middleware that adds the "Deprecation" and "Sunset" headers to the
+// responses of deprecated endpoints. We add them up front so that even failed calls include them.
+func announceDeprecation(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	endpoint := EndpointFromContext(req.Context())
+	if endpoint == nil || endpoint.Deprecation == nil {
+		next(w, req)
+		return
+	}
+	w.Header().Set("Deprecation", "true")
+	if endpoint.Deprecation.Sunset != "" {
+		w.Header().Set("Sunset", endpoint.Deprecation.Sunset)
+	}
+	next(w, req)
+}
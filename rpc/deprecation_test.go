@@ -0,0 +1,64 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+// Ensures that only deprecated endpoints respond w/ the "Deprecation" and "Sunset" headers.
+func (suite *GatewaySuite) TestDeprecation() {
+	ok := func(w http.ResponseWriter, req *http.Request) {
+		rpc.Reply(w, req, 200, map[string]string{})
+	}
+	old := testEndpoint("GET", "/old", "Old", ok)
+	old.Deprecation = &rpc.Deprecation{Notice: "use New", Sunset: "Fri, 01 Jan 2027 00:00:00 GMT"}
+
+	gw := suite.newGateway()
+	gw.Register(old)
+	gw.Register(testEndpoint("GET", "/new", "New", ok))
+	server := httptest.NewServer(gw)
+	defer server.Close()
+
+	suite.assertExchanges(server, []exchange{
+		{
+			name:           "deprecated",
+			method:         "GET",
+			path:           "/old",
+			status:         200,
+			responseHeader: http.Header{"Deprecation": {"true"}, "Sunset": {"Fri, 01 Jan 2027 00:00:00 GMT"}},
+		},
+		{
+			name:           "not deprecated",
+			method:         "GET",
+			path:           "/new",
+			status:         200,
+			responseHeader: http.Header{"Deprecation": {}, "Sunset": {}},
+		},
+	})
+}
+
+// Ensures that the client hands deprecated operations to its handler.
+func (suite *GatewaySuite) TestDeprecation_client() {
+	r := suite.Require()
+
+	var names []string
+	var deprecations []rpc.Deprecation
+	client := rpc.NewClient("TestService", "http://localhost:9999", rpc.WithDeprecationHandler(
+		func(ctx context.Context, name string, deprecation rpc.Deprecation) {
+			names = append(names, name)
+			deprecations = append(deprecations, deprecation)
+		},
+	))
+	client.WarnDeprecated(context.Background(), "Old", rpc.Deprecation{Notice: "use New"})
+	client.WarnDeprecated(context.Background(), "Old", rpc.Deprecation{Notice: "use New"})
+	r.Equal([]string{"TestService.Old", "TestService.Old"}, names)
+	r.Equal(rpc.Deprecation{Notice: "use New"}, deprecations[0])
+
+	// The default handler just logs, so we're just making sure that it doesn't blow up.
+	rpc.NewClient("TestService", "http://localhost:9999").WarnDeprecated(context.Background(), "Old", rpc.Deprecation{})
+}
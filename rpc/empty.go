@@ -0,0 +1,13 @@
+package rpc
+
+// Empty is the request or response for service functions that don't have any meaningful input or output. This
+// lets simple operations skip declaring their own empty structs:
+//
+//     type HealthService interface {
+//         // Ping makes sure that the service is up and running.
+//         Ping(ctx context.Context, _ *rpc.Empty) (*rpc.Empty, error)
+//     }
+//
+// It is the only type from outside of your service's package that you can use as a request/response. Gateways
+// still accept (and ignore) any JSON body/query string sent to these functions and they respond w/ "{}".
+type Empty struct{}
@@ -0,0 +1,149 @@
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/monadicstack/respond"
+)
+
+// EnvelopeHeader is the response header the gateway includes when it wraps the service response in an
+// envelope. Frodo's generated clients look for it to determine whether they need to unwrap the "data".
+const EnvelopeHeader = "X-RPC-Envelope"
+
+// WithResponseEnvelope wraps every JSON response in a standard envelope that includes some metadata
+// about the request:
+//
+//     {"data": {"Result": 7}, "error": null, "meta": {"requestId": "0184e...", "durationMs": 12}}
+//
+// Failures use the same envelope; the "data" is null and the "error" is the failure in the gateway's error
+// format (see WithErrorFormat):
+//
+//     {"data": null, "error": {"status": 404, "message": "not found"}, "meta": {"durationMs": 3}}
+//
+// The "requestId" is only present when the request has an operation id (see the "rpc/operation" package).
+// Raw file data and redirects are NOT wrapped. Frodo's generated clients unwrap the envelope automatically,
+// so this only matters to callers hitting the raw HTTP API.
+func WithResponseEnvelope() GatewayOption {
+	return func(gw *Gateway) {
+		gw.ResponseEnvelope = true
+	}
+}
+
+// responseEnvelope is the JSON structure that the gateway writes when it is configured to use envelopes.
+type responseEnvelope struct {
+	// Data is the actual service response value. It's null when the call failed.
+	Data interface{} `json:"data"`
+	// Error is the failure in the gateway's error format. It's null when the call succeeded.
+	Error interface{} `json:"error"`
+	// Meta contains the extra information about the request.
+	Meta responseEnvelopeMeta `json:"meta"`
+}
+
+// responseEnvelopeMeta describes the request that generated the enveloped response.
+type responseEnvelopeMeta struct {
+	// RequestID is the operation id of the request (if it has one).
+	RequestID string `json:"requestId,omitempty"`
+	// DurationMs is the number of milliseconds the gateway spent handling the request.
+	DurationMs int64 `json:"durationMs"`
+}
+
+// contextKeyStartTime is where ServeHTTP stores the time that the gateway started handling the request.
+type contextKeyStartTime struct{}
+
+// Reply writes the service response using the given HTTP status. Typically, the value is marshaled as JSON, but
+// it also handles raw file data and redirects the same way that 'github.com/monadicstack/respond' does. Raw
+// file data also supports "Range" and conditional requests (see writeContent). If the gateway is configured
+// WithResponseEnvelope(), JSON responses are wrapped in the standard envelope. JSON responses follow the
+// gateway's JSON settings (see WithJSON). The handler can override the status using SetStatus(), and "204" and
+// "205" responses never have a body, no matter what the service response contains.
+func Reply(w http.ResponseWriter, req *http.Request, status int, serviceResponse interface{}) {
+	reply(w, req, responseStatus(req, status), serviceResponse, gatewayJSON(req))
+}
+
+// reply writes the response just like Reply(), but lets you decide which JSON settings to use. This is
+// how we keep frodo's own types (e.g. jobs.Job) in the same format no matter how the gateway is configured.
+func reply(w http.ResponseWriter, req *http.Request, status int, serviceResponse interface{}, config *JSON) {
+	if respondedRaw(req) {
+		return
+	}
+	writeHeaders(w, serviceResponse)
+
+	switch response := serviceResponse.(type) {
+	case Redirector:
+		writeRedirect(w, req, response)
+		return
+	case respond.Redirector:
+		respond.To(w, req).Reply(status, serviceResponse)
+		return
+	case ContentReader:
+		writeContent(w, req, status, response)
+		return
+	}
+	if hasNoContent(status) {
+		w.WriteHeader(status)
+		return
+	}
+
+	gw, ok := req.Context().Value(contextKeyGateway{}).(*Gateway)
+	useEnvelope := ok && gw.ResponseEnvelope
+	useETag := shouldTagResponse(req, status)
+	if ok && gw.Protobuf {
+		// The same URL responds w/ either protobuf or JSON, so caches need to keep them separate.
+		w.Header().Add("Vary", "Accept")
+		if !useEnvelope && acceptsProtobuf(req) && writeProtobuf(w, req, status, serviceResponse, useETag) {
+			return
+		}
+	}
+	if !useEnvelope && !useETag && config.standard() {
+		respond.To(w, req).Reply(status, serviceResponse)
+		return
+	}
+
+	responseJSON, err := config.marshal(serviceResponse)
+	if err != nil {
+		http.Error(w, "json marshal error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// The tag only covers the response data, not the envelope, since the envelope's metadata changes every time.
+	if useETag {
+		etag := jsonETag(responseJSON)
+		w.Header().Set("ETag", etag)
+		if contentNotModified(req, etag, time.Time{}) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	if !useEnvelope {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write(responseJSON)
+		return
+	}
+
+	writeEnvelope(w, req, status, responseEnvelope{Data: json.RawMessage(responseJSON)})
+}
+
+// writeEnvelope fills in the envelope's metadata and writes it w/ the given status.
+func writeEnvelope(w http.ResponseWriter, req *http.Request, status int, envelope responseEnvelope) {
+	envelope.Meta.RequestID = operationID(w, req)
+	if startTime, ok := req.Context().Value(contextKeyStartTime{}).(time.Time); ok {
+		envelope.Meta.DurationMs = time.Since(startTime).Milliseconds()
+	}
+
+	envelopeJSON, err := json.Marshal(envelope)
+	if err != nil {
+		http.Error(w, "json marshal error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Browsers hide non-standard headers from cross-origin callers unless we explicitly expose them. Without
+	// this, the JS client wouldn't be able to tell that it needs to unwrap the response.
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(EnvelopeHeader, "true")
+	w.Header().Add("Access-Control-Expose-Headers", EnvelopeHeader)
+	w.WriteHeader(status)
+	_, _ = w.Write(envelopeJSON)
+}
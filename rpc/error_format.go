@@ -0,0 +1,195 @@
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/operation"
+	"github.com/monadicstack/respond"
+)
+
+// ErrorFormat determines how the gateway encodes failures in the body of HTTP responses.
+type ErrorFormat int
+
+const (
+	// DefaultJSON encodes errors as a simple JSON object containing the status and message. For
+	// example: {"status":404, "message":"user not found: 123"}
+	DefaultJSON = ErrorFormat(0)
+	// ProblemJSON encodes errors as RFC 7807 "application/problem+json" documents. For
+	// example: {"type":"about:blank", "title":"Not Found", "status":404, "detail":"user not found: 123", "instance":"/user/123"}
+	ProblemJSON = ErrorFormat(1)
+)
+
+// ProblemJSONContentType is the Content-Type header value for RFC 7807 error responses.
+const ProblemJSONContentType = "application/problem+json"
+
+// WithErrorFormat changes how the gateway encodes failures in the body of HTTP responses. By default,
+// gateways use the DefaultJSON format, but you can opt in to other standard formats such as ProblemJSON.
+// Frodo's generated clients understand every format, so this only matters to callers hitting the raw HTTP API.
+func WithErrorFormat(format ErrorFormat) GatewayOption {
+	return func(gw *Gateway) {
+		gw.ErrorFormat = format
+	}
+}
+
+// WithErrorRegistry uses the registry to attach error codes to failures that match one of its registered errors
+// (according to errors.Is()). Share the same registry w/ your clients via WithClientErrorRegistry() so that they
+// can map those codes back to your errors.
+func WithErrorRegistry(registry *errors.Registry) GatewayOption {
+	return func(gw *Gateway) {
+		gw.ErrorRegistry = registry
+	}
+}
+
+// WithClientErrorRegistry uses the registry to restore the original errors that the gateway failed with. When
+// the gateway responds w/ an error code that the registry knows about, the client's error will wrap the registered
+// error, so you can use errors.Is() and errors.As() to check for it.
+func WithClientErrorRegistry(registry *errors.Registry) ClientOption {
+	return func(client *Client) {
+		client.ErrorRegistry = registry
+	}
+}
+
+// Fail writes the error to the HTTP response using the error format of the gateway that is handling
+// the request. The HTTP status is derived from the error the same way as errors.Status(). Use this in your
+// own middleware when you want failures to look the same as the ones generated by your service functions.
+// If the gateway is configured WithResponseEnvelope(), the error is wrapped in the standard envelope. If the
+// gateway is configured WithErrorSanitization(), 5xx errors are replaced w/ a generic message.
+func Fail(w http.ResponseWriter, req *http.Request, err error) {
+	if respondedRaw(req) {
+		return
+	}
+	format := DefaultJSON
+	useEnvelope := false
+	if gw, ok := req.Context().Value(contextKeyGateway{}).(*Gateway); ok {
+		format = gw.ErrorFormat
+		useEnvelope = gw.ResponseEnvelope
+		if code := gw.ErrorRegistry.CodeFor(err); code != "" && errors.Code(err) == "" {
+			err = errors.WithCode(err, code)
+		}
+		if gw.sanitizes(err) {
+			id := sanitizedOperationID(operationID(w, req))
+			w.Header().Set(operation.Header, id)
+			err = sanitizeError(req.Context(), err, id)
+		}
+	}
+
+	switch {
+	case useEnvelope && format == ProblemJSON:
+		writeEnvelope(w, req, errors.Status(err), responseEnvelope{Error: newProblemDetails(w, req, err)})
+		return
+	case useEnvelope:
+		writeEnvelope(w, req, errors.Status(err), responseEnvelope{Error: newRPCError(w, req, err)})
+		return
+	}
+
+	switch format {
+	case ProblemJSON:
+		writeProblemJSON(w, req, err)
+	default:
+		writeDefaultJSON(w, req, err)
+	}
+}
+
+// problemDetails is the RFC 7807 representation of an error. The gateway writes these when using
+// the ProblemJSON format and the client uses it to reconstitute the error on the other side.
+type problemDetails struct {
+	// Type is a URI that identifies the type of problem. We always use "about:blank" which
+	// indicates that the problem has no semantics beyond the HTTP status.
+	Type string `json:"type"`
+	// Title is the short, human-readable summary of the problem type (i.e. the HTTP status text).
+	Title string `json:"title"`
+	// Status is the HTTP status code for this failure.
+	Status int `json:"status"`
+	// Detail is the human-readable explanation of this specific failure (i.e. the error message).
+	Detail string `json:"detail,omitempty"`
+	// Instance is a URI that identifies the specific occurrence of the problem (i.e. the request path).
+	Instance string `json:"instance,omitempty"`
+	// Code is the (optional) application-specific error code for the failure. This is an RFC 7807 extension member.
+	Code string `json:"code,omitempty"`
+	// Details is the (optional) structured data describing the failure. This is an RFC 7807 extension member.
+	Details map[string]interface{} `json:"details,omitempty"`
+	// OperationID is the (optional) unique id of the failed request. This is an RFC 7807 extension member.
+	OperationID string `json:"operationId,omitempty"`
+}
+
+func writeProblemJSON(w http.ResponseWriter, req *http.Request, err error) {
+	problem := newProblemDetails(w, req, err)
+	w.Header().Set("Content-Type", ProblemJSONContentType)
+	writeJSONError(w, problem.Status, problem)
+}
+
+// newProblemDetails creates the RFC 7807 representation of the error.
+func newProblemDetails(w http.ResponseWriter, req *http.Request, err error) problemDetails {
+	status := errors.Status(err)
+	problem := problemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+	if err != nil {
+		problem.Detail = err.Error()
+		problem.Code = errors.Code(err)
+		problem.Details = errors.Details(err)
+	}
+	if req.URL != nil {
+		problem.Instance = req.URL.Path
+	}
+	problem.OperationID = operationID(w, req)
+	return problem
+}
+
+func writeDefaultJSON(w http.ResponseWriter, req *http.Request, err error) {
+	// The responder only knows about the status/message, so we need to encode the error
+	// ourselves if it has an error code, details, etc that the caller needs to see.
+	operationID := operationID(w, req)
+	if errors.Code(err) == "" && len(errors.Details(err)) == 0 && operationID == "" {
+		respond.To(w, req).Fail(err)
+		return
+	}
+
+	rpcErr := newRPCError(w, req, err)
+	writeJSONError(w, rpcErr.HTTPStatus, rpcErr)
+}
+
+// newRPCError creates the default JSON representation of the error (e.g. {"status":404, "message":"not found"}).
+func newRPCError(w http.ResponseWriter, req *http.Request, err error) errors.RPCError {
+	status := errors.Status(err)
+	message := "unknown error"
+	if err != nil {
+		message = err.Error()
+	}
+	return errors.RPCError{
+		HTTPStatus:  status,
+		Message:     message,
+		Code:        errors.Code(err),
+		Details:     errors.Details(err),
+		OperationID: operationID(w, req),
+	}
+}
+
+// operationID returns the unique id of the current request (if it has one). Failures such as panics are handled
+// using the original request which doesn't have the operation id on its context, so we'll fall back to the
+// response header that the operation middleware already set.
+func operationID(w http.ResponseWriter, req *http.Request) string {
+	if id := operation.ID(req.Context()); id != "" {
+		return id
+	}
+	return w.Header().Get(operation.Header)
+}
+
+// writeJSONError writes the JSON-encoded error body w/ the given status. If the Content-Type
+// header hasn't been set yet, we'll use "application/json".
+func writeJSONError(w http.ResponseWriter, status int, body interface{}) {
+	bodyJSON, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, "json marshal error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(bodyJSON)
+}
@@ -24,6 +24,19 @@ type RPCError struct {
 	HTTPStatus int `json:"status"`
 	// Message is the human-readable error message.
 	Message string `json:"message"`
+	// Code is an optional, application-specific identifier for the type of failure (e.g. "ORDER_EXPIRED"). Unlike
+	// the status, which is shared by lots of different failures, this lets callers programmatically react to the
+	// specific failure w/o parsing the message.
+	Code string `json:"code,omitempty"`
+	// Details contains optional, structured data that gives the caller more context about the failure, such as
+	// which fields failed validation or how long to wait before retrying.
+	Details map[string]interface{} `json:"details,omitempty"`
+	// OperationID is the unique id of the request that failed (see the "rpc/operation" package). Include
+	// it when reporting a failure so that it can be correlated w/ the server's logs.
+	OperationID string `json:"operationId,omitempty"`
+	// Cause is the (optional) underlying error that resulted in this failure. It is not sent over
+	// the wire, but clients will restore it when the error code is registered w/ an error Registry.
+	Cause error `json:"-"`
 }
 
 // Error returns the underlying error message that describes this failure.
@@ -31,6 +44,24 @@ func (err RPCError) Error() string {
 	return err.Message
 }
 
+// Unwrap returns the underlying cause of this error (if any) so that it plays nicely w/ errors.Is() and errors.As().
+func (err RPCError) Unwrap() error {
+	return err.Cause
+}
+
+// Is lets errors.Is() match RPCError values. Errors w/ a code match any other error with the same code. Otherwise
+// they match when they have the same status and message.
+func (err RPCError) Is(target error) bool {
+	targetErr, ok := target.(RPCError)
+	if !ok {
+		return false
+	}
+	if targetErr.Code != "" {
+		return err.Code == targetErr.Code
+	}
+	return err.HTTPStatus == targetErr.HTTPStatus && err.Message == targetErr.Message
+}
+
 // Status returns the most relevant HTTP status code to return for this error.
 func (err RPCError) Status() int {
 	return err.HTTPStatus
@@ -60,6 +91,80 @@ func New(status int, messageFormat string, args ...interface{}) RPCError {
 	}
 }
 
+// WithCode attaches an application-specific error code (e.g. "ORDER_EXPIRED") to the error. The resulting
+// error has the same status and message as the original, so you can wrap any error you encounter.
+//
+//     return nil, errors.WithCode(errors.BadRequest("order has expired"), "ORDER_EXPIRED")
+func WithCode(err error, code string) RPCError {
+	rpcErr := toRPCError(err)
+	rpcErr.Code = code
+	return rpcErr
+}
+
+// Code returns the application-specific error code attached to the error using WithCode(). This
+// returns "" if the error does not have one.
+func Code(err error) string {
+	var rpcErr RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code
+	}
+	return ""
+}
+
+// WithDetails attaches structured data to the error that describes the failure in more detail. If the error
+// already has details, the new values are merged into them (new values win when the keys collide). The details
+// must be JSON-friendly since they are included in the HTTP response when the error comes out of a gateway.
+//
+//     return nil, errors.WithDetails(errors.BadRequest("invalid user"), map[string]interface{}{
+//         "fields": []string{"email", "phone"},
+//     })
+func WithDetails(err error, details map[string]interface{}) RPCError {
+	rpcErr := toRPCError(err)
+	merged := make(map[string]interface{}, len(rpcErr.Details)+len(details))
+	for key, value := range rpcErr.Details {
+		merged[key] = value
+	}
+	for key, value := range details {
+		merged[key] = value
+	}
+	rpcErr.Details = merged
+	return rpcErr
+}
+
+// WithCause records the underlying error that resulted in this failure, so that the resulting error works
+// w/ errors.Is() and errors.As() against the cause. The status and message are the same as 'err'.
+//
+//     return nil, errors.WithCause(errors.NotFound("user not found: %s", id), sql.ErrNoRows)
+func WithCause(err error, cause error) RPCError {
+	rpcErr := toRPCError(err)
+	rpcErr.Cause = cause
+	return rpcErr
+}
+
+// Details returns the structured data attached to the error using WithDetails(). This returns
+// nil if the error does not have any.
+func Details(err error) map[string]interface{} {
+	var rpcErr RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Details
+	}
+	return nil
+}
+
+// toRPCError converts any error into an RPCError that has the same status and message.
+func toRPCError(err error) RPCError {
+	if err == nil {
+		return Unexpected("unknown error")
+	}
+	// Use the outermost message in case the RPCError was wrapped w/ some extra context.
+	var rpcErr RPCError
+	if errors.As(err, &rpcErr) {
+		rpcErr.Message = err.Error()
+		return rpcErr
+	}
+	return RPCError{HTTPStatus: Status(err), Message: err.Error(), Cause: err}
+}
+
 // Status looks for either a Status(), StatusCode(), or Code() method on the error to
 // figure out the most appropriate HTTP status code for it. If the error doesn't have any of
 // those methods then we'll just assume that it is a 500 error.
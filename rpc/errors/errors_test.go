@@ -3,6 +3,7 @@
 package errors_test
 
 import (
+	stderrors "errors"
 	"fmt"
 	"testing"
 
@@ -52,6 +53,73 @@ func (suite *ErrorsSuite) TestStatus() {
 	suite.Equal(404, errors.Status(errWithStatusCode{statusCode: 404}))
 }
 
+func (suite *ErrorsSuite) TestWithCode() {
+	err := errors.WithCode(errors.BadRequest("order expired"), "ORDER_EXPIRED")
+	suite.assertError(err, 400, "order expired")
+	suite.Equal("ORDER_EXPIRED", errors.Code(err))
+	suite.Equal("ORDER_EXPIRED", errors.Code(fmt.Errorf("wrapped: %w", err)))
+
+	// Non-RPCErrors keep their status/message.
+	err = errors.WithCode(errWithStatusCode{statusCode: 404}, "NOPE")
+	suite.assertError(err, 404, "")
+	suite.Equal("NOPE", errors.Code(err))
+
+	suite.Equal("", errors.Code(errors.NotFound("nope")))
+	suite.Equal("", errors.Code(fmt.Errorf("nope")))
+	suite.Equal("", errors.Code(nil))
+}
+
+func (suite *ErrorsSuite) TestWithDetails() {
+	original := errors.WithDetails(errors.BadRequest("invalid"), map[string]interface{}{"fields": []string{"email"}})
+	suite.assertError(original, 400, "invalid")
+	suite.Equal(map[string]interface{}{"fields": []string{"email"}}, errors.Details(original))
+
+	// Details should be merged, and adding more should not modify the original error's details.
+	merged := errors.WithDetails(original, map[string]interface{}{"retryAfter": 5})
+	suite.Equal(map[string]interface{}{"fields": []string{"email"}, "retryAfter": 5}, errors.Details(merged))
+	suite.Equal(map[string]interface{}{"fields": []string{"email"}}, errors.Details(original))
+
+	// Codes and details should both survive.
+	both := errors.WithCode(merged, "INVALID_USER")
+	suite.Equal("INVALID_USER", errors.Code(both))
+	suite.Equal(errors.Details(merged), errors.Details(both))
+
+	suite.Nil(errors.Details(errors.NotFound("nope")))
+	suite.Nil(errors.Details(fmt.Errorf("nope")))
+}
+
+func (suite *ErrorsSuite) TestWithCause() {
+	cause := stderrors.New("sql: no rows")
+	err := errors.WithCause(errors.NotFound("user not found"), cause)
+	suite.assertError(err, 404, "user not found")
+	suite.True(stderrors.Is(err, cause), "errors.Is() should find the cause")
+	suite.Equal(cause, stderrors.Unwrap(err))
+
+	// Converting a non-RPCError should keep the original as the cause.
+	err = errors.WithCode(cause, "NO_ROWS")
+	suite.True(stderrors.Is(err, cause), "errors.Is() should find the original error")
+
+	var statusErr errWithStatusCode
+	suite.True(stderrors.As(errors.WithCause(errors.Unexpected("oops"), errWithStatusCode{statusCode: 418}), &statusErr))
+	suite.Equal(418, statusErr.statusCode)
+
+	suite.Nil(stderrors.Unwrap(errors.NotFound("nope")))
+}
+
+func (suite *ErrorsSuite) TestIs() {
+	expired := errors.WithCode(errors.BadRequest("order expired"), "ORDER_EXPIRED")
+	suite.True(stderrors.Is(errors.WithCode(errors.BadRequest("rpc error: order expired"), "ORDER_EXPIRED"), expired))
+	suite.True(stderrors.Is(fmt.Errorf("wrapped: %w", expired), expired))
+	suite.False(stderrors.Is(errors.BadRequest("order expired"), expired), "Errors w/o the code should not match")
+	suite.False(stderrors.Is(errors.WithCode(errors.BadRequest("order expired"), "NOPE"), expired))
+
+	// No codes, so compare the status/message.
+	suite.True(stderrors.Is(errors.NotFound("nope"), errors.NotFound("nope")))
+	suite.False(stderrors.Is(errors.NotFound("nope"), errors.NotFound("yep")))
+	suite.False(stderrors.Is(errors.NotFound("nope"), errors.BadRequest("nope")))
+	suite.False(stderrors.Is(errors.NotFound("nope"), stderrors.New("nope")))
+}
+
 func (suite *ErrorsSuite) TestUnexpected() {
 	expectedStatus := 500
 	suite.assertError(errors.Unexpected("foo"), expectedStatus, "foo")
@@ -0,0 +1,78 @@
+package errors
+
+import (
+	"errors"
+	"sync"
+)
+
+// Registry maps application-specific error codes to the errors your service defines for them. When
+// you share a registry between your gateway and your client, errors keep their identity as they make
+// the round trip over HTTP, so callers can use errors.Is()/errors.As() against your sentinel errors.
+//
+//     var ErrOrderExpired = errors.BadRequest("order has expired")
+//     var Errors = errors.NewRegistry().Register("ORDER_EXPIRED", ErrOrderExpired)
+//
+//     // Server: errors that match ErrOrderExpired will include the code "ORDER_EXPIRED"
+//     gateway := ordersrpc.NewOrderServiceGateway(service, rpc.WithErrorRegistry(Errors))
+//
+//     // Client: errors w/ the code "ORDER_EXPIRED" will wrap ErrOrderExpired
+//     client := ordersrpc.NewOrderServiceClient(address, rpc.WithClientErrorRegistry(Errors))
+//     _, err := client.Checkout(ctx, &CheckoutRequest{...})
+//     if errors.Is(err, ErrOrderExpired) {
+//         ...
+//     }
+type Registry struct {
+	mutex  sync.RWMutex
+	codes  []string
+	errors map[string]error
+}
+
+// NewRegistry creates an empty registry that you can populate using Register().
+func NewRegistry() *Registry {
+	return &Registry{errors: map[string]error{}}
+}
+
+// Register associates the error code with the given error. This returns the registry itself so that
+// you can chain multiple registrations together. Registering the same code again replaces the original.
+func (registry *Registry) Register(code string, err error) *Registry {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if _, ok := registry.errors[code]; !ok {
+		registry.codes = append(registry.codes, code)
+	}
+	registry.errors[code] = err
+	return registry
+}
+
+// Lookup returns the error that was registered for the given code. The boolean is false when there
+// is no error registered for that code.
+func (registry *Registry) Lookup(code string) (error, bool) {
+	if registry == nil || code == "" {
+		return nil, false
+	}
+
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	err, ok := registry.errors[code]
+	return err, ok
+}
+
+// CodeFor returns the code for the first registered error that matches 'err' according to errors.Is(). This
+// returns "" if the error doesn't match any of the registered errors.
+func (registry *Registry) CodeFor(err error) string {
+	if registry == nil || err == nil {
+		return ""
+	}
+
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	for _, code := range registry.codes {
+		if errors.Is(err, registry.errors[code]) {
+			return code
+		}
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+// +build unit
+
+package errors_test
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type RegistrySuite struct {
+	suite.Suite
+}
+
+var errPlain = stderrors.New("plain")
+var errExpired = errors.BadRequest("order expired")
+
+func (suite *RegistrySuite) TestLookup() {
+	registry := errors.NewRegistry().
+		Register("PLAIN", errPlain).
+		Register("EXPIRED", errExpired)
+
+	err, ok := registry.Lookup("PLAIN")
+	suite.Require().True(ok)
+	suite.Require().Equal(errPlain, err)
+
+	err, ok = registry.Lookup("EXPIRED")
+	suite.Require().True(ok)
+	suite.Require().Equal(errExpired, err)
+
+	_, ok = registry.Lookup("NOPE")
+	suite.Require().False(ok)
+	_, ok = registry.Lookup("")
+	suite.Require().False(ok)
+
+	// Re-registering a code should replace the original error.
+	registry.Register("PLAIN", errExpired)
+	err, _ = registry.Lookup("PLAIN")
+	suite.Require().Equal(errExpired, err)
+}
+
+func (suite *RegistrySuite) TestCodeFor() {
+	registry := errors.NewRegistry().
+		Register("PLAIN", errPlain).
+		Register("EXPIRED", errExpired)
+
+	suite.Require().Equal("PLAIN", registry.CodeFor(errPlain))
+	suite.Require().Equal("PLAIN", registry.CodeFor(fmt.Errorf("wrapped: %w", errPlain)))
+	suite.Require().Equal("EXPIRED", registry.CodeFor(errExpired))
+	suite.Require().Equal("", registry.CodeFor(stderrors.New("plain")))
+	suite.Require().Equal("", registry.CodeFor(errors.BadRequest("not expired")))
+	suite.Require().Equal("", registry.CodeFor(nil))
+}
+
+// Ensures that you can use a nil registry without blowing up.
+func (suite *RegistrySuite) TestNil() {
+	var registry *errors.Registry
+	_, ok := registry.Lookup("PLAIN")
+	suite.Require().False(ok)
+	suite.Require().Equal("", registry.CodeFor(errPlain))
+}
+
+func TestRegistrySuite(t *testing.T) {
+	suite.Run(t, new(RegistrySuite))
+}
@@ -0,0 +1,159 @@
+package rpc
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"sync"
+)
+
+// WithETags makes the gateway include an "ETag" header (a hash of the JSON) in the responses of GET/HEAD
+// service functions. When the caller sends that tag back in the "If-None-Match" header and the response
+// hasn't changed, the gateway replies w/ an empty "304 Not Modified" instead of the whole payload. Clients
+// that poll for changes only pay for the bytes when something actually changed. The Go client can do this
+// for you automatically (see WithETagCache).
+//
+// The service function still runs every time, so this saves bandwidth, not work on the server. Responses
+// w/ raw content have their own tags (see ContentETagReader), so this only applies to JSON responses.
+func WithETags() GatewayOption {
+	return func(gw *Gateway) {
+		gw.ETags = true
+	}
+}
+
+// shouldTagResponse returns true when the gateway should include an "ETag" w/ the JSON response.
+func shouldTagResponse(req *http.Request, status int) bool {
+	gw, ok := req.Context().Value(contextKeyGateway{}).(*Gateway)
+	if !ok || !gw.ETags || status != http.StatusOK {
+		return false
+	}
+	return req.Method == http.MethodGet || req.Method == http.MethodHead
+}
+
+// jsonETag creates the weak entity tag for the response JSON. It's weak because the bytes on the wire are
+// different when the gateway compresses the response, even though the JSON is the same.
+func jsonETag(responseJSON []byte) string {
+	hash := sha256.Sum256(responseJSON)
+	return `W/"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// WithETagCache makes the client remember the "ETag" and body of the JSON responses to GET calls (up to 'maxEntries'
+// of them). The next time you make the same call, the client sends the tag in the "If-None-Match" header. If the
+// response hasn't changed, the gateway replies w/ a tiny "304 Not Modified" and the client uses the cached body
+// instead, so your code never knows the difference. This is great for polling, but it only helps when the
+// gateway is using WithETags(). A 'maxEntries' <= 0 remembers the 1000 most recent responses.
+func WithETagCache(maxEntries int) ClientOption {
+	return func(rpcClient *Client) {
+		if maxEntries <= 0 {
+			maxEntries = 1000
+		}
+		rpcClient.etagCache = newETagCache(maxEntries)
+	}
+}
+
+// newETagCache creates an empty cache that holds up to 'maxEntries' responses.
+func newETagCache(maxEntries int) *etagCache {
+	return &etagCache{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		recent:     list.New(),
+	}
+}
+
+// etagCache remembers the most recently used JSON responses (and their tags) by URL.
+type etagCache struct {
+	mutex      sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	recent     *list.List
+}
+
+// etagCacheEntry is a single JSON response that we can reuse when the gateway says it's "Not Modified".
+type etagCacheEntry struct {
+	url    string
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+// revalidate is client middleware that sends the cached tag for the URL along w/ GET calls, replacing 304
+// responses w/ the cached response and caching new responses that have a tag.
+func (cache *etagCache) revalidate(request *http.Request, next RoundTripperFunc) (*http.Response, error) {
+	if request.Method != http.MethodGet || request.Header.Get("Range") != "" || isStreamCall(request.Context()) {
+		return next(request)
+	}
+
+	url := request.URL.String()
+	cached := cache.load(url)
+	if cached != nil && request.Header.Get("If-None-Match") == "" {
+		request.Header.Set("If-None-Match", cached.etag)
+	}
+
+	response, err := next(request)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case response.StatusCode == http.StatusNotModified && cached != nil && request.Header.Get("If-None-Match") == cached.etag:
+		_ = response.Body.Close()
+		response.StatusCode = http.StatusOK
+		response.Status = "200 OK"
+		response.Header = cached.header.Clone()
+		response.Body = ioutil.NopCloser(bytes.NewReader(cached.body))
+		response.ContentLength = int64(len(cached.body))
+		return response, nil
+
+	case response.StatusCode == http.StatusOK && response.Header.Get("ETag") != "" && isJSONResponse(response):
+		body, err := ioutil.ReadAll(response.Body)
+		_ = response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		cache.store(&etagCacheEntry{url: url, etag: response.Header.Get("ETag"), header: response.Header.Clone(), body: body})
+		response.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return response, nil
+
+	default:
+		return response, nil
+	}
+}
+
+func (cache *etagCache) load(url string) *etagCacheEntry {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, ok := cache.entries[url]
+	if !ok {
+		return nil
+	}
+	cache.recent.MoveToFront(element)
+	return element.Value.(*etagCacheEntry)
+}
+
+func (cache *etagCache) store(entry *etagCacheEntry) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if element, ok := cache.entries[entry.url]; ok {
+		element.Value = entry
+		cache.recent.MoveToFront(element)
+		return
+	}
+	cache.entries[entry.url] = cache.recent.PushFront(entry)
+	for cache.recent.Len() > cache.maxEntries {
+		oldest := cache.recent.Back()
+		cache.recent.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*etagCacheEntry).url)
+	}
+}
+
+// isJSONResponse returns true when the response body is JSON (as opposed to raw content or an event stream).
+func isJSONResponse(response *http.Response) bool {
+	contentType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	return contentType == "application/json"
+}
@@ -0,0 +1,120 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+type etagResponse struct {
+	Text string
+}
+
+// Ensures that gateways w/ ETags tag GET responses and only reply w/ a 304 when the response is the same. Envelopes
+// shouldn't change the tag, even though their metadata is different every time. Gateways don't tag responses
+// unless you ask them to.
+func (suite *GatewaySuite) TestWithETags() {
+	tests := []struct {
+		name    string
+		options []rpc.GatewayOption
+		tagged  bool
+	}{
+		{name: "disabled"},
+		{name: "enabled", options: []rpc.GatewayOption{rpc.WithETags()}, tagged: true},
+		{name: "envelope", options: []rpc.GatewayOption{rpc.WithETags(), rpc.WithResponseEnvelope()}, tagged: true},
+	}
+	for _, test := range tests {
+		r := suite.Require()
+		text := "a"
+		gw := suite.newGateway(test.options...)
+		handler := func(w http.ResponseWriter, req *http.Request) {
+			rpc.Reply(w, req, 200, etagResponse{Text: text})
+		}
+		gw.Register(testEndpoint("GET", "/text", "Get", handler))
+		gw.Register(testEndpoint("POST", "/text", "Set", handler))
+
+		call := func(method string, ifNoneMatch string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, "/text", strings.NewReader("{}"))
+			if ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			gw.ServeHTTP(w, req)
+			return w
+		}
+
+		w := call("GET", "")
+		r.Equal(200, w.Code, test.name)
+		r.Contains(w.Body.String(), `"Text":"a"`, test.name)
+		etag := w.Header().Get("ETag")
+		if !test.tagged {
+			r.Empty(etag, test.name)
+			continue
+		}
+		r.True(strings.HasPrefix(etag, `W/"`), "%s: Should be a weak tag", test.name)
+
+		w = call("GET", etag)
+		r.Equal(304, w.Code, test.name)
+		r.Equal(etag, w.Header().Get("ETag"), test.name)
+		r.Empty(w.Body.String(), test.name)
+
+		w = call("GET", `W/"nope", `+etag)
+		r.Equal(304, w.Code, "%s: Should match any of the tags", test.name)
+
+		text = "b"
+		w = call("GET", etag)
+		r.Equal(200, w.Code, "%s: Should reply normally once the response changes", test.name)
+		r.Contains(w.Body.String(), `"Text":"b"`, test.name)
+		r.NotEqual(etag, w.Header().Get("ETag"), test.name)
+
+		w = call("POST", "")
+		r.Equal(200, w.Code, test.name)
+		r.Empty(w.Header().Get("ETag"), "%s: Should only tag GET/HEAD responses", test.name)
+	}
+}
+
+// Ensures that the client revalidates the responses it cached and uses the cached body on a 304.
+func (suite *GatewaySuite) TestWithETagCache() {
+	r := suite.Require()
+	text := &atomic.Value{}
+	text.Store("a")
+	gw := suite.newGateway(rpc.WithETags())
+	gw.Register(testEndpoint("GET", "/text", "Get", func(w http.ResponseWriter, req *http.Request) {
+		rpc.Reply(w, req, 200, etagResponse{Text: text.Load().(string)})
+	}))
+
+	notModified := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		recorder := httptest.NewRecorder()
+		gw.ServeHTTP(recorder, req)
+		if recorder.Code == http.StatusNotModified {
+			atomic.AddInt32(&notModified, 1)
+		}
+		for key, values := range recorder.Header() {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(recorder.Code)
+		_, _ = w.Write(recorder.Body.Bytes())
+	}))
+	defer server.Close()
+
+	client := rpc.NewClient("TestService", server.URL, rpc.WithETagCache(10))
+	for i := 0; i < 3; i++ {
+		response := etagResponse{}
+		r.NoError(client.Invoke(context.Background(), "GET", "/text", nil, &response))
+		r.Equal("a", response.Text)
+	}
+	r.Equal(int32(2), atomic.LoadInt32(&notModified), "Should revalidate after the first call")
+
+	text.Store("b")
+	response := etagResponse{}
+	r.NoError(client.Invoke(context.Background(), "GET", "/text", nil, &response))
+	r.Equal("b", response.Text, "Should use the new response once it changes")
+	r.Equal(int32(2), atomic.LoadInt32(&notModified))
+}
@@ -0,0 +1,24 @@
+package rpc
+
+import (
+	"net/http"
+
+	"github.com/monadicstack/frodo/rpc/events"
+)
+
+// WithEventBroker lets your service functions publish events using events.Emit(). The gateway gives every
+// request an "outbox" that collects the emitted events, and they're only sent to the broker once your
+// function succeeds. When your function fails, the events are discarded.
+func WithEventBroker(broker events.Broker) GatewayOption {
+	return func(gw *Gateway) {
+		gw.EventBroker = broker
+	}
+}
+
+// attachOutbox creates the built-in middleware that gives each request its own outbox for emitted events. The
+// generated handler flushes the outbox after the service function succeeds.
+func attachOutbox(broker events.Broker) MiddlewareFunc {
+	return func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		next(w, req.WithContext(events.WithOutbox(req.Context(), broker)))
+	}
+}
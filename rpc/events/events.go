@@ -0,0 +1,188 @@
+// Package events lets service functions publish events for other services to react to, so service-to-service
+// communication isn't limited to synchronous HTTP calls. Your function "emits" events using the context:
+//
+//     func (svc UserServiceHandler) CreateUser(ctx context.Context, req *CreateUserRequest) (*CreateUserResponse, error) {
+//         user, err := svc.Repo.Create(ctx, req.Email)
+//         if err != nil {
+//             return nil, err
+//         }
+//         events.Emit(ctx, &UserCreated{UserID: user.ID})
+//         return &CreateUserResponse{User: user}, nil
+//     }
+//
+// The gateway collects emitted events in a per-request "outbox" and only publishes them to the broker once your
+// function succeeds. If the function fails, the events are discarded, so subscribers never hear about work that
+// didn't actually happen. Configure the broker when you create the gateway:
+//
+//     gateway := usersrpc.NewUserServiceGateway(service, rpc.WithEventBroker(broker))
+//
+// Frodo only ships w/ an in-memory Broker, but the interface is small enough that adapting NATS, Kafka, or
+// whatever your organization uses only takes a few lines of code.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc/ids"
+	"github.com/monadicstack/frodo/rpc/metadata"
+)
+
+// Message is the envelope that brokers transport. It contains the JSON-encoded event along w/ some
+// information about where it came from.
+type Message struct {
+	// ID uniquely identifies this message so that subscribers can detect duplicate deliveries.
+	ID string
+	// Topic is where the message was published (e.g. "UserCreated").
+	Topic string
+	// Time is when the event was emitted.
+	Time time.Time
+	// Metadata is the JSON-encoded request metadata from the context that emitted the event.
+	Metadata string `json:",omitempty"`
+	// Data is the JSON-encoded event value.
+	Data json.RawMessage
+}
+
+// Decode unmarshals the event data into the given value (typically a pointer to your event struct).
+func (msg Message) Decode(out interface{}) error {
+	if err := json.Unmarshal(msg.Data, out); err != nil {
+		return fmt.Errorf("events: unable to decode '%s' message: %w", msg.Topic, err)
+	}
+	return nil
+}
+
+// Context restores the metadata from the context that emitted the event onto the given context. This
+// way, values such as the operation id follow the event to the subscriber.
+func (msg Message) Context(ctx context.Context) context.Context {
+	values, err := metadata.FromJSON(msg.Metadata)
+	if err != nil {
+		return ctx
+	}
+	return metadata.WithValues(ctx, values)
+}
+
+// Handler is a function that processes messages delivered to a subscriber.
+type Handler func(ctx context.Context, msg Message) error
+
+// Subscription represents an active subscription to a topic.
+type Subscription interface {
+	// Unsubscribe stops the delivery of messages to the subscriber.
+	Unsubscribe() error
+}
+
+// Broker is the messaging system that transports events between services. Implementations must be
+// safe for concurrent use.
+type Broker interface {
+	// Publish sends the message to everyone subscribed to its topic.
+	Publish(ctx context.Context, msg Message) error
+	// Subscribe starts delivering messages published on the topic to the handler.
+	Subscribe(topic string, handler Handler) (Subscription, error)
+}
+
+// Topic lets an event decide which topic it is published to. Events that don't implement this are
+// published to a topic that is the name of the event's type (e.g. "UserCreated").
+type Topic interface {
+	Topic() string
+}
+
+// TopicOf determines the topic that the event should be published to.
+func TopicOf(event interface{}) string {
+	if topic, ok := event.(Topic); ok {
+		return topic.Topic()
+	}
+	eventType := reflect.TypeOf(event)
+	for eventType != nil && eventType.Kind() == reflect.Ptr {
+		eventType = eventType.Elem()
+	}
+	if eventType == nil {
+		return ""
+	}
+	return eventType.Name()
+}
+
+// NewMessage encodes the event into a message that you can give to a broker.
+func NewMessage(ctx context.Context, event interface{}) (Message, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return Message{}, fmt.Errorf("events: unable to encode event: %w", err)
+	}
+	metadataJSON, _ := metadata.ToJSON(ctx)
+	if metadataJSON == "null" {
+		metadataJSON = ""
+	}
+	return Message{
+		ID:       ids.New(),
+		Topic:    TopicOf(event),
+		Time:     time.Now(),
+		Metadata: metadataJSON,
+		Data:     data,
+	}, nil
+}
+
+// Publish immediately sends the event to the broker. Service functions should use Emit() instead so
+// that events are only published when the function succeeds.
+func Publish(ctx context.Context, broker Broker, event interface{}) error {
+	msg, err := NewMessage(ctx, event)
+	if err != nil {
+		return err
+	}
+	return broker.Publish(ctx, msg)
+}
+
+type contextKeyOutbox struct{}
+
+// outbox holds all of the events that a service function emitted until we know whether it succeeded.
+type outbox struct {
+	broker   Broker
+	mutex    sync.Mutex
+	messages []Message
+}
+
+// WithOutbox creates a context that collects emitted events in an outbox until you Flush() them to the
+// broker. The gateway does this for every request when configured w/ a broker, so you typically won't
+// need to call this yourself.
+func WithOutbox(ctx context.Context, broker Broker) context.Context {
+	return context.WithValue(ctx, contextKeyOutbox{}, &outbox{broker: broker})
+}
+
+// Emit adds the event to the context's outbox. It will be published once the service function succeeds. This
+// is a no-op when the context doesn't have an outbox (e.g. the gateway doesn't have a broker or you're calling
+// your service directly in a unit test). This only fails when the event can't be encoded as JSON.
+func Emit(ctx context.Context, event interface{}) error {
+	box, ok := ctx.Value(contextKeyOutbox{}).(*outbox)
+	if !ok {
+		return nil
+	}
+	msg, err := NewMessage(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	box.mutex.Lock()
+	defer box.mutex.Unlock()
+	box.messages = append(box.messages, msg)
+	return nil
+}
+
+// Flush publishes all of the events in the context's outbox to the broker and empties the outbox. If the
+// broker fails to publish one of the events, that event and the ones after it remain in the outbox.
+func Flush(ctx context.Context) error {
+	box, ok := ctx.Value(contextKeyOutbox{}).(*outbox)
+	if !ok {
+		return nil
+	}
+
+	box.mutex.Lock()
+	defer box.mutex.Unlock()
+	for len(box.messages) > 0 {
+		if err := box.broker.Publish(ctx, box.messages[0]); err != nil {
+			return fmt.Errorf("events: unable to publish '%s' message: %w", box.messages[0].Topic, err)
+		}
+		box.messages = box.messages[1:]
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+// +build unit
+
+package events_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/monadicstack/frodo/rpc/events"
+	"github.com/monadicstack/frodo/rpc/metadata"
+	"github.com/stretchr/testify/suite"
+)
+
+type EventsSuite struct {
+	suite.Suite
+}
+
+type UserCreated struct {
+	ID string
+}
+
+type customTopic struct{}
+
+func (customTopic) Topic() string {
+	return "users.created.v2"
+}
+
+// failingBroker rejects every message published after it has accepted 'limit' of them.
+type failingBroker struct {
+	limit     int
+	published []events.Message
+}
+
+func (broker *failingBroker) Publish(_ context.Context, msg events.Message) error {
+	if len(broker.published) >= broker.limit {
+		return fmt.Errorf("broker unavailable")
+	}
+	broker.published = append(broker.published, msg)
+	return nil
+}
+
+func (broker *failingBroker) Subscribe(string, events.Handler) (events.Subscription, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+// Ensures that events are published to a topic named after their type unless they pick their own.
+func (suite *EventsSuite) TestTopicOf() {
+	r := suite.Require()
+	r.Equal("UserCreated", events.TopicOf(UserCreated{}))
+	r.Equal("UserCreated", events.TopicOf(&UserCreated{}))
+	r.Equal("users.created.v2", events.TopicOf(customTopic{}))
+	r.Equal("", events.TopicOf(nil))
+}
+
+// Ensures that messages contain everything the subscriber needs to decode the event.
+func (suite *EventsSuite) TestNewMessage() {
+	r := suite.Require()
+	ctx := metadata.WithValue(context.Background(), "user", "dude")
+
+	msg, err := events.NewMessage(ctx, &UserCreated{ID: "123"})
+	r.NoError(err)
+	r.NotEmpty(msg.ID)
+	r.Equal("UserCreated", msg.Topic)
+	r.False(msg.Time.IsZero())
+
+	event := UserCreated{}
+	r.NoError(msg.Decode(&event))
+	r.Equal("123", event.ID)
+
+	user := ""
+	r.True(metadata.Value(msg.Context(context.Background()), "user", &user), "Metadata should follow the event")
+	r.Equal("dude", user)
+
+	_, err = events.NewMessage(ctx, make(chan int))
+	r.Error(err, "Should fail when the event can't be encoded")
+}
+
+// Ensures that emitted events wait in the outbox until they're flushed.
+func (suite *EventsSuite) TestEmit() {
+	r := suite.Require()
+	broker := &failingBroker{limit: 100}
+	ctx := events.WithOutbox(context.Background(), broker)
+
+	r.NoError(events.Emit(ctx, &UserCreated{ID: "1"}))
+	r.NoError(events.Emit(ctx, &UserCreated{ID: "2"}))
+	r.Len(broker.published, 0, "Events should not be published until the outbox is flushed")
+
+	r.NoError(events.Flush(ctx))
+	r.Len(broker.published, 2)
+	r.Contains(string(broker.published[0].Data), `"1"`)
+	r.Contains(string(broker.published[1].Data), `"2"`)
+
+	r.NoError(events.Flush(ctx))
+	r.Len(broker.published, 2, "Flushing should empty the outbox")
+}
+
+// Ensures that events that fail to publish stay in the outbox so you can try again.
+func (suite *EventsSuite) TestFlush_failure() {
+	r := suite.Require()
+	broker := &failingBroker{limit: 1}
+	ctx := events.WithOutbox(context.Background(), broker)
+
+	r.NoError(events.Emit(ctx, &UserCreated{ID: "1"}))
+	r.NoError(events.Emit(ctx, &UserCreated{ID: "2"}))
+	r.Error(events.Flush(ctx))
+	r.Len(broker.published, 1)
+
+	broker.limit = 100
+	r.NoError(events.Flush(ctx))
+	r.Len(broker.published, 2)
+	r.Contains(string(broker.published[1].Data), `"2"`)
+}
+
+// Ensures that emitting/flushing w/o an outbox does nothing rather than failing.
+func (suite *EventsSuite) TestEmit_noOutbox() {
+	r := suite.Require()
+	r.NoError(events.Emit(context.Background(), &UserCreated{ID: "1"}))
+	r.NoError(events.Flush(context.Background()))
+}
+
+// Ensures that the in-memory broker delivers messages to the topic's subscribers.
+func (suite *EventsSuite) TestMemoryBroker() {
+	r := suite.Require()
+	broker := events.NewMemoryBroker()
+
+	var received []string
+	subscribe := func(name string, topic string) events.Subscription {
+		sub, err := broker.Subscribe(topic, func(ctx context.Context, msg events.Message) error {
+			event := UserCreated{}
+			_ = msg.Decode(&event)
+			received = append(received, name+":"+event.ID)
+			return fmt.Errorf("subscriber errors should be ignored")
+		})
+		r.NoError(err)
+		return sub
+	}
+	subA := subscribe("a", "UserCreated")
+	subscribe("b", "UserCreated")
+	subscribe("c", "UserDeleted")
+
+	r.NoError(events.Publish(context.Background(), broker, &UserCreated{ID: "1"}))
+	r.ElementsMatch([]string{"a:1", "b:1"}, received)
+
+	received = nil
+	r.NoError(subA.Unsubscribe())
+	r.NoError(events.Publish(context.Background(), broker, &UserCreated{ID: "2"}))
+	r.Equal([]string{"b:2"}, received)
+}
+
+func TestEventsSuite(t *testing.T) {
+	suite.Run(t, new(EventsSuite))
+}
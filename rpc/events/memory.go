@@ -0,0 +1,67 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// NewMemoryBroker creates a Broker that delivers messages to subscribers in the same process. It's
+// great for local development (e.g. when you Compose() all of your services into one server) and tests.
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		subscribers: map[string]map[int]Handler{},
+	}
+}
+
+// MemoryBroker delivers every message to each of the topic's subscribers before Publish() returns. Errors
+// returned by the subscribers are ignored; just like a real broker, the publisher doesn't care whether
+// the subscribers successfully handled the event.
+type MemoryBroker struct {
+	mutex       sync.RWMutex
+	nextID      int
+	subscribers map[string]map[int]Handler
+}
+
+// Publish sends the message to everyone subscribed to its topic.
+func (broker *MemoryBroker) Publish(ctx context.Context, msg Message) error {
+	broker.mutex.RLock()
+	handlers := make([]Handler, 0, len(broker.subscribers[msg.Topic]))
+	for _, handler := range broker.subscribers[msg.Topic] {
+		handlers = append(handlers, handler)
+	}
+	broker.mutex.RUnlock()
+
+	// The subscriber shouldn't be canceled just because the request that emitted the event finished.
+	for _, handler := range handlers {
+		_ = handler(msg.Context(context.Background()), msg)
+	}
+	return nil
+}
+
+// Subscribe starts delivering messages published on the topic to the handler.
+func (broker *MemoryBroker) Subscribe(topic string, handler Handler) (Subscription, error) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	broker.nextID++
+	if broker.subscribers[topic] == nil {
+		broker.subscribers[topic] = map[int]Handler{}
+	}
+	broker.subscribers[topic][broker.nextID] = handler
+	return memorySubscription{broker: broker, topic: topic, id: broker.nextID}, nil
+}
+
+// memorySubscription removes the handler from the broker when you unsubscribe.
+type memorySubscription struct {
+	broker *MemoryBroker
+	topic  string
+	id     int
+}
+
+func (sub memorySubscription) Unsubscribe() error {
+	sub.broker.mutex.Lock()
+	defer sub.broker.mutex.Unlock()
+
+	delete(sub.broker.subscribers[sub.topic], sub.id)
+	return nil
+}
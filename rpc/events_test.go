@@ -0,0 +1,72 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/events"
+)
+
+type greeted struct {
+	Name string
+}
+
+// Ensures that events emitted by successful functions are published and failed ones are discarded. Functions
+// that emit events should still work when the gateway doesn't have a broker.
+func (suite *GatewaySuite) TestWithEventBroker() {
+	broker := events.NewMemoryBroker()
+	var received []string
+	_, err := broker.Subscribe("greeted", func(ctx context.Context, msg events.Message) error {
+		event := greeted{}
+		_ = msg.Decode(&event)
+		received = append(received, event.Name)
+		return nil
+	})
+	suite.Require().NoError(err)
+
+	tests := []struct {
+		name     string
+		options  []rpc.GatewayOption
+		path     string
+		status   int
+		received []string
+	}{
+		{name: "no broker", path: "/hello/dude", status: 200},
+		{name: "success", options: []rpc.GatewayOption{rpc.WithEventBroker(broker)}, path: "/hello/dude", status: 200, received: []string{"dude"}},
+		{name: "failure", options: []rpc.GatewayOption{rpc.WithEventBroker(broker)}, path: "/hello/fail", status: 500},
+	}
+	for _, test := range tests {
+		r := suite.Require()
+		received = nil
+
+		// The function emits an event and then fails when the name is "fail".
+		gw := suite.newGateway(test.options...)
+		gw.Register(testEndpoint("POST", "/hello/:name", "Hello", func(w http.ResponseWriter, req *http.Request) {
+			serviceRequest := struct{ Name string }{}
+			if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			_ = events.Emit(req.Context(), &greeted{Name: serviceRequest.Name})
+			if serviceRequest.Name == "fail" {
+				rpc.Fail(w, req, fmt.Errorf("nope"))
+				return
+			}
+			if err := events.Flush(req.Context()); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Reply(w, req, 200, serviceRequest)
+		}))
+
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, httptest.NewRequest("POST", test.path, nil))
+		r.Equal(test.status, w.Code, test.name)
+		r.Equal(test.received, received, test.name)
+	}
+}
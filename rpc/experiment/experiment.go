@@ -0,0 +1,189 @@
+// Package experiment provides deterministic A/B bucket assignment that follows a request as it
+// hops from service to service. The first gateway to see a request (the "edge") hashes some stable
+// key such as the user's id to pick a bucket for each experiment. The assignments are stored in the
+// request's metadata, so every downstream frodo service sees the exact same buckets without having
+// to re-derive them.
+//
+//     gateway := calcrpc.NewCalculatorServiceGateway(service, rpc.WithMiddleware(
+//         experiment.Middleware(experiment.ByHeader("X-User-ID"),
+//             experiment.New("checkout-flow", "control", "one-click"),
+//             experiment.New("search-ranking", "classic", "ml"),
+//         ),
+//     ))
+//
+//     // ... then in this service or any service it calls ...
+//     if experiment.Bucket(ctx, "checkout-flow") == "one-click" {
+//         ...
+//     }
+package experiment
+
+import (
+	"context"
+	"hash/fnv"
+	"net/http"
+
+	"github.com/monadicstack/frodo/rpc/authorization"
+	"github.com/monadicstack/frodo/rpc/metadata"
+)
+
+// MetadataKey is the metadata entry where we store the map of experiment name to bucket name.
+const MetadataKey = "frodo.experiments"
+
+// Experiment describes a single A/B(/C/D...) test and the buckets that callers can be sorted into.
+type Experiment struct {
+	// Name uniquely identifies the experiment (e.g. "checkout-flow").
+	Name string
+	// Buckets are all of the variants a caller can be assigned to for this experiment.
+	Buckets []Variant
+}
+
+// Variant is a single bucket within an experiment.
+type Variant struct {
+	// Name identifies the bucket (e.g. "control" or "treatment").
+	Name string
+	// Weight is the relative share of traffic this bucket receives. A bucket with weight 2 receives
+	// twice as much traffic as one with weight 1. A weight of 0 means that no one is assigned to it.
+	Weight uint
+}
+
+// New creates an experiment where all of the named buckets receive an equal share of the traffic.
+func New(name string, buckets ...string) Experiment {
+	exp := Experiment{Name: name}
+	for _, bucket := range buckets {
+		exp.Buckets = append(exp.Buckets, Variant{Name: bucket, Weight: 1})
+	}
+	return exp
+}
+
+// Assign deterministically picks one of the experiment's buckets for the given key. The same
+// experiment name and key will always result in the same bucket, so it's safe to call this on
+// every request for a user. This returns "" if the experiment has no buckets with a positive weight.
+func (exp Experiment) Assign(key string) string {
+	total := uint64(0)
+	for _, bucket := range exp.Buckets {
+		total += uint64(bucket.Weight)
+	}
+	if total == 0 {
+		return ""
+	}
+
+	// Include the experiment name in the hash so that a user in the "treatment" group for one
+	// experiment isn't automatically in the "treatment" group for every other experiment, too.
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(exp.Name))
+	_, _ = hash.Write([]byte{0})
+	_, _ = hash.Write([]byte(key))
+	slot := hash.Sum64() % total
+
+	for _, bucket := range exp.Buckets {
+		if slot < uint64(bucket.Weight) {
+			return bucket.Name
+		}
+		slot -= uint64(bucket.Weight)
+	}
+	return ""
+}
+
+// KeyFunc extracts the stable identifier (user id, session id, etc) from the incoming request that we'll
+// hash to determine bucket assignments. Return "" if the request doesn't have a usable key; no buckets
+// will be assigned in that case.
+type KeyFunc func(req *http.Request) string
+
+// ByHeader uses the value of the given HTTP header as the key to hash for bucket assignments.
+func ByHeader(name string) KeyFunc {
+	return func(req *http.Request) string {
+		return req.Header.Get(name)
+	}
+}
+
+// ByCookie uses the value of the given cookie as the key to hash for bucket assignments.
+func ByCookie(name string) KeyFunc {
+	return func(req *http.Request) string {
+		cookie, err := req.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	}
+}
+
+// ByAuthorization uses the caller's credentials from the HTTP Authorization header as the
+// key to hash for bucket assignments.
+func ByAuthorization() KeyFunc {
+	return func(req *http.Request) string {
+		return authorization.FromContext(req.Context()).String()
+	}
+}
+
+// Middleware creates gateway middleware that assigns buckets for all of the given experiments. Assignments
+// only happen at the edge; if an upstream service already assigned a bucket for an experiment, it travels
+// with the request metadata and we'll leave it alone.
+func Middleware(keyFunc KeyFunc, experiments ...Experiment) func(http.ResponseWriter, *http.Request, http.HandlerFunc) {
+	return func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		key := keyFunc(req)
+		if key == "" {
+			next(w, req)
+			return
+		}
+
+		ctx := req.Context()
+		for _, exp := range experiments {
+			ctx = Assign(ctx, exp, key)
+		}
+		next(w, req.WithContext(ctx))
+	}
+}
+
+// Assign places a bucket for the experiment on the context's metadata if it doesn't already have one. You
+// usually won't call this yourself since Middleware() does it for you, but it's useful if the key you want
+// to hash isn't available until your handler is running.
+func Assign(ctx context.Context, exp Experiment, key string) context.Context {
+	if _, ok := Lookup(ctx, exp.Name); ok {
+		return ctx
+	}
+	bucket := exp.Assign(key)
+	if bucket == "" {
+		return ctx
+	}
+	return WithBucket(ctx, exp.Name, bucket)
+}
+
+// WithBucket forcibly places the caller into the given bucket for the experiment, overriding any
+// assignment they already had. This is handy for QA overrides or tests.
+func WithBucket(ctx context.Context, name string, bucket string) context.Context {
+	if ctx == nil {
+		return ctx
+	}
+	assignments := All(ctx)
+	assignments[name] = bucket
+	return metadata.WithValue(ctx, MetadataKey, assignments)
+}
+
+// Lookup returns the bucket that the current request was assigned for the given experiment. The
+// boolean is false when the request was never assigned a bucket for that experiment.
+func Lookup(ctx context.Context, name string) (string, bool) {
+	bucket, ok := All(ctx)[name]
+	return bucket, ok
+}
+
+// Bucket returns the bucket that the current request was assigned for the given experiment. This
+// returns "" if the request was never assigned a bucket for that experiment.
+func Bucket(ctx context.Context, name string) string {
+	bucket, _ := Lookup(ctx, name)
+	return bucket
+}
+
+// All returns a copy of every experiment/bucket assignment for the current request.
+func All(ctx context.Context) map[string]string {
+	assignments := map[string]string{}
+	if !metadata.Value(ctx, MetadataKey, &assignments) {
+		return map[string]string{}
+	}
+
+	// Copy the map so that callers mutating the result don't mutate the shared metadata value.
+	result := make(map[string]string, len(assignments))
+	for name, bucket := range assignments {
+		result[name] = bucket
+	}
+	return result
+}
@@ -0,0 +1,132 @@
+// +build unit
+
+package experiment_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/monadicstack/frodo/rpc/experiment"
+	"github.com/monadicstack/frodo/rpc/metadata"
+	"github.com/stretchr/testify/suite"
+)
+
+type ExperimentSuite struct {
+	suite.Suite
+}
+
+// Ensures that the same key always lands in the same bucket.
+func (suite *ExperimentSuite) TestAssign_deterministic() {
+	exp := experiment.New("checkout", "control", "treatment")
+	for _, key := range []string{"a", "b", "user-123", "user-456", "x"} {
+		first := exp.Assign(key)
+		suite.Require().NotEqual("", first, "Assign should always pick a bucket when weights are positive")
+		for i := 0; i < 10; i++ {
+			suite.Require().Equal(first, exp.Assign(key), "Assign should be deterministic for key '%s'", key)
+		}
+	}
+}
+
+// Ensures that weights are respected, including zero weights that should never be chosen.
+func (suite *ExperimentSuite) TestAssign_weights() {
+	exp := experiment.Experiment{
+		Name: "checkout",
+		Buckets: []experiment.Variant{
+			{Name: "never", Weight: 0},
+			{Name: "always", Weight: 1},
+		},
+	}
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		suite.Require().Equal("always", exp.Assign(key))
+	}
+
+	suite.Require().Equal("", experiment.New("empty").Assign("a"), "No buckets should result in no assignment")
+	suite.Require().Equal("", experiment.Experiment{Name: "zero", Buckets: []experiment.Variant{{Name: "a"}}}.Assign("a"))
+}
+
+// Ensures that buckets are spread somewhat evenly across many keys.
+func (suite *ExperimentSuite) TestAssign_distribution() {
+	exp := experiment.New("checkout", "a", "b")
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		counts[exp.Assign(string(rune('A'+i%26))+string(rune(i)))]++
+	}
+	suite.Require().Greater(counts["a"], 350)
+	suite.Require().Greater(counts["b"], 350)
+}
+
+// Ensures that WithBucket/Lookup/Bucket round trip through the context metadata.
+func (suite *ExperimentSuite) TestWithBucket() {
+	ctx := context.Background()
+	_, ok := experiment.Lookup(ctx, "checkout")
+	suite.Require().False(ok)
+	suite.Require().Equal("", experiment.Bucket(ctx, "checkout"))
+
+	ctx = experiment.WithBucket(ctx, "checkout", "treatment")
+	ctx = experiment.WithBucket(ctx, "search", "ml")
+	suite.Require().Equal("treatment", experiment.Bucket(ctx, "checkout"))
+	suite.Require().Equal("ml", experiment.Bucket(ctx, "search"))
+	suite.Require().Equal(map[string]string{"checkout": "treatment", "search": "ml"}, experiment.All(ctx))
+
+	// Mutating the result of All() should not affect the assignments.
+	experiment.All(ctx)["checkout"] = "control"
+	suite.Require().Equal("treatment", experiment.Bucket(ctx, "checkout"))
+}
+
+// Ensures that Assign() doesn't clobber assignments that were made upstream.
+func (suite *ExperimentSuite) TestAssign_preservesExisting() {
+	exp := experiment.New("checkout", "control", "treatment")
+	ctx := experiment.WithBucket(context.Background(), "checkout", "forced")
+	ctx = experiment.Assign(ctx, exp, "user-123")
+	suite.Require().Equal("forced", experiment.Bucket(ctx, "checkout"))
+
+	ctx = experiment.Assign(context.Background(), exp, "user-123")
+	suite.Require().Equal(exp.Assign("user-123"), experiment.Bucket(ctx, "checkout"))
+}
+
+// Ensures that assignments survive the trip through the X-RPC-Values header to a downstream service.
+func (suite *ExperimentSuite) TestAssign_metadataRoundTrip() {
+	ctx := experiment.WithBucket(context.Background(), "checkout", "treatment")
+	encoded, err := metadata.ToJSON(ctx)
+	suite.Require().NoError(err)
+
+	values, err := metadata.FromJSON(encoded)
+	suite.Require().NoError(err)
+	downstream := metadata.WithValues(context.Background(), values)
+	suite.Require().Equal("treatment", experiment.Bucket(downstream, "checkout"))
+}
+
+// Ensures that the middleware assigns buckets based on the request key and skips requests w/o one.
+func (suite *ExperimentSuite) TestMiddleware() {
+	exp := experiment.New("checkout", "control", "treatment")
+	mw := experiment.Middleware(experiment.ByHeader("X-User-ID"), exp)
+
+	bucket := ""
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		bucket = experiment.Bucket(req.Context(), "checkout")
+	}
+
+	req := httptest.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-User-ID", "user-123")
+	mw(httptest.NewRecorder(), req, handler)
+	suite.Require().Equal(exp.Assign("user-123"), bucket)
+
+	bucket = "nope"
+	mw(httptest.NewRecorder(), httptest.NewRequest("GET", "/foo", nil), handler)
+	suite.Require().Equal("", bucket, "Requests without a key should not be assigned buckets")
+}
+
+// Ensures that the cookie key function reads the right cookie.
+func (suite *ExperimentSuite) TestByCookie() {
+	req := httptest.NewRequest("GET", "/foo", nil)
+	suite.Require().Equal("", experiment.ByCookie("session")(req))
+
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	suite.Require().Equal("abc", experiment.ByCookie("session")(req))
+}
+
+func TestExperimentSuite(t *testing.T) {
+	suite.Run(t, new(ExperimentSuite))
+}
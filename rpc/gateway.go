@@ -2,12 +2,20 @@ package rpc
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/dimfeld/httptreemux/v5"
 	"github.com/monadicstack/frodo/rpc/authorization"
+	"github.com/monadicstack/frodo/rpc/dedup"
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/events"
+	"github.com/monadicstack/frodo/rpc/internal/constraints"
+	"github.com/monadicstack/frodo/rpc/jobs"
 	"github.com/monadicstack/frodo/rpc/metadata"
 	"github.com/monadicstack/respond"
 	"github.com/urfave/negroni"
@@ -15,14 +23,19 @@ import (
 
 // NewGateway creates a wrapper around your raw service to expose it via HTTP for RPC calls.
 func NewGateway(options ...GatewayOption) Gateway {
-	router := httptreemux.New()
+	mux := httptreemux.New()
+	mux.SafeAddRoutesWhileRunning = true
 	gw := Gateway{
-		Router:      router,
-		routerGroup: router.UsingContext(),
-		Binder:      jsonBinder{},
-		middleware:  middlewarePipeline{},
-		PathPrefix:  "",
-		endpoints:   map[route]Endpoint{},
+		Router:          mux,
+		router:          TreeMuxRouter(mux),
+		Binder:          jsonBinder{},
+		PathPrefix:      "",
+		endpoints:       newEndpointRegistry(),
+		JobStore:        jobs.NewMemoryStore(0),
+		DedupStore:      dedup.NewMemoryStore(),
+		ConcurrencyWait: defaultConcurrencyWait,
+		jobs:            newJobTracker(),
+		streams:         newStreamTracker(),
 	}
 	for _, option := range options {
 		option(&gw)
@@ -45,13 +58,45 @@ func NewGateway(options ...GatewayOption) Gateway {
 	//   ROUTER->restoreEndpoint->restoreMetadata->your_middleware->serviceHandler
 	//
 	// Since the router goes first, 'restoreEndpoint' has the info it needs to properly populate the context.
-	mw := middlewarePipeline{
-		MiddlewareFunc(recoverFromPanic),
+	mw := middlewarePipeline{MiddlewareFunc(recoverFromPanic)}
+	if gw.MaxInFlight > 0 {
+		mw = append(mw, shedLoad(gw.MaxInFlight))
+	}
+	if gw.Compression != nil {
+		mw = append(mw, compressResponse(gw.Compression))
+	}
+	mw = append(mw,
+		decompressRequest(gw.Compression),
 		MiddlewareFunc(restoreEndpoint),
+		logPayloads(gw.payloadLogging),
+		MiddlewareFunc(announceDeprecation),
+		MiddlewareFunc(validatePathParams),
+		limitConcurrency(gw.MaxConcurrency, gw.ConcurrencyWait),
+		limitRequestBody(gw.MaxRequestBytes),
 		MiddlewareFunc(restoreMetadata),
+		MiddlewareFunc(restoreTimeout),
 		MiddlewareFunc(restoreAuthorization),
+		MiddlewareFunc(enforceAuthorization),
+		MiddlewareFunc(restoreResponseHeaders),
+		MiddlewareFunc(restoreResponseStatus),
+		MiddlewareFunc(restoreRawHTTP),
+		MiddlewareFunc(trackProvidedFields),
+	)
+	if gw.EventBroker != nil {
+		mw = append(mw, attachOutbox(gw.EventBroker))
+	}
+	gw.builtinMiddleware = mw
+	gw.middlewareGroups = gw.middlewareGroups.sorted()
+	gw.middleware = append(append(middlewarePipeline{}, mw...), gw.middlewareGroups.pipeline(nil)...)
+
+	// Deduplication goes last so that calls rejected by any other middleware don't use up their invocation id.
+	gw.middleware = append(gw.middleware, MiddlewareFunc(deduplicate))
+
+	for _, static := range gw.staticFiles {
+		for _, endpoint := range static.endpoints() {
+			gw.Register(endpoint)
+		}
 	}
-	gw.middleware = append(mw, gw.middleware...)
 	return gw
 }
 
@@ -63,24 +108,78 @@ type GatewayOption func(*Gateway)
 // your service response struct data back to the caller. Aside from feeding this to `http.ListenAndServe()`
 // you likely won't interact with this at all yourself.
 type Gateway struct {
-	Name        string
-	Router      *httptreemux.TreeMux
-	routerGroup *httptreemux.ContextGroup
-	Binder      Binder
-	PathPrefix  string
-	middleware  middlewarePipeline
-	endpoints   map[route]Endpoint
+	Name string
+	// Router is the httptreemux router that the gateway uses by default.
+	//
+	// Deprecated: Gateways send requests to your endpoints through their Router interface now (see WithRouter),
+	// and they handle requests that don't match any route on their own (see WithNotFoundMiddleware), so changing
+	// this doesn't affect the gateway. This is nil when you supply your own router.
+	Router                 *httptreemux.TreeMux
+	router                 Router
+	Binder                 Binder
+	PathPrefix             string
+	PrefixVariables        map[string]string
+	ErrorFormat            ErrorFormat
+	ErrorRegistry          *errors.Registry
+	ResponseEnvelope       bool
+	JobStore               jobs.Store
+	DedupStore             dedup.Store
+	EventBroker            events.Broker
+	Compression            *Compression
+	MaxRequestBytes        int64
+	MaxInFlight            int
+	MaxConcurrency         int
+	ConcurrencyWait        time.Duration
+	BatchConcurrency       int
+	JSONRPCConcurrency     int
+	EndpointListing        bool
+	ETags                  bool
+	JSON                   *JSON
+	Protobuf               bool
+	StrictBinding          bool
+	PanicStackTraces       bool
+	SanitizeErrors         bool
+	middleware             middlewarePipeline
+	builtinMiddleware      middlewarePipeline
+	middlewareGroups       middlewareGroups
+	endpoints              *endpointRegistry
+	requestInterceptors    []RequestInterceptor
+	responseInterceptors   []ResponseInterceptor
+	components             []Component
+	jobs                   *jobTracker
+	streams                *streamTracker
+	staticFiles            []staticFiles
+	mountPrefix            string
+	payloadLogging         *payloadLogging
+	panicHandlers          []PanicHandler
+	bindingFailureHandlers []BindingFailureHandler
+	optionsHandler         http.HandlerFunc
+	notFoundMiddleware     middlewarePipeline
 }
 
-// Register the operation with the gateway so that it can be exposed for invoking remotely.
+// Register the operation with the gateway so that it can be exposed for invoking remotely. It's safe to
+// call this while the gateway is serving requests, so plugin-style services can add functions at runtime.
+// Registering the same method/path again replaces the existing endpoint. Paths that only differ by the names
+// of their params (e.g. "/foo/:a" and "/foo/:b") are the same path as far as this is concerned.
 func (gw *Gateway) Register(endpoint Endpoint) {
 	// The user specified a path like "GET /user/:id" in their code, so when they fetch the
 	// endpoint data later, that's what we want it to look like, so we'll leave the endpoint's
 	// Path attribute alone. But... the router needs the full path which includes the optional
 	// prefix (e.g. "/v2"). So we'll use the full path for routing and lookups (transparent to
 	// the user), but the user will never have to see the "/v2" portion.
-	path := toEndpointPath(gw.PathPrefix, endpoint.Path)
-	method := strings.ToUpper(endpoint.Method)
+	path := toEndpointPath(gw.pathPrefix(), endpoint.Path)
+	if endpoint.static {
+		path = endpoint.Path
+	}
+	r := route{method: strings.ToUpper(endpoint.Method), path: path}
+	options := route{method: http.MethodOptions, path: path}
+
+	// Even if you're re-registering an endpoint you got from EndpointFromContext(), its Defaults may have
+	// changed since we compiled its binding, so it always starts fresh.
+	endpoint.binding = &endpointBinding{}
+
+	gw.endpoints.mutex.Lock()
+	defer gw.endpoints.mutex.Unlock()
 
 	// If you're registering "POST /FooService.Bar" we're going to create a route for
 	// the POST as well as an additional, implicit OPTIONS route. This is so that
@@ -88,39 +187,142 @@ func (gw *Gateway) Register(endpoint Endpoint) {
 	// is actually part of the router/mux handling (see comments in New() for details as to why), so
 	// if we don't include an explicit OPTIONS route for this path then your CORS middleware
 	// will never actually get invoked - httprouter will just reject the request. We fully expect
-	// your CORS middleware to short-circuit the 'next' chain, so the 405 failure we're hard-coding
-	// as the OPTIONS handler won't actually be invoked if you enable CORS via middleware.
-	gw.endpoints[route{method: method, path: path}] = endpoint
-	gw.endpoints[route{method: http.MethodOptions, path: path}] = endpoint
-	gw.routerGroup.Handle(method, path, gw.middleware.Then(endpoint.Handler))
-	gw.registerOptions(path)
-}
-
-func (gw Gateway) registerOptions(path string) {
-	// I realize that recovering from panics makes the baby jesus cry. This is to handle the case where you
-	// register multiple service functions with the same path, but different methods. For instance:
-	//
-	//   GET  /foo/bar
-	//   POST /foo/bar
+	// your CORS middleware to short-circuit the 'next' chain, so the OPTIONS handler (a 405 failure
+	// unless you supply one via WithOptionsHandler) won't actually be invoked if you enable CORS via middleware.
 	//
-	// Since we blindly register an options with each, we will end up registering OPTIONS twice for that
-	// path. The httptreemux will panic when that happens. At first I planned on just looking through the
-	// gateway's already-registered endpoint paths for a match (and thus skip), but there's a case that's
-	// hard to detect:
+	// We don't need to do anything special for HEAD requests. The router sends them to the GET route for
+	// the same path when there's no explicit HEAD route, so dispatch hands them to the GET endpoint.
 	//
-	//   GET  /foo/:bar
-	//   POST /foo/:goo
-	//
-	// A dumb string-based check would see those as unique paths, but the router will still barf because they
-	// are functionally equivalent.
-	//
-	// So.... since the mux is already doing all of the hard work, I'm catching the panic in this
-	// instance to make life easier. If there's something fundamentally wrong with the route, we'll fail
-	// more naturally when we register the "real" endpoint route, so we're not going to miss meaningful errors.
-	defer func() {
-		recover()
-	}()
-	gw.routerGroup.OPTIONS(path, gw.middleware.Then(methodNotAllowedHandler{}.ServeHTTP))
+	// The router can't remove routes, so we only add a route the first time we see its shape. It always
+	// dispatches to whatever handler the registry has for that shape now (see Unregister). Functions like
+	// "GET /foo/:bar" and "POST /foo/:goo" share the same OPTIONS route, so this only adds it once, too.
+	routerPath := gw.endpoints.routerPath(r.path)
+	for _, method := range []string{r.method, http.MethodOptions} {
+		routed := route{method: method, path: routerPath}
+		if !gw.endpoints.routed[routed.shape()] {
+			gw.router.Handle(routed.method, routed.path, gw.dispatch(routed))
+			gw.endpoints.routed[routed.shape()] = true
+		}
+	}
+	gw.endpoints.add(r, endpoint, gw.pipeline(endpoint).Then(endpoint.Handler))
+	gw.endpoints.add(options, endpoint, gw.middleware.Then(gw.optionsRouteHandler()))
+}
+
+// Unregister removes the endpoint w/ the given method and path (relative to the PathPrefix, just like the
+// Endpoint you registered) from the gateway. Just like Register, the names of the path's params don't matter.
+// It's safe to call this while the gateway is serving requests; requests that are already running finish
+// normally, but new requests for the endpoint get a 404 (or whatever your WithNotFoundMiddleware() does). It
+// returns false if there is no such endpoint.
+//
+// Composite gateways (see Compose) capture the endpoints that were registered at the time you composed
+// them, so they're not affected by this.
+func (gw *Gateway) Unregister(method string, path string) bool {
+	r := route{method: strings.ToUpper(method), path: toEndpointPath(gw.pathPrefix(), path)}
+	options := route{method: http.MethodOptions, path: r.path}
+
+	gw.endpoints.mutex.Lock()
+	defer gw.endpoints.mutex.Unlock()
+
+	if r.method == http.MethodOptions || !gw.endpoints.remove(r) {
+		return false
+	}
+
+	// The implicit OPTIONS route sticks around as long as another function uses the same path.
+	gw.endpoints.remove(options)
+	for other, endpoint := range gw.endpoints.endpoints {
+		if other.method != http.MethodOptions && other.shape().path == options.shape().path {
+			gw.endpoints.add(route{method: http.MethodOptions, path: other.path}, endpoint, gw.middleware.Then(gw.optionsRouteHandler()))
+			break
+		}
+	}
+	return true
+}
+
+// Endpoints returns all of the endpoints that are currently registered w/ the gateway, ordered by path
+// and then method.
+func (gw Gateway) Endpoints() []Endpoint {
+	snapshot := gw.endpoints.snapshot()
+	var endpoints []Endpoint
+	for _, r := range sortedRoutes(snapshot) {
+		if r.method != http.MethodOptions {
+			endpoints = append(endpoints, snapshot[r])
+		}
+	}
+	return endpoints
+}
+
+// dispatch creates the router's handler for the route, which runs the current handler in the registry for the
+// route's shape. If the endpoint has been unregistered, the request is handled like any other path that doesn't
+// exist. The endpoint and path params go on the context before any middleware runs (see restoreEndpoint).
+func (gw Gateway) dispatch(routed route) http.HandlerFunc {
+	routedNames := pathParamNames(routed.path)
+	return func(w http.ResponseWriter, req *http.Request) {
+		r, endpoint, handler, ok := gw.endpoints.current(routed.shape())
+		if !ok {
+			gw.notFound(w, req, nil)
+			return
+		}
+
+		// The router uses the first names that we gave it for each param (see routerPath), so give the values
+		// to the current endpoint's params in the same positions (e.g. ":a" in "/foo/:a" becomes ":b").
+		params := make(map[string]string, len(routedNames))
+		for i, name := range pathParamNames(r.path) {
+			params[name] = gw.router.PathParam(req, routedNames[i])
+		}
+		ctx := context.WithValue(req.Context(), contextKeyEndpoint{}, endpoint)
+		ctx = context.WithValue(ctx, contextKeyPathParams{}, params)
+		handler(w, req.WithContext(ctx))
+	}
+}
+
+// notFound responds to requests that don't match any of the gateway's routes. That's a 405 w/ the "Allow" header
+// when the path exists, but only for the 'allowed' methods, or a 404 when it doesn't exist at all. Either way,
+// your WithNotFoundMiddleware() runs first.
+func (gw Gateway) notFound(w http.ResponseWriter, req *http.Request, allowed []string) {
+	handler := gw.notFoundMiddleware.Then(func(w http.ResponseWriter, req *http.Request) {
+		if len(allowed) == 0 {
+			http.NotFound(w, req)
+			return
+		}
+		for _, method := range allowed {
+			w.Header().Add("Allow", method)
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+	handler(w, req)
+}
+
+// allowedMethods returns the methods of the gateway's routes that match the request's path. The router only
+// tells us whether a route matches the request's method, so we ask it about each of the other methods, too.
+func (gw Gateway) allowedMethods(req *http.Request) []string {
+	var allowed []string
+	for _, method := range gw.endpoints.routedMethods() {
+		probe := *req
+		probe.Method = method
+		if _, ok := gw.router.Lookup(&probe); ok && method != req.Method {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// pipeline returns all of the middleware that we should run for the endpoint. That's everything unless the
+// endpoint skips any of the middleware groups (see WithMiddlewareGroup).
+func (gw Gateway) pipeline(endpoint Endpoint) middlewarePipeline {
+	if len(endpoint.SkipMiddleware) == 0 {
+		return gw.middleware
+	}
+	mw := append(append(middlewarePipeline{}, gw.builtinMiddleware...), gw.middlewareGroups.pipeline(endpoint.SkipMiddleware)...)
+	return append(mw, MiddlewareFunc(deduplicate))
+}
+
+// optionsRouteHandler returns the handler for the implicit OPTIONS routes. That's the one from WithOptionsHandler,
+// or one that replies w/ a 405 when you didn't supply one.
+func (gw Gateway) optionsRouteHandler() http.HandlerFunc {
+	if gw.optionsHandler != nil {
+		return gw.optionsHandler
+	}
+	return methodNotAllowedHandler{}.ServeHTTP
 }
 
 // ServeHTTP is the central HTTP handler that includes all http routing, middleware, service forwarding, etc.
@@ -144,7 +346,35 @@ func (gw Gateway) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	//
 	// tldr; Frodo doesn't use the wrapped writer for anything special. It's just to keep negroni from barfing.
 	ctx := context.WithValue(req.Context(), contextKeyGateway{}, &gw)
-	gw.Router.ServeHTTP(negroni.NewResponseWriter(w), req.WithContext(ctx))
+	if gw.ResponseEnvelope {
+		ctx = context.WithValue(ctx, contextKeyStartTime{}, time.Now())
+	}
+	w = negroni.NewResponseWriter(w)
+	req = req.WithContext(ctx)
+
+	// Your endpoints' middleware already recovers from panics (see recoverFromPanic), but your
+	// not-found middleware doesn't run as part of that.
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			failPanic(w, req, recovered)
+		}
+	}()
+
+	handler, ok := gw.router.Lookup(req)
+	if !ok && req.Method == http.MethodHead {
+		// Not every router sends HEAD requests to the GET route when there's no explicit HEAD route for the
+		// path, so we do it ourselves. The HTTP server discards the body of the response for us.
+		get := *req
+		get.Method = http.MethodGet
+		if handler, ok = gw.router.Lookup(&get); ok {
+			req = &get
+		}
+	}
+	if !ok {
+		gw.notFound(w, req, gw.allowedMethods(req))
+		return
+	}
+	handler.ServeHTTP(w, req)
 }
 
 // Endpoint describes an operation that we expose through an RPC gateway.
@@ -157,8 +387,55 @@ type Endpoint struct {
 	ServiceName string
 	// Name is the name of the function/operation that this endpoint describes.
 	Name string
+	// Status is the HTTP status code that the operation responds w/ when it succeeds (e.g. 200 or 202). This
+	// is 0 for endpoints that you register yourself w/o one.
+	Status int
+	// Auth indicates whether callers need to supply the "Authorization" header to invoke this operation. This
+	// is blank when the operation doesn't have an "AUTH" doc option.
+	Auth AuthRequirement
+	// MaxRequestBytes overrides the gateway's limit on the size of the request body for this operation. This
+	// is 0 when the operation doesn't have a "MAXBYTES" doc option and negative when the option is "unlimited".
+	MaxRequestBytes int64
+	// MaxConcurrency overrides the gateway's limit on how many requests for this operation can run at the same
+	// time. This is 0 when the operation doesn't have a "CONCURRENCY" doc option and negative when the option
+	// is "unlimited".
+	MaxConcurrency int
+	// PathConstraints are the formats (e.g. "uuid" or "int") that path params must match, keyed by the param
+	// name. The gateway rejects requests w/ a 400 when they don't, so malformed values never reach your handler.
+	PathConstraints map[string]string
+	// Version is the version name (e.g. "v2") that this endpoint was mounted under when the gateway was composed
+	// using ComposeVersions(). It's blank otherwise.
+	Version string
+	// Strict rejects request bodies that contain attributes w/o a matching field in the request struct, even
+	// if the gateway isn't using WithStrictBinding(). This is enabled via the "STRICT" doc option.
+	Strict bool
+	// SkipMiddleware are the names of the middleware groups (see WithMiddlewareGroup) that should NOT run for
+	// this endpoint. This is enabled via the "SKIP-MIDDLEWARE" doc option.
+	SkipMiddleware []string
+	// Deprecation (optional) makes the gateway respond w/ the "Deprecation" and "Sunset" headers. This is
+	// enabled via the "DEPRECATED" and "SUNSET" doc options.
+	Deprecation *Deprecation
+	// Dedupe is how long the gateway remembers the invocation ids of calls to this operation so that it can reject
+	// retries of calls that it already handled (see WithDedupStore). This is 0 when the operation doesn't have a
+	// "DEDUPE" doc option, so every call runs.
+	Dedupe time.Duration
+	// Defaults are the values that the binder applies to request fields that are still zero after binding, keyed
+	// by the field's attribute name (e.g. {"Limit": "25"}). These come from "DEFAULT" doc options on the request's
+	// fields. The binder also applies `default:"25"` struct tags on its own, but these take precedence.
+	Defaults map[string]string
 	// Handler is the gateway function that does the "work".
 	Handler http.HandlerFunc
+	// batch is true for the "POST /rpc/batch" endpoint (see WithBatch).
+	batch bool
+	// jsonrpc is true for the "POST /rpc" endpoint (see WithJSONRPC).
+	jsonrpc bool
+	// static is true for endpoints that serve static files (see WithStaticFiles). They ignore the PathPrefix.
+	static bool
+	// listing is true for the "GET /.well-known/frodo/endpoints" endpoint (see WithEndpointListing).
+	listing bool
+	// binding is the endpoint's compiled binding info, which the binder builds on the first request (see Bind).
+	// It's a pointer so that every copy of the endpoint (e.g. on each request's context) shares it.
+	binding *endpointBinding
 }
 
 // String just returns the fully qualified "Service.Operation" descriptor for the operation.
@@ -182,56 +459,74 @@ func EndpointFromContext(ctx context.Context) *Endpoint {
 	return &endpoint
 }
 
-// recoverFromPanic automatically recovers from a panic thrown by your handler so that if you nil-pointer
-// or something else unexpected, we'll safely just return a 500-style error.
-func recoverFromPanic(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
-	defer func() {
-		if err := recover(); err != nil {
-			respond.To(w, req).InternalServerError("%v", err)
-		}
-	}()
-	next(w, req)
-}
-
-// restoreEndpoint places the *Endpoint data for the current operation onto the request context
+// restoreEndpoint makes sure that the *Endpoint data for the current operation is on the request context
 // so your handler can access the RPC details about what is being invoked. Mainly useful for fetching
 // logging/tracing info about the operation.
+//
+// Whatever routed the request (the gateway's router, a composite gateway, or your own router) already put the
+// endpoint there. The gateway sends HEAD requests to the GET route when there's no explicit HEAD route for
+// the path, so they're handled by the GET endpoint. The HTTP server discards the body of HEAD responses for us
+// (it still reports the Content-Length), so the headers are identical to what the GET would respond with.
 func restoreEndpoint(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
-	gw, ok := req.Context().Value(contextKeyGateway{}).(*Gateway)
-	if !ok {
-		respond.To(w, req).InternalServerError("invalid rpc gateway context")
-		return
-	}
-
-	routeData := httptreemux.ContextData(req.Context())
-	routePath := routeData.Route()
-
 	// The more you know: This failure is a 500, not a 404 because to hit this point in the code, the
 	// router/mux must have routed the caller to a real handler that we're currently processing middleware
 	// for, so the route "exists". What failed is the fact that our internal data structure for the
 	// service operation endpoint is not there when it should be. The server is in a bad state, so 500, not 404.
-	endpoint, ok := gw.endpoints[route{method: req.Method, path: routePath}]
-	if !ok {
-		respond.To(w, req).InternalServerError("no endpoint for route '%s %s'", req.Method, routePath)
+	if _, ok := req.Context().Value(contextKeyEndpoint{}).(Endpoint); !ok {
+		Fail(w, req, errors.Unexpected("no endpoint for route '%s %s'", req.Method, req.URL.Path))
 		return
 	}
+	next(w, req)
+}
 
-	ctx := context.WithValue(req.Context(), contextKeyEndpoint{}, endpoint)
-	next(w, req.WithContext(ctx))
+// validatePathParams rejects requests w/ a 400 when a path param doesn't match its endpoint's constraint (e.g.
+// "abc" for the ":id" in "GET /user/:id(int)"). It runs after restoreEndpoint so that the constraints are available.
+func validatePathParams(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	endpoint := EndpointFromContext(req.Context())
+	if endpoint == nil || len(endpoint.PathConstraints) == 0 {
+		next(w, req)
+		return
+	}
+
+	for name, value := range PathParams(req) {
+		if constraint, ok := endpoint.PathConstraints[name]; ok && !constraints.Matches(constraint, value) {
+			err := errors.BadRequest("invalid path parameter '%s': '%s' is not a valid %s", name, value, constraint)
+			ReportBindingFailure(req, "path", name, err)
+			Fail(w, req, err)
+			return
+		}
+	}
+	next(w, req)
 }
 
 // restoreMetadata parses the "X-RPC-Values" request header and places the values onto the context's metadata
 // so that all shared values from the caller are available for your handler when it's finally invoked.
+//
+// We also accept the entries of a W3C "Baggage" header as metadata so that values propagated by non-frodo
+// services (e.g. via OpenTelemetry) are available, too.
+//
+// When the same key shows up more than once, the server's value wins. Values that were already on the context
+// before the gateway saw the request (e.g. from an http.Handler wrapping the gateway) beat the caller's values,
+// and anything your middleware/handler sets afterwards beats both. Among the caller's values, the ones in
+// X-RPC-Values beat the baggage since they keep their original types. Whatever wins is what we forward to the
+// next service you call.
 func restoreMetadata(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
 	encodedValues := req.Header.Get(metadata.RequestHeader)
 
 	values, err := metadata.FromJSON(encodedValues)
 	if err != nil {
-		respond.To(w, req).BadRequest("rpc metadata error: %v", err.Error())
+		err = errors.BadRequest("rpc metadata error: %v", err.Error())
+		ReportBindingFailure(req, "metadata", metadata.RequestHeader, err)
+		Fail(w, req, err)
 		return
 	}
 
 	ctx := metadata.WithValues(req.Context(), values)
+	if baggage := req.Header.Values(metadata.BaggageHeader); len(baggage) > 0 {
+		baggageValues := metadata.FromBaggage(strings.Join(baggage, ","))
+		ctx = metadata.Merge(metadata.WithValues(req.Context(), baggageValues), ctx)
+	}
+	ctx = metadata.Merge(ctx, req.Context())
 	next(w, req.WithContext(ctx))
 }
 
@@ -261,11 +556,12 @@ func toEndpointPath(prefix string, path string) string {
 
 // methodNotAllowedHandler just replies with a 405 error status no matter what. It's the
 // default OPTIONS handler we use so that you can insert the CORS middleware of your
-// choice should you choose to enable browser-based communication w/ your service.
+// choice should you choose to enable browser-based communication w/ your service (or
+// supply your own handler via WithOptionsHandler).
 type methodNotAllowedHandler struct{}
 
 func (methodNotAllowedHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	respond.To(w, req).MethodNotAllowed("")
+	Fail(w, req, errors.New(http.StatusMethodNotAllowed, ""))
 }
 
 // CompositeGateway is a gateway that is composed of multiple service RPC Gateway instances. You use
@@ -297,16 +593,24 @@ func (gw CompositeGateway) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 //     groupGateway := groups.NewGroupServiceGateway(groupService)
 //     projectGateway := projects.NewProjectServiceGateway(projectService)
 //
-//     gateway := rpc.Compose(
+//     gateway, err := rpc.Compose(
 //         userGateway,
 //         groupGateway,
 //         projectGateway,
 //     )
+//     if err != nil {
+//         log.Fatal(err)
+//     }
 //     http.listenAndService(":8080", gateway)
 //
 // This will preserve all of the original gateways as well. Now you'll just have a "master" gateway that contains
-// all of the routes/endpoints from all of the services.
-func Compose(gateways ...Gateway) CompositeGateway {
+// all of the routes/endpoints from all of the services. Requests that don't match any route are handled by the
+// not-found/method-not-allowed handling (see WithNotFoundMiddleware) of the gateway whose path prefix matches.
+//
+// If two gateways have routes that conflict (e.g. "GET /user/:id" and "GET /user/:userID"), the error lists every
+// one of the conflicts so that you can fix them all at once. You can resolve them by giving the gateways their own
+// prefixes at compose time using Mount().
+func Compose(gateways ...Gateway) (CompositeGateway, error) {
 	router := httptreemux.New()
 	result := CompositeGateway{
 		Name:        "Composite",
@@ -315,15 +619,214 @@ func Compose(gateways ...Gateway) CompositeGateway {
 		Gateways:    gateways,
 		endpoints:   map[route]Endpoint{},
 	}
+	routes := newComposedRoutes(result.routerGroup)
 
-	for _, gw := range gateways {
+	// Each gateway w/ batching (or JSON-RPC) enabled has its own "/rpc/batch" (or "/rpc") endpoint that only knows
+	// about its own service. We mount a single one instead that can call any function on any of the services
+	// (prefixed ones are left alone). The same goes for the endpoint listing, which describes all of the services.
+	var batchGateway, jsonrpcGateway, listingGateway *Gateway
+	for i, gw := range gateways {
 		result.Name = result.Name + ":" + gw.Name
-		for r, endpoint := range gw.endpoints {
-			result.routerGroup.Handler(r.method, r.path, endpoint.Handler)
-			result.endpoints[r] = endpoint
+		endpoints := gw.endpoints.snapshot()
+		for _, r := range sortedRoutes(endpoints) {
+			endpoint := endpoints[r]
+			if endpoint.batch && (batchGateway == nil || gw.BatchConcurrency > batchGateway.BatchConcurrency) {
+				batchGateway = &gateways[i]
+			}
+			if endpoint.jsonrpc && (jsonrpcGateway == nil || gw.JSONRPCConcurrency > jsonrpcGateway.JSONRPCConcurrency) {
+				jsonrpcGateway = &gateways[i]
+			}
+			if endpoint.listing {
+				if listingGateway == nil {
+					listingGateway = &gateways[i]
+				}
+				continue
+			}
+			if (endpoint.batch && r.path == BatchPath) || (endpoint.jsonrpc && r.path == JSONRPCPath) {
+				continue
+			}
+			mounted := route{method: r.method, path: r.path}
+			if gw.mountPrefix != "" {
+				mounted.path = toEndpointPath(gw.mountPrefix, r.path)
+			}
+			if r.method == http.MethodOptions {
+				endpoint.Handler = gw.optionsRouteHandler()
+			}
+			if routes.register(mounted, endpoint, composeHandler(gw, endpoint)) {
+				result.endpoints[mounted] = endpoint
+			}
+		}
+	}
+	mountShared := func(gw *Gateway, endpoint Endpoint) {
+		endpoint.ServiceName = result.Name
+		for _, method := range []string{endpoint.Method, http.MethodOptions} {
+			r := route{method: method, path: endpoint.Path}
+			if method == http.MethodOptions {
+				endpoint.Handler = gw.optionsRouteHandler()
+			}
+			if routes.register(r, endpoint, composeHandler(*gw, endpoint)) {
+				result.endpoints[r] = endpoint
+			}
+		}
+	}
+	if batchGateway != nil {
+		endpoint := batchGateway.BatchEndpoint()
+		endpoint.Handler = batchHandler(batchGateway.BatchConcurrency, result.lookupBatchEndpoint)
+		mountShared(batchGateway, endpoint)
+	}
+	if jsonrpcGateway != nil {
+		endpoint := jsonrpcGateway.JSONRPCEndpoint()
+		endpoint.Handler = jsonrpcHandler(jsonrpcGateway.JSONRPCConcurrency, result.lookupBatchEndpoint)
+		mountShared(jsonrpcGateway, endpoint)
+	}
+	if listingGateway != nil {
+		endpoint := listingGateway.EndpointListingEndpoint()
+		endpoint.Handler = endpointListingHandler(func() map[route]Endpoint { return result.endpoints }, listingGateway.JSON.framework())
+		mountShared(listingGateway, endpoint)
+	}
+	if len(gateways) > 0 {
+		router.NotFoundHandler = result.notFound
+		router.MethodNotAllowedHandler = result.methodNotAllowed
+	}
+	if len(routes.conflicts) > 0 {
+		return result, fmt.Errorf("rpc: unable to compose gateways w/ %d conflicting routes:\n    %s",
+			len(routes.conflicts),
+			strings.Join(routes.conflicts, "\n    "))
+	}
+	return result, nil
+}
+
+// Mount places all of the gateway's routes under an additional path prefix when you Compose() it w/ other
+// gateways. This lets you combine services whose routes would otherwise conflict w/o regenerating them with a
+// different PATH prefix:
+//
+//     gateway, err := rpc.Compose(
+//         rpc.Mount("/legacy", legacyrpc.NewUserServiceGateway(legacyService).Gateway),
+//         usersrpc.NewUserServiceGateway(userService).Gateway,
+//     )
+//
+// The prefix goes in front of the gateway's own PathPrefix, so "GET /user/:id" in a gateway w/ the prefix "/api"
+// is available at "/legacy/api/user/:id". Point that service's clients at "http://host:port/legacy" so that they
+// include it, too. This only affects the routes of the composite gateway; the gateway itself is unchanged when
+// you serve it on its own.
+func Mount(prefix string, gw Gateway) Gateway {
+	gw.mountPrefix = strings.Trim(toEndpointPath(prefix, gw.mountPrefix), "/")
+	return gw
+}
+
+// lookupBatchEndpoint finds the endpoint for a call in a batch request, no matter which service it belongs to.
+func (gw CompositeGateway) lookupBatchEndpoint(service string, name string) (*Gateway, Endpoint, bool) {
+	for i := range gw.Gateways {
+		if endpoint, ok := gw.Gateways[i].endpoints.lookupFunction(service, name); ok {
+			return &gw.Gateways[i], endpoint, true
+		}
+	}
+	return nil, Endpoint{}, false
+}
+
+// notFound lets the gateway that "owns" the request's path handle a request that doesn't match any of the
+// composite's routes, so it still runs that gateway's not-found middleware (see WithNotFoundMiddleware).
+func (gw CompositeGateway) notFound(w http.ResponseWriter, req *http.Request) {
+	owner := gw.owner(req.URL.Path)
+	ctx := context.WithValue(req.Context(), contextKeyGateway{}, owner)
+	owner.notFound(w, req.WithContext(ctx), nil)
+}
+
+// methodNotAllowed lets the gateway that "owns" the request's path reject a request whose path exists, but
+// not for the request's method, so it still runs that gateway's not-found middleware (see WithNotFoundMiddleware).
+func (gw CompositeGateway) methodNotAllowed(w http.ResponseWriter, req *http.Request, methods map[string]httptreemux.HandlerFunc) {
+	owner := gw.owner(req.URL.Path)
+	ctx := context.WithValue(req.Context(), contextKeyGateway{}, owner)
+	allowed := make([]string, 0, len(methods))
+	for method := range methods {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+	owner.notFound(w, req.WithContext(ctx), allowed)
+}
+
+// owner returns the composed gateway whose full prefix (mount prefix + PathPrefix) is the longest match for the
+// given request path. When none of them match, the first gateway is the owner.
+func (gw CompositeGateway) owner(path string) *Gateway {
+	owner, ownerPrefix := &gw.Gateways[0], ""
+	for i := range gw.Gateways {
+		prefix := strings.TrimSuffix(toEndpointPath(gw.Gateways[i].mountPrefix, gw.Gateways[i].pathPrefix()), "/")
+		if len(prefix) <= len(ownerPrefix) {
+			continue
+		}
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			owner, ownerPrefix = &gw.Gateways[i], prefix
+		}
+	}
+	return owner
+}
+
+// composeHandler makes sure that endpoints in a composite gateway still run the middleware from their
+// original gateway. That middleware expects to find the original gateway on the context, not the composite. The
+// endpoint goes on the context, too, since the composite's route may include a mount prefix (see Mount), so the
+// gateway wouldn't be able to look up the endpoint on its own.
+func composeHandler(gw Gateway, endpoint Endpoint) http.HandlerFunc {
+	handler := gw.pipeline(endpoint).Then(endpoint.Handler)
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := context.WithValue(req.Context(), contextKeyGateway{}, &gw)
+		ctx = context.WithValue(ctx, contextKeyEndpoint{}, endpoint)
+		handler(w, req.WithContext(ctx))
+	}
+}
+
+// newComposedRoutes creates the registry that tracks which endpoint owns each route in a composite gateway.
+func newComposedRoutes(group *httptreemux.ContextGroup) *composedRoutes {
+	return &composedRoutes{group: group, owners: map[route]string{}}
+}
+
+// composedRoutes registers routes w/ a composite gateway's router, collecting conflicts rather than panicking
+// on the first one like the router does.
+type composedRoutes struct {
+	group     *httptreemux.ContextGroup
+	owners    map[route]string
+	conflicts []string
+}
+
+// register adds the route to the router. It returns false when the route conflicts w/ one that is already
+// registered. Every gateway has an implicit OPTIONS route for each of its paths (see Gateway.Register), so
+// multiple gateways sharing one is not a conflict; the first one wins.
+func (routes *composedRoutes) register(r route, endpoint Endpoint, handler http.HandlerFunc) (ok bool) {
+	// Paths like "/foo/:bar" and "/foo/:goo" are different strings, but the router treats them as the same route.
+	key := route{method: r.method, path: routeShape(r.path)}
+	if owner, exists := routes.owners[key]; exists {
+		if r.method != http.MethodOptions {
+			routes.conflicts = append(routes.conflicts, fmt.Sprintf("%s %s (%s) conflicts w/ %s", r.method, r.path, endpoint, owner))
+		}
+		return false
+	}
+
+	// Just in case the router sees a conflict that we don't (e.g. "/foo/:bar" and "/foo/*bar").
+	defer func() {
+		if err := recover(); err != nil {
+			ok = false
+			if r.method != http.MethodOptions {
+				routes.conflicts = append(routes.conflicts, fmt.Sprintf("%s %s (%s): %v", r.method, r.path, endpoint, err))
+			}
+		}
+	}()
+	routes.group.Handler(r.method, r.path, handler)
+	routes.owners[key] = fmt.Sprintf("%s %s (%s)", r.method, r.path, endpoint)
+	return true
+}
+
+// routeShape strips the names from a path's params (e.g. "/foo/:bar/*baz" becomes "/foo/:/*") so that routes that
+// only differ by param names are treated as the same route.
+func routeShape(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			segments[i] = ":"
+		case strings.HasPrefix(segment, "*"):
+			segments[i] = "*"
 		}
 	}
-	return result
+	return strings.Join(segments, "/")
 }
 
 type route struct {
@@ -331,6 +834,11 @@ type route struct {
 	path   string
 }
 
+// shape returns the route w/ the names stripped from its path's params (see routeShape).
+func (r route) shape() route {
+	return route{method: r.method, path: routeShape(r.path)}
+}
+
 // ContentReader defines a response type that should be treated as raw bytes, not JSON.
 type ContentReader respond.ContentReader
 
@@ -375,25 +883,31 @@ type ContentFileNameWriter interface {
 // something like that, you can short circuit the standard handler by simply not calling "next()", just as
 // you would with standard middleware.
 func WithNotFoundMiddleware(handlers ...MiddlewareFunc) GatewayOption {
-	// For method not allowed handlers, we will shove the map of allowed methods onto the context so that
-	// we can use standard http.HandlerFunc functions to treat it like any other handler.
-	type contextKeyMethods struct{}
-
+	// Even if you provide custom handling, no need for you to have to re-invent the wheel to do basic 40X status
+	// handling and setting "Allow" headers and so forth. The gateway's default handling caps off the middleware
+	// chain for these types of requests (see Gateway.notFound).
 	return func(gateway *Gateway) {
-		// Even if you provide custom handling, no need for you to have to re-invent the wheel to do basic 40X status
-		// handling and setting "Allow" handlers and so forth. We will use the router's default handlers to
-		// cap off the middleware chain for these types of requests.
-		defaultNotFound := gateway.Router.NotFoundHandler
-		gateway.Router.NotFoundHandler = middlewarePipeline(handlers).Then(defaultNotFound)
-
-		defaultMethodNotAllowed := gateway.Router.MethodNotAllowedHandler
-		customMethodNotAllowed := middlewarePipeline(handlers).Then(func(w http.ResponseWriter, req *http.Request) {
-			methods := req.Context().Value(contextKeyMethods{}).(map[string]httptreemux.HandlerFunc)
-			defaultMethodNotAllowed(w, req, methods)
-		})
-		gateway.Router.MethodNotAllowedHandler = func(w http.ResponseWriter, req *http.Request, methods map[string]httptreemux.HandlerFunc) {
-			ctx := context.WithValue(req.Context(), contextKeyMethods{}, methods)
-			customMethodNotAllowed(w, req.WithContext(ctx))
-		}
+		gateway.notFoundMiddleware = append(gateway.notFoundMiddleware, handlers...)
+	}
+}
+
+// WithOptionsHandler replaces what the gateway does for OPTIONS requests. Every path that has at least one
+// of your service functions gets an implicit OPTIONS route; by default, it replies w/ a 405 so that the only
+// way to handle them is w/ CORS middleware that short-circuits the request. Supply your own handler when you
+// want to express CORS preflight or capability discovery as a handler instead:
+//
+//     gateway := users.NewUserServiceGateway(userService, rpc.WithOptionsHandler(
+//         func(w http.ResponseWriter, req *http.Request) {
+//             w.Header().Set("Access-Control-Allow-Origin", "*")
+//             w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+//             w.WriteHeader(http.StatusNoContent)
+//         },
+//     ))
+//
+// The handler runs after all of your middleware, so a CORS middleware that short-circuits OPTIONS requests
+// still wins. Use rpc.EndpointFromContext() to see which service the request's path belongs to.
+func WithOptionsHandler(handler http.HandlerFunc) GatewayOption {
+	return func(gw *Gateway) {
+		gw.optionsHandler = handler
 	}
 }
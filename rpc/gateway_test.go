@@ -13,17 +13,20 @@ import (
 	"testing"
 	"time"
 
-	"github.com/dimfeld/httptreemux/v5"
-	"github.com/monadicstack/frodo/internal/testext"
 	"github.com/monadicstack/frodo/rpc"
 	"github.com/monadicstack/frodo/rpc/authorization"
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/internal/testext"
 	"github.com/monadicstack/frodo/rpc/metadata"
+	"github.com/monadicstack/frodo/rpc/operation"
 	"github.com/stretchr/testify/suite"
 )
 
 type GatewaySuite struct {
 	suite.Suite
 	HTTPClient *http.Client
+	// newRouter creates the router that the suite's gateways use. They use the default one when it's nil.
+	newRouter func() rpc.Router
 }
 
 func (suite *GatewaySuite) SetupTest() {
@@ -38,12 +41,12 @@ func (suite *GatewaySuite) SetupTest() {
 }
 
 func (suite *GatewaySuite) TestNewGateway() {
-	gateway := rpc.NewGateway()
+	gateway := suite.newGateway()
 	suite.Require().NotNil(gateway, "Gateway should be non-nil with no special options")
 	suite.Require().NotNil(gateway.Binder, "Gateway should have binder by default")
 	suite.Require().Equal("", gateway.PathPrefix, "Gateway should not have a path prefix by default")
 
-	gateway = rpc.NewGateway(
+	gateway = suite.newGateway(
 		func(g *rpc.Gateway) { g.Binder = nil },
 		func(g *rpc.Gateway) { g.Name = "Foo" },
 		func(g *rpc.Gateway) { g.PathPrefix = "/fart" },
@@ -59,7 +62,7 @@ func (suite *GatewaySuite) TestNewGateway() {
 // Ensures that we respond with 404 to some otherwise common routes "GET /", "GET /ServiceName", "POST /ServiceName"
 // if you have not registered any endpoints.
 func (suite *GatewaySuite) TestNoRoutes() {
-	server := httptest.NewServer(rpc.NewGateway(
+	server := httptest.NewServer(suite.newGateway(
 		func(g *rpc.Gateway) { g.Name = "FooService" },
 	))
 
@@ -81,7 +84,7 @@ func (suite *GatewaySuite) TestNoRoutes() {
 
 // Ensures that when you ".Register()" endpoints that the proper routes AND their OPTIONS counterparts are there.
 func (suite *GatewaySuite) TestRegister() {
-	gateway := rpc.NewGateway(func(g *rpc.Gateway) { g.Name = "FooService" })
+	gateway := suite.newGateway(func(g *rpc.Gateway) { g.Name = "FooService" })
 	gateway.Register(rpc.Endpoint{
 		Method:      "POST",
 		Path:        "FooService.Hello",
@@ -97,7 +100,7 @@ func (suite *GatewaySuite) TestRegister() {
 		ServiceName: "FooService",
 		Name:        "Sum",
 		Handler: func(w http.ResponseWriter, req *http.Request) {
-			params := httptreemux.ContextParams(req.Context())
+			params := rpc.PathParams(req)
 			suite.respond(w, 202, fmt.Sprintf("%s + %s = ?", params["a"], params["b"]))
 		},
 	})
@@ -191,6 +194,246 @@ func (suite *GatewaySuite) TestRegister() {
 	suite.Require().Equal(404, status, "Gateway should not accept OPTIONS for any old path/route")
 }
 
+// Ensures that you can register and unregister endpoints while the gateway is serving requests.
+func (suite *GatewaySuite) TestRegister_running() {
+	gateway := suite.newGateway(func(g *rpc.Gateway) { g.Name = "PluginService" })
+	newEndpoint := func(method string, name string) rpc.Endpoint {
+		return rpc.Endpoint{
+			Method:      method,
+			Path:        "/plugins/:name",
+			ServiceName: "PluginService",
+			Name:        name,
+			Handler: func(w http.ResponseWriter, req *http.Request) {
+				params := rpc.PathParams(req)
+				suite.respond(w, 200, rpc.EndpointFromContext(req.Context()).String()+" "+params["name"])
+			},
+		}
+	}
+
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	status, _, err := suite.request(server, "GET", "/plugins/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(404, status)
+
+	// Hammer the gateway w/ requests while we register new endpoints to make sure that it's thread-safe.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			_, _, _ = suite.request(server, "GET", "/plugins/foo", "")
+		}
+	}()
+	gateway.Register(newEndpoint("GET", "GetPlugin"))
+	gateway.Register(newEndpoint("DELETE", "DeletePlugin"))
+	<-done
+
+	status, result, err := suite.request(server, "GET", "/plugins/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status)
+	suite.Require().Equal("PluginService.GetPlugin foo", result)
+	suite.Require().Len(gateway.Endpoints(), 2)
+
+	suite.Require().True(gateway.Unregister("get", "plugins/:name"))
+	suite.Require().False(gateway.Unregister("GET", "/plugins/:name"), "Should not unregister twice")
+	suite.Require().False(gateway.Unregister("OPTIONS", "/plugins/:name"), "Should not unregister implicit OPTIONS")
+
+	status, _, err = suite.request(server, "GET", "/plugins/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(404, status, "Unregistered endpoints should not be found")
+
+	status, result, err = suite.request(server, "DELETE", "/plugins/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status, "Other endpoints on the same path should still work")
+	suite.Require().Equal("PluginService.DeletePlugin foo", result)
+
+	status, _, err = suite.request(server, "OPTIONS", "/plugins/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(405, status, "OPTIONS should remain while the path has endpoints")
+
+	suite.Require().True(gateway.Unregister("DELETE", "/plugins/:name"))
+	suite.Require().Empty(gateway.Endpoints())
+	status, _, err = suite.request(server, "OPTIONS", "/plugins/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(404, status, "OPTIONS should go away w/ the last endpoint on the path")
+
+	// Registering it again brings it back to life.
+	gateway.Register(newEndpoint("GET", "GetPluginV2"))
+	status, result, err = suite.request(server, "GET", "/plugins/bar", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status)
+	suite.Require().Equal("PluginService.GetPluginV2 bar", result)
+}
+
+// Ensures that you can replace an endpoint w/ one whose path only differs by its param names while the gateway is
+// running. The router sees those as the same route, so it shouldn't blow up, and the new names should bind.
+func (suite *GatewaySuite) TestRegister_renamedParams() {
+	gateway := suite.newGateway(func(g *rpc.Gateway) { g.Name = "PluginService" })
+	newEndpoint := func(method string, path string) rpc.Endpoint {
+		return rpc.Endpoint{
+			Method:      method,
+			Path:        path,
+			ServiceName: "PluginService",
+			Name:        method + "Plugin",
+			Handler: func(w http.ResponseWriter, req *http.Request) {
+				params := struct{ Name, ID, Slug, Key string }{}
+				suite.Require().NoError(gateway.Binder.Bind(req, &params))
+				suite.respond(w, 200, fmt.Sprintf("%s %+v", rpc.EndpointFromContext(req.Context()).Path, params))
+			},
+		}
+	}
+
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	gateway.Register(newEndpoint("GET", "/plugins/:name"))
+	gateway.Register(newEndpoint("POST", "/plugins/:key"))
+	suite.Require().True(gateway.Unregister("GET", "/plugins/:name"))
+	suite.Require().NotPanics(func() { gateway.Register(newEndpoint("GET", "/plugins/:id")) })
+
+	status, result, err := suite.request(server, "GET", "/plugins/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status)
+	suite.Require().Equal("/plugins/:id {Name: ID:foo Slug: Key:}", result)
+
+	// Registering it again w/o unregistering it first should replace it, too.
+	gateway.Register(newEndpoint("GET", "/plugins/:slug"))
+	status, result, err = suite.request(server, "GET", "/plugins/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status)
+	suite.Require().Equal("/plugins/:slug {Name: ID: Slug:foo Key:}", result)
+	suite.Require().Len(gateway.Endpoints(), 2)
+
+	// The GET endpoint that first added the OPTIONS route is gone, but the POST still uses the path.
+	suite.Require().True(gateway.Unregister("GET", "/plugins/:id"), "Should unregister by shape, too")
+	status, _, err = suite.request(server, "OPTIONS", "/plugins/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(405, status, "OPTIONS should remain while the path has endpoints")
+
+	status, result, err = suite.request(server, "POST", "/plugins/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status)
+	suite.Require().Equal("/plugins/:key {Name: ID: Slug: Key:foo}", result)
+
+	suite.Require().True(gateway.Unregister("POST", "/plugins/:key"))
+	status, _, err = suite.request(server, "OPTIONS", "/plugins/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(404, status, "OPTIONS should go away w/ the last endpoint on the path")
+}
+
+// Ensures that HEAD requests are handled by the GET endpoint for the same path w/o the body.
+func (suite *GatewaySuite) TestHead() {
+	gateway := suite.newGateway()
+	for _, method := range []string{"GET", "POST"} {
+		gateway.Register(rpc.Endpoint{
+			Method:      method,
+			Path:        "/user/:id",
+			ServiceName: "UserService",
+			Name:        method + "User",
+			Handler: func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("X-Endpoint", rpc.EndpointFromContext(req.Context()).String())
+				suite.respond(w, 200, "user "+rpc.PathParams(req)["id"])
+			},
+		})
+	}
+	gateway.Register(rpc.Endpoint{
+		Method:      "GET",
+		Path:        "/group/:id",
+		ServiceName: "UserService",
+		Name:        "GetGroup",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			suite.respond(w, 200, "group")
+		},
+	})
+	gateway.Register(rpc.Endpoint{
+		Method:      "HEAD",
+		Path:        "/group/:id",
+		ServiceName: "UserService",
+		Name:        "CheckGroup",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(204)
+		},
+	})
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	get, err := suite.HTTPClient.Get(server.URL + "/user/123")
+	suite.Require().NoError(err)
+	_ = get.Body.Close()
+
+	head, err := suite.HTTPClient.Head(server.URL + "/user/123")
+	suite.Require().NoError(err)
+	body, err := io.ReadAll(head.Body)
+	_ = head.Body.Close()
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, head.StatusCode)
+	suite.Require().Empty(body)
+	suite.Require().Equal("UserService.GETUser", head.Header.Get("X-Endpoint"))
+	suite.Require().Equal(get.Header.Get("Content-Length"), head.Header.Get("Content-Length"))
+	suite.Require().Equal(get.Header.Get("Content-Type"), head.Header.Get("Content-Type"))
+	suite.Require().Len(gateway.Endpoints(), 4, "Should not list derived HEAD endpoints")
+
+	status, _, err := suite.request(server, "HEAD", "/group/1", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(204, status, "Explicit HEAD endpoints should win")
+
+	suite.Require().True(gateway.Unregister("GET", "/user/:id"))
+	status, _, err = suite.request(server, "HEAD", "/user/123", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(404, status, "HEAD should go away w/ the GET endpoint")
+}
+
+// Ensures that the implicit OPTIONS routes use the gateway's custom handler when there is one.
+func (suite *GatewaySuite) TestWithOptionsHandler() {
+	newGateway := func(name string, options ...rpc.GatewayOption) rpc.Gateway {
+		gw := suite.newGateway(options...)
+		gw.Name = name
+		for _, method := range []string{"GET", "DELETE"} {
+			gw.Register(rpc.Endpoint{
+				Method:      method,
+				Path:        "/" + name + "/:id",
+				ServiceName: name,
+				Name:        method,
+				Handler: func(w http.ResponseWriter, req *http.Request) {
+					suite.respond(w, 200, "nope")
+				},
+			})
+		}
+		return gw
+	}
+	preflight := rpc.WithOptionsHandler(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, DELETE")
+		suite.respond(w, 204, rpc.EndpointFromContext(req.Context()).ServiceName)
+	})
+
+	gateway := newGateway("A", preflight)
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	status, _, err := suite.request(server, "OPTIONS", "/A/1", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(204, status)
+
+	suite.Require().True(gateway.Unregister("GET", "/A/:id"))
+	status, _, err = suite.request(server, "OPTIONS", "/A/1", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(204, status, "Should keep the custom handler after unregistering one of the endpoints")
+
+	composite, err := rpc.Compose(newGateway("B", preflight), newGateway("C"))
+	suite.Require().NoError(err)
+	compositeServer := httptest.NewServer(composite)
+	defer compositeServer.Close()
+
+	status, _, err = suite.request(compositeServer, "OPTIONS", "/B/1", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(204, status, "Composite gateways should use each gateway's OPTIONS handler")
+
+	status, _, err = suite.request(compositeServer, "OPTIONS", "/C/1", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(405, status, "Composite gateways should use each gateway's OPTIONS handler")
+}
+
 // Ensures that you can fetch the current endpoint details from both middleware and your handler function.
 func (suite *GatewaySuite) TestEndpointFromContext() {
 	values := []string{"", "", ""}
@@ -206,7 +449,7 @@ func (suite *GatewaySuite) TestEndpointFromContext() {
 		next(w, req)
 	}
 
-	gateway := rpc.NewGateway(rpc.WithMiddleware(middlewareA, middlewareB))
+	gateway := suite.newGateway(rpc.WithMiddleware(middlewareA, middlewareB))
 	gateway.Register(rpc.Endpoint{
 		Method:      "GET",
 		Path:        "/foo",
@@ -237,7 +480,7 @@ func (suite *GatewaySuite) TestEndpointFromContext() {
 // Ensures that the HTTP Authorization header passed into the handler via the Context so that your service
 // function has access to it as well.
 func (suite *GatewaySuite) TestAuthorizationOnContext() {
-	gateway := rpc.NewGateway()
+	gateway := suite.newGateway()
 	gateway.Register(rpc.Endpoint{
 		Method:      "GET",
 		Path:        "/foo",
@@ -271,7 +514,7 @@ func (suite *GatewaySuite) TestAuthorizationOnContext() {
 
 // Ensure that we respond w/ a 500 if your handler panics rather than crashing the server
 func (suite *GatewaySuite) TestRecoverFromPanic_handler() {
-	gateway := rpc.NewGateway()
+	gateway := suite.newGateway()
 	gateway.Register(rpc.Endpoint{
 		Method: "GET",
 		Path:   "/foo",
@@ -290,7 +533,7 @@ func (suite *GatewaySuite) TestRecoverFromPanic_handler() {
 
 // Ensure that we respond w/ a 500 if your middleware panics rather than crashing the server
 func (suite *GatewaySuite) TestRecoverFromPanic_middleware() {
-	gateway := rpc.NewGateway(rpc.WithMiddleware(func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	gateway := suite.newGateway(rpc.WithMiddleware(func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
 		panic("nope")
 	}))
 	gateway.Register(rpc.Endpoint{
@@ -309,9 +552,322 @@ func (suite *GatewaySuite) TestRecoverFromPanic_middleware() {
 	suite.Require().Equal(500, status, "Should recover w/ 500 on middleware panic")
 }
 
+// Ensure that the panic handlers see the panics from both middleware and handlers along w/ the stack
+// trace of the code that panicked.
+func (suite *GatewaySuite) TestRecoverFromPanic_handlers() {
+	var recovered []interface{}
+	var stacks []string
+	onPanic := func(ctx context.Context, value interface{}, stack []byte) {
+		recovered = append(recovered, value)
+		stacks = append(stacks, string(stack))
+	}
+	panicky := func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		if req.URL.Query().Get("fail") == "middleware" {
+			panic("middleware nope")
+		}
+		next(w, req)
+	}
+	gateway := suite.newGateway(
+		rpc.WithPanicHandler(onPanic),
+		rpc.WithMiddleware(panicky),
+		rpc.WithNotFoundMiddleware(func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+			panic("not found nope")
+		}),
+	)
+	gateway.Register(rpc.Endpoint{
+		Method: "GET",
+		Path:   "/foo",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			panic(fmt.Errorf("handler nope"))
+		},
+	})
+
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	status, body, err := suite.request(server, "GET", "/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(500, status)
+	suite.Require().Contains(body, "handler nope")
+	suite.Require().NotContains(body, "stack", "Should not include stack traces unless you ask for them")
+
+	status, _, err = suite.request(server, "GET", "/foo?fail=middleware", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(500, status)
+
+	status, _, err = suite.request(server, "GET", "/nope", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(500, status, "Should recover from panics in not found middleware")
+
+	suite.Require().Equal([]interface{}{fmt.Errorf("handler nope"), "middleware nope", "not found nope"}, recovered)
+	for _, stack := range stacks {
+		suite.Require().Contains(stack, "rpc_test.(*GatewaySuite).TestRecoverFromPanic_handlers", "Should include the code that panicked")
+	}
+}
+
+// Ensure that we only include the stack trace in the error when the gateway asks for it.
+func (suite *GatewaySuite) TestRecoverFromPanic_stackTraces() {
+	gateway := suite.newGateway(
+		rpc.WithPanicStackTraces(),
+		rpc.WithPanicHandler(func(ctx context.Context, recovered interface{}, stack []byte) {}),
+	)
+	gateway.Register(rpc.Endpoint{
+		Method: "GET",
+		Path:   "/foo",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			panic("nope")
+		},
+	})
+
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	status, body, err := suite.request(server, "GET", "/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(500, status)
+	suite.Require().Contains(body, `"stack":"goroutine `)
+	suite.Require().Contains(body, "TestRecoverFromPanic_stackTraces")
+}
+
+// Ensure that rpc.Fail() uses the simple {"status":..., "message":...} format by default.
+func (suite *GatewaySuite) TestFail_defaultFormat() {
+	gateway := suite.newGateway()
+	gateway.Register(rpc.Endpoint{
+		Method: "GET",
+		Path:   "/foo",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			rpc.Fail(w, req, errors.NotFound("no foo for you"))
+		},
+	})
+
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	status, body, err := suite.request(server, "GET", "/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(404, status)
+	suite.Require().JSONEq(`{"status":404, "message":"no foo for you"}`, body)
+}
+
+// Ensure that rpc.Fail() includes error codes/details in the response when the error has them.
+func (suite *GatewaySuite) TestFail_codeAndDetails() {
+	err := errors.WithCode(errors.BadRequest("order expired"), "ORDER_EXPIRED")
+	err = errors.WithDetails(err, map[string]interface{}{"retryAfter": 5})
+
+	for format, expected := range map[rpc.ErrorFormat]string{
+		rpc.DefaultJSON: `{"status":400, "message":"order expired", "code":"ORDER_EXPIRED", "details":{"retryAfter":5}}`,
+		rpc.ProblemJSON: `{"type":"about:blank", "title":"Bad Request", "status":400, "detail":"order expired", "instance":"/foo", "code":"ORDER_EXPIRED", "details":{"retryAfter":5}}`,
+	} {
+		gateway := suite.newGateway(rpc.WithErrorFormat(format))
+		gateway.Register(rpc.Endpoint{
+			Method: "GET",
+			Path:   "/foo",
+			Handler: func(w http.ResponseWriter, req *http.Request) {
+				rpc.Fail(w, req, err)
+			},
+		})
+
+		server := httptest.NewServer(gateway)
+		status, body, reqErr := suite.request(server, "GET", "/foo", "")
+		server.Close()
+		suite.Require().NoError(reqErr)
+		suite.Require().Equal(400, status)
+		suite.Require().JSONEq(expected, body)
+	}
+}
+
+// Ensure that rpc.Fail() attaches codes to errors that match the gateway's error registry.
+func (suite *GatewaySuite) TestFail_errorRegistry() {
+	errExpired := errors.BadRequest("order expired")
+	registry := errors.NewRegistry().Register("ORDER_EXPIRED", errExpired)
+
+	gateway := suite.newGateway(rpc.WithErrorRegistry(registry))
+	gateway.Register(rpc.Endpoint{
+		Method: "GET",
+		Path:   "/foo",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			rpc.Fail(w, req, fmt.Errorf("checkout: %w", errExpired))
+		},
+	})
+	gateway.Register(rpc.Endpoint{
+		Method: "GET",
+		Path:   "/bar",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			rpc.Fail(w, req, errors.BadRequest("nope"))
+		},
+	})
+
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	status, body, err := suite.request(server, "GET", "/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(400, status)
+	suite.Require().JSONEq(`{"status":400, "message":"checkout: order expired", "code":"ORDER_EXPIRED"}`, body)
+
+	status, body, err = suite.request(server, "GET", "/bar", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(400, status)
+	suite.Require().JSONEq(`{"status":400, "message":"nope"}`, body)
+}
+
+// Ensure that rpc.Fail() includes the operation id in the error body so callers can correlate failures w/ logs.
+func (suite *GatewaySuite) TestFail_operationID() {
+	generator := func() string { return "op-123" }
+	gateway := suite.newGateway(rpc.WithMiddleware(operation.Middleware(generator)))
+	gateway.Register(rpc.Endpoint{
+		Method: "GET",
+		Path:   "/foo",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			rpc.Fail(w, req, errors.NotFound("no foo for you"))
+		},
+	})
+	gateway.Register(rpc.Endpoint{
+		Method: "GET",
+		Path:   "/panic",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			panic("nope")
+		},
+	})
+
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	status, body, err := suite.request(server, "GET", "/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(404, status)
+	suite.Require().JSONEq(`{"status":404, "message":"no foo for you", "operationId":"op-123"}`, body)
+
+	status, body, err = suite.request(server, "GET", "/panic", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(500, status)
+	suite.Require().JSONEq(`{"status":500, "message":"nope", "operationId":"op-123"}`, body)
+}
+
+// Ensure that rpc.Fail() writes RFC 7807 documents when the gateway opts in to the ProblemJSON format.
+func (suite *GatewaySuite) TestFail_problemJSON() {
+	gateway := suite.newGateway(rpc.WithErrorFormat(rpc.ProblemJSON))
+	gateway.Register(rpc.Endpoint{
+		Method: "GET",
+		Path:   "/foo",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			rpc.Fail(w, req, errors.NotFound("no foo for you"))
+		},
+	})
+
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	status, body, err := suite.request(server, "GET", "/foo", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(404, status)
+	suite.Require().JSONEq(`{"type":"about:blank", "title":"Not Found", "status":404, "detail":"no foo for you", "instance":"/foo"}`, body)
+
+	res, err := suite.HTTPClient.Get(server.URL + "/foo")
+	suite.Require().NoError(err)
+	defer res.Body.Close()
+	suite.Require().Equal(rpc.ProblemJSONContentType, res.Header.Get("Content-Type"))
+
+	// Built-in failures such as panics should use the same format.
+	gateway.Register(rpc.Endpoint{
+		Method: "GET",
+		Path:   "/panic",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			panic("nope")
+		},
+	})
+	status, body, err = suite.request(server, "GET", "/panic", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(500, status)
+	suite.Require().Contains(body, `"title":"Internal Server Error"`)
+}
+
+// Ensure that rpc.Reply() writes the plain JSON response by default.
+func (suite *GatewaySuite) TestReply() {
+	gateway := suite.newGateway()
+	gateway.Register(rpc.Endpoint{
+		Method: "GET",
+		Path:   "/foo",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			rpc.Reply(w, req, 202, map[string]string{"Name": "Dude"})
+		},
+	})
+
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	res, err := suite.HTTPClient.Get(server.URL + "/foo")
+	suite.Require().NoError(err)
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	suite.Require().Equal(202, res.StatusCode)
+	suite.Require().JSONEq(`{"Name":"Dude"}`, string(body))
+	suite.Require().Equal("", res.Header.Get(rpc.EnvelopeHeader))
+}
+
+// Ensure that rpc.Reply() and rpc.Fail() wrap JSON responses in an envelope when the gateway opts in.
+func (suite *GatewaySuite) TestReply_envelope() {
+	generator := func() string { return "op-123" }
+	gateway := suite.newGateway(
+		rpc.WithResponseEnvelope(),
+		rpc.WithMiddleware(operation.Middleware(generator)),
+	)
+	gateway.Register(rpc.Endpoint{
+		Method: "GET",
+		Path:   "/foo",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			rpc.Reply(w, req, 200, map[string]string{"Name": "Dude"})
+		},
+	})
+	gateway.Register(rpc.Endpoint{
+		Method: "GET",
+		Path:   "/fail",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			rpc.Fail(w, req, errors.NotFound("no foo for you"))
+		},
+	})
+
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	res, err := suite.HTTPClient.Get(server.URL + "/foo")
+	suite.Require().NoError(err)
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	suite.Require().Equal(200, res.StatusCode)
+	suite.Require().JSONEq(`{"data":{"Name":"Dude"}, "error":null, "meta":{"requestId":"op-123", "durationMs":0}}`, string(body))
+	suite.Require().Equal("true", res.Header.Get(rpc.EnvelopeHeader))
+	suite.Require().Equal(rpc.EnvelopeHeader, res.Header.Get("Access-Control-Expose-Headers"))
+
+	status, body2, err := suite.request(server, "GET", "/fail", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(404, status)
+	suite.Require().JSONEq(`{"data":null, "error":{"status":404, "message":"no foo for you", "operationId":"op-123"}, "meta":{"requestId":"op-123", "durationMs":0}}`, body2)
+
+	gateway = suite.newGateway(rpc.WithResponseEnvelope(), rpc.WithErrorFormat(rpc.ProblemJSON))
+	gateway.Register(rpc.Endpoint{
+		Method: "GET",
+		Path:   "/fail",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			rpc.Fail(w, req, errors.NotFound("no foo for you"))
+		},
+	})
+	problemServer := httptest.NewServer(gateway)
+	defer problemServer.Close()
+
+	res, err = suite.HTTPClient.Get(problemServer.URL + "/fail")
+	suite.Require().NoError(err)
+	defer res.Body.Close()
+	body, _ = io.ReadAll(res.Body)
+	suite.Require().Equal(404, res.StatusCode)
+	suite.Require().Equal("application/json", res.Header.Get("Content-Type"))
+	suite.Require().Equal("true", res.Header.Get(rpc.EnvelopeHeader))
+	suite.Require().JSONEq(`{"data":null, "error":{"type":"about:blank", "title":"Not Found", "status":404, "detail":"no foo for you", "instance":"/fail"}, "meta":{"durationMs":0}}`, string(body))
+}
+
 // Ensure that all endpoints use the path prefix on all endpoints.
 func (suite *GatewaySuite) TestGatewayPathPrefix() {
-	gateway := rpc.NewGateway()
+	gateway := suite.newGateway()
 	gateway.PathPrefix = "v2"
 	gateway.Register(rpc.Endpoint{
 		Method: "GET",
@@ -347,10 +903,77 @@ func (suite *GatewaySuite) TestGatewayPathPrefix() {
 	suite.Require().Equal(404, status, "Did not respond properly when path prefix is configured")
 }
 
+// Ensure that path params that don't match their constraints are rejected before we invoke the handler.
+func (suite *GatewaySuite) TestPathConstraints() {
+	calls := 0
+	gateway := suite.newGateway()
+	gateway.Register(rpc.Endpoint{
+		Method:          "GET",
+		Path:            "/user/:id/order/:num/:name",
+		PathConstraints: map[string]string{"id": "uuid", "num": "int"},
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			calls++
+			suite.respond(w, 200, "ok")
+		},
+	})
+
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	status, result, err := suite.request(server, "GET", "/user/3fa85f64-5717-4562-b3fc-2c963f66afa6/order/42/anything", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status, "Should accept params that match their constraints")
+	suite.Require().Equal("ok", result)
+
+	status, result, err = suite.request(server, "GET", "/user/123/order/42/anything", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(400, status, "Should reject params that aren't a valid uuid")
+	suite.Require().Contains(result, "invalid path parameter 'id': '123' is not a valid uuid")
+
+	status, result, err = suite.request(server, "GET", "/user/3fa85f64-5717-4562-b3fc-2c963f66afa6/order/4x2/anything", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(400, status, "Should reject params that aren't a valid int")
+	suite.Require().Contains(result, "invalid path parameter 'num': '4x2' is not a valid int")
+	suite.Require().Equal(1, calls, "Should not invoke the handler when params are invalid")
+}
+
+// Ensure that catch-all params (e.g. "*path") bind the rest of the path, slashes and all.
+func (suite *GatewaySuite) TestCatchAllParams() {
+	gateway := suite.newGateway()
+	gateway.Register(rpc.Endpoint{
+		Method: "GET",
+		Path:   "/files/:bucket/*path",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			serviceRequest := struct {
+				Bucket string
+				Path   string
+			}{}
+			if err := gateway.Binder.Bind(req, &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			suite.respond(w, 200, serviceRequest.Bucket+":"+serviceRequest.Path)
+		},
+	})
+
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	status, result, err := suite.request(server, "GET", "/files/docs/a/b/my%20notes.txt", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status)
+	suite.Require().Equal("docs:a/b/my notes.txt", result)
+
+	status, result, err = suite.request(server, "GET", "/files/docs/readme.txt", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status)
+	suite.Require().Equal("docs:readme.txt", result)
+}
+
 // Ensure that metadata passed in via the X-RPC-Values header are properly added to the context.
 func (suite *GatewaySuite) TestRestoreMetadata() {
 	values := []string{"", ""}
-	gateway := rpc.NewGateway(rpc.WithMiddleware(func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	gateway := suite.newGateway(rpc.WithMiddleware(func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
 		metaString := ""
 		metaBool := false
 		metadata.Value(req.Context(), "metaString", &metaString)
@@ -395,6 +1018,76 @@ func (suite *GatewaySuite) TestRestoreMetadata() {
 	suite.Require().Equal(400, status, "Should respond with BadRequest when metadata header is ill-formed")
 }
 
+// Ensure that the server's metadata values win over the caller's when they use the same keys.
+func (suite *GatewaySuite) TestRestoreMetadata_precedence() {
+	result := ""
+	gateway := suite.newGateway(rpc.WithMiddleware(func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		next(w, req.WithContext(metadata.WithValue(req.Context(), "middleware", "server")))
+	}))
+	gateway.Register(rpc.Endpoint{
+		Method: "GET",
+		Path:   "/foo",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			caller, wrapper, middleware := "", "", ""
+			metadata.Value(req.Context(), "caller", &caller)
+			metadata.Value(req.Context(), "wrapper", &wrapper)
+			metadata.Value(req.Context(), "middleware", &middleware)
+			result = caller + "." + wrapper + "." + middleware
+			suite.respond(w, 200, "ok")
+		},
+	})
+
+	// Something outside of the gateway that already put values on the context.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gateway.ServeHTTP(w, req.WithContext(metadata.WithValue(req.Context(), "wrapper", "server")))
+	}))
+	defer server.Close()
+
+	status, _, err := suite.request(server, "GET", "/foo", "", func(request *http.Request) {
+		request.Header.Set(metadata.RequestHeader, `{"caller":{"value":"caller"}, "wrapper":{"value":"caller"}, "middleware":{"value":"caller"}}`)
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status)
+	suite.Require().Equal("caller.server.server", result)
+
+	status, _, err = suite.request(server, "GET", "/foo", "", func(request *http.Request) {
+		request.Header.Set(metadata.RequestHeader, `null`)
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status, "Should treat a null header like an empty one")
+	suite.Require().Equal(".server.server", result)
+}
+
+// Ensure that W3C baggage entries become metadata, but the caller's X-RPC-Values beat them for the same key.
+func (suite *GatewaySuite) TestRestoreMetadata_baggage() {
+	result := ""
+	gateway := suite.newGateway()
+	gateway.Register(rpc.Endpoint{
+		Method: "GET",
+		Path:   "/foo",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			userID, tenant, region := "", "", ""
+			metadata.Value(req.Context(), "userId", &userID)
+			metadata.Value(req.Context(), "tenant", &tenant)
+			metadata.Value(req.Context(), "region", &region)
+			result = userID + "." + tenant + "." + region
+			suite.respond(w, 200, "ok")
+		},
+	})
+
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	status, _, err := suite.request(server, "GET", "/foo", "", func(request *http.Request) {
+		request.Header.Add(metadata.BaggageHeader, "userId=alice;sampled, tenant=baggage")
+		request.Header.Add(metadata.BaggageHeader, "region=us%20east")
+		request.Header.Set(metadata.RequestHeader, `{"tenant":{"value":"rpc"}}`)
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status)
+	suite.Require().Equal("alice.rpc.us east", result)
+}
+
 // Ensure that EndpointFromContext returns nil when it hasn't been applied to the context yet.
 func (suite *GatewaySuite) TestEndpointFromContext_missing() {
 	endpoint := rpc.EndpointFromContext(nil)
@@ -406,7 +1099,7 @@ func (suite *GatewaySuite) TestEndpointFromContext_missing() {
 
 // Ensure that composing multiple services gateways into one ensures that all operations from all N still work.
 func (suite *GatewaySuite) TestCompose() {
-	serviceA := rpc.NewGateway()
+	serviceA := suite.newGateway()
 	serviceA.Name = "A"
 	serviceA.PathPrefix = "/v2"
 	serviceA.Register(rpc.Endpoint{
@@ -428,7 +1121,7 @@ func (suite *GatewaySuite) TestCompose() {
 		},
 	})
 
-	serviceB := rpc.NewGateway()
+	serviceB := suite.newGateway()
 	serviceB.Name = "B"
 	serviceB.PathPrefix = "/v3"
 	serviceB.Register(rpc.Endpoint{
@@ -450,7 +1143,8 @@ func (suite *GatewaySuite) TestCompose() {
 		},
 	})
 
-	gateway := rpc.Compose(serviceA, serviceB)
+	gateway, err := rpc.Compose(serviceA, serviceB)
+	suite.Require().NoError(err)
 	server := httptest.NewServer(gateway)
 	defer server.Close()
 
@@ -492,40 +1186,297 @@ func (suite *GatewaySuite) TestCompose() {
 	suite.Require().Equal(404, status, "ServiceB.Hello should not have a v2/ prefix")
 }
 
-// Ensures that creating a composite gateway with conflicting routes fails miserably.
-func (suite *GatewaySuite) TestCompose_conflict() {
-	serviceA := rpc.NewGateway()
+// Ensures that endpoints in a composite gateway still run the middleware from their original gateway.
+func (suite *GatewaySuite) TestCompose_middleware() {
+	tag := func(value string) rpc.MiddlewareFunc {
+		return func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+			w.Header().Set("X-Gateway", value)
+			next(w, req)
+		}
+	}
+
+	serviceA := suite.newGateway(rpc.WithMiddleware(tag("A")))
 	serviceA.Name = "A"
 	serviceA.Register(rpc.Endpoint{
 		Method:      "POST",
-		Path:        "/foo/:bar",
+		Path:        "A.Hello",
 		ServiceName: "A",
 		Name:        "Hello",
 		Handler: func(w http.ResponseWriter, req *http.Request) {
-			suite.respond(w, 200, "hello post a")
+			suite.respond(w, 200, w.Header().Get("X-Gateway")+" "+rpc.EndpointFromContext(req.Context()).String())
 		},
 	})
 
-	serviceB := rpc.NewGateway()
+	serviceB := suite.newGateway(rpc.WithMiddleware(tag("B")))
 	serviceB.Name = "B"
 	serviceB.Register(rpc.Endpoint{
 		Method:      "POST",
-		Path:        "/foo/:bar",
+		Path:        "B.Hello",
 		ServiceName: "B",
 		Name:        "Hello",
 		Handler: func(w http.ResponseWriter, req *http.Request) {
-			suite.respond(w, 200, "hello post b")
+			suite.respond(w, 200, w.Header().Get("X-Gateway")+" "+rpc.EndpointFromContext(req.Context()).String())
 		},
 	})
 
-	suite.Panics(func() { rpc.Compose(serviceA, serviceB) }, "Compose should panic if multiple routes conflict")
+	gateway, err := rpc.Compose(serviceA, serviceB)
+	suite.Require().NoError(err)
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	status, result, err := suite.request(server, "POST", "/A.Hello", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status)
+	suite.Require().Equal("A A.Hello", result, "Composite should run gateway A's middleware")
+
+	status, result, err = suite.request(server, "POST", "/B.Hello", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status)
+	suite.Require().Equal("B B.Hello", result, "Composite should run gateway B's middleware")
+}
+
+// Ensures that middleware groups run in priority order and that endpoints can skip named groups, even when the
+// gateway is composed w/ others.
+func (suite *GatewaySuite) TestWithMiddlewareGroup() {
+	tag := func(value string) rpc.MiddlewareFunc {
+		return func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+			w.Header().Add("X-Trace", value)
+			next(w, req)
+		}
+	}
+
+	gw := suite.newGateway(
+		rpc.WithMiddlewareGroup("metrics", -10, tag("metrics")),
+		rpc.WithMiddleware(tag("plain1"), tag("plain2")),
+		rpc.WithMiddlewareGroup("auth", 10, tag("auth1"), tag("auth2")),
+		rpc.WithMiddlewareGroup("logging", 0, tag("old logging")),
+		rpc.WithMiddlewareGroup("logging", 0, tag("logging")),
+	)
+	gw.Name = "A"
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		suite.respond(w, 200, strings.Join(w.Header().Values("X-Trace"), ","))
+	}
+	gw.Register(rpc.Endpoint{Method: "GET", Path: "/Everything", ServiceName: "A", Name: "Everything", Handler: handler})
+	gw.Register(rpc.Endpoint{Method: "GET", Path: "/Health", ServiceName: "A", Name: "Health", Handler: handler,
+		SkipMiddleware: []string{"auth", "metrics", "nope"},
+	})
+
+	composite, err := rpc.Compose(gw)
+	suite.Require().NoError(err)
+	for _, server := range []*httptest.Server{httptest.NewServer(gw), httptest.NewServer(composite)} {
+		_, result, err := suite.request(server, "GET", "/Everything", "")
+		suite.Require().NoError(err)
+		suite.Require().Equal("auth1,auth2,plain1,plain2,logging,metrics", result)
+
+		_, result, err = suite.request(server, "GET", "/Health", "")
+		suite.Require().NoError(err)
+		suite.Require().Equal("plain1,plain2,logging", result)
+		server.Close()
+	}
+}
+
+// Ensures that creating a composite gateway with conflicting routes fails w/ an error that describes every conflict.
+func (suite *GatewaySuite) TestCompose_conflict() {
+	newGateway := func(name string, paths ...string) rpc.Gateway {
+		gw := suite.newGateway()
+		gw.Name = name
+		for _, path := range paths {
+			gw.Register(rpc.Endpoint{
+				Method:      "POST",
+				Path:        path,
+				ServiceName: name,
+				Name:        "Hello",
+				Handler: func(w http.ResponseWriter, req *http.Request) {
+					suite.respond(w, 200, "hello post "+name)
+				},
+			})
+		}
+		return gw
+	}
+
+	_, err := rpc.Compose(
+		newGateway("A", "/foo/:bar", "/hello", "/a"),
+		newGateway("B", "/foo/:goo", "/hello", "/b"),
+	)
+	suite.Require().Error(err, "Compose should fail if multiple routes conflict")
+	suite.Require().Contains(err.Error(), "2 conflicting routes")
+	suite.Require().Contains(err.Error(), "POST /foo/:goo (B.Hello) conflicts w/ POST /foo/:bar (A.Hello)")
+	suite.Require().Contains(err.Error(), "POST /hello (B.Hello) conflicts w/ POST /hello (A.Hello)")
+
+	_, err = rpc.Compose(
+		newGateway("A", "/foo/:bar", "/hello"),
+		rpc.Mount("/b", newGateway("B", "/foo/:goo", "/hello")),
+	)
+	suite.Require().NoError(err, "Mounting a gateway under a prefix should resolve the conflicts")
+}
+
+// Ensures that Mount() places a gateway's routes under an additional prefix when it's composed and that the
+// endpoints still run the gateway's own middleware.
+func (suite *GatewaySuite) TestCompose_mount() {
+	newGateway := func(name string, prefix string) rpc.Gateway {
+		gw := suite.newGateway(rpc.WithMiddleware(func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+			w.Header().Set("X-Gateway", name)
+			next(w, req)
+		}))
+		gw.Name = name
+		gw.PathPrefix = prefix
+		gw.Register(rpc.Endpoint{
+			Method:      "GET",
+			Path:        "/user/:id",
+			ServiceName: name,
+			Name:        "GetUser",
+			Handler: func(w http.ResponseWriter, req *http.Request) {
+				id := rpc.PathParams(req)["id"]
+				suite.respond(w, 200, w.Header().Get("X-Gateway")+" "+rpc.EndpointFromContext(req.Context()).String()+" "+id)
+			},
+		})
+		return gw
+	}
+
+	gateway, err := rpc.Compose(
+		newGateway("A", ""),
+		rpc.Mount("/legacy", newGateway("B", "")),
+		rpc.Mount("/", rpc.Mount("/old", newGateway("C", "/api"))),
+	)
+	suite.Require().NoError(err)
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	status, result, err := suite.request(server, "GET", "/user/1", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status)
+	suite.Require().Equal("A A.GetUser 1", result)
+
+	status, result, err = suite.request(server, "GET", "/legacy/user/2", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status)
+	suite.Require().Equal("B B.GetUser 2", result)
+
+	status, result, err = suite.request(server, "GET", "/old/api/user/3", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, status)
+	suite.Require().Equal("C C.GetUser 3", result)
+
+	status, _, err = suite.request(server, "GET", "/api/user/3", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal(404, status, "Mounted gateways should not be available w/o the mount prefix")
+}
+
+// Ensures that requests that don't match any route in a composite gateway still run the not-found
+// middleware of the gateway whose prefix matches the path.
+func (suite *GatewaySuite) TestCompose_notFound() {
+	newGateway := func(name string) rpc.Gateway {
+		gw := suite.newGateway(rpc.WithNotFoundMiddleware(func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+			w.Header().Set("X-Gateway", name)
+			next(w, req)
+		}))
+		gw.Name = name
+		gw.Register(rpc.Endpoint{
+			Method:      "POST",
+			Path:        name + ".Hello",
+			ServiceName: name,
+			Name:        "Hello",
+			Handler: func(w http.ResponseWriter, req *http.Request) {
+				suite.respond(w, 200, "hello "+name)
+			},
+		})
+		return gw
+	}
+
+	gateway, err := rpc.Compose(newGateway("A"), rpc.Mount("/b", newGateway("B")))
+	suite.Require().NoError(err)
+
+	send := func(method string, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w := send("GET", "/nope")
+	suite.Require().Equal(404, w.Code)
+	suite.Require().Equal("A", w.Header().Get("X-Gateway"))
+
+	w = send("GET", "/b/nope")
+	suite.Require().Equal(404, w.Code)
+	suite.Require().Equal("B", w.Header().Get("X-Gateway"))
+
+	w = send("DELETE", "/b/B.Hello")
+	suite.Require().Equal(405, w.Code)
+	suite.Require().Equal("B", w.Header().Get("X-Gateway"))
+	suite.Require().Contains(w.Header().Values("Allow"), "POST")
+}
+
+// Ensures that ComposeVersions() mounts each gateway under its version and negotiates the version for
+// requests that don't include one in the path.
+func (suite *GatewaySuite) TestComposeVersions() {
+	newGateway := func(version string, functions ...string) rpc.Gateway {
+		gw := suite.newGateway()
+		gw.Name = "A"
+		gw.PathPrefix = "api"
+		for _, function := range functions {
+			gw.Register(rpc.Endpoint{
+				Method:      "GET",
+				Path:        "A." + function,
+				ServiceName: "A",
+				Name:        function,
+				Handler: func(w http.ResponseWriter, req *http.Request) {
+					endpoint := rpc.EndpointFromContext(req.Context())
+					suite.respond(w, 200, version+" "+endpoint.String()+" "+endpoint.Version)
+				},
+			})
+		}
+		return gw
+	}
+
+	gateway := rpc.ComposeVersions(map[string]rpc.Gateway{
+		"v10": newGateway("ten", "Hello"),
+		"v2":  newGateway("two", "Hello", "Goodbye"),
+		"v9":  newGateway("nine", "Hello"),
+	})
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	suite.Require().Equal("Composite:A@v2:A@v9:A@v10", gateway.Name)
+	suite.Require().Len(gateway.Gateways, 3)
+
+	assertResponse := func(path string, version string, expectedStatus int, expected string) {
+		status, result, err := suite.request(server, "GET", path, "", func(request *http.Request) {
+			if version != "" {
+				request.Header.Set(rpc.VersionHeader, version)
+			}
+		})
+		suite.Require().NoError(err)
+		suite.Require().Equal(expectedStatus, status, "%s (%s)", path, version)
+		if expected != "" {
+			suite.Require().Equal(expected, result, "%s (%s)", path, version)
+		}
+	}
+
+	assertResponse("/v2/api/A.Hello", "", 200, "two A.Hello v2")
+	assertResponse("/v9/api/A.Hello", "", 200, "nine A.Hello v9")
+	assertResponse("/v10/api/A.Hello", "v2", 200, "ten A.Hello v10")
+	assertResponse("/v2/api/A.Goodbye", "", 200, "two A.Goodbye v2")
+	assertResponse("/v9/api/A.Goodbye", "", 404, "")
+	assertResponse("/v3/api/A.Hello", "", 404, "")
+
+	// No version in the path, so use the header or the latest version.
+	assertResponse("/api/A.Hello", "", 200, "ten A.Hello v10")
+	assertResponse("/api/A.Hello", "v9", 200, "nine A.Hello v9")
+	assertResponse("/api/A.Goodbye", "v2", 200, "two A.Goodbye v2")
+	assertResponse("/api/A.Goodbye", "", 404, "")
+	assertResponse("/api/A.Hello", "v3", 400, "")
+
+	res, err := suite.HTTPClient.Get(server.URL + "/api/A.Hello")
+	suite.Require().NoError(err)
+	defer res.Body.Close()
+	suite.Require().Equal("v10", res.Header.Get(rpc.ContentVersionHeader))
 }
 
 // Ensures that we handle missing routes by writing a 404 status and method not allowed
 // with a 405 if you don't supply a custom handler.
 func (suite *GatewaySuite) TestWithNotFoundHandler_default() {
 	assert := suite.Require()
-	gateway := rpc.NewGateway()
+	gateway := suite.newGateway()
 	gateway.Register(rpc.Endpoint{
 		Method:      "GET",
 		Path:        "/foo",
@@ -558,7 +1509,7 @@ func (suite *GatewaySuite) TestWithNotFoundHandler_basic() {
 		sequence.Append("A2")
 	}
 
-	gateway := rpc.NewGateway(rpc.WithNotFoundMiddleware(notFound))
+	gateway := suite.newGateway(rpc.WithNotFoundMiddleware(notFound))
 	gateway.Register(rpc.Endpoint{
 		Method:      "GET",
 		Path:        "/foo",
@@ -605,7 +1556,7 @@ func (suite *GatewaySuite) TestWithNotFoundHandler_chainedMiddleware() {
 		sequence.Append("C2")
 	}
 
-	gateway := rpc.NewGateway(rpc.WithNotFoundMiddleware(
+	gateway := suite.newGateway(rpc.WithNotFoundMiddleware(
 		middlewareA,
 		middlewareB,
 		middlewareC,
@@ -650,7 +1601,7 @@ func (suite *GatewaySuite) TestWithNotFoundHandler_shortCircuit() {
 		w.Write([]byte(`{"Foo":"Bar"}`))
 	}
 
-	gateway := rpc.NewGateway(rpc.WithNotFoundMiddleware(
+	gateway := suite.newGateway(rpc.WithNotFoundMiddleware(
 		middlewareA,
 		middlewareB,
 	))
@@ -698,11 +1649,175 @@ func (suite *GatewaySuite) request(server *httptest.Server, method string, path
 	return res.StatusCode, string(result), err
 }
 
+// newGateway creates a gateway just like NewGateway(), except that it uses the suite's router (if any).
+func (suite *GatewaySuite) newGateway(options ...rpc.GatewayOption) rpc.Gateway {
+	if suite.newRouter != nil {
+		options = append(options, rpc.WithRouter(suite.newRouter()))
+	}
+	return rpc.NewGateway(options...)
+}
+
 func (suite *GatewaySuite) respond(w http.ResponseWriter, status int, body string) {
 	w.WriteHeader(status)
 	_, _ = w.Write([]byte(body))
 }
 
+// exchange is one request that a test sends to its gateway and the response that it expects back.
+type exchange struct {
+	name   string
+	method string
+	path   string
+	body   string
+	header http.Header
+	status int
+	// response is the exact body that we expect back (not checked when empty).
+	response string
+	// json is the body that we expect back when it's JSON, so the formatting doesn't matter (not checked when empty).
+	json string
+	// noBody means that the response shouldn't have a body at all.
+	noBody bool
+	// responseHeader contains the response headers that we expect back. Other headers aren't checked, and
+	// empty values mean that the header shouldn't be there at all.
+	responseHeader http.Header
+}
+
+// assertExchanges sends each of the requests to the server (in order) and checks that it responds the way
+// that we expect.
+func (suite *GatewaySuite) assertExchanges(server *httptest.Server, exchanges []exchange) {
+	for _, test := range exchanges {
+		r := suite.Require()
+		request, err := http.NewRequest(test.method, server.URL+test.path, strings.NewReader(test.body))
+		r.NoError(err)
+		for key, values := range test.header {
+			request.Header[http.CanonicalHeaderKey(key)] = values
+		}
+
+		res, err := suite.HTTPClient.Do(request)
+		r.NoError(err, test.name)
+		body, err := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		r.NoError(err, test.name)
+		suite.assertExchange(test, res.StatusCode, res.Header, string(body))
+	}
+}
+
+// assertResponses is just like assertExchanges, but it calls the handler directly rather than going through a
+// server and HTTP client, so you see exactly what the handler wrote (e.g. redirects rather than their targets).
+func (suite *GatewaySuite) assertResponses(handler http.Handler, exchanges []exchange) {
+	for _, test := range exchanges {
+		request := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		for key, values := range test.header {
+			request.Header[http.CanonicalHeaderKey(key)] = values
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request)
+		suite.assertExchange(test, w.Code, w.Header(), w.Body.String())
+	}
+}
+
+func (suite *GatewaySuite) assertExchange(test exchange, status int, header http.Header, body string) {
+	r := suite.Require()
+	r.Equal(test.status, status, "%s: %s", test.name, body)
+	if test.response != "" {
+		r.Equal(test.response, body, test.name)
+	}
+	if test.json != "" {
+		r.JSONEq(test.json, body, test.name)
+	}
+	if test.noBody {
+		r.Empty(body, test.name)
+	}
+	for key, values := range test.responseHeader {
+		r.Equal(values, nonNil(header.Values(key)), "%s: %s header", test.name, key)
+	}
+}
+
+// nonNil returns an empty slice rather than nil so that we can compare missing headers to empty ones.
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// testEndpoint creates an endpoint for the "TestService" function w/ the given name, which runs the handler.
+func testEndpoint(method string, path string, name string, handler http.HandlerFunc) rpc.Endpoint {
+	return rpc.Endpoint{
+		Method:      method,
+		Path:        path,
+		ServiceName: "TestService",
+		Name:        name,
+		Handler:     handler,
+	}
+}
+
+// echoRequest is the request/response of the "Echo" function on the newEchoGateway() services.
+type echoRequest struct {
+	Text string
+}
+
+// newEchoGateway creates a gateway for the service whose "Echo" endpoint replies w/ the function's name and the text
+// it received, "Fail" always fails w/ a 403, and "Download" responds w/ raw content. Just like generated gateways,
+// it registers the batch and JSON-RPC endpoints if you enable them.
+func (suite *GatewaySuite) newEchoGateway(name string, options ...rpc.GatewayOption) rpc.Gateway {
+	gw := suite.newGateway(options...)
+	gw.Name = name
+	gw.Register(rpc.Endpoint{
+		Method:      "GET",
+		Path:        "/" + name + "/echo/:Text",
+		ServiceName: name,
+		Name:        "Echo",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			serviceRequest := echoRequest{}
+			if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Reply(w, req, 200, echoRequest{Text: rpc.EndpointFromContext(req.Context()).String() + ":" + serviceRequest.Text})
+		},
+	})
+	gw.Register(rpc.Endpoint{
+		Method:      "POST",
+		Path:        "/" + name + "/fail",
+		ServiceName: name,
+		Name:        "Fail",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			rpc.Fail(w, req, errors.PermissionDenied("nope"))
+		},
+	})
+	gw.Register(rpc.Endpoint{
+		Method:      "POST",
+		Path:        "/" + name + "/download",
+		ServiceName: name,
+		Name:        "Download",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("hello"))
+		},
+	})
+	if gw.BatchConcurrency > 0 {
+		gw.Register(gw.BatchEndpoint())
+	}
+	if gw.JSONRPCConcurrency > 0 {
+		gw.Register(gw.JSONRPCEndpoint())
+	}
+	return gw
+}
+
+// echo creates a handler for a service function that binds the request into a new value from 'newRequest' and
+// responds w/ it, so tests can see exactly what the gateway bound.
+func echo(gateway *rpc.Gateway, newRequest func() interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		serviceRequest := newRequest()
+		if err := gateway.Binder.Bind(req, serviceRequest); err != nil {
+			rpc.Fail(w, req, err)
+			return
+		}
+		rpc.Reply(w, req, 200, serviceRequest)
+	}
+}
+
 func TestGatewaySuite(t *testing.T) {
 	suite.Run(t, new(GatewaySuite))
 }
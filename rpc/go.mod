@@ -0,0 +1,11 @@
+module github.com/monadicstack/frodo/rpc
+
+go 1.16
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/dimfeld/httptreemux/v5 v5.2.2
+	github.com/monadicstack/respond v0.4.2
+	github.com/stretchr/testify v1.6.1
+	github.com/urfave/negroni v1.0.0
+)
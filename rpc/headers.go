@@ -0,0 +1,67 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+)
+
+// HeaderReader allows service responses to send custom HTTP headers (e.g. "X-Total-Count" or "X-RateLimit-Remaining")
+// along w/ the response. The gateway writes them before the body, so this works for JSON responses, raw content,
+// and redirects alike. Since the headers are usually derived from fields on the response, you'll likely want to
+// tag those fields w/ `json:"-"` so they aren't in the body, too:
+//
+//     type ListUsersResponse struct {
+//         Users      []User
+//         TotalCount int `json:"-"`
+//     }
+//
+//     func (res ListUsersResponse) Headers() http.Header {
+//         return http.Header{"X-Total-Count": []string{strconv.Itoa(res.TotalCount)}}
+//     }
+type HeaderReader interface {
+	// Headers returns the custom headers that the gateway should include in the HTTP response.
+	Headers() http.Header
+}
+
+// HeaderWriter allows service responses to capture the HTTP headers that the gateway responded with. This is
+// utilized by clients to automatically populate the value once the rest of the response has been decoded:
+//
+//     func (res *ListUsersResponse) SetHeaders(header http.Header) {
+//         res.TotalCount, _ = strconv.Atoi(header.Get("X-Total-Count"))
+//     }
+type HeaderWriter interface {
+	// SetHeaders applies the HTTP response headers to the response.
+	SetHeaders(header http.Header)
+}
+
+type contextKeyResponseHeaders struct{}
+
+// SetHeader adds a custom HTTP header to the gateway's response for the current call. It's an alternative to
+// implementing HeaderReader when the header doesn't belong on the response struct (e.g. some "Server-Timing"
+// info). Headers from HeaderReader win when both set the same one. When the function is not being invoked
+// through a gateway (e.g. you call the service directly), this does nothing.
+//
+// Headers must be set before the function returns, so they won't make it into the response for "ASYNC" and
+// "SSE" functions, which have already responded by the time they're done.
+func SetHeader(ctx context.Context, key string, value string) {
+	if header, ok := ctx.Value(contextKeyResponseHeaders{}).(http.Header); ok {
+		header.Set(key, value)
+	}
+}
+
+// restoreResponseHeaders gives the handler access to the response's headers so that SetHeader() works.
+func restoreResponseHeaders(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	ctx := context.WithValue(req.Context(), contextKeyResponseHeaders{}, w.Header())
+	next(w, req.WithContext(ctx))
+}
+
+// writeHeaders applies the custom headers from a HeaderReader response to the HTTP response.
+func writeHeaders(w http.ResponseWriter, serviceResponse interface{}) {
+	reader, ok := serviceResponse.(HeaderReader)
+	if !ok {
+		return
+	}
+	for key, values := range reader.Headers() {
+		w.Header()[http.CanonicalHeaderKey(key)] = values
+	}
+}
@@ -0,0 +1,63 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+// headersResponse sends its total count as the "X-Total-Count" header rather than in the body.
+type headersResponse struct {
+	Names      []string
+	TotalCount int `json:"-"`
+}
+
+func (res headersResponse) Headers() http.Header {
+	return http.Header{"x-total-count": []string{strconv.Itoa(res.TotalCount)}}
+}
+
+func (res *headersResponse) SetHeaders(header http.Header) {
+	res.TotalCount, _ = strconv.Atoi(header.Get("X-Total-Count"))
+}
+
+// Ensures that the gateway writes headers from both HeaderReader responses and SetHeader(), and that clients
+// populate HeaderWriter responses after decoding the body.
+func (suite *GatewaySuite) TestSetHeader() {
+	r := suite.Require()
+	gw := suite.newGateway()
+	gw.Register(testEndpoint("GET", "/names", "ListNames", func(w http.ResponseWriter, req *http.Request) {
+		rpc.SetHeader(req.Context(), "X-Request-Cost", "2")
+		rpc.SetHeader(req.Context(), "X-Total-Count", "0")
+		rpc.Reply(w, req, 200, headersResponse{Names: []string{"a", "b"}, TotalCount: 42})
+	}))
+	server := httptest.NewServer(gw)
+	defer server.Close()
+
+	// The HeaderReader should win over SetHeader().
+	suite.assertExchanges(server, []exchange{
+		{
+			name:           "gateway",
+			method:         "GET",
+			path:           "/names",
+			status:         200,
+			json:           `{"Names":["a","b"]}`,
+			responseHeader: http.Header{"X-Total-Count": {"42"}, "X-Request-Cost": {"2"}},
+		},
+	})
+
+	client := rpc.NewClient("TestService", server.URL)
+	response := headersResponse{}
+	err := client.Invoke(context.Background(), "GET", "/names", nil, &response)
+	r.NoError(err)
+	r.Equal([]string{"a", "b"}, response.Names)
+	r.Equal(42, response.TotalCount)
+
+	suite.NotPanics(func() {
+		rpc.SetHeader(context.Background(), "X-Total-Count", "42")
+	}, "Should do nothing outside of a gateway")
+}
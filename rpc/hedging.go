@@ -0,0 +1,96 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// WithHedging makes the client "hedge" its bets on idempotent (GET/HEAD) calls. If the service hasn't responded
+// after 'delay', we fire off a second, identical request and use whichever response comes back first. The other
+// request is canceled. This trims your tail latency when the occasional request gets stuck on a slow instance, at
+// the cost of some extra load on the service, so pick a delay around your p95 latency rather than something tiny.
+//
+// Hedging is off by default (a delay <= 0 disables it). POST/PUT/PATCH/DELETE calls and event streams are never
+// hedged since sending them twice could do the work twice.
+func WithHedging(delay time.Duration) ClientOption {
+	return func(rpcClient *Client) {
+		rpcClient.HedgeDelay = delay
+	}
+}
+
+// shouldHedge returns true when it's safe (and the client is configured) to send a duplicate of this request.
+func (c Client) shouldHedge(request *http.Request) bool {
+	if c.HedgeDelay <= 0 || isStreamCall(request.Context()) {
+		return false
+	}
+	return request.Method == http.MethodGet || request.Method == http.MethodHead
+}
+
+// hedgedAttempt is the outcome of one of the (up to 2) requests we send when hedging a call.
+type hedgedAttempt struct {
+	index    int
+	response *http.Response
+	err      error
+}
+
+// hedge sends the request through the client's middleware. If it hasn't finished by the time the hedge delay
+// elapses, we send a second copy and return whichever one finishes first. We only prefer the slower attempt if
+// the faster one failed to get any response at all (e.g. the connection was refused).
+func (c Client) hedge(request *http.Request) (*http.Response, error) {
+	attempts := make(chan hedgedAttempt, 2)
+	var cancels []context.CancelFunc
+	send := func() {
+		ctx, cancel := context.WithCancel(request.Context())
+		attemptRequest := request.Clone(ctx)
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			response, err := c.roundTrip(attemptRequest)
+			attempts <- hedgedAttempt{index: index, response: response, err: err}
+		}()
+	}
+
+	send()
+	pending := 1
+	timer := time.NewTimer(c.HedgeDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			send()
+			pending++
+
+		case attempt := <-attempts:
+			pending--
+			if attempt.err != nil {
+				cancels[attempt.index]()
+				if pending > 0 {
+					continue
+				}
+				return nil, attempt.err
+			}
+
+			// We have a winner, so cancel the loser (if we even sent it) and clean up after it in the background.
+			for i, cancel := range cancels {
+				if i != attempt.index {
+					cancel()
+				}
+			}
+			go discardAttempts(attempts, pending)
+			attempt.response.Body = cancelOnClose{ReadCloser: attempt.response.Body, cancel: cancels[attempt.index]}
+			return attempt.response, nil
+		}
+	}
+}
+
+// discardAttempts waits for the losing attempt(s) of a hedged call (which we've already canceled), closing any
+// response bodies so that we don't leak connections.
+func discardAttempts(attempts <-chan hedgedAttempt, pending int) {
+	for ; pending > 0; pending-- {
+		if attempt := <-attempts; attempt.response != nil {
+			_ = attempt.response.Body.Close()
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+type hedgingResponse struct {
+	Attempt int32
+}
+
+// newHedgingClient creates a client whose transport gets stuck on the first 'slow' attempts until the request is
+// canceled. The other attempts reply immediately w/ the attempt number. The channel receives the attempt number of
+// every request that was canceled.
+func newHedgingClient(slow int32, options ...rpc.ClientOption) (rpc.Client, *int32, chan int32) {
+	attempts := int32(0)
+	canceled := make(chan int32, 10)
+	client := rpc.NewClient("Test", "http://localhost:9000", options...)
+	client.HTTP.Transport = rpc.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		attempt := atomic.AddInt32(&attempts, 1)
+		if attempt <= slow {
+			<-r.Context().Done()
+			canceled <- attempt
+			return nil, r.Context().Err()
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(fmt.Sprintf(`{"Attempt":%d}`, attempt))),
+		}, nil
+	})
+	return client, &attempts, canceled
+}
+
+// Ensures that we only hedge when you ask for it, that fast calls never send the second request, and that slow GET
+// calls send a second request, use its response, and cancel the slow one. We never send non-idempotent calls twice,
+// and if every attempt fails, you get the error.
+func (suite *ClientSuite) TestWithHedging() {
+	tests := []struct {
+		name     string
+		method   string
+		slow     int32
+		options  []rpc.ClientOption
+		attempt  int32
+		attempts int32
+		canceled int32
+	}{
+		{name: "disabled", method: "GET", attempt: 1, attempts: 1},
+		{name: "fast", method: "GET", options: []rpc.ClientOption{rpc.WithHedging(time.Second)}, attempt: 1, attempts: 1},
+		{name: "slow", method: "GET", slow: 1, options: []rpc.ClientOption{rpc.WithHedging(10 * time.Millisecond)}, attempt: 2, attempts: 2, canceled: 1},
+		{name: "not idempotent", method: "POST", slow: 1, options: []rpc.ClientOption{rpc.WithHedging(10 * time.Millisecond)}, attempts: 1},
+		{name: "all fail", method: "GET", slow: 2, options: []rpc.ClientOption{rpc.WithHedging(10 * time.Millisecond)}, attempts: 2},
+	}
+	for _, test := range tests {
+		r := suite.Require()
+		client, attempts, canceled := newHedgingClient(test.slow, test.options...)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		response := hedgingResponse{}
+		err := client.Invoke(ctx, test.method, "/Foo", nil, &response)
+		cancel()
+
+		if test.attempt == 0 {
+			r.Error(err, test.name)
+		} else {
+			r.NoError(err, test.name)
+			r.Equal(test.attempt, response.Attempt, "%s: Should use the first response", test.name)
+		}
+		if test.canceled > 0 {
+			select {
+			case attempt := <-canceled:
+				r.Equal(test.canceled, attempt, "%s: Should cancel the slow request", test.name)
+			case <-time.After(time.Second):
+				r.Fail("Should cancel the slow request", test.name)
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		r.Equal(test.attempts, atomic.LoadInt32(attempts), "%s: Wrong number of attempts", test.name)
+	}
+}
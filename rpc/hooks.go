@@ -0,0 +1,46 @@
+package rpc
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/monadicstack/frodo/rpc/internal/reflection"
+)
+
+// Normalizer is an optional interface that your service request structs can implement in order to
+// canonicalize their values (trim whitespace, lower-case emails, fill in defaults, etc) before the
+// gateway hands them to your service. The gateway invokes it right after binding the incoming HTTP
+// request, so your handlers only ever see the normalized values.
+//
+// Any error you return is sent back to the caller just like an error returned by the service function,
+// so you'll typically want to return something like errors.BadRequest() when the input is bogus.
+type Normalizer interface {
+	Normalize(ctx context.Context) error
+}
+
+// Redactor is an optional interface that your service response structs can implement in order to scrub
+// sensitive values (password hashes, internal notes, etc) before the gateway marshals the response
+// and sends it back to the caller.
+type Redactor interface {
+	Redact(ctx context.Context)
+}
+
+// Normalize runs the request's Normalize() hook if it implements the Normalizer interface. This is
+// a no-op for all other values (including nil requests).
+func Normalize(ctx context.Context, serviceRequest interface{}) error {
+	normalizer, ok := serviceRequest.(Normalizer)
+	if !ok || reflection.IsNil(reflect.ValueOf(serviceRequest)) {
+		return nil
+	}
+	return normalizer.Normalize(ctx)
+}
+
+// Redact runs the response's Redact() hook if it implements the Redactor interface. This is
+// a no-op for all other values (including nil responses).
+func Redact(ctx context.Context, serviceResponse interface{}) {
+	redactor, ok := serviceResponse.(Redactor)
+	if !ok || reflection.IsNil(reflect.ValueOf(serviceResponse)) {
+		return
+	}
+	redactor.Redact(ctx)
+}
@@ -0,0 +1,68 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"strings"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+type hookRequest struct {
+	Email string
+}
+
+func (r *hookRequest) Normalize(ctx context.Context) error {
+	r.Email = strings.ToLower(strings.TrimSpace(r.Email))
+	if r.Email == "" {
+		return errors.BadRequest("email is required")
+	}
+	return nil
+}
+
+type hookResponse struct {
+	Email        string
+	PasswordHash string
+}
+
+func (r *hookResponse) Redact(ctx context.Context) {
+	r.PasswordHash = ""
+}
+
+// Ensures that Normalize() fires the request's hook when it has one and leaves everything else alone.
+func (suite *GatewaySuite) TestNormalize() {
+	r := suite.Require()
+	ctx := context.Background()
+
+	request := &hookRequest{Email: "  Dude@Example.COM "}
+	r.NoError(rpc.Normalize(ctx, request))
+	r.Equal("dude@example.com", request.Email)
+
+	err := rpc.Normalize(ctx, &hookRequest{Email: "   "})
+	r.Error(err)
+	r.True(errors.IsBadRequest(err), "Normalize() should pass along the hook's error as-is")
+
+	r.NoError(rpc.Normalize(ctx, &hookResponse{}), "Values w/o a Normalize() hook should be ignored")
+	r.NoError(rpc.Normalize(ctx, nil), "Nil values should be ignored")
+	r.NoError(rpc.Normalize(ctx, (*hookRequest)(nil)), "Nil pointers should be ignored")
+}
+
+// Ensures that Redact() fires the response's hook when it has one and leaves everything else alone.
+func (suite *GatewaySuite) TestRedact() {
+	r := suite.Require()
+	ctx := context.Background()
+
+	response := &hookResponse{Email: "dude@example.com", PasswordHash: "abc123"}
+	rpc.Redact(ctx, response)
+	r.Equal("dude@example.com", response.Email)
+	r.Equal("", response.PasswordHash)
+
+	request := &hookRequest{Email: "Dude@Example.com"}
+	rpc.Redact(ctx, request)
+	r.Equal("Dude@Example.com", request.Email, "Values w/o a Redact() hook should be ignored")
+
+	r.NotPanics(func() { rpc.Redact(ctx, nil) }, "Nil values should be ignored")
+	r.NotPanics(func() { rpc.Redact(ctx, (*hookResponse)(nil)) }, "Nil pointers should be ignored")
+}
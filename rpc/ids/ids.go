@@ -0,0 +1,83 @@
+// Package ids generates unique, time-ordered identifiers for records, requests, etc. Both formats
+// that we support embed a millisecond timestamp in the leading bits, so ids generated later sort
+// after ids generated earlier. That makes them friendly to database indexes as well as for humans
+// trying to line up log entries.
+//
+//     id := ids.New()     // "01890a5d-ac96-774b-bcce-b302099a8057"
+//     id := ids.ULID()    // "01H455VB4PEXBRGNEZC86QJNY9"
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+)
+
+// Generator is any function that creates new, unique identifiers. Components such as the operation
+// middleware accept one of these so that you can plug in whatever id format your organization prefers.
+type Generator func() string
+
+// New creates a new, unique identifier using our preferred format (currently UUIDv7).
+func New() string {
+	return UUIDv7()
+}
+
+// UUIDv7 creates a new RFC 9562 version 7 UUID (e.g. "01890a5d-ac96-774b-bcce-b302099a8057"). The
+// first 48 bits contain the Unix timestamp in milliseconds and the rest are random.
+func UUIDv7() string {
+	id := timestampedBytes(time.Now())
+	id[6] = (id[6] & 0x0F) | 0x70 // version 7
+	id[8] = (id[8] & 0x3F) | 0x80 // variant 10xx
+
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], id[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], id[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], id[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], id[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], id[10:])
+	return string(buf)
+}
+
+// crockford is the Base32 alphabet used by ULIDs. It skips I, L, O, and U to avoid confusion
+// w/ similar looking characters (and accidental obscenities).
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID creates a new Universally Unique Lexicographically Sortable Identifier (e.g. "01H455VB4PEXBRGNEZC86QJNY9").
+// The first 48 bits contain the Unix timestamp in milliseconds and the remaining 80 bits are random.
+func ULID() string {
+	id := timestampedBytes(time.Now())
+	hi := binary.BigEndian.Uint64(id[0:8])
+	lo := binary.BigEndian.Uint64(id[8:16])
+
+	// 26 characters * 5 bits = 130 bits, so the first character only encodes the top 3 bits.
+	buf := make([]byte, 26)
+	for i := len(buf) - 1; i >= 0; i-- {
+		buf[i] = crockford[lo&0x1F]
+		lo = (lo >> 5) | (hi << 59)
+		hi >>= 5
+	}
+	return string(buf)
+}
+
+// timestampedBytes creates 16 bytes where the first 6 contain the big-endian millisecond timestamp
+// and the remaining 10 are cryptographically random.
+func timestampedBytes(now time.Time) [16]byte {
+	id := [16]byte{}
+	if _, err := rand.Read(id[6:]); err != nil {
+		panic("ids: unable to read random bytes: " + err.Error())
+	}
+
+	millis := uint64(now.UnixNano() / int64(time.Millisecond))
+	id[0] = byte(millis >> 40)
+	id[1] = byte(millis >> 32)
+	id[2] = byte(millis >> 24)
+	id[3] = byte(millis >> 16)
+	id[4] = byte(millis >> 8)
+	id[5] = byte(millis)
+	return id
+}
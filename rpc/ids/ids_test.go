@@ -0,0 +1,65 @@
+// +build unit
+
+package ids_test
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc/ids"
+	"github.com/stretchr/testify/suite"
+)
+
+type IDsSuite struct {
+	suite.Suite
+}
+
+var uuidV7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+var ulidPattern = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+
+// Ensures that we generate properly formatted v7 UUIDs (version/variant bits set properly).
+func (suite *IDsSuite) TestUUIDv7_format() {
+	for i := 0; i < 100; i++ {
+		id := ids.UUIDv7()
+		suite.Require().Regexp(uuidV7Pattern, id)
+	}
+	suite.Require().Regexp(uuidV7Pattern, ids.New(), "New() should generate a UUIDv7")
+}
+
+// Ensures that we generate properly formatted ULIDs.
+func (suite *IDsSuite) TestULID_format() {
+	for i := 0; i < 100; i++ {
+		id := ids.ULID()
+		suite.Require().Regexp(ulidPattern, id)
+	}
+}
+
+// Ensures that we don't generate duplicate ids.
+func (suite *IDsSuite) TestUnique() {
+	for _, generator := range []ids.Generator{ids.UUIDv7, ids.ULID} {
+		seen := map[string]bool{}
+		for i := 0; i < 10000; i++ {
+			id := generator()
+			suite.Require().False(seen[id], "Generated duplicate id: %s", id)
+			seen[id] = true
+		}
+	}
+}
+
+// Ensures that ids generated in later milliseconds sort after earlier ones.
+func (suite *IDsSuite) TestSortable() {
+	for _, generator := range []ids.Generator{ids.UUIDv7, ids.ULID} {
+		var generated []string
+		for i := 0; i < 5; i++ {
+			generated = append(generated, generator())
+			time.Sleep(2 * time.Millisecond)
+		}
+		suite.Require().True(sort.StringsAreSorted(generated), "Ids should be time-ordered: %v", generated)
+	}
+}
+
+func TestIDsSuite(t *testing.T) {
+	suite.Run(t, new(IDsSuite))
+}
@@ -0,0 +1,88 @@
+package rpc
+
+import (
+	"context"
+)
+
+// RequestInterceptor is a hook that the gateway invokes w/ the service request struct right after it binds the
+// HTTP request (and runs the request's Normalize() hook). Unlike middleware, which only sees the raw HTTP request,
+// interceptors get the actual Go value (e.g. *CreateUserRequest), so you can inspect or modify it before
+// your service function runs. Returning an error fails the call just like an error from the service function.
+type RequestInterceptor func(ctx context.Context, endpoint Endpoint, serviceRequest interface{}) error
+
+// ResponseInterceptor is a hook that the gateway invokes w/ the service response struct right before it marshals
+// it (after the response's Redact() hook). You can modify the response or return a completely different value
+// for the gateway to marshal instead (e.g. wrap it in your own envelope). Returning an error fails the call just
+// like an error from the service function.
+type ResponseInterceptor func(ctx context.Context, endpoint Endpoint, serviceResponse interface{}) (interface{}, error)
+
+// WithRequestInterceptor adds hooks that the gateway runs on every service request struct after it's been bound
+// from the HTTP request. When you supply multiple interceptors, they run in the order you provide them.
+//
+//     gateway := usersrpc.NewUserServiceGateway(service, rpc.WithRequestInterceptor(
+//         func(ctx context.Context, endpoint rpc.Endpoint, serviceRequest interface{}) error {
+//             if scoped, ok := serviceRequest.(TenantScoped); ok {
+//                 scoped.SetTenantID(tenant.FromContext(ctx))
+//             }
+//             return nil
+//         },
+//     ))
+func WithRequestInterceptor(interceptors ...RequestInterceptor) GatewayOption {
+	return func(gw *Gateway) {
+		gw.requestInterceptors = append(gw.requestInterceptors, interceptors...)
+	}
+}
+
+// WithResponseInterceptor adds hooks that the gateway runs on every service response struct before it marshals
+// it. When you supply multiple interceptors, they run in the order you provide them, each one receiving the
+// value returned by the previous one.
+func WithResponseInterceptor(interceptors ...ResponseInterceptor) GatewayOption {
+	return func(gw *Gateway) {
+		gw.responseInterceptors = append(gw.responseInterceptors, interceptors...)
+	}
+}
+
+// OnRequestBound runs all of the gateway's request interceptors on the service request. Generated gateways call
+// this for you right after binding the request, so you shouldn't need to call it yourself.
+func (gw Gateway) OnRequestBound(ctx context.Context, serviceRequest interface{}) error {
+	recordRequestPayload(ctx, serviceRequest)
+	if len(gw.requestInterceptors) == 0 {
+		return nil
+	}
+
+	endpoint := interceptedEndpoint(ctx)
+	for _, interceptor := range gw.requestInterceptors {
+		if err := interceptor(ctx, endpoint, serviceRequest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnResponseMarshal runs all of the gateway's response interceptors on the service response, returning the value
+// that the gateway should actually marshal. Generated gateways call this for you right before replying, so you
+// shouldn't need to call it yourself.
+func (gw Gateway) OnResponseMarshal(ctx context.Context, serviceResponse interface{}) (interface{}, error) {
+	if len(gw.responseInterceptors) == 0 {
+		recordResponsePayload(ctx, serviceResponse)
+		return serviceResponse, nil
+	}
+
+	endpoint := interceptedEndpoint(ctx)
+	for _, interceptor := range gw.responseInterceptors {
+		var err error
+		if serviceResponse, err = interceptor(ctx, endpoint, serviceResponse); err != nil {
+			return nil, err
+		}
+	}
+	recordResponsePayload(ctx, serviceResponse)
+	return serviceResponse, nil
+}
+
+// interceptedEndpoint returns the endpoint we're currently invoking so we can pass it to interceptors.
+func interceptedEndpoint(ctx context.Context) Endpoint {
+	if endpoint := EndpointFromContext(ctx); endpoint != nil {
+		return *endpoint
+	}
+	return Endpoint{}
+}
@@ -0,0 +1,116 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+type interceptedRequest struct {
+	Tenant string
+	Name   string
+}
+
+type interceptedResponse struct {
+	Tenant string
+	Name   string
+}
+
+// Ensures that request interceptors run in order w/ the endpoint and decoded request and that they can modify it,
+// and that response interceptors can modify or replace the response, each one seeing the previous one's result.
+// Errors from either should fail the call, and the gateway should work exactly the same w/o any interceptors.
+func (suite *GatewaySuite) TestInterceptors() {
+	var calls []string
+	tests := []struct {
+		name      string
+		options   []rpc.GatewayOption
+		exchanges []exchange
+		calls     []string
+	}{
+		{
+			name: "none",
+			exchanges: []exchange{
+				{method: "POST", path: "/Greeter.Hello", body: `{"Tenant":"A", "Name":"Bob"}`, status: 200, json: `{"Tenant":"A","Name":"Bob"}`},
+			},
+		},
+		{
+			name: "request",
+			options: []rpc.GatewayOption{
+				rpc.WithRequestInterceptor(func(ctx context.Context, endpoint rpc.Endpoint, serviceRequest interface{}) error {
+					calls = append(calls, "first "+endpoint.String())
+					serviceRequest.(*interceptedRequest).Tenant = "Scoped"
+					return nil
+				}),
+				rpc.WithRequestInterceptor(func(ctx context.Context, endpoint rpc.Endpoint, serviceRequest interface{}) error {
+					calls = append(calls, "second "+serviceRequest.(*interceptedRequest).Tenant)
+					if serviceRequest.(*interceptedRequest).Name == "" {
+						return errors.BadRequest("name is required")
+					}
+					return nil
+				}),
+			},
+			exchanges: []exchange{
+				{method: "POST", path: "/Greeter.Hello", body: `{"Tenant":"A", "Name":"Bob"}`, status: 200, json: `{"Tenant":"Scoped","Name":"Bob"}`},
+				{method: "POST", path: "/Greeter.Hello", body: `{"Tenant":"A"}`, status: 400},
+			},
+			calls: []string{"first Greeter.Hello", "second Scoped", "first Greeter.Hello", "second Scoped"},
+		},
+		{
+			name: "response",
+			options: []rpc.GatewayOption{
+				rpc.WithResponseInterceptor(
+					func(ctx context.Context, endpoint rpc.Endpoint, serviceResponse interface{}) (interface{}, error) {
+						serviceResponse.(*interceptedResponse).Tenant = ""
+						return serviceResponse, nil
+					},
+					func(ctx context.Context, endpoint rpc.Endpoint, serviceResponse interface{}) (interface{}, error) {
+						if serviceResponse.(*interceptedResponse).Name == "Fail" {
+							return nil, errors.PermissionDenied("nope")
+						}
+						return map[string]interface{}{"operation": endpoint.String(), "result": serviceResponse}, nil
+					},
+				),
+			},
+			exchanges: []exchange{
+				{method: "POST", path: "/Greeter.Hello", body: `{"Tenant":"A", "Name":"Bob"}`, status: 200, json: `{"operation":"Greeter.Hello","result":{"Tenant":"","Name":"Bob"}}`},
+				{method: "POST", path: "/Greeter.Hello", body: `{"Tenant":"A", "Name":"Fail"}`, status: 403},
+			},
+		},
+	}
+	for _, test := range tests {
+		calls = nil
+
+		// The endpoint uses the interceptors the same way that a generated gateway does.
+		gw := suite.newGateway(test.options...)
+		hello := testEndpoint("POST", "/Greeter.Hello", "Hello", func(w http.ResponseWriter, req *http.Request) {
+			serviceRequest := interceptedRequest{}
+			if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := gw.OnRequestBound(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			serviceResponse := &interceptedResponse{Tenant: serviceRequest.Tenant, Name: serviceRequest.Name}
+			response, err := gw.OnResponseMarshal(req.Context(), serviceResponse)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Reply(w, req, 200, response)
+		})
+		hello.ServiceName = "Greeter"
+		gw.Register(hello)
+
+		server := httptest.NewServer(gw)
+		suite.assertExchanges(server, test.exchanges)
+		suite.Require().Equal(test.calls, calls, test.name)
+		server.Close()
+	}
+}
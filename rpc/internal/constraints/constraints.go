@@ -0,0 +1,24 @@
+// Package constraints validates path parameters that have a format constraint in their route
+// (e.g. "GET /user/:id(uuid)"). The code generator's copy of this table (internal/constraints in the
+// frodo module) also documents each format, so keep the patterns in the two packages in sync.
+package constraints
+
+import (
+	"regexp"
+)
+
+var patterns = map[string]*regexp.Regexp{
+	"int":      regexp.MustCompile(`^-?[0-9]+$`),
+	"uint":     regexp.MustCompile(`^[0-9]+$`),
+	"float":    regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`),
+	"uuid":     regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`),
+	"alpha":    regexp.MustCompile(`^[A-Za-z]+$`),
+	"alphanum": regexp.MustCompile(`^[A-Za-z0-9]+$`),
+	"slug":     regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`),
+}
+
+// Matches returns true when the value satisfies the named constraint. Values always match unknown constraints.
+func Matches(name string, value string) bool {
+	pattern, ok := patterns[name]
+	return !ok || pattern.MatchString(value)
+}
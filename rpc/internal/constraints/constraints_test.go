@@ -0,0 +1,40 @@
+// +build unit
+
+package constraints_test
+
+import (
+	"testing"
+
+	"github.com/monadicstack/frodo/rpc/internal/constraints"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConstraintsSuite struct {
+	suite.Suite
+}
+
+func (suite *ConstraintsSuite) TestMatches() {
+	r := suite.Require()
+
+	r.True(constraints.Matches("int", "42"))
+	r.True(constraints.Matches("int", "-42"))
+	r.False(constraints.Matches("int", "4.2"))
+	r.False(constraints.Matches("int", ""))
+	r.True(constraints.Matches("uint", "42"))
+	r.False(constraints.Matches("uint", "-42"))
+	r.True(constraints.Matches("float", "4.2"))
+	r.False(constraints.Matches("float", "4."))
+	r.True(constraints.Matches("uuid", "3FA85F64-5717-4562-b3fc-2c963f66afa6"))
+	r.False(constraints.Matches("uuid", "3fa85f64-5717-4562-b3fc"))
+	r.True(constraints.Matches("alpha", "abcXYZ"))
+	r.False(constraints.Matches("alpha", "abc1"))
+	r.True(constraints.Matches("alphanum", "abc1"))
+	r.False(constraints.Matches("alphanum", "abc-1"))
+	r.True(constraints.Matches("slug", "hello-world-1"))
+	r.False(constraints.Matches("slug", "Hello--world"))
+	r.True(constraints.Matches("nope", "anything"), "Unknown constraints should match everything")
+}
+
+func TestConstraintsSuite(t *testing.T) {
+	suite.Run(t, new(ConstraintsSuite))
+}
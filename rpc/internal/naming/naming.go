@@ -0,0 +1,55 @@
+// Package naming contains the identifier/header helpers that the RPC runtime needs. The frodo module has its
+// own, larger naming package for the code generator; ToSnakeCase must behave the same in both since the
+// gateway and the generated clients need to agree on field names.
+package naming
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ToSnakeCase converts a Go-style identifier to snake_case (e.g. "FirstName" -> "first_name"). Acronyms
+// stay together, so "UserID" becomes "user_id" and "HTTPServer" becomes "http_server".
+func ToSnakeCase(value string) string {
+	runes := []rune(value)
+	result := strings.Builder{}
+	for i, r := range runes {
+		if !unicode.IsUpper(r) {
+			result.WriteRune(r)
+			continue
+		}
+		// Start a new word when we go from lower to upper ("firstName") or when we hit the last
+		// letter of an acronym that is followed by another word ("HTTPServer").
+		if i > 0 && runes[i-1] != '_' {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				result.WriteRune('_')
+			}
+		}
+		result.WriteRune(unicode.ToLower(r))
+	}
+	return result.String()
+}
+
+// DispositionFileName extracts the "filename" from an HTTP Content-Disposition header value.
+func DispositionFileName(contentDisposition string) string {
+	// The start or the file name in the header is the index of "filename=" plus the 9
+	// characters in that substring.
+	fileNameAttrIndex := strings.Index(contentDisposition, "filename=")
+	if fileNameAttrIndex < 0 {
+		return ""
+	}
+
+	// Support the fact that all of these are valid for the disposition header:
+	//
+	//   attachment; filename=foo.pdf
+	//   attachment; filename="foo.pdf"
+	//   attachment; filename='foo.pdf'
+	//
+	// This just makes sure that you don't have any quotes in your final value.
+	fileName := contentDisposition[fileNameAttrIndex+9:]
+	fileName = strings.Trim(fileName, `"'`)
+	fileName = strings.ReplaceAll(fileName, `\"`, `"`)
+	return fileName
+}
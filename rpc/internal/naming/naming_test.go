@@ -0,0 +1,43 @@
+// +build unit
+
+package naming_test
+
+import (
+	"testing"
+
+	"github.com/monadicstack/frodo/rpc/internal/naming"
+	"github.com/stretchr/testify/suite"
+)
+
+type NamingSuite struct {
+	suite.Suite
+}
+
+func (suite *NamingSuite) TestToSnakeCase() {
+	r := suite.Require()
+	r.Equal("", naming.ToSnakeCase(""))
+	r.Equal("foo", naming.ToSnakeCase("foo"))
+	r.Equal("foo", naming.ToSnakeCase("Foo"))
+	r.Equal("first_name", naming.ToSnakeCase("FirstName"))
+	r.Equal("first_name", naming.ToSnakeCase("firstName"))
+	r.Equal("first_name", naming.ToSnakeCase("first_name"))
+	r.Equal("id", naming.ToSnakeCase("ID"))
+	r.Equal("user_id", naming.ToSnakeCase("UserID"))
+	r.Equal("http_server", naming.ToSnakeCase("HTTPServer"))
+	r.Equal("address2", naming.ToSnakeCase("Address2"))
+	r.Equal("v2_name", naming.ToSnakeCase("V2Name"))
+}
+
+func (suite *NamingSuite) TestDispositionFileName() {
+	r := suite.Require()
+	r.Equal("", naming.DispositionFileName(""))
+	r.Equal("", naming.DispositionFileName("attachment"))
+	r.Equal("foo.pdf", naming.DispositionFileName("attachment; filename=foo.pdf"))
+	r.Equal("foo.pdf", naming.DispositionFileName(`attachment; filename="foo.pdf"`))
+	r.Equal("foo.pdf", naming.DispositionFileName("attachment; filename='foo.pdf'"))
+	r.Equal(`my "foo".pdf`, naming.DispositionFileName(`attachment; filename="my \"foo\".pdf"`))
+}
+
+func TestNamingSuite(t *testing.T) {
+	suite.Run(t, new(NamingSuite))
+}
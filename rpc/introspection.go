@@ -0,0 +1,92 @@
+package rpc
+
+import (
+	"net/http"
+)
+
+// EndpointsPath is the path of the endpoint that describes all of the gateway's endpoints (see WithEndpointListing).
+// Unlike your service's endpoints, it ignores the gateway's path prefix so that tooling always knows where to look.
+const EndpointsPath = "/.well-known/frodo/endpoints"
+
+// WithEndpointListing enables the "GET /.well-known/frodo/endpoints" endpoint, which describes every endpoint
+// that the gateway currently exposes. This lets admin dashboards and smoke tests discover what a live gateway
+// can do rather than parsing your service's source code:
+//
+//     GET /.well-known/frodo/endpoints
+//
+//     200 OK
+//     [
+//         {"service": "UserService", "name": "GetUser", "method": "GET", "path": "/v2/user/:ID", "status": 200},
+//         {"service": "UserService", "name": "CreateUser", "method": "POST", "path": "/v2/user", "status": 201}
+//     ]
+//
+// The paths include the gateway's path prefix, so they're the routes that callers actually use. Like everything
+// else in the gateway, the endpoint runs through your middleware, so put it behind your auth middleware if you
+// don't want to advertise your API to the world. It's disabled by default.
+func WithEndpointListing() GatewayOption {
+	return func(gw *Gateway) {
+		gw.EndpointListing = true
+	}
+}
+
+// EndpointListingEndpoint creates the "GET /.well-known/frodo/endpoints" endpoint that describes the gateway's
+// endpoints. Generated gateways register it automatically when you enable it using WithEndpointListing().
+func (gw Gateway) EndpointListingEndpoint() Endpoint {
+	return Endpoint{
+		Method:      http.MethodGet,
+		Path:        EndpointsPath,
+		ServiceName: gw.Name,
+		Name:        "Endpoints",
+		Status:      http.StatusOK,
+		static:      true,
+		listing:     true,
+		Handler:     endpointListingHandler(gw.endpoints.snapshot, gw.JSON.framework()),
+	}
+}
+
+// Endpoints returns all of the endpoints from all of the composed gateways, ordered by path and then method.
+// Unlike Gateway.Endpoints(), the endpoints' paths include their prefixes since that's the only way to tell
+// apart the same endpoint mounted under different versions (see ComposeVersions).
+func (gw CompositeGateway) Endpoints() []Endpoint {
+	var endpoints []Endpoint
+	for _, r := range sortedRoutes(gw.endpoints) {
+		if r.method != http.MethodOptions {
+			endpoint := gw.endpoints[r]
+			endpoint.Path = r.path
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// endpointDescription is a single entry in the body of a "GET /.well-known/frodo/endpoints" response.
+type endpointDescription struct {
+	Service string `json:"service"`
+	Name    string `json:"name"`
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Status  int    `json:"status,omitempty"`
+}
+
+// endpointListingHandler responds w/ a description of every endpoint that 'endpoints' returns at the time of the
+// request, so endpoints that you Register() or Unregister() while the gateway is running come and go, too.
+func endpointListingHandler(endpoints func() map[route]Endpoint, config *JSON) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		snapshot := endpoints()
+		descriptions := make([]endpointDescription, 0, len(snapshot))
+		for _, r := range sortedRoutes(snapshot) {
+			if r.method == http.MethodOptions {
+				continue
+			}
+			endpoint := snapshot[r]
+			descriptions = append(descriptions, endpointDescription{
+				Service: endpoint.ServiceName,
+				Name:    endpoint.Name,
+				Method:  r.method,
+				Path:    r.path,
+				Status:  endpoint.Status,
+			})
+		}
+		reply(w, req, http.StatusOK, descriptions, config)
+	}
+}
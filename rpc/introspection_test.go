@@ -0,0 +1,98 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+type endpointDescription struct {
+	Service string
+	Name    string
+	Method  string
+	Path    string
+	Status  int
+}
+
+// Ensures that the listing is opt-in since it advertises every route in your API. When enabled, it describes the
+// live endpoints (w/ the full paths) and ignores the gateway's PathPrefix. Composed gateways share a single
+// listing that describes all of the services.
+func (suite *GatewaySuite) TestWithEndpointListing() {
+	r := suite.Require()
+	ok := func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(200)
+	}
+
+	// Each gateway has a "GET /user/:ID" and "POST /user" endpoint. Just like generated gateways, it registers
+	// the endpoint listing if you enable it.
+	newGateway := func(service string, prefix string, options ...rpc.GatewayOption) rpc.Gateway {
+		gw := suite.newGateway(options...)
+		gw.Name = service
+		gw.PathPrefix = prefix
+		gw.Register(rpc.Endpoint{Method: "GET", Path: "/user/:ID", ServiceName: service, Name: "GetUser", Status: 200, Handler: ok})
+		gw.Register(rpc.Endpoint{Method: "POST", Path: "/user", ServiceName: service, Name: "CreateUser", Status: 201, Handler: ok})
+		if gw.EndpointListing {
+			gw.Register(gw.EndpointListingEndpoint())
+		}
+		return gw
+	}
+	listEndpoints := func(handler http.Handler) (int, []endpointDescription) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", rpc.EndpointsPath, nil))
+
+		var descriptions []endpointDescription
+		if w.Code == 200 {
+			r.NoError(json.Unmarshal(w.Body.Bytes(), &descriptions))
+		}
+		return w.Code, descriptions
+	}
+
+	gw := newGateway("UserService", "")
+	status, _ := listEndpoints(gw)
+	r.Equal(404, status)
+	r.Len(gw.Endpoints(), 2)
+
+	gw = newGateway("UserService", "v2", rpc.WithEndpointListing())
+	status, descriptions := listEndpoints(gw)
+	r.Equal(200, status)
+	r.Equal([]endpointDescription{
+		{Service: "UserService", Name: "Endpoints", Method: "GET", Path: "/.well-known/frodo/endpoints", Status: 200},
+		{Service: "UserService", Name: "CreateUser", Method: "POST", Path: "/v2/user", Status: 201},
+		{Service: "UserService", Name: "GetUser", Method: "GET", Path: "/v2/user/:ID", Status: 200},
+	}, descriptions)
+
+	endpoints := gw.Endpoints()
+	r.Len(endpoints, 3)
+	r.Equal("/user", endpoints[1].Path, "Endpoints() should still have the paths you registered")
+
+	gw.Register(rpc.Endpoint{Method: "DELETE", Path: "/user/:ID", ServiceName: "UserService", Name: "DeleteUser", Handler: ok})
+	gw.Unregister("POST", "/user")
+	_, descriptions = listEndpoints(gw)
+	r.Equal([]endpointDescription{
+		{Service: "UserService", Name: "Endpoints", Method: "GET", Path: "/.well-known/frodo/endpoints", Status: 200},
+		{Service: "UserService", Name: "DeleteUser", Method: "DELETE", Path: "/v2/user/:ID"},
+		{Service: "UserService", Name: "GetUser", Method: "GET", Path: "/v2/user/:ID", Status: 200},
+	}, descriptions, "Listing should reflect endpoints registered while running")
+
+	composite, err := rpc.Compose(
+		newGateway("UserService", "users", rpc.WithEndpointListing()),
+		newGateway("GroupService", "groups", rpc.WithEndpointListing()),
+	)
+	r.NoError(err, "Both gateways' listings should NOT conflict")
+
+	status, descriptions = listEndpoints(composite)
+	r.Equal(200, status)
+	r.Equal([]endpointDescription{
+		{Service: "Composite:UserService:GroupService", Name: "Endpoints", Method: "GET", Path: "/.well-known/frodo/endpoints", Status: 200},
+		{Service: "GroupService", Name: "CreateUser", Method: "POST", Path: "/groups/user", Status: 201},
+		{Service: "GroupService", Name: "GetUser", Method: "GET", Path: "/groups/user/:ID", Status: 200},
+		{Service: "UserService", Name: "CreateUser", Method: "POST", Path: "/users/user", Status: 201},
+		{Service: "UserService", Name: "GetUser", Method: "GET", Path: "/users/user/:ID", Status: 200},
+	}, descriptions)
+	r.Len(composite.Endpoints(), 5)
+	r.Equal("/groups/user", composite.Endpoints()[1].Path)
+}
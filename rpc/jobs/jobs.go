@@ -0,0 +1,128 @@
+// Package jobs tracks the status of long-running service operations. When you mark a service function
+// w/ the "ASYNC" doc option, the gateway responds immediately w/ a 202 and a Job, runs your function in
+// the background, then records the result in a Store so that callers can poll "GET /jobs/:id" until it
+// finishes.
+//
+// By default, gateways keep jobs in memory which is fine for a single instance of your service. If you
+// run multiple instances behind a load balancer, you'll want to provide a Store backed by something that
+// every instance can see (Redis, your database, etc) so polling works no matter which instance you hit:
+//
+//     gateway := gamesrpc.NewGameServiceGateway(service, rpc.WithJobStore(myRedisJobStore))
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+// Status describes where the job is in its lifecycle.
+type Status string
+
+const (
+	// StatusPending means that the job has been accepted, but the worker hasn't started it yet.
+	StatusPending = Status("pending")
+	// StatusRunning means that the worker is currently executing the service function.
+	StatusRunning = Status("running")
+	// StatusSucceeded means that the service function finished w/o an error. The job's Result is available.
+	StatusSucceeded = Status("succeeded")
+	// StatusFailed means that the service function returned an error. The job's Error describes the failure.
+	StatusFailed = Status("failed")
+)
+
+// Job captures the current state of a single asynchronous service call.
+type Job struct {
+	// ID uniquely identifies this job. Use it to poll "GET /jobs/:id" for updates.
+	ID string
+	// ServiceName is the name of the service whose function is running.
+	ServiceName string
+	// Name is the name of the service function that is running.
+	Name string
+	// Status describes where the job is in its lifecycle (pending, running, etc).
+	Status Status
+	// Result is the JSON-encoded service response once the job has succeeded.
+	Result json.RawMessage `json:",omitempty"`
+	// Error describes why the service function failed once the job has failed.
+	Error *errors.RPCError `json:",omitempty"`
+	// CreatedAt is when the gateway accepted the request.
+	CreatedAt time.Time
+	// UpdatedAt is the last time that the job's status changed.
+	UpdatedAt time.Time
+}
+
+// Done returns true when the job has either succeeded or failed; it won't change any more.
+func (job Job) Done() bool {
+	return job.Status == StatusSucceeded || job.Status == StatusFailed
+}
+
+// Store persists jobs so that callers can check on them while they run and fetch the results
+// once they're done. Implementations must be safe for concurrent use.
+type Store interface {
+	// Save creates or overwrites the job w/ the same ID.
+	Save(ctx context.Context, job Job) error
+	// Load fetches the job w/ the given ID. This should return an errors.NotFound() error
+	// when there is no job w/ that ID.
+	Load(ctx context.Context, id string) (*Job, error)
+}
+
+// NewMemoryStore creates a job Store that keeps all jobs in memory for the life of the process. Finished
+// jobs are discarded once they're older than the retention duration, so memory doesn't grow forever. A
+// retention of zero uses the default of 1 hour.
+func NewMemoryStore(retention time.Duration) *MemoryStore {
+	if retention <= 0 {
+		retention = time.Hour
+	}
+	return &MemoryStore{
+		retention: retention,
+		jobs:      map[string]Job{},
+	}
+}
+
+// MemoryStore is the default Store that gateways use. It works great when you run a single instance
+// of your service, but jobs don't survive restarts and aren't shared between processes.
+type MemoryStore struct {
+	retention time.Duration
+	evictedAt time.Time
+	mutex     sync.RWMutex
+	jobs      map[string]Job
+}
+
+// Save creates or overwrites the job w/ the same ID.
+func (store *MemoryStore) Save(_ context.Context, job Job) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.jobs[job.ID] = job
+	store.evict(time.Now())
+	return nil
+}
+
+// Load fetches the job w/ the given ID.
+func (store *MemoryStore) Load(_ context.Context, id string) (*Job, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	job, ok := store.jobs[id]
+	if !ok {
+		return nil, errors.NotFound("job not found: %s", id)
+	}
+	return &job, nil
+}
+
+// evict discards any finished jobs that have been around longer than our retention period. It only scans
+// the jobs about once a minute so that busy services don't pay for it on every save. You must already have
+// the write lock when calling this.
+func (store *MemoryStore) evict(now time.Time) {
+	if now.Sub(store.evictedAt) < time.Minute {
+		return
+	}
+	store.evictedAt = now
+	for id, job := range store.jobs {
+		if job.Done() && now.Sub(job.UpdatedAt) > store.retention {
+			delete(store.jobs, id)
+		}
+	}
+}
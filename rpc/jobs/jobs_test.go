@@ -0,0 +1,75 @@
+// +build unit
+
+package jobs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/jobs"
+	"github.com/stretchr/testify/suite"
+)
+
+type JobsSuite struct {
+	suite.Suite
+}
+
+// Ensures that only succeeded/failed jobs are considered done.
+func (suite *JobsSuite) TestDone() {
+	r := suite.Require()
+	r.False(jobs.Job{}.Done())
+	r.False(jobs.Job{Status: jobs.StatusPending}.Done())
+	r.False(jobs.Job{Status: jobs.StatusRunning}.Done())
+	r.True(jobs.Job{Status: jobs.StatusSucceeded}.Done())
+	r.True(jobs.Job{Status: jobs.StatusFailed}.Done())
+}
+
+// Ensures that we can save/load jobs and that saving again overwrites the job.
+func (suite *JobsSuite) TestMemoryStore() {
+	r := suite.Require()
+	ctx := context.Background()
+	store := jobs.NewMemoryStore(0)
+
+	r.NoError(store.Save(ctx, jobs.Job{ID: "1", Status: jobs.StatusPending, UpdatedAt: time.Now()}))
+	r.NoError(store.Save(ctx, jobs.Job{ID: "2", Status: jobs.StatusRunning, UpdatedAt: time.Now()}))
+
+	job, err := store.Load(ctx, "1")
+	r.NoError(err)
+	r.Equal(jobs.StatusPending, job.Status)
+
+	r.NoError(store.Save(ctx, jobs.Job{ID: "1", Status: jobs.StatusSucceeded, UpdatedAt: time.Now()}))
+	job, err = store.Load(ctx, "1")
+	r.NoError(err)
+	r.Equal(jobs.StatusSucceeded, job.Status)
+
+	job, err = store.Load(ctx, "2")
+	r.NoError(err)
+	r.Equal(jobs.StatusRunning, job.Status)
+
+	_, err = store.Load(ctx, "3")
+	r.True(errors.IsNotFound(err), "Missing jobs should result in a 404")
+}
+
+// Ensures that we discard finished jobs once they're past the retention period, but not unfinished ones.
+func (suite *JobsSuite) TestMemoryStore_retention() {
+	r := suite.Require()
+	ctx := context.Background()
+	old := time.Now().Add(-time.Hour)
+
+	// Each store scans for old jobs on its first save, so use fresh stores to check each case.
+	store := jobs.NewMemoryStore(time.Minute)
+	r.NoError(store.Save(ctx, jobs.Job{ID: "running", Status: jobs.StatusRunning, UpdatedAt: old}))
+	_, err := store.Load(ctx, "running")
+	r.NoError(err, "Unfinished jobs should never be discarded")
+
+	store = jobs.NewMemoryStore(time.Minute)
+	r.NoError(store.Save(ctx, jobs.Job{ID: "finished", Status: jobs.StatusFailed, UpdatedAt: old}))
+	_, err = store.Load(ctx, "finished")
+	r.True(errors.IsNotFound(err), "Finished jobs past retention should be discarded")
+}
+
+func TestJobsSuite(t *testing.T) {
+	suite.Run(t, new(JobsSuite))
+}
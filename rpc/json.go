@@ -0,0 +1,458 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/monadicstack/frodo/rpc/internal/naming"
+)
+
+// Marshaler lets you replace the standard library's "encoding/json" w/ a faster, drop-in library such as
+// go-json or sonic. Most of them already have functions w/ these exact signatures, so adapting one is easy:
+//
+//     type sonicMarshaler struct{}
+//
+//     func (sonicMarshaler) Marshal(value interface{}) ([]byte, error) {
+//         return sonic.Marshal(value)
+//     }
+//
+//     func (sonicMarshaler) Unmarshal(data []byte, out interface{}) error {
+//         return sonic.Unmarshal(data, out)
+//     }
+//
+//     gateway := calcrpc.NewCalculatorServiceGateway(service, rpc.WithJSON(
+//         rpc.JSONMarshaler(sonicMarshaler{}),
+//     ))
+type Marshaler interface {
+	// Marshal encodes the value as JSON.
+	Marshal(value interface{}) ([]byte, error)
+	// Unmarshal decodes the JSON data onto the 'out' value.
+	Unmarshal(data []byte, out interface{}) error
+}
+
+// StandardMarshaler encodes/decodes JSON using the standard library's "encoding/json" package. This is
+// what gateways and clients use unless you tell them otherwise.
+var StandardMarshaler Marshaler = standardMarshaler{}
+
+type standardMarshaler struct{}
+
+func (standardMarshaler) Marshal(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (standardMarshaler) Unmarshal(data []byte, out interface{}) error {
+	return json.Unmarshal(data, out)
+}
+
+// JSON describes how the gateway/client encodes and decodes the JSON bodies of requests and responses.
+type JSON struct {
+	// Marshaler does the actual encoding/decoding. The default is StandardMarshaler.
+	Marshaler Marshaler
+	// SnakeCase names fields that don't have a `json` tag in snake_case (e.g. "FirstName" becomes "first_name")
+	// rather than using the exact name of the Go field. Fields w/ a `json` tag always use the tag's name.
+	SnakeCase bool
+}
+
+// JSONOption is a setting that lets you tune how the gateway/client encodes and decodes JSON.
+type JSONOption func(*JSON)
+
+// JSONMarshaler replaces "encoding/json" w/ the JSON library of your choice (see Marshaler).
+func JSONMarshaler(marshaler Marshaler) JSONOption {
+	return func(config *JSON) {
+		config.Marshaler = marshaler
+	}
+}
+
+// JSONSnakeCase names fields that don't have a `json` tag in snake_case (e.g. "FirstName" becomes "first_name").
+// The gateway still accepts requests that use the Go field names, but every response uses the snake_case names.
+func JSONSnakeCase() JSONOption {
+	return func(config *JSON) {
+		config.SnakeCase = true
+	}
+}
+
+// WithJSON customizes how the gateway encodes/decodes JSON request and response bodies. Unlike most options,
+// this builds on any JSON settings you've already applied, so generated gateways for services w/ the
+// "JSON snake_case" doc option stay snake_case when you supply your own Marshaler.
+func WithJSON(options ...JSONOption) GatewayOption {
+	return func(gw *Gateway) {
+		gw.JSON = applyJSONOptions(gw.JSON, options)
+	}
+}
+
+// WithClientJSON customizes how the client encodes/decodes JSON request and response bodies. Just like
+// WithJSON(), this builds on any JSON settings you've already applied. Make sure that these match what
+// the gateway expects!
+func WithClientJSON(options ...JSONOption) ClientOption {
+	return func(rpcClient *Client) {
+		rpcClient.JSON = applyJSONOptions(rpcClient.JSON, options)
+	}
+}
+
+// applyJSONOptions creates a new set of JSON settings by applying the options to the existing settings.
+func applyJSONOptions(config *JSON, options []JSONOption) *JSON {
+	updated := JSON{}
+	if config != nil {
+		updated = *config
+	}
+	for _, option := range options {
+		option(&updated)
+	}
+	if updated.Marshaler == nil {
+		updated.Marshaler = StandardMarshaler
+	}
+	return &updated
+}
+
+// defaultJSON is used by gateways and clients that weren't given any JSON settings.
+var defaultJSON = &JSON{Marshaler: StandardMarshaler}
+
+// standard returns true when these settings behave exactly like "encoding/json", so callers can stick
+// to their existing (streaming) encoding/decoding logic.
+func (config *JSON) standard() bool {
+	return config == nil || (config.Marshaler == StandardMarshaler && !config.SnakeCase)
+}
+
+// framework returns the settings we use for frodo's own types (e.g. jobs.Job) whose JSON attributes
+// need to look the same no matter how you've configured the gateway. We still use your Marshaler.
+func (config *JSON) framework() *JSON {
+	if config == nil || !config.SnakeCase {
+		return config
+	}
+	return &JSON{Marshaler: config.Marshaler}
+}
+
+// Marshal encodes the value exactly like a gateway w/ these settings would. Generated code that sits in front
+// of your service (e.g. GraphQL resolvers) uses this so its JSON matches the gateway's.
+func (config *JSON) Marshal(value interface{}) ([]byte, error) {
+	return config.marshal(value)
+}
+
+// Unmarshal decodes the JSON onto the 'out' value exactly like a gateway w/ these settings would.
+func (config *JSON) Unmarshal(data []byte, out interface{}) error {
+	return config.unmarshal(data, out)
+}
+
+// marshal encodes the value using the Marshaler, renaming attributes to snake_case if need be.
+func (config *JSON) marshal(value interface{}) ([]byte, error) {
+	if config == nil {
+		config = defaultJSON
+	}
+	data, err := config.Marshaler.Marshal(value)
+	if err != nil || !config.SnakeCase {
+		return data, err
+	}
+	return renameJSON(data, reflect.TypeOf(value), toSnakeCase)
+}
+
+// unmarshal decodes the JSON onto the 'out' value using the Marshaler, restoring the Go field
+// names of any snake_case attributes first if need be.
+func (config *JSON) unmarshal(data []byte, out interface{}) error {
+	if config == nil {
+		config = defaultJSON
+	}
+	if config.SnakeCase {
+		var err error
+		if data, err = renameJSON(data, reflect.TypeOf(out), fromSnakeCase); err != nil {
+			return err
+		}
+	}
+	return config.Marshaler.Unmarshal(data, out)
+}
+
+// gatewayJSON returns the JSON settings for the gateway that is handling the request.
+func gatewayJSON(req *http.Request) *JSON {
+	if gw, ok := req.Context().Value(contextKeyGateway{}).(*Gateway); ok && gw.JSON != nil {
+		return gw.JSON
+	}
+	return defaultJSON
+}
+
+// jsonNaming indicates which way we're renaming attributes.
+type jsonNaming int
+
+const (
+	// toSnakeCase renames Go field names to snake_case when marshaling.
+	toSnakeCase = jsonNaming(0)
+	// fromSnakeCase renames snake_case attributes back to the Go field names when unmarshaling.
+	fromSnakeCase = jsonNaming(1)
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// renameJSON rewrites the attributes of the JSON objects that correspond to untagged struct fields of
+// type 't' (or types nested inside it). We do this to the encoded JSON rather than the values themselves
+// so that it works w/ any Marshaler. Attributes keep their original order.
+func renameJSON(data []byte, t reflect.Type, direction jsonNaming) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := renameJSONValue(buf, data, t, direction); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func renameJSONValue(buf *bytes.Buffer, data []byte, t reflect.Type, direction jsonNaming) error {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	trimmed := bytes.TrimSpace(data)
+	if t == nil || len(trimmed) == 0 || customJSON(t) {
+		buf.Write(data)
+		return nil
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct && trimmed[0] == '{':
+		fields := jsonFieldsFor(t)
+		return renameJSONObject(buf, trimmed, direction, func(key string) (string, reflect.Type) {
+			return fields.rename(key, direction)
+		})
+	case t.Kind() == reflect.Map && trimmed[0] == '{':
+		return renameJSONObject(buf, trimmed, direction, func(key string) (string, reflect.Type) {
+			return key, t.Elem()
+		})
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && trimmed[0] == '[':
+		return renameJSONArray(buf, trimmed, t.Elem(), direction)
+	default:
+		buf.Write(data)
+		return nil
+	}
+}
+
+// renameJSONObject copies the JSON object to the buffer, using the 'rename' function to determine
+// the new name for each attribute and the Go type of its value.
+func renameJSONObject(buf *bytes.Buffer, data []byte, direction jsonNaming, rename func(key string) (string, reflect.Type)) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+
+	buf.WriteByte('{')
+	for i := 0; decoder.More(); i++ {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		value := json.RawMessage{}
+		if err = decoder.Decode(&value); err != nil {
+			return err
+		}
+
+		key, _ := token.(string)
+		name, valueType := rename(key)
+		nameJSON, _ := json.Marshal(name)
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(nameJSON)
+		buf.WriteByte(':')
+		if err = renameJSONValue(buf, value, valueType, direction); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// renameJSONArray copies the JSON array to the buffer, renaming the attributes of each element.
+func renameJSONArray(buf *bytes.Buffer, data []byte, elemType reflect.Type, direction jsonNaming) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+
+	buf.WriteByte('[')
+	for i := 0; decoder.More(); i++ {
+		value := json.RawMessage{}
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := renameJSONValue(buf, value, elemType, direction); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+// customJSON returns true when the type has its own JSON/text marshaling logic, so we should leave
+// whatever JSON it produces alone (e.g. time.Time).
+func customJSON(t reflect.Type) bool {
+	for _, candidate := range []reflect.Type{t, reflect.PtrTo(t)} {
+		switch {
+		case candidate.Implements(jsonMarshalerType), candidate.Implements(jsonUnmarshalerType):
+			return true
+		case candidate.Implements(textMarshalerType), candidate.Implements(textUnmarshalerType):
+			return true
+		}
+	}
+	return false
+}
+
+// jsonFieldCache stores the jsonFields for every struct type we've renamed so far.
+var jsonFieldCache sync.Map
+
+// jsonField describes the JSON attribute for a single struct field.
+type jsonField struct {
+	// name is the attribute name that "encoding/json" uses (the tag name or the Go field name).
+	name string
+	// snakeName is the snake_case name for untagged fields. It's the same as 'name' for tagged fields.
+	snakeName string
+	// fieldType is the Go type of the field's value.
+	fieldType reflect.Type
+}
+
+type jsonFields []jsonField
+
+// jsonFieldsFor returns the JSON attributes of the struct type, including the promoted fields of any
+// embedded structs, just like "encoding/json" does.
+func jsonFieldsFor(t reflect.Type) jsonFields {
+	if fields, ok := jsonFieldCache.Load(t); ok {
+		return fields.(jsonFields)
+	}
+
+	var fields jsonFields
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct && fieldType != t {
+			fields = append(fields, jsonFieldsFor(fieldType)...)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+
+		if name == "" {
+			fields = append(fields, jsonField{name: field.Name, snakeName: naming.ToSnakeCase(field.Name), fieldType: field.Type})
+		} else {
+			fields = append(fields, jsonField{name: name, snakeName: name, fieldType: field.Type})
+		}
+	}
+	jsonFieldCache.Store(t, fields)
+	return fields
+}
+
+// rename returns the new name of the attribute as well as the Go type of its value. When marshaling, we rename
+// the Go field names to snake_case. When unmarshaling, we rename snake_case attributes back to the Go field
+// names; attributes that already use the Go field names are left alone since "encoding/json" will find them.
+func (fields jsonFields) rename(key string, direction jsonNaming) (string, reflect.Type) {
+	for _, field := range fields {
+		switch {
+		case direction == toSnakeCase && field.name == key:
+			return field.snakeName, field.fieldType
+		case direction == fromSnakeCase && strings.EqualFold(field.snakeName, key):
+			return field.name, field.fieldType
+		}
+	}
+	if direction == fromSnakeCase {
+		for _, field := range fields {
+			if strings.EqualFold(field.name, key) {
+				return key, field.fieldType
+			}
+		}
+	}
+	return key, nil
+}
+
+// find looks up the field for the JSON attribute, ignoring case just like "encoding/json" does. When
+// 'snakeCase' is true, the attribute can also be the snake_case name of an untagged field.
+func (fields jsonFields) find(key string, snakeCase bool) (jsonField, bool) {
+	for _, field := range fields {
+		if strings.EqualFold(field.name, key) || (snakeCase && strings.EqualFold(field.snakeName, key)) {
+			return field, true
+		}
+	}
+	return jsonField{}, false
+}
+
+// unknownJSONFields walks the JSON value and appends the path of every object attribute that doesn't
+// correspond to a field in type 't' (or the types nested inside it) to 'unknown'. Nested attributes
+// use dots (e.g. "Criteria.Limt") and array elements use their index (e.g. "Items[2].Nmae").
+func unknownJSONFields(data []byte, t reflect.Type, path string, snakeCase bool, unknown *[]string) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	trimmed := bytes.TrimSpace(data)
+	if t == nil || len(trimmed) == 0 || customJSON(t) {
+		return
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct && trimmed[0] == '{':
+		fields := jsonFieldsFor(t)
+		_ = eachJSONAttribute(trimmed, func(key string, value json.RawMessage) {
+			field, ok := fields.find(key, snakeCase)
+			if !ok {
+				*unknown = append(*unknown, joinJSONPath(path, key))
+				return
+			}
+			unknownJSONFields(value, field.fieldType, joinJSONPath(path, key), snakeCase, unknown)
+		})
+	case t.Kind() == reflect.Map && trimmed[0] == '{':
+		_ = eachJSONAttribute(trimmed, func(key string, value json.RawMessage) {
+			unknownJSONFields(value, t.Elem(), joinJSONPath(path, key), snakeCase, unknown)
+		})
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && trimmed[0] == '[':
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		if _, err := decoder.Token(); err != nil {
+			return
+		}
+		for i := 0; decoder.More(); i++ {
+			value := json.RawMessage{}
+			if err := decoder.Decode(&value); err != nil {
+				return
+			}
+			unknownJSONFields(value, t.Elem(), fmt.Sprintf("%s[%d]", path, i), snakeCase, unknown)
+		}
+	}
+}
+
+// eachJSONAttribute invokes the callback for each attribute of the JSON object in the order they appear.
+func eachJSONAttribute(data []byte, callback func(key string, value json.RawMessage)) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		value := json.RawMessage{}
+		if err = decoder.Decode(&value); err != nil {
+			return err
+		}
+		key, _ := token.(string)
+		callback(key, value)
+	}
+	return nil
+}
+
+func joinJSONPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,280 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/jobs"
+)
+
+type jsonRequest struct {
+	FirstName string
+	UserID    string
+	Tagged    string `json:"tagged_value,omitempty"`
+	Address   jsonAddress
+	Previous  []jsonAddress
+	Labels    map[string]jsonAddress
+	When      time.Time
+	Ignored   string `json:"-"`
+}
+
+type jsonAddress struct {
+	ZipCode string
+}
+
+// countingMarshaler is a "custom" JSON library that just counts how many times we used it.
+type countingMarshaler struct {
+	marshals   int
+	unmarshals int
+}
+
+func (m *countingMarshaler) Marshal(value interface{}) ([]byte, error) {
+	m.marshals++
+	return json.Marshal(value)
+}
+
+func (m *countingMarshaler) Unmarshal(data []byte, out interface{}) error {
+	m.unmarshals++
+	return json.Unmarshal(data, out)
+}
+
+// Ensures that the gateway reads and writes snake_case attributes when you enable it, but leaves tagged fields and
+// types w/ custom marshaling alone. The default settings still use the exact Go field names, and path/query
+// params w/ underscores only bind to fields when you're using snake_case. The gateway uses your Marshaler for
+// request and response bodies, and envelopes keep their standard attributes, but the data inside follows the
+// JSON settings. Each WithJSON() builds on the previous settings rather than replacing them.
+func (suite *GatewaySuite) TestWithJSON() {
+	marshaler := &countingMarshaler{}
+	snakeCase := []rpc.GatewayOption{rpc.WithJSON(rpc.JSONSnakeCase())}
+	custom := []rpc.GatewayOption{rpc.WithJSON(rpc.JSONMarshaler(marshaler))}
+	tests := []struct {
+		name        string
+		options     []rpc.GatewayOption
+		method      string
+		path        string
+		body        string
+		status      int
+		json        string
+		contains    []string
+		notContains []string
+		header      http.Header
+		// counts are the number of times that we expect the gateway to marshal/unmarshal (not checked when nil).
+		counts []int
+	}{
+		{
+			name:        "default",
+			method:      "POST",
+			path:        "/echo",
+			body:        `{"FirstName":"Jeff","first_name":"Walter","tagged_value":"x"}`,
+			status:      200,
+			contains:    []string{`"FirstName":"Jeff"`, `"tagged_value":"x"`},
+			notContains: []string{"Walter"},
+		},
+		{
+			name:     "default params",
+			method:   "GET",
+			path:     "/echo/123?first_name=Jeff",
+			status:   200,
+			contains: []string{`"FirstName":""`, `"UserID":""`},
+		},
+		{
+			name:    "snake_case",
+			options: snakeCase,
+			method:  "POST",
+			path:    "/echo",
+			body: `{
+				"first_name": "Jeff",
+				"user_id": "123",
+				"tagged_value": "x",
+				"address": {"zip_code": "90210"},
+				"previous": [{"zip_code": "10001"}],
+				"labels": {"Home_Address": {"zip_code": "60601"}},
+				"when": "2024-03-15T12:30:00Z"
+			}`,
+			status: 200,
+			json: `{
+				"first_name": "Jeff",
+				"user_id": "123",
+				"tagged_value": "x",
+				"address": {"zip_code": "90210"},
+				"previous": [{"zip_code": "10001"}],
+				"labels": {"Home_Address": {"zip_code": "60601"}},
+				"when": "2024-03-15T12:30:00Z"
+			}`,
+			// Attributes should keep their order.
+			contains: []string{`{"first_name":"Jeff","user_id":"123"`},
+		},
+		{
+			name:     "snake_case w/ Go field names",
+			options:  snakeCase,
+			method:   "POST",
+			path:     "/echo",
+			body:     `{"FirstName":"Jeff","UserID":"123"}`,
+			status:   200,
+			contains: []string{`"first_name":"Jeff","user_id":"123"`},
+		},
+		{
+			name:     "snake_case params",
+			options:  snakeCase,
+			method:   "GET",
+			path:     "/echo/123?first_name=Jeff&address.zip_code=90210",
+			status:   200,
+			contains: []string{`"first_name":"Jeff","user_id":"123"`, `"address":{"zip_code":"90210"}`},
+		},
+		{
+			name:     "marshaler",
+			options:  custom,
+			method:   "POST",
+			path:     "/echo",
+			body:     `{"FirstName":"Jeff"}`,
+			status:   200,
+			contains: []string{`"FirstName":"Jeff"`},
+			counts:   []int{1, 1},
+		},
+		{
+			name:    "marshaler w/ bad JSON",
+			options: custom,
+			method:  "POST",
+			path:    "/echo",
+			body:    `{"FirstName":`,
+			status:  400,
+		},
+		{
+			name:     "marshaler w/ empty body",
+			options:  custom,
+			method:   "POST",
+			path:     "/echo",
+			status:   400,
+			contains: []string{"empty body"},
+		},
+		{
+			name:     "merged",
+			options:  append(append([]rpc.GatewayOption{}, snakeCase...), custom...),
+			method:   "POST",
+			path:     "/echo",
+			body:     `{"first_name":"Jeff"}`,
+			status:   200,
+			contains: []string{`"first_name":"Jeff"`},
+			counts:   []int{1, 1},
+		},
+		{
+			name:     "envelope",
+			options:  append(append([]rpc.GatewayOption{}, snakeCase...), rpc.WithResponseEnvelope()),
+			method:   "POST",
+			path:     "/echo",
+			body:     `{"first_name":"Jeff"}`,
+			status:   200,
+			contains: []string{`{"data":{"first_name":"Jeff",`, `"meta":{"durationMs":`},
+			header:   http.Header{rpc.EnvelopeHeader: {"true"}},
+		},
+	}
+	for _, test := range tests {
+		r := suite.Require()
+		*marshaler = countingMarshaler{}
+		gw := suite.newGateway(test.options...)
+		handler := echo(&gw, func() interface{} { return &jsonRequest{} })
+		gw.Register(testEndpoint("POST", "/echo", "Echo", handler))
+		gw.Register(testEndpoint("GET", "/echo/:user_id", "EchoGet", handler))
+
+		req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+
+		r.Equal(test.status, w.Code, "%s: %s", test.name, w.Body.String())
+		if test.json != "" {
+			r.JSONEq(test.json, w.Body.String(), test.name)
+		}
+		for _, value := range test.contains {
+			r.Contains(w.Body.String(), value, test.name)
+		}
+		for _, value := range test.notContains {
+			r.NotContains(w.Body.String(), value, test.name)
+		}
+		for key, values := range test.header {
+			r.Equal(values[0], w.Header().Get(key), test.name)
+		}
+		if test.counts != nil {
+			r.Equal(test.counts, []int{marshaler.marshals, marshaler.unmarshals}, test.name)
+		}
+	}
+
+	gw := suite.newGateway(rpc.WithJSON(rpc.JSONSnakeCase()), rpc.WithJSON(rpc.JSONMarshaler(marshaler)))
+	suite.Require().True(gw.JSON.SnakeCase, "WithJSON() should build on the previous settings")
+}
+
+// Ensures that jobs keep their standard attributes, but the results follow the JSON settings.
+func (suite *GatewaySuite) TestWithJSON_async() {
+	r := suite.Require()
+	gw := suite.newGateway(rpc.WithJSON(rpc.JSONSnakeCase()))
+	gw.Register(testEndpoint("POST", "/echo", "Echo", func(w http.ResponseWriter, req *http.Request) {
+		serviceRequest := jsonRequest{}
+		if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
+			rpc.Fail(w, req, err)
+			return
+		}
+		gw.RunAsync(w, req, func(ctx context.Context) (interface{}, error) {
+			return serviceRequest, nil
+		})
+	}))
+	gw.Register(gw.JobEndpoint())
+	server := httptest.NewServer(gw)
+	defer server.Close()
+
+	client := rpc.NewClient("TestService", server.URL, rpc.WithClientJSON(rpc.JSONSnakeCase()), rpc.WithJobPollInterval(10*time.Millisecond))
+	job := jobs.Job{}
+	r.NoError(client.Invoke(context.Background(), "POST", "/echo", &jsonRequest{FirstName: "Jeff"}, &job))
+	r.NotEmpty(job.ID)
+
+	res, err := http.Get(server.URL + "/jobs/" + job.ID)
+	r.NoError(err)
+	defer res.Body.Close()
+	body := map[string]interface{}{}
+	r.NoError(json.NewDecoder(res.Body).Decode(&body))
+	r.Equal(job.ID, body["ID"], "Jobs should use the standard attribute names")
+
+	response := jsonRequest{}
+	r.NoError(client.Await(context.Background(), job.ID, &response))
+	r.Equal("Jeff", response.FirstName)
+}
+
+// Ensures that the client encodes/decodes bodies w/ the same settings as the gateway.
+func (suite *GatewaySuite) TestWithClientJSON() {
+	r := suite.Require()
+	marshaler := &countingMarshaler{}
+	gw := suite.newGateway(rpc.WithJSON(rpc.JSONSnakeCase()))
+	handler := echo(&gw, func() interface{} { return &jsonRequest{} })
+	gw.Register(testEndpoint("POST", "/echo", "Echo", handler))
+	gw.Register(testEndpoint("GET", "/echo/:user_id", "EchoGet", handler))
+	server := httptest.NewServer(gw)
+	defer server.Close()
+
+	client := rpc.NewClient("TestService", server.URL, rpc.WithClientJSON(rpc.JSONSnakeCase(), rpc.JSONMarshaler(marshaler)))
+	request := jsonRequest{
+		FirstName: "Jeff",
+		UserID:    "123",
+		Tagged:    "x",
+		Address:   jsonAddress{ZipCode: "90210"},
+		Previous:  []jsonAddress{{ZipCode: "10001"}},
+		Labels:    map[string]jsonAddress{"home": {ZipCode: "60601"}},
+		When:      time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC),
+	}
+
+	response := jsonRequest{}
+	r.NoError(client.Invoke(context.Background(), "POST", "/echo", &request, &response))
+	r.Equal(request, response)
+	r.Equal(1, marshaler.marshals)
+	r.Equal(1, marshaler.unmarshals)
+
+	response = jsonRequest{}
+	r.NoError(client.Invoke(context.Background(), "GET", "/echo/:user_id", &jsonRequest{UserID: "123", FirstName: "Jeff"}, &response))
+	r.Equal("123", response.UserID)
+	r.Equal("Jeff", response.FirstName)
+}
@@ -0,0 +1,259 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/respond"
+)
+
+// JSONRPCPath is the path of the endpoint that speaks JSON-RPC 2.0 (see WithJSONRPC). Like any other endpoint,
+// it sits under the gateway's path prefix (if any).
+const JSONRPCPath = "/rpc"
+
+// The error codes that the JSON-RPC 2.0 spec reserves for failures of the protocol itself. Failures of the
+// service functions use the HTTP status of the failure as the code instead (e.g. 404 or 403).
+const (
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCMethodNotFound = -32601
+	JSONRPCInvalidParams  = -32602
+)
+
+// WithJSONRPC enables the "POST /rpc" endpoint, which lets tools that only speak JSON-RPC 2.0 call your service
+// functions w/o any changes. The method is "Service.Function" and the params are the request struct:
+//
+//     POST /rpc
+//     {"jsonrpc": "2.0", "id": 1, "method": "UserService.GetUser", "params": {"ID": "123"}}
+//
+//     200 OK
+//     {"jsonrpc": "2.0", "id": 1, "result": {"ID": "123", ...}}
+//
+// Failed calls respond w/ an error whose code is the HTTP status that the function failed with (e.g. 404) and
+// whose data is the error body you'd get calling the function directly. Just like WithBatch(), each call runs
+// through the same middleware that it would if you called it directly, and the gateway runs up to 'concurrency'
+// of the calls in a JSON-RPC batch at the same time. Functions that respond w/ raw content or an event stream
+// ("SSE") can't be called this way. The default of 0 disables the endpoint entirely.
+func WithJSONRPC(concurrency int) GatewayOption {
+	return func(gw *Gateway) {
+		gw.JSONRPCConcurrency = concurrency
+	}
+}
+
+// JSONRPCEndpoint creates the "POST /rpc" endpoint that JSON-RPC 2.0 callers use to invoke service functions.
+// Generated gateways register it automatically when you enable it using WithJSONRPC().
+func (gw Gateway) JSONRPCEndpoint() Endpoint {
+	return Endpoint{
+		Method:      http.MethodPost,
+		Path:        JSONRPCPath,
+		ServiceName: gw.Name,
+		Name:        "JSONRPC",
+		Status:      http.StatusOK,
+		jsonrpc:     true,
+		Handler: jsonrpcHandler(gw.JSONRPCConcurrency, func(service string, name string) (*Gateway, Endpoint, bool) {
+			endpoint, ok := gw.endpoints.lookupFunction(service, name)
+			return &gw, endpoint, ok
+		}),
+	}
+}
+
+// jsonrpcRequest is a single request object in the body of a "POST /rpc" request.
+type jsonrpcRequest struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// notification returns true when the request doesn't have an id, so the caller doesn't want a response.
+func (call jsonrpcRequest) notification() bool {
+	return len(call.ID) == 0
+}
+
+// lookup splits the method (e.g. "UserService.GetUser") into its service/function names and finds that
+// function's endpoint.
+func (call jsonrpcRequest) lookup(lookup batchLookup) (*Gateway, Endpoint, bool) {
+	dot := strings.LastIndex(call.Method, ".")
+	if dot <= 0 {
+		return nil, Endpoint{}, false
+	}
+	return lookup(call.Method[:dot], call.Method[dot+1:])
+}
+
+// body returns the request body for the service function. We also accept params that are an array w/ just the
+// request struct in it since that's what Go's "net/rpc/jsonrpc" (and the tools built on it) send.
+func (call jsonrpcRequest) body() (json.RawMessage, bool) {
+	params := bytes.TrimSpace(call.Params)
+	switch {
+	case len(params) == 0 || bytes.Equal(params, []byte("null")):
+		return nil, true
+	case params[0] == '{':
+		return params, true
+	case params[0] == '[':
+		var positional []json.RawMessage
+		if err := json.Unmarshal(params, &positional); err != nil || len(positional) > 1 {
+			return nil, false
+		}
+		if len(positional) == 0 {
+			return nil, true
+		}
+		return jsonrpcRequest{Params: positional[0]}.body()
+	default:
+		return nil, false
+	}
+}
+
+// jsonrpcResponse is the response object for a single JSON-RPC request.
+type jsonrpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonrpcError   `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// jsonrpcError describes why a single JSON-RPC request failed.
+type jsonrpcError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// newJSONRPCFailure creates the response for a request that failed before we could call the function.
+func newJSONRPCFailure(id json.RawMessage, code int, message string) *jsonrpcResponse {
+	return &jsonrpcResponse{Version: "2.0", Error: &jsonrpcError{Code: code, Message: message}, ID: id}
+}
+
+// newJSONRPCResponse converts the outcome of the call into its JSON-RPC response.
+func newJSONRPCResponse(id json.RawMessage, result batchResult) *jsonrpcResponse {
+	if result.Status < 400 {
+		body := result.Body
+		if len(body) == 0 {
+			body = json.RawMessage("null")
+		}
+		return &jsonrpcResponse{Version: "2.0", Result: body, ID: id}
+	}
+
+	// Dig the message out of whichever error format/envelope the gateway uses.
+	message := http.StatusText(result.Status)
+	failure := struct {
+		Message string `json:"message"`
+		Detail  string `json:"detail"`
+		Error   *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}{}
+	if json.Unmarshal(result.Body, &failure) == nil {
+		switch {
+		case failure.Message != "":
+			message = failure.Message
+		case failure.Detail != "":
+			message = failure.Detail
+		case failure.Error != nil && failure.Error.Message != "":
+			message = failure.Error.Message
+		}
+	}
+	response := newJSONRPCFailure(id, result.Status, message)
+	response.Error.Data = result.Body
+	return response
+}
+
+// jsonrpcHandler runs each of the JSON-RPC requests through its endpoint's middleware/handler, 'concurrency' at a
+// time, and replies w/ the responses once they're done. Notifications (requests w/o an id) still run, but they
+// don't get a response.
+func jsonrpcHandler(concurrency int, lookup batchLookup) http.HandlerFunc {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			if errors.Status(err) == http.StatusRequestEntityTooLarge {
+				Fail(w, req, err)
+				return
+			}
+			Fail(w, req, errors.BadRequest("invalid json-rpc request: %v", err))
+			return
+		}
+
+		body = bytes.TrimSpace(body)
+		batch := len(body) > 0 && body[0] == '['
+		messages := []json.RawMessage{body}
+		if batch {
+			messages = nil
+			if err = json.Unmarshal(body, &messages); err == nil && len(messages) == 0 {
+				respond.To(w, req).Reply(http.StatusOK, newJSONRPCFailure(nil, JSONRPCInvalidRequest, "empty batch"))
+				return
+			}
+		}
+		if err != nil || !json.Valid(body) {
+			respond.To(w, req).Reply(http.StatusOK, newJSONRPCFailure(nil, JSONRPCParseError, "parse error"))
+			return
+		}
+
+		// The JSON-RPC request already counts against the gateway's in-flight limit, so don't shed its calls.
+		ctx := context.WithValue(req.Context(), contextKeyBatch{}, true)
+		req = req.WithContext(ctx)
+
+		responses := make([]*jsonrpcResponse, len(messages))
+		slots := make(chan struct{}, concurrency)
+		wg := sync.WaitGroup{}
+		for i, message := range messages {
+			call := jsonrpcRequest{}
+			if err := json.Unmarshal(message, &call); err != nil || call.Version != "2.0" || call.Method == "" {
+				// We can't trust the id of an invalid request, so the spec says to always respond w/o one.
+				responses[i] = newJSONRPCFailure(nil, JSONRPCInvalidRequest, "invalid request")
+				continue
+			}
+
+			gw, endpoint, ok := call.lookup(lookup)
+			if !ok {
+				if !call.notification() {
+					responses[i] = newJSONRPCFailure(call.ID, JSONRPCMethodNotFound, "method not found: "+call.Method)
+				}
+				continue
+			}
+			params, ok := call.body()
+			if !ok {
+				if !call.notification() {
+					responses[i] = newJSONRPCFailure(call.ID, JSONRPCInvalidParams, "params must be the request object")
+				}
+				continue
+			}
+
+			wg.Add(1)
+			slots <- struct{}{}
+			go func(i int, call jsonrpcRequest, gw *Gateway, endpoint Endpoint) {
+				defer func() { <-slots }()
+				defer wg.Done()
+				result := runBatchCall(req, gw, endpoint, params)
+				if !call.notification() {
+					responses[i] = newJSONRPCResponse(call.ID, result)
+				}
+			}(i, call, gw, endpoint)
+		}
+		wg.Wait()
+
+		var results []*jsonrpcResponse
+		for _, response := range responses {
+			if response != nil {
+				results = append(results, response)
+			}
+		}
+
+		// The responses are frodo's own type, so they're never wrapped in an envelope or snake_cased.
+		switch {
+		case len(results) == 0:
+			w.WriteHeader(http.StatusNoContent)
+		case !batch:
+			respond.To(w, req).Reply(http.StatusOK, results[0])
+		default:
+			respond.To(w, req).Reply(http.StatusOK, results)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+type jsonrpcResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	} `json:"error"`
+}
+
+// Ensures that a single request calls the function and responds w/ its result and the caller's id, and that a
+// composite gateway has a single JSON-RPC endpoint that can call functions on any of its services. The JSON-RPC
+// endpoint doesn't exist unless you ask for it.
+func (suite *GatewaySuite) TestWithJSONRPC() {
+	r := suite.Require()
+	gw := suite.newEchoGateway("EchoService", rpc.WithJSONRPC(1))
+	composite, err := rpc.Compose(
+		suite.newEchoGateway("FooService", rpc.WithJSONRPC(2)),
+		suite.newEchoGateway("BarService", rpc.WithJSONRPC(2)),
+	)
+	r.NoError(err)
+
+	tests := []struct {
+		name    string
+		gateway http.Handler
+		body    string
+		status  int
+		json    string
+	}{
+		{
+			name:    "disabled",
+			gateway: suite.newEchoGateway("EchoService"),
+			body:    `{"jsonrpc":"2.0","id":1,"method":"EchoService.Echo"}`,
+			status:  404,
+		},
+		{
+			name:    "named params",
+			gateway: gw,
+			body:    `{"jsonrpc":"2.0", "id":"abc", "method":"EchoService.Echo", "params":{"Text":"hello"}}`,
+			status:  200,
+			json:    `{"jsonrpc":"2.0", "id":"abc", "result":{"Text":"EchoService.Echo:hello"}}`,
+		},
+		{
+			name:    "positional params",
+			gateway: gw,
+			body:    `{"jsonrpc":"2.0", "id":2, "method":"EchoService.Echo", "params":[{"Text":"positional"}]}`,
+			status:  200,
+			json:    `{"jsonrpc":"2.0", "id":2, "result":{"Text":"EchoService.Echo:positional"}}`,
+		},
+		{
+			name:    "no params",
+			gateway: gw,
+			body:    `{"jsonrpc":"2.0", "id":3, "method":"EchoService.Echo"}`,
+			status:  200,
+			json:    `{"jsonrpc":"2.0", "id":3, "result":{"Text":"EchoService.Echo:"}}`,
+		},
+		{
+			name:    "composite",
+			gateway: composite,
+			body: `[
+				{"jsonrpc":"2.0", "id":1, "method":"FooService.Echo", "params":{"Text":"a"}},
+				{"jsonrpc":"2.0", "id":2, "method":"BarService.Echo", "params":{"Text":"b"}}
+			]`,
+			status: 200,
+			json: `[
+				{"jsonrpc":"2.0", "id":1, "result":{"Text":"FooService.Echo:a"}},
+				{"jsonrpc":"2.0", "id":2, "result":{"Text":"BarService.Echo:b"}}
+			]`,
+		},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		test.gateway.ServeHTTP(w, httptest.NewRequest("POST", rpc.JSONRPCPath, strings.NewReader(test.body)))
+		r.Equal(test.status, w.Code, test.name)
+		if test.json != "" {
+			r.JSONEq(test.json, w.Body.String(), test.name)
+		}
+	}
+}
+
+// Ensures that both failed calls and invalid requests respond w/ the appropriate JSON-RPC error.
+func (suite *GatewaySuite) TestWithJSONRPC_errors() {
+	tests := []struct {
+		body string
+		code int
+		id   string
+	}{
+		{body: `{"jsonrpc":"2.0", "id":1, "method":"EchoService.Fail"}`, code: 403, id: "1"},
+		{body: `{"jsonrpc":"2.0", "id":1, "method":"EchoService.Nope"}`, code: rpc.JSONRPCMethodNotFound, id: "1"},
+		{body: `{"jsonrpc":"2.0", "id":1, "method":"Echo"}`, code: rpc.JSONRPCMethodNotFound, id: "1"},
+		{body: `{"jsonrpc":"2.0", "id":1, "method":"EchoService.JSONRPC"}`, code: rpc.JSONRPCMethodNotFound, id: "1"},
+		{body: `{"jsonrpc":"2.0", "id":1, "method":"EchoService.Echo", "params":"hello"}`, code: rpc.JSONRPCInvalidParams, id: "1"},
+		{body: `{"jsonrpc":"2.0", "id":1, "method":"EchoService.Echo", "params":[{}, {}]}`, code: rpc.JSONRPCInvalidParams, id: "1"},
+		{body: `{"jsonrpc":"1.0", "id":1, "method":"EchoService.Echo"}`, code: rpc.JSONRPCInvalidRequest, id: "null"},
+		{body: `{"jsonrpc":"2.0", "id":1, "method":"EchoService.Echo"`, code: rpc.JSONRPCParseError, id: "null"},
+		{body: `[]`, code: rpc.JSONRPCInvalidRequest, id: "null"},
+	}
+
+	gw := suite.newEchoGateway("EchoService", rpc.WithJSONRPC(1))
+	for _, test := range tests {
+		r := suite.Require()
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, httptest.NewRequest("POST", rpc.JSONRPCPath, strings.NewReader(test.body)))
+		r.Equal(200, w.Code, test.body)
+
+		response := jsonrpcResponse{}
+		r.NoError(json.Unmarshal(w.Body.Bytes(), &response), test.body)
+		r.Equal(test.code, response.Error.Code, test.body)
+		r.Equal(test.id, string(response.ID), test.body)
+		r.Nil(response.Result, test.body)
+		if test.code == 403 {
+			// Failed calls include the function's error, too.
+			r.Equal("nope", response.Error.Message)
+			r.JSONEq(`{"status":403, "message":"nope"}`, string(response.Error.Data))
+		}
+	}
+}
+
+// Ensures that batches respond w/ an array of responses in the same order, leaving out notifications.
+func (suite *GatewaySuite) TestWithJSONRPC_batch() {
+	r := suite.Require()
+	notified := int32(0)
+	gw := suite.newEchoGateway("EchoService", rpc.WithJSONRPC(4))
+	gw.Register(rpc.Endpoint{
+		Method:      "POST",
+		Path:        "/EchoService/notify",
+		ServiceName: "EchoService",
+		Name:        "Notify",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			atomic.AddInt32(&notified, 1)
+			rpc.Reply(w, req, 200, echoRequest{})
+		},
+	})
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, httptest.NewRequest("POST", rpc.JSONRPCPath, strings.NewReader(body)))
+		return w
+	}
+
+	w := post(`[
+		{"jsonrpc":"2.0", "id":1, "method":"EchoService.Echo", "params":{"Text":"a"}},
+		{"jsonrpc":"2.0", "method":"EchoService.Notify"},
+		{"jsonrpc":"2.0", "id":2, "method":"EchoService.Fail"},
+		1,
+		{"jsonrpc":"2.0", "method":"EchoService.Nope"},
+		{"jsonrpc":"2.0", "id":3, "method":"EchoService.Echo", "params":{"Text":"b"}}
+	]`)
+	r.Equal(200, w.Code)
+
+	var responses []jsonrpcResponse
+	r.NoError(json.Unmarshal(w.Body.Bytes(), &responses))
+	r.Len(responses, 4)
+	r.Equal("1", string(responses[0].ID))
+	r.JSONEq(`{"Text":"EchoService.Echo:a"}`, string(responses[0].Result))
+	r.Equal("2", string(responses[1].ID))
+	r.Equal(403, responses[1].Error.Code)
+	r.Equal("null", string(responses[2].ID))
+	r.Equal(rpc.JSONRPCInvalidRequest, responses[2].Error.Code)
+	r.Equal("3", string(responses[3].ID))
+	r.JSONEq(`{"Text":"EchoService.Echo:b"}`, string(responses[3].Result))
+	r.Equal(int32(1), atomic.LoadInt32(&notified))
+
+	w = post(`[{"jsonrpc":"2.0", "method":"EchoService.Notify"}, {"jsonrpc":"2.0", "method":"EchoService.Notify"}]`)
+	r.Equal(204, w.Code, "Should not respond w/ anything when every request is a notification")
+	r.Empty(w.Body.String())
+	r.Equal(int32(3), atomic.LoadInt32(&notified))
+}
@@ -0,0 +1,314 @@
+// Package lambda lets you run a frodo gateway (or any http.Handler) as an AWS Lambda function behind API
+// Gateway (REST or HTTP APIs) or an Application Load Balancer. Your service and gateway don't change at all;
+// the adapter converts each event into an http.Request, runs it through the gateway, and converts the response
+// back into the format that AWS expects:
+//
+//     import (
+//         awslambda "github.com/aws/aws-lambda-go/lambda"
+//         "github.com/monadicstack/frodo/rpc/lambda"
+//     )
+//
+//     func main() {
+//         gateway := calcrpc.NewCalculatorServiceGateway(calc.CalculatorServiceHandler{})
+//         awslambda.Start(lambda.Wrap(gateway))
+//     }
+//
+// Frodo doesn't depend on the AWS SDK, so the Event/Response types mirror the JSON that AWS sends/expects
+// rather than using the ones from "github.com/aws/aws-lambda-go/events". The Lambda runtime only cares
+// about the JSON, so they're interchangeable.
+//
+// If your API Gateway stage name is part of the request path (e.g. "/prod/v1/CalculatorService.Add"), strip
+// it before the gateway sees it: lambda.Wrap(http.StripPrefix("/prod", gateway)).
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Handler is the function that you hand to the Lambda runtime (i.e. "lambda.Start(handler)").
+type Handler func(ctx context.Context, event Event) (Response, error)
+
+// Event is the request that API Gateway or an Application Load Balancer sends to your Lambda function. It
+// covers the REST API (version "1.0"), HTTP API (version "2.0"), and ALB payload formats since they share
+// most of their fields.
+type Event struct {
+	// Version is "2.0" for the HTTP API payload format and blank/"1.0" for the others.
+	Version string `json:"version"`
+	// HTTPMethod is the request method in the REST API and ALB formats.
+	HTTPMethod string `json:"httpMethod"`
+	// Path is the request path in the REST API and ALB formats.
+	Path string `json:"path"`
+	// RawPath is the request path in the HTTP API format.
+	RawPath string `json:"rawPath"`
+	// RawQueryString is the URL-encoded query string in the HTTP API format.
+	RawQueryString string `json:"rawQueryString"`
+	// Headers are the request headers. If a header has multiple values, this only has the last one.
+	Headers map[string]string `json:"headers"`
+	// MultiValueHeaders are the request headers w/ all of their values (REST API and ALB formats only).
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+	// QueryStringParameters are the query string parameters. If a parameter has multiple values, this only
+	// has the last one (REST API and ALB formats only).
+	QueryStringParameters map[string]string `json:"queryStringParameters"`
+	// MultiValueQueryStringParameters are the query string parameters w/ all of their values (REST API and
+	// ALB formats only).
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+	// Cookies are the request's cookies (HTTP API format only; the others leave them in the headers).
+	Cookies []string `json:"cookies"`
+	// RequestContext contains extra information about the request from API Gateway/ALB.
+	RequestContext RequestContext `json:"requestContext"`
+	// Body is the request body, which is base64 encoded when IsBase64Encoded is true.
+	Body string `json:"body"`
+	// IsBase64Encoded indicates that the Body contains base64 encoded binary data.
+	IsBase64Encoded bool `json:"isBase64Encoded"`
+}
+
+// RequestContext contains extra information about the request from API Gateway/ALB.
+type RequestContext struct {
+	// RequestID is the id that API Gateway assigned to the request.
+	RequestID string `json:"requestId"`
+	// Stage is the API Gateway deployment stage (e.g. "prod").
+	Stage string `json:"stage"`
+	// HTTP describes the request in the HTTP API format.
+	HTTP struct {
+		Method   string `json:"method"`
+		SourceIP string `json:"sourceIp"`
+	} `json:"http"`
+	// Identity describes the caller in the REST API format.
+	Identity struct {
+		SourceIP string `json:"sourceIp"`
+	} `json:"identity"`
+	// ELB is only present when the event came from an Application Load Balancer.
+	ELB *struct {
+		TargetGroupARN string `json:"targetGroupArn"`
+	} `json:"elb"`
+	// Authorizer contains the output of the API Gateway authorizer (e.g. JWT claims), if any.
+	Authorizer map[string]interface{} `json:"authorizer"`
+}
+
+// Response is what your Lambda function sends back to API Gateway or the Application Load Balancer. We
+// only fill in the fields that the event's payload format uses.
+type Response struct {
+	StatusCode        int                 `json:"statusCode"`
+	StatusDescription string              `json:"statusDescription,omitempty"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Cookies           []string            `json:"cookies,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// Wrap creates a Lambda handler that runs every event through your gateway. Responses whose bodies aren't
+// text (e.g. images from raw ContentReader responses or compressed responses) are base64 encoded, so make
+// sure that API Gateway is set up to handle binary media types if your service has them.
+func Wrap(handler http.Handler) Handler {
+	return func(ctx context.Context, event Event) (Response, error) {
+		req, err := NewRequest(ctx, event)
+		if err != nil {
+			return Response{}, err
+		}
+		w := &responseWriter{header: http.Header{}}
+		handler.ServeHTTP(w, req)
+		return w.toResponse(event), nil
+	}
+}
+
+// NewRequest converts the Lambda event into the equivalent http.Request. The event is available to your
+// service functions and middleware via EventFromContext().
+func NewRequest(ctx context.Context, event Event) (*http.Request, error) {
+	body := []byte(event.Body)
+	if event.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(event.Body)
+		if err != nil {
+			return nil, fmt.Errorf("lambda: unable to decode request body: %w", err)
+		}
+		body = decoded
+	}
+
+	method := event.HTTPMethod
+	if event.Version == "2.0" {
+		method = event.RequestContext.HTTP.Method
+	}
+	requestURI := event.rawPath()
+	if query := event.rawQuery(); query != "" {
+		requestURI += "?" + query
+	}
+
+	ctx = context.WithValue(ctx, contextKeyEvent{}, &event)
+	req, err := http.NewRequestWithContext(ctx, method, requestURI, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("lambda: unable to create request: %w", err)
+	}
+
+	if len(event.MultiValueHeaders) > 0 {
+		for name, values := range event.MultiValueHeaders {
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+	} else {
+		for name, value := range event.Headers {
+			req.Header.Set(name, value)
+		}
+	}
+	if len(event.Cookies) > 0 {
+		req.Header.Set("Cookie", strings.Join(event.Cookies, "; "))
+	}
+
+	req.Host = req.Header.Get("Host")
+	req.RequestURI = requestURI
+	req.RemoteAddr = event.RequestContext.HTTP.SourceIP
+	if req.RemoteAddr == "" {
+		req.RemoteAddr = event.RequestContext.Identity.SourceIP
+	}
+	return req, nil
+}
+
+// EventFromContext returns the Lambda event for the current request (e.g. so you can look at the claims from
+// the API Gateway authorizer). It returns nil when the request didn't come through the Lambda adapter.
+func EventFromContext(ctx context.Context) *Event {
+	if ctx == nil {
+		return nil
+	}
+	event, _ := ctx.Value(contextKeyEvent{}).(*Event)
+	return event
+}
+
+type contextKeyEvent struct{}
+
+// rawPath returns the URL-encoded request path. API Gateway REST APIs decode the path for us, but the
+// other formats give it to us as-is, so we only need to encode the former.
+func (event Event) rawPath() string {
+	switch {
+	case event.Version == "2.0":
+		return event.RawPath
+	case event.RequestContext.ELB != nil:
+		return event.Path
+	default:
+		return (&url.URL{Path: event.Path}).EscapedPath()
+	}
+}
+
+// rawQuery rebuilds the URL-encoded query string from the event. The HTTP API format gives it to us as-is. API
+// Gateway REST APIs decode the parameters for us, but the ALB doesn't, so we only need to encode the former.
+func (event Event) rawQuery() string {
+	if event.Version == "2.0" {
+		return event.RawQueryString
+	}
+
+	params := event.MultiValueQueryStringParameters
+	if len(params) == 0 {
+		params = map[string][]string{}
+		for name, value := range event.QueryStringParameters {
+			params[name] = []string{value}
+		}
+	}
+	if event.RequestContext.ELB == nil {
+		return url.Values(params).Encode()
+	}
+
+	var query []string
+	for name, values := range params {
+		for _, value := range values {
+			query = append(query, name+"="+value)
+		}
+	}
+	sort.Strings(query)
+	return strings.Join(query, "&")
+}
+
+// responseWriter buffers the gateway's response since Lambda functions can't stream their responses.
+type responseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(data)
+}
+
+// Flush does nothing since the whole response goes back to AWS at once, but some handlers (e.g. "SSE"
+// streams) insist on flushing as they go.
+func (w *responseWriter) Flush() {}
+
+// toResponse converts the buffered response into the payload format of the event that triggered it.
+func (w *responseWriter) toResponse(event Event) Response {
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := Response{StatusCode: status}
+
+	if text(w.header, w.body.Bytes()) {
+		response.Body = w.body.String()
+	} else {
+		response.Body = base64.StdEncoding.EncodeToString(w.body.Bytes())
+		response.IsBase64Encoded = true
+	}
+
+	switch {
+	case event.Version == "2.0":
+		// HTTP APIs want cookies on their own, and join the values of any other repeated headers w/ commas.
+		response.Cookies = w.header.Values("Set-Cookie")
+		response.Headers = map[string]string{}
+		for name, values := range w.header {
+			if name != "Set-Cookie" {
+				response.Headers[name] = strings.Join(values, ",")
+			}
+		}
+	case event.RequestContext.ELB != nil && len(event.MultiValueHeaders) == 0:
+		// The ALB only accepts multi-value headers when the target group has them enabled, which we can
+		// tell by whether the request had them.
+		response.StatusDescription = fmt.Sprintf("%d %s", status, http.StatusText(status))
+		response.Headers = map[string]string{}
+		for name := range w.header {
+			response.Headers[name] = w.header.Get(name)
+		}
+	case event.RequestContext.ELB != nil:
+		response.StatusDescription = fmt.Sprintf("%d %s", status, http.StatusText(status))
+		response.MultiValueHeaders = w.header
+	default:
+		response.MultiValueHeaders = w.header
+	}
+	return response
+}
+
+// text returns true when the response body is text that can go back to AWS as-is. Anything else (images,
+// PDFs, compressed JSON, etc) must be base64 encoded.
+func text(header http.Header, body []byte) bool {
+	if encoding := header.Get("Content-Encoding"); encoding != "" && encoding != "identity" {
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	switch {
+	case contentType == "":
+		return utf8.Valid(body)
+	case strings.HasPrefix(contentType, "text/"):
+		return true
+	case strings.Contains(contentType, "json"), strings.Contains(contentType, "xml"):
+		return true
+	case strings.Contains(contentType, "javascript"), strings.Contains(contentType, "x-www-form-urlencoded"):
+		return true
+	default:
+		return false
+	}
+}
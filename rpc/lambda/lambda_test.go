@@ -0,0 +1,221 @@
+// +build unit
+
+package lambda_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/dimfeld/httptreemux/v5"
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/lambda"
+	"github.com/monadicstack/frodo/rpc/metadata"
+	"github.com/stretchr/testify/suite"
+)
+
+type LambdaSuite struct {
+	suite.Suite
+}
+
+// echo is a handler that responds w/ everything it knows about the request.
+func (suite *LambdaSuite) echo() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		event := lambda.EventFromContext(req.Context())
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Add("Set-Cookie", "a=1")
+		w.Header().Add("Set-Cookie", "b=2")
+		w.WriteHeader(201)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"method":  req.Method,
+			"uri":     req.RequestURI,
+			"query":   req.URL.Query().Get("q"),
+			"host":    req.Host,
+			"remote":  req.RemoteAddr,
+			"cookie":  req.Header.Get("Cookie"),
+			"foo":     req.Header.Get("X-Foo"),
+			"body":    string(body),
+			"request": event.RequestContext.RequestID,
+		})
+	}
+}
+
+func (suite *LambdaSuite) decode(response lambda.Response) map[string]string {
+	values := map[string]string{}
+	suite.Require().False(response.IsBase64Encoded, "JSON responses should not be base64 encoded")
+	suite.Require().NoError(json.Unmarshal([]byte(response.Body), &values))
+	return values
+}
+
+// Ensures that we can handle events from an HTTP API (payload format 2.0).
+func (suite *LambdaSuite) TestHTTPAPI() {
+	r := suite.Require()
+	event := lambda.Event{
+		Version:         "2.0",
+		RawPath:         "/user/a%2Fb",
+		RawQueryString:  "q=hello%20world",
+		Headers:         map[string]string{"host": "api.example.com", "x-foo": "bar"},
+		Cookies:         []string{"session=123", "theme=dark"},
+		Body:            base64.StdEncoding.EncodeToString([]byte(`{"name":"dude"}`)),
+		IsBase64Encoded: true,
+	}
+	event.RequestContext.RequestID = "abc"
+	event.RequestContext.HTTP.Method = "POST"
+	event.RequestContext.HTTP.SourceIP = "1.2.3.4"
+
+	response, err := lambda.Wrap(suite.echo())(context.Background(), event)
+	r.NoError(err)
+	r.Equal(201, response.StatusCode)
+	r.Equal("application/json", response.Headers["Content-Type"])
+	r.Equal([]string{"a=1", "b=2"}, response.Cookies)
+	r.Nil(response.MultiValueHeaders)
+	r.Equal(map[string]string{
+		"method":  "POST",
+		"uri":     "/user/a%2Fb?q=hello%20world",
+		"query":   "hello world",
+		"host":    "api.example.com",
+		"remote":  "1.2.3.4",
+		"cookie":  "session=123; theme=dark",
+		"foo":     "bar",
+		"body":    `{"name":"dude"}`,
+		"request": "abc",
+	}, suite.decode(response))
+}
+
+// Ensures that we can handle events from a REST API (payload format 1.0), which decodes the path/query for us.
+func (suite *LambdaSuite) TestRESTAPI() {
+	r := suite.Require()
+	event := lambda.Event{
+		HTTPMethod:                      "GET",
+		Path:                            "/user/hello world",
+		MultiValueHeaders:               map[string][]string{"X-Foo": {"bar", "baz"}},
+		MultiValueQueryStringParameters: map[string][]string{"q": {"a&b"}},
+	}
+	event.RequestContext.Identity.SourceIP = "1.2.3.4"
+
+	response, err := lambda.Wrap(suite.echo())(context.Background(), event)
+	r.NoError(err)
+	r.Equal(201, response.StatusCode)
+	r.Equal([]string{"a=1", "b=2"}, response.MultiValueHeaders["Set-Cookie"])
+	r.Nil(response.Headers)
+	r.Empty(response.StatusDescription)
+
+	values := suite.decode(response)
+	r.Equal("/user/hello%20world?q=a%26b", values["uri"])
+	r.Equal("a&b", values["query"])
+	r.Equal("bar", values["foo"])
+	r.Equal("1.2.3.4", values["remote"])
+}
+
+// Ensures that we can handle events from an Application Load Balancer w/ and w/o multi-value headers.
+func (suite *LambdaSuite) TestALB() {
+	r := suite.Require()
+	event := lambda.Event{}
+	r.NoError(json.Unmarshal([]byte(`{
+		"requestContext": {"elb": {"targetGroupArn": "arn:aws:elasticloadbalancing:..."}},
+		"httpMethod": "GET",
+		"path": "/user/a%2Fb",
+		"queryStringParameters": {"q": "hello%20world"},
+		"headers": {"x-foo": "bar"},
+		"body": ""
+	}`), &event))
+
+	response, err := lambda.Wrap(suite.echo())(context.Background(), event)
+	r.NoError(err)
+	r.Equal("201 Created", response.StatusDescription)
+	r.Equal("a=1", response.Headers["Set-Cookie"])
+	r.Nil(response.MultiValueHeaders)
+
+	values := suite.decode(response)
+	r.Equal("/user/a%2Fb?q=hello%20world", values["uri"])
+	r.Equal("hello world", values["query"])
+
+	event.Headers = nil
+	event.MultiValueHeaders = map[string][]string{"x-foo": {"bar"}}
+	response, err = lambda.Wrap(suite.echo())(context.Background(), event)
+	r.NoError(err)
+	r.Equal([]string{"a=1", "b=2"}, response.MultiValueHeaders["Set-Cookie"])
+	r.Nil(response.Headers)
+}
+
+// Ensures that binary responses are base64 encoded and text responses aren't.
+func (suite *LambdaSuite) TestBinary() {
+	r := suite.Require()
+	responses := map[string][]byte{
+		"image/png":                {0x89, 'P', 'N', 'G', 0xff},
+		"text/plain; charset=utf8": []byte("hello"),
+		"":                         {0xff, 0xfe},
+	}
+	for contentType, body := range responses {
+		contentType, body := contentType, body
+		handler := lambda.Wrap(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if contentType != "" {
+				w.Header().Set("Content-Type", contentType)
+			}
+			_, _ = w.Write(body)
+		}))
+
+		response, err := handler(context.Background(), lambda.Event{HTTPMethod: "GET", Path: "/file"})
+		r.NoError(err)
+		r.Equal(200, response.StatusCode)
+		if contentType == "text/plain; charset=utf8" {
+			r.False(response.IsBase64Encoded)
+			r.Equal("hello", response.Body)
+			continue
+		}
+		r.True(response.IsBase64Encoded, "Should encode binary %q responses", contentType)
+		r.Equal(base64.StdEncoding.EncodeToString(body), response.Body)
+	}
+}
+
+// Ensures that events make it through a frodo gateway (routing, path params, metadata, errors).
+func (suite *LambdaSuite) TestGateway() {
+	r := suite.Require()
+	gateway := rpc.NewGateway()
+	gateway.Register(rpc.Endpoint{
+		Method:      "GET",
+		Path:        "/user/:id",
+		ServiceName: "UserService",
+		Name:        "GetUser",
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			tenant := ""
+			metadata.Value(req.Context(), "tenant", &tenant)
+			rpc.Reply(w, req, 200, map[string]string{"id": httptreemux.ContextParams(req.Context())["id"], "tenant": tenant})
+		},
+	})
+	handler := lambda.Wrap(gateway)
+
+	encoded, err := metadata.ToJSON(metadata.WithValue(context.Background(), "tenant", "acme"))
+	r.NoError(err)
+	response, err := handler(context.Background(), lambda.Event{
+		HTTPMethod: "GET",
+		Path:       "/user/123",
+		Headers:    map[string]string{"X-RPC-Values": encoded},
+	})
+	r.NoError(err)
+	r.Equal(200, response.StatusCode)
+	r.JSONEq(`{"id":"123","tenant":"acme"}`, response.Body)
+
+	response, err = handler(context.Background(), lambda.Event{HTTPMethod: "GET", Path: "/nope"})
+	r.NoError(err)
+	r.Equal(404, response.StatusCode)
+}
+
+// Ensures that we fail when the event's body isn't valid base64 rather than handing junk to the gateway.
+func (suite *LambdaSuite) TestInvalidBody() {
+	_, err := lambda.Wrap(suite.echo())(context.Background(), lambda.Event{
+		HTTPMethod:      "POST",
+		Path:            "/user",
+		Body:            "not base64!",
+		IsBase64Encoded: true,
+	})
+	suite.Require().Error(err)
+}
+
+func TestLambdaSuite(t *testing.T) {
+	suite.Run(t, new(LambdaSuite))
+}
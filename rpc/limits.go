@@ -0,0 +1,253 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+// WithMaxRequestBytes limits the size of request bodies that the gateway will accept. Requests that declare
+// a larger "Content-Length" are rejected w/ a 413 before we read a single byte and bodies w/o one (e.g. chunked
+// or compressed requests) fail w/ a 413 as soon as the binder reads past the limit. The limit applies to the
+// decompressed bytes, so a tiny gzip bomb can't sneak past it. The default of 0 means there's no limit.
+//
+// You can give individual operations a bigger (or smaller) limit using the "MAXBYTES" doc option:
+//
+//     // UploadAvatar stores a new profile image for the user.
+//     //
+//     // MAXBYTES 20MB
+//     UploadAvatar(ctx context.Context, req *UploadAvatarRequest) (*UploadAvatarResponse, error)
+//
+// Use "MAXBYTES unlimited" to exempt an operation from the gateway's limit entirely.
+func WithMaxRequestBytes(n int64) GatewayOption {
+	return func(gw *Gateway) {
+		gw.MaxRequestBytes = n
+	}
+}
+
+// LimitRequestBody makes the client fail w/ a 413 before it sends a request whose (uncompressed) body is larger
+// than 'maxBytes'. Generated clients do this for you on functions w/ a "MAXBYTES" doc option, so you find out
+// that your 50MB blob is too big without uploading it first. Values <= 0 mean there's no limit.
+func LimitRequestBody(ctx context.Context, maxBytes int64) context.Context {
+	return context.WithValue(ctx, contextKeyRequestBodyLimit{}, maxBytes)
+}
+
+type contextKeyRequestBodyLimit struct{}
+
+// checkRequestBodyLimit fails w/ the same 413 error that the gateway would when the request body is larger than
+// the limit that the generated client put on the context via LimitRequestBody().
+func checkRequestBodyLimit(ctx context.Context, body io.Reader) error {
+	limit, _ := ctx.Value(contextKeyRequestBodyLimit{}).(int64)
+	sized, ok := body.(interface{ Len() int })
+	if limit <= 0 || !ok || int64(sized.Len()) <= limit {
+		return nil
+	}
+	return requestTooLarge(limit)
+}
+
+// WithLoadShedding caps the number of requests that the gateway will work on at the same time. Once 'maxInFlight'
+// requests are in progress, the gateway immediately rejects new ones w/ a 503 and a "Retry-After" header rather than
+// letting them pile up. It's better to tell some callers to back off (so they can retry against another instance)
+// than to let every caller time out while the process drowns. The default of 0 means there's no limit.
+//
+// Long-lived requests such as event streams ("SSE") count against the limit for as long as they're open.
+func WithLoadShedding(maxInFlight int) GatewayOption {
+	return func(gw *Gateway) {
+		gw.MaxInFlight = maxInFlight
+	}
+}
+
+// shedLoad is gateway middleware that rejects requests w/ a 503 once the gateway is already working
+// on 'maxInFlight' requests. It runs before the rest of the middleware so rejections are as cheap as possible.
+func shedLoad(maxInFlight int) MiddlewareFunc {
+	inFlight := make(chan struct{}, maxInFlight)
+	return func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		// The calls in a batch (see WithBatch) share the slot that the batch request itself is holding.
+		if isBatchCall(req.Context()) {
+			next(w, req)
+			return
+		}
+		select {
+		case inFlight <- struct{}{}:
+			defer func() { <-inFlight }()
+			next(w, req)
+		default:
+			w.Header().Set("Retry-After", "1")
+			Fail(w, req, errors.Unavailable("server is overloaded: too many requests in progress"))
+		}
+	}
+}
+
+// WithConcurrencyLimit caps the number of requests that each endpoint will work on at the same time. It's a
+// bulkhead: when one slow operation backs up, only callers of that operation have to wait, so it can't
+// starve the rest of the service of connections, memory, or database handles. Once an endpoint is at
+// capacity, new requests wait up to 'maxWait' for a slot to open before the gateway rejects them w/ a 503
+// and a "Retry-After" header. The default of 0 means endpoints have no limit unless they ask for one.
+//
+// You can give individual operations their own limit using the "CONCURRENCY" doc option:
+//
+//     // GenerateReport crunches the numbers for the whole quarter.
+//     //
+//     // CONCURRENCY 4
+//     GenerateReport(ctx context.Context, req *GenerateReportRequest) (*GenerateReportResponse, error)
+//
+// Use "CONCURRENCY unlimited" to exempt an operation from the gateway's limit entirely. Operations w/ their
+// own limit still use the gateway's 'maxWait', which is 100ms if you never call this option.
+func WithConcurrencyLimit(maxPerEndpoint int, maxWait time.Duration) GatewayOption {
+	return func(gw *Gateway) {
+		gw.MaxConcurrency = maxPerEndpoint
+		gw.ConcurrencyWait = maxWait
+	}
+}
+
+// defaultConcurrencyWait is how long requests wait for a slot in an endpoint that's at its "CONCURRENCY"
+// limit when you haven't used WithConcurrencyLimit to pick your own.
+const defaultConcurrencyWait = 100 * time.Millisecond
+
+// limitConcurrency is gateway middleware that enforces the gateway's (or the endpoint's) limit on how many
+// requests for the same endpoint can run at once. It runs after restoreEndpoint so that we know which
+// endpoint (and which override) the request is for.
+func limitConcurrency(maxConcurrency int, maxWait time.Duration) MiddlewareFunc {
+	bulkheads := bulkheads{slots: map[bulkheadKey]chan struct{}{}}
+	return func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		endpoint := EndpointFromContext(req.Context())
+		if endpoint == nil {
+			next(w, req)
+			return
+		}
+		limit := maxConcurrency
+		if endpoint.MaxConcurrency != 0 {
+			limit = endpoint.MaxConcurrency
+		}
+		if limit <= 0 {
+			next(w, req)
+			return
+		}
+
+		slots := bulkheads.get(endpoint, limit)
+		if !acquireSlot(req, slots, maxWait) {
+			w.Header().Set("Retry-After", "1")
+			Fail(w, req, errors.Unavailable("%s is overloaded: too many requests in progress", endpoint.String()))
+			return
+		}
+		defer func() { <-slots }()
+		next(w, req)
+	}
+}
+
+// acquireSlot grabs one of the slots, waiting up to 'maxWait' for one to open when they're all taken. It gives
+// up early if the caller goes away while we're waiting.
+func acquireSlot(req *http.Request, slots chan struct{}, maxWait time.Duration) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+	}
+	if maxWait <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-req.Context().Done():
+		return false
+	}
+}
+
+// bulkheadKey identifies an endpoint. Composed gateways (see ComposeVersions) can have the same operation
+// under multiple versions, and each one gets its own bulkhead.
+type bulkheadKey struct {
+	method  string
+	path    string
+	version string
+}
+
+// bulkheads lazily creates the semaphore for each endpoint the first time someone calls it.
+type bulkheads struct {
+	mutex sync.Mutex
+	slots map[bulkheadKey]chan struct{}
+}
+
+func (b *bulkheads) get(endpoint *Endpoint, limit int) chan struct{} {
+	key := bulkheadKey{method: endpoint.Method, path: endpoint.Path, version: endpoint.Version}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if slots, ok := b.slots[key]; ok {
+		return slots
+	}
+	slots := make(chan struct{}, limit)
+	b.slots[key] = slots
+	return slots
+}
+
+// limitRequestBody is gateway middleware that enforces the gateway's (or the endpoint's) limit on the size
+// of the request body. It runs after restoreEndpoint so that the endpoint's override is available.
+func limitRequestBody(maxBytes int64) MiddlewareFunc {
+	return func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		limit := maxBytes
+		if endpoint := EndpointFromContext(req.Context()); endpoint != nil && endpoint.MaxRequestBytes != 0 {
+			limit = endpoint.MaxRequestBytes
+		}
+		if limit <= 0 || req.Body == nil || req.Body == http.NoBody {
+			next(w, req)
+			return
+		}
+
+		// Don't bother reading anything if the caller already told us that the body is too big.
+		if req.ContentLength > limit {
+			Fail(w, req, requestTooLarge(limit))
+			return
+		}
+		req.Body = &limitedBody{ReadCloser: req.Body, remaining: limit, limit: limit}
+		next(w, req)
+	}
+}
+
+// requestTooLarge creates the 413 error we respond w/ when the body exceeds the limit.
+func requestTooLarge(limit int64) error {
+	return errors.New(http.StatusRequestEntityTooLarge, "request body too large: limit is %d bytes", limit)
+}
+
+// limitedBody works like http.MaxBytesReader, except that the error it returns once you read past the limit
+// is a 413 RPCError, so it flows through the binder and back to the caller w/ the right status.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	limit     int64
+	err       error
+}
+
+func (body *limitedBody) Read(p []byte) (int, error) {
+	if body.err != nil {
+		return 0, body.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	// Read one byte more than we have left so that we can tell the difference between a body
+	// that is exactly the limit and one that goes past it.
+	if int64(len(p)) > body.remaining+1 {
+		p = p[:body.remaining+1]
+	}
+	n, err := body.ReadCloser.Read(p)
+	if int64(n) <= body.remaining {
+		body.remaining -= int64(n)
+		return n, err
+	}
+
+	n = int(body.remaining)
+	body.remaining = 0
+	body.err = requestTooLarge(body.limit)
+	return n, body.err
+}
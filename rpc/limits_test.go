@@ -0,0 +1,269 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+type limitsRequest struct {
+	Text string
+}
+
+// limitsText returns text that makes the JSON body {"Text":"..."} exactly 'size' bytes.
+func limitsText(size int) string {
+	return strings.Repeat("a", size-11)
+}
+
+// Ensures that we reject bodies w/ a Content-Length over the limit as well as ones that only go over the limit
+// once we've read them ("chunked"). An endpoint's own limit (like you'd get w/ a "MAXBYTES" doc option) overrides
+// the gateway's limit, and gateways don't limit the body unless you ask them to. The limit applies to the
+// decompressed body, so small compressed payloads can't blow up memory, and form bodies over the limit also
+// result in a 413 rather than a generic 400.
+func (suite *GatewaySuite) TestWithMaxRequestBytes() {
+	r := suite.Require()
+	body := func(size int) string {
+		return `{"Text":"` + limitsText(size) + `"}`
+	}
+
+	compressed := bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(&compressed)
+	_, _ = gzipWriter.Write([]byte(body(10000)))
+	r.NoError(gzipWriter.Close())
+	r.Less(compressed.Len(), 1000)
+
+	multipartBody := bytes.Buffer{}
+	multipartWriter := multipart.NewWriter(&multipartBody)
+	r.NoError(multipartWriter.WriteField("Text", strings.Repeat("a", 200)))
+	r.NoError(multipartWriter.Close())
+
+	limit := func(maxBytes int64) []rpc.GatewayOption {
+		return []rpc.GatewayOption{rpc.WithMaxRequestBytes(maxBytes)}
+	}
+	tests := []struct {
+		name        string
+		options     []rpc.GatewayOption
+		uploadLimit int64
+		path        string
+		body        string
+		header      http.Header
+		chunked     bool
+		status      int
+		echoed      bool
+	}{
+		{name: "disabled", path: "/echo", body: body(1 << 20), status: 200, echoed: true},
+		{name: "at limit", options: limit(100), path: "/echo", body: body(100), status: 200, echoed: true},
+		{name: "over limit", options: limit(100), path: "/echo", body: body(101), status: 413},
+		{name: "chunked at limit", options: limit(100), path: "/echo", body: body(100), chunked: true, status: 200, echoed: true},
+		{name: "chunked over limit", options: limit(100), path: "/echo", body: body(101), chunked: true, status: 413},
+		{name: "endpoint larger", options: limit(100), uploadLimit: 2000, path: "/echo", body: body(1000), chunked: true, status: 413},
+		{name: "endpoint larger (override)", options: limit(100), uploadLimit: 2000, path: "/upload", body: body(1000), chunked: true, status: 200},
+		{name: "endpoint smaller", options: limit(2000), uploadLimit: 100, path: "/echo", body: body(1000), chunked: true, status: 200},
+		{name: "endpoint smaller (override)", options: limit(2000), uploadLimit: 100, path: "/upload", body: body(1000), chunked: true, status: 413},
+		{name: "endpoint only", uploadLimit: 100, path: "/echo", body: body(1000), chunked: true, status: 200},
+		{name: "endpoint only (override)", uploadLimit: 100, path: "/upload", body: body(1000), chunked: true, status: 413},
+		{name: "endpoint unlimited", options: limit(100), uploadLimit: -1, path: "/echo", body: body(1000), chunked: true, status: 413},
+		{name: "endpoint unlimited (override)", options: limit(100), uploadLimit: -1, path: "/upload", body: body(1000), chunked: true, status: 200},
+		{
+			name:    "compressed",
+			options: limit(1000),
+			path:    "/echo",
+			body:    compressed.String(),
+			header:  http.Header{"Content-Encoding": {"gzip"}},
+			status:  413,
+		},
+		{
+			name:    "url-encoded form",
+			options: limit(100),
+			path:    "/echo",
+			body:    "Text=" + strings.Repeat("a", 200),
+			header:  http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+			chunked: true,
+			status:  413,
+		},
+		{
+			name:    "multipart form",
+			options: limit(100),
+			path:    "/echo",
+			body:    multipartBody.String(),
+			header:  http.Header{"Content-Type": {multipartWriter.FormDataContentType()}},
+			chunked: true,
+			status:  413,
+		},
+	}
+	for _, test := range tests {
+		gw := suite.newGateway(test.options...)
+		handler := echo(&gw, func() interface{} { return &limitsRequest{} })
+		upload := testEndpoint("POST", "/upload", "Upload", handler)
+		upload.MaxRequestBytes = test.uploadLimit
+		gw.Register(testEndpoint("POST", "/echo", "Echo", handler))
+		gw.Register(upload)
+
+		// We hide the Content-Length of "chunked" bodies so that the gateway has to enforce the limit while
+		// reading the body.
+		var reader io.Reader = strings.NewReader(test.body)
+		if test.chunked {
+			reader = struct{ io.Reader }{reader}
+		}
+		req := httptest.NewRequest("POST", test.path, reader)
+		if test.chunked {
+			req.ContentLength = -1
+		}
+		for key, values := range test.header {
+			req.Header.Set(key, values[0])
+		}
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+
+		r.Equal(test.status, w.Code, test.name)
+		if test.echoed {
+			r.JSONEq(test.body, w.Body.String(), test.name)
+		}
+		if test.status == 413 {
+			r.Contains(w.Body.String(), "request body too large", test.name)
+		}
+	}
+}
+
+// Ensures that clients reject bodies over the call's limit w/o ever sending them to the gateway.
+func (suite *GatewaySuite) TestLimitRequestBody() {
+	r := suite.Require()
+	calls := 0
+	gw := suite.newGateway()
+	gw.Register(testEndpoint("POST", "/echo", "Echo", echo(&gw, func() interface{} { return &limitsRequest{} })))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		gw.ServeHTTP(w, req)
+	}))
+	defer server.Close()
+
+	client := rpc.NewClient("TestService", server.URL)
+	ctx := rpc.LimitRequestBody(context.Background(), 100)
+
+	response := limitsRequest{}
+	r.NoError(client.Invoke(ctx, "POST", "/echo", &limitsRequest{Text: limitsText(100)}, &response))
+	r.Equal(limitsText(100), response.Text)
+	r.Equal(1, calls)
+
+	err := client.Invoke(ctx, "POST", "/echo", &limitsRequest{Text: limitsText(101)}, &response)
+	r.Error(err)
+	r.Equal(413, errors.Status(err))
+	r.Equal(1, calls, "Client should not send bodies over the limit")
+
+	r.NoError(client.Invoke(context.Background(), "POST", "/echo", &limitsRequest{Text: limitsText(101)}, &response))
+	r.NoError(client.Invoke(rpc.LimitRequestBody(ctx, 0), "POST", "/echo", &limitsRequest{Text: limitsText(101)}, &response))
+	r.Equal(3, calls)
+}
+
+// Ensures that the gateway rejects requests w/ a 503 once it's working on too many at the same time (either
+// across the whole gateway or for an endpoint w/ a "CONCURRENCY" limit), and that it accepts them again as soon
+// as the in-flight requests finish. Endpoints inherit the gateway's concurrency limit unless they have their own,
+// and each endpoint has its own slots. Requests can wait briefly for a slot to open up before we reject them.
+func (suite *GatewaySuite) TestWithConcurrencyLimit() {
+	r := suite.Require()
+
+	// The "Slow" endpoint (w/ the given limit) blocks until you close 'release'. The "Fast" endpoint replies
+	// immediately. Every request to "Slow" writes to 'started'.
+	newSlowGateway := func(limit int, started chan struct{}, release chan struct{}, options ...rpc.GatewayOption) rpc.Gateway {
+		gw := suite.newGateway(options...)
+		slow := testEndpoint("POST", "/slow", "Slow", func(w http.ResponseWriter, req *http.Request) {
+			started <- struct{}{}
+			<-release
+			rpc.Reply(w, req, 200, limitsRequest{Text: "done"})
+		})
+		slow.MaxConcurrency = limit
+		gw.Register(slow)
+		gw.Register(testEndpoint("POST", "/fast", "Fast", func(w http.ResponseWriter, req *http.Request) {
+			rpc.Reply(w, req, 200, limitsRequest{Text: "done"})
+		}))
+		return gw
+	}
+	post := func(gw rpc.Gateway, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, httptest.NewRequest("POST", path, strings.NewReader("{}")))
+		return w
+	}
+
+	tests := []struct {
+		name     string
+		limit    int
+		options  []rpc.GatewayOption
+		overload string
+	}{
+		{name: "load shedding", options: []rpc.GatewayOption{rpc.WithLoadShedding(2)}, overload: "server is overloaded"},
+		{name: "endpoint", limit: 2, overload: "TestService.Slow is overloaded"},
+	}
+	for _, test := range tests {
+		started, release := make(chan struct{}, 3), make(chan struct{})
+		gw := newSlowGateway(test.limit, started, release, test.options...)
+
+		results := make(chan int, 2)
+		for i := 0; i < 2; i++ {
+			go func() { results <- post(gw, "/slow").Code }()
+			<-started
+		}
+
+		w := post(gw, "/slow")
+		r.Equal(503, w.Code, "%s: Should reject requests once we're at capacity", test.name)
+		r.Equal("1", w.Header().Get("Retry-After"), test.name)
+		r.Contains(w.Body.String(), test.overload, test.name)
+
+		close(release)
+		r.Equal(200, <-results, test.name)
+		r.Equal(200, <-results, test.name)
+		r.Equal(200, post(gw, "/slow").Code, "%s: Should accept requests again once there's capacity", test.name)
+	}
+
+	started, release := make(chan struct{}, 3), make(chan struct{})
+	gw := newSlowGateway(2, started, release)
+	go post(gw, "/slow")
+	go post(gw, "/slow")
+	<-started
+	<-started
+	r.Equal(200, post(gw, "/fast").Code, "Other endpoints should not be affected by an endpoint's limit")
+	close(release)
+
+	started, release = make(chan struct{}, 3), make(chan struct{})
+	gw = newSlowGateway(1, started, release, rpc.WithConcurrencyLimit(0, 5*time.Second))
+	results := make(chan int, 1)
+	go func() { results <- post(gw, "/slow").Code }()
+	<-started
+	waiting := make(chan int, 1)
+	go func() { waiting <- post(gw, "/slow").Code }()
+	time.Sleep(50 * time.Millisecond)
+	release <- struct{}{}
+	r.Equal(200, <-results)
+	<-started
+	close(release)
+	r.Equal(200, <-waiting, "Should run the queued request once the first one finishes")
+
+	started, release = make(chan struct{}, 3), make(chan struct{})
+	gw = newSlowGateway(-1, started, release, rpc.WithConcurrencyLimit(1, 0))
+	results = make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() { results <- post(gw, "/slow").Code }()
+		<-started
+	}
+	close(release)
+	r.Equal(200, <-results)
+	r.Equal(200, <-results, "Unlimited endpoints should ignore the gateway's limit")
+
+	started, release = make(chan struct{}, 3), make(chan struct{})
+	defer close(release)
+	gw = newSlowGateway(0, started, release, rpc.WithConcurrencyLimit(1, 0))
+	go post(gw, "/slow")
+	<-started
+	r.Equal(503, post(gw, "/slow").Code, "Should inherit the gateway's limit")
+	r.Equal(200, post(gw, "/fast").Code, "Each endpoint should have its own slots")
+}
@@ -0,0 +1,85 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+// replyHost responds w/ the host that the request was sent to, so tests can see how the client dialed the server.
+func replyHost(w http.ResponseWriter, req *http.Request) {
+	rpc.Reply(w, req, 200, echoRequest{Text: "hello " + req.Host})
+}
+
+// Ensures that servers can listen on a Unix domain socket (replacing a stale socket file) and clients can call them.
+func (suite *GatewaySuite) TestServer_unixSocket() {
+	r := suite.Require()
+	dir, err := ioutil.TempDir("", "frodo")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "gw.sock")
+
+	// Simulate a process that crashed w/o cleaning up after itself.
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: socketPath, Net: "unix"})
+	r.NoError(err)
+	stale.SetUnlinkOnClose(false)
+	r.NoError(stale.Close())
+	r.FileExists(socketPath)
+
+	gw := suite.newGateway()
+	gw.Register(testEndpoint("GET", "/hello", "Hello", replyHost))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- rpc.NewServer("unix://"+socketPath, gw).Run(ctx)
+	}()
+
+	client := rpc.NewClient("TestService", "unix://"+socketPath)
+	r.Eventually(func() bool {
+		response := echoRequest{}
+		return client.Invoke(context.Background(), "GET", "/hello", nil, &response) == nil && response.Text == "hello localhost"
+	}, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+	r.NoError(suite.awaitShutdown(result))
+	r.NoFileExists(socketPath, "Should remove the socket file on shutdown")
+}
+
+// Ensures that clients can call a gateway through a memory listener and that dialing fails once it's closed.
+func (suite *GatewaySuite) TestServer_memoryListener() {
+	r := suite.Require()
+	listener := rpc.NewMemoryListener()
+	r.Equal("memory", listener.Addr().String())
+
+	gw := suite.newGateway()
+	gw.Register(testEndpoint("GET", "/hello", "Hello", replyHost))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- rpc.NewServer("", gw).Serve(ctx, listener)
+	}()
+
+	client := rpc.NewClient("TestService", "http://memory", rpc.WithDialContext(listener.DialContext))
+	for i := 0; i < 3; i++ {
+		response := echoRequest{}
+		r.NoError(client.Invoke(context.Background(), "GET", "/hello", nil, &response))
+		r.Equal("hello memory", response.Text)
+	}
+
+	cancel()
+	r.NoError(suite.awaitShutdown(result))
+
+	r.NoError(listener.Close())
+	_, err := listener.DialContext(context.Background(), "tcp", "memory")
+	r.Error(err)
+}
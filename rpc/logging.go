@@ -0,0 +1,252 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc/internal/reflection"
+	"github.com/monadicstack/frodo/rpc/tenant"
+	"github.com/urfave/negroni"
+)
+
+// PayloadLog describes a single call that the gateway sampled for payload logging (see WithPayloadLogging).
+type PayloadLog struct {
+	// ServiceName is the name of the service that handled the call (e.g. "UserService").
+	ServiceName string
+	// Name is the name of the function that handled the call (e.g. "GetUser").
+	Name string
+	// Method is the HTTP method of the request (e.g. "GET").
+	Method string
+	// Path is the actual URL path of the request (e.g. "/user/123").
+	Path string
+	// Tenant is the id of the tenant that the call was for (see the "rpc/tenant" package). It's blank when
+	// the call wasn't for any particular tenant or it failed before the gateway could bind it.
+	Tenant string
+	// Status is the HTTP status code that the gateway responded with.
+	Status int
+	// Duration is how long the gateway took to handle the call.
+	Duration time.Duration
+	// Request is the service request decoded from its JSON into generic maps/slices w/ its `frodo:"redact"` fields
+	// scrubbed. It's nil when the call failed before the gateway could bind it (e.g. malformed JSON).
+	Request interface{}
+	// Response is the service response decoded from its JSON into generic maps/slices w/ its `frodo:"redact"`
+	// fields scrubbed. It's nil when the call failed.
+	Response interface{}
+}
+
+// PayloadLogger receives the calls that the gateway sampled for payload logging (see WithPayloadLogging).
+type PayloadLogger func(ctx context.Context, entry PayloadLog)
+
+// WithPayloadLogging makes the gateway log the full request/response payloads for a random sample of calls, so
+// you can debug production issues using the actual data that went in and out of your service. The sample rate
+// is the fraction of calls to log (e.g. 0.01 logs 1% of them and 1 logs all of them).
+//
+// The logged payloads look just like the request/response JSON, except that every field tagged w/ `frodo:"redact"`
+// has the value "[REDACTED]", so passwords and PII never make it into your logs:
+//
+//     type CreateUserRequest struct {
+//         Email    string
+//         Password string `frodo:"redact"`
+//     }
+//
+// By default, we write each payload as JSON using the standard "log" package. Supply your own logger to send
+// them somewhere else (e.g. your structured logger of choice).
+func WithPayloadLogging(sampleRate float64, logger PayloadLogger) GatewayOption {
+	return func(gw *Gateway) {
+		if logger == nil {
+			logger = logPayload
+		}
+		gw.payloadLogging = &payloadLogging{sampleRate: sampleRate, logger: logger}
+	}
+}
+
+// payloadLogging is the configuration provided by WithPayloadLogging.
+type payloadLogging struct {
+	sampleRate float64
+	logger     PayloadLogger
+}
+
+// logPayload is the default PayloadLogger. It writes the call and its payloads to the standard logger.
+func logPayload(_ context.Context, entry PayloadLog) {
+	requestJSON, _ := json.Marshal(entry.Request)
+	responseJSON, _ := json.Marshal(entry.Response)
+	log.Printf("rpc: %s.%s %s %s %d (%s) tenant=%s request=%s response=%s",
+		entry.ServiceName,
+		entry.Name,
+		entry.Method,
+		entry.Path,
+		entry.Status,
+		entry.Duration,
+		entry.Tenant,
+		requestJSON,
+		responseJSON,
+	)
+}
+
+// logPayloads is gateway middleware that decides whether to sample each call. When it does, it gives the
+// handler a place to record the payloads (see OnRequestBound/OnResponseMarshal) and logs them once the
+// handler finishes. It runs after restoreEndpoint so that we know which operation the call is for.
+func logPayloads(logging *payloadLogging) MiddlewareFunc {
+	return func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		if logging == nil || rand.Float64() >= logging.sampleRate {
+			next(w, req)
+			return
+		}
+
+		start := time.Now()
+		payloads := &payloadCapture{}
+		next(w, req.WithContext(context.WithValue(req.Context(), contextKeyPayloadCapture{}, payloads)))
+
+		entry := payloads.finish()
+		entry.Method = req.Method
+		entry.Path = req.URL.Path
+		entry.Duration = time.Since(start)
+		if endpoint := EndpointFromContext(req.Context()); endpoint != nil {
+			entry.ServiceName = endpoint.ServiceName
+			entry.Name = endpoint.Name
+		}
+		if writer, ok := w.(negroni.ResponseWriter); ok {
+			entry.Status = writer.Status()
+		}
+		logging.logger(req.Context(), entry)
+	}
+}
+
+type contextKeyPayloadCapture struct{}
+
+// payloadCapture holds the redacted payloads of a call that we sampled for logging. ASYNC and SSE calls may
+// record their response after we've already logged the call, so we ignore anything recorded once it's done.
+type payloadCapture struct {
+	mutex    sync.Mutex
+	done     bool
+	tenant   string
+	request  interface{}
+	response interface{}
+}
+
+// finish stops recording payloads and returns the log entry w/ the ones that we captured.
+func (payloads *payloadCapture) finish() PayloadLog {
+	payloads.mutex.Lock()
+	defer payloads.mutex.Unlock()
+	payloads.done = true
+	return PayloadLog{Tenant: payloads.tenant, Request: payloads.request, Response: payloads.response}
+}
+
+// recordRequestPayload stores a redacted copy of the service request when we're logging this call's payloads. We
+// grab the tenant here, too, since your middleware (e.g. tenant.Middleware) has run by the time we bind the request.
+func recordRequestPayload(ctx context.Context, serviceRequest interface{}) {
+	if payloads, ok := ctx.Value(contextKeyPayloadCapture{}).(*payloadCapture); ok {
+		payloads.mutex.Lock()
+		defer payloads.mutex.Unlock()
+		if !payloads.done {
+			payloads.tenant = tenant.FromContext(ctx).ID
+			payloads.request = redactPayload(serviceRequest)
+		}
+	}
+}
+
+// recordResponsePayload stores a redacted copy of the service response when we're logging this call's payloads.
+func recordResponsePayload(ctx context.Context, serviceResponse interface{}) {
+	if payloads, ok := ctx.Value(contextKeyPayloadCapture{}).(*payloadCapture); ok {
+		payloads.mutex.Lock()
+		defer payloads.mutex.Unlock()
+		if !payloads.done {
+			payloads.response = redactPayload(serviceResponse)
+		}
+	}
+}
+
+// redactedValue replaces the values of fields tagged w/ `frodo:"redact"` in logged payloads.
+const redactedValue = "[REDACTED]"
+
+// redactPayload encodes the value as JSON and decodes it into generic maps/slices so that the logged payload looks
+// exactly like what went over the wire. Then we use the value's type to find the attributes for fields tagged w/
+// `frodo:"redact"` and replace them w/ "[REDACTED]". Since we can't tell what's in an interface{} field until
+// runtime, we can't redact anything inside of one.
+func redactPayload(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(valueJSON))
+	decoder.UseNumber()
+	var payload interface{}
+	if err = decoder.Decode(&payload); err != nil {
+		return nil
+	}
+	return redactJSON(payload, reflect.TypeOf(value))
+}
+
+// redactJSON replaces the redacted attributes in the decoded JSON of a value w/ the given type.
+func redactJSON(payload interface{}, t reflect.Type) interface{} {
+	t = reflection.FlattenPointerType(t)
+
+	// Types like time.Time that encode themselves don't look like the struct, so leave them alone.
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return payload
+	}
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return payload
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if attributes, ok := payload.(map[string]interface{}); ok {
+			redactJSONAttributes(attributes, t)
+		}
+	case reflect.Slice, reflect.Array:
+		if items, ok := payload.([]interface{}); ok {
+			for i := range items {
+				items[i] = redactJSON(items[i], t.Elem())
+			}
+		}
+	case reflect.Map:
+		if entries, ok := payload.(map[string]interface{}); ok {
+			for key := range entries {
+				entries[key] = redactJSON(entries[key], t.Elem())
+			}
+		}
+	}
+	return payload
+}
+
+// redactJSONAttributes redacts the attributes of a JSON object decoded from a struct of the given type. The fields
+// of embedded structs are flattened into the same object just like they are when encoding/json marshals them.
+func redactJSONAttributes(attributes map[string]interface{}, structType reflect.Type) {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		if field.Anonymous && (jsonTag == "" || jsonTag[0] == ',') {
+			if embeddedType := reflection.FlattenPointerType(field.Type); embeddedType.Kind() == reflect.Struct {
+				redactJSONAttributes(attributes, embeddedType)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := reflection.BindingName(field)
+		if _, ok := attributes[name]; !ok {
+			continue
+		}
+		if reflection.Redacted(field.Tag) {
+			attributes[name] = redactedValue
+			continue
+		}
+		attributes[name] = redactJSON(attributes[name], field.Type)
+	}
+}
@@ -0,0 +1,143 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/tenant"
+)
+
+type loggingCredentials struct {
+	Username string
+	Password string `json:"pw" frodo:"redact"`
+}
+
+type loggingRequest struct {
+	loggingCredentials
+	APIKey  string `frodo:"header=X-API-Key,redact"`
+	Aliases []loggingCredentials
+	Tags    map[string]string
+	Secret  *string `json:"secret,omitempty" frodo:"redact"`
+	Ignored string  `json:"-"`
+}
+
+type loggingResponse struct {
+	Token   string `frodo:"redact"`
+	Expires time.Time
+}
+
+// loggedPayloads collects the entries that the gateway logs.
+type loggedPayloads struct {
+	mutex   sync.Mutex
+	entries []rpc.PayloadLog
+}
+
+func (logged *loggedPayloads) log(_ context.Context, entry rpc.PayloadLog) {
+	logged.mutex.Lock()
+	defer logged.mutex.Unlock()
+	logged.entries = append(logged.entries, entry)
+}
+
+// Ensures that we log redacted copies of the payloads along w/ the details of the call (even when it fails before
+// we can bind the request), and that we only log the sampled fraction of calls.
+func (suite *GatewaySuite) TestWithPayloadLogging() {
+	r := suite.Require()
+
+	// The endpoint uses the interceptor hooks the same way that a generated gateway does.
+	newServer := func(options ...rpc.GatewayOption) *httptest.Server {
+		gw := suite.newGateway(options...)
+		gw.Register(testEndpoint("POST", "/login", "Login", func(w http.ResponseWriter, req *http.Request) {
+			serviceRequest := loggingRequest{}
+			if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			if err := gw.OnRequestBound(req.Context(), &serviceRequest); err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			serviceResponse := &loggingResponse{Token: "t0ps3cret", Expires: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)}
+			response, err := gw.OnResponseMarshal(req.Context(), serviceResponse)
+			if err != nil {
+				rpc.Fail(w, req, err)
+				return
+			}
+			rpc.Reply(w, req, 201, response)
+		}))
+		return httptest.NewServer(gw)
+	}
+	call := func(server *httptest.Server, body string) {
+		req, err := http.NewRequest("POST", server.URL+"/login", strings.NewReader(body))
+		r.NoError(err)
+		req.Header.Set("X-API-Key", "abc123")
+		req.Header.Set(tenant.Header, "acme")
+		res, err := suite.HTTPClient.Do(req)
+		r.NoError(err)
+		_ = res.Body.Close()
+	}
+
+	logged := &loggedPayloads{}
+	server := newServer(
+		rpc.WithPayloadLogging(1, logged.log),
+		rpc.WithMiddleware(tenant.Middleware(tenant.ByHeader(tenant.Header))),
+	)
+	defer server.Close()
+
+	call(server, `{"Username":"dude", "pw":"abides", "Aliases":[{"Username":"duder", "pw":"rug"}], "Tags":{"a":"b"}, "secret":"shh", "Ignored":"x"}`)
+	r.Len(logged.entries, 1)
+
+	entry := logged.entries[0]
+	r.Equal("TestService", entry.ServiceName)
+	r.Equal("Login", entry.Name)
+	r.Equal("POST", entry.Method)
+	r.Equal("/login", entry.Path)
+	r.Equal("acme", entry.Tenant)
+	r.Equal(201, entry.Status)
+	r.Greater(int64(entry.Duration), int64(0))
+	r.Equal(map[string]interface{}{
+		"Username": "dude",
+		"pw":       "[REDACTED]",
+		"APIKey":   "[REDACTED]",
+		"Aliases": []interface{}{
+			map[string]interface{}{"Username": "duder", "pw": "[REDACTED]"},
+		},
+		"Tags":   map[string]interface{}{"a": "b"},
+		"secret": "[REDACTED]",
+	}, entry.Request)
+	r.Equal(map[string]interface{}{
+		"Token":   "[REDACTED]",
+		"Expires": "2027-01-01T00:00:00Z",
+	}, entry.Response)
+
+	call(server, `{"Username":`)
+	r.Len(logged.entries, 2)
+	r.Equal(400, logged.entries[1].Status, "Should still log calls that fail before we bind the request")
+	r.Nil(logged.entries[1].Request)
+	r.Nil(logged.entries[1].Response)
+
+	tests := []struct {
+		rate float64
+		min  int
+		max  int
+	}{
+		{rate: 0, min: 0, max: 0},
+		{rate: 0.5, min: 1, max: 199},
+	}
+	for _, test := range tests {
+		logged = &loggedPayloads{}
+		server = newServer(rpc.WithPayloadLogging(test.rate, logged.log))
+		for i := 0; i < 200; i++ {
+			call(server, `{"Username":"dude"}`)
+		}
+		server.Close()
+		r.GreaterOrEqual(len(logged.entries), test.min, "Sample rate %v", test.rate)
+		r.LessOrEqual(len(logged.entries), test.max, "Sample rate %v", test.rate)
+	}
+}
@@ -0,0 +1,126 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// BaggageHeader is the W3C Baggage header (https://www.w3.org/TR/baggage/) that OpenTelemetry and other standard
+// propagators use to pass key/value pairs from service to service. Frodo gateways turn its entries into metadata
+// values and clients send your metadata as baggage (alongside X-RPC-Values) so that non-frodo services can
+// participate in propagation, too.
+const BaggageHeader = "Baggage"
+
+// The limits that the W3C Baggage spec places on a single header. We drop entries that don't fit rather than
+// sending a header that other services would reject outright.
+const (
+	maxBaggageMembers = 180
+	maxBaggageBytes   = 8192
+)
+
+// ToBaggage serializes the context's metadata values as the value of a W3C "Baggage" header. String values are
+// sent as-is while everything else is sent as its JSON (e.g. 42 or {"ID":"123"}). Keys that aren't valid
+// baggage keys and entries that would push the header past the spec's size limits are left out, but they're
+// still sent in the X-RPC-Values header.
+func ToBaggage(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	meta := fromContext(ctx)
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		if isBaggageKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	members := make([]string, 0, len(keys))
+	size := 0
+	for _, key := range keys {
+		value, ok := meta[key].baggageValue()
+		if !ok {
+			continue
+		}
+		member := key + "=" + url.PathEscape(value)
+		if len(members) > 0 {
+			size++ // the comma separating this member from the previous one
+		}
+		if len(members) == maxBaggageMembers || size+len(member) > maxBaggageBytes {
+			break
+		}
+		members = append(members, member)
+		size += len(member)
+	}
+	return strings.Join(members, ",")
+}
+
+// FromBaggage rebuilds a Values map from the entries in a W3C "Baggage" header. Baggage values are always strings,
+// so you should look them up using a string 'out' value. Malformed entries are ignored, as are any properties
+// that follow an entry's value (e.g. "userId=123;sampled").
+func FromBaggage(baggageHeader string) Values {
+	meta := Values{}
+	for _, member := range strings.Split(baggageHeader, ",") {
+		if propertyIndex := strings.Index(member, ";"); propertyIndex >= 0 {
+			member = member[:propertyIndex]
+		}
+		equalsIndex := strings.Index(member, "=")
+		if equalsIndex < 0 {
+			continue
+		}
+		key := strings.TrimSpace(member[:equalsIndex])
+		if !isBaggageKey(key) {
+			continue
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(member[equalsIndex+1:]))
+		if err != nil {
+			continue
+		}
+		valueJSON, _ := json.Marshal(value)
+		meta[key] = valuesEntry{JSON: string(valueJSON)}
+	}
+	return meta
+}
+
+// baggageValue converts the entry into the plain text we send in a "Baggage" header.
+func (v valuesEntry) baggageValue() (string, bool) {
+	// We received this value from another service, but nobody has looked it up yet. If it's a JSON
+	// string, send the string itself rather than its quoted JSON.
+	if v.Value == nil && v.JSON != "" {
+		var text string
+		if err := json.Unmarshal([]byte(v.JSON), &text); err == nil {
+			return text, true
+		}
+		return v.JSON, true
+	}
+	if text, ok := v.Value.(string); ok {
+		return text, true
+	}
+	if text, ok := v.Value.(*string); ok && text != nil {
+		return *text, true
+	}
+	valueJSON, err := json.Marshal(v.Value)
+	if err != nil {
+		return "", false
+	}
+	return string(valueJSON), true
+}
+
+// isBaggageKey determines if the metadata key is a valid baggage key (an RFC 7230 token).
+func isBaggageKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, ch := range key {
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", ch):
+		default:
+			return false
+		}
+	}
+	return true
+}
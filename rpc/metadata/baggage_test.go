@@ -0,0 +1,79 @@
+// +build unit
+
+package metadata_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/monadicstack/frodo/rpc/metadata"
+	"github.com/stretchr/testify/suite"
+)
+
+type BaggageSuite struct {
+	suite.Suite
+}
+
+// Ensures that strings are sent as-is, everything else is sent as JSON, and values are escaped properly.
+func (suite *BaggageSuite) TestToBaggage() {
+	r := suite.Require()
+	r.Equal("", metadata.ToBaggage(nil))
+	r.Equal("", metadata.ToBaggage(context.Background()))
+
+	ctx := context.Background()
+	ctx = metadata.WithValue(ctx, "userId", "alice")
+	ctx = metadata.WithValue(ctx, "count", 42)
+	ctx = metadata.WithValue(ctx, "query", "a b,c;d")
+	ctx = metadata.WithValue(ctx, "not valid", "skipped")
+	r.Equal(`count=42,query=a%20b%2Cc%3Bd,userId=alice`, metadata.ToBaggage(ctx))
+}
+
+// Ensures that we don't build headers that exceed the W3C limits.
+func (suite *BaggageSuite) TestToBaggage_limits() {
+	r := suite.Require()
+
+	ctx := context.Background()
+	for i := 0; i < 200; i++ {
+		ctx = metadata.WithValue(ctx, fmt.Sprintf("key%03d", i), "value")
+	}
+	r.Len(strings.Split(metadata.ToBaggage(ctx), ","), 180)
+
+	ctx = metadata.WithValue(context.Background(), "a", strings.Repeat("x", 5000))
+	ctx = metadata.WithValue(ctx, "b", strings.Repeat("x", 5000))
+	r.Equal("a="+strings.Repeat("x", 5000), metadata.ToBaggage(ctx))
+}
+
+// Ensures that we can read well-formed entries while ignoring properties and junk.
+func (suite *BaggageSuite) TestFromBaggage() {
+	r := suite.Require()
+
+	values := metadata.FromBaggage(` userId = alice ;sampled, query=a%20b%2Cc, junk, =empty, bad=%zz`)
+	ctx := metadata.WithValues(context.Background(), values)
+	r.Equal([]string{"query", "userId"}, metadata.Keys(ctx))
+
+	userID, query := "", ""
+	r.True(metadata.Value(ctx, "userId", &userID))
+	r.Equal("alice", userID)
+	r.True(metadata.Value(ctx, "query", &query))
+	r.Equal("a b,c", query)
+
+	r.Empty(metadata.FromBaggage(""))
+}
+
+// Ensures that values we received as baggage are forwarded as the same plain text.
+func (suite *BaggageSuite) TestFromBaggage_roundTrip() {
+	r := suite.Require()
+
+	ctx := metadata.WithValues(context.Background(), metadata.FromBaggage("userId=alice,query=a%20b"))
+	r.Equal("query=a%20b,userId=alice", metadata.ToBaggage(ctx))
+
+	var userID string
+	metadata.Value(ctx, "userId", &userID)
+	r.Equal("query=a%20b,userId=alice", metadata.ToBaggage(ctx))
+}
+
+func TestBaggageSuite(t *testing.T) {
+	suite.Run(t, new(BaggageSuite))
+}
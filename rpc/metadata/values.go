@@ -15,8 +15,9 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"sort"
 
-	"github.com/monadicstack/frodo/internal/reflection"
+	"github.com/monadicstack/frodo/rpc/internal/reflection"
 )
 
 // The context value entry for our metadata map.
@@ -61,6 +62,14 @@ type valuesEntry struct {
 func (v valuesEntry) MarshalJSON() ([]byte, error) {
 	buf := &bytes.Buffer{}
 	buf.WriteString(`{"value":`)
+
+	// We received this value from another service, but nobody has looked it up yet, so we still
+	// don't know its type. Just pass along the JSON we received so that it isn't lost.
+	if v.Value == nil && v.JSON != "" {
+		buf.WriteString(v.JSON)
+		buf.WriteString(`}`)
+		return buf.Bytes(), nil
+	}
 	err := json.NewEncoder(buf).Encode(v.Value)
 	if err != nil {
 		return nil, err
@@ -105,14 +114,8 @@ func Value(ctx context.Context, key string, out interface{}) bool {
 		return false
 	}
 
-	// Make sure that we even *have* scope values on the context first.
-	scope, ok := ctx.Value(contextKey{}).(Values)
-	if !ok {
-		return false
-	}
-
-	// We have a scope but nothing for this key
-	entry, ok := scope[key]
+	// Nothing for this key (or no metadata at all; a nil map is still safe to read)
+	entry, ok := fromContext(ctx)[key]
 	if !ok {
 		return false
 	}
@@ -134,7 +137,8 @@ func Value(ctx context.Context, key string, out interface{}) bool {
 }
 
 // WithValue stores a key/value pair in the context metadata. It returns a new context that contains
-// the metadata map with your value.
+// the metadata map with your value. The metadata on the original context is left alone, so it's safe
+// to call this from multiple goroutines that share the same parent context.
 func WithValue(ctx context.Context, key string, value interface{}) context.Context {
 	if ctx == nil {
 		return ctx
@@ -142,32 +146,74 @@ func WithValue(ctx context.Context, key string, value interface{}) context.Conte
 	if key == "" {
 		return ctx
 	}
-	meta, ok := ctx.Value(contextKey{}).(Values)
-	if !ok || meta == nil {
-		meta = Values{}
-		ctx = WithValues(ctx, meta)
-	}
-
-	// At some point I would love for this to be not rely on side-effects and mutating a map. I'd
-	// rather that adding new values create copies of the metadata structure so it's more thread
-	// safe like normal context values. But this will work for now...
+	meta := fromContext(ctx).clone()
 	meta[key] = valuesEntry{Value: value}
-	return ctx
+	return WithValues(ctx, meta)
 }
 
 // WithValues does a wholesale replacement of ALL metadata values stored on the context. Usually you
 // will not call this yourself - you should interact with individual values via Value()/WithValue(). This
 // is typically just used by frodo internals to preserve your metadata across RPC calls.
 func WithValues(ctx context.Context, meta Values) context.Context {
+	if meta == nil {
+		meta = Values{}
+	}
 	return context.WithValue(ctx, contextKey{}, meta)
 }
 
+// Merge copies all of the metadata values from 'other' onto the context. When both contexts have a value for
+// the same key, the value from 'other' wins; just as if you called WithValue() for each of its values. This
+// is handy when you need to carry the metadata from a request over to a context that outlives it (e.g. a
+// background goroutine using context.Background()). It returns a new context, leaving both originals alone.
+func Merge(ctx context.Context, other context.Context) context.Context {
+	if ctx == nil || other == nil {
+		return ctx
+	}
+	otherMeta := fromContext(other)
+	if len(otherMeta) == 0 {
+		return ctx
+	}
+	meta := fromContext(ctx).clone()
+	for key, entry := range otherMeta {
+		meta[key] = entry
+	}
+	return WithValues(ctx, meta)
+}
+
+// Keys returns the (sorted) names of all of the metadata values on the context.
+func Keys(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+	meta := fromContext(ctx)
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fromContext returns the metadata on the context. It's nil if there isn't any, which is still safe to read.
+func fromContext(ctx context.Context) Values {
+	meta, _ := ctx.Value(contextKey{}).(Values)
+	return meta
+}
+
+// clone creates a shallow copy of the metadata that we can modify w/o affecting other contexts.
+func (meta Values) clone() Values {
+	result := make(Values, len(meta)+1)
+	for key, entry := range meta {
+		result[key] = entry
+	}
+	return result
+}
+
 // ToJSON serializes all of the metadata values into a single JSON string. You won't usually call this
 // yourself as this is mainly used to set the X-RPC-Values header when making RPC calls to preserve your
 // data across RPC calls.
 func ToJSON(ctx context.Context) (string, error) {
-	meta, _ := ctx.Value(contextKey{}).(Values)
-	metaJSON, err := json.Marshal(meta)
+	metaJSON, err := json.Marshal(fromContext(ctx))
 	return string(metaJSON), err
 }
 
@@ -180,6 +226,11 @@ func FromJSON(rpcValuesHeader string) (Values, error) {
 	}
 
 	meta := Values{}
-	err := json.Unmarshal([]byte(rpcValuesHeader), &meta)
-	return meta, err
+	if err := json.Unmarshal([]byte(rpcValuesHeader), &meta); err != nil {
+		return Values{}, err
+	}
+	if meta == nil {
+		return Values{}, nil // the header was "null"
+	}
+	return meta, nil
 }
@@ -126,6 +126,28 @@ func (suite *ValuesSuite) TestValues_json() {
 	suite.assertStruct(b, testCase{key: "struct", expect: structValue{Name: "Kid", Age: 12}, expectOK: true})
 }
 
+// Ensure that values we received but never looked up still survive when we send them along to another service.
+func (suite *ValuesSuite) TestValues_json_forward() {
+	a := context.Background()
+	a = metadata.WithValue(a, "string", "12345")
+	a = metadata.WithValue(a, "struct", structValue{Name: "Kid", Age: 12})
+	valueJSON, err := metadata.ToJSON(a)
+	suite.Require().NoError(err)
+
+	values, err := metadata.FromJSON(valueJSON)
+	suite.Require().NoError(err)
+	b := metadata.WithValues(context.Background(), values)
+	suite.assertString(b, testCase{key: "string", expect: "12345", expectOK: true})
+
+	valueJSON, err = metadata.ToJSON(b)
+	suite.Require().NoError(err)
+	values, err = metadata.FromJSON(valueJSON)
+	suite.Require().NoError(err)
+	c := metadata.WithValues(context.Background(), values)
+	suite.assertString(c, testCase{key: "string", expect: "12345", expectOK: true})
+	suite.assertStruct(c, testCase{key: "struct", expect: structValue{Name: "Kid", Age: 12}, expectOK: true})
+}
+
 // When transporting metadata from a context w/ no values, don't fail, just keep it empty.
 func (suite *ValuesSuite) TestValues_json_noValues() {
 	a := context.Background()
@@ -174,6 +196,94 @@ func (suite *ValuesSuite) TestValues_json_invalid() {
 	suite.Require().Error(err, "Should return an error when value contains a type that can't be marshaled")
 }
 
+// Ensures that adding values never modifies the metadata on the parent context.
+func (suite *ValuesSuite) TestWithValue_clone() {
+	parent := metadata.WithValue(context.Background(), "string", "12345")
+	a := metadata.WithValue(parent, "string", "a")
+	b := metadata.WithValue(parent, "int", 9999)
+
+	suite.assertString(parent, testCase{key: "string", expect: "12345", expectOK: true})
+	suite.assertInt(parent, testCase{key: "int", expect: 0, expectOK: false})
+	suite.assertString(a, testCase{key: "string", expect: "a", expectOK: true})
+	suite.assertInt(a, testCase{key: "int", expect: 0, expectOK: false})
+	suite.assertString(b, testCase{key: "string", expect: "12345", expectOK: true})
+	suite.assertInt(b, testCase{key: "int", expect: 9999, expectOK: true})
+
+	// Should be safe to add values from multiple goroutines that share a parent.
+	done := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		go func(i int) {
+			defer func() { done <- struct{}{} }()
+			metadata.WithValue(parent, "int", i)
+		}(i)
+	}
+	for i := 0; i < 10; i++ {
+		<-done
+	}
+	suite.assertInt(parent, testCase{key: "int", expect: 0, expectOK: false})
+}
+
+// Ensures that nil metadata maps behave just like empty ones rather than panicking.
+func (suite *ValuesSuite) TestWithValues_nil() {
+	ctx := metadata.WithValues(context.Background(), nil)
+	suite.assertString(ctx, testCase{key: "string", expect: "", expectOK: false})
+	suite.Empty(metadata.Keys(ctx))
+
+	ctx = metadata.WithValue(ctx, "string", "12345")
+	suite.assertString(ctx, testCase{key: "string", expect: "12345", expectOK: true})
+
+	values, err := metadata.FromJSON("null")
+	suite.Require().NoError(err)
+	suite.Require().NotNil(values)
+}
+
+// Ensures that merging copies all of the values and that the other context's values win.
+func (suite *ValuesSuite) TestMerge() {
+	a := context.Background()
+	a = metadata.WithValue(a, "string", "a")
+	a = metadata.WithValue(a, "int", 9999)
+
+	b := context.Background()
+	b = metadata.WithValue(b, "string", "b")
+	b = metadata.WithValue(b, "bool", true)
+
+	merged := metadata.Merge(a, b)
+	suite.assertString(merged, testCase{key: "string", expect: "b", expectOK: true})
+	suite.assertInt(merged, testCase{key: "int", expect: 9999, expectOK: true})
+	suite.assertBool(merged, testCase{key: "bool", expect: true, expectOK: true})
+	suite.Equal([]string{"bool", "int", "string"}, metadata.Keys(merged))
+
+	// Neither of the originals should change.
+	suite.Equal([]string{"int", "string"}, metadata.Keys(a))
+	suite.Equal([]string{"bool", "string"}, metadata.Keys(b))
+	suite.assertString(a, testCase{key: "string", expect: "a", expectOK: true})
+
+	// Values we received over the wire should still be around after merging.
+	valueJSON, err := metadata.ToJSON(b)
+	suite.Require().NoError(err)
+	values, err := metadata.FromJSON(valueJSON)
+	suite.Require().NoError(err)
+	merged = metadata.Merge(a, metadata.WithValues(context.Background(), values))
+	suite.assertString(merged, testCase{key: "string", expect: "b", expectOK: true})
+	suite.assertBool(merged, testCase{key: "bool", expect: true, expectOK: true})
+
+	suite.Nil(metadata.Merge(nil, b))
+	suite.Equal(a, metadata.Merge(a, nil))
+	suite.Equal(a, metadata.Merge(a, context.Background()))
+}
+
+// Ensures that we can list the names of all of the values on the context.
+func (suite *ValuesSuite) TestKeys() {
+	suite.Nil(metadata.Keys(nil))
+	suite.Empty(metadata.Keys(context.Background()))
+
+	ctx := context.Background()
+	ctx = metadata.WithValue(ctx, "b", 1)
+	ctx = metadata.WithValue(ctx, "a", 2)
+	ctx = metadata.WithValue(ctx, "c", 3)
+	suite.Equal([]string{"a", "b", "c"}, metadata.Keys(ctx))
+}
+
 func (suite *ValuesSuite) assertString(ctx context.Context, c testCase) {
 	var out string
 	ok := metadata.Value(ctx, c.key, &out)
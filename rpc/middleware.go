@@ -2,6 +2,7 @@ package rpc
 
 import (
 	"net/http"
+	"sort"
 )
 
 /* ----- SERVER MIDDLEWARE ----- */
@@ -9,10 +10,75 @@ import (
 // WithMiddleware invokes this chain of work before executing the actual HTTP handler for your service call. Any
 // functions yu supply here will fire before your service function, but after built-in middleware functions such
 // as the one that restores metadata (i.e. your middleware will have access to request/context metadata).
+//
+// This is the same as an unnamed middleware group w/ a priority of 0 (see WithMiddlewareGroup).
 func WithMiddleware(mw ...MiddlewareFunc) GatewayOption {
+	return WithMiddlewareGroup("", 0, mw...)
+}
+
+// WithMiddlewareGroup registers a named chain of middleware w/ a priority that determines where it fires relative
+// to your other middleware. Groups w/ higher priorities run first (closer to the caller), and groups w/ the same
+// priority run in the order you supplied them. Middleware from WithMiddleware() has a priority of 0, so:
+//
+//     gateway := usersrpc.NewUserServiceGateway(service,
+//         rpc.WithMiddleware(logRequests),                 // runs 2nd
+//         rpc.WithMiddlewareGroup("auth", 10, validateJWT), // runs 1st
+//         rpc.WithMiddlewareGroup("metrics", -10, timer),   // runs 3rd
+//     )
+//
+// Naming the group lets individual functions opt out of it using the "SKIP-MIDDLEWARE" doc option (e.g.
+// "SKIP-MIDDLEWARE auth" for a public health check). Registering a group w/ the same name again replaces it.
+func WithMiddlewareGroup(name string, priority int, mw ...MiddlewareFunc) GatewayOption {
 	return func(gw *Gateway) {
-		gw.middleware = mw
+		group := middlewareGroup{name: name, priority: priority, pipeline: mw}
+		for i, existing := range gw.middlewareGroups {
+			if existing.name == name {
+				gw.middlewareGroups[i] = group
+				return
+			}
+		}
+		gw.middlewareGroups = append(gw.middlewareGroups, group)
+	}
+}
+
+// middlewareGroup is a chain of middleware that you registered via WithMiddleware/WithMiddlewareGroup.
+type middlewareGroup struct {
+	name     string
+	priority int
+	pipeline middlewarePipeline
+}
+
+// middlewareGroups are all of the groups of user-supplied middleware for a gateway.
+type middlewareGroups []middlewareGroup
+
+// sorted returns a copy of the groups, ordered by priority (highest first).
+func (groups middlewareGroups) sorted() middlewareGroups {
+	results := append(middlewareGroups{}, groups...)
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].priority > results[j].priority
+	})
+	return results
+}
+
+// pipeline flattens the groups into a single pipeline, leaving out any of the named groups in 'skip'.
+func (groups middlewareGroups) pipeline(skip []string) middlewarePipeline {
+	var results middlewarePipeline
+	for _, group := range groups {
+		if group.name != "" && containsString(skip, group.name) {
+			continue
+		}
+		results = append(results, group.pipeline...)
+	}
+	return results
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
+	return false
 }
 
 // MiddlewareFunc is a component that conforms to the 'negroni' middleware function. It accepts the
@@ -0,0 +1,137 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Union encodes/decodes a struct that uses the "ONEOF" doc option. The struct embeds a pointer to each of the
+// shapes that it can take, and exactly one of them should be set:
+//
+//     // SearchResult is either a user or a group that matches the query.
+//     //
+//     // ONEOF kind
+//     type SearchResult struct {
+//         *UserMatch
+//         *GroupMatch
+//     }
+//
+// In JSON, the union is just the alternative that is set, plus a "discriminator" attribute w/ the name of the
+// alternative's type (e.g. {"kind":"UserMatch", "ID":"123", "Name":"Dude"}). When decoding, we use that attribute
+// to figure out which of the alternatives to populate. If it's missing or unrecognized, none of them are set.
+type Union struct {
+	value         interface{}
+	discriminator string
+}
+
+// OneOf wraps the "ONEOF" union value so that it's encoded/decoded using the given discriminator attribute. Generated
+// gateways and clients do this for you, so you only need it when you encode/decode a union on your own. If the value
+// isn't a struct, it's encoded/decoded as-is.
+func OneOf(value interface{}, discriminator string) *Union {
+	return &Union{value: value, discriminator: discriminator}
+}
+
+// MarshalJSON encodes the alternative that is set along w/ the discriminator attribute. It fails if more than one
+// alternative is set. When none of them are, you get an empty object.
+func (u *Union) MarshalJSON() ([]byte, error) {
+	unionValue, ok := u.structValue()
+	if !ok {
+		return json.Marshal(u.value)
+	}
+
+	var name string
+	var alternative reflect.Value
+	for _, field := range unionAlternatives(unionValue.Type()) {
+		fieldValue := unionValue.Field(field.Index[0])
+		if fieldValue.IsNil() {
+			continue
+		}
+		if name != "" {
+			return nil, fmt.Errorf("rpc: union %s has multiple alternatives set: %s, %s", unionValue.Type(), name, field.Name)
+		}
+		name, alternative = field.Name, fieldValue
+	}
+	if name == "" {
+		return []byte("{}"), nil
+	}
+
+	alternativeJSON, err := json.Marshal(alternative.Interface())
+	if err != nil {
+		return nil, err
+	}
+	alternativeJSON = bytes.TrimSpace(alternativeJSON)
+	if len(alternativeJSON) < 2 || alternativeJSON[0] != '{' {
+		return nil, fmt.Errorf("rpc: union %s alternative %s is not a JSON object", unionValue.Type(), name)
+	}
+
+	keyJSON, _ := json.Marshal(u.discriminator)
+	nameJSON, _ := json.Marshal(name)
+	buf := bytes.Buffer{}
+	buf.WriteByte('{')
+	buf.Write(keyJSON)
+	buf.WriteByte(':')
+	buf.Write(nameJSON)
+	if body := bytes.TrimSpace(alternativeJSON[1:]); len(body) > 0 && body[0] != '}' {
+		buf.WriteByte(',')
+		buf.Write(body)
+	} else {
+		buf.WriteByte('}')
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON populates the alternative named by the discriminator attribute and clears all of the others.
+func (u *Union) UnmarshalJSON(data []byte) error {
+	unionValue, ok := u.structValue()
+	if !ok || !unionValue.CanSet() {
+		return json.Unmarshal(data, u.value)
+	}
+
+	attributes := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return err
+	}
+	name := ""
+	_ = json.Unmarshal(attributes[u.discriminator], &name)
+
+	for _, field := range unionAlternatives(unionValue.Type()) {
+		fieldValue := unionValue.Field(field.Index[0])
+		if field.Name != name {
+			fieldValue.Set(reflect.Zero(field.Type))
+			continue
+		}
+		alternative := reflect.New(field.Type.Elem())
+		if err := json.Unmarshal(data, alternative.Interface()); err != nil {
+			return err
+		}
+		fieldValue.Set(alternative)
+	}
+	return nil
+}
+
+// structValue dereferences the union value, returning false if it's not a struct (or a non-nil pointer to one).
+func (u *Union) structValue() (reflect.Value, bool) {
+	value := reflect.ValueOf(u.value)
+	if value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	return value, value.Kind() == reflect.Struct
+}
+
+// unionAlternatives returns the exported, embedded struct pointers of the union struct type.
+func unionAlternatives(t reflect.Type) []reflect.StructField {
+	var alternatives []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.Anonymous || field.PkgPath != "" || field.Type.Kind() != reflect.Ptr {
+			continue
+		}
+		if field.Type.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		alternatives = append(alternatives, field)
+	}
+	return alternatives
+}
@@ -0,0 +1,97 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+type UserMatch struct {
+	ID   string
+	Name string
+}
+
+type GroupMatch struct {
+	ID      string
+	Members int
+}
+
+type searchResult struct {
+	*UserMatch
+	*GroupMatch
+}
+
+// Ensures that we encode the alternative that is set along w/ the discriminator.
+func (suite *GatewaySuite) TestOneOf_marshal() {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected string
+		err      bool
+	}{
+		{name: "pointer", value: rpc.OneOf(&searchResult{UserMatch: &UserMatch{ID: "1", Name: "Dude"}}, "kind"), expected: `{"kind":"UserMatch","ID":"1","Name":"Dude"}`},
+		{name: "value", value: rpc.OneOf(searchResult{GroupMatch: &GroupMatch{}}, "type"), expected: `{"type":"GroupMatch","ID":"","Members":0}`},
+		{name: "none", value: rpc.OneOf(&searchResult{}, "kind"), expected: `{}`},
+		{name: "should encode non-structs as-is", value: rpc.OneOf([]int{1, 2}, "kind"), expected: `[1,2]`},
+		{name: "should fail when multiple alternatives are set", value: rpc.OneOf(&searchResult{UserMatch: &UserMatch{}, GroupMatch: &GroupMatch{}}, "kind"), err: true},
+	}
+	for _, test := range tests {
+		r := suite.Require()
+		data, err := json.Marshal(test.value)
+		if test.err {
+			r.Error(err, test.name)
+			continue
+		}
+		r.NoError(err, test.name)
+		r.Equal(test.expected, string(data), test.name)
+	}
+}
+
+// Ensures that we only populate the alternative named by the discriminator.
+func (suite *GatewaySuite) TestOneOf_unmarshal() {
+	tests := []struct {
+		name     string
+		data     string
+		expected searchResult
+		err      bool
+	}{
+		{name: "alternative", data: `{"kind":"GroupMatch","ID":"2","Members":5}`, expected: searchResult{GroupMatch: &GroupMatch{ID: "2", Members: 5}}},
+		{name: "should ignore unknown alternatives", data: `{"kind":"NopeMatch","ID":"2"}`},
+		{name: "should ignore missing discriminators", data: `{"ID":"2"}`},
+		{name: "not an object", data: `[1, 2]`, err: true},
+	}
+	for _, test := range tests {
+		r := suite.Require()
+		result := searchResult{UserMatch: &UserMatch{ID: "old"}}
+		err := json.Unmarshal([]byte(test.data), rpc.OneOf(&result, "kind"))
+		if test.err {
+			r.Error(err, test.name)
+			continue
+		}
+		r.NoError(err, test.name)
+		r.Equal(test.expected, result, test.name)
+	}
+}
+
+// Ensures that a union survives the round trip from the gateway to the client.
+func (suite *GatewaySuite) TestOneOf_client() {
+	r := suite.Require()
+	gw := suite.newGateway()
+	gw.Register(testEndpoint("GET", "/search", "Search", func(w http.ResponseWriter, req *http.Request) {
+		response := &searchResult{UserMatch: &UserMatch{ID: "1", Name: "Dude"}}
+		rpc.Reply(w, req, 200, rpc.OneOf(response, "kind"))
+	}))
+	server := httptest.NewServer(gw)
+	defer server.Close()
+
+	response := &searchResult{}
+	client := rpc.NewClient("TestService", server.URL)
+	r.NoError(client.Invoke(context.Background(), "GET", "/search", nil, rpc.OneOf(response, "kind")))
+	r.Equal(&UserMatch{ID: "1", Name: "Dude"}, response.UserMatch)
+	r.Nil(response.GroupMatch)
+}
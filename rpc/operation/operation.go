@@ -0,0 +1,85 @@
+// Package operation tags every request w/ a unique operation id so that you can correlate everything that
+// happened as a result of that request: log entries, metrics, and error responses. The id travels w/ the
+// request metadata, so when one service calls another, both services use the same operation id.
+//
+//     gateway := gamesrpc.NewGameServiceGateway(service, rpc.WithMiddleware(
+//         operation.Middleware(ids.New),
+//     ))
+//
+//     // ... then in your handler, or any service it calls ...
+//     log.Printf("[%s] registering game", operation.ID(ctx))
+//
+// The gateway also includes the id in the "X-Operation-ID" response header as well as the body of
+// error responses, so when a user reports a 500, they can give you the id to look up in your logs.
+package operation
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/monadicstack/frodo/rpc/ids"
+	"github.com/monadicstack/frodo/rpc/metadata"
+)
+
+// Header is the HTTP header that contains the operation id in both requests and responses.
+const Header = "X-Operation-ID"
+
+// MetadataKey is the metadata entry where we store the operation id so that it follows the request.
+const MetadataKey = "frodo.operationId"
+
+// maxLength is the longest operation id we'll accept from the caller via the request header.
+const maxLength = 128
+
+// Middleware creates gateway middleware that makes sure that every request has an operation id. We'll reuse
+// the id from an upstream frodo service or the "X-Operation-ID" request header if there is one. Otherwise,
+// we'll create a new one using the generator (or ids.New if you don't supply one).
+func Middleware(generator ids.Generator) func(http.ResponseWriter, *http.Request, http.HandlerFunc) {
+	if generator == nil {
+		generator = ids.New
+	}
+
+	return func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		ctx := req.Context()
+		id := ID(ctx)
+		if id == "" {
+			id = fromHeader(req)
+		}
+		if id == "" {
+			id = generator()
+		}
+
+		w.Header().Set(Header, id)
+		next(w, req.WithContext(WithID(ctx, id)))
+	}
+}
+
+// ID returns the operation id for the current request. This returns "" if the request doesn't
+// have one (i.e. you're not using the operation Middleware).
+func ID(ctx context.Context) string {
+	id := ""
+	metadata.Value(ctx, MetadataKey, &id)
+	return id
+}
+
+// WithID forcibly sets the operation id for the current request and anything downstream of it.
+func WithID(ctx context.Context, id string) context.Context {
+	if ctx == nil {
+		return ctx
+	}
+	return metadata.WithValue(ctx, MetadataKey, id)
+}
+
+// fromHeader returns the operation id supplied by the caller. Since the id ends up in our logs, we
+// only accept reasonably sized values that contain printable, non-space ASCII characters.
+func fromHeader(req *http.Request) string {
+	id := req.Header.Get(Header)
+	if len(id) > maxLength {
+		return ""
+	}
+	for _, ch := range id {
+		if ch <= ' ' || ch > '~' {
+			return ""
+		}
+	}
+	return id
+}
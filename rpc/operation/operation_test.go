@@ -0,0 +1,93 @@
+// +build unit
+
+package operation_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/monadicstack/frodo/rpc/metadata"
+	"github.com/monadicstack/frodo/rpc/operation"
+	"github.com/stretchr/testify/suite"
+)
+
+type OperationSuite struct {
+	suite.Suite
+}
+
+// Ensures that WithID/ID round trip through the context metadata.
+func (suite *OperationSuite) TestWithID() {
+	suite.Require().Equal("", operation.ID(context.Background()))
+	suite.Require().Equal("", operation.ID(nil))
+
+	ctx := operation.WithID(context.Background(), "abc")
+	suite.Require().Equal("abc", operation.ID(ctx))
+	suite.Require().Equal("def", operation.ID(operation.WithID(ctx, "def")))
+}
+
+// Ensures that the operation id survives the trip through the X-RPC-Values header to a downstream service.
+func (suite *OperationSuite) TestID_metadataRoundTrip() {
+	encoded, err := metadata.ToJSON(operation.WithID(context.Background(), "abc"))
+	suite.Require().NoError(err)
+
+	values, err := metadata.FromJSON(encoded)
+	suite.Require().NoError(err)
+	suite.Require().Equal("abc", operation.ID(metadata.WithValues(context.Background(), values)))
+}
+
+// Ensures that the middleware generates new ids when the request doesn't already have one.
+func (suite *OperationSuite) TestMiddleware_generate() {
+	id, w := suite.invoke(httptest.NewRequest("GET", "/foo", nil), func() string { return "generated" })
+	suite.Require().Equal("generated", id)
+	suite.Require().Equal("generated", w.Header().Get(operation.Header))
+
+	// A nil generator should fall back to the default id generator.
+	id, w = suite.invoke(httptest.NewRequest("GET", "/foo", nil), nil)
+	suite.Require().Len(id, 36)
+	suite.Require().Equal(id, w.Header().Get(operation.Header))
+}
+
+// Ensures that the middleware reuses the id from an upstream service's metadata or the request header.
+func (suite *OperationSuite) TestMiddleware_reuse() {
+	generator := func() string { return "generated" }
+
+	req := httptest.NewRequest("GET", "/foo", nil)
+	req.Header.Set(operation.Header, "from-header")
+	id, w := suite.invoke(req, generator)
+	suite.Require().Equal("from-header", id)
+	suite.Require().Equal("from-header", w.Header().Get(operation.Header))
+
+	req = httptest.NewRequest("GET", "/foo", nil)
+	req.Header.Set(operation.Header, "from-header")
+	req = req.WithContext(operation.WithID(req.Context(), "from-metadata"))
+	id, w = suite.invoke(req, generator)
+	suite.Require().Equal("from-metadata", id)
+	suite.Require().Equal("from-metadata", w.Header().Get(operation.Header))
+}
+
+// Ensures that the middleware ignores header values that we don't want to dump into our logs.
+func (suite *OperationSuite) TestMiddleware_invalidHeader() {
+	generator := func() string { return "generated" }
+	for _, invalid := range []string{"has space", "new\nline", "tab\tbed", strings.Repeat("x", 129), "ünicode"} {
+		req := httptest.NewRequest("GET", "/foo", nil)
+		req.Header.Set(operation.Header, invalid)
+		id, _ := suite.invoke(req, generator)
+		suite.Require().Equal("generated", id, "Should not accept header value %q", invalid)
+	}
+}
+
+func (suite *OperationSuite) invoke(req *http.Request, generator func() string) (string, *httptest.ResponseRecorder) {
+	id := ""
+	w := httptest.NewRecorder()
+	operation.Middleware(generator)(w, req, func(w http.ResponseWriter, req *http.Request) {
+		id = operation.ID(req.Context())
+	})
+	return id, w
+}
+
+func TestOperationSuite(t *testing.T) {
+	suite.Run(t, new(OperationSuite))
+}
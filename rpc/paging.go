@@ -0,0 +1,152 @@
+package rpc
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+
+	"github.com/monadicstack/frodo/rpc/internal/reflection"
+)
+
+// PagingRequest contains the standard inputs for service functions that return results one page at a
+// time. Embed it in your request struct to opt in to frodo's pagination support:
+//
+//     type ListUsersRequest struct {
+//         rpc.PagingRequest
+//         GroupID string
+//     }
+//
+// Offset-based pagination uses Limit/Offset. Cursor-based pagination uses Limit/Cursor where the cursor
+// is the opaque NextCursor value from the previous page's response.
+type PagingRequest struct {
+	// Limit is the maximum number of results the caller wants on this page.
+	Limit int
+	// Offset is the number of results to skip (offset-based pagination).
+	Offset int
+	// Cursor is the NextCursor value from the previous page (cursor-based pagination).
+	Cursor string
+}
+
+// Paging lets the gateway and generated clients find the paging inputs on any request that embeds this.
+func (r *PagingRequest) Paging() *PagingRequest {
+	return r
+}
+
+// PagingResponse contains the standard outputs for service functions that return results one page at a
+// time. Embed it in your response struct along with a single slice of results:
+//
+//     type ListUsersResponse struct {
+//         rpc.PagingResponse
+//         Users []User
+//     }
+//
+// Generated clients use this info to figure out how to fetch the next page (e.g. ListUsersAll()).
+type PagingResponse struct {
+	// Limit is the page size that the service actually used. Fill this in when the service applies
+	// a default/max page size so the caller knows how far to advance the offset.
+	Limit int
+	// Total is the total number of results across all pages (offset-based pagination).
+	Total int
+	// NextCursor is the opaque token the caller passes as the Cursor to fetch the next page. Leave this
+	// blank when there are no more results (cursor-based pagination).
+	NextCursor string
+}
+
+// Paging lets the gateway and generated clients find the paging outputs on any response that embeds this.
+func (r *PagingResponse) Paging() *PagingResponse {
+	return r
+}
+
+type pagingRequester interface {
+	Paging() *PagingRequest
+}
+
+type pagingResponder interface {
+	Paging() *PagingResponse
+}
+
+// NextPage returns the paging inputs you'd use to fetch the page after the one described by the request and
+// response. It returns nil when there are no more pages.
+func NextPage(request PagingRequest, response PagingResponse) *PagingRequest {
+	limit := pageLimit(request, response)
+	switch {
+	case response.NextCursor != "":
+		return &PagingRequest{Limit: request.Limit, Cursor: response.NextCursor}
+	case request.Cursor != "":
+		return nil // We were paging using cursors, but the service didn't give us another one.
+	case limit <= 0 || request.Offset+limit >= response.Total:
+		return nil
+	default:
+		return &PagingRequest{Limit: request.Limit, Offset: request.Offset + limit}
+	}
+}
+
+// PrevPage returns the paging inputs you'd use to fetch the page before the one described by the request and
+// response. It returns nil when this is the first page or when using cursor-based pagination (cursors only
+// go forward).
+func PrevPage(request PagingRequest, response PagingResponse) *PagingRequest {
+	limit := pageLimit(request, response)
+	if request.Cursor != "" || limit <= 0 || request.Offset <= 0 {
+		return nil
+	}
+
+	offset := request.Offset - limit
+	if offset < 0 {
+		offset = 0
+	}
+	return &PagingRequest{Limit: request.Limit, Offset: offset}
+}
+
+// pageLimit is the page size that the service used to generate the response.
+func pageLimit(request PagingRequest, response PagingResponse) int {
+	if response.Limit > 0 {
+		return response.Limit
+	}
+	return request.Limit
+}
+
+// Paginate writes the standard pagination headers when the service request/response embed PagingRequest
+// and PagingResponse. This includes an RFC 8288 "Link" header w/ the URLs of the "next" and "prev" pages as
+// well as "X-Total-Count" when the response has a total. This is a no-op for all other values.
+func Paginate(w http.ResponseWriter, req *http.Request, serviceRequest interface{}, serviceResponse interface{}) {
+	requester, ok := serviceRequest.(pagingRequester)
+	if !ok || req.URL == nil || reflection.IsNil(reflect.ValueOf(serviceRequest)) {
+		return
+	}
+	responder, ok := serviceResponse.(pagingResponder)
+	if !ok || reflection.IsNil(reflect.ValueOf(serviceResponse)) {
+		return
+	}
+
+	request := *requester.Paging()
+	response := *responder.Paging()
+	if response.Total > 0 {
+		w.Header().Set("X-Total-Count", strconv.Itoa(response.Total))
+	}
+	if next := NextPage(request, response); next != nil {
+		w.Header().Add("Link", pageLink(req.URL, *next, "next"))
+	}
+	if prev := PrevPage(request, response); prev != nil {
+		w.Header().Add("Link", pageLink(req.URL, *prev, "prev"))
+	}
+}
+
+// pageLink builds the "Link" header value that points to the same endpoint w/ different paging params.
+func pageLink(requestURL *url.URL, page PagingRequest, rel string) string {
+	query := requestURL.Query()
+	query.Del("Offset")
+	query.Del("Cursor")
+	if page.Limit > 0 {
+		query.Set("Limit", strconv.Itoa(page.Limit))
+	}
+	if page.Cursor != "" {
+		query.Set("Cursor", page.Cursor)
+	} else {
+		query.Set("Offset", strconv.Itoa(page.Offset))
+	}
+
+	pageURL := url.URL{Path: requestURL.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf(`<%s>; rel="%s"`, pageURL.String(), rel)
+}
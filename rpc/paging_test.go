@@ -0,0 +1,121 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+type pagedRequest struct {
+	rpc.PagingRequest
+	Group string
+}
+
+type pagedResponse struct {
+	rpc.PagingResponse
+	Users []string
+}
+
+// Ensures that we can figure out the next page for both offset and cursor based pagination, and the previous
+// page for offset based pagination.
+func (suite *GatewaySuite) TestNextPage() {
+	tests := []struct {
+		name     string
+		request  rpc.PagingRequest
+		response rpc.PagingResponse
+		next     *rpc.PagingRequest
+		prev     *rpc.PagingRequest
+	}{
+		{
+			name:     "first offset page",
+			request:  rpc.PagingRequest{Limit: 10},
+			response: rpc.PagingResponse{Total: 25},
+			next:     &rpc.PagingRequest{Limit: 10, Offset: 10},
+		},
+		{
+			name:     "middle offset page",
+			request:  rpc.PagingRequest{Limit: 10, Offset: 10},
+			response: rpc.PagingResponse{Total: 25},
+			next:     &rpc.PagingRequest{Limit: 10, Offset: 20},
+			prev:     &rpc.PagingRequest{Limit: 10, Offset: 0},
+		},
+		{
+			name:     "should not go past the total",
+			request:  rpc.PagingRequest{Limit: 10, Offset: 20},
+			response: rpc.PagingResponse{Total: 25},
+			prev:     &rpc.PagingRequest{Limit: 10, Offset: 10},
+		},
+		{
+			name:     "should not go before the first result",
+			request:  rpc.PagingRequest{Limit: 10, Offset: 5},
+			response: rpc.PagingResponse{Total: 25},
+			next:     &rpc.PagingRequest{Limit: 10, Offset: 15},
+			prev:     &rpc.PagingRequest{Limit: 10, Offset: 0},
+		},
+		{
+			name:     "should advance by the service's page size when the caller doesn't give a limit",
+			response: rpc.PagingResponse{Limit: 5, Total: 25},
+			next:     &rpc.PagingRequest{Offset: 5},
+		},
+		{
+			name:     "can't advance when we don't know the page size",
+			response: rpc.PagingResponse{Total: 25},
+		},
+		{
+			name:     "first cursor page",
+			request:  rpc.PagingRequest{Limit: 10},
+			response: rpc.PagingResponse{NextCursor: "abc"},
+			next:     &rpc.PagingRequest{Limit: 10, Cursor: "abc"},
+		},
+		{
+			name:     "cursors only go forward",
+			request:  rpc.PagingRequest{Limit: 10, Offset: 20, Cursor: "abc"},
+			response: rpc.PagingResponse{NextCursor: "def"},
+			next:     &rpc.PagingRequest{Limit: 10, Cursor: "def"},
+		},
+		{
+			name:     "should stop when the service doesn't return another cursor",
+			request:  rpc.PagingRequest{Limit: 10, Cursor: "def"},
+			response: rpc.PagingResponse{Total: 1000},
+		},
+	}
+	for _, test := range tests {
+		r := suite.Require()
+		r.Equal(test.next, rpc.NextPage(test.request, test.response), "%s: NextPage()", test.name)
+		r.Equal(test.prev, rpc.PrevPage(test.request, test.response), "%s: PrevPage()", test.name)
+	}
+}
+
+// Ensures that the gateway writes the Link and X-Total-Count headers for paged responses.
+func (suite *GatewaySuite) TestPaginate() {
+	r := suite.Require()
+
+	req := httptest.NewRequest("GET", "/users?Group=admins&Offset=10&Limit=10", nil)
+	w := httptest.NewRecorder()
+	request := &pagedRequest{PagingRequest: rpc.PagingRequest{Limit: 10, Offset: 10}, Group: "admins"}
+	response := &pagedResponse{PagingResponse: rpc.PagingResponse{Total: 35}}
+	rpc.Paginate(w, req, request, response)
+	r.Equal("35", w.Header().Get("X-Total-Count"))
+	r.Equal([]string{
+		`</users?Group=admins&Limit=10&Offset=20>; rel="next"`,
+		`</users?Group=admins&Limit=10&Offset=0>; rel="prev"`,
+	}, w.Header().Values("Link"))
+
+	req = httptest.NewRequest("GET", "/users?Cursor=abc", nil)
+	w = httptest.NewRecorder()
+	request = &pagedRequest{PagingRequest: rpc.PagingRequest{Cursor: "abc"}}
+	response = &pagedResponse{PagingResponse: rpc.PagingResponse{NextCursor: "d e"}}
+	rpc.Paginate(w, req, request, response)
+	r.Equal("", w.Header().Get("X-Total-Count"))
+	r.Equal([]string{`</users?Cursor=d+e>; rel="next"`}, w.Header().Values("Link"))
+
+	// Non-paged values and nil responses should be ignored.
+	w = httptest.NewRecorder()
+	rpc.Paginate(w, req, &struct{}{}, response)
+	rpc.Paginate(w, req, request, (*pagedResponse)(nil))
+	rpc.Paginate(w, req, request, &struct{}{})
+	r.Equal(http.Header{}, w.Header())
+}
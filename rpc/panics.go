@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+// PanicHandler is notified whenever the gateway recovers from a panic in your middleware, your handlers, or the
+// background work for "ASYNC"/"SSE" functions. The stack is the trace of the goroutine that panicked, so this
+// is where you ship the failure off to Sentry, Honeybadger, etc. The caller still gets a 500 either way.
+type PanicHandler func(ctx context.Context, recovered interface{}, stack []byte)
+
+// WithPanicHandler registers callbacks that the gateway invokes whenever it recovers from a panic. By
+// default, the gateway logs the panic along w/ its stack trace; once you supply your own handlers, it
+// only invokes those.
+//
+//     gateway := users.NewUserServiceGateway(service, rpc.WithPanicHandler(
+//         func(ctx context.Context, recovered interface{}, stack []byte) {
+//             sentry.CurrentHub().Recover(recovered)
+//         },
+//     ))
+func WithPanicHandler(handlers ...PanicHandler) GatewayOption {
+	return func(gw *Gateway) {
+		gw.panicHandlers = append(gw.panicHandlers, handlers...)
+	}
+}
+
+// WithPanicStackTraces includes the stack trace in the details of the 500 error that callers receive when
+// the gateway recovers from a panic (e.g. {"status":500, "message":"...", "details":{"stack":"..."}}). This
+// leaks the internals of your service, so only use it in development.
+func WithPanicStackTraces() GatewayOption {
+	return func(gw *Gateway) {
+		gw.PanicStackTraces = true
+	}
+}
+
+// logPanic is the default PanicHandler. It logs the panic so that it isn't silently swallowed.
+func logPanic(_ context.Context, recovered interface{}, stack []byte) {
+	log.Printf("rpc: recovered from panic: %v\n%s", recovered, stack)
+}
+
+// recoverFromPanic automatically recovers from a panic thrown by your handler so that if you nil-pointer
+// or something else unexpected, we'll safely just return a 500-style error.
+func recoverFromPanic(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			failPanic(w, req, recovered)
+		}
+	}()
+	next(w, req)
+}
+
+// failPanic notifies the gateway's panic handlers and responds w/ the 500 error. Only call this from
+// the deferred function that recovered, so that the stack trace still includes the code that panicked.
+func failPanic(w http.ResponseWriter, req *http.Request, recovered interface{}) {
+	stack := debug.Stack()
+	gw, ok := req.Context().Value(contextKeyGateway{}).(*Gateway)
+	if !ok {
+		gw = &Gateway{}
+	}
+	Fail(w, req, gw.panicked(req.Context(), recovered, stack))
+}
+
+// panicked notifies the panic handlers and returns the error that we should report to the caller.
+func (gw Gateway) panicked(ctx context.Context, recovered interface{}, stack []byte) error {
+	handlers := gw.panicHandlers
+	if len(handlers) == 0 {
+		handlers = []PanicHandler{logPanic}
+	}
+	for _, handler := range handlers {
+		handler(ctx, recovered, stack)
+	}
+
+	err := errors.Unexpected("%v", recovered)
+	if gw.PanicStackTraces {
+		return recoveredPanic{errors.WithDetails(err, map[string]interface{}{"stack": string(stack)})}
+	}
+	return recoveredPanic{err}
+}
+
+// recoveredPanic marks the error we send to the caller when we recover from a panic. The panic handlers
+// have already reported it, so nobody else needs to log it again (see sanitizeError).
+type recoveredPanic struct {
+	error
+}
+
+func (err recoveredPanic) Unwrap() error {
+	return err.error
+}
@@ -0,0 +1,98 @@
+package rpc
+
+import (
+	"net/http"
+	"os"
+	"strings"
+)
+
+// WithPrefixVariables supplies the values for the variables in the gateway's PathPrefix, so the same generated
+// gateway can run behind ingresses w/ different path layouts. A "PATH ${API_PREFIX}/v2" doc option uses the
+// "API_PREFIX" value you supply here or the environment variable w/ that name if you don't, and "PATH /:tenant/v2"
+// uses the "tenant" value you supply here:
+//
+//     gateway := calcrpc.NewCalculatorServiceGateway(service, rpc.WithPrefixVariables(map[string]string{
+//         "API_PREFIX": "/edge",
+//         "tenant":     "acme",
+//     }))
+//
+// Routes are "/edge/v2/CalculatorService.Add" and "/acme/v2/CalculatorService.Add". When you don't supply a
+// value for a ":tenant" looking variable, it stays a path parameter, so the gateway accepts any tenant and binds
+// it to your request's "tenant" field (if it has one) just like the params in the function's own route.
+func WithPrefixVariables(variables map[string]string) GatewayOption {
+	return func(gw *Gateway) {
+		gw.PrefixVariables = mergePrefixVariables(gw.PrefixVariables, variables)
+	}
+}
+
+// WithClientPrefixVariables is the client's version of WithPrefixVariables. Use the same values you give to
+// the gateway so that the client calls the right routes. Unlike the gateway, the client fills in ":tenant"
+// looking variables that you don't supply w/ the "tenant" field of each service request.
+func WithClientPrefixVariables(variables map[string]string) ClientOption {
+	return func(rpcClient *Client) {
+		rpcClient.PrefixVariables = mergePrefixVariables(rpcClient.PrefixVariables, variables)
+	}
+}
+
+func mergePrefixVariables(existing map[string]string, variables map[string]string) map[string]string {
+	merged := make(map[string]string, len(existing)+len(variables))
+	for name, value := range existing {
+		merged[name] = value
+	}
+	for name, value := range variables {
+		merged[name] = value
+	}
+	return merged
+}
+
+// pathPrefix is the gateway's PathPrefix w/ all of the variables we know about filled in.
+func (gw Gateway) pathPrefix() string {
+	return expandPathPrefix(gw.PathPrefix, gw.PrefixVariables)
+}
+
+// requestPathPrefix is the gateway's PathPrefix w/ its variables filled in, including the ":tenant" looking ones
+// that stayed path params, whose values come from the route that the request matched. Use this when you need
+// a path that the caller can use to get back to this gateway (e.g. the job URL of an "ASYNC" function).
+func (gw Gateway) requestPathPrefix(req *http.Request) string {
+	params := PathParams(req)
+	segments := strings.Split(gw.pathPrefix(), "/")
+	for i, segment := range segments {
+		if value, ok := params[strings.TrimPrefix(segment, ":")]; ok && strings.HasPrefix(segment, ":") {
+			segments[i] = value
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// pathPrefix is the client's PathPrefix w/ all of the variables we know about filled in.
+func (c Client) pathPrefix() string {
+	return expandPathPrefix(c.PathPrefix, c.PrefixVariables)
+}
+
+// expandPathPrefix fills in the variables in a path prefix like "${API_PREFIX}/v2" or "/:tenant/v2". The "${NAME}"
+// (or "$NAME") ones use the value in 'variables' or the environment variable NAME, and ":name" segments use
+// the value in 'variables' if there is one (otherwise we leave them alone). Variables that resolve to
+// blank values don't leave empty segments behind, so "${API_PREFIX}/v2" is just "/v2" when it's not set.
+func expandPathPrefix(prefix string, variables map[string]string) string {
+	if !strings.ContainsAny(prefix, "$:") {
+		return prefix
+	}
+
+	prefix = os.Expand(prefix, func(name string) string {
+		if value, ok := variables[name]; ok {
+			return value
+		}
+		return os.Getenv(name)
+	})
+
+	var segments []string
+	for _, segment := range strings.Split(prefix, "/") {
+		if value, ok := variables[strings.TrimPrefix(segment, ":")]; ok && strings.HasPrefix(segment, ":") {
+			segment = strings.Trim(value, "/")
+		}
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
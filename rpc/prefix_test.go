@@ -0,0 +1,109 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+type prefixRequest struct {
+	Tenant string
+	ID     string
+}
+
+// newPrefixGateway creates a gateway w/ the given path prefix whose "Lookup" endpoint echoes back the request.
+func (suite *GatewaySuite) newPrefixGateway(prefix string, options ...rpc.GatewayOption) rpc.Gateway {
+	gw := suite.newGateway(options...)
+	gw.PathPrefix = prefix
+	gw.Register(testEndpoint("GET", "/lookup/:ID", "Lookup", echo(&gw, func() interface{} { return &prefixRequest{} })))
+	return gw
+}
+
+// Ensures that "${NAME}" variables come from WithPrefixVariables first and the environment second, and that
+// later options win.
+func (suite *GatewaySuite) TestWithPrefixVariables() {
+	_ = os.Setenv("FRODO_TEST_PREFIX", "/from-env/")
+	defer func() { _ = os.Unsetenv("FRODO_TEST_PREFIX") }()
+
+	tests := []struct {
+		prefix    string
+		options   []rpc.GatewayOption
+		exchanges []exchange
+	}{
+		{
+			prefix: "/${FRODO_TEST_PREFIX}/v2",
+			exchanges: []exchange{
+				{name: "env", method: "GET", path: "/from-env/v2/lookup/1", status: 200},
+				{name: "env", method: "GET", path: "/v2/lookup/1", status: 404},
+			},
+		},
+		{
+			prefix:  "/${FRODO_TEST_PREFIX}/v2",
+			options: []rpc.GatewayOption{rpc.WithPrefixVariables(map[string]string{"FRODO_TEST_PREFIX": "edge"})},
+			exchanges: []exchange{
+				{name: "option", method: "GET", path: "/edge/v2/lookup/1", status: 200},
+				{name: "option", method: "GET", path: "/from-env/v2/lookup/1", status: 404},
+			},
+		},
+		{
+			prefix: "/${FRODO_TEST_MISSING}/v2",
+			exchanges: []exchange{
+				{name: "blank variables shouldn't leave an empty segment", method: "GET", path: "/v2/lookup/1", status: 200},
+			},
+		},
+		{
+			prefix: "/:tenant/v2",
+			options: []rpc.GatewayOption{
+				rpc.WithPrefixVariables(map[string]string{"tenant": "nope"}),
+				rpc.WithPrefixVariables(map[string]string{"tenant": "acme"}),
+			},
+			exchanges: []exchange{
+				{name: "param", method: "GET", path: "/acme/v2/lookup/1", status: 200, json: `{"Tenant":"", "ID":"1"}`},
+				{name: "param", method: "GET", path: "/globex/v2/lookup/1", status: 404},
+				{name: "later options should win", method: "GET", path: "/nope/v2/lookup/1", status: 404},
+			},
+		},
+	}
+	for _, test := range tests {
+		server := httptest.NewServer(suite.newPrefixGateway(test.prefix, test.options...))
+		suite.assertExchanges(server, test.exchanges)
+		server.Close()
+	}
+}
+
+// Ensures that ":name" prefix variables w/o a value stay path params that we bind like any other, and that the
+// client resolves the same variables as the gateway so they agree on the routes.
+func (suite *GatewaySuite) TestWithPrefixVariables_client() {
+	r := suite.Require()
+	server := httptest.NewServer(suite.newPrefixGateway("/:tenant/v2"))
+	defer server.Close()
+
+	client := rpc.NewClient("TestService", server.URL)
+	client.PathPrefix = "/:tenant/v2"
+
+	response := prefixRequest{}
+	r.NoError(client.Invoke(context.Background(), "GET", "/lookup/:ID", &prefixRequest{Tenant: "acme", ID: "1"}, &response))
+	r.Equal(prefixRequest{Tenant: "acme", ID: "1"}, response)
+
+	client = rpc.NewClient("TestService", server.URL, rpc.WithClientPrefixVariables(map[string]string{"tenant": "globex"}))
+	client.PathPrefix = "/:tenant/v2"
+
+	response = prefixRequest{}
+	r.NoError(client.Invoke(context.Background(), "GET", "/lookup/:ID", &prefixRequest{Tenant: "acme", ID: "2"}, &response))
+	r.Equal(prefixRequest{Tenant: "globex", ID: "2"}, response, "Client variables should beat request fields")
+
+	variables := map[string]string{"API_PREFIX": "/edge"}
+	server = httptest.NewServer(suite.newPrefixGateway("${API_PREFIX}/v2", rpc.WithPrefixVariables(variables)))
+	defer server.Close()
+
+	client = rpc.NewClient("TestService", server.URL, rpc.WithClientPrefixVariables(variables))
+	client.PathPrefix = "${API_PREFIX}/v2"
+
+	response = prefixRequest{}
+	r.NoError(client.Invoke(context.Background(), "GET", "/lookup/:ID", &prefixRequest{ID: "1"}, &response))
+	r.Equal("1", response.ID)
+}
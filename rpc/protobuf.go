@@ -0,0 +1,173 @@
+package rpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/protobuf"
+)
+
+// ProtobufContentType is the Content-Type/Accept header value for request/response bodies that use the
+// Protocol Buffers wire format rather than JSON (see WithProtobuf).
+const ProtobufContentType = protobuf.ContentType
+
+// WithProtobuf lets callers send/receive Protocol Buffers bodies instead of JSON, which are smaller and
+// faster to encode/decode for performance-sensitive service-to-service calls. Request bodies whose Content-Type
+// is "application/x-protobuf" are decoded as protobuf, and callers that include "application/x-protobuf" in
+// their Accept header get protobuf responses. Everyone else keeps using JSON, so you can turn this on w/o
+// breaking any existing callers. Errors, enveloped responses, raw content, and event streams are always JSON.
+//
+// The messages are the ones that "frodo docs --format=proto" generates from your request/response structs, so
+// non-Go callers can use them, too. Go clients just need WithClientProtobuf().
+func WithProtobuf() GatewayOption {
+	return func(gw *Gateway) {
+		gw.Protobuf = true
+	}
+}
+
+// WithClientProtobuf makes the client ask for Protocol Buffers responses instead of JSON. Once the gateway
+// responds w/ protobuf, we know that it speaks protobuf (see WithProtobuf), so the client starts sending its
+// request bodies as protobuf, too. Until then (or forever, if the gateway doesn't support it), the client sticks
+// w/ JSON, so it's safe to enable this before you've updated the gateway.
+func WithClientProtobuf() ClientOption {
+	return func(rpcClient *Client) {
+		rpcClient.protobuf = &protobufNegotiation{}
+	}
+}
+
+// protobufNegotiation remembers whether the client has received a protobuf response from the gateway yet. It's
+// shared by every copy of the client, so the first protobuf response switches all of them over.
+type protobufNegotiation struct {
+	supported int32
+}
+
+// negotiated returns true once the gateway has responded w/ protobuf, so it's safe to send protobuf bodies.
+func (n *protobufNegotiation) negotiated() bool {
+	return n != nil && atomic.LoadInt32(&n.supported) == 1
+}
+
+// negotiate marks the gateway as one that speaks protobuf.
+func (n *protobufNegotiation) negotiate() {
+	if n != nil {
+		atomic.StoreInt32(&n.supported, 1)
+	}
+}
+
+// gatewayProtobuf returns true when the gateway handling the request speaks protobuf (see WithProtobuf).
+func gatewayProtobuf(req *http.Request) bool {
+	gw, ok := req.Context().Value(contextKeyGateway{}).(*Gateway)
+	return ok && gw.Protobuf
+}
+
+// acceptsProtobuf returns true when the caller listed the protobuf content type in its "Accept" header.
+func acceptsProtobuf(req *http.Request) bool {
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == ProtobufContentType && params["q"] != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+// isProtobuf returns true when the Content-Type header says that the body uses the protobuf wire format.
+func isProtobuf(header http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	return mediaType == ProtobufContentType
+}
+
+// marshalProtobuf encodes the request/response using the protobuf wire format. Generated gateways/clients wrap
+// "ONEOF" unions using OneOf(), so those are messages whose alternatives are in a 'oneof'.
+func marshalProtobuf(value interface{}) ([]byte, error) {
+	if union, ok := value.(*Union); ok {
+		return protobuf.MarshalOneOf(union.value)
+	}
+	return protobuf.Marshal(value)
+}
+
+// unmarshalProtobuf decodes the protobuf request/response, handling "ONEOF" unions just like marshalProtobuf().
+func unmarshalProtobuf(data []byte, out interface{}) error {
+	if union, ok := out.(*Union); ok {
+		return protobuf.UnmarshalOneOf(data, union.value)
+	}
+	return protobuf.Unmarshal(data, out)
+}
+
+// bindBodyProtobuf decodes the protobuf request body onto the 'out' value.
+func bindBodyProtobuf(body io.Reader, out interface{}) error {
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		if errors.Status(err) == http.StatusRequestEntityTooLarge {
+			return err
+		}
+		return errors.BadRequest("unable to read request body: %v", err)
+	}
+	if err = unmarshalProtobuf(data, out); err != nil {
+		return errors.BadRequest("invalid protobuf request body: %v", err)
+	}
+	return nil
+}
+
+// writeProtobuf writes the service response using the protobuf wire format. It returns false w/o writing
+// anything when the response can't be encoded as protobuf, so the caller can fall back to JSON.
+func writeProtobuf(w http.ResponseWriter, req *http.Request, status int, serviceResponse interface{}, useETag bool) bool {
+	data, err := marshalProtobuf(serviceResponse)
+	if err != nil {
+		return false
+	}
+	if useETag {
+		etag := jsonETag(data)
+		w.Header().Set("ETag", etag)
+		if contentNotModified(req, etag, time.Time{}) {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	w.Header().Set("Content-Type", ProtobufContentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+	return true
+}
+
+// createRequestBodyProtobuf encodes the service request using the protobuf wire format.
+func (c Client) createRequestBodyProtobuf(serviceRequest interface{}) (io.Reader, error) {
+	data, err := marshalProtobuf(serviceRequest)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+// decodeResponseProtobuf decodes the protobuf response body onto the service response.
+func (c Client) decodeResponseProtobuf(response *http.Response, serviceResponse interface{}) error {
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("rpc: unable to read response: %w", err)
+	}
+	if err = unmarshalProtobuf(data, serviceResponse); err != nil {
+		return fmt.Errorf("rpc: unable to decode response: %w", err)
+	}
+	return nil
+}
+
+// writeProtobufHeaders asks the gateway for a protobuf response and labels the request body when it's protobuf.
+// We still accept JSON in case the gateway doesn't speak protobuf. Raw content responses don't need either.
+func writeProtobufHeaders(request *http.Request, serviceResponse interface{}, protobufBody bool) {
+	if protobufBody && request.Body != nil && request.Body != http.NoBody {
+		request.Header.Set("Content-Type", ProtobufContentType)
+	}
+	if _, ok := serviceResponse.(ContentWriter); ok {
+		return
+	}
+	request.Header.Set("Accept", ProtobufContentType+", application/json")
+}
@@ -0,0 +1,663 @@
+// Package protobuf encodes/decodes your service's request/response structs using the Protocol Buffers (proto3)
+// wire format. There's no generated code involved; we use reflection to map the struct's fields to the fields of
+// the message that "frodo docs --format=proto" generates for it:
+//
+//   - Fields are numbered in the order that they appear in the struct (starting at 1), and the fields of embedded
+//     structs are numbered as though they were declared in place of the embedded struct. Fields tagged w/
+//     `json:"-"` and unexported fields are skipped, just like they are in JSON. Only ever add new fields to the
+//     end of the struct, or older callers will confuse them w/ the fields that used to have their numbers.
+//   - Numbers, strings, bools, []byte, nested structs, slices, and maps w/ string/number/bool keys become their
+//     proto3 equivalents. Pointers to numbers/strings/bools are "optional" fields, so you can tell nil from zero.
+//   - A time.Time is an RFC 3339 string, just like it is in JSON.
+//   - A "ONEOF" union (see MarshalOneOf) is a message w/ a 'oneof' whose fields are the union's alternatives.
+//   - Anything else (interface{} fields, slices of slices, structs w/o any exported fields, etc) is a string
+//     containing the value's JSON.
+package protobuf
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ContentType is the Content-Type/Accept header value for Protocol Buffers request/response bodies.
+const ContentType = "application/x-protobuf"
+
+// Marshal encodes the struct (or pointer to a struct) as a Protocol Buffers message.
+func Marshal(value interface{}) ([]byte, error) {
+	v, err := marshalValue(value)
+	if err != nil || !v.IsValid() {
+		return nil, err
+	}
+	return appendMessage(nil, v, fieldsOf(v.Type()))
+}
+
+// MarshalOneOf encodes a "ONEOF" union struct, which embeds a pointer to each of the alternatives it can take.
+// Rather than flattening the alternatives' fields like Marshal() would, each alternative is a field of the
+// message (numbered in the order they're embedded), and only the one that's set is encoded.
+func MarshalOneOf(value interface{}) ([]byte, error) {
+	v, err := marshalValue(value)
+	if err != nil || !v.IsValid() {
+		return nil, err
+	}
+	return appendMessage(nil, v, alternativesOf(v.Type()))
+}
+
+// Unmarshal decodes the Protocol Buffers message onto the 'out' struct, which must be a pointer. Fields in the
+// message that the struct doesn't have are ignored.
+func Unmarshal(data []byte, out interface{}) error {
+	v, err := unmarshalValue(out)
+	if err != nil {
+		return err
+	}
+	return decodeMessage(data, v, fieldsOf(v.Type()))
+}
+
+// UnmarshalOneOf decodes a message encoded using MarshalOneOf() onto the 'out' union struct, which must be
+// a pointer. Only the alternative in the message is populated.
+func UnmarshalOneOf(data []byte, out interface{}) error {
+	v, err := unmarshalValue(out)
+	if err != nil {
+		return err
+	}
+	return decodeMessage(data, v, alternativesOf(v.Type()))
+}
+
+// marshalValue dereferences the value we're encoding. It's invalid (w/o an error) when the value is nil.
+func marshalValue(value interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("protobuf: unable to marshal %T: not a struct", value)
+	}
+	return v, nil
+}
+
+// unmarshalValue dereferences the pointer we're decoding onto, creating the struct if need be.
+func unmarshalValue(out interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return reflect.Value{}, fmt.Errorf("protobuf: unable to unmarshal onto non-pointer %T", out)
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("protobuf: unable to unmarshal onto %T: not a struct", out)
+	}
+	return v, nil
+}
+
+// The wire types that prefix each field in a message.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte{})
+)
+
+// field describes how a single struct field maps to a field in the message.
+type field struct {
+	number int
+	index  []int
+}
+
+// structFields caches the fields of each struct type that we've encoded/decoded.
+var structFields = sync.Map{}
+
+// fieldsOf returns the fields of the struct type in the order that we number them.
+func fieldsOf(t reflect.Type) []field {
+	if cached, ok := structFields.Load(t); ok {
+		return cached.([]field)
+	}
+	var fields []field
+	for _, index := range flattenFields(t, nil) {
+		fields = append(fields, field{number: len(fields) + 1, index: index})
+	}
+	structFields.Store(t, fields)
+	return fields
+}
+
+// alternativesOf returns the embedded struct pointers of the "ONEOF" union type in the order that we number them.
+func alternativesOf(t reflect.Type) []field {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if structField.PkgPath != "" || !structField.Anonymous || structField.Type.Kind() != reflect.Ptr {
+			continue
+		}
+		if structField.Type.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		fields = append(fields, field{number: len(fields) + 1, index: []int{i}})
+	}
+	return fields
+}
+
+// flattenFields returns the index paths of the struct's exported fields, including the ones in embedded structs.
+func flattenFields(t reflect.Type, parent []int) [][]int {
+	var results [][]int
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		index := append(append([]int{}, parent...), i)
+		if structField.PkgPath != "" || structField.Tag.Get("json") == "-" {
+			continue
+		}
+		embedded := structField.Type
+		if embedded.Kind() == reflect.Ptr {
+			embedded = embedded.Elem()
+		}
+		if structField.Anonymous && embedded.Kind() == reflect.Struct {
+			results = append(results, flattenFields(embedded, index)...)
+			continue
+		}
+		results = append(results, index)
+	}
+	return results
+}
+
+// fieldValue returns the value of the (possibly embedded) field. When 'alloc' is true, we create any nil
+// embedded structs along the way. Otherwise, we return false when we run into one.
+func fieldValue(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, fieldIndex := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(fieldIndex)
+	}
+	return v, true
+}
+
+// usesJSON returns true for the types that proto3 can't describe, so we encode them as a JSON string instead.
+func usesJSON(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64:
+		return false
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return false
+	case reflect.Struct:
+		return t != timeType && len(fieldsOf(t)) == 0
+	case reflect.Slice:
+		return t != bytesType && !repeatable(t.Elem())
+	case reflect.Map:
+		return !mapKey(t.Key()) || !repeatable(t.Elem())
+	default:
+		return true
+	}
+}
+
+// repeatable returns true when slices/maps of this type can be repeated fields (i.e. not slices/maps of slices).
+func repeatable(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == bytesType || (t.Kind() != reflect.Slice && t.Kind() != reflect.Map && !usesJSON(t))
+}
+
+// mapKey returns true for the types that proto3 allows as map keys.
+func mapKey(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool:
+		return true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// packed returns true for the types whose repeated fields are packed into a single length-delimited field.
+func packed(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+/* ---- Encoding ---- */
+
+// appendMessage appends the fields of the struct to the buffer.
+func appendMessage(buf []byte, v reflect.Value, fields []field) ([]byte, error) {
+	var err error
+	for _, f := range fields {
+		fv, ok := fieldValue(v, f.index, false)
+		if !ok {
+			continue
+		}
+		if buf, err = appendField(buf, f.number, fv, false); err != nil {
+			return nil, fmt.Errorf("protobuf: %s: %w", v.Type().FieldByIndex(f.index).Name, err)
+		}
+	}
+	return buf, nil
+}
+
+// appendField appends the value as the field w/ the given number. Like proto3, we leave out zero values unless
+// they're 'explicit' (elements of repeated fields or non-nil pointers), so callers can tell nil from zero.
+func appendField(buf []byte, number int, v reflect.Value, explicit bool) ([]byte, error) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if !explicit {
+				return buf, nil
+			}
+			v = reflect.Zero(v.Type().Elem())
+			continue
+		}
+		v = v.Elem()
+		explicit = true
+	}
+
+	t := v.Type()
+	switch {
+	case t == timeType:
+		timestamp := v.Interface().(time.Time)
+		if timestamp.IsZero() && !explicit {
+			return buf, nil
+		}
+		text, err := timestamp.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(buf, number, text), nil
+	case usesJSON(t):
+		if v.IsZero() && !explicit {
+			return buf, nil
+		}
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(buf, number, data), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		if t == bytesType || t.Elem().Kind() == reflect.Uint8 {
+			if v.Len() == 0 && !explicit {
+				return buf, nil
+			}
+			return appendBytes(buf, number, v.Bytes()), nil
+		}
+		return appendRepeated(buf, number, v)
+	case reflect.Map:
+		return appendMap(buf, number, v)
+	case reflect.Struct:
+		message, err := appendMessage(nil, v, fieldsOf(t))
+		if err != nil {
+			return nil, err
+		}
+		if len(message) == 0 && !explicit {
+			return buf, nil
+		}
+		return appendBytes(buf, number, message), nil
+	}
+
+	if v.IsZero() && !explicit {
+		return buf, nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return appendBytes(buf, number, []byte(v.String())), nil
+	case reflect.Float32:
+		buf = appendTag(buf, number, wireFixed32)
+		return appendFixed32(buf, math.Float32bits(float32(v.Float()))), nil
+	case reflect.Float64:
+		buf = appendTag(buf, number, wireFixed64)
+		return appendFixed64(buf, math.Float64bits(v.Float())), nil
+	default:
+		buf = appendTag(buf, number, wireVarint)
+		return appendVarint(buf, varint(v)), nil
+	}
+}
+
+// appendRepeated appends each element of the slice. Numbers and bools are packed into a single field.
+func appendRepeated(buf []byte, number int, v reflect.Value) ([]byte, error) {
+	if v.Len() == 0 {
+		return buf, nil
+	}
+	if !packed(v.Type().Elem()) {
+		var err error
+		for i := 0; i < v.Len(); i++ {
+			if buf, err = appendField(buf, number, v.Index(i), true); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	}
+
+	var values []byte
+	for i := 0; i < v.Len(); i++ {
+		element := v.Index(i)
+		switch element.Kind() {
+		case reflect.Float32:
+			values = appendFixed32(values, math.Float32bits(float32(element.Float())))
+		case reflect.Float64:
+			values = appendFixed64(values, math.Float64bits(element.Float()))
+		default:
+			values = appendVarint(values, varint(element))
+		}
+	}
+	return appendBytes(buf, number, values), nil
+}
+
+// appendMap appends each entry of the map as a message whose key is field 1 and whose value is field 2. We sort
+// the entries so that the same map always encodes the same way (e.g. for ETags).
+func appendMap(buf []byte, number int, v reflect.Value) ([]byte, error) {
+	var entries [][]byte
+	iter := v.MapRange()
+	for iter.Next() {
+		entry, err := appendField(nil, 1, iter.Key(), true)
+		if err != nil {
+			return nil, err
+		}
+		if entry, err = appendField(entry, 2, iter.Value(), true); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return string(entries[i]) < string(entries[j])
+	})
+	for _, entry := range entries {
+		buf = appendBytes(buf, number, entry)
+	}
+	return buf, nil
+}
+
+func appendTag(buf []byte, number int, wireType int) []byte {
+	return appendVarint(buf, uint64(number)<<3|uint64(wireType))
+}
+
+func appendBytes(buf []byte, number int, data []byte) []byte {
+	buf = appendTag(buf, number, wireBytes)
+	buf = appendVarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+// varint returns the varint value of a bool or integer. Negative numbers use all 10 bytes, just like proto3's
+// int32/int64 types.
+func varint(v reflect.Value) uint64 {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return 1
+		}
+		return 0
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(v.Int())
+	default:
+		return v.Uint()
+	}
+}
+
+/* ---- Decoding ---- */
+
+// decodeMessage decodes the fields of the message onto the struct.
+func decodeMessage(data []byte, v reflect.Value, messageFields []field) error {
+	fields := map[int][]int{}
+	for _, f := range messageFields {
+		fields[f.number] = f.index
+	}
+
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("protobuf: invalid field tag")
+		}
+		data = data[n:]
+		number, wireType := int(tag>>3), int(tag&7)
+
+		value, raw, rest, err := consumeValue(data, wireType)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		index, ok := fields[number]
+		if !ok {
+			continue
+		}
+		fv, _ := fieldValue(v, index, true)
+		if err = decodeField(fv, wireType, value, raw); err != nil {
+			return fmt.Errorf("protobuf: %s: %w", v.Type().FieldByIndex(index).Name, err)
+		}
+	}
+	return nil
+}
+
+// consumeValue reads the value of a field w/ the given wire type. Varints and fixed numbers are returned as a
+// uint64. Length-delimited values are returned as raw bytes.
+func consumeValue(data []byte, wireType int) (uint64, []byte, []byte, error) {
+	switch wireType {
+	case wireVarint:
+		value, n := binary.Uvarint(data)
+		if n <= 0 {
+			return 0, nil, nil, fmt.Errorf("protobuf: invalid varint")
+		}
+		return value, nil, data[n:], nil
+	case wireFixed64:
+		if len(data) < 8 {
+			return 0, nil, nil, fmt.Errorf("protobuf: unexpected end of message")
+		}
+		return binary.LittleEndian.Uint64(data), nil, data[8:], nil
+	case wireFixed32:
+		if len(data) < 4 {
+			return 0, nil, nil, fmt.Errorf("protobuf: unexpected end of message")
+		}
+		return uint64(binary.LittleEndian.Uint32(data)), nil, data[4:], nil
+	case wireBytes:
+		length, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < length {
+			return 0, nil, nil, fmt.Errorf("protobuf: unexpected end of message")
+		}
+		end := n + int(length)
+		return 0, data[n:end], data[end:], nil
+	default:
+		return 0, nil, nil, fmt.Errorf("protobuf: unsupported wire type %d", wireType)
+	}
+}
+
+// decodeField sets the field to the value we read from the message.
+func decodeField(v reflect.Value, wireType int, value uint64, raw []byte) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	t := v.Type()
+	switch {
+	case t == timeType:
+		if err := expectWireType(wireType, wireBytes); err != nil {
+			return err
+		}
+		return v.Addr().Interface().(*time.Time).UnmarshalText(raw)
+	case usesJSON(t):
+		if err := expectWireType(wireType, wireBytes); err != nil {
+			return err
+		}
+		return json.Unmarshal(raw, v.Addr().Interface())
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		if t == bytesType || t.Elem().Kind() == reflect.Uint8 {
+			if err := expectWireType(wireType, wireBytes); err != nil {
+				return err
+			}
+			v.SetBytes(append([]byte{}, raw...))
+			return nil
+		}
+		return decodeRepeated(v, wireType, value, raw)
+	case reflect.Map:
+		if err := expectWireType(wireType, wireBytes); err != nil {
+			return err
+		}
+		return decodeMapEntry(v, raw)
+	case reflect.Struct:
+		if err := expectWireType(wireType, wireBytes); err != nil {
+			return err
+		}
+		return decodeMessage(raw, v, fieldsOf(t))
+	case reflect.String:
+		if err := expectWireType(wireType, wireBytes); err != nil {
+			return err
+		}
+		v.SetString(string(raw))
+		return nil
+	case reflect.Float32:
+		if err := expectWireType(wireType, wireFixed32); err != nil {
+			return err
+		}
+		v.SetFloat(float64(math.Float32frombits(uint32(value))))
+		return nil
+	case reflect.Float64:
+		if err := expectWireType(wireType, wireFixed64); err != nil {
+			return err
+		}
+		v.SetFloat(math.Float64frombits(value))
+		return nil
+	}
+
+	if err := expectWireType(wireType, wireVarint); err != nil {
+		return err
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		v.SetBool(value != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(value))
+	default:
+		v.SetUint(value)
+	}
+	return nil
+}
+
+// decodeRepeated appends the element(s) to the slice. Numbers and bools can either be packed (many values in
+// one field) or not (one value per field), so we accept both.
+func decodeRepeated(v reflect.Value, wireType int, value uint64, raw []byte) error {
+	elemType := v.Type().Elem()
+	if wireType != wireBytes || !packed(elemType) {
+		element := reflect.New(elemType).Elem()
+		if err := decodeField(element, wireType, value, raw); err != nil {
+			return err
+		}
+		v.Set(reflect.Append(v, element))
+		return nil
+	}
+
+	elemWireType := wireVarint
+	switch elemType.Kind() {
+	case reflect.Float32:
+		elemWireType = wireFixed32
+	case reflect.Float64:
+		elemWireType = wireFixed64
+	}
+	for len(raw) > 0 {
+		elemValue, _, rest, err := consumeValue(raw, elemWireType)
+		if err != nil {
+			return err
+		}
+		raw = rest
+		element := reflect.New(elemType).Elem()
+		if err = decodeField(element, elemWireType, elemValue, nil); err != nil {
+			return err
+		}
+		v.Set(reflect.Append(v, element))
+	}
+	return nil
+}
+
+// decodeMapEntry adds the entry (a message whose key is field 1 and value is field 2) to the map.
+func decodeMapEntry(v reflect.Value, raw []byte) error {
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	key := reflect.New(v.Type().Key()).Elem()
+	value := reflect.New(v.Type().Elem()).Elem()
+	for len(raw) > 0 {
+		tag, n := binary.Uvarint(raw)
+		if n <= 0 {
+			return fmt.Errorf("protobuf: invalid field tag")
+		}
+		number, wireType := int(tag>>3), int(tag&7)
+		fieldValue, fieldRaw, rest, err := consumeValue(raw[n:], wireType)
+		if err != nil {
+			return err
+		}
+		raw = rest
+
+		switch number {
+		case 1:
+			err = decodeField(key, wireType, fieldValue, fieldRaw)
+		case 2:
+			err = decodeField(value, wireType, fieldValue, fieldRaw)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	v.SetMapIndex(key, value)
+	return nil
+}
+
+func expectWireType(actual int, expected int) error {
+	if actual != expected {
+		return fmt.Errorf("wire type %d doesn't match the field's type (expected %d)", actual, expected)
+	}
+	return nil
+}
+
+func appendVarint(buf []byte, value uint64) []byte {
+	var scratch [binary.MaxVarintLen64]byte
+	return append(buf, scratch[:binary.PutUvarint(scratch[:], value)]...)
+}
+
+func appendFixed32(buf []byte, value uint32) []byte {
+	var scratch [4]byte
+	binary.LittleEndian.PutUint32(scratch[:], value)
+	return append(buf, scratch[:]...)
+}
+
+func appendFixed64(buf []byte, value uint64) []byte {
+	var scratch [8]byte
+	binary.LittleEndian.PutUint64(scratch[:], value)
+	return append(buf, scratch[:]...)
+}
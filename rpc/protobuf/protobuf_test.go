@@ -0,0 +1,207 @@
+// +build unit
+
+package protobuf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc/protobuf"
+	"github.com/stretchr/testify/suite"
+)
+
+type ProtobufSuite struct {
+	suite.Suite
+}
+
+type Address struct {
+	Street string
+	Zip    int
+}
+
+type Audit struct {
+	CreatedBy string
+	Deleted   *bool
+}
+
+type Person struct {
+	Name     string
+	Age      int
+	Height   float64
+	Weight   float32
+	Visits   uint64
+	Offset   int32
+	Active   bool
+	Nickname *string
+	Score    *int
+	Avatar   []byte
+	Tags     []string
+	Lucky    []int
+	Ratios   []float64
+	Home     Address
+	Work     *Address
+	Previous []Address
+	Labels   map[string]string
+	Counts   map[int]float32
+	Places   map[string]Address
+	Birthday time.Time
+	Extra    interface{}
+	Matrix   [][]int
+	Secret   string `json:"-"`
+	*Audit
+	internal string
+}
+
+// Ensures that every kind of field survives the trip through the wire format.
+func (suite *ProtobufSuite) TestRoundTrip() {
+	r := suite.Require()
+
+	nickname := "Lebowski"
+	score := 0
+	deleted := false
+	input := Person{
+		Name:     "Jeff",
+		Age:      -48,
+		Height:   1.8,
+		Weight:   85.5,
+		Visits:   1 << 40,
+		Offset:   -7,
+		Active:   true,
+		Nickname: &nickname,
+		Score:    &score,
+		Avatar:   []byte{0, 1, 2},
+		Tags:     []string{"dude", "", "bowler"},
+		Lucky:    []int{7, -11, 0},
+		Ratios:   []float64{0.5, 0},
+		Home:     Address{Street: "Venice Blvd", Zip: 90291},
+		Work:     &Address{Street: "Bowling Alley"},
+		Previous: []Address{{Zip: 1}, {}},
+		Labels:   map[string]string{"a": "1", "b": ""},
+		Counts:   map[int]float32{1: 1.5, -2: 0},
+		Places:   map[string]Address{"rug": {Street: "Ties the room together"}},
+		Birthday: time.Date(1973, 12, 4, 12, 30, 0, 0, time.UTC),
+		Extra:    map[string]interface{}{"abides": true},
+		Matrix:   [][]int{{1, 2}, {3}},
+		Secret:   "nope",
+		Audit:    &Audit{CreatedBy: "Walter", Deleted: &deleted},
+		internal: "nope",
+	}
+
+	data, err := protobuf.Marshal(&input)
+	r.NoError(err)
+
+	output := Person{}
+	r.NoError(protobuf.Unmarshal(data, &output))
+
+	input.Secret = ""
+	input.internal = ""
+	r.Equal(input, output)
+}
+
+// Ensures that zero values are left out of the message entirely.
+func (suite *ProtobufSuite) TestMarshal_zero() {
+	r := suite.Require()
+
+	data, err := protobuf.Marshal(Person{})
+	r.NoError(err)
+	r.Empty(data)
+
+	data, err = protobuf.Marshal(Address{Zip: 150})
+	r.NoError(err)
+	r.Equal([]byte{0x10, 0x96, 0x01}, data, "Should only encode field 2 as a varint")
+}
+
+// Ensures that we produce the same bytes as a protoc-generated message would.
+func (suite *ProtobufSuite) TestMarshal_wireFormat() {
+	r := suite.Require()
+
+	data, err := protobuf.Marshal(struct {
+		Name  string
+		Lucky []int
+		Home  Address
+	}{Name: "testing", Lucky: []int{3, 270}, Home: Address{Zip: 1}})
+	r.NoError(err)
+	r.Equal([]byte{
+		0x0a, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g',
+		0x12, 0x03, 0x03, 0x8e, 0x02,
+		0x1a, 0x02, 0x10, 0x01,
+	}, data)
+}
+
+// Ensures that we skip fields we don't know about, so older callers can still decode newer messages.
+func (suite *ProtobufSuite) TestUnmarshal_unknownFields() {
+	r := suite.Require()
+
+	data, err := protobuf.Marshal(Person{Name: "Jeff", Age: 48, Height: 1.8, Tags: []string{"dude"}})
+	r.NoError(err)
+
+	output := struct {
+		Name string
+		Age  int
+	}{}
+	r.NoError(protobuf.Unmarshal(data, &output))
+	r.Equal("Jeff", output.Name)
+	r.Equal(48, output.Age)
+}
+
+// Ensures that we accept repeated numbers whether or not they're packed.
+func (suite *ProtobufSuite) TestUnmarshal_unpacked() {
+	r := suite.Require()
+
+	output := struct{ Lucky []int }{}
+	r.NoError(protobuf.Unmarshal([]byte{0x08, 0x03, 0x08, 0x8e, 0x02}, &output))
+	r.Equal([]int{3, 270}, output.Lucky)
+}
+
+// Ensures that we reject messages that aren't well-formed or don't match the struct.
+func (suite *ProtobufSuite) TestUnmarshal_invalid() {
+	r := suite.Require()
+
+	r.Error(protobuf.Unmarshal([]byte{0x0a, 0x07, 't'}, &Address{}), "Should fail when the message is cut off")
+	r.Error(protobuf.Unmarshal([]byte{0x08, 0x01}, &Address{}), "Should fail when the wire type doesn't match")
+	r.Error(protobuf.Unmarshal([]byte{0x0b}, &Address{}), "Should fail on unsupported wire types")
+	r.Error(protobuf.Unmarshal(nil, Address{}), "Should fail when the output isn't a pointer")
+
+	_, err := protobuf.Marshal("nope")
+	r.Error(err, "Should only marshal structs")
+}
+
+type SearchResult struct {
+	*UserMatch
+	*GroupMatch
+}
+
+type UserMatch struct {
+	ID   string
+	Name string
+}
+
+type GroupMatch struct {
+	ID      string
+	Members int
+}
+
+// Ensures that unions encode each alternative as its own field, so we only populate the one that's set.
+func (suite *ProtobufSuite) TestOneOf() {
+	r := suite.Require()
+
+	data, err := protobuf.MarshalOneOf(&SearchResult{GroupMatch: &GroupMatch{ID: "1", Members: 3}})
+	r.NoError(err)
+	r.Equal([]byte{0x12, 0x05, 0x0a, 0x01, '1', 0x10, 0x03}, data, "Should be field 2 w/ the nested message")
+
+	output := SearchResult{}
+	r.NoError(protobuf.UnmarshalOneOf(data, &output))
+	r.Nil(output.UserMatch)
+	r.Equal(&GroupMatch{ID: "1", Members: 3}, output.GroupMatch)
+
+	data, err = protobuf.MarshalOneOf(&SearchResult{UserMatch: &UserMatch{}})
+	r.NoError(err)
+	output = SearchResult{}
+	r.NoError(protobuf.UnmarshalOneOf(data, &output))
+	r.Equal(&UserMatch{}, output.UserMatch, "Should set the alternative even when it's empty")
+	r.Nil(output.GroupMatch)
+}
+
+func TestProtobufSuite(t *testing.T) {
+	suite.Run(t, new(ProtobufSuite))
+}
@@ -0,0 +1,188 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/protobuf"
+)
+
+type protobufRequest struct {
+	Name   string
+	Values []int
+}
+
+type protobufResponse struct {
+	Greeting string
+	Total    int
+}
+
+// greetEndpoint creates the "POST /greet" endpoint, which greets the name and adds up the values. It records
+// the Content-Type of each request it receives in 'contentTypes'.
+func greetEndpoint(gw *rpc.Gateway, contentTypes *[]string) rpc.Endpoint {
+	mutex := sync.Mutex{}
+	return testEndpoint("POST", "/greet", "Greet", func(w http.ResponseWriter, req *http.Request) {
+		mutex.Lock()
+		*contentTypes = append(*contentTypes, req.Header.Get("Content-Type"))
+		mutex.Unlock()
+
+		serviceRequest := protobufRequest{}
+		if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
+			rpc.Fail(w, req, err)
+			return
+		}
+		response := protobufResponse{Greeting: "Hello " + serviceRequest.Name}
+		for _, value := range serviceRequest.Values {
+			response.Total += value
+		}
+		rpc.Reply(w, req, 200, response)
+	})
+}
+
+// Ensures that the gateway only speaks protobuf when it's enabled and the caller asks for it. Errors and envelopes
+// are always JSON, even when the caller accepts protobuf.
+func (suite *GatewaySuite) TestWithProtobuf() {
+	requestBody, err := protobuf.Marshal(protobufRequest{Name: "Dude", Values: []int{1, 2, 3}})
+	suite.Require().NoError(err)
+	enabled := []rpc.GatewayOption{rpc.WithProtobuf()}
+
+	tests := []struct {
+		name        string
+		options     []rpc.GatewayOption
+		contentType string
+		accept      string
+		body        string
+		status      int
+		// protobuf is the response that we expect back as protobuf (otherwise we expect the 'json' one).
+		protobuf *protobufResponse
+		vary     string
+		// json contains the top-level attributes that we expect in the JSON response (others aren't checked).
+		json string
+	}{
+		{
+			name:        "protobuf",
+			options:     enabled,
+			contentType: rpc.ProtobufContentType,
+			accept:      "application/x-protobuf, application/json",
+			body:        string(requestBody),
+			status:      200,
+			protobuf:    &protobufResponse{Greeting: "Hello Dude", Total: 6},
+			vary:        "Accept",
+		},
+		{
+			name:        "should respond w/ JSON when the caller doesn't accept protobuf",
+			options:     enabled,
+			contentType: rpc.ProtobufContentType,
+			accept:      "application/json",
+			body:        string(requestBody),
+			status:      200,
+			json:        `{"Greeting":"Hello Dude", "Total":6}`,
+			vary:        "Accept",
+		},
+		{
+			name:        "should respect q=0",
+			options:     enabled,
+			contentType: "application/json",
+			accept:      "application/x-protobuf;q=0, application/json",
+			body:        `{"Name":"Walter"}`,
+			status:      200,
+			json:        `{"Greeting":"Hello Walter", "Total":0}`,
+			vary:        "Accept",
+		},
+		{
+			name:        "should reject malformed protobuf bodies w/ JSON errors",
+			options:     enabled,
+			contentType: rpc.ProtobufContentType,
+			accept:      rpc.ProtobufContentType,
+			body:        string([]byte{0x0a, 0x07}),
+			status:      400,
+			json:        `{"status":400}`,
+		},
+		{
+			name:        "should ignore protobuf unless it's enabled",
+			contentType: "application/json",
+			accept:      rpc.ProtobufContentType,
+			body:        `{"Name":"Donny"}`,
+			status:      200,
+			json:        `{"Greeting":"Hello Donny", "Total":0}`,
+		},
+		{
+			name:        "envelope",
+			options:     []rpc.GatewayOption{rpc.WithProtobuf(), rpc.WithResponseEnvelope()},
+			contentType: "application/json",
+			accept:      rpc.ProtobufContentType,
+			body:        `{"Name":"Dude"}`,
+			status:      200,
+			json:        `{"data":{"Greeting":"Hello Dude", "Total":0}}`,
+			vary:        "Accept",
+		},
+	}
+	for _, test := range tests {
+		r := suite.Require()
+		var contentTypes []string
+		gw := suite.newGateway(test.options...)
+		gw.Register(greetEndpoint(&gw, &contentTypes))
+
+		req := httptest.NewRequest("POST", "/greet", strings.NewReader(test.body))
+		req.Header.Set("Content-Type", test.contentType)
+		req.Header.Set("Accept", test.accept)
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+
+		r.Equal(test.status, w.Code, test.name)
+		r.Equal(test.vary, w.Header().Get("Vary"), test.name)
+		if test.protobuf != nil {
+			r.Equal(rpc.ProtobufContentType, w.Header().Get("Content-Type"), test.name)
+			response := protobufResponse{}
+			r.NoError(protobuf.Unmarshal(w.Body.Bytes(), &response), test.name)
+			r.Equal(*test.protobuf, response, test.name)
+			continue
+		}
+
+		r.True(strings.HasPrefix(w.Header().Get("Content-Type"), "application/json"), test.name)
+		actual := map[string]interface{}{}
+		r.NoError(json.Unmarshal(w.Body.Bytes(), &actual), test.name)
+		expected := map[string]interface{}{}
+		r.NoError(json.Unmarshal([]byte(test.json), &expected), test.name)
+		for key, value := range expected {
+			r.Equal(value, actual[key], "%s: %s", test.name, key)
+		}
+	}
+}
+
+// Ensures that the client only sends protobuf bodies once the gateway responds w/ protobuf, and that it falls
+// back to JSON when the gateway doesn't speak protobuf.
+func (suite *GatewaySuite) TestWithClientProtobuf() {
+	tests := []struct {
+		name         string
+		options      []rpc.GatewayOption
+		contentTypes []string
+	}{
+		{name: "protobuf", options: []rpc.GatewayOption{rpc.WithProtobuf()}, contentTypes: []string{"", rpc.ProtobufContentType}},
+		{name: "fallback", contentTypes: []string{"", ""}},
+	}
+	for _, test := range tests {
+		r := suite.Require()
+		var contentTypes []string
+		gw := suite.newGateway(test.options...)
+		gw.Register(greetEndpoint(&gw, &contentTypes))
+		server := httptest.NewServer(gw)
+
+		client := rpc.NewClient("TestService", server.URL, rpc.WithClientProtobuf())
+		for i := 0; i < 2; i++ {
+			response := protobufResponse{}
+			err := client.Invoke(context.Background(), "POST", "/greet", &protobufRequest{Name: "Dude", Values: []int{4, 5}}, &response)
+			r.NoError(err, test.name)
+			r.Equal(protobufResponse{Greeting: "Hello Dude", Total: 9}, response, test.name)
+		}
+		r.Equal(test.contentTypes, contentTypes, test.name)
+		server.Close()
+	}
+}
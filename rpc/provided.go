@@ -0,0 +1,161 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type contextKeyProvided struct{}
+
+// providedFields keeps track of every attribute that the caller actually sent (body, path, query, header, or cookie)
+// so that service functions can tell the difference between "you didn't send a Name" and "you sent an empty Name".
+type providedFields struct {
+	// keys are the normalized names (see providedKey) of the attributes that we know the caller provided.
+	keys map[string]bool
+	// body is the raw JSON body. We don't need to walk its attributes unless someone calls Provided(), so
+	// we hold onto it until then rather than making every request pay for it.
+	body []byte
+	// once makes sure that we only parse the body the first time we need it.
+	once sync.Once
+}
+
+// Provided returns true when the caller explicitly supplied a value for the given field, even if that value was the
+// zero value (e.g. `{"Name": ""}` or "?Limit=0"). This lets PATCH-style functions only update what the caller asked
+// them to update:
+//
+//     func (svc *UserServiceHandler) UpdateUser(ctx context.Context, req *UpdateUserRequest) (*UpdateUserResponse, error) {
+//         if rpc.Provided(ctx, "Email") {
+//             user.Email = req.Email
+//         }
+//         if rpc.Provided(ctx, "Address.ZipCode") {
+//             user.Address.ZipCode = req.Address.ZipCode
+//         }
+//         ...
+//     }
+//
+// Field names are the request attribute names (json tags included), using dots for nested values, and they're
+// case-insensitive. A nested name like "Address" is provided when the caller sent any part of the address.
+//
+// This only knows what the gateway's default binder saw, so it always returns false when the function isn't
+// invoked through a gateway. Use WithProvidedFields() to indicate what the caller sent when you call the
+// service directly (e.g. in your tests).
+func Provided(ctx context.Context, field string) bool {
+	provided := providedFieldsFromContext(ctx)
+	if provided == nil {
+		return false
+	}
+
+	key := providedKey(field)
+	for providedKey := range provided.all() {
+		if providedKey == key || strings.HasPrefix(providedKey, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// ProvidedFields returns the normalized (lower case, dot-separated) names of every attribute that the caller
+// explicitly supplied. The names are sorted, so parents like "address" come before "address.zipcode".
+func ProvidedFields(ctx context.Context) []string {
+	provided := providedFieldsFromContext(ctx)
+	if provided == nil {
+		return nil
+	}
+
+	var keys []string
+	for key := range provided.all() {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WithProvidedFields returns a child context that reports the given fields as the ones that the caller supplied.
+// The gateway does this for you, so you only need it when calling your service directly (e.g. in your tests).
+func WithProvidedFields(ctx context.Context, fields ...string) context.Context {
+	provided := &providedFields{keys: map[string]bool{}}
+	for _, field := range fields {
+		provided.add(field)
+	}
+	return context.WithValue(ctx, contextKeyProvided{}, provided)
+}
+
+// trackProvidedFields gives the binder a place to record which attributes the caller sent so that
+// Provided() works in the service function.
+func trackProvidedFields(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	provided := &providedFields{keys: map[string]bool{}}
+	next(w, req.WithContext(context.WithValue(req.Context(), contextKeyProvided{}, provided)))
+}
+
+func providedFieldsFromContext(ctx context.Context) *providedFields {
+	if ctx == nil {
+		return nil
+	}
+	provided, _ := ctx.Value(contextKeyProvided{}).(*providedFields)
+	return provided
+}
+
+// add records that the caller provided a value for the attribute (e.g. "Address.ZipCode").
+func (provided *providedFields) add(field string) {
+	if provided != nil {
+		provided.keys[providedKey(field)] = true
+	}
+}
+
+// addKey records the attribute when we've already normalized its name (see providedKey).
+func (provided *providedFields) addKey(key string) {
+	if provided != nil {
+		provided.keys[key] = true
+	}
+}
+
+// addBody holds onto the raw JSON body so that we can figure out which attributes it contains if/when we need to.
+func (provided *providedFields) addBody(body []byte) {
+	if provided != nil {
+		provided.body = body
+	}
+}
+
+// all returns every attribute the caller provided, including the ones in the JSON body.
+func (provided *providedFields) all() map[string]bool {
+	provided.once.Do(func() {
+		if len(provided.body) == 0 {
+			return
+		}
+		attributes := map[string]json.RawMessage{}
+		if err := json.Unmarshal(provided.body, &attributes); err != nil {
+			return
+		}
+		provided.addAttributes("", attributes)
+		provided.body = nil
+	})
+	return provided.keys
+}
+
+// addAttributes records the JSON object's attributes, walking into nested objects so that we know
+// about "address.zipcode" and not just "address".
+func (provided *providedFields) addAttributes(prefix string, attributes map[string]json.RawMessage) {
+	for name, value := range attributes {
+		provided.add(prefix + name)
+
+		value = bytes.TrimSpace(value)
+		if len(value) == 0 || value[0] != '{' {
+			continue
+		}
+		children := map[string]json.RawMessage{}
+		if err := json.Unmarshal(value, &children); err == nil {
+			provided.addAttributes(prefix+name+".", children)
+		}
+	}
+}
+
+// providedKey normalizes the attribute name so that lookups ignore case and snake_case ("first_name" is the
+// same as "FirstName"), just like the binder does.
+func providedKey(field string) string {
+	return strings.ToLower(strings.ReplaceAll(field, "_", ""))
+}
@@ -0,0 +1,137 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+type providedAddress struct {
+	City    string
+	ZipCode string `json:"zip_code"`
+}
+
+type providedRequest struct {
+	ID       string
+	Name     *string
+	Age      int
+	Address  *providedAddress `json:"address"`
+	TenantID string           `frodo:"header=X-Tenant-ID"`
+}
+
+type providedResponse struct {
+	Fields  []string
+	Name    bool
+	Age     bool
+	Address bool
+	ZipCode bool
+	City    bool
+}
+
+// newProvidedGateway creates a gateway whose "/users/:ID" endpoints bind the request the same way that generated
+// gateways do, and respond w/ what the service function would see from Provided() and ProvidedFields().
+func (suite *GatewaySuite) newProvidedGateway() rpc.Gateway {
+	gw := suite.newGateway()
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		serviceRequest := providedRequest{}
+		if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
+			rpc.Fail(w, req, err)
+			return
+		}
+		ctx := req.Context()
+		rpc.Reply(w, req, 200, providedResponse{
+			Fields:  rpc.ProvidedFields(ctx),
+			Name:    rpc.Provided(ctx, "Name"),
+			Age:     rpc.Provided(ctx, "age"),
+			Address: rpc.Provided(ctx, "Address"),
+			ZipCode: rpc.Provided(ctx, "Address.ZipCode"),
+			City:    rpc.Provided(ctx, "Address.City"),
+		})
+	}
+	gw.Register(testEndpoint("PATCH", "/users/:ID", "Patch", handler))
+	gw.Register(testEndpoint("GET", "/users/:ID", "Get", handler))
+	return gw
+}
+
+// Ensures that we know which attributes the caller sent in the body or query string, even when they're zero/empty
+// values. Attributes are provided when any nested attribute is, and we ignore case and snake_case.
+func (suite *GatewaySuite) TestProvided() {
+	gw := suite.newProvidedGateway()
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		header   http.Header
+		expected providedResponse
+	}{
+		{
+			name:     "body",
+			method:   "PATCH",
+			path:     "/users/123",
+			body:     `{"Name":"", "address":{"zip_code":""}}`,
+			header:   http.Header{"Content-Type": {"application/json"}, "X-Tenant-ID": {"abc"}},
+			expected: providedResponse{Fields: []string{"address", "address.zipcode", "id", "name", "tenantid"}, Name: true, Address: true, ZipCode: true},
+		},
+		{
+			name:     "query",
+			method:   "GET",
+			path:     "/users/123?Age=0&Address.City=",
+			expected: providedResponse{Fields: []string{"address.city", "age", "id"}, Age: true, Address: true, City: true},
+		},
+	}
+	for _, test := range tests {
+		r := suite.Require()
+		req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		for key, values := range test.header {
+			req.Header[http.CanonicalHeaderKey(key)] = values
+		}
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+		r.Equal(200, w.Code, w.Body.String())
+
+		response := providedResponse{}
+		r.NoError(json.Unmarshal(w.Body.Bytes(), &response), test.name)
+		r.Equal(test.expected, response, test.name)
+	}
+}
+
+// Ensures that the Go client only sends the pointer fields that you actually set, so nil pointers don't
+// look like the caller explicitly provided a null.
+func (suite *GatewaySuite) TestProvided_client() {
+	r := suite.Require()
+	server := httptest.NewServer(suite.newProvidedGateway())
+	defer server.Close()
+	client := rpc.NewClient("TestService", server.URL)
+
+	response := providedResponse{}
+	r.NoError(client.Invoke(context.Background(), "PATCH", "/users/:ID", &providedRequest{ID: "123"}, &response))
+	r.Equal([]string{"age", "id"}, response.Fields, "Should not send nil pointers")
+
+	name := ""
+	serviceRequest := &providedRequest{ID: "123", Name: &name, Address: &providedAddress{}, TenantID: "abc"}
+	r.NoError(client.Invoke(context.Background(), "PATCH", "/users/:ID", serviceRequest, &response))
+	r.Equal([]string{"address", "address.city", "address.zipcode", "age", "id", "name", "tenantid"}, response.Fields)
+}
+
+// Ensures that you can indicate which fields were provided when calling a service directly.
+func (suite *GatewaySuite) TestWithProvidedFields() {
+	r := suite.Require()
+	r.False(rpc.Provided(context.Background(), "Name"), "Should be false outside of a gateway")
+	r.Nil(rpc.ProvidedFields(context.Background()))
+
+	ctx := rpc.WithProvidedFields(context.Background(), "Name", "Address.ZipCode")
+	r.True(rpc.Provided(ctx, "name"))
+	r.True(rpc.Provided(ctx, "address"))
+	r.True(rpc.Provided(ctx, "Address.Zip_Code"))
+	r.False(rpc.Provided(ctx, "Age"))
+	r.False(rpc.Provided(ctx, "Address.City"))
+	r.False(rpc.Provided(ctx, "Addr"), "Should only match whole attribute names")
+	r.Equal([]string{"address.zipcode", "name"}, rpc.ProvidedFields(ctx))
+}
@@ -0,0 +1,67 @@
+package rpc
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+// ProxyHandler creates the handler for an endpoint that forwards its requests to another HTTP server rather than
+// invoking your service function. This is what generated gateways use for functions w/ the "PROXY" doc option, so
+// that a service that you're migrating into frodo can expose a single, unified API while some of its functions
+// still live in the legacy backend:
+//
+//     // GetUser fetches a user's profile (still handled by the old monolith for now).
+//     //
+//     // GET /user/:id
+//     // PROXY http://legacy:8080
+//     GetUser(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error)
+//
+// The backend receives the same method, path, query string, headers, and body that the gateway did. If the target
+// URL has a path (e.g. "http://legacy:8080/api"), it goes in front of the request's path. The usual "X-Forwarded-For",
+// "X-Forwarded-Host", and "X-Forwarded-Proto" headers tell the backend who originally made the request. If the
+// backend can't be reached, the caller gets a 502. The gateway's middleware still runs before the request is
+// forwarded, so things like authorization and request size limits still apply.
+func ProxyHandler(target string) http.HandlerFunc {
+	targetURL, err := url.Parse(target)
+	if err != nil || targetURL.Scheme == "" || targetURL.Host == "" {
+		return func(w http.ResponseWriter, req *http.Request) {
+			Fail(w, req, errors.Unexpected("invalid proxy target: '%s'", target))
+		}
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			if req.Header.Get("X-Forwarded-Host") == "" {
+				req.Header.Set("X-Forwarded-Host", req.Host)
+			}
+			req.Header.Set("X-Forwarded-Proto", forwardedProto(req))
+			req.URL.Scheme = targetURL.Scheme
+			req.URL.Host = targetURL.Host
+			// Keep the escaped path, too, so that encoded slashes and such make it to the backend as-is.
+			rawPath := req.URL.EscapedPath()
+			req.URL.Path = strings.TrimSuffix(targetURL.Path, "/") + req.URL.Path
+			req.URL.RawPath = strings.TrimSuffix(targetURL.EscapedPath(), "/") + rawPath
+			req.Host = targetURL.Host
+		},
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			Fail(w, req, errors.New(http.StatusBadGateway, "proxy error: %v", err))
+		},
+	}
+	return proxy.ServeHTTP
+}
+
+// forwardedProto returns the protocol the caller used to reach us, trusting any proxies that were already in front of us.
+func forwardedProto(req *http.Request) string {
+	switch {
+	case req.Header.Get("X-Forwarded-Proto") != "":
+		return req.Header.Get("X-Forwarded-Proto")
+	case req.TLS != nil:
+		return "https"
+	default:
+		return "http"
+	}
+}
@@ -0,0 +1,115 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+// proxyEcho is what the legacy backend responds with so we can see exactly what the gateway forwarded.
+type proxyEcho struct {
+	Method        string
+	Path          string
+	Query         string
+	Host          string
+	Body          string
+	TenantID      string
+	ForwardedHost string
+	ForwardedFor  string
+}
+
+// Ensures that the backend receives the original method, path, query, headers, and body (w/ the target's path in
+// front of the request's path), and that we fail w/ a 502 when the backend is down and a 500 when the target is garbage.
+func (suite *GatewaySuite) TestProxyHandler() {
+	// The legacy backend echoes back the request so we can see exactly what the gateway forwarded.
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		w.Header().Set("X-Legacy", "true")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(proxyEcho{
+			Method:        req.Method,
+			Path:          req.URL.EscapedPath(),
+			Query:         req.URL.RawQuery,
+			Host:          req.Host,
+			Body:          string(body),
+			TenantID:      req.Header.Get("X-Tenant-ID"),
+			ForwardedHost: req.Header.Get("X-Forwarded-Host"),
+			ForwardedFor:  req.Header.Get("X-Forwarded-For"),
+		})
+	}))
+	defer backend.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	tests := []struct {
+		name     string
+		target   string
+		path     string
+		body     string
+		status   int
+		echo     proxyEcho
+		contains string
+	}{
+		{
+			name:   "forward",
+			target: backend.URL,
+			path:   "/v2/user/a%2Fb/rename?force=true",
+			body:   `{"Name":"Dude"}`,
+			status: http.StatusCreated,
+			echo: proxyEcho{
+				Method:        "POST",
+				Path:          "/v2/user/a%2Fb/rename",
+				Query:         "force=true",
+				Host:          strings.TrimPrefix(backend.URL, "http://"),
+				Body:          `{"Name":"Dude"}`,
+				TenantID:      "acme",
+				ForwardedHost: "example.com",
+			},
+		},
+		{
+			name:   "target path",
+			target: backend.URL + "/legacy/",
+			path:   "/v2/user/123/rename",
+			status: http.StatusCreated,
+			echo: proxyEcho{
+				Method:        "POST",
+				Path:          "/legacy/v2/user/123/rename",
+				Host:          strings.TrimPrefix(backend.URL, "http://"),
+				TenantID:      "acme",
+				ForwardedHost: "example.com",
+			},
+		},
+		{name: "backend down", target: down.URL, path: "/v2/user/123/rename", status: http.StatusBadGateway, contains: "proxy error"},
+		{name: "invalid target", target: "legacy:8080", path: "/v2/user/123/rename", status: http.StatusInternalServerError, contains: "invalid proxy target"},
+	}
+	for _, test := range tests {
+		r := suite.Require()
+		gw := suite.newGateway()
+		gw.PathPrefix = "/v2"
+		gw.Register(testEndpoint("POST", "/user/:id/rename", "Rename", rpc.ProxyHandler(test.target)))
+
+		req := httptest.NewRequest("POST", test.path, strings.NewReader(test.body))
+		req.Header.Set("X-Tenant-ID", "acme")
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+		r.Equal(test.status, w.Code, test.name)
+
+		if test.contains != "" {
+			r.Contains(w.Body.String(), test.contains, test.name)
+			continue
+		}
+		r.Equal("true", w.Header().Get("X-Legacy"), "%s: Should preserve the backend's headers", test.name)
+		echo := proxyEcho{}
+		r.NoError(json.Unmarshal(w.Body.Bytes(), &echo), test.name)
+		r.NotEmpty(echo.ForwardedFor, test.name)
+		echo.ForwardedFor = ""
+		r.Equal(test.echo, echo, test.name)
+	}
+}
@@ -0,0 +1,326 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/monadicstack/frodo/rpc/authorization"
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/events"
+	"github.com/monadicstack/frodo/rpc/metadata"
+)
+
+// QueueConn is the subset of a request/reply messaging system (e.g. NATS) that gateways and clients need in
+// order to invoke service functions over a message queue instead of HTTP. Frodo doesn't depend on any specific
+// messaging library, so you adapt your connection of choice to this interface. For NATS that looks like this:
+//
+//     type NATSConn struct {
+//         Conn *nats.Conn
+//     }
+//
+//     func (c NATSConn) Request(ctx context.Context, subject string, data []byte) ([]byte, error) {
+//         msg, err := c.Conn.RequestWithContext(ctx, subject, data)
+//         if err != nil {
+//             return nil, err
+//         }
+//         return msg.Data, nil
+//     }
+//
+//     func (c NATSConn) Subscribe(subject string, group string, handler func([]byte) []byte) (rpc.QueueSubscription, error) {
+//         return c.Conn.QueueSubscribe(subject, group, func(msg *nats.Msg) {
+//             msg.Respond(handler(msg.Data))
+//         })
+//     }
+type QueueConn interface {
+	// Request sends the data to the subject and waits for the reply (or for the context to end).
+	Request(ctx context.Context, subject string, data []byte) ([]byte, error)
+	// Subscribe invokes the handler for every message sent to the subject, replying w/ the bytes that it
+	// returns. Subscribers in the same group share the load; each message goes to only one of them.
+	Subscribe(subject string, group string, handler func(data []byte) []byte) (QueueSubscription, error)
+}
+
+// QueueSubscription represents an active subscription to a subject on a QueueConn.
+type QueueSubscription interface {
+	// Unsubscribe stops the delivery of messages to the subscriber.
+	Unsubscribe() error
+}
+
+// QueueMessage is the payload that clients send to a service function's subject. It carries the
+// same information that the HTTP transport sends in the request body and headers.
+type QueueMessage struct {
+	// Metadata is the JSON-encoded metadata from the caller's context (the "X-RPC-Values" header in HTTP).
+	Metadata string `json:",omitempty"`
+	// Authorization is the caller's credentials (the "Authorization" header in HTTP).
+	Authorization string `json:",omitempty"`
+	// Body is the JSON-encoded service request.
+	Body json.RawMessage `json:",omitempty"`
+}
+
+// Decode unmarshals the message body into the service request struct.
+func (msg QueueMessage) Decode(serviceRequest interface{}) error {
+	if len(msg.Body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(msg.Body, serviceRequest); err != nil {
+		return errors.BadRequest("unable to decode request: %v", err)
+	}
+	return nil
+}
+
+// queueReply is the payload that the queue server sends back to the client after invoking the function.
+type queueReply struct {
+	Body            json.RawMessage  `json:",omitempty"`
+	Content         []byte           `json:",omitempty"`
+	ContentType     string           `json:",omitempty"`
+	ContentFileName string           `json:",omitempty"`
+	Error           *errors.RPCError `json:",omitempty"`
+}
+
+// QueueHandlerFunc is the generated function that decodes the service request from the message, invokes
+// your service function, and returns the service response.
+type QueueHandlerFunc func(ctx context.Context, msg QueueMessage) (interface{}, error)
+
+// QueueServerOption defines a setting you can apply when creating a queue server via NewQueueServer().
+type QueueServerOption func(*QueueServer)
+
+// WithQueueGroup changes the group that all of the server's subscriptions belong to. Every instance of your
+// service in the same group shares the load. The default group is the name of the service.
+func WithQueueGroup(group string) QueueServerOption {
+	return func(server *QueueServer) {
+		server.Group = group
+	}
+}
+
+// WithQueueEventBroker lets your service functions publish events using events.Emit() when invoked over
+// the message queue. It works exactly like WithEventBroker() does for HTTP gateways.
+func WithQueueEventBroker(broker events.Broker) QueueServerOption {
+	return func(server *QueueServer) {
+		server.EventBroker = broker
+	}
+}
+
+// NewQueueServer creates the message-driven equivalent of a Gateway. Rather than routing HTTP requests, it
+// subscribes to a subject for each function (e.g. "UserService.CreateUser"), decodes requests from the
+// messages, and replies w/ the responses. You typically won't call this yourself; use the generated
+// "NewXxxServiceQueueGateway()" function from "frodo gateway --transport=nats" instead.
+func NewQueueServer(conn QueueConn, options ...QueueServerOption) *QueueServer {
+	server := &QueueServer{conn: conn}
+	for _, option := range options {
+		option(server)
+	}
+	return server
+}
+
+// QueueServer manages the subscriptions for all of a service's functions on a message queue. Gateway
+// middleware is HTTP-specific, so it does not apply to functions invoked this way.
+type QueueServer struct {
+	// Group is the queue group shared by all instances of the service (defaults to the service name).
+	Group string
+	// EventBroker (optional) publishes the events that service functions emit.
+	EventBroker events.Broker
+
+	conn          QueueConn
+	mutex         sync.Mutex
+	subscriptions []QueueSubscription
+}
+
+// Register subscribes to the endpoint's "Service.Function" subject, invoking the handler for every message.
+func (server *QueueServer) Register(endpoint Endpoint, handler QueueHandlerFunc) error {
+	group := server.Group
+	if group == "" {
+		group = endpoint.ServiceName
+	}
+
+	subscription, err := server.conn.Subscribe(endpoint.String(), group, func(data []byte) []byte {
+		return server.handle(endpoint, handler, data)
+	})
+	if err != nil {
+		return fmt.Errorf("rpc: unable to subscribe to '%s': %w", endpoint.String(), err)
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.subscriptions = append(server.subscriptions, subscription)
+	return nil
+}
+
+// Close unsubscribes from all of the service's subjects, so this instance stops handling messages.
+func (server *QueueServer) Close() error {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	var firstErr error
+	for _, subscription := range server.subscriptions {
+		if err := subscription.Unsubscribe(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	server.subscriptions = nil
+	return firstErr
+}
+
+// Run lets the queue server be one of the background components of a Server (see WithServerComponents). The
+// subscriptions are already active, so this just unsubscribes from everything when the server shuts down.
+func (server *QueueServer) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return server.Close()
+}
+
+// handle does the queue equivalent of all of the gateway's built-in middleware before running the
+// generated handler and encoding its result (or failure) as the reply.
+func (server *QueueServer) handle(endpoint Endpoint, handler QueueHandlerFunc, data []byte) []byte {
+	msg := QueueMessage{}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return encodeQueueReply(context.Background(), nil, errors.BadRequest("unable to decode message: %v", err))
+	}
+
+	values, err := metadata.FromJSON(msg.Metadata)
+	if err != nil {
+		return encodeQueueReply(context.Background(), nil, errors.BadRequest("rpc metadata error: %v", err))
+	}
+
+	ctx := context.WithValue(context.Background(), contextKeyEndpoint{}, endpoint)
+	ctx = metadata.WithValues(ctx, values)
+	ctx = authorization.WithHeader(ctx, authorization.New(msg.Authorization))
+	if server.EventBroker != nil {
+		ctx = events.WithOutbox(ctx, server.EventBroker)
+	}
+
+	if endpoint.Auth == AuthRequired && authorization.FromContext(ctx).Empty() {
+		return encodeQueueReply(ctx, nil, errors.BadCredentials("authorization required"))
+	}
+
+	serviceResponse, err := invokeQueueHandler(ctx, handler, msg)
+	if err == nil {
+		err = events.Flush(ctx)
+	}
+	return encodeQueueReply(ctx, serviceResponse, err)
+}
+
+// invokeQueueHandler runs the handler, converting panics into errors so that one bad message
+// doesn't take down the whole server.
+func invokeQueueHandler(ctx context.Context, handler QueueHandlerFunc, msg QueueMessage) (serviceResponse interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			serviceResponse, err = nil, errors.Unexpected("%v", recovered)
+		}
+	}()
+	return handler(ctx, msg)
+}
+
+// encodeQueueReply converts the service response (or error) into the bytes we reply to the caller with.
+func encodeQueueReply(ctx context.Context, serviceResponse interface{}, err error) []byte {
+	reply := queueReply{}
+	if err == nil {
+		err = encodeQueueReplyBody(&reply, serviceResponse)
+	}
+	if err != nil {
+		reply = queueReply{Error: toRPCError(ctx, err)}
+	}
+
+	data, err := json.Marshal(reply)
+	if err != nil {
+		data, _ = json.Marshal(queueReply{Error: &errors.RPCError{
+			HTTPStatus: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("unable to encode reply: %v", err),
+		}})
+	}
+	return data
+}
+
+// encodeQueueReplyBody fills in either the JSON body or the raw content of the reply, depending
+// on whether the service response is a ContentReader.
+func encodeQueueReplyBody(reply *queueReply, serviceResponse interface{}) error {
+	contentReader, ok := serviceResponse.(ContentReader)
+	if !ok {
+		body, err := json.Marshal(serviceResponse)
+		reply.Body = body
+		return err
+	}
+
+	content := contentReader.Content()
+	if content == nil {
+		return nil
+	}
+	defer content.Close()
+
+	var err error
+	if reply.Content, err = ioutil.ReadAll(content); err != nil {
+		return err
+	}
+	if typeReader, ok := serviceResponse.(ContentTypeReader); ok {
+		reply.ContentType = typeReader.ContentType()
+	}
+	if fileNameReader, ok := serviceResponse.(ContentFileNameReader); ok {
+		reply.ContentFileName = fileNameReader.ContentFileName()
+	}
+	return nil
+}
+
+// WithQueueConn makes the client invoke service functions by sending messages to their subjects instead
+// of making HTTP requests. The subjects map each function's route (e.g. "POST /CalculatorService.Add") to its
+// subject (e.g. "CalculatorService.Add"). You typically won't call this yourself; use the generated
+// "WithXxxServiceQueue()" option from "frodo gateway --transport=nats" instead. Client middleware is
+// HTTP-specific, so it does not apply to calls made this way.
+func WithQueueConn(conn QueueConn, subjects map[string]string) ClientOption {
+	return func(rpcClient *Client) {
+		rpcClient.Queue = conn
+		rpcClient.QueueSubjects = subjects
+	}
+}
+
+// invokeQueue is the message queue equivalent of Invoke().
+func (c Client) invokeQueue(ctx context.Context, method string, path string, serviceRequest interface{}, serviceResponse interface{}) error {
+	subject, ok := c.QueueSubjects[method+" "+path]
+	if !ok {
+		return fmt.Errorf("rpc: %s %s is not available over the message queue", method, path)
+	}
+
+	msg := QueueMessage{Authorization: c.AuthorizationProvider.resolve(ctx).String()}
+	var err error
+	if msg.Metadata, err = metadata.ToJSON(ctx); err != nil {
+		return fmt.Errorf("rpc: unable to encode metadata: %w", err)
+	}
+	if msg.Body, err = json.Marshal(serviceRequest); err != nil {
+		return fmt.Errorf("rpc: unable to create request body: %w", err)
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("rpc: unable to create request: %w", err)
+	}
+
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+
+	replyData, err := c.Queue.Request(ctx, subject, data)
+	if err != nil {
+		return fmt.Errorf("rpc: round trip error: %w", err)
+	}
+
+	reply := queueReply{}
+	if err = json.Unmarshal(replyData, &reply); err != nil {
+		return fmt.Errorf("rpc: unable to decode response: %w", err)
+	}
+	if reply.Error != nil {
+		return c.toError(*reply.Error)
+	}
+	if contentWriter, ok := serviceResponse.(ContentWriter); ok {
+		contentWriter.SetContent(ioutil.NopCloser(bytes.NewReader(reply.Content)))
+		if typeWriter, ok := serviceResponse.(ContentTypeWriter); ok {
+			typeWriter.SetContentType(reply.ContentType)
+		}
+		if fileNameWriter, ok := serviceResponse.(ContentFileNameWriter); ok {
+			fileNameWriter.SetContentFileName(reply.ContentFileName)
+		}
+		return nil
+	}
+	if err = json.Unmarshal(reply.Body, serviceResponse); err != nil {
+		return fmt.Errorf("rpc: unable to decode response: %w", err)
+	}
+	return nil
+}
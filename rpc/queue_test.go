@@ -0,0 +1,209 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/authorization"
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/events"
+	"github.com/monadicstack/frodo/rpc/metadata"
+)
+
+// memoryQueue is a QueueConn that delivers requests to subscribers in the same process.
+type memoryQueue struct {
+	mutex       sync.Mutex
+	subscribers map[string]func([]byte) []byte
+}
+
+func (q *memoryQueue) Request(_ context.Context, subject string, data []byte) ([]byte, error) {
+	q.mutex.Lock()
+	handler, ok := q.subscribers[subject]
+	q.mutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no responders for '%s'", subject)
+	}
+	return handler(data), nil
+}
+
+func (q *memoryQueue) Subscribe(subject string, _ string, handler func([]byte) []byte) (rpc.QueueSubscription, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.subscribers[subject] = handler
+	return memoryQueueSubscription{queue: q, subject: subject}, nil
+}
+
+type memoryQueueSubscription struct {
+	queue   *memoryQueue
+	subject string
+}
+
+func (sub memoryQueueSubscription) Unsubscribe() error {
+	sub.queue.mutex.Lock()
+	defer sub.queue.mutex.Unlock()
+	delete(sub.queue.subscribers, sub.subject)
+	return nil
+}
+
+type queueRequest struct {
+	Name string
+}
+
+type queueResponse struct {
+	Greeting      string
+	Authorization string
+}
+
+type queueRawResponse struct {
+	content     io.ReadCloser
+	contentType string
+}
+
+func (r queueRawResponse) Content() io.ReadCloser            { return r.content }
+func (r queueRawResponse) ContentType() string               { return r.contentType }
+func (r *queueRawResponse) SetContent(content io.ReadCloser) { r.content = content }
+func (r *queueRawResponse) SetContentType(value string)      { r.contentType = value }
+
+// newQueueServer creates a queue server whose "Hello" function greets the caller (failing or panicking for some
+// names) and whose "Download" function responds w/ raw bytes. The client talks to it through the same queue.
+func (suite *ClientSuite) newQueueServer() (*rpc.QueueServer, rpc.Client) {
+	queue := &memoryQueue{subscribers: map[string]func([]byte) []byte{}}
+	server := rpc.NewQueueServer(queue)
+
+	hello := func(ctx context.Context, msg rpc.QueueMessage) (interface{}, error) {
+		serviceRequest := queueRequest{}
+		if err := msg.Decode(&serviceRequest); err != nil {
+			return nil, err
+		}
+		_ = events.Emit(ctx, &greeted{Name: serviceRequest.Name})
+
+		switch serviceRequest.Name {
+		case "fail":
+			return nil, errors.WithCode(errors.PermissionDenied("not allowed"), "NOPE")
+		case "panic":
+			panic("kaboom")
+		}
+
+		greeting := ""
+		metadata.Value(ctx, "greeting", &greeting)
+		return &queueResponse{
+			Greeting:      greeting + " " + serviceRequest.Name + " from " + rpc.EndpointFromContext(ctx).String(),
+			Authorization: authorization.FromContext(ctx).String(),
+		}, nil
+	}
+	download := func(ctx context.Context, msg rpc.QueueMessage) (interface{}, error) {
+		return queueRawResponse{content: ioutil.NopCloser(strings.NewReader("raw bytes")), contentType: "text/plain"}, nil
+	}
+
+	r := suite.Require()
+	r.NoError(server.Register(rpc.Endpoint{Method: "POST", Path: "/hello", ServiceName: "QueueService", Name: "Hello"}, hello))
+	r.NoError(server.Register(rpc.Endpoint{Method: "POST", Path: "/secret", ServiceName: "QueueService", Name: "Secret", Auth: rpc.AuthRequired}, hello))
+	r.NoError(server.Register(rpc.Endpoint{Method: "GET", Path: "/download", ServiceName: "QueueService", Name: "Download"}, download))
+
+	client := rpc.NewClient("QueueService", "", rpc.WithQueueConn(queue, map[string]string{
+		"POST /hello":   "QueueService.Hello",
+		"POST /secret":  "QueueService.Secret",
+		"GET /download": "QueueService.Download",
+		"GET /missing":  "QueueService.Missing",
+	}))
+	return server, client
+}
+
+// Ensures that the client invokes functions through the queue and gets back the response.
+func (suite *ClientSuite) TestWithQueueConn() {
+	r := suite.Require()
+	server, client := suite.newQueueServer()
+	defer func() { _ = server.Close() }()
+	ctx := metadata.WithValue(context.Background(), "greeting", "Hello")
+	ctx = authorization.WithHeader(ctx, authorization.New("Token 12345"))
+
+	res := queueResponse{}
+	r.NoError(client.Invoke(ctx, "POST", "/hello", &queueRequest{Name: "Dude"}, &res))
+	r.Equal("Hello Dude from QueueService.Hello", res.Greeting, "Should restore metadata and endpoint info")
+	r.Equal("Token 12345", res.Authorization, "Should restore the caller's authorization")
+}
+
+// Ensures that raw/file responses survive the trip through the queue.
+func (suite *ClientSuite) TestWithQueueConn_raw() {
+	r := suite.Require()
+	server, client := suite.newQueueServer()
+	defer func() { _ = server.Close() }()
+
+	res := queueRawResponse{}
+	r.NoError(client.Invoke(context.Background(), "GET", "/download", &queueRequest{}, &res))
+	content, err := ioutil.ReadAll(res.content)
+	r.NoError(err)
+	r.Equal("raw bytes", string(content))
+	r.Equal("text/plain", res.contentType)
+}
+
+// Ensures that failures come back w/ the same status, code, etc. as the HTTP transport.
+func (suite *ClientSuite) TestWithQueueConn_errors() {
+	server, client := suite.newQueueServer()
+	defer func() { _ = server.Close() }()
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		request queueRequest
+		status  int
+		code    string
+		message string
+	}{
+		{name: "failure", method: "POST", path: "/hello", request: queueRequest{Name: "fail"}, status: 403, code: "NOPE", message: "not allowed"},
+		{name: "should recover from panics", method: "POST", path: "/hello", request: queueRequest{Name: "panic"}, status: 500, message: "kaboom"},
+		{name: "should enforce AUTH required", method: "POST", path: "/secret", request: queueRequest{Name: "Dude"}, status: 401},
+		{name: "should fail for routes w/o a subject", method: "DELETE", path: "/hello"},
+		{name: "should fail when nobody is subscribed to the subject", method: "GET", path: "/missing"},
+	}
+	for _, test := range tests {
+		r := suite.Require()
+		err := client.Invoke(context.Background(), test.method, test.path, &test.request, &queueResponse{})
+		r.Error(err, test.name)
+		if test.status != 0 {
+			r.Equal(test.status, errors.Status(err), test.name)
+		}
+		r.Equal(test.code, errors.Code(err), test.name)
+		r.Contains(err.Error(), test.message, test.name)
+	}
+}
+
+// Ensures that events emitted by successful functions are published and failed ones are discarded.
+func (suite *ClientSuite) TestQueueServer_eventBroker() {
+	r := suite.Require()
+	server, client := suite.newQueueServer()
+	defer func() { _ = server.Close() }()
+	broker := events.NewMemoryBroker()
+	var received []string
+	_, err := broker.Subscribe("greeted", func(ctx context.Context, msg events.Message) error {
+		event := greeted{}
+		_ = msg.Decode(&event)
+		received = append(received, event.Name)
+		return nil
+	})
+	r.NoError(err)
+
+	server.EventBroker = broker
+	r.NoError(client.Invoke(context.Background(), "POST", "/hello", &queueRequest{Name: "Dude"}, &queueResponse{}))
+	r.Error(client.Invoke(context.Background(), "POST", "/hello", &queueRequest{Name: "fail"}, &queueResponse{}))
+	r.Equal([]string{"Dude"}, received)
+}
+
+// Ensures that closing the server stops it from handling messages.
+func (suite *ClientSuite) TestQueueServer_close() {
+	r := suite.Require()
+	server, client := suite.newQueueServer()
+	defer func() { _ = server.Close() }()
+	r.NoError(server.Close())
+
+	err := client.Invoke(context.Background(), "POST", "/hello", &queueRequest{Name: "Dude"}, &queueResponse{})
+	r.Error(err)
+}
@@ -0,0 +1,103 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+type contextKeyRawHTTP struct{}
+
+// rawHTTP is what the gateway puts on the context so that service functions can reach the underlying HTTP
+// request/response using HTTPRequest() and HTTPResponseWriter().
+type rawHTTP struct {
+	request *http.Request
+	writer  *rawResponseWriter
+}
+
+// HTTPRequest is an escape hatch that gives your service function the raw HTTP request that the gateway is
+// handling. Frodo's whole point is that your service doesn't know or care about HTTP, so only reach for this
+// in the edge cases that the gateway can't handle for you (e.g. a custom auth protocol that signs the raw
+// headers). The gateway has already bound the body to your request struct, so don't try to read it again.
+//
+// This returns nil when the function is not being invoked through a gateway (e.g. you call the service directly
+// in your tests), so make sure that your function still does something sensible when that happens.
+func HTTPRequest(ctx context.Context) *http.Request {
+	if raw, ok := ctx.Value(contextKeyRawHTTP{}).(*rawHTTP); ok {
+		return raw.request
+	}
+	return nil
+}
+
+// HTTPResponseWriter is an escape hatch that lets your service function write the HTTP response itself (e.g.
+// to upgrade the connection to a websocket). Once you write to it (or hijack the connection), the gateway
+// won't write anything else, so the response/error that your function returns is ignored:
+//
+//     func (svc *ChatServiceHandler) Connect(ctx context.Context, req *ConnectRequest) (*ConnectResponse, error) {
+//         w, r := rpc.HTTPResponseWriter(ctx), rpc.HTTPRequest(ctx)
+//         if w == nil {
+//             return nil, errors.BadRequest("websockets are only supported via the gateway")
+//         }
+//         conn, err := upgrader.Upgrade(w, r, nil)
+//         ...
+//         return &ConnectResponse{}, nil
+//     }
+//
+// Only use this for "normal" functions. "ASYNC" and "SSE" functions have already responded by the time they
+// run. Like HTTPRequest(), this returns nil when the function isn't being invoked through a gateway.
+func HTTPResponseWriter(ctx context.Context) http.ResponseWriter {
+	if raw, ok := ctx.Value(contextKeyRawHTTP{}).(*rawHTTP); ok {
+		return raw.writer
+	}
+	return nil
+}
+
+// restoreRawHTTP gives the handler access to the raw request/response so that HTTPRequest() and
+// HTTPResponseWriter() work.
+func restoreRawHTTP(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	raw := &rawHTTP{request: req, writer: &rawResponseWriter{ResponseWriter: w}}
+	next(w, req.WithContext(context.WithValue(req.Context(), contextKeyRawHTTP{}, raw)))
+}
+
+// respondedRaw returns true when the service function already wrote the response using HTTPResponseWriter().
+func respondedRaw(req *http.Request) bool {
+	raw, ok := req.Context().Value(contextKeyRawHTTP{}).(*rawHTTP)
+	return ok && raw.writer.responded
+}
+
+// rawResponseWriter is the writer we hand to service functions. It remembers whether the function wrote
+// anything so that the gateway doesn't try to write a second response.
+type rawResponseWriter struct {
+	http.ResponseWriter
+	responded bool
+}
+
+func (w *rawResponseWriter) WriteHeader(status int) {
+	w.responded = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *rawResponseWriter) Write(data []byte) (int, error) {
+	w.responded = true
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush sends any buffered data to the caller if the underlying writer supports it.
+func (w *rawResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.responded = true
+		flusher.Flush()
+	}
+}
+
+// Hijack lets the function take over the connection (e.g. for websockets) if the underlying writer supports it.
+func (w *rawResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	w.responded = true
+	return hijacker.Hijack()
+}
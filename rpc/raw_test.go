@@ -0,0 +1,137 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+// rawEndpoint creates the "GET /raw" endpoint, which calls the service function the same way that generated
+// gateways do (replying w/ its result or failing w/ its error).
+func rawEndpoint(serviceFunc func(ctx context.Context) (interface{}, error)) rpc.Endpoint {
+	return testEndpoint("GET", "/raw", "Raw", func(w http.ResponseWriter, req *http.Request) {
+		serviceResponse, err := serviceFunc(req.Context())
+		if err != nil {
+			rpc.Fail(w, req, err)
+			return
+		}
+		rpc.Reply(w, req, 200, serviceResponse)
+	})
+}
+
+// Ensures that the service function can see the raw request and write its own response. The gateway shouldn't
+// write a second response once the function writes its own, but it still responds normally when the function
+// doesn't write anything itself.
+func (suite *GatewaySuite) TestHTTPResponseWriter() {
+	tests := []struct {
+		name        string
+		serviceFunc func(ctx context.Context) (interface{}, error)
+		status      int
+		body        string
+		json        string
+		header      http.Header
+	}{
+		{
+			name: "request",
+			serviceFunc: func(ctx context.Context) (interface{}, error) {
+				req := rpc.HTTPRequest(ctx)
+				return map[string]string{"signature": req.Header.Get("X-Signature"), "query": req.URL.RawQuery}, nil
+			},
+			status: 200,
+			json:   `{"signature":"abc123", "query":"q=1"}`,
+		},
+		{
+			name: "response",
+			serviceFunc: func(ctx context.Context) (interface{}, error) {
+				writer := rpc.HTTPResponseWriter(ctx)
+				writer.Header().Set("Content-Type", "text/plain")
+				writer.WriteHeader(http.StatusTeapot)
+				_, _ = writer.Write([]byte("short and stout"))
+				return map[string]string{"ignored": "true"}, nil
+			},
+			status: http.StatusTeapot,
+			body:   "short and stout",
+			header: http.Header{"Content-Type": {"text/plain"}},
+		},
+		{
+			name: "should ignore errors once the function responded",
+			serviceFunc: func(ctx context.Context) (interface{}, error) {
+				_, _ = rpc.HTTPResponseWriter(ctx).Write([]byte("partial"))
+				return nil, errors.Unexpected("nope")
+			},
+			status: 200,
+			body:   "partial",
+		},
+		{
+			name: "setting headers alone should not count as responding",
+			serviceFunc: func(ctx context.Context) (interface{}, error) {
+				rpc.HTTPResponseWriter(ctx).Header().Set("X-Custom", "yes")
+				return map[string]string{"ok": "true"}, nil
+			},
+			status: 200,
+			json:   `{"ok":"true"}`,
+			header: http.Header{"X-Custom": {"yes"}},
+		},
+	}
+	for _, test := range tests {
+		r := suite.Require()
+		gw := suite.newGateway()
+		gw.Register(rawEndpoint(test.serviceFunc))
+
+		req := httptest.NewRequest("GET", "/raw?q=1", nil)
+		req.Header.Set("X-Signature", "abc123")
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+
+		r.Equal(test.status, w.Code, test.name)
+		if test.body != "" {
+			r.Equal(test.body, w.Body.String(), test.name)
+		}
+		if test.json != "" {
+			r.JSONEq(test.json, w.Body.String(), test.name)
+		}
+		for key, values := range test.header {
+			r.Equal(values, w.Header().Values(key), test.name)
+		}
+	}
+
+	suite.Require().Nil(rpc.HTTPRequest(context.Background()), "Should be nil outside of the gateway")
+	suite.Require().Nil(rpc.HTTPResponseWriter(context.Background()), "Should be nil outside of the gateway")
+}
+
+// Ensures that the function can take over the connection entirely (e.g. websocket upgrades).
+func (suite *GatewaySuite) TestHTTPResponseWriter_hijack() {
+	r := suite.Require()
+	gw := suite.newGateway()
+	gw.Register(rawEndpoint(func(ctx context.Context) (interface{}, error) {
+		conn, buf, err := rpc.HTTPResponseWriter(ctx).(http.Hijacker).Hijack()
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		_, _ = buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: dude\r\nConnection: Upgrade\r\n\r\nabides")
+		_ = buf.Flush()
+		return map[string]string{"ignored": "true"}, nil
+	}))
+	server := httptest.NewServer(gw)
+	defer server.Close()
+
+	req, err := http.NewRequest("GET", server.URL+"/raw", nil)
+	r.NoError(err)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "dude")
+	res, err := suite.HTTPClient.Do(req)
+	r.NoError(err)
+	defer res.Body.Close()
+	r.Equal(http.StatusSwitchingProtocols, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	r.NoError(err)
+	r.Equal("abides", string(body))
+}
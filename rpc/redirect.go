@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+)
+
+// Redirector allows service responses to redirect the caller to another URL rather than responding w/ JSON. This
+// is handy when your function locates/authorizes a resource, but something else (S3, a CDN, etc.) actually serves
+// it up. The status should be one of the 3XX redirect statuses; the gateway uses 307 when it isn't:
+//
+//     func (res DownloadResponse) Redirect() (string, int) {
+//         return "https://" + res.Bucket + ".s3.amazonaws.com/" + res.Key, http.StatusFound
+//     }
+//
+// The gateway still supports the respond.Redirector interface from 'github.com/monadicstack/respond', which
+// always responds w/ a 307.
+type Redirector interface {
+	// Redirect returns the URL to redirect the caller to as well as the HTTP status to do it with.
+	Redirect() (string, int)
+}
+
+// RedirectWriter allows service responses to capture the target of a redirect instead of following it. By default,
+// the Go client follows redirects just like any other HTTP client would. When your response implements this
+// interface, the client stops at the redirect and hands you the URL (resolved against the service's address)
+// and status instead. This is useful when the caller wants to pass the URL along (e.g. to a browser) rather
+// than download the resource itself.
+type RedirectWriter interface {
+	// SetRedirect applies the redirect's target URL and HTTP status to the response.
+	SetRedirect(url string, status int)
+}
+
+// contextKeyNoRedirect marks client requests that should not follow redirects.
+type contextKeyNoRedirect struct{}
+
+// writeRedirect responds w/ a redirect to the URL of the given Redirector.
+func writeRedirect(w http.ResponseWriter, req *http.Request, response Redirector) {
+	location, status := response.Redirect()
+	if status < 300 || status > 399 {
+		status = http.StatusTemporaryRedirect
+	}
+	http.Redirect(w, req, location, status)
+}
+
+// withoutRedirects marks the request context so that the client stops at the first redirect rather than following it.
+func withoutRedirects(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKeyNoRedirect{}, true)
+}
+
+// do sends the HTTP request, following redirects unless the request was marked using withoutRedirects().
+func (c Client) do(request *http.Request) (*http.Response, error) {
+	if noRedirect, _ := request.Context().Value(contextKeyNoRedirect{}).(bool); !noRedirect {
+		return c.HTTP.Do(request)
+	}
+
+	// Shallow copy so that we don't change the behavior of an HTTP client that you supplied via WithHTTPClient().
+	httpClient := *c.HTTP
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return httpClient.Do(request)
+}
+
+// isRedirect returns true when the response is a redirect that has a target URL (i.e. not a 304).
+func isRedirect(response *http.Response) bool {
+	return response.StatusCode >= 300 && response.StatusCode <= 399 && response.Header.Get("Location") != ""
+}
+
+// decodeRedirect populates the RedirectWriter w/ the target of the redirect response.
+func (c Client) decodeRedirect(response *http.Response, serviceResponse RedirectWriter) error {
+	defer response.Body.Close()
+
+	location, err := response.Location()
+	if err != nil {
+		return err
+	}
+	serviceResponse.SetRedirect(location.String(), response.StatusCode)
+	return nil
+}
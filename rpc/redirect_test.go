@@ -0,0 +1,86 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+type redirectRequest struct {
+	Status int
+}
+
+// redirectResponse sends the caller to "/target" using whatever status the request asked for.
+type redirectResponse struct {
+	status int
+}
+
+func (res redirectResponse) Redirect() (string, int) {
+	return "/target", res.status
+}
+
+// redirectTarget is what the client gets when it follows the redirect.
+type redirectTarget struct {
+	Text string
+}
+
+// redirectLink captures the redirect rather than following it.
+type redirectLink struct {
+	URL    string
+	Status int
+}
+
+func (link *redirectLink) SetRedirect(url string, status int) {
+	link.URL = url
+	link.Status = status
+}
+
+// Ensures that the gateway responds w/ the redirect's status and location (using a 307 for non-3XX statuses), and
+// that the client follows redirects unless the response implements RedirectWriter.
+func (suite *GatewaySuite) TestRedirect() {
+	r := suite.Require()
+	gw := suite.newGateway()
+	gw.Register(testEndpoint("GET", "/redirect", "Redirect", func(w http.ResponseWriter, req *http.Request) {
+		serviceRequest := redirectRequest{}
+		if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
+			rpc.Fail(w, req, err)
+			return
+		}
+		rpc.Reply(w, req, 200, redirectResponse{status: serviceRequest.Status})
+	}))
+	gw.Register(testEndpoint("GET", "/target", "Target", func(w http.ResponseWriter, req *http.Request) {
+		rpc.Reply(w, req, 200, redirectTarget{Text: "You made it"})
+	}))
+
+	suite.assertResponses(gw, []exchange{
+		{method: "GET", path: "/redirect?Status=302", status: 302, responseHeader: http.Header{"Location": {"/target"}}},
+		{method: "GET", path: "/redirect?Status=200", status: 307, responseHeader: http.Header{"Location": {"/target"}}},
+	})
+
+	server := httptest.NewServer(gw)
+	defer server.Close()
+
+	httpClient := &http.Client{}
+	client := rpc.NewClient("TestService", server.URL, rpc.WithHTTPClient(httpClient))
+
+	target := redirectTarget{}
+	err := client.Invoke(context.Background(), "GET", "/redirect", &redirectRequest{Status: 302}, &target)
+	r.NoError(err)
+	r.Equal("You made it", target.Text)
+
+	link := redirectLink{}
+	err = client.Invoke(context.Background(), "GET", "/redirect", &redirectRequest{Status: 308}, &link)
+	r.NoError(err)
+	r.Equal(server.URL+"/target", link.URL, "Should resolve the location against the service's address")
+	r.Equal(308, link.Status)
+	r.Nil(httpClient.CheckRedirect, "Should not modify your HTTP client")
+
+	target = redirectTarget{}
+	err = client.Invoke(context.Background(), "GET", "/target", nil, &target)
+	r.NoError(err)
+	r.Equal("You made it", target.Text, "Should work normally when there's no redirect")
+}
@@ -0,0 +1,112 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc/authorization"
+)
+
+// TokenRefreshFunc fetches a new bearer token for the client (e.g. by calling your OAuth2 token endpoint w/ a
+// refresh token or client credentials). It returns the token and the time that it expires. A zero expiration
+// means that we use the token until the service rejects it.
+type TokenRefreshFunc func(ctx context.Context) (token string, expiresAt time.Time, err error)
+
+// WithTokenRefresh makes the client manage its own bearer token. It fetches one using 'refresh' before the first
+// call, reuses it for every call after that, and fetches a new one once it expires. When a service responds w/ a
+// 401 anyway (e.g. the token was revoked), the client refreshes the token and retries the call once w/ the new one:
+//
+//     client := usersrpc.NewUserServiceClient("http://localhost:9000",
+//         rpc.WithTokenRefresh(func(ctx context.Context) (string, time.Time, error) {
+//             token, err := oauthConfig.Token(ctx)
+//             if err != nil {
+//                 return "", time.Time{}, err
+//             }
+//             return token.AccessToken, token.Expiry, nil
+//         }),
+//     )
+//
+// Concurrent calls share a single refresh rather than each fetching their own token. Calls whose context already
+// has credentials (e.g. the ones your gateway received from your caller) are left alone; we never swap the caller's
+// identity for the client's. The token also takes priority over your AuthorizationProvider, if you have one.
+func WithTokenRefresh(refresh TokenRefreshFunc) ClientOption {
+	return func(rpcClient *Client) {
+		rpcClient.tokenRefresher = &tokenRefresher{refresh: refresh}
+	}
+}
+
+// tokenRefresher memoizes the client's bearer token, fetching a new one when it expires or gets rejected.
+type tokenRefresher struct {
+	mutex     sync.Mutex
+	refresh   TokenRefreshFunc
+	token     string
+	expiresAt time.Time
+}
+
+// authorize is client middleware that writes the client's bearer token to requests that don't have credentials
+// on their context, refreshing the token and retrying the request once when the service responds w/ a 401.
+func (refresher *tokenRefresher) authorize(request *http.Request, next RoundTripperFunc) (*http.Response, error) {
+	ctx := request.Context()
+	if authorization.FromContext(ctx).NotEmpty() {
+		return next(request)
+	}
+
+	token, err := refresher.current(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to refresh token: %w", err)
+	}
+
+	// Downstream middleware (e.g. compression) might modify the request, so send a copy in case we need to retry.
+	attempt := request.Clone(ctx)
+	attempt.Header.Set("Authorization", authorization.NewBearer(token).String())
+	response, err := next(attempt)
+	if err != nil || response.StatusCode != http.StatusUnauthorized {
+		return response, err
+	}
+
+	// We can only retry when we're able to send the same body again (service requests always can).
+	body := request.Body
+	if body != nil && body != http.NoBody {
+		if request.GetBody == nil {
+			return response, nil
+		}
+		if body, err = request.GetBody(); err != nil {
+			return response, nil
+		}
+	}
+
+	_ = response.Body.Close()
+	token, err = refresher.current(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("unable to refresh token: %w", err)
+	}
+
+	attempt = request.Clone(ctx)
+	attempt.Body = body
+	attempt.Header.Set("Authorization", authorization.NewBearer(token).String())
+	return next(attempt)
+}
+
+// current returns the memoized token, refreshing it when we don't have one yet, it expired, or it's the
+// 'rejected' token that the service just responded to w/ a 401. If another call already replaced the
+// rejected token, we use that one rather than refreshing again.
+func (refresher *tokenRefresher) current(ctx context.Context, rejected string) (string, error) {
+	refresher.mutex.Lock()
+	defer refresher.mutex.Unlock()
+
+	expired := !refresher.expiresAt.IsZero() && !time.Now().Before(refresher.expiresAt)
+	if refresher.token != "" && refresher.token != rejected && !expired {
+		return refresher.token, nil
+	}
+
+	token, expiresAt, err := refresher.refresh(ctx)
+	if err != nil {
+		return "", err
+	}
+	refresher.token = token
+	refresher.expiresAt = expiresAt
+	return token, nil
+}
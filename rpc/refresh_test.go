@@ -0,0 +1,187 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/authorization"
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+type refreshRequest struct {
+	Text string
+}
+
+// refreshBackend is a server that only accepts the token in 'valid' and echoes back the request's text. The
+// channel receives the "Authorization" header of every request.
+type refreshBackend struct {
+	*httptest.Server
+	valid atomic.Value
+	seen  chan string
+}
+
+func newRefreshBackend(valid string) *refreshBackend {
+	backend := &refreshBackend{seen: make(chan string, 100)}
+	backend.valid.Store(valid)
+	backend.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		backend.seen <- req.Header.Get("Authorization")
+		if req.Header.Get("Authorization") != "Bearer "+backend.valid.Load().(string) {
+			rpc.Fail(w, req, errors.BadCredentials("invalid token"))
+			return
+		}
+		body, _ := ioutil.ReadAll(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	return backend
+}
+
+// drain returns the "Authorization" headers the backend has seen since the last time you drained it.
+func (backend *refreshBackend) drain() []string {
+	var headers []string
+	for {
+		select {
+		case header := <-backend.seen:
+			headers = append(headers, header)
+		default:
+			return headers
+		}
+	}
+}
+
+// newRefresh returns a refresh function that hands out "token-1", "token-2", etc, each of which expires
+// after 'ttl' (0 means never). The counter tracks how many times it was called.
+func newRefresh(ttl time.Duration) (rpc.TokenRefreshFunc, *int32) {
+	calls := int32(0)
+	return func(ctx context.Context) (string, time.Time, error) {
+		token := fmt.Sprintf("token-%d", atomic.AddInt32(&calls, 1))
+		if ttl == 0 {
+			return token, time.Time{}, nil
+		}
+		return token, time.Now().Add(ttl), nil
+	}, &calls
+}
+
+// Ensures that we fetch a token before the first call and keep using it after that.
+func (suite *ClientSuite) TestWithTokenRefresh() {
+	r := suite.Require()
+	backend := newRefreshBackend("token-1")
+	defer backend.Close()
+
+	refresh, calls := newRefresh(0)
+	client := rpc.NewClient("RefreshService", backend.URL, rpc.WithTokenRefresh(refresh))
+
+	for i := 0; i < 3; i++ {
+		response := refreshRequest{}
+		r.NoError(client.Invoke(context.Background(), "POST", "/echo", &refreshRequest{Text: "hi"}, &response))
+		r.Equal("hi", response.Text)
+	}
+	r.Equal(int32(1), atomic.LoadInt32(calls))
+	r.Equal([]string{"Bearer token-1", "Bearer token-1", "Bearer token-1"}, backend.drain())
+}
+
+// Ensures that we fetch a new token once the current one expires, even if the service would still accept it.
+func (suite *ClientSuite) TestWithTokenRefresh_expiration() {
+	r := suite.Require()
+	backend := newRefreshBackend("token-2")
+	defer backend.Close()
+
+	refresh, calls := newRefresh(10 * time.Millisecond)
+	client := rpc.NewClient("RefreshService", backend.URL, rpc.WithTokenRefresh(refresh))
+
+	_ = client.Invoke(context.Background(), "POST", "/echo", &refreshRequest{}, &refreshRequest{})
+	r.Equal(int32(2), atomic.LoadInt32(calls), "Should refresh and retry after the 401")
+	r.Equal([]string{"Bearer token-1", "Bearer token-2"}, backend.drain())
+
+	time.Sleep(20 * time.Millisecond)
+	backend.valid.Store("token-3")
+	r.NoError(client.Invoke(context.Background(), "POST", "/echo", &refreshRequest{}, &refreshRequest{}))
+	r.Equal(int32(3), atomic.LoadInt32(calls))
+	r.Equal([]string{"Bearer token-3"}, backend.drain(), "Should refresh the expired token before sending the call")
+}
+
+// Ensures that we refresh the token and retry (w/ the same body) when the service rejects it, but only once.
+func (suite *ClientSuite) TestWithTokenRefresh_retry() {
+	r := suite.Require()
+	backend := newRefreshBackend("token-2")
+	defer backend.Close()
+
+	refresh, calls := newRefresh(0)
+	client := rpc.NewClient("RefreshService", backend.URL, rpc.WithTokenRefresh(refresh))
+
+	response := refreshRequest{}
+	r.NoError(client.Invoke(context.Background(), "POST", "/echo", &refreshRequest{Text: "hi"}, &response))
+	r.Equal("hi", response.Text, "Should send the same body on the retry")
+	r.Equal([]string{"Bearer token-1", "Bearer token-2"}, backend.drain())
+
+	backend.valid.Store("nope")
+	err := client.Invoke(context.Background(), "POST", "/echo", &refreshRequest{Text: "hi"}, &response)
+	r.True(errors.IsBadCredentials(err), "Should give up after a single retry")
+	r.Equal(int32(3), atomic.LoadInt32(calls))
+	r.Equal([]string{"Bearer token-2", "Bearer token-3"}, backend.drain())
+}
+
+// Ensures that concurrent calls that get rejected share a single refresh.
+func (suite *ClientSuite) TestWithTokenRefresh_concurrent() {
+	r := suite.Require()
+	backend := newRefreshBackend("token-1")
+	defer backend.Close()
+
+	refresh, calls := newRefresh(0)
+	client := rpc.NewClient("RefreshService", backend.URL, rpc.WithTokenRefresh(refresh))
+	r.NoError(client.Invoke(context.Background(), "POST", "/echo", &refreshRequest{}, &refreshRequest{}))
+
+	backend.valid.Store("token-2")
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.NoError(client.Invoke(context.Background(), "POST", "/echo", &refreshRequest{}, &refreshRequest{}))
+		}()
+	}
+	wg.Wait()
+	r.Equal(int32(2), atomic.LoadInt32(calls))
+}
+
+// Ensures that refresh failures fail the call rather than sending it w/o credentials.
+func (suite *ClientSuite) TestWithTokenRefresh_error() {
+	r := suite.Require()
+	backend := newRefreshBackend("")
+	defer backend.Close()
+
+	client := rpc.NewClient("RefreshService", backend.URL, rpc.WithTokenRefresh(func(ctx context.Context) (string, time.Time, error) {
+		return "", time.Time{}, errors.Unavailable("token service is down")
+	}))
+
+	err := client.Invoke(context.Background(), "POST", "/echo", &refreshRequest{}, &refreshRequest{})
+	r.Error(err)
+	r.True(errors.IsUnavailable(err))
+	r.Contains(err.Error(), "token service is down")
+	r.Empty(backend.drain())
+}
+
+// Ensures that we leave credentials on the context alone and never replace them w/ the client's token.
+func (suite *ClientSuite) TestWithTokenRefresh_contextCredentials() {
+	r := suite.Require()
+	backend := newRefreshBackend("token-1")
+	defer backend.Close()
+
+	refresh, calls := newRefresh(0)
+	client := rpc.NewClient("RefreshService", backend.URL, rpc.WithTokenRefresh(refresh))
+
+	ctx := authorization.WithToken(context.Background(), "caller")
+	err := client.Invoke(ctx, "POST", "/echo", &refreshRequest{}, &refreshRequest{})
+	r.True(errors.IsBadCredentials(err))
+	r.Equal(int32(0), atomic.LoadInt32(calls))
+	r.Equal([]string{"Bearer caller"}, backend.drain())
+}
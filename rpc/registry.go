@@ -0,0 +1,158 @@
+package rpc
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// newEndpointRegistry creates an empty registry for a gateway's endpoints.
+func newEndpointRegistry() *endpointRegistry {
+	return &endpointRegistry{
+		endpoints:  map[route]Endpoint{},
+		handlers:   map[route]http.HandlerFunc{},
+		shapes:     map[route]route{},
+		routed:     map[route]bool{},
+		paramNames: map[string]string{},
+	}
+}
+
+// endpointRegistry tracks the endpoints that a gateway exposes. Endpoints can come and go while the gateway is
+// serving requests (see Gateway.Unregister), so every access goes through the lock. The router can't remove
+// routes, so it always invokes the registry's current handler for the route instead (see Gateway.dispatch).
+//
+// The router treats "GET /foo/:a" and "GET /foo/:b" as the same route, so we do, too. Only one endpoint can have
+// a given method and shape (see routeShape) at a time, and the router's route for that shape dispatches to it
+// no matter which param names it was originally added with. The router also panics when two routes use
+// different names for the same param (e.g. "GET /foo/:a" and "POST /foo/:b"), so we always give it the first
+// name that we saw for each param (see routerPath) and rename them for the current endpoint (see Gateway.dispatch).
+type endpointRegistry struct {
+	mutex sync.RWMutex
+	// endpoints are the registered endpoints keyed by the full route (PathPrefix included), including the
+	// implicit OPTIONS route for each path.
+	endpoints map[route]Endpoint
+	// handlers are the middleware+handler pipelines for the routes in 'endpoints'.
+	handlers map[route]http.HandlerFunc
+	// shapes maps the shape of each route (see route.shape) to the full route that currently has it.
+	shapes map[route]route
+	// routed are the shapes that we've added to the router at some point, even if they've been unregistered since.
+	routed map[route]bool
+	// paramNames are the names of the path params that we've given the router, keyed by the shape of the path up
+	// to and including the param (e.g. "/foo/:" for the ":a" in "/foo/:a/bar").
+	paramNames map[string]string
+}
+
+// routerPath returns the path that we give the router for the endpoint's full path. It's the same path, but
+// each param has the name that we first gave the router for it, so "/foo/:b/baz" becomes "/foo/:a/baz" if
+// you've already registered "/foo/:a/bar". You must already have the write lock when calling this.
+func (registry *endpointRegistry) routerPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		key := routeShape(strings.Join(segments[:i+1], "/"))
+		if name, ok := registry.paramNames[key]; ok {
+			segments[i] = name
+			continue
+		}
+		registry.paramNames[key] = segment
+	}
+	return strings.Join(segments, "/")
+}
+
+// add registers the endpoint and its handler for the route, replacing the endpoint that has the same method and
+// shape (if any). You must already have the write lock when calling this.
+func (registry *endpointRegistry) add(r route, endpoint Endpoint, handler http.HandlerFunc) {
+	registry.remove(r)
+	registry.shapes[r.shape()] = r
+	registry.endpoints[r] = endpoint
+	registry.handlers[r] = handler
+}
+
+// remove unregisters the endpoint that has the same method and shape as the route, returning false when there
+// isn't one. You must already have the write lock when calling this.
+func (registry *endpointRegistry) remove(r route) bool {
+	current, ok := registry.shapes[r.shape()]
+	if !ok {
+		return false
+	}
+	delete(registry.shapes, r.shape())
+	delete(registry.endpoints, current)
+	delete(registry.handlers, current)
+	return true
+}
+
+// routedMethods returns the methods of all of the routes that we've added to the router, in alphabetical order.
+func (registry *endpointRegistry) routedMethods() []string {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	seen := map[string]bool{}
+	var methods []string
+	for r := range registry.routed {
+		if !seen[r.method] {
+			seen[r.method] = true
+			methods = append(methods, r.method)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// current returns the full route, endpoint, and middleware+handler pipeline that currently have the given shape.
+func (registry *endpointRegistry) current(shape route) (route, Endpoint, http.HandlerFunc, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	r, ok := registry.shapes[shape]
+	if !ok {
+		return route{}, Endpoint{}, nil, false
+	}
+	return r, registry.endpoints[r], registry.handlers[r], true
+}
+
+// lookupFunction finds the endpoint for the given service function. Batches (and JSON-RPC calls) can't include
+// other batches.
+func (registry *endpointRegistry) lookupFunction(service string, name string) (Endpoint, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	for r, endpoint := range registry.endpoints {
+		if r.method == http.MethodOptions || endpoint.batch || endpoint.jsonrpc {
+			continue
+		}
+		if endpoint.ServiceName == service && endpoint.Name == name {
+			return endpoint, true
+		}
+	}
+	return Endpoint{}, false
+}
+
+// snapshot returns a copy of all of the registered endpoints keyed by route.
+func (registry *endpointRegistry) snapshot() map[route]Endpoint {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	endpoints := make(map[route]Endpoint, len(registry.endpoints))
+	for r, endpoint := range registry.endpoints {
+		endpoints[r] = endpoint
+	}
+	return endpoints
+}
+
+// sortedRoutes returns the routes in the snapshot in a stable order (by path, then method).
+func sortedRoutes(endpoints map[route]Endpoint) []route {
+	routes := make([]route, 0, len(endpoints))
+	for r := range endpoints {
+		routes = append(routes, r)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].path != routes[j].path {
+			return routes[i].path < routes[j].path
+		}
+		return routes[i].method < routes[j].method
+	})
+	return routes
+}
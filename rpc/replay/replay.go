@@ -0,0 +1,231 @@
+// Package replay provides client middleware that records the calls your client makes to other services and plays
+// them back later. Record the calls once against real services, commit the recordings, and your integration tests
+// can run in CI w/o any of those services (or the network) being available:
+//
+//     client := usersrpc.NewUserServiceClient(usersAddress, rpc.WithClientMiddleware(
+//         replay.Middleware("testdata/recordings"),
+//     ))
+//
+// By default, the middleware replays the recordings in the directory and fails any call that doesn't have one.
+// Run your tests w/ FRODO_REPLAY=record (or use WithMode) to call the real services and save their responses.
+// Calls match a recording based on their method, path, query string, and a hash of the request body, so
+// different requests to the same function get their own recordings.
+package replay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+// EnvMode is the environment variable that sets the default mode ("record" or "replay") when you don't use WithMode.
+const EnvMode = "FRODO_REPLAY"
+
+// Mode determines whether the middleware calls the real service and saves its response or plays back a recording.
+type Mode string
+
+const (
+	// ModeReplay responds to every call w/ its recording and fails calls that don't have one. This is the default.
+	ModeReplay = Mode("replay")
+	// ModeRecord sends every call to the real service and saves the response, replacing any existing recording.
+	ModeRecord = Mode("record")
+)
+
+// Option customizes the behavior of the replay middleware.
+type Option func(*config)
+
+// WithMode explicitly sets whether the middleware records or replays calls, ignoring the FRODO_REPLAY variable.
+func WithMode(mode Mode) Option {
+	return func(c *config) {
+		c.mode = mode
+	}
+}
+
+type config struct {
+	dir  string
+	mode Mode
+}
+
+// Middleware creates client middleware that records calls to (or replays calls from) the given directory.
+func Middleware(dir string, options ...Option) rpc.ClientMiddlewareFunc {
+	c := config{dir: dir, mode: Mode(strings.ToLower(os.Getenv(EnvMode)))}
+	if c.mode == "" {
+		c.mode = ModeReplay
+	}
+	for _, option := range options {
+		option(&c)
+	}
+
+	return func(request *http.Request, next rpc.RoundTripperFunc) (*http.Response, error) {
+		body, err := readBody(request)
+		if err != nil {
+			return nil, err
+		}
+		fileName := filepath.Join(c.dir, recordingName(request, body))
+
+		if c.mode != ModeRecord {
+			return replay(request, fileName)
+		}
+
+		response, err := next(request)
+		if err != nil {
+			return nil, err
+		}
+		return record(request, body, response, fileName)
+	}
+}
+
+// readBody reads the entire request body so that we can hash it, then resets the body so that the
+// rest of the middleware (and the real HTTP client) can still send it.
+func readBody(request *http.Request) ([]byte, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return nil, fmt.Errorf("replay: unable to read request body: %w", err)
+	}
+	_ = request.Body.Close()
+
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	request.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+// recordingName builds the file name for the call, such as "POST_UserService.GetByID_8c1f0e0a3d7b5e21.json". The
+// readable prefix makes it easier to find the recording for a specific function, but the hash is what matters.
+func recordingName(request *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(request.Method + " " + request.URL.RequestURI() + "\n"))
+	hash.Write(body)
+
+	prefix := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.Trim(request.URL.Path, "/"))
+	if len(prefix) > 64 {
+		prefix = prefix[len(prefix)-64:]
+	}
+	return request.Method + "_" + prefix + "_" + hex.EncodeToString(hash.Sum(nil)[:8]) + ".json"
+}
+
+// recording is the file format for a single call. We store bodies as JSON when they are JSON so that the
+// recordings are easy to read (and diff) when you commit them.
+type recording struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+}
+
+type recordedRequest struct {
+	Method string          `json:"method"`
+	URI    string          `json:"uri"`
+	Body   json.RawMessage `json:"body,omitempty"`
+	Raw    []byte          `json:"raw,omitempty"`
+}
+
+type recordedResponse struct {
+	Status int             `json:"status"`
+	Header http.Header     `json:"header,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+	Raw    []byte          `json:"raw,omitempty"`
+}
+
+// record saves the real response to the recording file and hands back a copy of it to the client.
+func record(request *http.Request, body []byte, response *http.Response, fileName string) (*http.Response, error) {
+	responseBody, err := ioutil.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("replay: unable to read response body: %w", err)
+	}
+
+	// The date changes every time, and the length won't match once we re-indent the body.
+	header := response.Header.Clone()
+	header.Del("Date")
+	header.Del("Content-Length")
+
+	rec := recording{
+		Request:  recordedRequest{Method: request.Method, URI: request.URL.RequestURI()},
+		Response: recordedResponse{Status: response.StatusCode, Header: header},
+	}
+	rec.Request.Body, rec.Request.Raw = splitBody(body)
+	rec.Response.Body, rec.Response.Raw = splitBody(responseBody)
+
+	recordingJSON, err := json.MarshalIndent(rec, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("replay: unable to encode recording: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return nil, fmt.Errorf("replay: unable to create recording directory: %w", err)
+	}
+	if err = ioutil.WriteFile(fileName, recordingJSON, 0644); err != nil {
+		return nil, fmt.Errorf("replay: unable to write recording: %w", err)
+	}
+
+	response.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+	return response, nil
+}
+
+// replay builds the response for the call from its recording.
+func replay(request *http.Request, fileName string) (*http.Response, error) {
+	recordingJSON, err := ioutil.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("replay: no recording for %s %s (run w/ %s=record to create %s)",
+			request.Method, request.URL.RequestURI(), EnvMode, fileName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("replay: unable to read recording: %w", err)
+	}
+
+	rec := recording{}
+	if err = json.Unmarshal(recordingJSON, &rec); err != nil {
+		return nil, fmt.Errorf("replay: unable to decode recording %s: %w", fileName, err)
+	}
+
+	body := rec.Response.Raw
+	if len(rec.Response.Body) > 0 {
+		body = rec.Response.Body
+	}
+	header := rec.Response.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Response.Status, http.StatusText(rec.Response.Status)),
+		StatusCode:    rec.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}, nil
+}
+
+// splitBody returns the body as JSON when it's valid JSON or as raw bytes (base64 in the file) when it isn't.
+func splitBody(body []byte) (json.RawMessage, []byte) {
+	switch {
+	case len(body) == 0:
+		return nil, nil
+	case json.Valid(body):
+		return body, nil
+	default:
+		return nil, body
+	}
+}
@@ -0,0 +1,170 @@
+// +build unit
+
+package replay_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/replay"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReplaySuite struct {
+	suite.Suite
+	server *httptest.Server
+	calls  int32
+	dir    string
+}
+
+type echoRequest struct {
+	ID   string
+	Text string
+}
+
+type echoResponse struct {
+	ID   string
+	Text string
+	Call int32
+}
+
+// SetupTest starts a server that echoes back the request along w/ how many calls it has handled so
+// far, so we can tell whether a response came from the server or a recording. Requests for the ID
+// "missing" fail w/ a 404.
+func (suite *ReplaySuite) SetupTest() {
+	suite.calls = 0
+	suite.dir = filepath.Join(suite.T().TempDir(), "recordings")
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		call := atomic.AddInt32(&suite.calls, 1)
+		request := echoRequest{ID: req.URL.Query().Get("ID")}
+		if req.Method == http.MethodPost {
+			_ = json.NewDecoder(req.Body).Decode(&request)
+		}
+		if request.ID == "missing" {
+			rpc.Fail(w, req, errors.NotFound("not found: %s", request.ID))
+			return
+		}
+		rpc.Reply(w, req, http.StatusOK, echoResponse{ID: request.ID, Text: request.Text, Call: call})
+	}))
+}
+
+func (suite *ReplaySuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *ReplaySuite) client(mode replay.Mode) rpc.Client {
+	return rpc.NewClient("EchoService", suite.server.URL, rpc.WithClientMiddleware(
+		replay.Middleware(suite.dir, replay.WithMode(mode)),
+	))
+}
+
+func (suite *ReplaySuite) invoke(client rpc.Client, method string, request echoRequest) (echoResponse, error) {
+	response := echoResponse{}
+	err := client.Invoke(context.Background(), method, "/EchoService.Echo", &request, &response)
+	return response, err
+}
+
+// Ensures that we play back the responses that we recorded w/o calling the service again.
+func (suite *ReplaySuite) TestRecordAndReplay() {
+	r := suite.Require()
+
+	recorder := suite.client(replay.ModeRecord)
+	response, err := suite.invoke(recorder, http.MethodPost, echoRequest{ID: "1", Text: "hello"})
+	r.NoError(err)
+	r.Equal(echoResponse{ID: "1", Text: "hello", Call: 1}, response)
+	response, err = suite.invoke(recorder, http.MethodGet, echoRequest{ID: "2"})
+	r.NoError(err)
+	r.Equal(echoResponse{ID: "2", Call: 2}, response)
+
+	files, err := ioutil.ReadDir(suite.dir)
+	r.NoError(err)
+	r.Len(files, 2)
+
+	suite.server.Close()
+	player := suite.client(replay.ModeReplay)
+	response, err = suite.invoke(player, http.MethodPost, echoRequest{ID: "1", Text: "hello"})
+	r.NoError(err)
+	r.Equal(echoResponse{ID: "1", Text: "hello", Call: 1}, response)
+	response, err = suite.invoke(player, http.MethodGet, echoRequest{ID: "2"})
+	r.NoError(err)
+	r.Equal(echoResponse{ID: "2", Call: 2}, response)
+	r.Equal(int32(2), atomic.LoadInt32(&suite.calls))
+}
+
+// Ensures that recorded failures replay as the same errors.
+func (suite *ReplaySuite) TestRecordAndReplay_error() {
+	r := suite.Require()
+
+	_, err := suite.invoke(suite.client(replay.ModeRecord), http.MethodPost, echoRequest{ID: "missing"})
+	r.True(errors.IsNotFound(err))
+
+	_, err = suite.invoke(suite.client(replay.ModeReplay), http.MethodPost, echoRequest{ID: "missing"})
+	r.True(errors.IsNotFound(err))
+	r.Contains(err.Error(), "not found: missing")
+	r.Equal(int32(1), atomic.LoadInt32(&suite.calls))
+}
+
+// Ensures that calls only match recordings w/ the same method, path, and body.
+func (suite *ReplaySuite) TestReplay_noRecording() {
+	r := suite.Require()
+
+	_, err := suite.invoke(suite.client(replay.ModeRecord), http.MethodPost, echoRequest{ID: "1", Text: "hello"})
+	r.NoError(err)
+
+	player := suite.client(replay.ModeReplay)
+	_, err = suite.invoke(player, http.MethodPost, echoRequest{ID: "1", Text: "goodbye"})
+	r.Error(err)
+	r.Contains(err.Error(), "replay: no recording for POST /EchoService.Echo")
+
+	_, err = suite.invoke(player, http.MethodPut, echoRequest{ID: "1", Text: "hello"})
+	r.Error(err)
+	r.Contains(err.Error(), "replay: no recording for PUT /EchoService.Echo")
+	r.Equal(int32(1), atomic.LoadInt32(&suite.calls), "Should never call the service when replaying")
+}
+
+// Ensures that recording again replaces the old recording w/ the new response.
+func (suite *ReplaySuite) TestRecord_overwrite() {
+	r := suite.Require()
+	recorder := suite.client(replay.ModeRecord)
+
+	_, err := suite.invoke(recorder, http.MethodPost, echoRequest{ID: "1"})
+	r.NoError(err)
+	_, err = suite.invoke(recorder, http.MethodPost, echoRequest{ID: "1"})
+	r.NoError(err)
+
+	response, err := suite.invoke(suite.client(replay.ModeReplay), http.MethodPost, echoRequest{ID: "1"})
+	r.NoError(err)
+	r.Equal(int32(2), response.Call)
+}
+
+// Ensures that the FRODO_REPLAY environment variable picks the mode when we don't use WithMode().
+func (suite *ReplaySuite) TestEnvMode() {
+	r := suite.Require()
+
+	defer os.Unsetenv(replay.EnvMode)
+
+	_ = os.Setenv(replay.EnvMode, "record")
+	client := rpc.NewClient("EchoService", suite.server.URL, rpc.WithClientMiddleware(replay.Middleware(suite.dir)))
+	_, err := suite.invoke(client, http.MethodPost, echoRequest{ID: "1"})
+	r.NoError(err)
+	r.Equal(int32(1), atomic.LoadInt32(&suite.calls))
+
+	_ = os.Unsetenv(replay.EnvMode)
+	client = rpc.NewClient("EchoService", suite.server.URL, rpc.WithClientMiddleware(replay.Middleware(suite.dir)))
+	_, err = suite.invoke(client, http.MethodPost, echoRequest{ID: "1"})
+	r.NoError(err)
+	r.Equal(int32(1), atomic.LoadInt32(&suite.calls), "Should replay by default")
+}
+
+func TestReplaySuite(t *testing.T) {
+	suite.Run(t, new(ReplaySuite))
+}
@@ -0,0 +1,269 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dimfeld/httptreemux/v5"
+	"github.com/urfave/negroni"
+)
+
+// Router is the HTTP router/mux that sends requests to a gateway's endpoints. Gateways use httptreemux by default
+// (see TreeMuxRouter), but you can have them use chi, the standard library's ServeMux, or anything else that
+// implements this (see WithRouter). You can also embed a gateway's endpoints in a router that you're already
+// serving (see Gateway.RegisterRoutes).
+type Router interface {
+	// Handle registers the handler for requests w/ the given method and path. Paths use frodo's syntax (e.g.
+	// "/user/:id" or "/files/*path"), so implementations translate them into their router's own syntax.
+	Handle(method string, path string, handler http.Handler)
+	// Lookup finds the handler that the router would run for the request's method and path. It returns false
+	// when none of the routes match, so that the gateway can respond w/ a 404 or 405 (see WithNotFoundMiddleware).
+	// Running the handler must make the request's path params available to PathParam().
+	Lookup(req *http.Request) (http.Handler, bool)
+	// PathParam returns the value of the named path param (e.g. "id" for "/user/:id") for a request that the
+	// router sent to one of the handlers registered via Handle().
+	PathParam(req *http.Request, name string) string
+}
+
+// WithRouter changes the router that the gateway uses to send requests to your endpoints. Use ChiRouter() or
+// ServeMuxRouter() to adapt the most common ones, or implement the Router interface yourself for anything else:
+//
+//     gateway := users.NewUserServiceGateway(userService,
+//         rpc.WithRouter(rpc.ServeMuxRouter(http.NewServeMux())),
+//     )
+//     http.ListenAndServe(":8080", gateway)
+//
+// Give the gateway a router of its own rather than one that you're already serving; to add the gateway's
+// endpoints to your existing router, use RegisterRoutes() instead. Not every router lets you add routes while
+// it's serving requests, so if you Register() endpoints after you start serving, make sure that yours does.
+func WithRouter(router Router) GatewayOption {
+	return func(gw *Gateway) {
+		gw.router = router
+		gw.Router = nil
+	}
+}
+
+// TreeMuxRouter adapts an httptreemux router (github.com/dimfeld/httptreemux). This is the router that gateways
+// use unless you supply one via WithRouter(). Handlers can also read the path params using httptreemux's
+// ContextParams(), just like they did before gateways supported other routers.
+func TreeMuxRouter(mux *httptreemux.TreeMux) Router {
+	return treeMuxRouter{mux: mux, group: mux.UsingContext()}
+}
+
+type treeMuxRouter struct {
+	mux   *httptreemux.TreeMux
+	group *httptreemux.ContextGroup
+}
+
+func (r treeMuxRouter) Handle(method string, path string, handler http.Handler) {
+	r.group.Handler(method, path, handler)
+}
+
+func (r treeMuxRouter) Lookup(req *http.Request) (http.Handler, bool) {
+	// The lookup itself never writes to the response, so it doesn't need a writer.
+	result, ok := r.mux.Lookup(nil, req)
+	if !ok {
+		return nil, false
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mux.ServeLookupResult(w, req, result)
+	}), true
+}
+
+func (r treeMuxRouter) PathParam(req *http.Request, name string) string {
+	return httptreemux.ContextParams(req.Context())[name]
+}
+
+// RegisterRoutes adds all of the gateway's endpoints to your own router, so you can serve your service functions
+// alongside the rest of your app and wrap them in your router's middleware. The endpoints still run all of the
+// gateway's middleware, binding, etc. just as if you had served the gateway itself:
+//
+//     r := chi.NewRouter()
+//     r.Use(middleware.RequestID, middleware.Logger)
+//     r.Get("/health", healthCheck)
+//
+//     gateway := users.NewUserServiceGateway(userService)
+//     gateway.RegisterRoutes(rpc.ChiRouter(r, chi.URLParam))
+//     http.ListenAndServe(":8080", r)
+//
+// Just like Compose(), this captures the endpoints that are registered when you call it, so functions that you
+// Register() or Unregister() afterwards don't affect your router. Requests that don't match any route are
+// handled by your router, not the gateway's WithNotFoundMiddleware().
+func (gw Gateway) RegisterRoutes(router Router) {
+	endpoints := gw.endpoints.snapshot()
+	options := map[string]bool{}
+	for _, r := range sortedRoutes(endpoints) {
+		endpoint := endpoints[r]
+		if r.method == http.MethodOptions {
+			// Functions like "GET /foo/:bar" and "POST /foo/:goo" each have an implicit OPTIONS route, but
+			// they're the same route as far as most routers are concerned, so only register one of them.
+			if options[routeShape(r.path)] {
+				continue
+			}
+			options[routeShape(r.path)] = true
+			endpoint.Handler = gw.optionsRouteHandler()
+		}
+		router.Handle(r.method, r.path, gw.routeHandler(router, r.path, endpoint))
+	}
+}
+
+// routeHandler creates the handler that your router runs for one of the gateway's routes. It sets up the request
+// the same way that ServeHTTP does, except that the path params come from your router rather than ours.
+func (gw Gateway) routeHandler(router Router, path string, endpoint Endpoint) http.HandlerFunc {
+	handler := composeHandler(gw, endpoint)
+	names := pathParamNames(path)
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := make(map[string]string, len(names))
+		for _, name := range names {
+			params[name] = router.PathParam(req, name)
+		}
+		ctx := context.WithValue(req.Context(), contextKeyPathParams{}, params)
+		if gw.ResponseEnvelope {
+			ctx = context.WithValue(ctx, contextKeyStartTime{}, time.Now())
+		}
+		handler(negroni.NewResponseWriter(w), req.WithContext(ctx))
+	}
+}
+
+type contextKeyPathParams struct{}
+
+// PathParams returns the values of the path params (e.g. {"id": "123"} for "/user/:id") for the route that the
+// request matched, no matter which router routed it. Your service functions receive these on their request
+// structs, so you only need this in handlers that you Register() yourself.
+func PathParams(req *http.Request) map[string]string {
+	if params, ok := req.Context().Value(contextKeyPathParams{}).(map[string]string); ok {
+		return params
+	}
+	return httptreemux.ContextParams(req.Context())
+}
+
+// pathParamNames returns the names of the params in a route's path (e.g. ["id", "path"] for "/user/:id/*path").
+func pathParamNames(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			names = append(names, segment[1:])
+		}
+	}
+	return names
+}
+
+// ChiMux is the part of chi's Router (github.com/go-chi/chi) that ChiRouter() uses. We don't import chi, so
+// any version of it works w/o adding it to your dependencies.
+type ChiMux interface {
+	http.Handler
+	Method(method string, pattern string, handler http.Handler)
+}
+
+// ChiRouter adapts a chi router so that a gateway can use it (see WithRouter) or so that you can embed a gateway's
+// endpoints in it (see Gateway.RegisterRoutes). Pass chi.URLParam as the 'urlParam' function that looks up
+// path params:
+//
+//     gateway.RegisterRoutes(rpc.ChiRouter(r, chi.URLParam))
+//
+// Paths like "/user/:id" become "/user/{id}", and catch-all params like "/files/*path" become "/files/*". Chi
+// can't look up a route w/o serving it, so Lookup() only knows about the routes registered through the adapter.
+func ChiRouter(mux ChiMux, urlParam func(req *http.Request, name string) string) Router {
+	return chiRouter{mux: mux, urlParam: urlParam, routes: &routeTable{}}
+}
+
+type chiRouter struct {
+	mux      ChiMux
+	urlParam func(req *http.Request, name string) string
+	routes   *routeTable
+}
+
+type contextKeyChiCatchAll struct{}
+
+func (r chiRouter) Handle(method string, path string, handler http.Handler) {
+	segments := strings.Split(path, "/")
+	catchAll := ""
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			segments[i] = "{" + segment[1:] + "}"
+		case strings.HasPrefix(segment, "*"):
+			segments[i] = "*"
+			catchAll = segment[1:]
+		}
+	}
+
+	// Chi always names the catch-all param "*", so remember which param it really is.
+	if catchAll != "" {
+		next := handler
+		handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), contextKeyChiCatchAll{}, catchAll)))
+		})
+	}
+	r.mux.Method(method, strings.Join(segments, "/"), handler)
+	r.routes.add(method, path)
+}
+
+func (r chiRouter) Lookup(req *http.Request) (http.Handler, bool) {
+	if !r.routes.matches(req.Method, req.URL.Path) {
+		return nil, false
+	}
+	return r.mux, true
+}
+
+func (r chiRouter) PathParam(req *http.Request, name string) string {
+	if catchAll, _ := req.Context().Value(contextKeyChiCatchAll{}).(string); catchAll != "" && catchAll == name {
+		return r.urlParam(req, "*")
+	}
+	return r.urlParam(req, name)
+}
+
+// routeTable keeps track of the routes that an adapter registered so that it can look them up for routers that
+// can only match a route by serving the request (e.g. chi).
+type routeTable struct {
+	mutex sync.RWMutex
+	paths map[string][]string
+}
+
+// add remembers the route w/ the given method and path (in frodo's syntax).
+func (table *routeTable) add(method string, path string) {
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+
+	if table.paths == nil {
+		table.paths = map[string][]string{}
+	}
+	table.paths[method] = append(table.paths[method], path)
+}
+
+// matches determines if any of the routes w/ the given method match the request path. Params match a single
+// non-empty segment (e.g. "42" for ":id"), and catch-all params match the rest of the path.
+func (table *routeTable) matches(method string, requestPath string) bool {
+	table.mutex.RLock()
+	defer table.mutex.RUnlock()
+
+	requestSegments := strings.Split(requestPath, "/")
+	for _, path := range table.paths[method] {
+		if routeMatches(strings.Split(path, "/"), requestSegments) {
+			return true
+		}
+	}
+	return false
+}
+
+// routeMatches determines if the request path's segments match those of the route's path.
+func routeMatches(segments []string, requestSegments []string) bool {
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, "*"):
+			return i < len(requestSegments)
+		case i >= len(requestSegments):
+			return false
+		case strings.HasPrefix(segment, ":"):
+			if requestSegments[i] == "" {
+				return false
+			}
+		case segment != requestSegments[i]:
+			return false
+		}
+	}
+	return len(segments) == len(requestSegments)
+}
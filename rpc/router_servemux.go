@@ -0,0 +1,61 @@
+//go:build go1.22
+// +build go1.22
+
+package rpc
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ServeMuxRouter adapts the standard library's ServeMux so that a gateway can use it (see WithRouter) or so that
+// you can embed a gateway's endpoints in it (see Gateway.RegisterRoutes). It relies on the method and wildcard
+// patterns that ServeMux supports starting in Go 1.22, so the "go" version in your main module's go.mod needs to
+// be 1.22 or later:
+//
+//     mux := http.NewServeMux()
+//     mux.HandleFunc("GET /health", healthCheck)
+//
+//     gateway := users.NewUserServiceGateway(userService)
+//     gateway.RegisterRoutes(rpc.ServeMuxRouter(mux))
+//     http.ListenAndServe(":8080", mux)
+//
+// Paths like "/user/:id" become "GET /user/{id}", and catch-all params like "/files/*path" become "/files/{path...}".
+func ServeMuxRouter(mux *http.ServeMux) Router {
+	return serveMuxRouter{mux: mux}
+}
+
+type serveMuxRouter struct {
+	mux *http.ServeMux
+}
+
+func (r serveMuxRouter) Handle(method string, path string, handler http.Handler) {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			segments[i] = "{" + segment[1:] + "}"
+		case strings.HasPrefix(segment, "*"):
+			segments[i] = "{" + segment[1:] + "...}"
+		}
+	}
+
+	// Patterns that end in a slash match every path that starts w/ them, but our routes only match exactly.
+	pattern := strings.Join(segments, "/")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "{$}"
+	}
+	r.mux.Handle(method+" "+pattern, handler)
+}
+
+func (r serveMuxRouter) Lookup(req *http.Request) (http.Handler, bool) {
+	// ServeMux only fills in the path values when it serves the request, so that's the handler we run.
+	if _, pattern := r.mux.Handler(req); pattern == "" {
+		return nil, false
+	}
+	return r.mux, true
+}
+
+func (r serveMuxRouter) PathParam(req *http.Request, name string) string {
+	return req.PathValue(name)
+}
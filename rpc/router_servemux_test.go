@@ -0,0 +1,41 @@
+//go:build unit && go1.22
+// +build unit,go1.22
+
+//go:debug httpmuxgo121=0
+
+package rpc_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/stretchr/testify/suite"
+)
+
+// Ensures that we translate routes into ServeMux patterns and bind the params that it extracted. Your own
+// routes keep working alongside the gateway's.
+func (suite *GatewaySuite) TestServeMuxRouter() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	suite.newRouterGateway().RegisterRoutes(rpc.ServeMuxRouter(mux))
+
+	suite.assertResponses(mux, []exchange{
+		{method: "GET", path: "/acme/v1/user/42?Name=Bob", status: 200, json: `{"Tenant":"acme", "ID":42, "Path":"", "Name":"Bob"}`},
+		{method: "POST", path: "/acme/v1/user/42", body: `{"Name":"Bob"}`, status: 200, json: `{"Tenant":"acme", "ID":42, "Path":"", "Name":"Bob"}`},
+		{method: "GET", path: "/acme/v1/files/a/b.txt", status: 200, json: `{"Tenant":"acme", "ID":0, "Path":"a/b.txt", "Name":""}`},
+		{method: "GET", path: "/acme/v1/user/abc", status: 400},
+		{method: "DELETE", path: "/acme/v1/user/42", status: 405},
+		{method: "GET", path: "/acme/v1/nope", status: 404},
+		{method: "GET", path: "/health", status: 200, response: "ok"},
+	})
+}
+
+// Ensures that gateways behave the same when they route requests through a ServeMux instead of httptreemux.
+func TestGatewaySuite_serveMux(t *testing.T) {
+	suite.Run(t, &GatewaySuite{newRouter: func() rpc.Router {
+		return rpc.ServeMuxRouter(http.NewServeMux())
+	}})
+}
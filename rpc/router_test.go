@@ -0,0 +1,104 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+type routerRequest struct {
+	Tenant string
+	ID     int
+	Path   string
+	Name   string
+}
+
+// newRouterGateway creates a gateway (w/ a prefix variable) whose functions echo the request that they bound.
+func (suite *GatewaySuite) newRouterGateway() rpc.Gateway {
+	gw := suite.newGateway()
+	gw.PathPrefix = "/:Tenant/v1"
+	getUser := testEndpoint("GET", "/user/:ID", "GetUser", echo(&gw, func() interface{} { return &routerRequest{} }))
+	getUser.PathConstraints = map[string]string{"ID": "int"}
+	gw.Register(getUser)
+	gw.Register(testEndpoint("POST", "/user/:ID", "SaveUser", echo(&gw, func() interface{} { return &routerRequest{} })))
+	gw.Register(testEndpoint("GET", "/files/*Path", "GetFile", echo(&gw, func() interface{} { return &routerRequest{} })))
+	return gw
+}
+
+// fakeChiMux records the handlers that the ChiRouter registers by their chi-style patterns.
+type fakeChiMux map[string]http.Handler
+
+func (mux fakeChiMux) Method(method string, pattern string, handler http.Handler) {
+	mux[method+" "+pattern] = handler
+}
+
+func (mux fakeChiMux) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	http.NotFound(w, req)
+}
+
+// Ensures that we translate routes into chi's syntax and bind the params that chi extracted.
+func (suite *GatewaySuite) TestChiRouter() {
+	r := suite.Require()
+	mux := fakeChiMux{}
+	var params map[string]string
+	urlParam := func(req *http.Request, name string) string {
+		return params[name]
+	}
+	router := rpc.ChiRouter(mux, urlParam)
+	suite.newRouterGateway().RegisterRoutes(router)
+
+	var patterns []string
+	for pattern := range mux {
+		patterns = append(patterns, pattern)
+	}
+	r.ElementsMatch([]string{
+		"GET /{Tenant}/v1/user/{ID}",
+		"POST /{Tenant}/v1/user/{ID}",
+		"OPTIONS /{Tenant}/v1/user/{ID}",
+		"GET /{Tenant}/v1/files/*",
+		"OPTIONS /{Tenant}/v1/files/*",
+	}, patterns)
+
+	tests := []struct {
+		pattern string
+		params  map[string]string
+		exchange
+	}{
+		{
+			pattern:  "GET /{Tenant}/v1/user/{ID}",
+			params:   map[string]string{"Tenant": "acme", "ID": "42"},
+			exchange: exchange{method: "GET", path: "/acme/v1/user/42?Name=Bob", status: 200, json: `{"Tenant":"acme", "ID":42, "Path":"", "Name":"Bob"}`},
+		},
+		{
+			pattern:  "GET /{Tenant}/v1/user/{ID}",
+			params:   map[string]string{"Tenant": "acme", "ID": "abc"},
+			exchange: exchange{name: "path constraints should still apply", method: "GET", path: "/acme/v1/user/abc", status: 400},
+		},
+		{
+			pattern:  "GET /{Tenant}/v1/files/*",
+			params:   map[string]string{"Tenant": "acme", "*": "a/b.txt"},
+			exchange: exchange{method: "GET", path: "/acme/v1/files/a/b.txt", status: 200, json: `{"Tenant":"acme", "ID":0, "Path":"a/b.txt", "Name":""}`},
+		},
+		{
+			pattern:  "POST /{Tenant}/v1/user/{ID}",
+			params:   map[string]string{"Tenant": "acme", "ID": "42"},
+			exchange: exchange{method: "POST", path: "/acme/v1/user/42", body: `{"Name":"Bob"}`, status: 200, json: `{"Tenant":"acme", "ID":42, "Path":"", "Name":"Bob"}`},
+		},
+	}
+	for _, test := range tests {
+		params = test.params
+		suite.assertResponses(mux[test.pattern], []exchange{test.exchange})
+	}
+
+	_, ok := router.Lookup(httptest.NewRequest("GET", "/acme/v1/user/42", nil))
+	r.True(ok, "Lookup should match the registered routes")
+	_, ok = router.Lookup(httptest.NewRequest("GET", "/acme/v1/files/a/b.txt", nil))
+	r.True(ok, "Lookup should match catch-all params")
+	_, ok = router.Lookup(httptest.NewRequest("DELETE", "/acme/v1/user/42", nil))
+	r.False(ok, "Lookup should not match other methods")
+	_, ok = router.Lookup(httptest.NewRequest("GET", "/acme/v1/nope/42", nil))
+	r.False(ok, "Lookup should not match other paths")
+}
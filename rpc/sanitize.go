@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"context"
+	stderrors "errors"
+	"log"
+	"net/http"
+
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/ids"
+)
+
+// WithErrorSanitization hides the details of 5xx failures from callers. Rather than the real error message
+// (e.g. "pq: relation \"users\" does not exist" or the value of a panic), callers receive a generic message
+// for the status (e.g. "Internal Server Error") along w/ the request's operation id. The gateway logs the real
+// error w/ that same id, so when a user reports a failure, you can look it up:
+//
+//     2021/06/12 18:10:00 rpc: [01F7ZK3WQX...] UserService.GetUser failed: pq: relation "users" does not exist
+//
+// The request's operation id is used when it has one (see operation.Middleware). Otherwise, the gateway creates
+// a new id for the failure and sends it in the "X-Operation-ID" response header and the body of the error. Panics
+// aren't logged again since your PanicHandlers already reported them (see WithPanicHandler). Error
+// codes still go to the caller, but details (including stack traces from WithPanicStackTraces) do not. Failures
+// w/ 4xx statuses are left alone since those messages are meant for the caller. Use this on gateways that
+// face the outside world.
+func WithErrorSanitization() GatewayOption {
+	return func(gw *Gateway) {
+		gw.SanitizeErrors = true
+	}
+}
+
+// sanitizes determines if the gateway should hide the details of this failure from the caller.
+func (gw *Gateway) sanitizes(err error) bool {
+	return gw != nil && gw.SanitizeErrors && errors.Status(err) >= 500
+}
+
+// sanitizeError logs the real error under the given id and returns the generic version of it that we
+// send to the caller. It keeps the original status and error code, but nothing else.
+func sanitizeError(ctx context.Context, err error, id string) error {
+	var panicErr recoveredPanic
+	switch endpoint := EndpointFromContext(ctx); {
+	case stderrors.As(err, &panicErr):
+		// The panic handlers already reported this one.
+	case endpoint != nil:
+		log.Printf("rpc: [%s] %s failed: %v", id, endpoint.String(), err)
+	default:
+		log.Printf("rpc: [%s] request failed: %v", id, err)
+	}
+
+	status := errors.Status(err)
+	sanitized := errors.New(status, "%s", http.StatusText(status))
+	if code := errors.Code(err); code != "" {
+		return errors.WithCode(sanitized, code)
+	}
+	return sanitized
+}
+
+// sanitizedOperationID returns the operation id that identifies a sanitized failure, creating a new one when
+// the request doesn't already have one.
+func sanitizedOperationID(id string) string {
+	if id == "" {
+		return ids.New()
+	}
+	return id
+}
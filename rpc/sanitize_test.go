@@ -0,0 +1,144 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/operation"
+)
+
+// Ensures that 5xx failures only give the caller a generic message (w/o details or stack traces) and the id to
+// find the real error in the logs, reusing the operation id that the request already has. Gateways w/o the
+// option send the real message, just like before, and 4xx failures always keep their messages.
+func (suite *GatewaySuite) TestWithErrorSanitization() {
+	logs := &bytes.Buffer{}
+	log.SetOutput(logs)
+	defer log.SetOutput(os.Stderr)
+
+	// The gateway's endpoints fail w/ a 500, a coded 503, a 404, and a panic.
+	newGateway := func(options ...rpc.GatewayOption) rpc.Gateway {
+		gw := suite.newGateway(options...)
+		register := func(path string, handler http.HandlerFunc) {
+			gw.Register(testEndpoint("GET", path, path[1:], handler))
+		}
+		register("/unexpected", func(w http.ResponseWriter, req *http.Request) {
+			rpc.Fail(w, req, errors.WithDetails(errors.Unexpected("password=hunter2"), map[string]interface{}{"table": "users"}))
+		})
+		register("/unavailable", func(w http.ResponseWriter, req *http.Request) {
+			rpc.Fail(w, req, errors.WithCode(errors.Unavailable("replica db-3 is down"), "MAINTENANCE"))
+		})
+		register("/missing", func(w http.ResponseWriter, req *http.Request) {
+			rpc.Fail(w, req, errors.NotFound("user not found: 123"))
+		})
+		register("/panic", func(w http.ResponseWriter, req *http.Request) {
+			panic("nil map in handler.go:42")
+		})
+		return gw
+	}
+	sanitized := newGateway(rpc.WithErrorSanitization(), rpc.WithPanicStackTraces())
+	withOperationID := newGateway(rpc.WithErrorSanitization(), rpc.WithMiddleware(operation.Middleware(func() string {
+		return "op-123"
+	})))
+
+	tests := []struct {
+		name        string
+		gateway     http.Handler
+		path        string
+		status      int
+		message     string
+		code        string
+		operationID string
+		// logged is what we expect to find in the logs, where "{id}" is the call's operation id.
+		logged string
+	}{
+		{
+			name:        "unexpected",
+			gateway:     sanitized,
+			path:        "/unexpected",
+			status:      500,
+			message:     "Internal Server Error",
+			operationID: "*",
+			logged:      "[{id}] TestService.unexpected failed: password=hunter2",
+		},
+		{
+			name:        "coded",
+			gateway:     sanitized,
+			path:        "/unavailable",
+			status:      503,
+			message:     "Service Unavailable",
+			code:        "MAINTENANCE",
+			operationID: "*",
+		},
+		{
+			name:        "panic",
+			gateway:     sanitized,
+			path:        "/panic",
+			status:      500,
+			message:     "Internal Server Error",
+			operationID: "*",
+		},
+		{
+			name:    "4xx",
+			gateway: sanitized,
+			path:    "/missing",
+			status:  404,
+			message: "user not found: 123",
+		},
+		{
+			name:        "operation id",
+			gateway:     withOperationID,
+			path:        "/unexpected",
+			status:      500,
+			message:     "Internal Server Error",
+			operationID: "op-123",
+			logged:      "[op-123] TestService.unexpected failed: password=hunter2",
+		},
+		{
+			name:    "disabled",
+			gateway: newGateway(),
+			path:    "/unexpected",
+			status:  500,
+			message: "password=hunter2",
+		},
+	}
+	for _, test := range tests {
+		r := suite.Require()
+		w := httptest.NewRecorder()
+		test.gateway.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+
+		body := errors.RPCError{}
+		r.NoError(json.Unmarshal(w.Body.Bytes(), &body), test.name)
+		r.Equal(test.status, w.Code, test.name)
+		r.Equal(test.message, body.Message, test.name)
+		r.Equal(test.code, body.Code, test.name)
+		switch test.operationID {
+		case "":
+			r.Empty(w.Header().Get(operation.Header), test.name)
+		case "*":
+			r.NotEmpty(body.OperationID, test.name)
+			r.Equal(body.OperationID, w.Header().Get(operation.Header), test.name)
+		default:
+			r.Equal(test.operationID, body.OperationID, test.name)
+			r.Equal(test.operationID, w.Header().Get(operation.Header), test.name)
+		}
+		if test.message != "password=hunter2" {
+			r.Empty(body.Details, "%s: Sanitization should hide details and stack traces", test.name)
+			r.NotContains(w.Body.String(), "hunter2", test.name)
+			r.NotContains(w.Body.String(), "db-3", test.name)
+			r.NotContains(w.Body.String(), "handler.go", test.name)
+		}
+		if test.logged != "" {
+			r.Contains(logs.String(), strings.Replace(test.logged, "{id}", body.OperationID, 1), test.name)
+		}
+	}
+	suite.Require().Equal(1, strings.Count(logs.String(), "nil map in handler.go:42"), "Panics should only be logged once")
+}
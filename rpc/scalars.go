@@ -0,0 +1,102 @@
+package rpc
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ScalarParser converts a single path/query/header/cookie value into a Go value. The result must be assignable
+// (or convertible) to the type you registered it for; for example, a parser registered for time.Duration should
+// return a time.Duration.
+type ScalarParser func(value string) (interface{}, error)
+
+// scalars are the types that the binder converts using a registered ScalarParser rather than their reflect.Kind.
+var scalars = struct {
+	sync.RWMutex
+	parsers map[reflect.Type]ScalarParser
+}{
+	parsers: map[reflect.Type]ScalarParser{
+		reflect.TypeOf(time.Duration(0)): parseDuration,
+		reflect.TypeOf(time.Time{}):      TimeParser(time.RFC3339Nano, "2006-01-02"),
+	},
+}
+
+// RegisterScalar teaches the binder how to convert path/query/header/cookie values into some type that it
+// can't handle on its own, so you don't need to write an UnmarshalJSON() just to support "?since=2021-04-06".
+// The example value only tells us which type you're registering, so a zero value is fine:
+//
+//	rpc.RegisterScalar(decimal.Decimal{}, func(value string) (interface{}, error) {
+//	    return decimal.NewFromString(value)
+//	})
+//
+// Registering a type that already has a parser replaces it, so you can also use this to change how
+// time.Duration and time.Time values are parsed. You should do this during startup, before your gateway
+// handles any requests.
+func RegisterScalar(example interface{}, parse ScalarParser) {
+	scalarType := reflect.TypeOf(example)
+	for scalarType != nil && scalarType.Kind() == reflect.Ptr {
+		scalarType = scalarType.Elem()
+	}
+	if scalarType == nil {
+		return
+	}
+
+	scalars.Lock()
+	if parse == nil {
+		delete(scalars.parsers, scalarType)
+	} else {
+		scalars.parsers[scalarType] = parse
+	}
+	scalars.Unlock()
+
+	// Any plans we've already built may have decided how to bind this type, so start over.
+	bindingPlans.Range(func(key, _ interface{}) bool {
+		bindingPlans.Delete(key)
+		return true
+	})
+}
+
+// TimeParser creates a ScalarParser for time.Time values that accepts any of the given layouts (see time.Parse),
+// trying them in order. For example, you can accept both timestamps and dates like this:
+//
+//	rpc.RegisterScalar(time.Time{}, rpc.TimeParser(time.RFC3339, "2006-01-02", "01/02/2006"))
+func TimeParser(layouts ...string) ScalarParser {
+	return func(value string) (interface{}, error) {
+		var err error
+		for _, layout := range layouts {
+			var t time.Time
+			if t, err = time.Parse(layout, value); err == nil {
+				return t, nil
+			}
+		}
+		if err == nil {
+			return nil, fmt.Errorf("invalid time: no layouts to parse '%s'", value)
+		}
+		return nil, fmt.Errorf("invalid time: %w", err)
+	}
+}
+
+// parseDuration is the default parser for time.Duration values. It accepts the standard Go format ("5m30s") as well
+// as a plain integer number of nanoseconds, which is how the duration is encoded in JSON.
+func parseDuration(value string) (interface{}, error) {
+	if nanos, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(nanos), nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration: %w", err)
+	}
+	return d, nil
+}
+
+// scalarParser returns the registered parser for the given type, if there is one.
+func scalarParser(t reflect.Type) (ScalarParser, bool) {
+	scalars.RLock()
+	defer scalars.RUnlock()
+
+	parse, ok := scalars.parsers[t]
+	return parse, ok
+}
@@ -0,0 +1,223 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Component is a long-running piece of background work such as a scheduler, poller, metrics pusher, or health
+// prober. Gateway/client options that need to do work in the background register components rather than starting
+// their own goroutines so that the Server can start them all together and stop them all cleanly on shutdown.
+type Component interface {
+	// Run performs the background work until the context is canceled. Return nil once you've stopped because
+	// the context ended. Returning an error (or returning early) shuts down the entire server.
+	Run(ctx context.Context) error
+}
+
+// ComponentFunc lets an ordinary function be a Component.
+type ComponentFunc func(ctx context.Context) error
+
+// Run invokes the underlying function.
+func (f ComponentFunc) Run(ctx context.Context) error {
+	return f(ctx)
+}
+
+// componentOwner is anything that started background components that the server should manage (gateways, clients, etc).
+type componentOwner interface {
+	Components() []Component
+}
+
+// WithComponents registers background components that should run for as long as the gateway is being served. They
+// are started/stopped by the Server that runs the gateway (see NewServer).
+func WithComponents(components ...Component) GatewayOption {
+	return func(gw *Gateway) {
+		gw.components = append(gw.components, components...)
+	}
+}
+
+// WithClientComponents registers background components that the client needs (e.g. something that periodically
+// refreshes credentials). Hand them to your server via WithServerComponents(client.Components()...) so they are
+// stopped cleanly on shutdown.
+func WithClientComponents(components ...Component) ClientOption {
+	return func(rpcClient *Client) {
+		rpcClient.components = append(rpcClient.components, components...)
+	}
+}
+
+// Components returns all of the background components started by the client's options.
+func (c Client) Components() []Component {
+	return c.components
+}
+
+// Components returns all of the background components that the gateway needs, including the ones started by
+// its options and the built-in ones that let in-flight "ASYNC" jobs finish and close "SSE" streams during shutdown.
+func (gw Gateway) Components() []Component {
+	components := append([]Component{}, gw.components...)
+	if gw.jobs != nil {
+		components = append(components, gw.jobs)
+	}
+	if gw.streams != nil {
+		components = append(components, gw.streams)
+	}
+	return components
+}
+
+// Components returns the background components for all of the composed gateways.
+func (gw CompositeGateway) Components() []Component {
+	var components []Component
+	for _, gateway := range gw.Gateways {
+		components = append(components, gateway.Components()...)
+	}
+	return components
+}
+
+// ServerOption defines a setting you can apply when creating a server via NewServer().
+type ServerOption func(*Server)
+
+// WithShutdownTimeout changes how long the server waits for in-flight requests and background components to
+// finish once shutdown begins (default is 30 seconds).
+func WithShutdownTimeout(timeout time.Duration) ServerOption {
+	return func(server *Server) {
+		server.ShutdownTimeout = timeout
+	}
+}
+
+// WithServerComponents adds background components (beyond the ones that the gateway started) that should
+// live and die w/ the server, such as the ones from your clients or a QueueServer.
+func WithServerComponents(components ...Component) ServerOption {
+	return func(server *Server) {
+		server.components = append(server.components, components...)
+	}
+}
+
+// NewServer creates a server that runs your gateway along w/ all of the background components that it (and your
+// other options) started. When you give it a gateway, its components are included automatically.
+//
+//     ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+//     defer stop()
+//
+//     gateway := calcrpc.NewCalculatorServiceGateway(service)
+//     server := rpc.NewServer(":8080", gateway)
+//     if err := server.Run(ctx); err != nil {
+//         log.Fatal(err)
+//     }
+func NewServer(addr string, handler http.Handler, options ...ServerOption) *Server {
+	server := &Server{
+		HTTP:            &http.Server{Addr: addr, Handler: handler},
+		ShutdownTimeout: 30 * time.Second,
+	}
+	if owner, ok := handler.(componentOwner); ok {
+		server.components = append(server.components, owner.Components()...)
+	}
+	for _, option := range options {
+		option(server)
+	}
+	return server
+}
+
+// Server owns the lifecycle of your HTTP server and every background component. They all start together and
+// when any of them fails or the context ends, they all shut down together.
+type Server struct {
+	// HTTP is the underlying standard library server. Feel free to tweak its timeouts, TLS config, etc.
+	HTTP *http.Server
+	// ShutdownTimeout is how long we wait for requests/components to finish once shutdown begins.
+	ShutdownTimeout time.Duration
+	// components are all of the background workers that run alongside the HTTP server.
+	components []Component
+}
+
+// Run listens on the server's address and serves requests until the context is canceled (e.g. by SIGTERM) or
+// the HTTP server/one of the components fails. See Serve() for details about how shutdown works. The address
+// can be a TCP address like ":8080" or a Unix domain socket like "unix:///var/run/users.sock". We clean up a
+// stale socket file left behind by a process that crashed, and the socket file is removed once we shut down.
+func (server *Server) Run(ctx context.Context) error {
+	listener, err := listen(server.HTTP.Addr)
+	if err != nil {
+		return fmt.Errorf("rpc: unable to listen on %s: %w", server.HTTP.Addr, err)
+	}
+	return server.Serve(ctx, listener)
+}
+
+// Serve accepts requests on the listener and runs all of the background components until the context is canceled
+// or the HTTP server/one of the components fails. Then we stop accepting new requests, cancel the context given
+// to every component, and wait up to ShutdownTimeout for in-flight requests and components to finish. You get back
+// the first error that caused the shutdown (nil when the context was canceled) or an error if we ran out of time.
+func (server *Server) Serve(ctx context.Context, listener net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	group := &componentGroup{ctx: ctx, cancel: cancel}
+	group.Go(func(context.Context) error {
+		if err := server.HTTP.Serve(listener); err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	})
+	for _, component := range server.components {
+		group.Go(component.Run)
+	}
+
+	<-ctx.Done()
+	shutdownCtx, done := context.WithTimeout(context.Background(), server.ShutdownTimeout)
+	defer done()
+
+	if err := server.HTTP.Shutdown(shutdownCtx); err != nil {
+		group.fail(fmt.Errorf("rpc: unable to shut down http server: %w", err))
+	}
+	return group.Wait(shutdownCtx)
+}
+
+// componentGroup runs a bunch of functions at the same time. The first one that fails (or returns at all) cancels
+// the context shared by the others, so they all stop together. It's basically "golang.org/x/sync/errgroup",
+// but we can wait w/ a deadline.
+type componentGroup struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	mutex  sync.Mutex
+	err    error
+}
+
+// Go runs the function in its own goroutine, giving it the group's context.
+func (group *componentGroup) Go(fn func(ctx context.Context) error) {
+	group.wg.Add(1)
+	go func() {
+		defer group.wg.Done()
+		if err := fn(group.ctx); err != nil {
+			group.fail(err)
+		}
+		group.cancel()
+	}()
+}
+
+// fail records the first error that caused the group to shut down.
+func (group *componentGroup) fail(err error) {
+	group.mutex.Lock()
+	if group.err == nil {
+		group.err = err
+	}
+	group.mutex.Unlock()
+	group.cancel()
+}
+
+// Wait blocks until all of the functions have returned (or the context ends) and returns the first error.
+func (group *componentGroup) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		group.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		group.mutex.Lock()
+		defer group.mutex.Unlock()
+		return group.err
+	case <-ctx.Done():
+		return fmt.Errorf("rpc: background components did not stop before the shutdown timeout: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,148 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+// serveInBackground runs the server in the background, returning its address and a channel that receives
+// Serve()'s result.
+func (suite *GatewaySuite) serveInBackground(ctx context.Context, server *rpc.Server) (string, <-chan error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- server.Serve(ctx, listener)
+	}()
+	return "http://" + listener.Addr().String(), result
+}
+
+// awaitShutdown waits a reasonable amount of time for the server to stop.
+func (suite *GatewaySuite) awaitShutdown(result <-chan error) error {
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(2 * time.Second):
+		suite.FailNow("Server did not shut down")
+		return nil
+	}
+}
+
+// Ensures that the HTTP server and all components run until the context is canceled, then stop together.
+func (suite *GatewaySuite) TestServer() {
+	r := suite.Require()
+	var started, stopped int32
+	component := rpc.ComponentFunc(func(ctx context.Context) error {
+		atomic.AddInt32(&started, 1)
+		<-ctx.Done()
+		atomic.AddInt32(&stopped, 1)
+		return nil
+	})
+
+	gw := suite.newGateway(rpc.WithComponents(component))
+	gw.Register(testEndpoint("GET", "/hello", "Hello", func(w http.ResponseWriter, req *http.Request) {
+		rpc.Reply(w, req, 200, "hello")
+	}))
+	client := rpc.NewClient("TestService", "", rpc.WithClientComponents(component))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	addr, result := suite.serveInBackground(ctx, rpc.NewServer("", gw, rpc.WithServerComponents(client.Components()...)))
+
+	res, err := suite.HTTPClient.Get(addr + "/hello")
+	r.NoError(err)
+	r.Equal(200, res.StatusCode)
+	r.Equal(int32(2), atomic.LoadInt32(&started), "Should start gateway and client components")
+	r.Equal(int32(0), atomic.LoadInt32(&stopped))
+
+	cancel()
+	r.NoError(suite.awaitShutdown(result))
+	r.Equal(int32(2), atomic.LoadInt32(&stopped), "Should stop all components on shutdown")
+
+	_, err = suite.HTTPClient.Get(addr + "/hello")
+	r.Error(err, "HTTP server should be shut down")
+}
+
+// Ensures that when one component fails, everything else shuts down and you get the error.
+func (suite *GatewaySuite) TestServer_componentFailure() {
+	r := suite.Require()
+	var stopped int32
+	healthy := rpc.ComponentFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		atomic.AddInt32(&stopped, 1)
+		return nil
+	})
+	broken := rpc.ComponentFunc(func(ctx context.Context) error {
+		return fmt.Errorf("kaboom")
+	})
+
+	server := rpc.NewServer("", suite.newGateway(), rpc.WithServerComponents(healthy, broken))
+	_, result := suite.serveInBackground(context.Background(), server)
+
+	err := suite.awaitShutdown(result)
+	r.Error(err)
+	r.Contains(err.Error(), "kaboom")
+	r.Equal(int32(1), atomic.LoadInt32(&stopped))
+}
+
+// Ensures that we don't wait forever for components that ignore the shutdown.
+func (suite *GatewaySuite) TestServer_shutdownTimeout() {
+	r := suite.Require()
+	stubborn := rpc.ComponentFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	server := rpc.NewServer("", suite.newGateway(),
+		rpc.WithServerComponents(stubborn),
+		rpc.WithShutdownTimeout(50*time.Millisecond),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	_, result := suite.serveInBackground(ctx, server)
+
+	cancel()
+	err := suite.awaitShutdown(result)
+	r.Error(err)
+	r.Contains(err.Error(), "shutdown timeout")
+}
+
+// Ensures that shutdown waits for in-flight "ASYNC" jobs to finish.
+func (suite *GatewaySuite) TestServer_asyncJobs() {
+	r := suite.Require()
+	var finished int32
+
+	gw := suite.newGateway()
+	gw.Register(testEndpoint("POST", "/slow", "Slow", func(w http.ResponseWriter, req *http.Request) {
+		gw.RunAsync(w, req, func(ctx context.Context) (interface{}, error) {
+			time.Sleep(200 * time.Millisecond)
+			atomic.AddInt32(&finished, 1)
+			return "done", nil
+		})
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	addr, result := suite.serveInBackground(ctx, rpc.NewServer("", gw))
+
+	res, err := suite.HTTPClient.Post(addr+"/slow", "application/json", nil)
+	r.NoError(err)
+	r.Equal(202, res.StatusCode)
+
+	cancel()
+	r.NoError(suite.awaitShutdown(result))
+	r.Equal(int32(1), atomic.LoadInt32(&finished), "Shutdown should wait for running jobs")
+}
+
+// Ensures that Run fails right away when we can't listen on the address.
+func (suite *GatewaySuite) TestServer_badAddress() {
+	err := rpc.NewServer("not-an-address", suite.newGateway()).Run(context.Background())
+	suite.Require().Error(err)
+}
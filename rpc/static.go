@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+// WithStaticFiles serves the files in 'fsys' from the given path alongside your API. This lets a single process
+// serve your generated JS client and a small frontend bundle w/o composing a second mux by hand:
+//
+//     //go:embed dist
+//     var dist embed.FS
+//
+//     ...
+//
+//     assets, _ := fs.Sub(dist, "dist")
+//     gateway := users.NewUserServiceGateway(service,
+//         rpc.WithStaticFiles("/app", assets, rpc.SPAFallback("index.html")),
+//     )
+//
+// The gateway serves "GET /app/js/main.js" from "js/main.js" in the file system, and requests for a directory
+// serve the "index.html" in that directory (there are no directory listings). Responses support "Range" and
+// conditional requests. Unlike your service functions, the path is NOT relative to the gateway's PathPrefix,
+// so you can mount your frontend at "/" even when the API lives under "/api". Your API's routes still win
+// when a file path overlaps w/ one of them. Static files run through the same middleware as your service
+// functions (e.g. CORS, compression, logging) and EndpointFromContext() describes them as "StaticFiles" w/
+// the mount path as the name.
+func WithStaticFiles(path string, fsys fs.FS, options ...StaticOption) GatewayOption {
+	static := staticFiles{path: toEndpointPath("", path), fsys: http.FS(fsys)}
+	for _, option := range options {
+		option(&static)
+	}
+	return func(gw *Gateway) {
+		gw.staticFiles = append(gw.staticFiles, static)
+	}
+}
+
+// StaticOption customizes how the gateway serves a directory of static files (see WithStaticFiles).
+type StaticOption func(*staticFiles)
+
+// SPAFallback serves the given file (relative to the root of the file system) instead of a 404 when there is no
+// file at the requested path. Single page apps need this so that a deep link or page refresh (e.g. "/app/users/123")
+// loads the app, which then routes the request on the client side. Missing paths that look like assets (they have a
+// file extension and the caller doesn't accept "text/html") still result in a 404, so a broken script tag doesn't
+// quietly receive your HTML.
+func SPAFallback(fileName string) StaticOption {
+	return func(static *staticFiles) {
+		static.fallback = "/" + strings.TrimPrefix(fileName, "/")
+	}
+}
+
+// staticFiles is a single directory of files that the gateway serves via WithStaticFiles().
+type staticFiles struct {
+	path     string
+	fsys     http.FileSystem
+	fallback string
+}
+
+// endpoints creates the "GET" endpoints that serve everything under the static files' path. The router doesn't
+// match an empty catch-all param, so the root of the mount (e.g. "/app/") needs its own route. We don't register
+// "HEAD" routes since the gateway sends HEAD requests to the GET route (and an explicit HEAD catch-all conflicts
+// w/ the GET root in routers like ServeMux, where GET routes match HEAD requests, too).
+func (static staticFiles) endpoints() []Endpoint {
+	root := strings.TrimSuffix(static.path, "/") + "/"
+	var endpoints []Endpoint
+	for _, routePath := range []string{root, root + "*filepath"} {
+		endpoints = append(endpoints, Endpoint{
+			Method:      http.MethodGet,
+			Path:        routePath,
+			ServiceName: "StaticFiles",
+			Name:        static.path,
+			Handler:     static.serve,
+			static:      true,
+		})
+	}
+	return endpoints
+}
+
+// serve writes the file at the request's path, the directory's "index.html", or the SPA fallback (in that order).
+func (static staticFiles) serve(w http.ResponseWriter, req *http.Request) {
+	name := path.Clean("/" + PathParams(req)["filepath"])
+
+	file, info, ok := static.open(name)
+	if !ok && static.fallback != "" && (path.Ext(name) == "" || strings.Contains(req.Header.Get("Accept"), "text/html")) {
+		file, info, ok = static.open(static.fallback)
+	}
+	if !ok {
+		Fail(w, req, errors.NotFound("not found: %s", req.URL.Path))
+		return
+	}
+	defer file.Close()
+
+	http.ServeContent(w, req, info.Name(), info.ModTime(), file)
+}
+
+// open returns the file w/ the given name, using the directory's "index.html" when the name refers to a directory.
+// The boolean is false when there's no file to serve.
+func (static staticFiles) open(name string) (http.File, fs.FileInfo, bool) {
+	file, err := static.fsys.Open(name)
+	if err != nil {
+		return nil, nil, false
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, false
+	}
+	if info.IsDir() {
+		_ = file.Close()
+		return static.open(path.Join(name, "index.html"))
+	}
+	return file, info, true
+}
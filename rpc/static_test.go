@@ -0,0 +1,95 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing/fstest"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+var staticFS = fstest.MapFS{
+	"index.html":      {Data: []byte("<html>app</html>")},
+	"js/main.js":      {Data: []byte("console.log('hi');")},
+	"docs/index.html": {Data: []byte("<html>docs</html>")},
+	"empty/.keep":     {Data: []byte("")},
+}
+
+// Ensures that we serve files (and directory index files) from the mount path and 404 otherwise, and that missing
+// paths serve the SPA fallback unless they look like missing assets. API routes should win over static files.
+func (suite *GatewaySuite) TestWithStaticFiles() {
+	js := http.Header{"Content-Type": {"text/javascript; charset=utf-8"}}
+	tests := []struct {
+		name      string
+		options   []rpc.GatewayOption
+		exchanges []exchange
+	}{
+		{
+			name:    "files",
+			options: []rpc.GatewayOption{rpc.WithStaticFiles("/app/", staticFS)},
+			exchanges: []exchange{
+				{method: "GET", path: "/app/js/main.js", status: 200, response: "console.log('hi');", responseHeader: js},
+				{method: "HEAD", path: "/app/js/main.js", status: 200, responseHeader: http.Header{"Content-Length": {"18"}}},
+				{method: "GET", path: "/app/", status: 200, response: "<html>app</html>"},
+				{method: "GET", path: "/app/docs", status: 200, response: "<html>docs</html>"},
+				{name: "should not list directories", method: "GET", path: "/app/empty/", status: 404},
+				{method: "GET", path: "/app/users/123", header: http.Header{"Accept": {"text/html"}}, status: 404},
+				{method: "GET", path: "/api/status", status: 200, json: `{"Status":"ok"}`},
+			},
+		},
+		{
+			name:    "SPA fallback",
+			options: []rpc.GatewayOption{rpc.WithStaticFiles("/", staticFS, rpc.SPAFallback("index.html"))},
+			exchanges: []exchange{
+				{method: "GET", path: "/users/123", status: 200, response: "<html>app</html>"},
+				{method: "GET", path: "/users/john.doe", header: http.Header{"Accept": {"text/html,application/xhtml+xml"}}, status: 200, response: "<html>app</html>"},
+				{name: "should not hide missing assets", method: "GET", path: "/js/missing.js", header: http.Header{"Accept": {"*/*"}}, status: 404},
+				{method: "GET", path: "/js/main.js", status: 200, response: "console.log('hi');"},
+				{name: "API routes should win over static files", method: "GET", path: "/api/status", status: 200, json: `{"Status":"ok"}`},
+			},
+		},
+	}
+	for _, test := range tests {
+		gw := suite.newGateway(test.options...)
+		gw.PathPrefix = "/api"
+		gw.Register(testEndpoint("GET", "/status", "Status", func(w http.ResponseWriter, req *http.Request) {
+			rpc.Reply(w, req, 200, struct{ Status string }{Status: "ok"})
+		}))
+		suite.assertResponses(gw, test.exchanges)
+	}
+
+	// The router decides how to redirect paths that aren't clean, so we only make sure that you end up in the
+	// right place and never outside of the mount.
+	r := suite.Require()
+	gw := suite.newGateway(rpc.WithStaticFiles("/app/", staticFS))
+	w := httptest.NewRecorder()
+	gw.ServeHTTP(w, httptest.NewRequest("GET", "/app", nil))
+	r.Contains([]int{301, 307}, w.Code)
+	r.Equal("/app/", w.Header().Get("Location"))
+
+	w = httptest.NewRecorder()
+	gw.ServeHTTP(w, httptest.NewRequest("GET", "/app/../app/nope.js", nil))
+	r.Contains([]int{301, 307, 404}, w.Code)
+	r.Contains([]string{"", "/app/nope.js"}, w.Header().Get("Location"))
+}
+
+// Ensures that static files run through the gateway's middleware.
+func (suite *GatewaySuite) TestWithStaticFiles_middleware() {
+	r := suite.Require()
+	var endpoint *rpc.Endpoint
+	gw := suite.newGateway(
+		rpc.WithStaticFiles("/app", staticFS),
+		rpc.WithMiddleware(func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+			endpoint = rpc.EndpointFromContext(req.Context())
+			next(w, req)
+		}),
+	)
+
+	w := httptest.NewRecorder()
+	gw.ServeHTTP(w, httptest.NewRequest("GET", "/app/js/main.js", nil))
+	r.Equal(200, w.Code)
+	r.NotNil(endpoint)
+	r.Equal("StaticFiles./app", endpoint.String())
+}
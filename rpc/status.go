@@ -0,0 +1,53 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+)
+
+type contextKeyResponseStatus struct{}
+
+// SetStatus overrides the success status that the gateway responds w/ for the current call. The "HTTP 201" doc
+// option is fine when a function always responds the same way, but sometimes it depends on what happened. An
+// "upsert" might respond w/ a 201 and the new resource's location when it creates one, but a 200 when it updates
+// an existing one:
+//
+//     func (svc UserServiceHandler) SaveUser(ctx context.Context, req *SaveUserRequest) (*SaveUserResponse, error) {
+//         if req.ID == "" {
+//             user := svc.create(req)
+//             rpc.SetStatus(ctx, http.StatusCreated)
+//             rpc.SetHeader(ctx, "Location", "/user/"+user.ID)
+//             return &SaveUserResponse{User: user}, nil
+//         }
+//         return &SaveUserResponse{User: svc.update(req)}, nil
+//     }
+//
+// This only affects successful responses; errors still use the status of the error. Just like SetHeader(), it
+// does nothing when the function is not being invoked through a gateway, and it won't make it into the response
+// for "ASYNC" and "SSE" functions, which have already responded by the time they're done.
+func SetStatus(ctx context.Context, status int) {
+	if override, ok := ctx.Value(contextKeyResponseStatus{}).(*int); ok {
+		*override = status
+	}
+}
+
+// restoreResponseStatus gives the handler a place to store the status from SetStatus() so that Reply() can use it.
+func restoreResponseStatus(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	status := 0
+	ctx := context.WithValue(req.Context(), contextKeyResponseStatus{}, &status)
+	next(w, req.WithContext(ctx))
+}
+
+// responseStatus is the status that the handler asked for using SetStatus() or the given default if it didn't.
+func responseStatus(req *http.Request, status int) int {
+	if override, ok := req.Context().Value(contextKeyResponseStatus{}).(*int); ok && *override > 0 {
+		return *override
+	}
+	return status
+}
+
+// hasNoContent is true for the "204 No Content" and "205 Reset Content" statuses, whose responses never have a
+// body, so neither the gateway nor the client should try to marshal/unmarshal one.
+func hasNoContent(status int) bool {
+	return status == http.StatusNoContent || status == http.StatusResetContent
+}
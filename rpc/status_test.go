@@ -0,0 +1,116 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+type statusRequest struct {
+	ID     string
+	Status int
+}
+
+type statusResponse struct {
+	ID       string
+	Location string `json:"-"`
+}
+
+func (res *statusResponse) SetHeaders(header http.Header) {
+	res.Location = header.Get("Location")
+}
+
+// newStatusGateway creates a gateway whose "PUT /user" endpoint responds w/ a 200 unless the request asks for a
+// different status, which it sets using SetStatus().
+func (suite *GatewaySuite) newStatusGateway(options ...rpc.GatewayOption) rpc.Gateway {
+	gw := suite.newGateway(options...)
+	saveUser := testEndpoint("PUT", "/user", "SaveUser", func(w http.ResponseWriter, req *http.Request) {
+		serviceRequest := statusRequest{}
+		if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
+			rpc.Fail(w, req, err)
+			return
+		}
+		if serviceRequest.Status == 201 {
+			rpc.SetHeader(req.Context(), "Location", "/user/"+serviceRequest.ID)
+		}
+		if serviceRequest.Status > 0 {
+			rpc.SetStatus(req.Context(), serviceRequest.Status)
+		}
+		rpc.Reply(w, req, 200, statusResponse{ID: serviceRequest.ID})
+	})
+	saveUser.Status = 200
+	gw.Register(saveUser)
+	return gw
+}
+
+// Ensures that SetStatus() overrides the status that the gateway would normally respond with, and that "204" and
+// "205" responses don't have a body, even when the service response has data.
+func (suite *GatewaySuite) TestSetStatus() {
+	noContent := func(status int) exchange {
+		return exchange{
+			name:           strconv.Itoa(status),
+			method:         "PUT",
+			path:           "/user",
+			body:           `{"ID":"123", "Status":` + strconv.Itoa(status) + `}`,
+			status:         status,
+			noBody:         true,
+			responseHeader: http.Header{"Content-Type": {}},
+		}
+	}
+	tests := []struct {
+		name      string
+		options   []rpc.GatewayOption
+		exchanges []exchange
+	}{
+		{
+			name: "default",
+			exchanges: []exchange{
+				{method: "PUT", path: "/user", body: `{"ID":"123"}`, status: 200, json: `{"ID":"123"}`},
+				{method: "PUT", path: "/user", body: `{"ID":"123", "Status":201}`, status: 201, json: `{"ID":"123"}`, responseHeader: http.Header{"Location": {"/user/123"}}},
+				noContent(204),
+				noContent(205),
+			},
+		},
+		{
+			name:      "envelope",
+			options:   []rpc.GatewayOption{rpc.WithResponseEnvelope()},
+			exchanges: []exchange{noContent(204), noContent(205)},
+		},
+		{
+			name:      "etags",
+			options:   []rpc.GatewayOption{rpc.WithETags()},
+			exchanges: []exchange{noContent(204), noContent(205)},
+		},
+	}
+	for _, test := range tests {
+		suite.assertResponses(suite.newStatusGateway(test.options...), test.exchanges)
+	}
+
+	suite.NotPanics(func() {
+		rpc.SetStatus(context.Background(), 201)
+	}, "Should be harmless when the function isn't running in a gateway")
+}
+
+// Ensures that clients don't try to decode the body of "204" and "205" responses.
+func (suite *GatewaySuite) TestSetStatus_client() {
+	r := suite.Require()
+	server := httptest.NewServer(suite.newStatusGateway())
+	defer server.Close()
+	client := rpc.NewClient("TestService", server.URL)
+
+	for _, status := range []int{204, 205} {
+		response := statusResponse{ID: "unchanged"}
+		r.NoError(client.Invoke(context.Background(), "PUT", "/user", &statusRequest{ID: "123", Status: status}, &response))
+		r.Equal("unchanged", response.ID)
+	}
+
+	response := statusResponse{}
+	r.NoError(client.Invoke(context.Background(), "PUT", "/user", &statusRequest{ID: "123", Status: 201}, &response))
+	r.Equal("123", response.ID)
+	r.Equal("/user/123", response.Location)
+}
@@ -0,0 +1,296 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc/calls"
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+// EventStreamContentType is the content type of the responses for "SSE" service functions.
+const EventStreamContentType = "text/event-stream"
+
+// streamHeartbeat is how often we send a comment line down an idle event stream so that proxies and
+// load balancers don't decide that the connection is dead and close it on us.
+var streamHeartbeat = 15 * time.Second
+
+// StreamHandler receives each event that an "SSE" service function sends via StreamEvent(). If it
+// returns an error, the client stops listening and the service call fails w/ that error.
+type StreamHandler func(event interface{}) error
+
+type contextKeyEventStream struct{}
+type contextKeyStreamHandler struct{}
+type contextKeyStreamCall struct{}
+
+// StreamEvent sends an event to the caller of an "SSE" service function. The gateway JSON-encodes the
+// event and immediately flushes it to the caller, so you can call this as many times as you like while
+// the function runs:
+//
+//     // SSE
+//     WatchNotifications(ctx context.Context, req *WatchRequest) (*Notification, error)
+//
+//     func (svc NotificationService) WatchNotifications(ctx context.Context, req *WatchRequest) (*Notification, error) {
+//         for notification := range svc.feed.Subscribe(ctx, req.UserID) {
+//             if err := rpc.StreamEvent(ctx, notification); err != nil {
+//                 return nil, err
+//             }
+//         }
+//         return nil, nil
+//     }
+//
+// This returns an error when the caller hangs up, so stop doing work when you get one. When the
+// function is not being invoked through a gateway (e.g. you call the service directly), the event
+// goes to the context's StreamHandler (see WithStreamHandler) or nowhere at all if there isn't one.
+func StreamEvent(ctx context.Context, event interface{}) error {
+	if stream, ok := ctx.Value(contextKeyEventStream{}).(*eventStream); ok {
+		return stream.send(ctx, event)
+	}
+	if handler, ok := ctx.Value(contextKeyStreamHandler{}).(StreamHandler); ok {
+		return handler(event)
+	}
+	return nil
+}
+
+// WithStreamHandler registers the function that should receive each event sent by the "SSE" service function
+// that you invoke w/ this context. When you use a generated client, each event is a pointer to a new instance
+// of the function's response type:
+//
+//     ctx = rpc.WithStreamHandler(ctx, func(event interface{}) error {
+//         notification := event.(*notifications.Notification)
+//         fmt.Println(notification.Text)
+//         return nil
+//     })
+//     _, err := client.WatchNotifications(ctx, &notifications.WatchRequest{UserID: "123"})
+//
+// The service call blocks until the stream ends, so run it in a goroutine if you need to keep working. The
+// response of the call is the (optional) final value the function returned after sending all of its events.
+func WithStreamHandler(ctx context.Context, handler StreamHandler) context.Context {
+	return context.WithValue(ctx, contextKeyStreamHandler{}, handler)
+}
+
+// StreamEvents is used by generated gateways to handle "SSE" service functions. It responds w/ a
+// "text/event-stream" and keeps the connection open while the handler runs, writing each event that the
+// handler sends via StreamEvent() as a "message". When the handler finishes, we send either an "end"
+// event w/ the value it returned or an "error" event w/ the failure, then close the stream.
+//
+// The handler's context is canceled when the caller hangs up or the server shuts down.
+func (gw Gateway) StreamEvents(w http.ResponseWriter, req *http.Request, handler func(ctx context.Context) (interface{}, error)) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		Fail(w, req, errors.Unexpected("response writer does not support streaming"))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+	stream := &eventStream{events: make(chan []byte), done: ctx.Done(), json: gw.JSON}
+	ctx = context.WithValue(ctx, contextKeyEventStream{}, stream)
+
+	// The handler might finish after we've stopped listening (e.g. the caller hung up), so make
+	// sure that it can always deliver the result w/o blocking forever.
+	results := make(chan streamResult, 1)
+	go func() {
+		value, err := gw.invokeJobHandler(ctx, handler)
+		results <- streamResult{value: value, err: err}
+	}()
+
+	w.Header().Set("Content-Type", EventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case data := <-stream.events:
+			writeStreamEvent(w, "", data)
+		case result := <-results:
+			if result.err != nil {
+				data, _ := json.Marshal(toRPCError(ctx, result.err))
+				writeStreamEvent(w, "error", data)
+			} else {
+				data, _ := gw.JSON.marshal(result.value)
+				writeStreamEvent(w, "end", data)
+			}
+			flusher.Flush()
+			return
+		case <-heartbeat.C:
+			_, _ = io.WriteString(w, ": ping\n\n")
+		case <-ctx.Done():
+			return
+		case <-gw.streams.closing():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// streamResult is the final outcome of an "SSE" service function.
+type streamResult struct {
+	value interface{}
+	err   error
+}
+
+// eventStream is the channel-backed writer that StreamEvent() uses to hand events from the service
+// function's goroutine to the gateway goroutine that's actually writing the response.
+type eventStream struct {
+	events chan []byte
+	done   <-chan struct{}
+	json   *JSON
+}
+
+func (stream *eventStream) send(ctx context.Context, event interface{}) error {
+	Redact(ctx, event)
+	data, err := stream.json.marshal(event)
+	if err != nil {
+		return fmt.Errorf("rpc: unable to encode event: %w", err)
+	}
+
+	select {
+	case stream.events <- data:
+		return nil
+	case <-stream.done:
+		return errors.Unavailable("event stream closed")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// writeStreamEvent writes a single event in the "text/event-stream" format. Events w/o a name are
+// dispatched to the caller's "message" listeners.
+func writeStreamEvent(w io.Writer, name string, data []byte) {
+	if name != "" {
+		_, _ = io.WriteString(w, "event: "+name+"\n")
+	}
+	_, _ = io.WriteString(w, "data: ")
+	_, _ = w.Write(data)
+	_, _ = io.WriteString(w, "\n\n")
+}
+
+// newStreamTracker creates the built-in component that closes all of the open event streams when the
+// server shuts down. Otherwise, long-lived streams would keep the server from shutting down gracefully.
+func newStreamTracker() *streamTracker {
+	return &streamTracker{closed: make(chan struct{})}
+}
+
+type streamTracker struct {
+	once   sync.Once
+	closed chan struct{}
+}
+
+// closing returns a channel that is closed once the server begins shutting down.
+func (tracker *streamTracker) closing() <-chan struct{} {
+	if tracker == nil {
+		return nil
+	}
+	return tracker.closed
+}
+
+// Run waits for the server to shut down and then closes all of the open event streams.
+func (tracker *streamTracker) Run(ctx context.Context) error {
+	<-ctx.Done()
+	tracker.once.Do(func() { close(tracker.closed) })
+	return nil
+}
+
+// Stream is used by generated clients to invoke "SSE" service functions. It works just like Invoke(), except
+// that it sends each event to the context's StreamHandler (see WithStreamHandler) as it arrives and only returns
+// once the stream ends. The client's default timeout does not apply since streams are meant to stay open for
+// a long time; use a context w/ a deadline (or calls.WithTimeout) if you only want to listen for so long.
+func (c Client) Stream(ctx context.Context, method string, path string, serviceRequest interface{}, serviceResponse interface{}, options ...calls.Option) error {
+	return c.Invoke(context.WithValue(ctx, contextKeyStreamCall{}, true), method, path, serviceRequest, serviceResponse, options...)
+}
+
+// isStreamCall returns true when the context is for a call made via Client.Stream().
+func isStreamCall(ctx context.Context) bool {
+	streaming, _ := ctx.Value(contextKeyStreamCall{}).(bool)
+	return streaming
+}
+
+// decodeResponseStream reads each event from the "text/event-stream" response. Messages go to the context's
+// StreamHandler, the "end" event is decoded into the service response, and the "error" event becomes the error.
+func (c Client) decodeResponseStream(response *http.Response, serviceResponse interface{}) error {
+	defer response.Body.Close()
+
+	var handler StreamHandler
+	if response.Request != nil {
+		handler, _ = response.Request.Context().Value(contextKeyStreamHandler{}).(StreamHandler)
+	}
+
+	reader := bufio.NewReader(response.Body)
+	name, data := "", bytes.Buffer{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("rpc: event stream ended unexpectedly: %w", io.ErrUnexpectedEOF)
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == "":
+			// A blank line dispatches the event that we've been building up.
+		case strings.HasPrefix(line, ":"):
+			continue // Comments (e.g. heartbeats)
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(line[6:])
+			continue
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(line[5:], " "))
+			continue
+		default:
+			continue // Fields we don't care about (e.g. "id" and "retry").
+		}
+
+		switch name {
+		case "end":
+			if err := c.JSON.unmarshal(data.Bytes(), serviceResponse); err != nil {
+				return fmt.Errorf("rpc: unable to decode response: %w", err)
+			}
+			return nil
+		case "error":
+			failure := errors.RPCError{}
+			if err := json.Unmarshal(data.Bytes(), &failure); err != nil {
+				return fmt.Errorf("rpc: unable to decode error: %w", err)
+			}
+			return c.toError(failure)
+		case "", "message":
+			if handler != nil && data.Len() > 0 {
+				event, target := newStreamEvent(serviceResponse)
+				if err := c.JSON.unmarshal(data.Bytes(), target); err != nil {
+					return fmt.Errorf("rpc: unable to decode event: %w", err)
+				}
+				if err := handler(event); err != nil {
+					return err
+				}
+			}
+		}
+		name = ""
+		data.Reset()
+	}
+}
+
+// newStreamEvent creates a new, empty value of the same type as the service response for decoding a message. The
+// target is what you should decode the JSON into; it's only different from the event for "ONEOF" unions.
+func newStreamEvent(serviceResponse interface{}) (event interface{}, target interface{}) {
+	if union, ok := serviceResponse.(*Union); ok {
+		event = reflect.New(reflect.TypeOf(union.value).Elem()).Interface()
+		return event, OneOf(event, union.discriminator)
+	}
+	event = reflect.New(reflect.TypeOf(serviceResponse).Elem()).Interface()
+	return event, event
+}
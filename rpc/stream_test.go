@@ -0,0 +1,189 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc"
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+type streamRequest struct {
+	Count int
+	Fail  bool
+	Wait  bool
+}
+
+type streamEvent struct {
+	Text string
+}
+
+// watchEndpoint creates the "Watch" endpoint, which sends 'Count' events and then either fails or finishes w/ a
+// final "done" event. When 'Wait' is set, it keeps the stream open until the caller hangs up.
+func watchEndpoint(gw *rpc.Gateway, canceled chan<- error) rpc.Endpoint {
+	return testEndpoint("GET", "/watch", "Watch", func(w http.ResponseWriter, req *http.Request) {
+		serviceRequest := streamRequest{}
+		if err := gw.Binder.Bind(req, &serviceRequest); err != nil {
+			rpc.Fail(w, req, err)
+			return
+		}
+		gw.StreamEvents(w, req, func(ctx context.Context) (interface{}, error) {
+			for i := 0; i < serviceRequest.Count; i++ {
+				if err := rpc.StreamEvent(ctx, &streamEvent{Text: strings.Repeat("x", i+1)}); err != nil {
+					return nil, err
+				}
+			}
+			if serviceRequest.Wait {
+				<-ctx.Done()
+				canceled <- ctx.Err()
+				return nil, ctx.Err()
+			}
+			if serviceRequest.Fail {
+				return nil, errors.WithCode(errors.PermissionDenied("no more for you"), "NOPE")
+			}
+			return &streamEvent{Text: "done"}, nil
+		})
+	})
+}
+
+// Ensures that the gateway writes each event followed by the final "end" event, and that failures after the
+// stream has started are sent as an "error" event.
+func (suite *GatewaySuite) TestStreamEvents() {
+	gw := suite.newGateway()
+	gw.Register(watchEndpoint(&gw, nil))
+
+	suite.assertResponses(gw, []exchange{
+		{
+			name:   "events",
+			method: "GET",
+			path:   "/watch?Count=2",
+			status: 200,
+			response: "data: {\"Text\":\"x\"}\n\n" +
+				"data: {\"Text\":\"xx\"}\n\n" +
+				"event: end\ndata: {\"Text\":\"done\"}\n\n",
+			responseHeader: http.Header{"Content-Type": {"text/event-stream"}, "Cache-Control": {"no-cache"}},
+		},
+		{
+			name:   "error",
+			method: "GET",
+			path:   "/watch?Count=1&Fail=true",
+			status: 200,
+			response: "data: {\"Text\":\"x\"}\n\n" +
+				"event: error\ndata: {\"status\":403,\"message\":\"no more for you\",\"code\":\"NOPE\"}\n\n",
+		},
+	})
+}
+
+// Ensures that the client delivers each event to the stream handler and returns the final value.
+func (suite *GatewaySuite) TestStreamEvents_client() {
+	r := suite.Require()
+	gw := suite.newGateway()
+	gw.Register(watchEndpoint(&gw, nil))
+	server := httptest.NewServer(gw)
+	defer server.Close()
+
+	var events []string
+	ctx := rpc.WithStreamHandler(context.Background(), func(event interface{}) error {
+		events = append(events, event.(*streamEvent).Text)
+		return nil
+	})
+
+	client := rpc.NewClient("TestService", server.URL, rpc.WithTimeout(time.Millisecond))
+	response := &streamEvent{}
+	r.NoError(client.Stream(ctx, "GET", "/watch", &streamRequest{Count: 3}, response))
+	r.Equal([]string{"x", "xx", "xxx"}, events)
+	r.Equal("done", response.Text)
+
+	events = nil
+	response = &streamEvent{}
+	err := client.Stream(ctx, "GET", "/watch", &streamRequest{Count: 2, Fail: true}, response)
+	r.Error(err)
+	r.Equal(403, errors.Status(err))
+	r.Equal("NOPE", errors.Code(err))
+	r.Equal([]string{"x", "xx"}, events)
+
+	events = nil
+	response = &streamEvent{}
+	r.NoError(client.Stream(context.Background(), "GET", "/watch", &streamRequest{Count: 2}, response))
+	r.Nil(events, "Events should be ignored w/o a stream handler")
+	r.Equal("done", response.Text)
+}
+
+// Ensures that the service function's context is canceled when the caller stops listening.
+func (suite *GatewaySuite) TestStreamEvents_hangUp() {
+	r := suite.Require()
+	canceled := make(chan error, 1)
+	gw := suite.newGateway()
+	gw.Register(watchEndpoint(&gw, canceled))
+	server := httptest.NewServer(gw)
+	defer server.Close()
+
+	hangUp := errors.BadRequest("that's enough")
+	ctx := rpc.WithStreamHandler(context.Background(), func(event interface{}) error {
+		return hangUp
+	})
+
+	client := rpc.NewClient("TestService", server.URL)
+	err := client.Stream(ctx, "GET", "/watch", &streamRequest{Count: 5, Wait: true}, &streamEvent{})
+	r.Error(err)
+	r.Contains(err.Error(), "that's enough")
+
+	select {
+	case err := <-canceled:
+		r.Equal(context.Canceled, err)
+	case <-time.After(2 * time.Second):
+		r.Fail("Service function should be canceled when the caller hangs up")
+	}
+}
+
+// Ensures that the gateway closes open streams when the server shuts down.
+func (suite *GatewaySuite) TestStreamEvents_shutdown() {
+	r := suite.Require()
+	canceled := make(chan error, 1)
+	gw := suite.newGateway()
+	gw.Register(watchEndpoint(&gw, canceled))
+	server := httptest.NewServer(gw)
+	defer server.Close()
+
+	ctx, shutdown := context.WithCancel(context.Background())
+	for _, component := range gw.Components() {
+		go func(component rpc.Component) { _ = component.Run(ctx) }(component)
+	}
+
+	res, err := suite.HTTPClient.Get(server.URL + "/watch?Count=1&Wait=true")
+	r.NoError(err)
+	defer res.Body.Close()
+
+	shutdown()
+	body, err := ioutil.ReadAll(res.Body)
+	r.NoError(err, "Stream should end cleanly on shutdown")
+	r.Equal("data: {\"Text\":\"x\"}\n\n", string(body))
+
+	select {
+	case err := <-canceled:
+		r.Equal(context.Canceled, err)
+	case <-time.After(2 * time.Second):
+		r.Fail("Service function should be canceled when the server shuts down")
+	}
+}
+
+// Ensures that events go straight to the stream handler when you invoke the service w/o a gateway.
+func (suite *GatewaySuite) TestStreamEvent_noGateway() {
+	r := suite.Require()
+	r.NoError(rpc.StreamEvent(context.Background(), &streamEvent{Text: "x"}), "Events w/o a listener are ignored")
+
+	var events []interface{}
+	ctx := rpc.WithStreamHandler(context.Background(), func(event interface{}) error {
+		events = append(events, event)
+		return nil
+	})
+	event := &streamEvent{Text: "x"}
+	r.NoError(rpc.StreamEvent(ctx, event))
+	r.Equal([]interface{}{event}, events)
+}
@@ -0,0 +1,177 @@
+// Package tenant identifies which customer/organization each request is for in multi-tenant services. The
+// first gateway to see a request (the "edge") extracts the tenant id from a header, the subdomain, or a claim
+// in the caller's JWT. The tenant is stored in the request's metadata, so every downstream frodo service sees
+// the same tenant without having to re-derive it.
+//
+//     gateway := ordersrpc.NewOrderServiceGateway(service, rpc.WithMiddleware(
+//         tenant.Middleware(tenant.ByClaim("tid"), tenant.BySubdomain("example.com")),
+//     ))
+//
+//     // ... then in this service or any service it calls ...
+//     t, err := tenant.Require(ctx)
+//     if err != nil {
+//         return nil, err
+//     }
+//     orders := repo.FindOrders(t.ID, ...)
+//
+// The gateway also includes the tenant in the entries that it logs (see rpc.WithPayloadLogging).
+package tenant
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/monadicstack/frodo/rpc/authorization"
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/metadata"
+)
+
+// Header is the default HTTP header that callers use to tell us which tenant the request is for.
+const Header = "X-Tenant-ID"
+
+// MetadataKey is the metadata entry where we store the tenant so that it follows the request.
+const MetadataKey = "frodo.tenant"
+
+// maxLength is the longest tenant id we'll accept from the request.
+const maxLength = 128
+
+// Tenant is the customer/organization that the current request is for.
+type Tenant struct {
+	// ID uniquely identifies the tenant (e.g. "acme").
+	ID string
+}
+
+// String returns the tenant's id.
+func (t Tenant) String() string {
+	return t.ID
+}
+
+// Empty returns true when the request isn't for any particular tenant.
+func (t Tenant) Empty() bool {
+	return t.ID == ""
+}
+
+// Source extracts the tenant id from the incoming request. Return "" if the request doesn't say which
+// tenant it's for.
+type Source func(req *http.Request) string
+
+// ByHeader uses the value of the given HTTP header (e.g. "X-Tenant-ID") as the tenant id.
+func ByHeader(name string) Source {
+	return func(req *http.Request) string {
+		return req.Header.Get(name)
+	}
+}
+
+// BySubdomain uses the subdomain of the request's host as the tenant id. For instance, when the domain is
+// "example.com", a request for "acme.example.com" is for the tenant "acme". Requests for the domain
+// itself (or some other domain) don't have a tenant.
+func BySubdomain(domain string) Source {
+	suffix := "." + strings.ToLower(strings.Trim(domain, "."))
+	return func(req *http.Request) string {
+		host := strings.ToLower(req.Host)
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+		if !strings.HasSuffix(host, suffix) {
+			return ""
+		}
+		subdomain := strings.TrimSuffix(host, suffix)
+		if strings.Contains(subdomain, ".") {
+			return ""
+		}
+		return subdomain
+	}
+}
+
+// ByClaim uses the given claim (e.g. "tid") from the caller's "Authorization: Bearer" JWT as the tenant id.
+// This does NOT verify the token's signature; we assume that your auth middleware already did that and
+// rejected any requests w/ bad tokens.
+func ByClaim(name string) Source {
+	return func(req *http.Request) string {
+		segments := strings.Split(authorization.FromContext(req.Context()).Bearer(), ".")
+		if len(segments) != 3 {
+			return ""
+		}
+		payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segments[1], "="))
+		if err != nil {
+			return ""
+		}
+		claims := map[string]interface{}{}
+		if err = json.Unmarshal(payload, &claims); err != nil {
+			return ""
+		}
+		switch claim := claims[name].(type) {
+		case string:
+			return claim
+		case float64:
+			return fmt.Sprintf("%v", claim)
+		default:
+			return ""
+		}
+	}
+}
+
+// Middleware creates gateway middleware that puts the tenant on the request context. We use the first source
+// that has a valid tenant id, so list them in order of preference. When none of them do, we leave alone the
+// tenant that an upstream frodo service sent in the request metadata (if any). The sources win so that a
+// caller can't claim to be some other tenant by forging the metadata.
+//
+// This does NOT reject requests w/o a tenant since some of your functions probably don't need one. Use
+// Require() in the ones that do.
+func Middleware(sources ...Source) func(http.ResponseWriter, *http.Request, http.HandlerFunc) {
+	return func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		for _, source := range sources {
+			if id := source(req); valid(id) {
+				next(w, req.WithContext(WithTenant(req.Context(), Tenant{ID: id})))
+				return
+			}
+		}
+		next(w, req)
+	}
+}
+
+// FromContext returns the tenant that the current request is for. The tenant is empty when the request
+// isn't for any particular tenant.
+func FromContext(ctx context.Context) Tenant {
+	t := Tenant{}
+	metadata.Value(ctx, MetadataKey, &t)
+	return t
+}
+
+// Require returns the tenant that the current request is for. It fails w/ a 400 error when the request
+// isn't for any particular tenant, so you can return the error straight from your service function.
+func Require(ctx context.Context) (Tenant, error) {
+	t := FromContext(ctx)
+	if t.Empty() {
+		return t, errors.BadRequest("tenant is required")
+	}
+	return t, nil
+}
+
+// WithTenant forcibly sets the tenant for the current request and anything downstream of it. This is
+// handy for background jobs and tests that aren't handling a request.
+func WithTenant(ctx context.Context, t Tenant) context.Context {
+	if ctx == nil {
+		return ctx
+	}
+	return metadata.WithValue(ctx, MetadataKey, t)
+}
+
+// valid returns true when the tenant id is a reasonably sized value that contains printable, non-space
+// ASCII characters. Since the id ends up in our logs (and likely your queries), we don't accept anything else.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, ch := range id {
+		if ch <= ' ' || ch > '~' {
+			return false
+		}
+	}
+	return true
+}
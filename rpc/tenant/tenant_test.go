@@ -0,0 +1,147 @@
+// +build unit
+
+package tenant_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/monadicstack/frodo/rpc/authorization"
+	"github.com/monadicstack/frodo/rpc/errors"
+	"github.com/monadicstack/frodo/rpc/metadata"
+	"github.com/monadicstack/frodo/rpc/tenant"
+	"github.com/stretchr/testify/suite"
+)
+
+type TenantSuite struct {
+	suite.Suite
+}
+
+// Ensures that WithTenant/FromContext round trip through the context metadata.
+func (suite *TenantSuite) TestWithTenant() {
+	suite.Require().True(tenant.FromContext(context.Background()).Empty())
+	suite.Require().True(tenant.FromContext(nil).Empty())
+
+	ctx := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "acme"})
+	suite.Require().Equal("acme", tenant.FromContext(ctx).ID)
+	suite.Require().Equal("acme", tenant.FromContext(ctx).String())
+	suite.Require().Equal("initech", tenant.FromContext(tenant.WithTenant(ctx, tenant.Tenant{ID: "initech"})).ID)
+}
+
+// Ensures that the tenant survives the trip through the X-RPC-Values header to a downstream service.
+func (suite *TenantSuite) TestFromContext_metadataRoundTrip() {
+	encoded, err := metadata.ToJSON(tenant.WithTenant(context.Background(), tenant.Tenant{ID: "acme"}))
+	suite.Require().NoError(err)
+
+	values, err := metadata.FromJSON(encoded)
+	suite.Require().NoError(err)
+	suite.Require().Equal("acme", tenant.FromContext(metadata.WithValues(context.Background(), values)).ID)
+}
+
+// Ensures that Require() fails w/ a 400 when the request isn't for a tenant.
+func (suite *TenantSuite) TestRequire() {
+	_, err := tenant.Require(context.Background())
+	suite.Require().Error(err)
+	suite.Require().Equal(400, errors.Status(err))
+
+	t, err := tenant.Require(tenant.WithTenant(context.Background(), tenant.Tenant{ID: "acme"}))
+	suite.Require().NoError(err)
+	suite.Require().Equal("acme", t.ID)
+}
+
+// Ensures that we can pull the tenant id from a header.
+func (suite *TenantSuite) TestByHeader() {
+	source := tenant.ByHeader(tenant.Header)
+
+	req := httptest.NewRequest("GET", "/foo", nil)
+	suite.Require().Equal("", source(req))
+
+	req.Header.Set(tenant.Header, "acme")
+	suite.Require().Equal("acme", source(req))
+}
+
+// Ensures that we can pull the tenant id from the subdomain of the host.
+func (suite *TenantSuite) TestBySubdomain() {
+	source := tenant.BySubdomain("example.com")
+	hosts := map[string]string{
+		"acme.example.com":      "acme",
+		"ACME.Example.com:8080": "acme",
+		"example.com":           "",
+		"a.b.example.com":       "",
+		"acme.example.org":      "",
+		"acmeexample.com":       "",
+	}
+	for host, expected := range hosts {
+		req := httptest.NewRequest("GET", "/foo", nil)
+		req.Host = host
+		suite.Require().Equal(expected, source(req), "Wrong tenant for host %q", host)
+	}
+}
+
+// Ensures that we can pull the tenant id from a claim in the bearer token.
+func (suite *TenantSuite) TestByClaim() {
+	token := func(claims string) string {
+		return "e30." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".c2ln"
+	}
+	invoke := func(tid string, header authorization.Header) string {
+		req := httptest.NewRequest("GET", "/foo", nil)
+		req = req.WithContext(authorization.WithHeader(req.Context(), header))
+		return tenant.ByClaim(tid)(req)
+	}
+
+	suite.Require().Equal("acme", invoke("tid", authorization.NewBearer(token(`{"sub":"dude","tid":"acme"}`))))
+	suite.Require().Equal("42", invoke("tid", authorization.NewBearer(token(`{"tid":42}`))))
+	suite.Require().Equal("", invoke("org", authorization.NewBearer(token(`{"tid":"acme"}`))))
+	suite.Require().Equal("", invoke("tid", authorization.NewBearer(token(`nope`))))
+	suite.Require().Equal("", invoke("tid", authorization.NewBearer("not-a-jwt")))
+	suite.Require().Equal("", invoke("tid", authorization.NewBasic("dude", "abides")))
+	suite.Require().Equal("", invoke("tid", authorization.None))
+}
+
+// Ensures that the middleware uses the first source w/ a tenant, and falls back to the upstream metadata.
+func (suite *TenantSuite) TestMiddleware() {
+	middleware := tenant.Middleware(tenant.ByHeader("X-Org"), tenant.ByHeader(tenant.Header))
+
+	req := httptest.NewRequest("GET", "/foo", nil)
+	req.Header.Set(tenant.Header, "acme")
+	suite.Require().Equal("acme", suite.invoke(middleware, req))
+
+	req.Header.Set("X-Org", "initech")
+	suite.Require().Equal("initech", suite.invoke(middleware, req))
+
+	req = httptest.NewRequest("GET", "/foo", nil)
+	req = req.WithContext(tenant.WithTenant(req.Context(), tenant.Tenant{ID: "upstream"}))
+	suite.Require().Equal("upstream", suite.invoke(middleware, req))
+
+	req.Header.Set(tenant.Header, "acme")
+	suite.Require().Equal("acme", suite.invoke(middleware, req), "Sources should beat the metadata")
+
+	req = httptest.NewRequest("GET", "/foo", nil)
+	suite.Require().Equal("", suite.invoke(middleware, req))
+}
+
+// Ensures that the middleware ignores tenant ids that we don't want to dump into our logs.
+func (suite *TenantSuite) TestMiddleware_invalid() {
+	middleware := tenant.Middleware(tenant.ByHeader(tenant.Header))
+	for _, invalid := range []string{"has space", "tab\tbed", strings.Repeat("x", 129), "ünicode"} {
+		req := httptest.NewRequest("GET", "/foo", nil)
+		req.Header.Set(tenant.Header, invalid)
+		suite.Require().Equal("", suite.invoke(middleware, req), "Should not accept tenant %q", invalid)
+	}
+}
+
+func (suite *TenantSuite) invoke(middleware func(http.ResponseWriter, *http.Request, http.HandlerFunc), req *http.Request) string {
+	id := ""
+	middleware(httptest.NewRecorder(), req, func(w http.ResponseWriter, req *http.Request) {
+		id = tenant.FromContext(req.Context()).ID
+	})
+	return id
+}
+
+func TestTenantSuite(t *testing.T) {
+	suite.Run(t, new(TenantSuite))
+}
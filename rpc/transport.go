@@ -0,0 +1,143 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc/calls"
+)
+
+// newTransport creates the default transport for clients. It's similar to http.DefaultTransport, but since a
+// client only ever talks to one service, we keep a lot more idle connections around for that host. The standard
+// library only keeps 2, so under heavy load you churn through connections (and ephemeral ports) like crazy.
+func newTransport(dialer *net.Dialer) *http.Transport {
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   dialer.Timeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// WithTimeout changes how long the client waits for a call to finish when the context you pass to the service
+// function doesn't already have a deadline (default is 30 seconds). To give one specific call more or less time
+// than that, just use a context w/ a deadline:
+//
+//     // This report takes forever, so give it a few minutes.
+//     ctx, cancel := context.WithTimeout(ctx, 3*time.Minute)
+//     defer cancel()
+//     report, err := client.GenerateReport(ctx, &reports.GenerateReportRequest{})
+//
+// A timeout of 0 means that calls w/o a deadline wait forever.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(rpcClient *Client) {
+		rpcClient.Timeout = timeout
+	}
+}
+
+// WithMaxIdleConnsPerHost changes how many idle (keep-alive) connections the client keeps open to the service
+// so that they can be reused by later calls (default is 100). If you're making a ton of concurrent calls, raise
+// this so that you're not constantly opening new connections. This only applies to the default transport (or
+// your own *http.Transport if you supplied one using WithHTTPClient before this option).
+func WithMaxIdleConnsPerHost(max int) ClientOption {
+	return func(rpcClient *Client) {
+		if transport := rpcClient.transport(); transport != nil {
+			transport.MaxIdleConnsPerHost = max
+			if transport.MaxIdleConns != 0 && transport.MaxIdleConns < max {
+				transport.MaxIdleConns = max
+			}
+		}
+	}
+}
+
+// WithMaxConnsPerHost limits the total number of connections (active and idle) to the service. Once you hit
+// the limit, calls wait for a connection to become available. The default of 0 means there's no limit.
+func WithMaxConnsPerHost(max int) ClientOption {
+	return func(rpcClient *Client) {
+		if transport := rpcClient.transport(); transport != nil {
+			transport.MaxConnsPerHost = max
+		}
+	}
+}
+
+// WithKeepAlive changes how long idle connections stay in the pool before being closed (default is 90 seconds).
+// Use a value <= 0 to disable keep-alive entirely, so every call uses a brand new connection.
+func WithKeepAlive(idleTimeout time.Duration) ClientOption {
+	return func(rpcClient *Client) {
+		if transport := rpcClient.transport(); transport != nil {
+			transport.DisableKeepAlives = idleTimeout <= 0
+			transport.IdleConnTimeout = idleTimeout
+		}
+	}
+}
+
+// WithHTTP2 enables/disables HTTP/2 for services that you talk to over TLS (it's enabled by default). HTTP/2
+// multiplexes concurrent calls over a single connection, which can drastically cut down on the number
+// of connections you need.
+func WithHTTP2(enabled bool) ClientOption {
+	return func(rpcClient *Client) {
+		if transport := rpcClient.transport(); transport != nil {
+			transport.ForceAttemptHTTP2 = enabled
+		}
+	}
+}
+
+// WithDialContext changes how the client opens connections to the service. Use this to talk to a gateway served
+// by a MemoryListener (see NewMemoryListener) or over some other exotic type of connection. Since you're deciding
+// exactly where each connection goes, the client no longer uses the HTTP_PROXY/HTTPS_PROXY environment variables.
+// This only applies to the default transport (or your own *http.Transport if you supplied one using WithHTTPClient
+// before this option).
+func WithDialContext(dial func(ctx context.Context, network string, addr string) (net.Conn, error)) ClientOption {
+	return func(rpcClient *Client) {
+		if transport := rpcClient.transport(); transport != nil {
+			transport.DialContext = dial
+			transport.Proxy = nil
+		}
+	}
+}
+
+// transport returns the client's underlying *http.Transport so that options can tune it. This is nil when
+// you've supplied your own HTTP client w/ some other type of http.RoundTripper.
+func (c *Client) transport() *http.Transport {
+	if c.HTTP == nil {
+		return nil
+	}
+	if c.HTTP.Transport == nil {
+		c.HTTP.Transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	transport, _ := c.HTTP.Transport.(*http.Transport)
+	return transport
+}
+
+// callContext applies the client's default timeout to the call if the context doesn't already have a deadline. Event
+// streams (see Client.Stream) are meant to stay open, so they never get the default timeout. A per-call timeout
+// (see calls.WithTimeout) always applies, but it can only shorten the context's deadline.
+func (c Client) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := calls.FromContext(ctx).Timeout; timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	if _, ok := ctx.Deadline(); ok || c.Timeout <= 0 || isStreamCall(ctx) {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.Timeout)
+}
+
+// cancelOnClose releases the call's context once you're done reading the response body. We can't just cancel
+// it when Invoke() returns because raw file responses are read after that.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (body cancelOnClose) Close() error {
+	err := body.ReadCloser.Close()
+	body.cancel()
+	return err
+}
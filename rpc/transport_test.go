@@ -0,0 +1,156 @@
+// +build unit
+
+package rpc_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/monadicstack/frodo/rpc"
+)
+
+type transportFile struct {
+	content io.ReadCloser
+}
+
+func (f *transportFile) SetContent(content io.ReadCloser) {
+	f.content = content
+}
+
+// newSleepServer creates a server that waits for the "Sleep" query param's duration before responding.
+func newSleepServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		sleep, _ := time.ParseDuration(req.URL.Query().Get("Sleep"))
+		select {
+		case <-time.After(sleep):
+		case <-req.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Text":"done"}`))
+	}))
+}
+
+// Ensures that the default client has a connection pool tuned for talking to a single service.
+func (suite *ClientSuite) TestNewClient_defaultTransport() {
+	r := suite.Require()
+	client := rpc.NewClient("FooService", ":9000")
+
+	transport, ok := client.HTTP.Transport.(*http.Transport)
+	r.True(ok, "Default client should use an *http.Transport")
+	r.Equal(100, transport.MaxIdleConnsPerHost)
+	r.Equal(0, transport.MaxConnsPerHost)
+	r.Equal(90*time.Second, transport.IdleConnTimeout)
+	r.False(transport.DisableKeepAlives)
+	r.True(transport.ForceAttemptHTTP2)
+	r.Equal(time.Duration(0), client.HTTP.Timeout, "Timeouts should be per-call, not for the whole HTTP client")
+}
+
+// Ensures that the transport options tune the client's connection pool.
+func (suite *ClientSuite) TestNewClient_transportOptions() {
+	r := suite.Require()
+	client := rpc.NewClient("FooService", ":9000",
+		rpc.WithMaxIdleConnsPerHost(500),
+		rpc.WithMaxConnsPerHost(1000),
+		rpc.WithKeepAlive(5*time.Minute),
+		rpc.WithHTTP2(false),
+		rpc.WithTimeout(time.Minute),
+	)
+
+	transport := client.HTTP.Transport.(*http.Transport)
+	r.Equal(500, transport.MaxIdleConnsPerHost)
+	r.Equal(500, transport.MaxIdleConns, "Total idle connections should be at least the per-host limit")
+	r.Equal(1000, transport.MaxConnsPerHost)
+	r.Equal(5*time.Minute, transport.IdleConnTimeout)
+	r.False(transport.DisableKeepAlives)
+	r.False(transport.ForceAttemptHTTP2)
+	r.Equal(time.Minute, client.Timeout)
+
+	client = rpc.NewClient("FooService", ":9000", rpc.WithKeepAlive(0))
+	r.True(client.HTTP.Transport.(*http.Transport).DisableKeepAlives)
+}
+
+// Ensures that transport options tune your own HTTP client's transport, too, when it's an *http.Transport.
+func (suite *ClientSuite) TestNewClient_customHTTPClient() {
+	r := suite.Require()
+
+	httpClient := &http.Client{}
+	client := rpc.NewClient("FooService", ":9000",
+		rpc.WithHTTPClient(httpClient),
+		rpc.WithMaxIdleConnsPerHost(50),
+	)
+	r.Same(httpClient, client.HTTP)
+	r.Equal(50, httpClient.Transport.(*http.Transport).MaxIdleConnsPerHost)
+	r.NotSame(http.DefaultTransport, httpClient.Transport, "Should never modify the global default transport")
+
+	roundTripper := rpc.RoundTripperFunc(func(r *http.Request) (*http.Response, error) { return nil, nil })
+	httpClient = &http.Client{Transport: roundTripper}
+	r.NotPanics(func() {
+		rpc.NewClient("FooService", ":9000",
+			rpc.WithHTTPClient(httpClient),
+			rpc.WithMaxIdleConnsPerHost(50),
+			rpc.WithHTTP2(false),
+		)
+	}, "Options should ignore transports that aren't an *http.Transport")
+}
+
+// Ensures that calls w/o a deadline use the client's default timeout, and that the context's deadline wins over
+// the default, so individual calls can have more (or less) time than the default.
+func (suite *ClientSuite) TestInvoke_timeout() {
+	server := newSleepServer()
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		deadline time.Duration
+		sleep    string
+		ok       bool
+	}{
+		{name: "default timeout", timeout: 50 * time.Millisecond, sleep: "5ms", ok: true},
+		{name: "call should time out w/ the client's default", timeout: 50 * time.Millisecond, sleep: "1s"},
+		{name: "call should get the context's longer deadline", timeout: 20 * time.Millisecond, deadline: 2 * time.Second, sleep: "100ms", ok: true},
+		{name: "call should get the context's shorter deadline", deadline: 20 * time.Millisecond, sleep: "1s"},
+	}
+	for _, test := range tests {
+		r := suite.Require()
+		client := rpc.NewClient("FooService", server.URL, rpc.WithTimeout(test.timeout))
+
+		ctx, cancel := context.Background(), context.CancelFunc(func() {})
+		if test.deadline > 0 {
+			ctx, cancel = context.WithTimeout(ctx, test.deadline)
+		}
+		response := struct{ Text string }{}
+		err := client.Invoke(ctx, "GET", "/slow", &struct{ Sleep string }{Sleep: test.sleep}, &response)
+		cancel()
+
+		if !test.ok {
+			r.Error(err, test.name)
+			continue
+		}
+		r.NoError(err, test.name)
+		r.Equal("done", response.Text, test.name)
+	}
+}
+
+// Ensures that the timeout doesn't cancel raw responses that you read after the call returns.
+func (suite *ClientSuite) TestInvoke_rawResponse() {
+	r := suite.Require()
+	server := newSleepServer()
+	defer server.Close()
+
+	client := rpc.NewClient("FooService", server.URL, rpc.WithTimeout(time.Second))
+	response := transportFile{}
+	err := client.Invoke(context.Background(), "GET", "/file", &struct{}{}, &response)
+	r.NoError(err)
+
+	data, err := ioutil.ReadAll(response.content)
+	r.NoError(err)
+	r.NoError(response.content.Close())
+	r.True(strings.Contains(string(data), "done"))
+}
@@ -0,0 +1,149 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dimfeld/httptreemux/v5"
+	"github.com/monadicstack/frodo/rpc/errors"
+)
+
+// VersionHeader is the request header that callers can use to pick the version of the service they want when
+// they call a versioned gateway (see ComposeVersions) w/o the version in the path.
+const VersionHeader = "Accept-Version"
+
+// ContentVersionHeader is the response header where a versioned gateway tells the caller which version of
+// the service actually handled the request.
+const ContentVersionHeader = "Content-Version"
+
+// WithVersion makes the client call a specific version of the service when the gateway is serving multiple
+// versions at once (see ComposeVersions). The version is prepended to every path, so it sits in front of
+// the service's own PATH prefix (e.g. "/v2/NameService.Split" or "/v2/api/users/:id").
+func WithVersion(version string) ClientOption {
+	return func(rpcClient *Client) {
+		rpcClient.Version = version
+	}
+}
+
+// ComposeVersions serves multiple versions of the same service (or different services) in a single gateway,
+// keyed by version name:
+//
+//     gateway := rpc.ComposeVersions(map[string]rpc.Gateway{
+//         "v1": usersv1rpc.NewUserServiceGateway(v1Service),
+//         "v2": usersv2rpc.NewUserServiceGateway(v2Service),
+//     })
+//     http.ListenAndServe(":8080", gateway)
+//
+// Every endpoint is mounted under its version prefix (e.g. "/v1/UserService.GetUser" and
+// "/v2/UserService.GetUser"), which is what clients using WithVersion() will call. Every endpoint is also
+// available w/o the prefix; those requests go to the version in the "Accept-Version" header or the latest
+// version (e.g. "v10" is later than "v9") if the caller doesn't ask for one. Either way, the response's
+// "Content-Version" header indicates which version handled the call, and EndpointFromContext() includes
+// the Version, so your handlers/middleware know, too.
+func ComposeVersions(versions map[string]Gateway) CompositeGateway {
+	router := httptreemux.New()
+	result := CompositeGateway{
+		Name:        "Composite",
+		Router:      router,
+		routerGroup: router.UsingContext(),
+		endpoints:   map[route]Endpoint{},
+	}
+
+	// Visit the versions in order so that the name/gateways don't change every time you start up.
+	versionNames := make([]string, 0, len(versions))
+	for version := range versions {
+		versionNames = append(versionNames, version)
+	}
+	sort.Slice(versionNames, func(i, j int) bool {
+		return compareVersions(versionNames[i], versionNames[j]) < 0
+	})
+
+	unversioned := map[route]map[string]http.HandlerFunc{}
+	for _, version := range versionNames {
+		gw := versions[version]
+		result.Name = result.Name + ":" + gw.Name + "@" + version
+		result.Gateways = append(result.Gateways, gw)
+
+		for r, endpoint := range gw.endpoints.snapshot() {
+			endpoint.Version = version
+			handler := composeVersionHandler(gw, endpoint)
+
+			versioned := route{method: r.method, path: toEndpointPath(version, r.path)}
+			result.routerGroup.Handler(versioned.method, versioned.path, handler)
+			result.endpoints[versioned] = endpoint
+
+			if unversioned[r] == nil {
+				unversioned[r] = map[string]http.HandlerFunc{}
+			}
+			unversioned[r][version] = handler
+		}
+	}
+
+	latest := ""
+	if len(versionNames) > 0 {
+		latest = versionNames[len(versionNames)-1]
+	}
+	for r, handlers := range unversioned {
+		result.routerGroup.Handler(r.method, r.path, negotiateVersion(handlers, versions, latest))
+	}
+	return result
+}
+
+// composeVersionHandler is just like composeHandler, but the endpoint is on the context before the original
+// gateway's middleware runs. The router's path includes the version prefix, so the gateway wouldn't be able
+// to look up the endpoint on its own.
+func composeVersionHandler(gw Gateway, endpoint Endpoint) http.HandlerFunc {
+	handler := gw.pipeline(endpoint).Then(endpoint.Handler)
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := context.WithValue(req.Context(), contextKeyGateway{}, &gw)
+		ctx = context.WithValue(ctx, contextKeyEndpoint{}, endpoint)
+		w.Header().Set(ContentVersionHeader, endpoint.Version)
+		handler(w, req.WithContext(ctx))
+	}
+}
+
+// negotiateVersion picks the handler for the version in the "Accept-Version" header (or the latest version) for
+// requests that don't include the version in the path.
+func negotiateVersion(handlers map[string]http.HandlerFunc, versions map[string]Gateway, latest string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		version := strings.TrimSpace(req.Header.Get(VersionHeader))
+		if version == "" {
+			version = latest
+		}
+		if _, ok := versions[version]; !ok {
+			Fail(w, req, errors.BadRequest("unsupported version: %s", version))
+			return
+		}
+		handler, ok := handlers[version]
+		if !ok {
+			Fail(w, req, errors.NotFound("%s %s is not available in version %s", req.Method, req.URL.Path, version))
+			return
+		}
+		handler(w, req)
+	}
+}
+
+// compareVersions orders version names like "v1", "v2", and "v10" (or "v1.2") numerically, falling back to
+// plain string comparison for anything that doesn't look like a number.
+func compareVersions(a string, b string) int {
+	aSegments := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bSegments := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(aSegments) && i < len(bSegments); i++ {
+		aNumber, aErr := strconv.Atoi(aSegments[i])
+		bNumber, bErr := strconv.Atoi(bSegments[i])
+		switch {
+		case aErr != nil || bErr != nil:
+			if cmp := strings.Compare(aSegments[i], bSegments[i]); cmp != 0 {
+				return cmp
+			}
+		case aNumber < bNumber:
+			return -1
+		case aNumber > bNumber:
+			return 1
+		}
+	}
+	return len(aSegments) - len(bSegments)
+}